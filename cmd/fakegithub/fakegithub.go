@@ -0,0 +1,95 @@
+// Command fakegithub runs a GitHub compatible API server backed by local bare git repositories so that commands such
+// as jx import, jx promote and jx step pr can be tested end to end without network access.
+//
+// The REST API is served below /api/v3 like GitHub Enterprise and the repositories are served over HTTP at
+// /owner/name.git. Requests must authenticate as the user with the token unless the token is blank.
+// The server runs until it is interrupted:
+//
+//	go run ./cmd/fakegithub --url-file fake-github-url.txt --repo myorg/environment-staging
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/jenkins-x/jx/pkg/gits/fakegithub"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// repositories are the repeated --repo flags
+type repositories []string
+
+func (r *repositories) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *repositories) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+func main() {
+	var repos repositories
+	dir := flag.String("dir", "", "The directory to create the git repositories in. Defaults to a temporary directory")
+	address := flag.String("address", "127.0.0.1:0", "The address to listen on. Port 0 picks a free port")
+	username := flag.String("user", "jenkins-x-bot", "The login of the authenticated user")
+	token := flag.String("token", "", "The API token requests must authenticate with. If blank requests are not authenticated")
+	urlFile := flag.String("url-file", "", "The file to write the URL of the server to once it is listening")
+	flag.Var(&repos, "repo", "The repositories to create as owner/name")
+	flag.Parse()
+
+	err := run(*dir, *address, *username, *token, repos, *urlFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, address string, username string, token string, repos []string, urlFile string) error {
+	if dir == "" {
+		var err error
+		dir, err = ioutil.TempDir("", "fake-github-")
+		if err != nil {
+			return errors.Wrap(err, "creating a temporary directory for the git repositories")
+		}
+		defer os.RemoveAll(dir)
+	}
+	server := fakegithub.NewServer(dir, username, token)
+	err := server.Start(address)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	for _, fullName := range repos {
+		paths := strings.Split(fullName, "/")
+		if len(paths) != 2 || paths[0] == "" || paths[1] == "" {
+			return fmt.Errorf("invalid repository %s which should be owner/name", fullName)
+		}
+		repo, err := server.CreateRepository(paths[0], paths[1])
+		if err != nil {
+			return err
+		}
+		log.Infof("Created the repository %s\n", util.ColorInfo(repo.CloneURL))
+	}
+	if urlFile != "" {
+		err = ioutil.WriteFile(urlFile, []byte(server.URL), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "writing the URL of the server to %s", urlFile)
+		}
+	}
+	log.Infof("Serving the GitHub API at %s for the user %s with the repositories in %s\n", util.ColorInfo(server.URL+fakegithub.APIPath), util.ColorInfo(username), util.ColorInfo(dir))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	log.Infof("Stopping the server\n")
+	return nil
+}
@@ -82,6 +82,43 @@ type StageActivityStep struct {
 	CoreActivityStep `json:",inline"`
 
 	Steps []CoreActivityStep `json:"steps,omitempty" protobuf:"bytes,1,opt,name=steps"`
+	// Approval is set if the stage waits for a manual approval
+	Approval *ApprovalActivityStep `json:"approval,omitempty" protobuf:"bytes,2,opt,name=approval"`
+}
+
+// ApprovalActivityStep is a manual approval which a stage of a pipeline waits for before the pipeline continues
+type ApprovalActivityStep struct {
+	Message string `json:"message,omitempty" protobuf:"bytes,1,opt,name=message"`
+	// Environment is the environment whose EnvironmentRoleBindings determine who can approve
+	Environment string `json:"environment,omitempty" protobuf:"bytes,2,opt,name=environment"`
+	// Roles if specified restricts the approvers to users bound to one of these roles
+	Roles []string `json:"roles,omitempty" protobuf:"bytes,3,opt,name=roles"`
+	// DefaultOutcome is the outcome used if nobody approves or rejects before the deadline
+	DefaultOutcome     ApprovalOutcomeType `json:"defaultOutcome,omitempty" protobuf:"bytes,4,opt,name=defaultOutcome"`
+	RequestedTimestamp *metav1.Time        `json:"requestedTimestamp,omitempty" protobuf:"bytes,5,opt,name=requestedTimestamp"`
+	DeadlineTimestamp  *metav1.Time        `json:"deadlineTimestamp,omitempty" protobuf:"bytes,6,opt,name=deadlineTimestamp"`
+	Outcome            ApprovalOutcomeType `json:"outcome,omitempty" protobuf:"bytes,7,opt,name=outcome"`
+	// DecidedBy is the user who approved or rejected, empty if the default outcome was used
+	DecidedBy        string       `json:"decidedBy,omitempty" protobuf:"bytes,8,opt,name=decidedBy"`
+	DecidedTimestamp *metav1.Time `json:"decidedTimestamp,omitempty" protobuf:"bytes,9,opt,name=decidedTimestamp"`
+	Comment          string       `json:"comment,omitempty" protobuf:"bytes,10,opt,name=comment"`
+}
+
+// ApprovalOutcomeType is the outcome of a manual approval
+type ApprovalOutcomeType string
+
+const (
+	// ApprovalOutcomeTypeNone the approval has not been decided yet
+	ApprovalOutcomeTypeNone ApprovalOutcomeType = ""
+	// ApprovalOutcomeTypeApproved the pipeline continues
+	ApprovalOutcomeTypeApproved ApprovalOutcomeType = "Approved"
+	// ApprovalOutcomeTypeRejected the stage fails so the pipeline stops
+	ApprovalOutcomeTypeRejected ApprovalOutcomeType = "Rejected"
+)
+
+// IsPending returns true if the approval has not been decided yet
+func (a *ApprovalActivityStep) IsPending() bool {
+	return a.Outcome == ApprovalOutcomeTypeNone
 }
 
 // PreviewActivityStep is the step of creating a preview environment as part of a Pull Request pipeline
@@ -176,6 +213,8 @@ const (
 	ActivityStatusTypeAborted ActivityStatusType = "Aborted"
 	// ActivityStatusTypeNotExecuted if the workflow was not executed
 	ActivityStatusTypeNotExecuted ActivityStatusType = "NotExecuted"
+	// ActivityStatusTypeCancelled if the pipeline was cancelled, such as when a newer commit superseded it
+	ActivityStatusTypeCancelled ActivityStatusType = "Cancelled"
 )
 
 type Attachment struct {
@@ -185,7 +224,7 @@ type Attachment struct {
 
 // IsTerminated returns true if this activity has stopped executing
 func (s ActivityStatusType) IsTerminated() bool {
-	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted
+	return s == ActivityStatusTypeSucceeded || s == ActivityStatusTypeFailed || s == ActivityStatusTypeError || s == ActivityStatusTypeAborted || s == ActivityStatusTypeCancelled
 }
 
 func (s ActivityStatusType) String() string {
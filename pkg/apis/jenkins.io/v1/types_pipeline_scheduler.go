@@ -63,6 +63,7 @@ type SchedulerSpec struct {
 	// Plugins is a list of plugin names enabled for a repo
 	Plugins       *ReplaceableSliceOfStrings `json:"plugins,omitempty" protobuf:"bytes,10,opt,name=plugins"`
 	ConfigUpdater *ConfigUpdater             `json:"configUpdater,omitempty" protobuf:"bytes,11,opt,name=configUpdater"`
+	Periodics     *Periodics                 `json:"periodics,omitempty" protobuf:"bytes,12,opt,name=periodics"`
 }
 
 // ConfigMapSpec contains configuration options for the configMap being updated
@@ -199,6 +200,28 @@ type Postsubmit struct {
 	Report *bool `json:"report,omitempty" protobuf:"bytes,2,opt,name=report"`
 }
 
+// Periodics is a list of jobs to be run periodically
+type Periodics struct {
+	// Items are the periodic job configurations
+	Items []*Periodic `json:"entries,omitempty" protobuf:"bytes,1,opt,name=entries"`
+	// Replace the existing entries
+	Replace bool `json:"replace,omitempty" protobuf:"bytes,2,opt,name=replace"`
+}
+
+// Periodic runs a pipeline on a schedule
+type Periodic struct {
+	// +optional
+	*JobBase
+	// Cron is the schedule of the job in cron format, e.g. '0 2 * * *' or '@daily'
+	Cron *string `json:"cron" protobuf:"bytes,1,opt,name=cron"`
+	// Branch is the branch the pipeline builds. (Default: master)
+	Branch *string `json:"branch,omitempty" protobuf:"bytes,2,opt,name=branch"`
+	// Context is the pipeline context of the job. (Default: the job name)
+	Context *string `json:"context,omitempty" protobuf:"bytes,3,opt,name=context"`
+	// Parameters are passed to the pipeline as environment variables
+	Parameters *ReplaceableMapOfStringString `json:"parameters,omitempty" protobuf:"bytes,4,opt,name=parameters"`
+}
+
 // JobBase contains attributes common to all job types
 type JobBase struct {
 	// The name of the job. Must match regex [A-Za-z0-9-._]+
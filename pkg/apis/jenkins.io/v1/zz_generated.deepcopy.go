@@ -115,6 +115,39 @@ func (in *AppSpec) DeepCopy() *AppSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalActivityStep) DeepCopyInto(out *ApprovalActivityStep) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestedTimestamp != nil {
+		in, out := &in.RequestedTimestamp, &out.RequestedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.DeadlineTimestamp != nil {
+		in, out := &in.DeadlineTimestamp, &out.DeadlineTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.DecidedTimestamp != nil {
+		in, out := &in.DecidedTimestamp, &out.DecidedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalActivityStep.
+func (in *ApprovalActivityStep) DeepCopy() *ApprovalActivityStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalActivityStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Approve) DeepCopyInto(out *Approve) {
 	*out = *in
@@ -1740,6 +1773,74 @@ func (in *Original) DeepCopy() *Original {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Periodic) DeepCopyInto(out *Periodic) {
+	*out = *in
+	if in.JobBase != nil {
+		in, out := &in.JobBase, &out.JobBase
+		*out = new(JobBase)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cron != nil {
+		in, out := &in.Cron, &out.Cron
+		*out = new(string)
+		**out = **in
+	}
+	if in.Branch != nil {
+		in, out := &in.Branch, &out.Branch
+		*out = new(string)
+		**out = **in
+	}
+	if in.Context != nil {
+		in, out := &in.Context, &out.Context
+		*out = new(string)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = new(ReplaceableMapOfStringString)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Periodic.
+func (in *Periodic) DeepCopy() *Periodic {
+	if in == nil {
+		return nil
+	}
+	out := new(Periodic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Periodics) DeepCopyInto(out *Periodics) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]*Periodic, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Periodic)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Periodics.
+func (in *Periodics) DeepCopy() *Periodics {
+	if in == nil {
+		return nil
+	}
+	out := new(Periodics)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineActivity) DeepCopyInto(out *PipelineActivity) {
 	*out = *in
@@ -3053,6 +3154,11 @@ func (in *SchedulerSpec) DeepCopyInto(out *SchedulerSpec) {
 		*out = new(ConfigUpdater)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Periodics != nil {
+		in, out := &in.Periodics, &out.Periodics
+		*out = new(Periodics)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -3238,6 +3344,11 @@ func (in *StageActivityStep) DeepCopyInto(out *StageActivityStep) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ApprovalActivityStep)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -0,0 +1,260 @@
+package builds
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+)
+
+// BuildTimesOptions configures how the durations of pipeline runs are aggregated
+type BuildTimesOptions struct {
+	// Runs is how many of the most recent completed runs are analysed
+	Runs int
+	// BaselineRuns is how many of the runs before the recent runs are used as the baseline for trends and regressions
+	BaselineRuns int
+	// IncludeFailed includes the runs which did not succeed
+	IncludeFailed bool
+	// RegressionThreshold is the fraction by which the recent p50 has to exceed the baseline p50 to be a regression
+	RegressionThreshold float64
+	// MinRegression is the smallest increase of the p50 which is reported as a regression
+	MinRegression time.Duration
+	// Slowest is how many of the slowest steps are reported
+	Slowest int
+}
+
+// BuildTimes is the duration of a pipeline, stage or step aggregated over a number of runs
+type BuildTimes struct {
+	Name  string   `json:"name"`
+	Stage string   `json:"stage,omitempty"`
+	Step  string   `json:"step,omitempty"`
+	Runs  int      `json:"runs"`
+	P50   Duration `json:"p50"`
+	P90   Duration `json:"p90"`
+	Max   Duration `json:"max"`
+	// BaselineRuns is zero if there are no baseline runs to compare with
+	BaselineRuns int      `json:"baselineRuns,omitempty"`
+	BaselineP50  Duration `json:"baselineP50,omitempty"`
+	// Trend is the change of the p50 relative to the baseline, such as 0.2 for 20% slower
+	Trend      float64 `json:"trend"`
+	Regression bool    `json:"regression,omitempty"`
+	// Durations are the durations of the recent runs, oldest first
+	Durations []Duration `json:"durations"`
+}
+
+// BuildTimesReport contains the build times of a pipeline and its stages and steps
+type BuildTimesReport struct {
+	Pipeline    string        `json:"pipeline"`
+	Builds      []string      `json:"builds"`
+	Total       *BuildTimes   `json:"total"`
+	Stages      []*BuildTimes `json:"stages"`
+	Steps       []*BuildTimes `json:"steps"`
+	Slowest     []*BuildTimes `json:"slowest"`
+	Regressions []*BuildTimes `json:"regressions"`
+}
+
+// Duration is a time.Duration which is marshalled as a string such as "1m30s"
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// String returns the duration rounded to a second
+func (d Duration) String() string {
+	return time.Duration(d).Round(time.Second).String()
+}
+
+// timingKey identifies a stage or a step of a stage
+type timingKey struct {
+	stage string
+	step  string
+}
+
+// timings holds the durations of the pipeline, stages and steps of a window of runs
+type timings struct {
+	keys      []timingKey
+	durations map[timingKey][]time.Duration
+}
+
+func (t *timings) add(key timingKey, step *v1.CoreActivityStep) {
+	if step.StartedTimestamp == nil || step.CompletedTimestamp == nil {
+		return
+	}
+	d := step.CompletedTimestamp.Sub(step.StartedTimestamp.Time)
+	if d < 0 {
+		return
+	}
+	if _, ok := t.durations[key]; !ok {
+		t.keys = append(t.keys, key)
+	}
+	t.durations[key] = append(t.durations[key], d)
+}
+
+func collectTimings(activities []v1.PipelineActivity) *timings {
+	t := &timings{durations: map[timingKey][]time.Duration{}}
+	for i := range activities {
+		spec := &activities[i].Spec
+		t.add(timingKey{}, &v1.CoreActivityStep{StartedTimestamp: spec.StartedTimestamp, CompletedTimestamp: spec.CompletedTimestamp})
+		for _, s := range spec.Steps {
+			stage := s.Stage
+			if stage == nil {
+				continue
+			}
+			t.add(timingKey{stage: stage.Name}, &stage.CoreActivityStep)
+			for j := range stage.Steps {
+				t.add(timingKey{stage: stage.Name, step: stage.Steps[j].Name}, &stage.Steps[j])
+			}
+		}
+	}
+	return t
+}
+
+// AnalyzeBuildTimes aggregates the durations of the completed runs of a pipeline. The most recent runs are compared
+// with the runs before them to find trends and regressions.
+func AnalyzeBuildTimes(pipeline string, activities []v1.PipelineActivity, o BuildTimesOptions) *BuildTimesReport {
+	var runs []v1.PipelineActivity
+	for _, a := range activities {
+		if a.Spec.Pipeline != pipeline || a.Spec.CompletedTimestamp == nil {
+			continue
+		}
+		if !o.IncludeFailed && a.Spec.Status != v1.ActivityStatusTypeSucceeded {
+			continue
+		}
+		runs = append(runs, a)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return activityBuildNumber(&runs[i]) < activityBuildNumber(&runs[j])
+	})
+
+	recentStart := len(runs) - o.Runs
+	if o.Runs <= 0 || recentStart < 0 {
+		recentStart = 0
+	}
+	baselineStart := recentStart - o.BaselineRuns
+	if baselineStart < 0 {
+		baselineStart = 0
+	}
+	recent := collectTimings(runs[recentStart:])
+	baseline := collectTimings(runs[baselineStart:recentStart])
+
+	report := &BuildTimesReport{
+		Pipeline: pipeline,
+	}
+	for i := recentStart; i < len(runs); i++ {
+		report.Builds = append(report.Builds, runs[i].Spec.Build)
+	}
+	for _, key := range recent.keys {
+		bt := newBuildTimes(key, recent.durations[key], baseline.durations[key], o)
+		switch {
+		case key.stage == "":
+			bt.Name = pipeline
+			report.Total = bt
+		case key.step == "":
+			report.Stages = append(report.Stages, bt)
+		default:
+			report.Steps = append(report.Steps, bt)
+		}
+		if bt.Regression {
+			report.Regressions = append(report.Regressions, bt)
+		}
+	}
+
+	slowest := append([]*BuildTimes{}, report.Steps...)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].P50 > slowest[j].P50
+	})
+	if o.Slowest >= 0 && len(slowest) > o.Slowest {
+		slowest = slowest[:o.Slowest]
+	}
+	report.Slowest = slowest
+	return report
+}
+
+func newBuildTimes(key timingKey, durations []time.Duration, baseline []time.Duration, o BuildTimesOptions) *BuildTimes {
+	name := key.stage
+	if key.step != "" {
+		name += " / " + key.step
+	}
+	bt := &BuildTimes{
+		Name:  name,
+		Stage: key.stage,
+		Step:  key.step,
+		Runs:  len(durations),
+		P50:   Duration(Percentile(durations, 50)),
+		P90:   Duration(Percentile(durations, 90)),
+		Max:   Duration(Percentile(durations, 100)),
+	}
+	for _, d := range durations {
+		bt.Durations = append(bt.Durations, Duration(d))
+	}
+	if len(baseline) > 0 {
+		bt.BaselineRuns = len(baseline)
+		bt.BaselineP50 = Duration(Percentile(baseline, 50))
+		if bt.BaselineP50 > 0 {
+			bt.Trend = float64(bt.P50-bt.BaselineP50) / float64(bt.BaselineP50)
+		}
+		bt.Regression = bt.Trend > o.RegressionThreshold && time.Duration(bt.P50-bt.BaselineP50) >= o.MinRegression
+	}
+	return bt
+}
+
+// Percentile returns the given percentile of the durations using the nearest rank method
+func Percentile(durations []time.Duration, percentile float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Sparkline renders the durations as a line of block characters scaled between the shortest and longest duration
+func Sparkline(durations []Duration) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	if len(durations) == 0 {
+		return ""
+	}
+	min, max := durations[0], durations[0]
+	for _, d := range durations {
+		if d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	line := make([]rune, len(durations))
+	for i, d := range durations {
+		idx := 0
+		if max > min {
+			idx = int(float64(d-min) / float64(max-min) * float64(len(blocks)-1))
+		}
+		line[i] = blocks[idx]
+	}
+	return string(line)
+}
+
+// activityBuildNumber returns the build number of the activity so that runs sort numerically
+func activityBuildNumber(a *v1.PipelineActivity) int {
+	n, err := strconv.Atoi(a.Spec.Build)
+	if err != nil {
+		if a.Spec.StartedTimestamp != nil {
+			return int(a.Spec.StartedTimestamp.Unix())
+		}
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package builds_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	var durations []time.Duration
+	for i := 10; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	assert.Equal(t, 5*time.Second, builds.Percentile(durations, 50))
+	assert.Equal(t, 9*time.Second, builds.Percentile(durations, 90))
+	assert.Equal(t, 10*time.Second, builds.Percentile(durations, 100))
+	assert.Equal(t, 1*time.Second, builds.Percentile(durations, 0))
+	assert.Equal(t, time.Duration(0), builds.Percentile(nil, 50))
+	assert.Equal(t, 10*time.Second, durations[0], "the durations should not be sorted in place")
+}
+
+func TestAnalyzeBuildTimes(t *testing.T) {
+	t.Parallel()
+
+	var activities []v1.PipelineActivity
+	// builds 1 to 4 are the baseline and builds 5 to 8 the recent runs in which the tests got slower
+	for build := 1; build <= 8; build++ {
+		test := 60 * time.Second
+		if build > 4 {
+			test = 90 * time.Second
+		}
+		activities = append(activities, buildTimesActivity("myorg/myapp/master", build, v1.ActivityStatusTypeSucceeded,
+			20*time.Second, test))
+	}
+	activities = append(activities,
+		buildTimesActivity("myorg/myapp/master", 9, v1.ActivityStatusTypeFailed, 20*time.Second, 5*time.Second),
+		buildTimesActivity("myorg/other/master", 1, v1.ActivityStatusTypeSucceeded, time.Hour, time.Hour),
+	)
+
+	report := builds.AnalyzeBuildTimes("myorg/myapp/master", activities, builds.BuildTimesOptions{
+		Runs:                4,
+		BaselineRuns:        4,
+		RegressionThreshold: 0.2,
+		MinRegression:       10 * time.Second,
+		Slowest:             1,
+	})
+
+	assert.Equal(t, []string{"5", "6", "7", "8"}, report.Builds)
+	require.NotNil(t, report.Total)
+	assert.Equal(t, builds.Duration(110*time.Second), report.Total.P50)
+	assert.Equal(t, builds.Duration(80*time.Second), report.Total.BaselineP50)
+
+	require.Len(t, report.Stages, 1)
+	assert.Equal(t, "Build", report.Stages[0].Name)
+	require.Len(t, report.Steps, 2)
+	assert.Equal(t, "Build / compile", report.Steps[0].Name)
+	assert.Equal(t, 4, report.Steps[0].Runs)
+	assert.Equal(t, 0.0, report.Steps[0].Trend)
+	assert.False(t, report.Steps[0].Regression)
+	assert.Equal(t, 0.5, report.Steps[1].Trend)
+	assert.True(t, report.Steps[1].Regression)
+
+	require.Len(t, report.Slowest, 1)
+	assert.Equal(t, "Build / test", report.Slowest[0].Name)
+
+	var regressions []string
+	for _, r := range report.Regressions {
+		regressions = append(regressions, r.Name)
+	}
+	assert.Equal(t, []string{"myorg/myapp/master", "Build", "Build / test"}, regressions)
+
+	failed := builds.AnalyzeBuildTimes("myorg/myapp/master", activities, builds.BuildTimesOptions{
+		Runs:          1,
+		IncludeFailed: true,
+	})
+	assert.Equal(t, []string{"9"}, failed.Builds)
+	assert.Equal(t, 0, failed.Total.BaselineRuns)
+}
+
+func TestBuildTimesJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(&builds.BuildTimes{
+		Name: "Build / test",
+		Runs: 2,
+		P50:  builds.Duration(90*time.Second + 400*time.Millisecond),
+		P90:  builds.Duration(2 * time.Minute),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"p50":"1m30s"`)
+	assert.Contains(t, string(data), `"p90":"2m0s"`)
+}
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	durations := []builds.Duration{builds.Duration(time.Second), builds.Duration(8 * time.Second), builds.Duration(time.Second)}
+	assert.Equal(t, "▁█▁", builds.Sparkline(durations))
+	assert.Equal(t, "▁▁", builds.Sparkline([]builds.Duration{durations[0], durations[2]}), "equal durations should render as the lowest block")
+	assert.Equal(t, "", builds.Sparkline(nil))
+}
+
+func buildTimesActivity(pipeline string, build int, status v1.ActivityStatusType, compile time.Duration, test time.Duration) v1.PipelineActivity {
+	start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(build) * time.Hour)
+	step := func(name string, from time.Time, d time.Duration) v1.CoreActivityStep {
+		return v1.CoreActivityStep{
+			Name:               name,
+			Status:             status,
+			StartedTimestamp:   &metav1.Time{Time: from},
+			CompletedTimestamp: &metav1.Time{Time: from.Add(d)},
+		}
+	}
+	stage := &v1.StageActivityStep{
+		CoreActivityStep: step("Build", start, compile+test),
+		Steps: []v1.CoreActivityStep{
+			step("compile", start, compile),
+			step("test", start.Add(compile), test),
+		},
+	}
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: pipeline + "-" + strconv.Itoa(build)},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           pipeline,
+			Build:              strconv.Itoa(build),
+			Status:             status,
+			StartedTimestamp:   &metav1.Time{Time: start},
+			CompletedTimestamp: &metav1.Time{Time: start.Add(compile + test)},
+			Steps: []v1.PipelineActivityStep{
+				{Kind: v1.ActivityStepKindTypeStage, Stage: stage},
+			},
+		},
+	}
+}
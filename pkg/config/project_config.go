@@ -38,6 +38,20 @@ type ProjectConfig struct {
 	NoReleasePrepare    bool                        `json:"noReleasePrepare,omitempty"`
 	DockerRegistryHost  string                      `json:"dockerRegistryHost,omitempty"`
 	DockerRegistryOwner string                      `json:"dockerRegistryOwner,omitempty"`
+	Concurrency         *ConcurrencyConfig          `json:"concurrency,omitempty"`
+}
+
+// ConcurrencyConfig limits how many pipelines of the project run at the same time. By default running pull request
+// pipelines are cancelled when a newer commit is pushed and the release pipelines of a branch never overlap.
+type ConcurrencyConfig struct {
+	// MaxRuns is the maximum number of pipelines of the repository which run at the same time. 0 is unlimited
+	MaxRuns int `json:"maxRuns,omitempty"`
+	// MaxRunsPerBranch is the maximum number of pipelines of a branch or pull request which run at the same time. 0 is unlimited
+	MaxRunsPerBranch int `json:"maxRunsPerBranch,omitempty"`
+	// KeepSuperseded keeps running the pipelines of older commits of a pull request when a newer commit is pushed
+	KeepSuperseded bool `json:"keepSuperseded,omitempty"`
+	// ParallelReleases allows the release pipelines of a branch to overlap
+	ParallelReleases bool `json:"parallelReleases,omitempty"`
 }
 
 type PreviewEnvironmentConfig struct {
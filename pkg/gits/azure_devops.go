@@ -0,0 +1,1340 @@
+package gits
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AzureDevOpsHost is the host of Azure DevOps Services
+	AzureDevOpsHost = "dev.azure.com"
+
+	// AzureDevOpsURL is the URL of Azure DevOps Services
+	AzureDevOpsURL = "https://dev.azure.com"
+
+	// azureDevOpsLegacyHostSuffix is the suffix of the hosts of Azure DevOps organizations which use the legacy URLs
+	azureDevOpsLegacyHostSuffix = ".visualstudio.com"
+
+	azureDevOpsAPIVersion = "5.0"
+
+	// azureDevOpsPageSize is the number of pull requests or commits requested at once
+	azureDevOpsPageSize = 100
+
+	// azureVoteApproved and azureVoteWaitingForAuthor are the votes of the reviewers of a pull request which approve
+	// it or ask its author for changes
+	azureVoteApproved         = 10
+	azureVoteWaitingForAuthor = -5
+)
+
+var (
+	azureDevOpsWebHookEvents = []string{
+		"git.push",
+		"git.pullrequest.created",
+		"git.pullrequest.updated",
+		"git.pullrequest.merged",
+		"ms.vss-code.git-pullrequest-comment-event",
+	}
+
+	commitShaRegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+)
+
+// AzureDevOpsProvider implements GitProvider for the repositories of an Azure DevOps organization. The projects of the
+// organization are used as the organisations of the repositories.
+type AzureDevOpsProvider struct {
+	// Transport sends the requests to the REST API, caching the responses of GET requests
+	Transport http.RoundTripper
+	// BaseURL is the URL of the Azure DevOps organization such as https://dev.azure.com/myorg
+	BaseURL  string
+	Username string
+
+	Server auth.AuthServer
+	User   auth.UserAuth
+	Git    Gitter
+
+	// Metrics records the calls made to the Azure DevOps API
+	Metrics *APIMetrics
+}
+
+// AzureDevOpsError is an error response of the Azure DevOps REST API
+type AzureDevOpsError struct {
+	StatusCode int
+	Message    string `json:"message"`
+	TypeKey    string `json:"typeKey"`
+}
+
+func (e *AzureDevOpsError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Azure DevOps returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("Azure DevOps returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsAzureDevOpsNotFound returns true if the error is an Azure DevOps response for a missing resource
+func IsAzureDevOpsNotFound(err error) bool {
+	azureErr, ok := errors.Cause(err).(*AzureDevOpsError)
+	return ok && azureErr.StatusCode == http.StatusNotFound
+}
+
+type azureList struct {
+	Count int         `json:"count"`
+	Value interface{} `json:"value"`
+}
+
+type azureProject struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Visibility  string `json:"visibility,omitempty"`
+}
+
+type azureRepository struct {
+	ID               string           `json:"id,omitempty"`
+	Name             string           `json:"name,omitempty"`
+	URL              string           `json:"url,omitempty"`
+	RemoteURL        string           `json:"remoteUrl,omitempty"`
+	SSHURL           string           `json:"sshUrl,omitempty"`
+	WebURL           string           `json:"webUrl,omitempty"`
+	DefaultBranch    string           `json:"defaultBranch,omitempty"`
+	IsFork           bool             `json:"isFork,omitempty"`
+	Project          *azureProject    `json:"project,omitempty"`
+	ParentRepository *azureRepository `json:"parentRepository,omitempty"`
+}
+
+type azureIdentity struct {
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	UniqueName  string `json:"uniqueName,omitempty"`
+	URL         string `json:"url,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+}
+
+type azureCommitRef struct {
+	CommitID string `json:"commitId,omitempty"`
+}
+
+type azureLabel struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Active bool   `json:"active,omitempty"`
+}
+
+type azurePullRequest struct {
+	PullRequestID         int              `json:"pullRequestId,omitempty"`
+	Status                string           `json:"status,omitempty"`
+	CreatedBy             *azureIdentity   `json:"createdBy,omitempty"`
+	CreationDate          *time.Time       `json:"creationDate,omitempty"`
+	ClosedDate            *time.Time       `json:"closedDate,omitempty"`
+	Title                 string           `json:"title,omitempty"`
+	Description           string           `json:"description,omitempty"`
+	SourceRefName         string           `json:"sourceRefName,omitempty"`
+	TargetRefName         string           `json:"targetRefName,omitempty"`
+	MergeStatus           string           `json:"mergeStatus,omitempty"`
+	LastMergeSourceCommit *azureCommitRef  `json:"lastMergeSourceCommit,omitempty"`
+	LastMergeTargetCommit *azureCommitRef  `json:"lastMergeTargetCommit,omitempty"`
+	LastMergeCommit       *azureCommitRef  `json:"lastMergeCommit,omitempty"`
+	Reviewers             []*azureIdentity `json:"reviewers,omitempty"`
+	Labels                []*azureLabel    `json:"labels,omitempty"`
+	Repository            *azureRepository `json:"repository,omitempty"`
+}
+
+type azureReviewer struct {
+	azureIdentity
+	Vote int `json:"vote"`
+}
+
+type azureGitUser struct {
+	Name  string     `json:"name,omitempty"`
+	Email string     `json:"email,omitempty"`
+	Date  *time.Time `json:"date,omitempty"`
+}
+
+type azureCommit struct {
+	CommitID  string        `json:"commitId,omitempty"`
+	Comment   string        `json:"comment,omitempty"`
+	Author    *azureGitUser `json:"author,omitempty"`
+	Committer *azureGitUser `json:"committer,omitempty"`
+	URL       string        `json:"url,omitempty"`
+	RemoteURL string        `json:"remoteUrl,omitempty"`
+}
+
+type azureStatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+type azureStatus struct {
+	ID          int                 `json:"id,omitempty"`
+	State       string              `json:"state"`
+	Description string              `json:"description,omitempty"`
+	Context     *azureStatusContext `json:"context,omitempty"`
+	TargetURL   string              `json:"targetUrl,omitempty"`
+}
+
+type azureRef struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+}
+
+type azureItem struct {
+	ObjectID      string `json:"objectId,omitempty"`
+	GitObjectType string `json:"gitObjectType,omitempty"`
+	CommitID      string `json:"commitId,omitempty"`
+	Path          string `json:"path,omitempty"`
+	IsFolder      bool   `json:"isFolder,omitempty"`
+	Content       string `json:"content,omitempty"`
+	URL           string `json:"url,omitempty"`
+}
+
+type azureSubscription struct {
+	ID               string            `json:"id,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion,omitempty"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+type azureComment struct {
+	Content     string `json:"content"`
+	CommentType int    `json:"commentType"`
+}
+
+type azureThread struct {
+	Comments []*azureComment `json:"comments"`
+	Status   int             `json:"status"`
+}
+
+type azureConnectionData struct {
+	AuthenticatedUser *struct {
+		ID                  string `json:"id"`
+		ProviderDisplayName string `json:"providerDisplayName"`
+		Properties          struct {
+			Account struct {
+				Value string `json:"$value"`
+			} `json:"Account"`
+		} `json:"properties"`
+	} `json:"authenticatedUser"`
+}
+
+// NewAzureDevOpsProvider creates a provider for the Azure DevOps organization of the server URL, such as
+// https://dev.azure.com/myorg or https://myorg.visualstudio.com
+func NewAzureDevOpsProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
+	if server.URL == "" {
+		return nil, util.MissingOption("url")
+	}
+	metrics := NewAPIMetrics()
+	provider := AzureDevOpsProvider{
+		Transport: NewCachingTransport(http.DefaultTransport, metrics),
+		BaseURL:   strings.TrimSuffix(server.URL, "/"),
+		Server:    *server,
+		User:      *user,
+		Username:  user.Username,
+		Git:       git,
+		Metrics:   metrics,
+	}
+	return &provider, nil
+}
+
+// APIMetrics returns the metrics of the calls made to the Azure DevOps API
+func (p *AzureDevOpsProvider) APIMetrics() *APIMetrics {
+	return p.Metrics
+}
+
+// IsAzureDevOpsHost returns true if the host is an Azure DevOps Services host
+func IsAzureDevOpsHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == AzureDevOpsHost || host == "ssh."+AzureDevOpsHost || strings.HasSuffix(host, azureDevOpsLegacyHostSuffix)
+}
+
+// AzureDevOpsAccessTokenURL returns the URL to create a personal access token for an Azure DevOps organization
+func AzureDevOpsAccessTokenURL(url string) string {
+	return util.UrlJoin(url, "_usersSettings/tokens")
+}
+
+// rest returns the client of the REST API of the organization
+func (p *AzureDevOpsProvider) rest() *restClient {
+	authorize := basicAuth(p.Username, p.User.ApiToken)
+	return newRestClient(p.BaseURL, p.Transport, func(req *http.Request) {
+		authorize(req)
+		req.Header.Set("Accept", "application/json")
+	})
+}
+
+// do invokes the REST API of the organization. The path is relative to the organization URL. The body, if not nil, is
+// sent as JSON and the response is unmarshalled into the result, if not nil.
+func (p *AzureDevOpsProvider) do(method string, path string, query url.Values, body interface{}, result interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", azureDevOpsAPIVersion)
+	err := p.rest().do(method, path, query, body, result)
+	if restErr, ok := err.(*RestError); ok {
+		azureErr := &AzureDevOpsError{}
+		json.Unmarshal([]byte(restErr.Body), azureErr)
+		azureErr.StatusCode = restErr.StatusCode
+		return azureErr
+	}
+	return err
+}
+
+func (p *AzureDevOpsProvider) repoPath(org string, name string, paths ...string) string {
+	return util.UrlJoin(append([]string{url.PathEscape(org), "_apis/git/repositories", url.PathEscape(name)}, paths...)...)
+}
+
+func (p *AzureDevOpsProvider) webURL(org string, name string) string {
+	return util.UrlJoin(p.BaseURL, url.PathEscape(org), "_git", url.PathEscape(name))
+}
+
+func (p *AzureDevOpsProvider) toGitRepository(repo *azureRepository) *GitRepository {
+	org := ""
+	private := true
+	if repo.Project != nil {
+		org = repo.Project.Name
+		private = repo.Project.Visibility != "public"
+	}
+	webURL := repo.WebURL
+	if webURL == "" {
+		webURL = p.webURL(org, repo.Name)
+	}
+	answer := &GitRepository{
+		Name:             repo.Name,
+		AllowMergeCommit: true,
+		HTMLURL:          webURL,
+		CloneURL:         repo.RemoteURL,
+		SSHURL:           repo.SSHURL,
+		Fork:             repo.IsFork,
+		URL:              webURL,
+		Organisation:     org,
+		Project:          org,
+		Private:          private,
+	}
+	parsed, err := ParseGitURL(webURL)
+	if err == nil {
+		answer.Scheme = parsed.Scheme
+		answer.Host = parsed.Host
+		answer.ServerPath = parsed.ServerPath
+	}
+	return answer
+}
+
+func (p *AzureDevOpsProvider) getProject(name string) (*azureProject, error) {
+	project := &azureProject{}
+	err := p.do(http.MethodGet, util.UrlJoin("_apis/projects", url.PathEscape(name)), nil, nil, project)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the project %s", name)
+	}
+	return project, nil
+}
+
+func (p *AzureDevOpsProvider) getRepository(org string, name string) (*azureRepository, error) {
+	repo := &azureRepository{}
+	err := p.do(http.MethodGet, p.repoPath(org, name), nil, nil, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the repository %s/%s", org, name)
+	}
+	return repo, nil
+}
+
+// ListOrganisations lists the projects of the organization
+func (p *AzureDevOpsProvider) ListOrganisations() ([]GitOrganisation, error) {
+	projects := []*azureProject{}
+	err := p.do(http.MethodGet, "_apis/projects", nil, nil, &azureList{Value: &projects})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the projects")
+	}
+	answer := []GitOrganisation{}
+	for _, project := range projects {
+		answer = append(answer, GitOrganisation{Login: project.Name})
+	}
+	return answer, nil
+}
+
+// ListRepositories lists the repositories of a project
+func (p *AzureDevOpsProvider) ListRepositories(org string) ([]*GitRepository, error) {
+	repos := []*azureRepository{}
+	err := p.do(http.MethodGet, util.UrlJoin(url.PathEscape(org), "_apis/git/repositories"), nil, nil, &azureList{Value: &repos})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the repositories of %s", org)
+	}
+	answer := []*GitRepository{}
+	for _, repo := range repos {
+		answer = append(answer, p.toGitRepository(repo))
+	}
+	return answer, nil
+}
+
+// CreateRepository creates a repository in a project. The visibility of a repository is that of its project.
+func (p *AzureDevOpsProvider) CreateRepository(org string, name string, private bool) (*GitRepository, error) {
+	project, err := p.getProject(org)
+	if err != nil {
+		return nil, err
+	}
+	request := &azureRepository{
+		Name:    name,
+		Project: &azureProject{ID: project.ID},
+	}
+	repo := &azureRepository{}
+	err = p.do(http.MethodPost, util.UrlJoin(url.PathEscape(org), "_apis/git/repositories"), nil, request, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the repository %s/%s", org, name)
+	}
+	if repo.Project == nil {
+		repo.Project = project
+	}
+	return p.toGitRepository(repo), nil
+}
+
+// GetRepository gets a repository of a project
+func (p *AzureDevOpsProvider) GetRepository(org string, name string) (*GitRepository, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.toGitRepository(repo), nil
+}
+
+// DeleteRepository deletes a repository of a project
+func (p *AzureDevOpsProvider) DeleteRepository(org string, name string) error {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return err
+	}
+	err = p.do(http.MethodDelete, p.repoPath(org, repo.ID), nil, nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete the repository %s/%s", org, name)
+	}
+	return nil
+}
+
+// ForkRepository forks a repository into another project of the organization
+func (p *AzureDevOpsProvider) ForkRepository(originalOrg string, name string, destinationOrg string) (*GitRepository, error) {
+	if destinationOrg == "" || destinationOrg == originalOrg {
+		return nil, fmt.Errorf("Azure DevOps can only fork the repository %s/%s into another project", originalOrg, name)
+	}
+	parent, err := p.getRepository(originalOrg, name)
+	if err != nil {
+		return nil, err
+	}
+	project, err := p.getProject(destinationOrg)
+	if err != nil {
+		return nil, err
+	}
+	request := &azureRepository{
+		Name:    name,
+		Project: &azureProject{ID: project.ID},
+		ParentRepository: &azureRepository{
+			ID:      parent.ID,
+			Project: &azureProject{ID: parent.Project.ID},
+		},
+	}
+	repo := &azureRepository{}
+	err = p.do(http.MethodPost, util.UrlJoin(url.PathEscape(destinationOrg), "_apis/git/repositories"), nil, request, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fork the repository %s/%s into %s", originalOrg, name, destinationOrg)
+	}
+	return p.toGitRepository(repo), nil
+}
+
+// RenameRepository renames a repository of a project
+func (p *AzureDevOpsProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	renamed := &azureRepository{}
+	err = p.do(http.MethodPatch, p.repoPath(org, repo.ID), nil, &azureRepository{Name: newName}, renamed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to rename the repository %s/%s to %s", org, name, newName)
+	}
+	return p.toGitRepository(renamed), nil
+}
+
+// ValidateRepositoryName returns an error if the repository already exists
+func (p *AzureDevOpsProvider) ValidateRepositoryName(org string, name string) error {
+	_, err := p.getRepository(org, name)
+	if err == nil {
+		return fmt.Errorf("repository %s/%s already exists", org, name)
+	}
+	if IsAzureDevOpsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func branchRefName(branch string) string {
+	if strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+// CreatePullRequest creates a pull request. A head of the form owner:branch is treated as the branch.
+func (p *AzureDevOpsProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
+	repo := data.GitRepository
+	if repo == nil {
+		return nil, fmt.Errorf("missing the repository of the pull request %s", data.Title)
+	}
+	head := data.Head
+	if i := strings.LastIndex(head, ":"); i >= 0 {
+		head = head[i+1:]
+	}
+	base := data.Base
+	if base == "" {
+		base = "master"
+	}
+	request := &azurePullRequest{
+		SourceRefName: branchRefName(head),
+		TargetRefName: branchRefName(base),
+		Title:         data.Title,
+		Description:   data.Body,
+	}
+	for _, label := range data.Labels {
+		request.Labels = append(request.Labels, &azureLabel{Name: label})
+	}
+	pr := &azurePullRequest{}
+	err := p.do(http.MethodPost, p.repoPath(repo.Organisation, repo.Name, "pullrequests"), nil, request, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the pull request from %s to %s in %s/%s", head, base, repo.Organisation, repo.Name)
+	}
+	return p.toPullRequest(repo.Organisation, repo.Name, pr), nil
+}
+
+func (p *AzureDevOpsProvider) getPullRequest(owner string, repo string, number int) (*azurePullRequest, error) {
+	pr := &azurePullRequest{}
+	err := p.do(http.MethodGet, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number)), nil, nil, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the pull request %d of %s/%s", number, owner, repo)
+	}
+	return pr, nil
+}
+
+func (p *AzureDevOpsProvider) toGitUser(identity *azureIdentity) *GitUser {
+	if identity == nil {
+		return nil
+	}
+	return &GitUser{
+		URL:       identity.URL,
+		Login:     identity.UniqueName,
+		Name:      identity.DisplayName,
+		AvatarURL: identity.ImageURL,
+	}
+}
+
+func (p *AzureDevOpsProvider) toPullRequest(owner string, repo string, pr *azurePullRequest) *GitPullRequest {
+	number := pr.PullRequestID
+	state := "open"
+	merged := pr.Status == "completed"
+	if pr.Status == "completed" || pr.Status == "abandoned" {
+		state = "closed"
+	}
+	mergeable := pr.MergeStatus == "succeeded"
+	headRef := strings.TrimPrefix(pr.SourceRefName, "refs/heads/")
+	baseRef := strings.TrimPrefix(pr.TargetRefName, "refs/heads/")
+	answer := &GitPullRequest{
+		URL:       util.UrlJoin(p.webURL(owner, repo), "pullrequest", strconv.Itoa(number)),
+		Author:    p.toGitUser(pr.CreatedBy),
+		Owner:     owner,
+		Repo:      repo,
+		Number:    &number,
+		Mergeable: &mergeable,
+		Merged:    &merged,
+		HeadRef:   &headRef,
+		BaseRef:   &baseRef,
+		State:     &state,
+		Title:     pr.Title,
+		Body:      pr.Description,
+		ClosedAt:  pr.ClosedDate,
+		UpdatedAt: pr.CreationDate,
+	}
+	if pr.LastMergeSourceCommit != nil {
+		answer.LastCommitSha = pr.LastMergeSourceCommit.CommitID
+	}
+	if pr.LastMergeTargetCommit != nil {
+		answer.BaseSha = pr.LastMergeTargetCommit.CommitID
+	}
+	if merged {
+		answer.MergedAt = pr.ClosedDate
+		if pr.LastMergeCommit != nil {
+			answer.MergeCommitSHA = &pr.LastMergeCommit.CommitID
+		}
+	}
+	for _, reviewer := range pr.Reviewers {
+		answer.RequestedReviewers = append(answer.RequestedReviewers, p.toGitUser(reviewer))
+	}
+	for _, label := range pr.Labels {
+		name := label.Name
+		answer.Labels = append(answer.Labels, &Label{Name: &name})
+	}
+	return answer
+}
+
+// UpdatePullRequestStatus updates the pull request with its current state
+func (p *AzureDevOpsProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	azurePR, err := p.getPullRequest(pr.Owner, pr.Repo, *pr.Number)
+	if err != nil {
+		return err
+	}
+	*pr = *p.toPullRequest(pr.Owner, pr.Repo, azurePR)
+	return nil
+}
+
+// AddLabelsToIssue adds labels to a pull request as Azure DevOps work items do not have labels
+func (p *AzureDevOpsProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
+	for _, label := range labels {
+		err := p.do(http.MethodPost, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number), "labels"), nil, &azureLabel{Name: label}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the label %s to the pull request %d of %s/%s", label, number, owner, repo)
+		}
+	}
+	return nil
+}
+
+// ListReviews lists the votes of the reviewers of a pull request who approved it or asked for changes
+func (p *AzureDevOpsProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	reviewers := []*azureReviewer{}
+	err := p.do(http.MethodGet, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number), "reviewers"), nil, nil, &azureList{Value: &reviewers})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the reviewers of the pull request %d of %s/%s", number, owner, repo)
+	}
+	answer := []*GitReview{}
+	for _, reviewer := range reviewers {
+		state := ""
+		switch {
+		case reviewer.Vote > 0:
+			state = ReviewStateApproved
+		case reviewer.Vote < 0:
+			state = ReviewStateChangesRequested
+		}
+		if state != "" {
+			answer = append(answer, &GitReview{
+				ID:    reviewer.ID,
+				User:  p.toGitUser(&reviewer.azureIdentity),
+				State: state,
+			})
+		}
+	}
+	return answer, nil
+}
+
+// SubmitReview votes on a pull request as the current user and adds the body of the review as a comment thread
+func (p *AzureDevOpsProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	answer := &GitReview{
+		State: review.State,
+		Body:  review.Body,
+	}
+	vote := 0
+	switch review.State {
+	case ReviewStateApproved:
+		vote = azureVoteApproved
+	case ReviewStateChangesRequested:
+		vote = azureVoteWaitingForAuthor
+	case ReviewStateCommented, "":
+		answer.State = ReviewStateCommented
+		if review.Body == "" {
+			return nil, fmt.Errorf("a comment on the pull request %d of %s/%s needs a body", number, owner, repo)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported review state %s", review.State)
+	}
+	if vote != 0 {
+		data := &azureConnectionData{}
+		err := p.do(http.MethodGet, "_apis/connectionData", nil, nil, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the current user")
+		}
+		if data.AuthenticatedUser == nil || data.AuthenticatedUser.ID == "" {
+			return nil, fmt.Errorf("no authenticated user found on %s", p.BaseURL)
+		}
+		result := &azureReviewer{}
+		err = p.do(http.MethodPut, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number), "reviewers", data.AuthenticatedUser.ID), nil, &azureReviewer{Vote: vote}, result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to vote on the pull request %d of %s/%s", number, owner, repo)
+		}
+		answer.ID = result.ID
+		answer.User = p.toGitUser(&result.azureIdentity)
+	}
+	if review.Body != "" {
+		err := p.CreateIssueComment(owner, repo, number, review.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return answer, nil
+}
+
+// RequestReviewers adds reviewers to a pull request. Azure DevOps identifies the reviewers by the IDs of their
+// identities rather than by their names.
+func (p *AzureDevOpsProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	for _, reviewer := range reviewers {
+		err := p.do(http.MethodPut, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number), "reviewers", reviewer), nil, &azureReviewer{}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the reviewer %s to the pull request %d of %s/%s", reviewer, number, owner, repo)
+		}
+	}
+	return nil
+}
+
+// GetBranchProtection is not supported as Azure DevOps protects branches with the branch policies of a project
+func (p *AzureDevOpsProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	return nil, fmt.Errorf("branch protection is not supported on Azure DevOps")
+}
+
+// SetBranchProtection is not supported as Azure DevOps protects branches with the branch policies of a project
+func (p *AzureDevOpsProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	return fmt.Errorf("branch protection is not supported on Azure DevOps")
+}
+
+// RemoveBranchProtection is not supported as Azure DevOps protects branches with the branch policies of a project
+func (p *AzureDevOpsProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	return fmt.Errorf("branch protection is not supported on Azure DevOps")
+}
+
+// GetPullRequest gets a pull request
+func (p *AzureDevOpsProvider) GetPullRequest(owner string, repo *GitRepository, number int) (*GitPullRequest, error) {
+	pr, err := p.getPullRequest(owner, repo.Name, number)
+	if err != nil {
+		return nil, err
+	}
+	return p.toPullRequest(owner, repo.Name, pr), nil
+}
+
+// ListOpenPullRequests lists the active pull requests of a repository
+func (p *AzureDevOpsProvider) ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error) {
+	answer := []*GitPullRequest{}
+	for skip := 0; ; skip += azureDevOpsPageSize {
+		query := url.Values{}
+		query.Set("searchCriteria.status", "active")
+		query.Set("$top", strconv.Itoa(azureDevOpsPageSize))
+		query.Set("$skip", strconv.Itoa(skip))
+		prs := []*azurePullRequest{}
+		err := p.do(http.MethodGet, p.repoPath(owner, repo, "pullrequests"), query, nil, &azureList{Value: &prs})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the pull requests of %s/%s", owner, repo)
+		}
+		for _, pr := range prs {
+			answer = append(answer, p.toPullRequest(owner, repo, pr))
+		}
+		if len(prs) < azureDevOpsPageSize {
+			return answer, nil
+		}
+	}
+}
+
+func (p *AzureDevOpsProvider) toCommit(commit *azureCommit) *GitCommit {
+	answer := &GitCommit{
+		SHA:     commit.CommitID,
+		Message: commit.Comment,
+		URL:     commit.RemoteURL,
+	}
+	if commit.Author != nil {
+		answer.Author = &GitUser{Name: commit.Author.Name, Email: commit.Author.Email}
+	}
+	if commit.Committer != nil {
+		answer.Committer = &GitUser{Name: commit.Committer.Name, Email: commit.Committer.Email}
+	}
+	return answer
+}
+
+// GetPullRequestCommits lists the commits of a pull request
+func (p *AzureDevOpsProvider) GetPullRequestCommits(owner string, repo *GitRepository, number int) ([]*GitCommit, error) {
+	commits := []*azureCommit{}
+	err := p.do(http.MethodGet, p.repoPath(owner, repo.Name, "pullrequests", strconv.Itoa(number), "commits"), nil, nil, &azureList{Value: &commits})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the commits of the pull request %d of %s/%s", number, owner, repo.Name)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range commits {
+		answer = append(answer, p.toCommit(commit))
+	}
+	return answer, nil
+}
+
+// PullRequestLastCommitStatus returns the state of the latest status of the last commit of the pull request
+func (p *AzureDevOpsProvider) PullRequestLastCommitStatus(pr *GitPullRequest) (string, error) {
+	if pr.LastCommitSha == "" {
+		return "", fmt.Errorf("the pull request %s has no last commit", pr.NumberString())
+	}
+	statuses, err := p.ListCommitStatus(pr.Owner, pr.Repo, pr.LastCommitSha)
+	if err != nil {
+		return "", err
+	}
+	if len(statuses) == 0 {
+		return "", fmt.Errorf("no status found for commit %s of the pull request %s", pr.LastCommitSha, pr.NumberString())
+	}
+	return statuses[0].State, nil
+}
+
+// toAzureState converts a jx commit status state to the Azure DevOps state
+func toAzureState(state string) string {
+	switch state {
+	case "success":
+		return "succeeded"
+	case "failure":
+		return "failed"
+	case "error", "pending":
+		return state
+	default:
+		return "notSet"
+	}
+}
+
+// fromAzureState converts an Azure DevOps commit status state to the jx state
+func fromAzureState(state string) string {
+	switch state {
+	case "succeeded":
+		return "success"
+	case "failed":
+		return "failure"
+	case "error":
+		return "error"
+	default:
+		return "pending"
+	}
+}
+
+func (p *AzureDevOpsProvider) toRepoStatus(status *azureStatus) *GitRepoStatus {
+	answer := &GitRepoStatus{
+		ID:          strconv.Itoa(status.ID),
+		State:       fromAzureState(status.State),
+		TargetURL:   status.TargetURL,
+		URL:         status.TargetURL,
+		Description: status.Description,
+	}
+	if status.Context != nil {
+		answer.Context = status.Context.Name
+		if status.Context.Genre != "" {
+			answer.Context = status.Context.Genre + "/" + status.Context.Name
+		}
+	}
+	return answer
+}
+
+// ListCommitStatus lists the statuses of a commit, most recent first
+func (p *AzureDevOpsProvider) ListCommitStatus(org string, repo string, sha string) ([]*GitRepoStatus, error) {
+	statuses := []*azureStatus{}
+	err := p.do(http.MethodGet, p.repoPath(org, repo, "commits", sha, "statuses"), nil, nil, &azureList{Value: &statuses})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the statuses of commit %s of %s/%s", sha, org, repo)
+	}
+	answer := []*GitRepoStatus{}
+	for _, status := range statuses {
+		answer = append(answer, p.toRepoStatus(status))
+	}
+	return answer, nil
+}
+
+// ListCommits lists the commits of a repository
+func (p *AzureDevOpsProvider) ListCommits(owner string, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
+	query := url.Values{}
+	if opt != nil {
+		if opt.SHA != "" {
+			query.Set("searchCriteria.itemVersion.version", opt.SHA)
+			if commitShaRegex.MatchString(opt.SHA) {
+				query.Set("searchCriteria.itemVersion.versionType", "commit")
+			}
+		}
+		if opt.Path != "" {
+			query.Set("searchCriteria.itemPath", opt.Path)
+		}
+		if opt.Author != "" {
+			query.Set("searchCriteria.author", opt.Author)
+		}
+		if !opt.Since.IsZero() {
+			query.Set("searchCriteria.fromDate", opt.Since.Format(time.RFC3339))
+		}
+		if !opt.Until.IsZero() {
+			query.Set("searchCriteria.toDate", opt.Until.Format(time.RFC3339))
+		}
+		if opt.PerPage > 0 {
+			query.Set("searchCriteria.$top", strconv.Itoa(opt.PerPage))
+			if opt.Page > 1 {
+				query.Set("searchCriteria.$skip", strconv.Itoa((opt.Page-1)*opt.PerPage))
+			}
+		}
+	}
+	commits := []*azureCommit{}
+	err := p.do(http.MethodGet, p.repoPath(owner, repo, "commits"), query, nil, &azureList{Value: &commits})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the commits of %s/%s", owner, repo)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range commits {
+		answer = append(answer, p.toCommit(commit))
+	}
+	return answer, nil
+}
+
+// UpdateCommitStatus adds a status to a commit. A context of the form genre/name is split into the genre and name of
+// the Azure DevOps status context.
+func (p *AzureDevOpsProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	context := &azureStatusContext{Name: status.Context}
+	if i := strings.LastIndex(status.Context, "/"); i > 0 {
+		context.Genre = status.Context[:i]
+		context.Name = status.Context[i+1:]
+	}
+	request := &azureStatus{
+		State:       toAzureState(status.State),
+		Description: status.Description,
+		Context:     context,
+		TargetURL:   status.TargetURL,
+	}
+	created := &azureStatus{}
+	err := p.do(http.MethodPost, p.repoPath(org, repo, "commits", sha, "statuses"), nil, request, created)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the status of commit %s of %s/%s", sha, org, repo)
+	}
+	return p.toRepoStatus(created), nil
+}
+
+// MergePullRequest completes a pull request
+func (p *AzureDevOpsProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	lastCommit := pr.LastCommitSha
+	if lastCommit == "" {
+		err := p.UpdatePullRequestStatus(pr)
+		if err != nil {
+			return err
+		}
+		lastCommit = pr.LastCommitSha
+	}
+	request := map[string]interface{}{
+		"status":                "completed",
+		"lastMergeSourceCommit": &azureCommitRef{CommitID: lastCommit},
+		"completionOptions": map[string]interface{}{
+			"mergeCommitMessage": message,
+		},
+	}
+	err := p.do(http.MethodPatch, p.repoPath(pr.Owner, pr.Repo, "pullrequests", strconv.Itoa(*pr.Number)), nil, request, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge the pull request %s of %s/%s", pr.NumberString(), pr.Owner, pr.Repo)
+	}
+	return nil
+}
+
+// webHookSubscriptions returns the service hook subscriptions which send webhooks for the events of a repository
+func (p *AzureDevOpsProvider) webHookSubscriptions(repo *azureRepository) ([]*azureSubscription, error) {
+	subscriptions := []*azureSubscription{}
+	err := p.do(http.MethodGet, "_apis/hooks/subscriptions", nil, nil, &azureList{Value: &subscriptions})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the service hook subscriptions")
+	}
+	answer := []*azureSubscription{}
+	for _, subscription := range subscriptions {
+		if subscription.ConsumerID == "webHooks" && subscription.PublisherInputs["repository"] == repo.ID {
+			answer = append(answer, subscription)
+		}
+	}
+	return answer, nil
+}
+
+func webHookConsumerInputs(data *GitWebHookArguments) map[string]string {
+	inputs := map[string]string{
+		"url": data.URL,
+	}
+	if data.Secret != "" {
+		inputs["httpHeaders"] = "Authorization: Bearer " + data.Secret
+	}
+	return inputs
+}
+
+// CreateWebHook creates a service hook subscription for each of the push, pull request and pull request comment
+// events of the repository. Azure DevOps cannot sign webhooks so the secret is sent as a bearer token.
+func (p *AzureDevOpsProvider) CreateWebHook(data *GitWebHookArguments) error {
+	owner, name, err := p.webHookRepository(data)
+	if err != nil {
+		return err
+	}
+	repo, err := p.getRepository(owner, name)
+	if err != nil {
+		return err
+	}
+	existing, err := p.webHookSubscriptions(repo)
+	if err != nil {
+		return err
+	}
+	for _, eventType := range azureDevOpsWebHookEvents {
+		found := false
+		for _, subscription := range existing {
+			if subscription.EventType == eventType && subscription.ConsumerInputs["url"] == data.URL {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		subscription := &azureSubscription{
+			PublisherID:      "tfs",
+			EventType:        eventType,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			PublisherInputs: map[string]string{
+				"projectId":  repo.Project.ID,
+				"repository": repo.ID,
+			},
+			ConsumerInputs: webHookConsumerInputs(data),
+		}
+		log.Infof("Creating Azure DevOps webhook for %s/%s on %s for url %s\n", util.ColorInfo(owner), util.ColorInfo(name), util.ColorInfo(eventType), util.ColorInfo(data.URL))
+		err = p.do(http.MethodPost, "_apis/hooks/subscriptions", nil, subscription, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the %s webhook of %s/%s", eventType, owner, name)
+		}
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) webHookRepository(data *GitWebHookArguments) (string, string, error) {
+	if data.Repo == nil || data.Repo.Name == "" {
+		return "", "", fmt.Errorf("missing the repository of the webhook %s", data.URL)
+	}
+	owner := data.Repo.Organisation
+	if owner == "" {
+		owner = data.Owner
+	}
+	return owner, data.Repo.Name, nil
+}
+
+// ListWebHooks lists the webhooks of a repository. The service hook subscriptions for the events of a URL are returned
+// as a single webhook.
+func (p *AzureDevOpsProvider) ListWebHooks(org string, name string) ([]*GitWebHookArguments, error) {
+	repo, err := p.getRepository(org, name)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := p.webHookSubscriptions(repo)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitWebHookArguments{}
+	urls := map[string]bool{}
+	for _, subscription := range subscriptions {
+		u := subscription.ConsumerInputs["url"]
+		if urls[u] {
+			continue
+		}
+		urls[u] = true
+		answer = append(answer, &GitWebHookArguments{
+			Owner: org,
+			Repo:  p.toGitRepository(repo),
+			URL:   u,
+		})
+	}
+	return answer, nil
+}
+
+// UpdateWebHook changes the URL and secret of the service hook subscriptions of a repository which use the existing
+// URL, creating them if there are none
+func (p *AzureDevOpsProvider) UpdateWebHook(data *GitWebHookArguments) error {
+	owner, name, err := p.webHookRepository(data)
+	if err != nil {
+		return err
+	}
+	repo, err := p.getRepository(owner, name)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := p.webHookSubscriptions(repo)
+	if err != nil {
+		return err
+	}
+	updated := false
+	for _, subscription := range subscriptions {
+		if subscription.ConsumerInputs["url"] != data.ExistingURL {
+			continue
+		}
+		subscription.ConsumerInputs = webHookConsumerInputs(data)
+		log.Infof("Updating Azure DevOps webhook for %s/%s on %s for url %s\n", util.ColorInfo(owner), util.ColorInfo(name), util.ColorInfo(subscription.EventType), util.ColorInfo(data.URL))
+		err = p.do(http.MethodPut, util.UrlJoin("_apis/hooks/subscriptions", subscription.ID), nil, subscription, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the %s webhook of %s/%s", subscription.EventType, owner, name)
+		}
+		updated = true
+	}
+	if !updated {
+		return p.CreateWebHook(data)
+	}
+	return nil
+}
+
+// IsGitHub returns false
+func (p *AzureDevOpsProvider) IsGitHub() bool {
+	return false
+}
+
+// IsGitea returns false
+func (p *AzureDevOpsProvider) IsGitea() bool {
+	return false
+}
+
+// IsBitbucketCloud returns false
+func (p *AzureDevOpsProvider) IsBitbucketCloud() bool {
+	return false
+}
+
+// IsBitbucketServer returns false
+func (p *AzureDevOpsProvider) IsBitbucketServer() bool {
+	return false
+}
+
+// IsGerrit returns false
+func (p *AzureDevOpsProvider) IsGerrit() bool {
+	return false
+}
+
+// Kind returns the kind of the provider
+func (p *AzureDevOpsProvider) Kind() string {
+	return KindAzureDevOps
+}
+
+// GetIssue is not supported as Azure DevOps tracks issues as work items of Azure Boards
+func (p *AzureDevOpsProvider) GetIssue(org string, name string, number int) (*GitIssue, error) {
+	log.Warn("Finding an issue on Azure DevOps is not supported at this moment")
+	return &GitIssue{}, nil
+}
+
+// IssueURL returns the URL of a pull request or a work item
+func (p *AzureDevOpsProvider) IssueURL(org string, name string, number int, isPull bool) string {
+	if isPull {
+		return util.UrlJoin(p.webURL(org, name), "pullrequest", strconv.Itoa(number))
+	}
+	return util.UrlJoin(p.BaseURL, url.PathEscape(org), "_workitems/edit", strconv.Itoa(number))
+}
+
+// SearchIssues is not supported as Azure DevOps tracks issues as work items of Azure Boards
+func (p *AzureDevOpsProvider) SearchIssues(org string, name string, query string) ([]*GitIssue, error) {
+	log.Warn("Searching issues on Azure DevOps is not supported at this moment")
+	return []*GitIssue{}, nil
+}
+
+// SearchIssuesClosedSince is not supported as Azure DevOps tracks issues as work items of Azure Boards
+func (p *AzureDevOpsProvider) SearchIssuesClosedSince(org string, name string, t time.Time) ([]*GitIssue, error) {
+	issues, err := p.SearchIssues(org, name, "")
+	if err != nil {
+		return issues, err
+	}
+	return FilterIssuesClosedSince(issues, t), nil
+}
+
+// CreateIssue is not supported as Azure DevOps tracks issues as work items of Azure Boards
+func (p *AzureDevOpsProvider) CreateIssue(owner string, repo string, issue *GitIssue) (*GitIssue, error) {
+	log.Warn("Creating an issue on Azure DevOps is not supported at this moment")
+	return &GitIssue{}, nil
+}
+
+// HasIssues returns false as Azure DevOps tracks issues as work items of Azure Boards
+func (p *AzureDevOpsProvider) HasIssues() bool {
+	return false
+}
+
+// AddPRComment adds a comment thread to a pull request
+func (p *AzureDevOpsProvider) AddPRComment(pr *GitPullRequest, comment string) error {
+	if pr.Number == nil {
+		return fmt.Errorf("Missing Number for GitPullRequest %#v", pr)
+	}
+	return p.CreateIssueComment(pr.Owner, pr.Repo, *pr.Number, comment)
+}
+
+// CreateIssueComment adds a comment thread to the pull request with the number
+func (p *AzureDevOpsProvider) CreateIssueComment(owner string, repo string, number int, comment string) error {
+	thread := &azureThread{
+		Comments: []*azureComment{
+			{
+				Content:     comment,
+				CommentType: 1,
+			},
+		},
+		Status: 1,
+	}
+	err := p.do(http.MethodPost, p.repoPath(owner, repo, "pullrequests", strconv.Itoa(number), "threads"), nil, thread, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to comment on the pull request %d of %s/%s", number, owner, repo)
+	}
+	return nil
+}
+
+func (p *AzureDevOpsProvider) listTags(org string, name string) ([]*azureRef, error) {
+	query := url.Values{}
+	query.Set("filter", "tags/")
+	refs := []*azureRef{}
+	err := p.do(http.MethodGet, p.repoPath(org, name, "refs"), query, nil, &azureList{Value: &refs})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the tags of %s/%s", org, name)
+	}
+	return refs, nil
+}
+
+// UpdateRelease checks the tag of the release exists. Azure Repos has no releases so the tags of a repository are used
+// as its releases.
+func (p *AzureDevOpsProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	refs, err := p.listTags(owner, repo)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Name == "refs/tags/"+tag {
+			log.Infof("Azure DevOps does not support release notes so the release %s is only the tag\n", util.ColorInfo(tag))
+			return nil
+		}
+	}
+	return fmt.Errorf("the tag %s of the release does not exist in %s/%s", tag, owner, repo)
+}
+
+// ListReleases lists the tags of a repository as Azure Repos has no releases
+func (p *AzureDevOpsProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
+	refs, err := p.listTags(org, name)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*GitRelease{}
+	for _, ref := range refs {
+		tag := strings.TrimPrefix(ref.Name, "refs/tags/")
+		u := p.webURL(org, name) + "?version=GT" + url.QueryEscape(tag)
+		answer = append(answer, &GitRelease{
+			Name:    tag,
+			TagName: tag,
+			URL:     u,
+			HTMLURL: u,
+		})
+	}
+	return answer, nil
+}
+
+// GetContent gets the content of a file at a branch, tag or commit. The content is base64 encoded like the content
+// returned by the other providers.
+func (p *AzureDevOpsProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
+	versionTypes := []string{"branch", "tag"}
+	if ref == "" {
+		versionTypes = []string{""}
+	} else if commitShaRegex.MatchString(ref) {
+		versionTypes = []string{"commit"}
+	}
+	var err error
+	for _, versionType := range versionTypes {
+		query := url.Values{}
+		query.Set("path", path)
+		query.Set("includeContent", "true")
+		query.Set("$format", "json")
+		if versionType != "" {
+			query.Set("versionDescriptor.version", ref)
+			query.Set("versionDescriptor.versionType", versionType)
+		}
+		item := &azureItem{}
+		err = p.do(http.MethodGet, p.repoPath(org, name, "items"), query, nil, item)
+		if err == nil {
+			fileType := "file"
+			if item.IsFolder {
+				fileType = "dir"
+			}
+			webURL := p.webURL(org, name) + "?path=" + url.QueryEscape(item.Path)
+			return &GitFileContent{
+				Type:        fileType,
+				Encoding:    "base64",
+				Size:        len(item.Content),
+				Name:        item.Path[strings.LastIndex(item.Path, "/")+1:],
+				Path:        item.Path,
+				Content:     base64.StdEncoding.EncodeToString([]byte(item.Content)),
+				Sha:         item.ObjectID,
+				Url:         item.URL,
+				HtmlUrl:     webURL,
+				DownloadUrl: item.URL,
+			}, nil
+		}
+		if !IsAzureDevOpsNotFound(err) {
+			break
+		}
+	}
+	return nil, errors.Wrapf(err, "failed to get the content of %s at %s in %s/%s", path, ref, org, name)
+}
+
+// JenkinsWebHookPath returns the path of the webhook of the Jenkins git plugin
+func (p *AzureDevOpsProvider) JenkinsWebHookPath(gitURL string, secret string) string {
+	return "/git/notifyCommit?url=" + url.QueryEscape(gitURL)
+}
+
+// Label returns the label of the server
+func (p *AzureDevOpsProvider) Label() string {
+	return p.Server.Label()
+}
+
+// ServerURL returns the URL of the organization
+func (p *AzureDevOpsProvider) ServerURL() string {
+	return p.Server.URL
+}
+
+// BranchArchiveURL returns the URL to download a ZIP archive of a branch
+func (p *AzureDevOpsProvider) BranchArchiveURL(org string, name string, branch string) string {
+	return util.UrlJoin(p.BaseURL, p.repoPath(org, name, "items")) + "?path=/&versionDescriptor.version=" + url.QueryEscape(branch) + "&$format=zip&download=true"
+}
+
+// CurrentUsername returns the current username
+func (p *AzureDevOpsProvider) CurrentUsername() string {
+	return p.Username
+}
+
+// UserAuth returns the current user auth
+func (p *AzureDevOpsProvider) UserAuth() auth.UserAuth {
+	return p.User
+}
+
+// UserInfo returns the user info of the current user. Azure DevOps cannot look up other users by their name.
+func (p *AzureDevOpsProvider) UserInfo(username string) *GitUser {
+	answer := &GitUser{
+		Login: username,
+	}
+	data := &azureConnectionData{}
+	err := p.do(http.MethodGet, "_apis/connectionData", nil, nil, data)
+	if err != nil {
+		log.Warnf("Unable to fetch user info for %s due to %s\n", username, err)
+		return answer
+	}
+	user := data.AuthenticatedUser
+	if user != nil && (username == "" || username == p.Username || username == user.Properties.Account.Value) {
+		answer.Name = user.ProviderDisplayName
+		answer.Email = user.Properties.Account.Value
+	}
+	return answer
+}
+
+// AddCollaborator is not supported as the permissions of a repository are those of its project
+func (p *AzureDevOpsProvider) AddCollaborator(user string, organisation string, repo string) error {
+	log.Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps. Please add user: %v as a contributor to the project %s.\n", user, organisation)
+	return nil
+}
+
+// ListInvitations is not supported
+func (p *AzureDevOpsProvider) ListInvitations() ([]*github.RepositoryInvitation, *github.Response, error) {
+	log.Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.\n")
+	return []*github.RepositoryInvitation{}, &github.Response{}, nil
+}
+
+// AcceptInvitation is not supported
+func (p *AzureDevOpsProvider) AcceptInvitation(ID int64) (*github.Response, error) {
+	log.Infof("Automatically adding the pipeline user as a collaborator is currently not implemented for Azure DevOps.\n")
+	return &github.Response{}, nil
+}
+
+// ShouldForkForPullRequest returns false as the contributors of a project push branches to its repositories
+func (p *AzureDevOpsProvider) ShouldForkForPullRequest(originalOwner string, repoName string, username string) bool {
+	return false
+}
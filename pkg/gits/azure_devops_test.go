@@ -0,0 +1,460 @@
+package gits_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	azureRepoPath = "/myorg/myproject/_apis/git/repositories/myrepo"
+	azureRepoID   = "5febef5a-833d-4e14-b9c0-14cb638f91e6"
+	azureSha      = "b60280bc6e62e2f880f1b63c1e24987664d3bda3"
+)
+
+type AzureDevOpsProviderTestSuite struct {
+	suite.Suite
+	mux      *http.ServeMux
+	server   *httptest.Server
+	provider *gits.AzureDevOpsProvider
+	requests []*azureRequest
+}
+
+// azureRequest is a request received by the fake Azure DevOps server
+type azureRequest struct {
+	Method        string
+	Path          string
+	Authorization string
+	Body          map[string]interface{}
+}
+
+var azureDevOpsRouter = util.Router{
+	"/myorg/_apis/projects": util.MethodMap{
+		"GET": "projects.json",
+	},
+	"/myorg/_apis/projects/myproject": util.MethodMap{
+		"GET": "project.json",
+	},
+	"/myorg/_apis/projects/otherproject": util.MethodMap{
+		"GET": "otherproject.json",
+	},
+	"/myorg/myproject/_apis/git/repositories": util.MethodMap{
+		"GET":  "repos.json",
+		"POST": "repo.json",
+	},
+	"/myorg/otherproject/_apis/git/repositories": util.MethodMap{
+		"POST": "repo-fork.json",
+	},
+	azureRepoPath: util.MethodMap{
+		"GET": "repo.json",
+	},
+	"/myorg/myproject/_apis/git/repositories/" + azureRepoID: util.MethodMap{
+		"PATCH":  "repo-renamed.json",
+		"DELETE": "empty.json",
+	},
+	azureRepoPath + "/pullrequests": util.MethodMap{
+		"GET":  "prs.json",
+		"POST": "pr.json",
+	},
+	azureRepoPath + "/pullrequests/1": util.MethodMap{
+		"GET":   "pr.json",
+		"PATCH": "pr-completed.json",
+	},
+	azureRepoPath + "/pullrequests/1/commits": util.MethodMap{
+		"GET": "pr-commits.json",
+	},
+	azureRepoPath + "/pullrequests/1/threads": util.MethodMap{
+		"POST": "thread.json",
+	},
+	azureRepoPath + "/pullrequests/1/labels": util.MethodMap{
+		"POST": "label.json",
+	},
+	azureRepoPath + "/commits": util.MethodMap{
+		"GET": "commits.json",
+	},
+	azureRepoPath + "/commits/" + azureSha + "/statuses": util.MethodMap{
+		"GET":  "statuses.json",
+		"POST": "status.json",
+	},
+	azureRepoPath + "/refs": util.MethodMap{
+		"GET": "tags.json",
+	},
+	azureRepoPath + "/items": util.MethodMap{
+		"GET": "item.json",
+	},
+	"/myorg/_apis/hooks/subscriptions": util.MethodMap{
+		"GET":  "subscriptions.json",
+		"POST": "subscription.json",
+	},
+	"/myorg/_apis/hooks/subscriptions/d4b1e7a2-5c8f-4e3b-9a6d-2f1c8b5e7a90": util.MethodMap{
+		"PUT": "subscription.json",
+	},
+	"/myorg/_apis/connectionData": util.MethodMap{
+		"GET": "connection-data.json",
+	},
+}
+
+func (suite *AzureDevOpsProviderTestSuite) SetupSuite() {
+	suite.mux = http.NewServeMux()
+
+	for path, methodMap := range azureDevOpsRouter {
+		suite.mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/azure_devops", methodMap))
+	}
+
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &azureRequest{
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Authorization: r.Header.Get("Authorization"),
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		suite.NoError(err)
+		if len(data) > 0 {
+			suite.NoError(json.Unmarshal(data, &request.Body))
+		}
+		suite.Equal("5.0", r.URL.Query().Get("api-version"))
+		suite.requests = append(suite.requests, request)
+		suite.mux.ServeHTTP(w, r)
+	}))
+	suite.Require().NotNil(suite.server)
+
+	as := auth.AuthServer{
+		URL:         suite.server.URL + "/myorg",
+		Name:        "Test Azure DevOps",
+		Kind:        gits.KindAzureDevOps,
+		CurrentUser: "test-user",
+	}
+	ua := auth.UserAuth{
+		Username: "test-user",
+		ApiToken: "0123456789abdef",
+	}
+	provider, err := gits.NewAzureDevOpsProvider(&as, &ua, gits.NewGitCLI())
+	suite.Require().NoError(err)
+
+	var ok bool
+	suite.provider, ok = provider.(*gits.AzureDevOpsProvider)
+	suite.Require().True(ok)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) SetupTest() {
+	suite.requests = nil
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+// requestsTo returns the requests received with the method and path
+func (suite *AzureDevOpsProviderTestSuite) requestsTo(method string, path string) []*azureRequest {
+	answer := []*azureRequest{}
+	for _, request := range suite.requests {
+		if request.Method == method && request.Path == path {
+			answer = append(answer, request)
+		}
+	}
+	return answer
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestBasicAuth() {
+	_, err := suite.provider.ListOrganisations()
+	suite.Require().NoError(err)
+	suite.Require().Len(suite.requests, 1)
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("test-user:0123456789abdef"))
+	suite.Equal(expected, suite.requests[0].Authorization)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestAPIMetrics() {
+	_, err := suite.provider.ListOrganisations()
+	suite.Require().NoError(err)
+	metrics := suite.provider.APIMetrics().Endpoint("GET /myorg/_apis/projects")
+	suite.True(metrics.Calls > 0)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListOrganisations() {
+	orgs, err := suite.provider.ListOrganisations()
+	suite.Require().NoError(err)
+	suite.Equal([]gits.GitOrganisation{{Login: "myproject"}, {Login: "otherproject"}}, orgs)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListRepositories() {
+	repos, err := suite.provider.ListRepositories("myproject")
+	suite.Require().NoError(err)
+	suite.Require().Len(repos, 2)
+	suite.Equal("environment-myorg-staging", repos[1].Name)
+	suite.Equal("myproject", repos[1].Organisation)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetRepository() {
+	repo, err := suite.provider.GetRepository("myproject", "myrepo")
+	suite.Require().NoError(err)
+	suite.Equal("myrepo", repo.Name)
+	suite.Equal("myproject", repo.Organisation)
+	suite.Equal("dev.azure.com", repo.Host)
+	suite.Equal("myorg", repo.ServerPath)
+	suite.Equal("https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", repo.CloneURL)
+	suite.Equal("git@ssh.dev.azure.com:v3/myorg/myproject/myrepo", repo.SSHURL)
+	suite.Equal("https://dev.azure.com/myorg/myproject/_git/myrepo", repo.HTMLURL)
+	suite.True(repo.Private)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreateRepository() {
+	repo, err := suite.provider.CreateRepository("myproject", "myrepo", true)
+	suite.Require().NoError(err)
+	suite.Equal("myrepo", repo.Name)
+
+	posts := suite.requestsTo("POST", "/myorg/myproject/_apis/git/repositories")
+	suite.Require().Len(posts, 1)
+	suite.Equal("myrepo", posts[0].Body["name"])
+	suite.Equal(map[string]interface{}{"id": "eb6e4656-77fc-42a1-9181-4c6d8e9da5d1"}, posts[0].Body["project"])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestDeleteRepository() {
+	err := suite.provider.DeleteRepository("myproject", "myrepo")
+	suite.Require().NoError(err)
+	suite.Len(suite.requestsTo("DELETE", "/myorg/myproject/_apis/git/repositories/"+azureRepoID), 1)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestRenameRepository() {
+	repo, err := suite.provider.RenameRepository("myproject", "myrepo", "myrepo-renamed")
+	suite.Require().NoError(err)
+	suite.Equal("myrepo-renamed", repo.Name)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestForkRepository() {
+	_, err := suite.provider.ForkRepository("myproject", "myrepo", "")
+	suite.Require().Error(err, "a fork must be in another project")
+
+	fork, err := suite.provider.ForkRepository("myproject", "myrepo", "otherproject")
+	suite.Require().NoError(err)
+	suite.True(fork.Fork)
+	suite.Equal("otherproject", fork.Organisation)
+	suite.False(fork.Private)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestValidateRepositoryName() {
+	err := suite.provider.ValidateRepositoryName("myproject", "myrepo")
+	suite.Error(err)
+
+	err = suite.provider.ValidateRepositoryName("myproject", "missing-repo")
+	suite.NoError(err)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreatePullRequest() {
+	pr, err := suite.provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		GitRepository: &gits.GitRepository{
+			Name:         "myrepo",
+			Organisation: "myproject",
+		},
+		Head:   "test-user:promote-myapp-0.0.2",
+		Base:   "master",
+		Title:  "Promote myapp to version 0.0.2",
+		Body:   "this commit will trigger a pipeline to promote myapp",
+		Labels: []string{"promotion"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(1, *pr.Number)
+	suite.Equal("open", *pr.State)
+	suite.Equal(suite.server.URL+"/myorg/myproject/_git/myrepo/pullrequest/1", pr.URL)
+	suite.Equal(azureSha, pr.LastCommitSha)
+	suite.Require().Len(pr.Labels, 1)
+	suite.Equal("promotion", *pr.Labels[0].Name)
+
+	posts := suite.requestsTo("POST", azureRepoPath+"/pullrequests")
+	suite.Require().Len(posts, 1)
+	suite.Equal("refs/heads/promote-myapp-0.0.2", posts[0].Body["sourceRefName"])
+	suite.Equal("refs/heads/master", posts[0].Body["targetRefName"])
+	suite.Equal([]interface{}{map[string]interface{}{"name": "promotion"}}, posts[0].Body["labels"])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetPullRequestAndCommits() {
+	repo := &gits.GitRepository{Name: "myrepo"}
+	pr, err := suite.provider.GetPullRequest("myproject", repo, 1)
+	suite.Require().NoError(err)
+	suite.Equal("promote-myapp-0.0.2", *pr.HeadRef)
+	suite.Equal("master", *pr.BaseRef)
+	suite.True(*pr.Mergeable)
+	suite.False(*pr.Merged)
+	suite.Equal("Test User", pr.Author.Name)
+
+	commits, err := suite.provider.GetPullRequestCommits("myproject", repo, 1)
+	suite.Require().NoError(err)
+	suite.Require().Len(commits, 1)
+	suite.Equal(azureSha, commits[0].SHA)
+	suite.Equal("test-user@example.com", commits[0].Author.Email)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListOpenPullRequests() {
+	prs, err := suite.provider.ListOpenPullRequests("myproject", "myrepo")
+	suite.Require().NoError(err)
+	suite.Require().Len(prs, 1)
+	suite.Equal(1, *prs[0].Number)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestMergePullRequest() {
+	number := 1
+	pr := &gits.GitPullRequest{
+		Owner:         "myproject",
+		Repo:          "myrepo",
+		Number:        &number,
+		LastCommitSha: azureSha,
+	}
+	err := suite.provider.MergePullRequest(pr, "Merged promotion")
+	suite.Require().NoError(err)
+
+	patches := suite.requestsTo("PATCH", azureRepoPath+"/pullrequests/1")
+	suite.Require().Len(patches, 1)
+	suite.Equal("completed", patches[0].Body["status"])
+	suite.Equal(map[string]interface{}{"commitId": azureSha}, patches[0].Body["lastMergeSourceCommit"])
+	suite.Equal(map[string]interface{}{"mergeCommitMessage": "Merged promotion"}, patches[0].Body["completionOptions"])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus("myproject", "myrepo", azureSha)
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 2)
+	suite.Equal("success", statuses[0].State)
+	suite.Equal("jenkins-x/pr-build", statuses[0].Context)
+	suite.Equal("pending", statuses[1].State)
+
+	number := 1
+	state, err := suite.provider.PullRequestLastCommitStatus(&gits.GitPullRequest{
+		Owner:         "myproject",
+		Repo:          "myrepo",
+		Number:        &number,
+		LastCommitSha: azureSha,
+	})
+	suite.Require().NoError(err)
+	suite.Equal("success", state)
+
+	status, err := suite.provider.UpdateCommitStatus("myproject", "myrepo", azureSha, &gits.GitRepoStatus{
+		State:       "failure",
+		Context:     "jenkins-x/pr-build",
+		Description: "Pipeline failed",
+		TargetURL:   "https://jenkins-x.example.com/builds/3",
+	})
+	suite.Require().NoError(err)
+	suite.Equal("failure", status.State)
+
+	posts := suite.requestsTo("POST", azureRepoPath+"/commits/"+azureSha+"/statuses")
+	suite.Require().Len(posts, 1)
+	suite.Equal("failed", posts[0].Body["state"])
+	suite.Equal(map[string]interface{}{"name": "pr-build", "genre": "jenkins-x"}, posts[0].Body["context"])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits("myproject", "myrepo", &gits.ListCommitsArguments{SHA: "master"})
+	suite.Require().NoError(err)
+	suite.Require().Len(commits, 1)
+	suite.Equal("chore: promote myapp to version 0.0.2", commits[0].Message)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestComments() {
+	number := 1
+	err := suite.provider.AddPRComment(&gits.GitPullRequest{Owner: "myproject", Repo: "myrepo", Number: &number}, "/approve")
+	suite.Require().NoError(err)
+
+	threads := suite.requestsTo("POST", azureRepoPath+"/pullrequests/1/threads")
+	suite.Require().Len(threads, 1)
+	comments := threads[0].Body["comments"].([]interface{})
+	suite.Require().Len(comments, 1)
+	suite.Equal("/approve", comments[0].(map[string]interface{})["content"])
+
+	err = suite.provider.AddLabelsToIssue("myproject", "myrepo", 1, []string{"updatebot"})
+	suite.Require().NoError(err)
+	suite.Len(suite.requestsTo("POST", azureRepoPath+"/pullrequests/1/labels"), 1)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestCreateWebHook() {
+	err := suite.provider.CreateWebHook(&gits.GitWebHookArguments{
+		Repo:   &gits.GitRepository{Name: "myrepo", Organisation: "myproject"},
+		URL:    "http://hook.jx.example.com/hook",
+		Secret: "s3cr3t",
+	})
+	suite.Require().NoError(err)
+
+	posts := suite.requestsTo("POST", "/myorg/_apis/hooks/subscriptions")
+	eventTypes := []string{}
+	for _, post := range posts {
+		eventTypes = append(eventTypes, post.Body["eventType"].(string))
+		suite.Equal("webHooks", post.Body["consumerId"])
+		suite.Equal(map[string]interface{}{
+			"projectId":  "eb6e4656-77fc-42a1-9181-4c6d8e9da5d1",
+			"repository": azureRepoID,
+		}, post.Body["publisherInputs"])
+		suite.Equal(map[string]interface{}{
+			"url":         "http://hook.jx.example.com/hook",
+			"httpHeaders": "Authorization: Bearer s3cr3t",
+		}, post.Body["consumerInputs"])
+	}
+	suite.NotContains(eventTypes, "git.push", "the push webhook already exists")
+	suite.Contains(eventTypes, "git.pullrequest.created")
+	suite.Contains(eventTypes, "git.pullrequest.updated")
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestListAndUpdateWebHooks() {
+	hooks, err := suite.provider.ListWebHooks("myproject", "myrepo")
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 1, "only the subscriptions of the repository are webhooks")
+	suite.Equal("http://hook.jx.example.com/hook", hooks[0].URL)
+
+	err = suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		Repo:        &gits.GitRepository{Name: "myrepo", Organisation: "myproject"},
+		ExistingURL: "http://hook.jx.example.com/hook",
+		URL:         "http://hook.jx.example.com/hook2",
+	})
+	suite.Require().NoError(err)
+	puts := suite.requestsTo("PUT", "/myorg/_apis/hooks/subscriptions/d4b1e7a2-5c8f-4e3b-9a6d-2f1c8b5e7a90")
+	suite.Require().Len(puts, 1)
+	suite.Equal(map[string]interface{}{"url": "http://hook.jx.example.com/hook2"}, puts[0].Body["consumerInputs"])
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestReleases() {
+	releases, err := suite.provider.ListReleases("myproject", "myrepo")
+	suite.Require().NoError(err)
+	suite.Require().Len(releases, 2)
+	suite.Equal("v0.0.2", releases[1].TagName)
+
+	err = suite.provider.UpdateRelease("myproject", "myrepo", "v0.0.2", &gits.GitRelease{Name: "v0.0.2"})
+	suite.NoError(err)
+
+	err = suite.provider.UpdateRelease("myproject", "myrepo", "v0.0.3", &gits.GitRelease{Name: "v0.0.3"})
+	suite.Error(err, "the tag does not exist")
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent("myproject", "myrepo", "env/requirements.yaml", "master")
+	suite.Require().NoError(err)
+	suite.Equal("requirements.yaml", content.Name)
+	suite.Equal("base64", content.Encoding)
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().NoError(err)
+	suite.Equal("dependencies:\n- name: myapp\n  version: 0.0.2\n", string(data))
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestUserInfo() {
+	user := suite.provider.UserInfo("test-user")
+	suite.Equal("Test User", user.Name)
+	suite.Equal("test-user@example.com", user.Email)
+}
+
+func (suite *AzureDevOpsProviderTestSuite) TestKind() {
+	suite.Equal(gits.KindAzureDevOps, suite.provider.Kind())
+	suite.False(suite.provider.HasIssues())
+	suite.Equal(suite.server.URL+"/myorg/_usersSettings/tokens", gits.ProviderAccessTokenURL(gits.KindAzureDevOps, suite.server.URL+"/myorg", "test-user"))
+}
+
+func TestAzureDevOpsProviderTestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping AzureDevOpsProviderTestSuite in short mode")
+	} else {
+		suite.Run(t, new(AzureDevOpsProviderTestSuite))
+	}
+}
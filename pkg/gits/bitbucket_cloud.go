@@ -2,7 +2,10 @@ package gits
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -23,6 +26,12 @@ type BitbucketCloudProvider struct {
 	Client   *bitbucket.APIClient
 	Username string
 	Context  context.Context
+	// BaseURL is the URL of the REST API used for the operations which the client library does not support
+	BaseURL string
+	// Transport sends the requests of the REST API client, caching the responses of GET requests
+	Transport http.RoundTripper
+	// Metrics records the calls made by the REST API client
+	Metrics *APIMetrics
 
 	Server auth.AuthServer
 	User   auth.UserAuth
@@ -36,6 +45,120 @@ var stateMap = map[string]string{
 	"STOPPED":    "stopped",
 }
 
+// bitbucketStateMap maps the states of commit statuses to the Bitbucket build states
+var bitbucketStateMap = map[string]string{
+	"success":     "SUCCESSFUL",
+	"failure":     "FAILED",
+	"error":       "FAILED",
+	"pending":     "INPROGRESS",
+	"in-progress": "INPROGRESS",
+	"stopped":     "STOPPED",
+}
+
+// bitbucketAuthorRegex matches the raw author of a commit such as Jane Doe <jane@example.com>
+var bitbucketAuthorRegex = regexp.MustCompile(`^\s*(.*?)\s*<([^>]*)>\s*$`)
+
+// bitbucketCloudPage is a page of the values returned by the Bitbucket Cloud REST API
+type bitbucketCloudPage struct {
+	Next   string      `json:"next"`
+	Values interface{} `json:"values"`
+}
+
+// bitbucketCloudBranchRestriction is a rule which restricts the changes to the branches matching a pattern
+type bitbucketCloudBranchRestriction struct {
+	ID              int    `json:"id,omitempty"`
+	Kind            string `json:"kind"`
+	Pattern         string `json:"pattern"`
+	BranchMatchKind string `json:"branch_match_kind,omitempty"`
+	Value           *int   `json:"value,omitempty"`
+}
+
+// bitbucketCloudProtectionKinds are the kinds of branch restrictions which protect a branch
+var bitbucketCloudProtectionKinds = []string{
+	"force",
+	"delete",
+	"require_passing_builds_to_merge",
+	"require_approvals_to_merge",
+	"reset_pullrequest_approvals_on_change",
+}
+
+type bitbucketCloudHook struct {
+	UUID        string   `json:"uuid,omitempty"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	Events      []string `json:"events"`
+}
+
+type bitbucketBuildStatus struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type bitbucketCloudCommit struct {
+	Hash    string    `json:"hash"`
+	Message string    `json:"message"`
+	Date    time.Time `json:"date"`
+	Author  struct {
+		Raw  string `json:"raw"`
+		User *struct {
+			Username    string `json:"username"`
+			Nickname    string `json:"nickname"`
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+	} `json:"author"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketCloudUser struct {
+	UUID        string `json:"uuid,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Nickname    string `json:"nickname,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Links       *struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+		Avatar struct {
+			Href string `json:"href"`
+		} `json:"avatar"`
+	} `json:"links,omitempty"`
+}
+
+type bitbucketCloudParticipant struct {
+	User           *bitbucketCloudUser `json:"user"`
+	Role           string              `json:"role"`
+	Approved       bool                `json:"approved"`
+	State          string              `json:"state"`
+	ParticipatedOn *time.Time          `json:"participated_on"`
+}
+
+type bitbucketCloudPullRequest struct {
+	Title        string                       `json:"title"`
+	Reviewers    []*bitbucketCloudUser        `json:"reviewers,omitempty"`
+	Participants []*bitbucketCloudParticipant `json:"participants,omitempty"`
+}
+
+type bitbucketCloudComment struct {
+	ID      int `json:"id,omitempty"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	CreatedOn *time.Time `json:"created_on,omitempty"`
+	Links     *struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links,omitempty"`
+}
+
 func NewBitbucketCloudProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	ctx := context.Background()
 
@@ -45,16 +168,20 @@ func NewBitbucketCloudProvider(server *auth.AuthServer, user *auth.UserAuth, git
 	}
 	basicAuthContext := context.WithValue(ctx, bitbucket.ContextBasicAuth, basicAuth)
 
+	metrics := NewAPIMetrics()
 	provider := BitbucketCloudProvider{
-		Server:   *server,
-		User:     *user,
-		Username: user.Username,
-		Context:  basicAuthContext,
-		Git:      git,
+		Transport: NewCachingTransport(http.DefaultTransport, metrics),
+		Metrics:   metrics,
+		Server:    *server,
+		User:      *user,
+		Username:  user.Username,
+		Context:   basicAuthContext,
+		Git:       git,
 	}
 
 	cfg := bitbucket.NewConfiguration()
 	provider.Client = bitbucket.NewAPIClient(cfg)
+	provider.BaseURL = cfg.BasePath
 
 	return &provider, nil
 }
@@ -656,8 +783,50 @@ func (b *BitbucketCloudProvider) ListCommitStatus(org string, repo string, sha s
 	return statuses, nil
 }
 
+// APIMetrics returns the metrics of the calls made to the Bitbucket Cloud API by the REST API client
+func (b *BitbucketCloudProvider) APIMetrics() *APIMetrics {
+	return b.Metrics
+}
+
+// rest returns the client of the REST API for the operations which the client library does not support
+func (b *BitbucketCloudProvider) rest() *restClient {
+	baseURL := b.BaseURL
+	if baseURL == "" {
+		baseURL = bitbucket.NewConfiguration().BasePath
+	}
+	return newRestClient(baseURL, b.Transport, basicAuth(b.Username, b.User.ApiToken))
+}
+
+// UpdateCommitStatus adds a build status to a commit. The context of the status is used as the key of the build.
 func (b *BitbucketCloudProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+	key := status.Context
+	if key == "" {
+		key = "jenkins-x"
+	}
+	state, ok := bitbucketStateMap[status.State]
+	if !ok {
+		return nil, fmt.Errorf("unsupported commit status state %s", status.State)
+	}
+	buildStatus := &bitbucketBuildStatus{
+		Key:         key,
+		State:       state,
+		Name:        key,
+		URL:         status.TargetURL,
+		Description: status.Description,
+	}
+	result := &bitbucketBuildStatus{}
+	err := b.rest().do(http.MethodPost, util.UrlJoin("repositories", org, repo, "commit", sha, "statuses", "build"), nil, buildStatus, result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the status of commit %s of %s/%s", sha, org, repo)
+	}
+	return &GitRepoStatus{
+		ID:          result.Key,
+		Context:     result.Key,
+		URL:         result.URL,
+		TargetURL:   result.URL,
+		State:       stateMap[result.State],
+		Description: result.Description,
+	}, nil
 }
 
 func (b *BitbucketCloudProvider) MergePullRequest(pr *GitPullRequest, message string) error {
@@ -713,15 +882,72 @@ func (b *BitbucketCloudProvider) CreateWebHook(data *GitWebHookArguments) error
 	return nil
 }
 
-// ListWebHooks lists the webhooks
+func (b *BitbucketCloudProvider) listHooks(owner string, repo string) ([]*bitbucketCloudHook, error) {
+	answer := []*bitbucketCloudHook{}
+	next := util.UrlJoin("repositories", owner, repo, "hooks")
+	for next != "" {
+		hooks := []*bitbucketCloudHook{}
+		page := &bitbucketCloudPage{Values: &hooks}
+		err := b.rest().do(http.MethodGet, next, nil, nil, page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the webhooks of %s/%s", owner, repo)
+		}
+		answer = append(answer, hooks...)
+		next = page.Next
+	}
+	return answer, nil
+}
+
+// ListWebHooks lists the webhooks. Their IDs are not set as Bitbucket Cloud identifies webhooks by UUID.
 func (b *BitbucketCloudProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	hooks, err := b.listHooks(owner, repo)
+	if err != nil {
+		return webHooks, err
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			Owner: owner,
+			Repo:  &GitRepository{Organisation: owner, Name: repo},
+			URL:   hook.URL,
+		})
+	}
+	return webHooks, nil
 }
 
-// UpdateWebHook updates the webhook
+// UpdateWebHook changes the URL of the webhooks which use the existing URL, creating the webhook if there is no
+// webhook for either URL
 func (b *BitbucketCloudProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	if data.Repo == nil {
+		return fmt.Errorf("missing property Repo")
+	}
+	owner := data.Repo.Organisation
+	repo := data.Repo.Name
+	hooks, err := b.listHooks(owner, repo)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, hook := range hooks {
+		if hook.URL == data.URL {
+			found = true
+			continue
+		}
+		if data.ExistingURL == "" || hook.URL != data.ExistingURL {
+			continue
+		}
+		hook.URL = data.URL
+		log.Infof("Updating Bitbucket Cloud webhook for %s/%s for url %s\n", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(data.URL))
+		err = b.rest().do(http.MethodPut, util.UrlJoin("repositories", owner, repo, "hooks", url.PathEscape(hook.UUID)), nil, hook, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the webhook %s of %s/%s", hook.UUID, owner, repo)
+		}
+		found = true
+	}
+	if !found {
+		return b.CreateWebHook(data)
+	}
+	return nil
 }
 
 func BitbucketIssueToGitIssue(bIssue bitbucket.Issue) *GitIssue {
@@ -997,8 +1223,28 @@ func (b *BitbucketCloudProvider) AcceptInvitation(ID int64) (*github.Response, e
 	return &github.Response{}, nil
 }
 
+// GetContent returns the base64 encoded content of a file on the given ref, which defaults to master
 func (b *BitbucketCloudProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on bitbucket")
+	if ref == "" {
+		ref = "master"
+	}
+	path = strings.TrimPrefix(path, "/")
+	u := util.UrlJoin("repositories", org, name, "src", ref, path)
+	data, err := b.rest().send(http.MethodGet, u, nil, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the content of %s on %s of %s/%s", path, ref, org, name)
+	}
+	return &GitFileContent{
+		Type:        "file",
+		Encoding:    "base64",
+		Size:        len(data),
+		Name:        path[strings.LastIndex(path, "/")+1:],
+		Path:        path,
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Url:         b.rest().url(u, nil),
+		HtmlUrl:     util.UrlJoin(b.Server.URL, org, name, "src", ref, path),
+		DownloadUrl: util.UrlJoin(b.Server.URL, org, name, "raw", ref, path),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -1017,12 +1263,321 @@ func BitBucketCloudAccessTokenURL(url string, username string) string {
 	return util.UrlJoin(url, "/account/user", username, "/app-passwords/new")
 }
 
-// ListCommits lists the commits for the specified repo and owner
+// ListCommits lists the commits for the specified repo and owner. All the pages are listed unless the arguments
+// specify the size of a page.
 func (b *BitbucketCloudProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on bitbucket")
+	if opt == nil {
+		opt = &ListCommitsArguments{}
+	}
+	query := url.Values{}
+	if opt.Path != "" {
+		query.Set("path", opt.Path)
+	}
+	if opt.PerPage > 0 {
+		query.Set("pagelen", strconv.Itoa(opt.PerPage))
+		if opt.Page > 0 {
+			query.Set("page", strconv.Itoa(opt.Page))
+		}
+	}
+	next := util.UrlJoin("repositories", owner, repo, "commits")
+	if opt.SHA != "" {
+		next = util.UrlJoin(next, opt.SHA)
+	}
+	answer := []*GitCommit{}
+	for next != "" {
+		commits := []*bitbucketCloudCommit{}
+		page := &bitbucketCloudPage{Values: &commits}
+		err := b.rest().do(http.MethodGet, next, query, nil, page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the commits of %s/%s", owner, repo)
+		}
+		for _, commit := range commits {
+			if (!opt.Since.IsZero() && commit.Date.Before(opt.Since)) || (!opt.Until.IsZero() && commit.Date.After(opt.Until)) {
+				continue
+			}
+			gitCommit := toBitbucketCloudCommit(commit)
+			if opt.Author != "" && gitCommit.Author.Login != opt.Author && gitCommit.Author.Email != opt.Author {
+				continue
+			}
+			answer = append(answer, gitCommit)
+		}
+		next = ""
+		if opt.PerPage == 0 {
+			// the next link already contains the query
+			next = page.Next
+			query = nil
+		}
+	}
+	return answer, nil
 }
 
-// AddLabelsToIssue adds labels to issues or pullrequests
+func toBitbucketCloudCommit(commit *bitbucketCloudCommit) *GitCommit {
+	author := &GitUser{
+		Name: commit.Author.Raw,
+	}
+	if arr := bitbucketAuthorRegex.FindStringSubmatch(commit.Author.Raw); arr != nil {
+		author.Name = arr[1]
+		author.Email = arr[2]
+	}
+	if commit.Author.User != nil {
+		author.Login = commit.Author.User.Username
+		if author.Login == "" {
+			author.Login = commit.Author.User.Nickname
+		}
+		if commit.Author.User.DisplayName != "" {
+			author.Name = commit.Author.User.DisplayName
+		}
+	}
+	return &GitCommit{
+		SHA:       commit.Hash,
+		Message:   commit.Message,
+		Author:    author,
+		URL:       commit.Links.HTML.Href,
+		Committer: author,
+	}
+}
+
+// AddLabelsToIssue does nothing as Bitbucket Cloud does not support labels
 func (b *BitbucketCloudProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	return fmt.Errorf("Getting content not supported on bitbucket")
+	log.Warn("Bitbucket Cloud doesn't support labels")
+	return nil
+}
+
+// ListReviews lists the participants of a pull request who approved it or requested changes
+func (b *BitbucketCloudProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	pr := &bitbucketCloudPullRequest{}
+	err := b.rest().do(http.MethodGet, util.UrlJoin("repositories", owner, repo, "pullrequests", strconv.Itoa(number)), nil, nil, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the pull request %d of %s/%s", number, owner, repo)
+	}
+	answer := []*GitReview{}
+	for _, participant := range pr.Participants {
+		review := toBitbucketCloudReview(participant)
+		if review.State != "" {
+			answer = append(answer, review)
+		}
+	}
+	return answer, nil
+}
+
+// SubmitReview approves or requests changes to a pull request and adds the body of the review as a comment
+func (b *BitbucketCloudProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	path := util.UrlJoin("repositories", owner, repo, "pullrequests", strconv.Itoa(number))
+	answer := &GitReview{
+		State: review.State,
+		Body:  review.Body,
+	}
+	action := ""
+	switch review.State {
+	case ReviewStateApproved:
+		action = "approve"
+	case ReviewStateChangesRequested:
+		action = "request-changes"
+	case ReviewStateCommented, "":
+		answer.State = ReviewStateCommented
+		if review.Body == "" {
+			return nil, fmt.Errorf("a comment on the pull request %d of %s/%s needs a body", number, owner, repo)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported review state %s", review.State)
+	}
+	if action != "" {
+		participant := &bitbucketCloudParticipant{}
+		err := b.rest().do(http.MethodPost, util.UrlJoin(path, action), nil, nil, participant)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to %s the pull request %d of %s/%s", action, number, owner, repo)
+		}
+		answer.User = toBitbucketCloudUser(participant.User)
+		answer.SubmittedAt = participant.ParticipatedOn
+	}
+	if review.Body != "" {
+		comment := &bitbucketCloudComment{}
+		comment.Content.Raw = review.Body
+		result := &bitbucketCloudComment{}
+		err := b.rest().do(http.MethodPost, util.UrlJoin(path, "comments"), nil, comment, result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to comment on the pull request %d of %s/%s", number, owner, repo)
+		}
+		answer.ID = strconv.Itoa(result.ID)
+		if answer.SubmittedAt == nil {
+			answer.SubmittedAt = result.CreatedOn
+		}
+		if result.Links != nil {
+			answer.URL = result.Links.HTML.Href
+		}
+	}
+	return answer, nil
+}
+
+// RequestReviewers adds the users, given by their username or {uuid}, to the reviewers of a pull request
+func (b *BitbucketCloudProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	path := util.UrlJoin("repositories", owner, repo, "pullrequests", strconv.Itoa(number))
+	pr := &bitbucketCloudPullRequest{}
+	err := b.rest().do(http.MethodGet, path, nil, nil, pr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the pull request %d of %s/%s", number, owner, repo)
+	}
+	update := &bitbucketCloudPullRequest{Title: pr.Title}
+	for _, reviewer := range pr.Reviewers {
+		update.Reviewers = append(update.Reviewers, &bitbucketCloudUser{UUID: reviewer.UUID})
+	}
+	for _, reviewer := range reviewers {
+		user := &bitbucketCloudUser{Username: reviewer}
+		if strings.HasPrefix(reviewer, "{") {
+			user = &bitbucketCloudUser{UUID: reviewer}
+		}
+		update.Reviewers = append(update.Reviewers, user)
+	}
+	err = b.rest().do(http.MethodPut, path, nil, update, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to request reviews of the pull request %d of %s/%s from %s", number, owner, repo, strings.Join(reviewers, ", "))
+	}
+	return nil
+}
+
+// listBranchRestrictions lists the branch restrictions of a branch which protect it
+func (b *BitbucketCloudProvider) listBranchRestrictions(owner string, repo string, branch string) ([]*bitbucketCloudBranchRestriction, error) {
+	answer := []*bitbucketCloudBranchRestriction{}
+	next := util.UrlJoin("repositories", owner, repo, "branch-restrictions")
+	query := url.Values{"pattern": []string{branch}}
+	for next != "" {
+		restrictions := []*bitbucketCloudBranchRestriction{}
+		page := &bitbucketCloudPage{Values: &restrictions}
+		err := b.rest().do(http.MethodGet, next, query, nil, page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the branch restrictions of %s/%s", owner, repo)
+		}
+		for _, restriction := range restrictions {
+			if restriction.Pattern == branch && util.StringArrayIndex(bitbucketCloudProtectionKinds, restriction.Kind) >= 0 {
+				answer = append(answer, restriction)
+			}
+		}
+		// the next link already contains the query
+		next = page.Next
+		query = nil
+	}
+	return answer, nil
+}
+
+// GetBranchProtection returns the protection of a branch or nil if the branch is not protected. Bitbucket Cloud can
+// only require a number of builds to succeed so any required contexts are returned as AnyStatusContext.
+func (b *BitbucketCloudProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	restrictions, err := b.listBranchRestrictions(owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(restrictions) == 0 {
+		return nil, nil
+	}
+	answer := &GitBranchProtection{}
+	for _, restriction := range restrictions {
+		switch restriction.Kind {
+		case "require_passing_builds_to_merge":
+			answer.RequiredContexts = []string{AnyStatusContext}
+		case "require_approvals_to_merge":
+			if restriction.Value != nil {
+				answer.RequiredApprovingReviewCount = *restriction.Value
+			}
+		case "reset_pullrequest_approvals_on_change":
+			answer.DismissStaleReviews = true
+		}
+	}
+	return answer, nil
+}
+
+// SetBranchProtection protects a branch from force pushes and deletion with branch restrictions. Any required
+// contexts require that many builds of a pull request to succeed before it can be merged.
+func (b *BitbucketCloudProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	expected := map[string]*int{
+		"force":  nil,
+		"delete": nil,
+	}
+	if builds := len(protection.RequiredContexts); builds > 0 {
+		expected["require_passing_builds_to_merge"] = &builds
+	}
+	if approvals := protection.RequiredApprovingReviewCount; approvals > 0 {
+		expected["require_approvals_to_merge"] = &approvals
+	}
+	if protection.DismissStaleReviews {
+		expected["reset_pullrequest_approvals_on_change"] = nil
+	}
+	restrictions, err := b.listBranchRestrictions(owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	path := util.UrlJoin("repositories", owner, repo, "branch-restrictions")
+	for _, restriction := range restrictions {
+		value, ok := expected[restriction.Kind]
+		switch {
+		case !ok:
+			err = b.rest().do(http.MethodDelete, util.UrlJoin(path, strconv.Itoa(restriction.ID)), nil, nil, nil)
+		case value != nil && (restriction.Value == nil || *restriction.Value != *value):
+			restriction.Value = value
+			err = b.rest().do(http.MethodPut, util.UrlJoin(path, strconv.Itoa(restriction.ID)), nil, restriction, nil)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the %s restriction of the branch %s of %s/%s", restriction.Kind, branch, owner, repo)
+		}
+		delete(expected, restriction.Kind)
+	}
+	for kind, value := range expected {
+		restriction := &bitbucketCloudBranchRestriction{
+			Kind:            kind,
+			Pattern:         branch,
+			BranchMatchKind: "glob",
+			Value:           value,
+		}
+		err = b.rest().do(http.MethodPost, path, nil, restriction, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the %s restriction to the branch %s of %s/%s", kind, branch, owner, repo)
+		}
+	}
+	return nil
+}
+
+// RemoveBranchProtection removes the branch restrictions which protect a branch
+func (b *BitbucketCloudProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	restrictions, err := b.listBranchRestrictions(owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	for _, restriction := range restrictions {
+		err = b.rest().do(http.MethodDelete, util.UrlJoin("repositories", owner, repo, "branch-restrictions", strconv.Itoa(restriction.ID)), nil, nil, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove the %s restriction of the branch %s of %s/%s", restriction.Kind, branch, owner, repo)
+		}
+	}
+	return nil
+}
+
+func toBitbucketCloudReview(participant *bitbucketCloudParticipant) *GitReview {
+	answer := &GitReview{
+		User:        toBitbucketCloudUser(participant.User),
+		SubmittedAt: participant.ParticipatedOn,
+	}
+	switch {
+	case participant.State == "changes_requested":
+		answer.State = ReviewStateChangesRequested
+	case participant.Approved || participant.State == "approved":
+		answer.State = ReviewStateApproved
+	}
+	return answer
+}
+
+func toBitbucketCloudUser(user *bitbucketCloudUser) *GitUser {
+	if user == nil {
+		return nil
+	}
+	answer := &GitUser{
+		Login: user.Username,
+		Name:  user.DisplayName,
+	}
+	if answer.Login == "" {
+		answer.Login = user.Nickname
+	}
+	if user.Links != nil {
+		answer.URL = user.Links.HTML.Href
+		answer.AvatarURL = user.Links.Avatar.Href
+	}
+	return answer
 }
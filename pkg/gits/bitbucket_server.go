@@ -2,8 +2,11 @@ package gits
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +30,10 @@ type BitbucketServerProvider struct {
 	Client   *bitbucket.APIClient
 	Username string
 	Context  context.Context
+	// Transport sends the requests of the REST API client, caching the responses of GET requests
+	Transport http.RoundTripper
+	// Metrics records the calls made by the REST API client
+	Metrics *APIMetrics
 
 	Server auth.AuthServer
 	User   auth.UserAuth
@@ -86,6 +93,62 @@ type webHooksPage struct {
 	Values        []webHook `json:"values"`
 }
 
+type participant struct {
+	User struct {
+		Name         string `json:"name"`
+		DisplayName  string `json:"displayName,omitempty"`
+		EmailAddress string `json:"emailAddress,omitempty"`
+		Slug         string `json:"slug,omitempty"`
+	} `json:"user"`
+	Role               string `json:"role,omitempty"`
+	Approved           bool   `json:"approved"`
+	Status             string `json:"status,omitempty"`
+	LastReviewedCommit string `json:"lastReviewedCommit,omitempty"`
+}
+
+type participantsPullRequest struct {
+	Reviewers []*participant `json:"reviewers"`
+}
+
+type pullRequestComment struct {
+	ID          int    `json:"id,omitempty"`
+	Text        string `json:"text"`
+	CreatedDate int64  `json:"createdDate,omitempty"`
+}
+
+// refRestriction is a branch permission which restricts the changes to the branches its matcher matches
+type refRestriction struct {
+	ID      int    `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Matcher struct {
+		ID   string `json:"id"`
+		Type struct {
+			ID string `json:"id"`
+		} `json:"type"`
+	} `json:"matcher"`
+}
+
+type refRestrictionsPage struct {
+	NextPageStart int               `json:"nextPageStart"`
+	IsLastPage    bool              `json:"isLastPage"`
+	Values        []*refRestriction `json:"values"`
+}
+
+type repositoryHook struct {
+	Enabled bool `json:"enabled"`
+}
+
+// requiredApproversSettings are the settings of the merge check which requires a number of approvals
+type requiredApproversSettings struct {
+	Enable bool        `json:"enable"`
+	Count  interface{} `json:"count,omitempty"`
+}
+
+const requiredApproversHook = "com.atlassian.bitbucket.server.bitbucket-bundled:requiredApproversMergeHook"
+
+// protectionRestrictionTypes are the types of the branch permissions which protect a branch
+var protectionRestrictionTypes = []string{"fast-forward-only", "no-deletes", "pull-request-only"}
+
 type webHook struct {
 	ID            int64                  `json:"id"`
 	Name          string                 `json:"name"`
@@ -101,12 +164,15 @@ func NewBitbucketServerProvider(server *auth.AuthServer, user *auth.UserAuth, gi
 	ctx := context.Background()
 	apiKeyAuthContext := context.WithValue(ctx, bitbucket.ContextAccessToken, user.ApiToken)
 
+	metrics := NewAPIMetrics()
 	provider := BitbucketServerProvider{
-		Server:   *server,
-		User:     *user,
-		Username: user.Username,
-		Context:  apiKeyAuthContext,
-		Git:      git,
+		Transport: NewCachingTransport(http.DefaultTransport, metrics),
+		Metrics:   metrics,
+		Server:    *server,
+		User:      *user,
+		Username:  user.Username,
+		Context:   apiKeyAuthContext,
+		Git:       git,
 	}
 
 	cfg := bitbucket.NewConfiguration(server.URL + "/rest")
@@ -697,8 +763,46 @@ func (b *BitbucketServerProvider) ListCommitStatus(org, repo, sha string) ([]*Gi
 	return statuses, nil
 }
 
+// APIMetrics returns the metrics of the calls made to the Bitbucket Server API by the REST API client
+func (b *BitbucketServerProvider) APIMetrics() *APIMetrics {
+	return b.Metrics
+}
+
+// rest returns the client of the REST API for the operations which the client library does not support
+func (b *BitbucketServerProvider) rest() *restClient {
+	return newRestClient(b.Server.URL+"/rest", b.Transport, headerAuth("Authorization", "Bearer "+b.User.ApiToken))
+}
+
+// UpdateCommitStatus adds a build status to a commit. The context of the status is used as the key of the build.
 func (b *BitbucketServerProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+	key := status.Context
+	if key == "" {
+		key = "jenkins-x"
+	}
+	// var from BitBucketCloudProvider
+	state, ok := bitbucketStateMap[status.State]
+	if !ok {
+		return nil, fmt.Errorf("unsupported commit status state %s", status.State)
+	}
+	buildStatus := &bitbucketBuildStatus{
+		Key:         key,
+		State:       state,
+		Name:        key,
+		URL:         status.TargetURL,
+		Description: status.Description,
+	}
+	err := b.rest().do(http.MethodPost, util.UrlJoin("build-status/1.0/commits", sha), nil, buildStatus, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the status of commit %s of %s/%s", sha, org, repo)
+	}
+	return &GitRepoStatus{
+		ID:          key,
+		Context:     key,
+		URL:         status.TargetURL,
+		TargetURL:   status.TargetURL,
+		State:       stateMap[state],
+		Description: status.Description,
+	}, nil
 }
 
 func convertBitBucketBuildStatusToGitStatus(buildStatus *bitbucket.BuildStatus) *GitRepoStatus {
@@ -1045,8 +1149,33 @@ func (b *BitbucketServerProvider) AcceptInvitation(ID int64) (*github.Response,
 	return &github.Response{}, nil
 }
 
+// GetContent returns the base64 encoded content of a file on the given ref, which defaults to the default branch
 func (b *BitbucketServerProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on bitbucket")
+	query := url.Values{}
+	if ref != "" {
+		query.Set("at", ref)
+	}
+	path = strings.TrimPrefix(path, "/")
+	u := util.UrlJoin("api/1.0/projects", org, "repos", name, "raw", path)
+	data, err := b.rest().send(http.MethodGet, u, query, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the content of %s of %s/%s", path, org, name)
+	}
+	browseURL := util.UrlJoin(b.Server.URL, "projects", org, "repos", name, "browse", path)
+	if ref != "" {
+		browseURL += "?at=" + url.QueryEscape(ref)
+	}
+	return &GitFileContent{
+		Type:        "file",
+		Encoding:    "base64",
+		Size:        len(data),
+		Name:        path[strings.LastIndex(path, "/")+1:],
+		Path:        path,
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Url:         b.rest().url(u, query),
+		HtmlUrl:     browseURL,
+		DownloadUrl: b.rest().url(u, query),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -1065,12 +1194,310 @@ func BitBucketServerAccessTokenURL(url string) string {
 	return util.UrlJoin(url, "/plugins/servlet/access-tokens/manage")
 }
 
-// ListCommits lists the commits for the specified repo and owner
+// ListCommits lists the commits for the specified repo and owner. All the pages are listed unless the arguments
+// specify the size of a page.
 func (b *BitbucketServerProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on bitbucket")
+	if opt == nil {
+		opt = &ListCommitsArguments{}
+	}
+	query := url.Values{}
+	if opt.SHA != "" {
+		query.Set("until", opt.SHA)
+	}
+	if opt.Path != "" {
+		query.Set("path", opt.Path)
+	}
+	limit := pageLimit
+	start := 0
+	if opt.PerPage > 0 {
+		limit = opt.PerPage
+		if opt.Page > 1 {
+			start = (opt.Page - 1) * opt.PerPage
+		}
+	}
+	repository := &GitRepository{
+		Name:    repo,
+		Project: owner,
+		URL:     util.UrlJoin(b.Server.URL, "projects", owner, "repos", repo),
+	}
+	commits := []*GitCommit{}
+	for {
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", strconv.Itoa(limit))
+		var page commitsPage
+		err := b.rest().do(http.MethodGet, util.UrlJoin("api/1.0/projects", owner, "repos", repo, "commits"), query, nil, &page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the commits of %s/%s", owner, repo)
+		}
+		for _, commit := range page.Values {
+			committed := time.Unix(0, commit.CommitterTimestamp*int64(time.Millisecond))
+			if (!opt.Since.IsZero() && committed.Before(opt.Since)) || (!opt.Until.IsZero() && committed.After(opt.Until)) {
+				continue
+			}
+			if opt.Author != "" && commit.Author.Name != opt.Author && commit.Author.Email != opt.Author {
+				continue
+			}
+			commits = append(commits, convertBitBucketCommitToGitCommit(&commit, repository))
+		}
+		if page.IsLastPage || opt.PerPage > 0 {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return commits, nil
 }
 
-// AddLabelsToIssue adds labels to issues or pullrequests
+// AddLabelsToIssue does nothing as Bitbucket Server does not support labels
 func (b *BitbucketServerProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	return fmt.Errorf("Getting content not supported on bitbucket")
+	log.Warn("Bitbucket Server doesn't support labels")
+	return nil
+}
+
+// ListReviews lists the reviewers of a pull request who approved it or said it needs work
+func (b *BitbucketServerProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	pr := &participantsPullRequest{}
+	err := b.rest().do(http.MethodGet, b.pullRequestPath(owner, repo, number), nil, nil, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the pull request %d of %s/%s", number, owner, repo)
+	}
+	answer := []*GitReview{}
+	for _, reviewer := range pr.Reviewers {
+		review := b.toReview(reviewer)
+		if review.State != "" {
+			answer = append(answer, review)
+		}
+	}
+	return answer, nil
+}
+
+// SubmitReview sets the status of the current user on a pull request and adds the body of the review as a comment
+func (b *BitbucketServerProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	path := b.pullRequestPath(owner, repo, number)
+	answer := &GitReview{
+		State: review.State,
+		Body:  review.Body,
+	}
+	status := ""
+	switch review.State {
+	case ReviewStateApproved:
+		status = "APPROVED"
+	case ReviewStateChangesRequested:
+		status = "NEEDS_WORK"
+	case ReviewStateCommented, "":
+		answer.State = ReviewStateCommented
+		if review.Body == "" {
+			return nil, fmt.Errorf("a comment on the pull request %d of %s/%s needs a body", number, owner, repo)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported review state %s", review.State)
+	}
+	if status != "" {
+		request := &participant{
+			Approved: status == "APPROVED",
+			Status:   status,
+		}
+		request.User.Name = b.Username
+		result := &participant{}
+		err := b.rest().do(http.MethodPut, util.UrlJoin(path, "participants", url.PathEscape(b.Username)), nil, request, result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set the status of %s on the pull request %d of %s/%s to %s", b.Username, number, owner, repo, status)
+		}
+		answer.User = b.toReview(result).User
+		answer.CommitSHA = result.LastReviewedCommit
+	}
+	if review.Body != "" {
+		result := &pullRequestComment{}
+		err := b.rest().do(http.MethodPost, util.UrlJoin(path, "comments"), nil, &pullRequestComment{Text: review.Body}, result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to comment on the pull request %d of %s/%s", number, owner, repo)
+		}
+		answer.ID = strconv.Itoa(result.ID)
+		if result.CreatedDate > 0 {
+			submitted := time.Unix(0, result.CreatedDate*int64(time.Millisecond))
+			answer.SubmittedAt = &submitted
+		}
+	}
+	return answer, nil
+}
+
+// RequestReviewers adds the users to the reviewers of a pull request
+func (b *BitbucketServerProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	path := util.UrlJoin(b.pullRequestPath(owner, repo, number), "participants")
+	for _, reviewer := range reviewers {
+		request := &participant{Role: "REVIEWER"}
+		request.User.Name = reviewer
+		err := b.rest().do(http.MethodPost, path, nil, request, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to request a review of the pull request %d of %s/%s from %s", number, owner, repo, reviewer)
+		}
+	}
+	return nil
+}
+
+func (b *BitbucketServerProvider) restrictionsPath(owner string, repo string) string {
+	return util.UrlJoin("branch-permissions/2.0/projects", owner, "repos", repo, "restrictions")
+}
+
+func (b *BitbucketServerProvider) requiredApproversPath(owner string, repo string) string {
+	return util.UrlJoin("api/1.0/projects", owner, "repos", repo, "settings/hooks", requiredApproversHook)
+}
+
+// listRestrictions lists the branch permissions of a branch which protect it
+func (b *BitbucketServerProvider) listRestrictions(owner string, repo string, branch string) ([]*refRestriction, error) {
+	answer := []*refRestriction{}
+	query := url.Values{
+		"matcherType": []string{"BRANCH"},
+		"matcherId":   []string{"refs/heads/" + branch},
+	}
+	for {
+		page := &refRestrictionsPage{}
+		err := b.rest().do(http.MethodGet, b.restrictionsPath(owner, repo), query, nil, page)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the branch permissions of %s/%s", owner, repo)
+		}
+		for _, restriction := range page.Values {
+			if util.StringArrayIndex(protectionRestrictionTypes, restriction.Type) >= 0 {
+				answer = append(answer, restriction)
+			}
+		}
+		if page.IsLastPage || len(page.Values) == 0 {
+			return answer, nil
+		}
+		query.Set("start", strconv.Itoa(page.NextPageStart))
+	}
+}
+
+// requiredApprovals returns the number of approvals the merge check of the repository requires, or 0 if it is
+// disabled
+func (b *BitbucketServerProvider) requiredApprovals(owner string, repo string) (int, error) {
+	hook := &repositoryHook{}
+	err := b.rest().do(http.MethodGet, b.requiredApproversPath(owner, repo), nil, nil, hook)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get the required approvers merge check of %s/%s", owner, repo)
+	}
+	if !hook.Enabled {
+		return 0, nil
+	}
+	settings := &requiredApproversSettings{}
+	err = b.rest().do(http.MethodGet, util.UrlJoin(b.requiredApproversPath(owner, repo), "settings"), nil, nil, settings)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get the settings of the required approvers merge check of %s/%s", owner, repo)
+	}
+	if settings.Count == nil {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(fmt.Sprint(settings.Count))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid number of required approvers %v of %s/%s", settings.Count, owner, repo)
+	}
+	return count, nil
+}
+
+// GetBranchProtection returns the protection of a branch or nil if the branch is not protected. The number of
+// approvals required by the merge check of the repository applies to all of its branches.
+func (b *BitbucketServerProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	restrictions, err := b.listRestrictions(owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(restrictions) == 0 {
+		return nil, nil
+	}
+	approvals, err := b.requiredApprovals(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return &GitBranchProtection{
+		RequiredApprovingReviewCount: approvals,
+	}, nil
+}
+
+// SetBranchProtection protects a branch with branch permissions which prevent changes without a pull request,
+// rewriting its history and deleting it. The number of approvals is required by the merge check of the repository
+// which applies to all of its branches.
+func (b *BitbucketServerProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	restrictions, err := b.listRestrictions(owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	for _, restrictionType := range protectionRestrictionTypes {
+		found := false
+		for _, restriction := range restrictions {
+			if restriction.Type == restrictionType {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		restriction := &refRestriction{Type: restrictionType}
+		restriction.Matcher.ID = "refs/heads/" + branch
+		restriction.Matcher.Type.ID = "BRANCH"
+		err = b.rest().do(http.MethodPost, b.restrictionsPath(owner, repo), nil, restriction, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add the %s permission to the branch %s of %s/%s", restrictionType, branch, owner, repo)
+		}
+	}
+
+	approvals, err := b.requiredApprovals(owner, repo)
+	if err != nil {
+		return err
+	}
+	enabledPath := util.UrlJoin(b.requiredApproversPath(owner, repo), "enabled")
+	switch {
+	case protection.RequiredApprovingReviewCount == approvals:
+		return nil
+	case protection.RequiredApprovingReviewCount == 0:
+		err = b.rest().do(http.MethodDelete, enabledPath, nil, nil, nil)
+	default:
+		err = b.rest().do(http.MethodPut, enabledPath, nil, &requiredApproversSettings{
+			Enable: true,
+			Count:  strconv.Itoa(protection.RequiredApprovingReviewCount),
+		}, nil)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the required approvers merge check of %s/%s", owner, repo)
+	}
+	return nil
+}
+
+// RemoveBranchProtection removes the branch permissions which protect a branch. The merge check which requires
+// approvals is left as it is as it applies to all the branches of the repository.
+func (b *BitbucketServerProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	restrictions, err := b.listRestrictions(owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	for _, restriction := range restrictions {
+		err = b.rest().do(http.MethodDelete, util.UrlJoin(b.restrictionsPath(owner, repo), strconv.Itoa(restriction.ID)), nil, nil, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove the %s permission of the branch %s of %s/%s", restriction.Type, branch, owner, repo)
+		}
+	}
+	return nil
+}
+
+func (b *BitbucketServerProvider) pullRequestPath(owner string, repo string, number int) string {
+	return util.UrlJoin("api/1.0/projects", owner, "repos", repo, "pull-requests", strconv.Itoa(number))
+}
+
+func (b *BitbucketServerProvider) toReview(reviewer *participant) *GitReview {
+	answer := &GitReview{
+		User: &GitUser{
+			Login: reviewer.User.Name,
+			Name:  reviewer.User.DisplayName,
+			Email: reviewer.User.EmailAddress,
+		},
+		CommitSHA: reviewer.LastReviewedCommit,
+	}
+	if reviewer.User.Slug != "" {
+		answer.User.URL = util.UrlJoin(b.Server.URL, "users", reviewer.User.Slug)
+	}
+	switch {
+	case reviewer.Status == "NEEDS_WORK":
+		answer.State = ReviewStateChangesRequested
+	case reviewer.Approved || reviewer.Status == "APPROVED":
+		answer.State = ReviewStateApproved
+	}
+	return answer
 }
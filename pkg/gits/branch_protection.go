@@ -0,0 +1,136 @@
+package gits
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AnyStatusContext is the required context of the branch protection of git providers which cannot require named
+// status contexts but can require all the builds of a pull request to succeed
+const AnyStatusContext = "*"
+
+// branchProtectionFeatures are the branch protection settings which a kind of git provider can enforce
+type branchProtectionFeatures struct {
+	namedContexts bool
+	anyContext    bool
+	strict        bool
+	approvals     bool
+	dismissStale  bool
+	codeOwners    bool
+	enforceAdmins bool
+}
+
+var branchProtectionSupport = map[string]branchProtectionFeatures{
+	KindGitHub:          {namedContexts: true, strict: true, approvals: true, dismissStale: true, codeOwners: true, enforceAdmins: true},
+	KindGitFake:         {namedContexts: true, strict: true, approvals: true, dismissStale: true, codeOwners: true, enforceAdmins: true},
+	KindGitlab:          {anyContext: true, approvals: true, dismissStale: true},
+	KindBitBucketCloud:  {anyContext: true, approvals: true, dismissStale: true},
+	KindBitBucketServer: {approvals: true},
+}
+
+// SupportedBranchProtection returns the protection which git providers of the kind can enforce along with the names
+// of the settings of the protection which they cannot. Providers which can only require all the builds of a pull
+// request to succeed have their required contexts replaced by AnyStatusContext.
+func SupportedBranchProtection(kind string, protection *GitBranchProtection) (*GitBranchProtection, []string) {
+	if protection == nil {
+		return nil, nil
+	}
+	features := branchProtectionSupport[kind]
+	answer := &GitBranchProtection{}
+	unsupported := []string{}
+	if len(protection.RequiredContexts) > 0 {
+		switch {
+		case features.namedContexts:
+			answer.RequiredContexts = append([]string{}, protection.RequiredContexts...)
+		case features.anyContext:
+			answer.RequiredContexts = []string{AnyStatusContext}
+		default:
+			unsupported = append(unsupported, "required contexts")
+		}
+	}
+	if protection.Strict {
+		if features.strict {
+			answer.Strict = true
+		} else {
+			unsupported = append(unsupported, "strict")
+		}
+	}
+	if protection.RequiredApprovingReviewCount > 0 {
+		if features.approvals {
+			answer.RequiredApprovingReviewCount = protection.RequiredApprovingReviewCount
+		} else {
+			unsupported = append(unsupported, "required approving reviews")
+		}
+	}
+	if protection.DismissStaleReviews {
+		if features.dismissStale {
+			answer.DismissStaleReviews = true
+		} else {
+			unsupported = append(unsupported, "dismiss stale reviews")
+		}
+	}
+	if protection.RequireCodeOwnerReviews {
+		if features.codeOwners {
+			answer.RequireCodeOwnerReviews = true
+		} else {
+			unsupported = append(unsupported, "require code owner reviews")
+		}
+	}
+	if protection.EnforceAdmins {
+		if features.enforceAdmins {
+			answer.EnforceAdmins = true
+		} else {
+			unsupported = append(unsupported, "enforce admins")
+		}
+	}
+	return answer, unsupported
+}
+
+// DiffBranchProtection describes how the actual protection of a branch differs from the expected protection, where
+// nil means the branch is not protected
+func DiffBranchProtection(expected *GitBranchProtection, actual *GitBranchProtection) []string {
+	switch {
+	case expected == nil && actual == nil:
+		return nil
+	case expected == nil:
+		return []string{"the branch is protected"}
+	case actual == nil:
+		return []string{"the branch is not protected"}
+	}
+	answer := []string{}
+	expectedContexts := sortedContexts(expected.RequiredContexts)
+	actualContexts := sortedContexts(actual.RequiredContexts)
+	if expectedContexts != actualContexts {
+		answer = append(answer, fmt.Sprintf("required contexts are %s instead of %s", describeContexts(actualContexts), describeContexts(expectedContexts)))
+	}
+	if expected.Strict != actual.Strict {
+		answer = append(answer, fmt.Sprintf("strict is %t instead of %t", actual.Strict, expected.Strict))
+	}
+	if expected.RequiredApprovingReviewCount != actual.RequiredApprovingReviewCount {
+		answer = append(answer, fmt.Sprintf("required approving reviews are %d instead of %d", actual.RequiredApprovingReviewCount, expected.RequiredApprovingReviewCount))
+	}
+	if expected.DismissStaleReviews != actual.DismissStaleReviews {
+		answer = append(answer, fmt.Sprintf("dismiss stale reviews is %t instead of %t", actual.DismissStaleReviews, expected.DismissStaleReviews))
+	}
+	if expected.RequireCodeOwnerReviews != actual.RequireCodeOwnerReviews {
+		answer = append(answer, fmt.Sprintf("require code owner reviews is %t instead of %t", actual.RequireCodeOwnerReviews, expected.RequireCodeOwnerReviews))
+	}
+	if expected.EnforceAdmins != actual.EnforceAdmins {
+		answer = append(answer, fmt.Sprintf("enforce admins is %t instead of %t", actual.EnforceAdmins, expected.EnforceAdmins))
+	}
+	return answer
+}
+
+func sortedContexts(contexts []string) string {
+	sorted := append([]string{}, contexts...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+func describeContexts(contexts string) string {
+	if contexts == "" {
+		return "none"
+	}
+	return contexts
+}
@@ -0,0 +1,64 @@
+package gits_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportedBranchProtection(t *testing.T) {
+	protection := &gits.GitBranchProtection{
+		RequiredContexts:             []string{"unit", "integration"},
+		Strict:                       true,
+		RequiredApprovingReviewCount: 2,
+		DismissStaleReviews:          true,
+		EnforceAdmins:                true,
+	}
+
+	answer, unsupported := gits.SupportedBranchProtection(gits.KindGitHub, protection)
+	assert.Equal(t, protection, answer)
+	assert.Empty(t, unsupported)
+
+	answer, unsupported = gits.SupportedBranchProtection(gits.KindGitlab, protection)
+	assert.Equal(t, &gits.GitBranchProtection{
+		RequiredContexts:             []string{gits.AnyStatusContext},
+		RequiredApprovingReviewCount: 2,
+		DismissStaleReviews:          true,
+	}, answer)
+	assert.Equal(t, []string{"strict", "enforce admins"}, unsupported)
+
+	answer, unsupported = gits.SupportedBranchProtection(gits.KindBitBucketServer, protection)
+	assert.Equal(t, &gits.GitBranchProtection{RequiredApprovingReviewCount: 2}, answer)
+	assert.Equal(t, []string{"required contexts", "strict", "dismiss stale reviews", "enforce admins"}, unsupported)
+
+	answer, unsupported = gits.SupportedBranchProtection(gits.KindGitHub, nil)
+	assert.Nil(t, answer)
+	assert.Empty(t, unsupported)
+}
+
+func TestDiffBranchProtection(t *testing.T) {
+	expected := &gits.GitBranchProtection{
+		RequiredContexts:             []string{"unit", "integration"},
+		RequiredApprovingReviewCount: 1,
+	}
+
+	assert.Empty(t, gits.DiffBranchProtection(nil, nil))
+	assert.Equal(t, []string{"the branch is protected"}, gits.DiffBranchProtection(nil, expected))
+	assert.Equal(t, []string{"the branch is not protected"}, gits.DiffBranchProtection(expected, nil))
+
+	// the order of the contexts does not matter
+	assert.Empty(t, gits.DiffBranchProtection(expected, &gits.GitBranchProtection{
+		RequiredContexts:             []string{"integration", "unit"},
+		RequiredApprovingReviewCount: 1,
+	}))
+
+	assert.Equal(t, []string{
+		"required contexts are unit instead of integration, unit",
+		"required approving reviews are 0 instead of 1",
+		"enforce admins is true instead of false",
+	}, gits.DiffBranchProtection(expected, &gits.GitBranchProtection{
+		RequiredContexts: []string{"unit"},
+		EnforceAdmins:    true,
+	}))
+}
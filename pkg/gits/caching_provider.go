@@ -0,0 +1,256 @@
+package gits
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a CachingProvider caches the results of the read only calls
+const DefaultCacheTTL = 10 * time.Second
+
+// APIMetricsProvider is implemented by the git providers which record metrics of the calls made to their API
+type APIMetricsProvider interface {
+	// APIMetrics returns the metrics of the calls made to the API
+	APIMetrics() *APIMetrics
+}
+
+// CachingProvider is a GitProvider which caches the results of the read only calls such as GetRepository,
+// ListReleases and GetContent of the provider it decorates and records metrics for each call. The state of pull
+// requests and commit statuses changes too often to cache so those calls always go to the provider.
+// Changes made to a repository through the CachingProvider invalidate the cached results of that repository.
+type CachingProvider struct {
+	GitProvider
+
+	// TTL is how long the results are cached
+	TTL time.Duration
+
+	metrics *APIMetrics
+	lock    sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewCachingProvider decorates the provider with a cache, sharing the metrics of the provider if it records any
+func NewCachingProvider(provider GitProvider) *CachingProvider {
+	var metrics *APIMetrics
+	if metricsProvider, ok := provider.(APIMetricsProvider); ok {
+		metrics = metricsProvider.APIMetrics()
+	}
+	if metrics == nil {
+		metrics = NewAPIMetrics()
+	}
+	return &CachingProvider{
+		GitProvider: provider,
+		TTL:         DefaultCacheTTL,
+		metrics:     metrics,
+		entries:     map[string]*cacheEntry{},
+	}
+}
+
+// cachingOrganisationChecker is a CachingProvider of a provider which can check the members of organisations
+type cachingOrganisationChecker struct {
+	*CachingProvider
+	checker OrganisationChecker
+}
+
+// IsUserInOrganisation checks the membership with the decorated provider
+func (p *cachingOrganisationChecker) IsUserInOrganisation(user string, organisation string) (bool, error) {
+	return p.checker.IsUserInOrganisation(user, organisation)
+}
+
+// WithCache decorates the provider with a cache. Unlike NewCachingProvider the returned provider implements the
+// optional interfaces such as OrganisationChecker which the decorated provider implements.
+func WithCache(provider GitProvider) GitProvider {
+	cachingProvider := NewCachingProvider(provider)
+	if checker, ok := provider.(OrganisationChecker); ok {
+		return &cachingOrganisationChecker{
+			CachingProvider: cachingProvider,
+			checker:         checker,
+		}
+	}
+	return cachingProvider
+}
+
+// UnwrapProvider returns the provider decorated by a CachingProvider or the provider itself otherwise
+func UnwrapProvider(provider GitProvider) GitProvider {
+	switch cachingProvider := provider.(type) {
+	case *CachingProvider:
+		return cachingProvider.GitProvider
+	case *cachingOrganisationChecker:
+		return cachingProvider.GitProvider
+	}
+	return provider
+}
+
+// APIMetrics returns the metrics of the calls of the provider and of its API
+func (p *CachingProvider) APIMetrics() *APIMetrics {
+	return p.metrics
+}
+
+// GetRepository returns the cached repository or gets it from the provider
+func (p *CachingProvider) GetRepository(org string, name string) (*GitRepository, error) {
+	value, err := p.cached("GetRepository", cacheKey(org, name, "repository"), func() (interface{}, error) {
+		return p.GitProvider.GetRepository(org, name)
+	})
+	repo, _ := value.(*GitRepository)
+	if repo == nil {
+		return nil, err
+	}
+	clone := *repo
+	return &clone, err
+}
+
+// ListReleases returns the cached releases or lists them with the provider
+func (p *CachingProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
+	value, err := p.cached("ListReleases", cacheKey(org, name, "releases"), func() (interface{}, error) {
+		return p.GitProvider.ListReleases(org, name)
+	})
+	releases, _ := value.([]*GitRelease)
+	if releases == nil {
+		return nil, err
+	}
+	answer := []*GitRelease{}
+	for _, release := range releases {
+		clone := *release
+		answer = append(answer, &clone)
+	}
+	return answer, err
+}
+
+// GetContent returns the cached content of a file or gets it from the provider
+func (p *CachingProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
+	value, err := p.cached("GetContent", cacheKey(org, name, "content", path, ref), func() (interface{}, error) {
+		return p.GitProvider.GetContent(org, name, path, ref)
+	})
+	content, _ := value.(*GitFileContent)
+	if content == nil {
+		return nil, err
+	}
+	clone := *content
+	return &clone, err
+}
+
+// CreateRepository creates a repository and invalidates the cached results of the repository
+func (p *CachingProvider) CreateRepository(org string, name string, private bool) (*GitRepository, error) {
+	p.invalidate(org, name)
+	return p.GitProvider.CreateRepository(org, name, private)
+}
+
+// DeleteRepository deletes a repository and invalidates its cached results
+func (p *CachingProvider) DeleteRepository(org string, name string) error {
+	defer p.invalidate(org, name)
+	return p.GitProvider.DeleteRepository(org, name)
+}
+
+// ForkRepository forks a repository and invalidates the cached results of the fork
+func (p *CachingProvider) ForkRepository(originalOrg string, name string, destinationOrg string) (*GitRepository, error) {
+	defer p.invalidate(destinationOrg, name)
+	return p.GitProvider.ForkRepository(originalOrg, name, destinationOrg)
+}
+
+// RenameRepository renames a repository and invalidates the cached results of both names
+func (p *CachingProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
+	defer p.invalidate(org, name)
+	defer p.invalidate(org, newName)
+	return p.GitProvider.RenameRepository(org, name, newName)
+}
+
+// CreatePullRequest creates a pull request and invalidates the cached results of its repository
+func (p *CachingProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
+	if data.GitRepository != nil {
+		defer p.invalidate(data.GitRepository.Organisation, data.GitRepository.Name)
+	}
+	return p.GitProvider.CreatePullRequest(data)
+}
+
+// UpdatePullRequestStatus refreshes a pull request and invalidates the cached results of its repository
+func (p *CachingProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
+	defer p.invalidate(pr.Owner, pr.Repo)
+	return p.GitProvider.UpdatePullRequestStatus(pr)
+}
+
+// MergePullRequest merges a pull request and invalidates the cached results of its repository
+func (p *CachingProvider) MergePullRequest(pr *GitPullRequest, message string) error {
+	defer p.invalidate(pr.Owner, pr.Repo)
+	return p.GitProvider.MergePullRequest(pr, message)
+}
+
+// AddLabelsToIssue adds labels to an issue or pull request and invalidates the cached results of its repository
+func (p *CachingProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
+	defer p.invalidate(owner, repo)
+	return p.GitProvider.AddLabelsToIssue(owner, repo, number, labels)
+}
+
+// SubmitReview reviews a pull request and invalidates the cached results of its repository
+func (p *CachingProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	defer p.invalidate(owner, repo)
+	return p.GitProvider.SubmitReview(owner, repo, number, review)
+}
+
+// RequestReviewers requests reviews of a pull request and invalidates the cached results of its repository
+func (p *CachingProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	defer p.invalidate(owner, repo)
+	return p.GitProvider.RequestReviewers(owner, repo, number, reviewers)
+}
+
+// UpdateRelease updates a release and invalidates the cached results of its repository
+func (p *CachingProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	defer p.invalidate(owner, repo)
+	return p.GitProvider.UpdateRelease(owner, repo, tag, releaseInfo)
+}
+
+// cached returns the unexpired cached value of the key or loads and caches it
+func (p *CachingProvider) cached(method string, key string, load func() (interface{}, error)) (interface{}, error) {
+	start := time.Now()
+	p.lock.Lock()
+	entry := p.entries[key]
+	p.lock.Unlock()
+	if entry != nil && time.Now().Before(entry.expires) {
+		p.metrics.record(method, func(metrics *EndpointMetrics) {
+			metrics.Calls++
+			metrics.CacheHits++
+			metrics.Duration += time.Since(start)
+		})
+		return entry.value, nil
+	}
+
+	value, err := load()
+	if err == nil && p.TTL > 0 {
+		p.lock.Lock()
+		p.entries[key] = &cacheEntry{
+			value:   value,
+			expires: time.Now().Add(p.TTL),
+		}
+		p.lock.Unlock()
+	}
+	p.metrics.record(method, func(metrics *EndpointMetrics) {
+		metrics.Calls++
+		metrics.Duration += time.Since(start)
+		if err != nil {
+			metrics.Errors++
+		}
+	})
+	return value, err
+}
+
+// invalidate removes the cached results of a repository
+func (p *CachingProvider) invalidate(org string, name string) {
+	prefix := cacheKey(org, name)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for key := range p.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.entries, key)
+		}
+	}
+}
+
+// cacheKey returns the key of a cached result of a repository
+func cacheKey(org string, name string, elements ...string) string {
+	return strings.Join(append([]string{strings.ToLower(org), strings.ToLower(name)}, elements...), "\x00") + "\x00"
+}
@@ -0,0 +1,136 @@
+package gits_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider counts the calls of the GitProvider methods which the CachingProvider caches
+type countingProvider struct {
+	gits.GitProvider
+	calls map[string]int
+}
+
+func (p *countingProvider) GetRepository(org string, name string) (*gits.GitRepository, error) {
+	p.calls["GetRepository"]++
+	return &gits.GitRepository{Organisation: org, Name: name}, nil
+}
+
+func (p *countingProvider) ListReleases(org string, name string) ([]*gits.GitRelease, error) {
+	p.calls["ListReleases"]++
+	return []*gits.GitRelease{{TagName: "v1.0.0"}}, nil
+}
+
+func (p *countingProvider) GetPullRequest(owner string, repo *gits.GitRepository, number int) (*gits.GitPullRequest, error) {
+	p.calls["GetPullRequest"]++
+	return &gits.GitPullRequest{Owner: owner, Repo: repo.Name, Number: &number}, nil
+}
+
+func (p *countingProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *gits.GitRelease) error {
+	p.calls["UpdateRelease"]++
+	return nil
+}
+
+func TestCachingProviderCachesReadOnlyCalls(t *testing.T) {
+	t.Parallel()
+	counting := &countingProvider{calls: map[string]int{}}
+	provider := gits.NewCachingProvider(counting)
+
+	for i := 0; i < 3; i++ {
+		repo, err := provider.GetRepository("myorg", "myrepo")
+		require.NoError(t, err)
+		assert.Equal(t, "myrepo", repo.Name)
+		// changing the result must not change the cached repository
+		repo.Name = "changed"
+	}
+	_, err := provider.GetRepository("myorg", "other")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, counting.calls["GetRepository"])
+	metrics := provider.APIMetrics().Endpoint("GetRepository")
+	assert.Equal(t, 4, metrics.Calls)
+	assert.Equal(t, 2, metrics.CacheHits)
+}
+
+func TestCachingProviderInvalidatesOnChanges(t *testing.T) {
+	t.Parallel()
+	counting := &countingProvider{calls: map[string]int{}}
+	provider := gits.NewCachingProvider(counting)
+
+	_, err := provider.ListReleases("myorg", "myrepo")
+	require.NoError(t, err)
+	_, err = provider.ListReleases("myorg", "myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, 1, counting.calls["ListReleases"])
+
+	err = provider.UpdateRelease("myorg", "myrepo", "v1.0.0", &gits.GitRelease{TagName: "v1.0.0"})
+	require.NoError(t, err)
+	_, err = provider.ListReleases("myorg", "myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, counting.calls["ListReleases"])
+	assert.Equal(t, 1, counting.calls["UpdateRelease"])
+}
+
+func TestCachingProviderExpiresResults(t *testing.T) {
+	t.Parallel()
+	counting := &countingProvider{calls: map[string]int{}}
+	provider := gits.NewCachingProvider(counting)
+	provider.TTL = time.Millisecond
+
+	_, err := provider.GetRepository("myorg", "myrepo")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = provider.GetRepository("myorg", "myrepo")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, counting.calls["GetRepository"])
+	assert.Equal(t, counting, gits.UnwrapProvider(provider))
+}
+
+func TestCachingProviderDoesNotCachePullRequests(t *testing.T) {
+	t.Parallel()
+	counting := &countingProvider{calls: map[string]int{}}
+	provider := gits.NewCachingProvider(counting)
+
+	for i := 0; i < 2; i++ {
+		_, err := provider.GetPullRequest("myorg", &gits.GitRepository{Name: "myrepo"}, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, counting.calls["GetPullRequest"])
+}
+
+// checkingProvider is a countingProvider which can check the members of organisations
+type checkingProvider struct {
+	countingProvider
+}
+
+func (p *checkingProvider) IsUserInOrganisation(user string, organisation string) (bool, error) {
+	p.calls["IsUserInOrganisation"]++
+	return user == "member", nil
+}
+
+func TestWithCacheKeepsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+	checking := &checkingProvider{countingProvider{calls: map[string]int{}}}
+	provider := gits.WithCache(checking)
+
+	checker, ok := provider.(gits.OrganisationChecker)
+	require.True(t, ok, "the cached provider should check the members of organisations")
+	member, err := checker.IsUserInOrganisation("member", "myorg")
+	require.NoError(t, err)
+	assert.True(t, member)
+	member, err = checker.IsUserInOrganisation("stranger", "myorg")
+	require.NoError(t, err)
+	assert.False(t, member)
+	assert.Equal(t, 2, checking.calls["IsUserInOrganisation"])
+	_, ok = provider.(gits.APIMetricsProvider)
+	assert.True(t, ok)
+	assert.Equal(t, checking, gits.UnwrapProvider(provider))
+
+	_, ok = gits.WithCache(&countingProvider{calls: map[string]int{}}).(gits.OrganisationChecker)
+	assert.False(t, ok, "the cached provider should not check memberships if the provider cannot")
+}
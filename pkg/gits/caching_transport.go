@@ -0,0 +1,357 @@
+package gits
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/log"
+)
+
+const (
+	// DefaultRateLimitMaxWait is the longest time to wait for the rate limit of a git provider API to reset
+	DefaultRateLimitMaxWait = 5 * time.Minute
+
+	// maxCachedResponses is the number of responses kept by a CachingTransport
+	maxCachedResponses = 1000
+)
+
+// APIMetrics records the calls made to the API of a git provider per endpoint
+type APIMetrics struct {
+	lock      sync.Mutex
+	endpoints map[string]*EndpointMetrics
+}
+
+// EndpointMetrics are the metrics of the calls made to an API endpoint or a GitProvider method
+type EndpointMetrics struct {
+	Endpoint string
+	// Calls is the number of calls made
+	Calls int
+	// CacheHits is the number of calls answered from the cache
+	CacheHits int
+	// Errors is the number of calls which failed
+	Errors int
+	// RateLimitWaits is the number of times a call had to wait for the rate limit to reset
+	RateLimitWaits int
+	// Duration is the total time spent on the calls
+	Duration time.Duration
+}
+
+// NewAPIMetrics creates an empty set of API metrics
+func NewAPIMetrics() *APIMetrics {
+	return &APIMetrics{
+		endpoints: map[string]*EndpointMetrics{},
+	}
+}
+
+func (m *APIMetrics) record(endpoint string, fn func(metrics *EndpointMetrics)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	metrics := m.endpoints[endpoint]
+	if metrics == nil {
+		metrics = &EndpointMetrics{Endpoint: endpoint}
+		m.endpoints[endpoint] = metrics
+	}
+	fn(metrics)
+}
+
+// Endpoints returns a copy of the metrics of each endpoint sorted by endpoint
+func (m *APIMetrics) Endpoints() []EndpointMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	answer := []EndpointMetrics{}
+	for _, metrics := range m.endpoints {
+		answer = append(answer, *metrics)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Endpoint < answer[j].Endpoint
+	})
+	return answer
+}
+
+// Endpoint returns a copy of the metrics of an endpoint
+func (m *APIMetrics) Endpoint(endpoint string) EndpointMetrics {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	metrics := m.endpoints[endpoint]
+	if metrics == nil {
+		return EndpointMetrics{Endpoint: endpoint}
+	}
+	return *metrics
+}
+
+// CachingTransport is a http.RoundTripper for git provider APIs which caches the responses of GET requests and
+// revalidates them with conditional requests using their ETag or Last-Modified headers. It waits for the rate limit
+// to reset when the rate limit headers of a response say it is exhausted.
+type CachingTransport struct {
+	// Next is the transport which sends the requests, http.DefaultTransport if nil
+	Next http.RoundTripper
+	// Metrics records the calls per endpoint
+	Metrics *APIMetrics
+	// MaxWait is the longest time to wait for the rate limit to reset
+	MaxWait time.Duration
+
+	lock      sync.Mutex
+	responses map[string]*cachedResponse
+	resetAt   time.Time
+}
+
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// NewCachingTransport creates a transport which caches the responses of the next transport
+func NewCachingTransport(next http.RoundTripper, metrics *APIMetrics) *CachingTransport {
+	if metrics == nil {
+		metrics = NewAPIMetrics()
+	}
+	return &CachingTransport{
+		Next:      next,
+		Metrics:   metrics,
+		MaxWait:   DefaultRateLimitMaxWait,
+		responses: map[string]*cachedResponse{},
+	}
+}
+
+// RoundTrip sends the request, or a conditional request if the response of the request is cached
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.Method + " " + req.URL.Path
+	start := time.Now()
+	waited := t.waitForRateLimit()
+
+	key := ""
+	var cached *cachedResponse
+	if req.Method == http.MethodGet && req.Header.Get("Range") == "" {
+		key = req.URL.String() + " " + req.Header.Get("Accept")
+		cached = t.cachedResponse(key)
+		if cached != nil {
+			req = cloneRequest(req)
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err == nil && t.updateRateLimit(resp) && canResend(req) {
+		drainBody(resp)
+		if t.waitForRateLimit() {
+			waited = true
+		}
+		if req.GetBody != nil {
+			req = cloneRequest(req)
+			req.Body, err = req.GetBody()
+		}
+		if err == nil {
+			resp, err = t.next().RoundTrip(req)
+			if err == nil {
+				t.updateRateLimit(resp)
+			}
+		}
+	}
+
+	if err == nil && cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp = cached.response(req, resp)
+		t.record(endpoint, start, waited, true, false)
+		return resp, nil
+	}
+	if err == nil && key != "" && resp.StatusCode == http.StatusOK {
+		etag := resp.Header.Get("ETag")
+		lastModified := resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			var body []byte
+			body, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil {
+				resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+				t.storeResponse(key, &cachedResponse{
+					etag:         etag,
+					lastModified: lastModified,
+					header:       copyHeader(resp.Header),
+					body:         body,
+				})
+			}
+		}
+	}
+	t.record(endpoint, start, waited, false, err != nil || resp.StatusCode >= 400)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *CachingTransport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func (t *CachingTransport) record(endpoint string, start time.Time, waited bool, hit bool, failed bool) {
+	if t.Metrics == nil {
+		return
+	}
+	duration := time.Since(start)
+	t.Metrics.record(endpoint, func(metrics *EndpointMetrics) {
+		metrics.Calls++
+		metrics.Duration += duration
+		if hit {
+			metrics.CacheHits++
+		}
+		if failed {
+			metrics.Errors++
+		}
+		if waited {
+			metrics.RateLimitWaits++
+		}
+	})
+}
+
+func (t *CachingTransport) cachedResponse(key string) *cachedResponse {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.responses[key]
+}
+
+func (t *CachingTransport) storeResponse(key string, response *cachedResponse) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.responses == nil {
+		t.responses = map[string]*cachedResponse{}
+	}
+	if _, ok := t.responses[key]; !ok && len(t.responses) >= maxCachedResponses {
+		// evict an arbitrary response to keep the cache bounded
+		for k := range t.responses {
+			delete(t.responses, k)
+			break
+		}
+	}
+	t.responses[key] = response
+}
+
+// updateRateLimit records when the rate limit resets if the response says it is exhausted and returns true if the
+// request was rejected because of the rate limit
+func (t *CachingTransport) updateRateLimit(resp *http.Response) bool {
+	rejected := resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+	var resetAt time.Time
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" && rejected {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			resetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	if resetAt.IsZero() {
+		remaining := firstHeader(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+		reset := firstHeader(resp.Header, "X-RateLimit-Reset", "RateLimit-Reset")
+		if remaining != "0" || reset == "" {
+			return false
+		}
+		seconds, err := strconv.ParseInt(reset, 10, 64)
+		if err != nil {
+			return false
+		}
+		resetAt = time.Unix(seconds, 0)
+	}
+	t.lock.Lock()
+	if resetAt.After(t.resetAt) {
+		t.resetAt = resetAt
+	}
+	t.lock.Unlock()
+	return rejected
+}
+
+// waitForRateLimit waits until the rate limit resets, up to the maximum wait, and returns true if it waited. The reset
+// time is only cleared once the wait is over so that the requests sent while waiting wait too.
+func (t *CachingTransport) waitForRateLimit() bool {
+	t.lock.Lock()
+	resetAt := t.resetAt
+	t.lock.Unlock()
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return false
+	}
+	maxWait := t.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultRateLimitMaxWait
+	}
+	if wait > maxWait {
+		log.Warnf("The git provider API rate limit resets in %s, only waiting %s\n", wait.Round(time.Second), maxWait)
+		wait = maxWait
+	} else {
+		log.Warnf("The git provider API rate limit is exhausted, waiting %s for it to reset\n", wait.Round(time.Second))
+	}
+	time.Sleep(wait)
+
+	t.lock.Lock()
+	if t.resetAt.Equal(resetAt) {
+		t.resetAt = time.Time{}
+	}
+	t.lock.Unlock()
+	return true
+}
+
+func (c *cachedResponse) response(req *http.Request, notModified *http.Response) *http.Response {
+	drainBody(notModified)
+	header := copyHeader(c.header)
+	// the rate limit and caching headers of the revalidation are more recent
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// canResend returns true if the request can be sent again
+func canResend(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// cloneRequest returns a copy of the request with its own headers as a RoundTripper must not modify the request
+func cloneRequest(req *http.Request) *http.Request {
+	answer := req.WithContext(req.Context())
+	answer.Header = copyHeader(req.Header)
+	return answer
+}
+
+func copyHeader(header http.Header) http.Header {
+	answer := http.Header{}
+	for k, v := range header {
+		answer[k] = append([]string{}, v...)
+	}
+	return answer
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func drainBody(resp *http.Response) {
+	if resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,159 @@
+package gits_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingTransportRevalidatesWithETag(t *testing.T) {
+	t.Parallel()
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"name":"myrepo"}`))
+	}))
+	defer server.Close()
+
+	metrics := gits.NewAPIMetrics()
+	client := &http.Client{Transport: gits.NewCachingTransport(nil, metrics)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/repos/myorg/myrepo")
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"name":"myrepo"}`, string(body))
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+	endpoint := metrics.Endpoint("GET /repos/myorg/myrepo")
+	assert.Equal(t, 3, endpoint.Calls)
+	assert.Equal(t, 2, endpoint.CacheHits)
+	assert.Equal(t, 0, endpoint.Errors)
+}
+
+func TestCachingTransportDoesNotCacheWrites(t *testing.T) {
+	t.Parallel()
+	var conditional int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: gits.NewCachingTransport(nil, nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(server.URL+"/repos/myorg/myrepo/hooks", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&conditional))
+}
+
+func TestCachingTransportWaitsForRateLimit(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "4102444800")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	metrics := gits.NewAPIMetrics()
+	transport := gits.NewCachingTransport(nil, metrics)
+	transport.MaxWait = 10 * time.Millisecond
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL + "/user")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	endpoint := metrics.Endpoint("GET /user")
+	assert.Equal(t, 1, endpoint.Calls)
+	assert.Equal(t, 1, endpoint.RateLimitWaits)
+	assert.Equal(t, 0, endpoint.Errors)
+}
+
+func TestCachingTransportConcurrentRequestsWaitForRateLimit(t *testing.T) {
+	t.Parallel()
+	resetAt := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	var lock sync.Mutex
+	var received []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/user" {
+			// the last request allowed before the rate limit resets
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+			w.Write([]byte("ok"))
+			return
+		}
+		lock.Lock()
+		received = append(received, time.Now())
+		lock.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	metrics := gits.NewAPIMetrics()
+	client := &http.Client{Transport: gits.NewCachingTransport(nil, metrics)}
+	resp, err := client.Get(server.URL + "/user")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	requests := 5
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL + "/repos/myorg/myrepo")
+			if err == nil {
+				resp.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, received, requests)
+	for _, at := range received {
+		assert.False(t, at.Before(resetAt), "a request was sent at %s before the rate limit reset at %s", at, resetAt)
+	}
+	assert.Equal(t, requests, metrics.Endpoint("GET /repos/myorg/myrepo").RateLimitWaits)
+}
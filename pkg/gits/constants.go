@@ -11,6 +11,8 @@ const (
 	KindGitlab = "gitlab"
 	// KindGitHub git kind for github
 	KindGitHub = "github"
+	// KindAzureDevOps git kind for Azure DevOps Repos
+	KindAzureDevOps = "azuredevops"
 	// KindGitFake git kind for fake git
 	KindGitFake = "fakegit"
 	// KindUnknown git kind for unknown git
@@ -24,5 +26,5 @@ const (
 )
 
 var (
-	KindGits = []string{KindBitBucketCloud, KindBitBucketServer, KindGitea, KindGitHub, KindGitlab}
+	KindGits = []string{KindBitBucketCloud, KindBitBucketServer, KindGitea, KindGitHub, KindGitlab, KindAzureDevOps}
 )
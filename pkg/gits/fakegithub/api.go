@@ -0,0 +1,1310 @@
+package fakegithub
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const nullSHA = "0000000000000000000000000000000000000000"
+
+// apiRoutes returns the operations of the REST API
+func (s *Server) apiRoutes() []*route {
+	return []*route{
+		s.handle(http.MethodGet, "user", s.getAuthenticatedUser),
+		s.handle(http.MethodGet, "users/:user", s.getUser),
+		s.handle(http.MethodGet, "user/orgs", s.listOrganizations),
+		s.handle(http.MethodGet, "user/memberships/orgs/:org", s.getOrganizationMembership),
+		s.handle(http.MethodGet, "user/repos", s.listRepositories),
+		s.handle(http.MethodPost, "user/repos", s.createRepositoryRequest),
+		s.handle(http.MethodGet, "users/:user/repos", s.listRepositories),
+		s.handle(http.MethodGet, "orgs/:org/repos", s.listRepositories),
+		s.handle(http.MethodPost, "orgs/:org/repos", s.createRepositoryRequest),
+
+		s.handle(http.MethodGet, "repos/:owner/:repo", s.repoHandler(s.getRepository)),
+		s.handle(http.MethodPatch, "repos/:owner/:repo", s.repoHandler(s.editRepository)),
+		s.handle(http.MethodDelete, "repos/:owner/:repo", s.repoHandler(s.deleteRepository)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/forks", s.repoHandler(s.forkRepository)),
+		s.handle(http.MethodPut, "repos/:owner/:repo/collaborators/:user", s.repoHandler(s.addCollaborator)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/commits", s.repoHandler(s.listCommits)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/commits/:ref/statuses", s.repoHandler(s.listStatuses)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/statuses/:sha", s.repoHandler(s.createStatus)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/contents/*path", s.repoHandler(s.getContents)),
+
+		s.handle(http.MethodGet, "repos/:owner/:repo/hooks", s.repoHandler(s.listHooks)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/hooks", s.repoHandler(s.createHook)),
+		s.handle(http.MethodPatch, "repos/:owner/:repo/hooks/:id", s.repoHandler(s.editHook)),
+		s.handle(http.MethodDelete, "repos/:owner/:repo/hooks/:id", s.repoHandler(s.deleteHook)),
+
+		s.handle(http.MethodGet, "repos/:owner/:repo/releases", s.repoHandler(s.listReleases)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/releases", s.repoHandler(s.createRelease)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/releases/tags/:tag", s.repoHandler(s.getReleaseByTag)),
+		s.handle(http.MethodPatch, "repos/:owner/:repo/releases/:id", s.repoHandler(s.editRelease)),
+
+		s.handle(http.MethodGet, "repos/:owner/:repo/pulls", s.repoHandler(s.listPullRequests)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/pulls", s.repoHandler(s.createPullRequest)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/pulls/:number", s.repoHandler(s.getPullRequest)),
+		s.handle(http.MethodPatch, "repos/:owner/:repo/pulls/:number", s.repoHandler(s.editPullRequest)),
+		s.handle(http.MethodPut, "repos/:owner/:repo/pulls/:number/merge", s.repoHandler(s.mergePullRequest)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/pulls/:number/commits", s.repoHandler(s.listPullRequestCommits)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/pulls/:number/reviews", s.repoHandler(s.listReviews)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/pulls/:number/reviews", s.repoHandler(s.createReview)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/pulls/:number/requested_reviewers", s.repoHandler(s.requestReviewers)),
+
+		s.handle(http.MethodGet, "repos/:owner/:repo/issues", s.repoHandler(s.listIssues)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/issues", s.repoHandler(s.createIssue)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/issues/:number", s.repoHandler(s.getIssue)),
+		s.handle(http.MethodPatch, "repos/:owner/:repo/issues/:number", s.repoHandler(s.editIssue)),
+		s.handle(http.MethodGet, "repos/:owner/:repo/issues/:number/comments", s.repoHandler(s.listComments)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/issues/:number/comments", s.repoHandler(s.createComment)),
+		s.handle(http.MethodPost, "repos/:owner/:repo/issues/:number/labels", s.repoHandler(s.addLabels)),
+	}
+}
+
+func (s *Server) getAuthenticatedUser(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, http.StatusOK, s.user(s.Username))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, http.StatusOK, s.user(params["user"]))
+}
+
+// organizations returns the owners of repositories other than the authenticated user
+func (s *Server) organizations() []*Organization {
+	answer := []*Organization{}
+	for _, user := range s.users {
+		if user.Login != s.Username && user.Type == "Organization" {
+			answer = append(answer, &Organization{
+				ID:    user.ID,
+				Login: user.Login,
+				URL:   s.apiURL("orgs", user.Login),
+			})
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Login < answer[j].Login
+	})
+	return answer
+}
+
+func (s *Server) listOrganizations(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	orgs := s.organizations()
+	start, end := paginate(w, r, len(orgs))
+	writeJSON(w, http.StatusOK, orgs[start:end])
+}
+
+func (s *Server) getOrganizationMembership(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	for _, org := range s.organizations() {
+		if org.Login == params["org"] {
+			writeJSON(w, http.StatusOK, &Membership{
+				State:        "active",
+				Role:         "admin",
+				Organization: org,
+				User:         s.user(s.Username),
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+// listRepositories lists the repositories of the organisation or user of the path or of the authenticated user
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	owner := params["org"]
+	if owner == "" {
+		owner = params["user"]
+	}
+	if owner == "" {
+		owner = s.Username
+	}
+	repos := []*Repository{}
+	for _, repo := range s.repos {
+		if repo.Owner.Login == owner {
+			repos = append(repos, repo.Repository)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].FullName < repos[j].FullName
+	})
+	start, end := paginate(w, r, len(repos))
+	writeJSON(w, http.StatusOK, repos[start:end])
+}
+
+func (s *Server) createRepositoryRequest(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	request := struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Private     bool   `json:"private"`
+		AutoInit    bool   `json:"auto_init"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	owner := params["org"]
+	if owner == "" {
+		owner = s.Username
+	}
+	if request.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Repository creation failed: name is missing")
+		return
+	}
+	if s.repos[owner+"/"+request.Name] != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Repository creation failed: name already exists on this account")
+		return
+	}
+	repo, err := s.createRepository(owner, request.Name, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	repo.Description = request.Description
+	repo.Private = request.Private
+	if request.AutoInit {
+		err = repo.git.initialCommit("README.md", "# "+request.Name+"\n", "Initial commit", s.user(s.Username))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, repo.Repository)
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	writeJSON(w, http.StatusOK, repo.Repository)
+}
+
+func (s *Server) editRepository(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := struct {
+		Description      *string `json:"description"`
+		Private          *bool   `json:"private"`
+		DefaultBranch    *string `json:"default_branch"`
+		AllowMergeCommit *bool   `json:"allow_merge_commit"`
+		AllowSquashMerge *bool   `json:"allow_squash_merge"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.DefaultBranch != nil && *request.DefaultBranch != repo.DefaultBranch {
+		_, err := repo.git.git("symbolic-ref", "HEAD", "refs/heads/"+*request.DefaultBranch)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		repo.DefaultBranch = *request.DefaultBranch
+	}
+	if request.Description != nil {
+		repo.Description = *request.Description
+	}
+	if request.Private != nil {
+		repo.Private = *request.Private
+	}
+	if request.AllowMergeCommit != nil {
+		repo.AllowMergeCommit = *request.AllowMergeCommit
+	}
+	if request.AllowSquashMerge != nil {
+		repo.AllowSquashMerge = *request.AllowSquashMerge
+	}
+	now := time.Now()
+	repo.UpdatedAt = &now
+	writeJSON(w, http.StatusOK, repo.Repository)
+}
+
+func (s *Server) deleteRepository(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	delete(s.repos, repo.FullName)
+	err := os.RemoveAll(repo.git.dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) forkRepository(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := struct {
+		Organization string `json:"organization"`
+	}{}
+	if r.ContentLength != 0 && !readJSON(w, r, &request) {
+		return
+	}
+	// older clients pass the organisation as a query parameter
+	owner := request.Organization
+	if owner == "" {
+		owner = r.URL.Query().Get("organization")
+	}
+	if owner == "" {
+		owner = s.Username
+	}
+	fork := s.repos[owner+"/"+repo.Name]
+	if fork == nil {
+		var err error
+		fork, err = s.createRepository(owner, repo.Name, repo)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusAccepted, fork.Repository)
+}
+
+func (s *Server) addCollaborator(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	s.user(params["user"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listCommits(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	ref := r.URL.Query().Get("sha")
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+	sha, err := repo.git.resolve(ref)
+	if err != nil {
+		writeError(w, http.StatusConflict, "Git Repository is empty.")
+		return
+	}
+	var paths []string
+	if filePath := strings.Trim(r.URL.Query().Get("path"), "/"); filePath != "" {
+		paths = append(paths, filePath)
+	}
+	commits, err := repo.git.commits(sha, paths...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, commit := range commits {
+		s.linkCommit(repo, commit)
+	}
+	start, end := paginate(w, r, len(commits))
+	writeJSON(w, http.StatusOK, commits[start:end])
+}
+
+func (s *Server) linkCommit(repo *repository, commit *RepositoryCommit) {
+	commit.HTMLURL = repo.HTMLURL + "/commit/" + commit.SHA
+	commit.URL = s.apiURL("repos", repo.FullName, "commits", commit.SHA)
+	commit.Author = s.commitUser(commit.Commit.Author)
+	commit.Committer = s.commitUser(commit.Commit.Committer)
+}
+
+// commitUser returns the known user who is the author or committer of a commit, which is the user whose login is
+// either the name or the noreply email address of the author
+func (s *Server) commitUser(author *CommitAuthor) *User {
+	login := author.Name
+	if strings.HasSuffix(author.Email, "@users.noreply.github.com") {
+		login = strings.TrimSuffix(author.Email, "@users.noreply.github.com")
+	}
+	if login != s.Username && s.users[login] == nil {
+		return nil
+	}
+	user := *s.user(login)
+	user.Email = author.Email
+	return &user
+}
+
+func (s *Server) listStatuses(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	sha, err := repo.git.resolve(params["ref"])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "No commit found for SHA: "+params["ref"])
+		return
+	}
+	// the latest statuses come first
+	statuses := []*RepoStatus{}
+	all := repo.statuses[sha]
+	for i := len(all) - 1; i >= 0; i-- {
+		statuses = append(statuses, all[i])
+	}
+	start, end := paginate(w, r, len(statuses))
+	writeJSON(w, http.StatusOK, statuses[start:end])
+}
+
+func (s *Server) createStatus(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := struct {
+		State       string `json:"state"`
+		TargetURL   string `json:"target_url"`
+		Description string `json:"description"`
+		Context     string `json:"context"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	switch request.State {
+	case "error", "failure", "pending", "success":
+	default:
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation Failed: invalid state %q", request.State))
+		return
+	}
+	sha, err := repo.git.resolve(params["sha"])
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "No commit found for SHA: "+params["sha"])
+		return
+	}
+	if request.Context == "" {
+		request.Context = "default"
+	}
+	now := time.Now()
+	status := &RepoStatus{
+		ID:          s.newID(),
+		State:       request.State,
+		TargetURL:   request.TargetURL,
+		Description: request.Description,
+		Context:     request.Context,
+		Creator:     s.user(s.Username),
+		URL:         s.apiURL("repos", repo.FullName, "statuses", sha),
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	repo.statuses[sha] = append(repo.statuses[sha], status)
+	s.queueEvent(repo, "status", map[string]interface{}{
+		"id":          status.ID,
+		"sha":         sha,
+		"state":       status.State,
+		"description": status.Description,
+		"target_url":  status.TargetURL,
+		"context":     status.Context,
+		"repository":  repo.Repository,
+		"sender":      s.user(s.Username),
+	})
+	writeJSON(w, http.StatusCreated, status)
+}
+
+func (s *Server) getContents(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	ref := r.URL.Query().Get("ref")
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+	filePath := strings.Trim(params["path"], "/")
+	_, err := repo.git.resolve(ref)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "No commit found for the ref "+ref)
+		return
+	}
+	entry, err := repo.git.entry(ref, filePath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entry == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if entry.kind == "blob" {
+		data, err := repo.git.blob(entry.sha)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		content := s.content(repo, ref, entry)
+		content.Encoding = "base64"
+		content.Content = base64.StdEncoding.EncodeToString(data)
+		writeJSON(w, http.StatusOK, content)
+		return
+	}
+	dir := filePath
+	if dir != "" {
+		dir += "/"
+	}
+	entries, err := repo.git.tree(ref, dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	contents := []*RepositoryContent{}
+	for _, entry := range entries {
+		contents = append(contents, s.content(repo, ref, entry))
+	}
+	writeJSON(w, http.StatusOK, contents)
+}
+
+func (s *Server) content(repo *repository, ref string, entry *treeEntry) *RepositoryContent {
+	answer := &RepositoryContent{
+		Type:    "file",
+		Size:    entry.size,
+		Name:    path.Base(entry.path),
+		Path:    entry.path,
+		SHA:     entry.sha,
+		URL:     s.apiURL("repos", repo.FullName, "contents", entry.path) + "?ref=" + ref,
+		HTMLURL: repo.HTMLURL + "/blob/" + ref + "/" + entry.path,
+	}
+	if entry.kind == "tree" {
+		answer.Type = "dir"
+		answer.HTMLURL = repo.HTMLURL + "/tree/" + ref + "/" + entry.path
+	} else {
+		answer.DownloadURL = repo.HTMLURL + "/raw/" + ref + "/" + entry.path
+	}
+	return answer
+}
+
+func (s *Server) listHooks(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	start, end := paginate(w, r, len(repo.hooks))
+	writeJSON(w, http.StatusOK, repo.hooks[start:end])
+}
+
+// hookRequest is the body of the requests which create and edit webhooks
+type hookRequest struct {
+	Name   string                 `json:"name"`
+	Config map[string]interface{} `json:"config"`
+	Events []string               `json:"events"`
+	Active *bool                  `json:"active"`
+}
+
+func (s *Server) createHook(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := hookRequest{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Config == nil || request.Config["url"] == nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: the config of a hook requires a url")
+		return
+	}
+	now := time.Now()
+	hook := &Hook{
+		ID:        s.newID(),
+		Name:      "web",
+		Active:    true,
+		Events:    request.Events,
+		Config:    request.Config,
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	hook.URL = s.apiURL("repos", repo.FullName, "hooks", strconv.FormatInt(hook.ID, 10))
+	if len(hook.Events) == 0 {
+		hook.Events = []string{"push"}
+	}
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+	repo.hooks = append(repo.hooks, hook)
+	if hook.Active {
+		s.pending = append(s.pending, &pendingDelivery{
+			hook:  hook,
+			event: "ping",
+			payload: map[string]interface{}{
+				"zen":        "Keep it logically awesome.",
+				"hook_id":    hook.ID,
+				"hook":       hook,
+				"repository": repo.Repository,
+				"sender":     s.user(s.Username),
+			},
+		})
+	}
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+// findHook returns the index of the webhook of the request, writing a not found response if there is none
+func (s *Server) findHook(w http.ResponseWriter, repo *repository, params map[string]string) int {
+	id, _ := strconv.ParseInt(params["id"], 10, 64)
+	for i, hook := range repo.hooks {
+		if hook.ID == id {
+			return i
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+	return -1
+}
+
+func (s *Server) editHook(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	i := s.findHook(w, repo, params)
+	if i < 0 {
+		return
+	}
+	request := hookRequest{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	hook := repo.hooks[i]
+	if request.Config != nil {
+		hook.Config = request.Config
+	}
+	if len(request.Events) > 0 {
+		hook.Events = request.Events
+	}
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+	now := time.Now()
+	hook.UpdatedAt = &now
+	writeJSON(w, http.StatusOK, hook)
+}
+
+func (s *Server) deleteHook(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	i := s.findHook(w, repo, params)
+	if i < 0 {
+		return
+	}
+	repo.hooks = append(repo.hooks[:i], repo.hooks[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listReleases(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	// the latest releases come first
+	releases := []*Release{}
+	for i := len(repo.releases) - 1; i >= 0; i-- {
+		releases = append(releases, repo.releases[i])
+	}
+	start, end := paginate(w, r, len(releases))
+	writeJSON(w, http.StatusOK, releases[start:end])
+}
+
+// releaseRequest is the body of the requests which create and edit releases
+type releaseRequest struct {
+	TagName         *string `json:"tag_name"`
+	TargetCommitish *string `json:"target_commitish"`
+	Name            *string `json:"name"`
+	Body            *string `json:"body"`
+	Draft           *bool   `json:"draft"`
+	Prerelease      *bool   `json:"prerelease"`
+}
+
+func (request *releaseRequest) apply(release *Release) {
+	if request.TagName != nil {
+		release.TagName = *request.TagName
+	}
+	if request.TargetCommitish != nil {
+		release.TargetCommitish = *request.TargetCommitish
+	}
+	if request.Name != nil {
+		release.Name = *request.Name
+	}
+	if request.Body != nil {
+		release.Body = *request.Body
+	}
+	if request.Draft != nil {
+		release.Draft = *request.Draft
+	}
+	if request.Prerelease != nil {
+		release.Prerelease = *request.Prerelease
+	}
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := releaseRequest{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	now := time.Now()
+	release := &Release{
+		ID:              s.newID(),
+		TargetCommitish: repo.DefaultBranch,
+		Author:          s.user(s.Username),
+		CreatedAt:       &now,
+	}
+	request.apply(release)
+	if release.TagName == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: tag_name is missing")
+		return
+	}
+	for _, existing := range repo.releases {
+		if existing.TagName == release.TagName {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed: a release already exists for the tag "+release.TagName)
+			return
+		}
+	}
+	err := repo.git.tag(release.TagName, release.TargetCommitish)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: "+err.Error())
+		return
+	}
+	s.linkRelease(repo, release)
+	repo.releases = append(repo.releases, release)
+	if !release.Draft {
+		release.PublishedAt = &now
+		s.queueEvent(repo, "release", map[string]interface{}{
+			"action":     "published",
+			"release":    release,
+			"repository": repo.Repository,
+			"sender":     s.user(s.Username),
+		})
+	}
+	writeJSON(w, http.StatusCreated, release)
+}
+
+func (s *Server) linkRelease(repo *repository, release *Release) {
+	release.HTMLURL = repo.HTMLURL + "/releases/tag/" + release.TagName
+	release.URL = s.apiURL("repos", repo.FullName, "releases", strconv.FormatInt(release.ID, 10))
+}
+
+func (s *Server) getReleaseByTag(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	for _, release := range repo.releases {
+		if release.TagName == params["tag"] {
+			writeJSON(w, http.StatusOK, release)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) editRelease(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	id, _ := strconv.ParseInt(params["id"], 10, 64)
+	for _, release := range repo.releases {
+		if release.ID == id {
+			request := releaseRequest{}
+			if !readJSON(w, r, &request) {
+				return
+			}
+			request.apply(release)
+			if !release.Draft && release.PublishedAt == nil {
+				now := time.Now()
+				release.PublishedAt = &now
+			}
+			s.linkRelease(repo, release)
+			writeJSON(w, http.StatusOK, release)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+// headRepository returns the repository and branch of the head of a pull request which is either a branch of the
+// repository or owner:branch for a branch of the fork of the owner
+func (s *Server) headRepository(repo *repository, head string) (*repository, string) {
+	paths := strings.SplitN(head, ":", 2)
+	if len(paths) < 2 || paths[0] == repo.Owner.Login {
+		return repo, paths[len(paths)-1]
+	}
+	fork := s.repos[paths[0]+"/"+repo.Name]
+	if fork == nil {
+		for _, r := range s.repos {
+			if r.Owner.Login == paths[0] && r.Parent != nil && r.Parent.FullName == repo.FullName {
+				fork = r
+			}
+		}
+	}
+	return fork, paths[1]
+}
+
+// refreshPullRequest updates a pull request with the commits of its branches and the labels of its issue
+func (s *Server) refreshPullRequest(repo *repository, pr *PullRequest) {
+	if pr.State == "open" {
+		headRepo, _ := s.headRepository(repo, pr.Head.Label)
+		if headRepo != nil {
+			sha, err := headRepo.git.resolve(pr.Head.Ref)
+			if err == nil {
+				pr.Head.SHA = sha
+			}
+		}
+		sha, err := repo.git.resolve(pr.Base.Ref)
+		if err == nil {
+			pr.Base.SHA = sha
+		}
+	}
+	issue := repo.issues[pr.Number]
+	if issue != nil {
+		pr.Labels = issue.Labels
+		pr.Assignees = issue.Assignees
+	}
+}
+
+// findPullRequest returns the pull request of the request, writing a not found response if there is none
+func (s *Server) findPullRequest(w http.ResponseWriter, repo *repository, params map[string]string) *PullRequest {
+	number, _ := strconv.Atoi(params["number"])
+	pr := repo.pulls[number]
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return nil
+	}
+	s.refreshPullRequest(repo, pr)
+	return pr
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	query := r.URL.Query()
+	state := query.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	prs := []*PullRequest{}
+	for _, pr := range repo.pulls {
+		if state != "all" && pr.State != state {
+			continue
+		}
+		if head := query.Get("head"); head != "" && head != pr.Head.Label && head != pr.Head.Ref {
+			continue
+		}
+		if base := query.Get("base"); base != "" && base != pr.Base.Ref {
+			continue
+		}
+		s.refreshPullRequest(repo, pr)
+		prs = append(prs, pr)
+	}
+	// the latest pull requests come first
+	sort.Slice(prs, func(i, j int) bool {
+		return prs[i].Number > prs[j].Number
+	})
+	start, end := paginate(w, r, len(prs))
+	writeJSON(w, http.StatusOK, prs[start:end])
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Title == "" || request.Head == "" || request.Base == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: title, head and base are required")
+		return
+	}
+	headRepo, headRef := s.headRepository(repo, request.Head)
+	if headRepo == nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: no repository found for the head "+request.Head)
+		return
+	}
+	headSHA, err := headRepo.git.resolve(headRef)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: the head "+request.Head+" does not exist")
+		return
+	}
+	baseSHA, err := repo.git.resolve(request.Base)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: the base "+request.Base+" does not exist")
+		return
+	}
+	headLabel := headRepo.Owner.Login + ":" + headRef
+	for _, pr := range repo.pulls {
+		if pr.State == "open" && pr.Head.Label == headLabel && pr.Base.Ref == request.Base {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed: A pull request already exists for "+headLabel+".")
+			return
+		}
+	}
+	_, err = git(repo.git.dir, nil, "fetch", "--quiet", headRepo.git.dir, headSHA)
+	if err == nil {
+		var out []byte
+		out, err = repo.git.git("rev-list", "--count", baseSHA+".."+headSHA)
+		if err == nil && strings.TrimSpace(string(out)) == "0" {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation Failed: No commits between %s and %s", request.Base, request.Head))
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	issue := s.newIssue(repo, request.Title, request.Body)
+	mergeable := true
+	pr := &PullRequest{
+		ID:     s.newID(),
+		Number: issue.Number,
+		State:  "open",
+		Title:  request.Title,
+		Body:   request.Body,
+		User:   issue.User,
+		Head: &PullRequestBranch{
+			Label: headLabel,
+			Ref:   headRef,
+			SHA:   headSHA,
+			Repo:  headRepo.Repository,
+			User:  headRepo.Owner,
+		},
+		Base: &PullRequestBranch{
+			Label: repo.Owner.Login + ":" + request.Base,
+			Ref:   request.Base,
+			SHA:   baseSHA,
+			Repo:  repo.Repository,
+			User:  repo.Owner,
+		},
+		Mergeable:          &mergeable,
+		Labels:             issue.Labels,
+		Assignees:          issue.Assignees,
+		RequestedReviewers: []*User{},
+		HTMLURL:            repo.HTMLURL + "/pull/" + strconv.Itoa(issue.Number),
+		URL:                s.apiURL("repos", repo.FullName, "pulls", strconv.Itoa(issue.Number)),
+		IssueURL:           issue.URL,
+		DiffURL:            repo.HTMLURL + "/pull/" + strconv.Itoa(issue.Number) + ".diff",
+		StatusesURL:        s.apiURL("repos", repo.FullName, "statuses", headSHA),
+		CreatedAt:          issue.CreatedAt,
+		UpdatedAt:          issue.UpdatedAt,
+	}
+	issue.HTMLURL = pr.HTMLURL
+	issue.PullRequestLinks = &IssuePullRequestLinks{
+		URL:     pr.URL,
+		HTMLURL: pr.HTMLURL,
+	}
+	repo.pulls[pr.Number] = pr
+	s.queuePullRequestEvent(repo, "opened", pr)
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) queuePullRequestEvent(repo *repository, action string, pr *PullRequest) {
+	s.queueEvent(repo, "pull_request", map[string]interface{}{
+		"action":       action,
+		"number":       pr.Number,
+		"pull_request": pr,
+		"repository":   repo.Repository,
+		"sender":       s.user(s.Username),
+	})
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr != nil {
+		writeJSON(w, http.StatusOK, pr)
+	}
+}
+
+func (s *Server) editPullRequest(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	request := struct {
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+		State *string `json:"state"`
+		Base  *string `json:"base"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	action := "edited"
+	now := time.Now()
+	if request.Title != nil {
+		pr.Title = *request.Title
+	}
+	if request.Body != nil {
+		pr.Body = *request.Body
+	}
+	if request.Base != nil {
+		pr.Base.Ref = *request.Base
+		pr.Base.Label = repo.Owner.Login + ":" + *request.Base
+	}
+	if request.State != nil && *request.State != pr.State && !pr.Merged {
+		pr.State = *request.State
+		if pr.State == "closed" {
+			action = "closed"
+			pr.ClosedAt = &now
+		} else {
+			action = "reopened"
+			pr.ClosedAt = nil
+		}
+	}
+	pr.UpdatedAt = &now
+	issue := repo.issues[pr.Number]
+	issue.Title = pr.Title
+	issue.Body = pr.Body
+	issue.State = pr.State
+	issue.ClosedAt = pr.ClosedAt
+	s.refreshPullRequest(repo, pr)
+	s.queuePullRequestEvent(repo, action, pr)
+	writeJSON(w, http.StatusOK, pr)
+}
+
+func (s *Server) mergePullRequest(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	request := struct {
+		CommitTitle   string `json:"commit_title"`
+		CommitMessage string `json:"commit_message"`
+		SHA           string `json:"sha"`
+		MergeMethod   string `json:"merge_method"`
+	}{}
+	if r.ContentLength != 0 && !readJSON(w, r, &request) {
+		return
+	}
+	if pr.State != "open" {
+		writeError(w, http.StatusMethodNotAllowed, "Pull Request is not mergeable")
+		return
+	}
+	if request.SHA != "" && request.SHA != pr.Head.SHA {
+		writeError(w, http.StatusConflict, "Head branch was modified. Review and try the merge again.")
+		return
+	}
+	if request.MergeMethod == "rebase" {
+		writeError(w, http.StatusMethodNotAllowed, "Rebase merges are not supported")
+		return
+	}
+	headRepo, _ := s.headRepository(repo, pr.Head.Label)
+	if headRepo == nil {
+		writeError(w, http.StatusMethodNotAllowed, "Pull Request is not mergeable")
+		return
+	}
+	title := request.CommitTitle
+	if title == "" {
+		title = fmt.Sprintf("Merge pull request #%d from %s", pr.Number, strings.Replace(pr.Head.Label, ":", "/", 1))
+	}
+	message := title
+	if request.CommitMessage != "" {
+		message += "\n\n" + request.CommitMessage
+	}
+	baseRef := "refs/heads/" + pr.Base.Ref
+	before := repo.git.refs()[baseRef]
+	sha, err := repo.git.merge(pr.Base.Ref, headRepo.git, pr.Head.SHA, request.MergeMethod, message, s.user(s.Username))
+	if err != nil {
+		mergeable := false
+		pr.Mergeable = &mergeable
+		writeError(w, http.StatusMethodNotAllowed, "Pull Request is not mergeable: "+err.Error())
+		return
+	}
+	now := time.Now()
+	pr.State = "closed"
+	pr.Merged = true
+	pr.MergeCommitSHA = sha
+	pr.MergedAt = &now
+	pr.ClosedAt = &now
+	pr.UpdatedAt = &now
+	issue := repo.issues[pr.Number]
+	issue.State = "closed"
+	issue.ClosedAt = &now
+	s.queueEvent(repo, "push", s.pushEvent(repo, baseRef, before, sha))
+	s.queuePullRequestEvent(repo, "closed", pr)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sha":     sha,
+		"merged":  true,
+		"message": "Pull Request successfully merged",
+	})
+}
+
+// pushEvent returns the payload of the event of the change of a reference
+func (s *Server) pushEvent(repo *repository, ref string, before string, after string) map[string]interface{} {
+	if before == "" {
+		before = nullSHA
+	}
+	if after == "" {
+		after = nullSHA
+	}
+	user := s.user(s.Username)
+	answer := map[string]interface{}{
+		"ref":        ref,
+		"before":     before,
+		"after":      after,
+		"created":    before == nullSHA,
+		"deleted":    after == nullSHA,
+		"forced":     false,
+		"compare":    repo.HTMLURL + "/compare/" + before + "..." + after,
+		"repository": repo.Repository,
+		"pusher": map[string]string{
+			"name":  user.Login,
+			"email": user.Login + "@users.noreply.github.com",
+		},
+		"sender": user,
+	}
+	if after != nullSHA {
+		commits, err := repo.git.commits(after + "^!")
+		if err == nil && len(commits) > 0 {
+			commit := commits[0]
+			answer["head_commit"] = map[string]interface{}{
+				"id":        commit.SHA,
+				"message":   commit.Commit.Message,
+				"timestamp": commit.Commit.Committer.Date,
+				"url":       repo.HTMLURL + "/commit/" + commit.SHA,
+				"author":    commit.Commit.Author,
+				"committer": commit.Commit.Committer,
+			}
+		}
+	}
+	return answer
+}
+
+func (s *Server) listPullRequestCommits(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	headRepo, _ := s.headRepository(repo, pr.Head.Label)
+	if headRepo == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	_, err := git(repo.git.dir, nil, "fetch", "--quiet", headRepo.git.dir, pr.Head.SHA)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	commits, err := repo.git.commits(pr.Base.SHA + ".." + pr.Head.SHA)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// the commits of a pull request are listed oldest first
+	answer := []*RepositoryCommit{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		s.linkCommit(repo, commits[i])
+		answer = append(answer, commits[i])
+	}
+	start, end := paginate(w, r, len(answer))
+	writeJSON(w, http.StatusOK, answer[start:end])
+}
+
+func (s *Server) listReviews(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	reviews := repo.reviews[pr.Number]
+	start, end := paginate(w, r, len(reviews))
+	writeJSON(w, http.StatusOK, append([]*Review{}, reviews[start:end]...))
+}
+
+func (s *Server) createReview(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	request := struct {
+		Body     string `json:"body"`
+		Event    string `json:"event"`
+		CommitID string `json:"commit_id"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	states := map[string]string{
+		"":                "PENDING",
+		"APPROVE":         "APPROVED",
+		"REQUEST_CHANGES": "CHANGES_REQUESTED",
+		"COMMENT":         "COMMENTED",
+	}
+	state, ok := states[request.Event]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: unknown event "+request.Event)
+		return
+	}
+	if request.CommitID == "" {
+		request.CommitID = pr.Head.SHA
+	}
+	now := time.Now()
+	review := &Review{
+		ID:          s.newID(),
+		User:        s.user(s.Username),
+		Body:        request.Body,
+		State:       state,
+		CommitID:    request.CommitID,
+		SubmittedAt: &now,
+	}
+	review.HTMLURL = fmt.Sprintf("%s#pullrequestreview-%d", pr.HTMLURL, review.ID)
+	repo.reviews[pr.Number] = append(repo.reviews[pr.Number], review)
+	s.queueEvent(repo, "pull_request_review", map[string]interface{}{
+		"action":       "submitted",
+		"review":       review,
+		"pull_request": pr,
+		"repository":   repo.Repository,
+		"sender":       review.User,
+	})
+	writeJSON(w, http.StatusOK, review)
+}
+
+func (s *Server) requestReviewers(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	pr := s.findPullRequest(w, repo, params)
+	if pr == nil {
+		return
+	}
+	request := struct {
+		Reviewers []string `json:"reviewers"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	for _, login := range request.Reviewers {
+		found := false
+		for _, reviewer := range pr.RequestedReviewers {
+			found = found || reviewer.Login == login
+		}
+		if !found {
+			pr.RequestedReviewers = append(pr.RequestedReviewers, s.user(login))
+		}
+	}
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+// newIssue creates an issue numbered after the existing issues and pull requests
+func (s *Server) newIssue(repo *repository, title string, body string) *Issue {
+	number := len(repo.issues) + 1
+	now := time.Now()
+	issue := &Issue{
+		ID:        s.newID(),
+		Number:    number,
+		State:     "open",
+		Title:     title,
+		Body:      body,
+		User:      s.user(s.Username),
+		Labels:    []*Label{},
+		Assignees: []*User{},
+		HTMLURL:   repo.HTMLURL + "/issues/" + strconv.Itoa(number),
+		URL:       s.apiURL("repos", repo.FullName, "issues", strconv.Itoa(number)),
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	repo.issues[number] = issue
+	return issue
+}
+
+// findIssue returns the issue of the request, writing a not found response if there is none
+func (s *Server) findIssue(w http.ResponseWriter, repo *repository, params map[string]string) *Issue {
+	number, _ := strconv.Atoi(params["number"])
+	issue := repo.issues[number]
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+	return issue
+}
+
+func (s *Server) listIssues(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+	issues := []*Issue{}
+	for _, issue := range repo.issues {
+		if state == "all" || issue.State == state {
+			issues = append(issues, issue)
+		}
+	}
+	// the latest issues come first
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Number > issues[j].Number
+	})
+	start, end := paginate(w, r, len(issues))
+	writeJSON(w, http.StatusOK, issues[start:end])
+}
+
+func (s *Server) createIssue(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	request := struct {
+		Title     string   `json:"title"`
+		Body      string   `json:"body"`
+		Labels    []string `json:"labels"`
+		Assignees []string `json:"assignees"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Title == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed: title is missing")
+		return
+	}
+	issue := s.newIssue(repo, request.Title, request.Body)
+	addLabels(issue, request.Labels)
+	for _, login := range request.Assignees {
+		issue.Assignees = append(issue.Assignees, s.user(login))
+	}
+	s.queueEvent(repo, "issues", map[string]interface{}{
+		"action":     "opened",
+		"issue":      issue,
+		"repository": repo.Repository,
+		"sender":     issue.User,
+	})
+	writeJSON(w, http.StatusCreated, issue)
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	issue := s.findIssue(w, repo, params)
+	if issue != nil {
+		writeJSON(w, http.StatusOK, issue)
+	}
+}
+
+func (s *Server) editIssue(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	issue := s.findIssue(w, repo, params)
+	if issue == nil {
+		return
+	}
+	request := struct {
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+		State *string `json:"state"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	now := time.Now()
+	if request.Title != nil {
+		issue.Title = *request.Title
+	}
+	if request.Body != nil {
+		issue.Body = *request.Body
+	}
+	if request.State != nil && *request.State != issue.State && repo.pulls[issue.Number] == nil {
+		issue.State = *request.State
+		if issue.State == "closed" {
+			issue.ClosedAt = &now
+		} else {
+			issue.ClosedAt = nil
+		}
+	}
+	issue.UpdatedAt = &now
+	writeJSON(w, http.StatusOK, issue)
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	issue := s.findIssue(w, repo, params)
+	if issue == nil {
+		return
+	}
+	comments := repo.comments[issue.Number]
+	start, end := paginate(w, r, len(comments))
+	writeJSON(w, http.StatusOK, append([]*IssueComment{}, comments[start:end]...))
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	issue := s.findIssue(w, repo, params)
+	if issue == nil {
+		return
+	}
+	request := struct {
+		Body string `json:"body"`
+	}{}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	now := time.Now()
+	comment := &IssueComment{
+		ID:        s.newID(),
+		Body:      request.Body,
+		User:      s.user(s.Username),
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	comment.HTMLURL = fmt.Sprintf("%s#issuecomment-%d", issue.HTMLURL, comment.ID)
+	comment.URL = s.apiURL("repos", repo.FullName, "issues", "comments", strconv.FormatInt(comment.ID, 10))
+	repo.comments[issue.Number] = append(repo.comments[issue.Number], comment)
+	s.queueEvent(repo, "issue_comment", map[string]interface{}{
+		"action":     "created",
+		"issue":      issue,
+		"comment":    comment,
+		"repository": repo.Repository,
+		"sender":     comment.User,
+	})
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+func (s *Server) addLabels(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string) {
+	issue := s.findIssue(w, repo, params)
+	if issue == nil {
+		return
+	}
+	labels := []string{}
+	if !readJSON(w, r, &labels) {
+		return
+	}
+	addLabels(issue, labels)
+	if pr := repo.pulls[issue.Number]; pr != nil {
+		s.refreshPullRequest(repo, pr)
+		s.queuePullRequestEvent(repo, "labeled", pr)
+	}
+	writeJSON(w, http.StatusOK, issue.Labels)
+}
+
+// addLabels adds the labels an issue does not have yet
+func addLabels(issue *Issue, labels []string) {
+	for _, name := range labels {
+		found := false
+		for _, label := range issue.Labels {
+			found = found || label.Name == name
+		}
+		if !found {
+			issue.Labels = append(issue.Labels, &Label{
+				Name:  name,
+				Color: "ededed",
+			})
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package fakegithub
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// bareRepository is a bare git repository served over HTTP
+type bareRepository struct {
+	dir string
+}
+
+// commitFormat separates the fields of the commits output by git log with NUL characters
+const commitFormat = "%H%x00%an%x00%ae%x00%aI%x00%cn%x00%ce%x00%cI%x00%B%x1e"
+
+// git runs a git command returning its standard output
+func git(dir string, env []string, args ...string) ([]byte, error) {
+	return gitWithInput(dir, env, nil, args...)
+}
+
+// gitWithInput runs a git command with the input returning its standard output
+func gitWithInput(dir string, env []string, input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// initBareRepository creates a bare repository whose default branch is master
+func initBareRepository(dir string) (*bareRepository, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the directory %s", dir)
+	}
+	_, err = git(dir, nil, "init", "--quiet", "--bare")
+	if err != nil {
+		return nil, err
+	}
+	repo := &bareRepository{dir: dir}
+	_, err = repo.git("symbolic-ref", "HEAD", "refs/heads/master")
+	return repo, err
+}
+
+// forkBareRepository creates a bare repository with the branches and tags of another
+func forkBareRepository(source *bareRepository, dir string) (*bareRepository, error) {
+	_, err := git("", nil, "clone", "--quiet", "--bare", source.dir, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &bareRepository{dir: dir}, nil
+}
+
+func (r *bareRepository) git(args ...string) ([]byte, error) {
+	return git(r.dir, nil, args...)
+}
+
+// resolve returns the SHA of the commit of a branch, tag or SHA
+func (r *bareRepository) resolve(ref string) (string, error) {
+	out, err := r.git("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("no commit found for %s", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// refs returns the SHAs of the branches and tags keyed by the name of their reference
+func (r *bareRepository) refs() map[string]string {
+	answer := map[string]string{}
+	out, err := r.git("for-each-ref", "--format=%(refname) %(objectname)", "refs/heads", "refs/tags")
+	if err != nil {
+		return answer
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			answer[fields[0]] = fields[1]
+		}
+	}
+	return answer
+}
+
+// commits returns the commits reachable from the revision range which change the paths, newest first
+func (r *bareRepository) commits(revisionRange string, paths ...string) ([]*RepositoryCommit, error) {
+	args := []string{"log", "--format=" + commitFormat, revisionRange}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	out, err := r.git(args...)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*RepositoryCommit{}
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) < 8 {
+			continue
+		}
+		answer = append(answer, &RepositoryCommit{
+			SHA: fields[0],
+			Commit: &Commit{
+				Message:   strings.TrimSpace(fields[7]),
+				Author:    commitAuthor(fields[1], fields[2], fields[3]),
+				Committer: commitAuthor(fields[4], fields[5], fields[6]),
+			},
+		})
+	}
+	return answer, nil
+}
+
+func commitAuthor(name string, email string, date string) *CommitAuthor {
+	answer := &CommitAuthor{
+		Name:  name,
+		Email: email,
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err == nil {
+		answer.Date = &t
+	}
+	return answer
+}
+
+// treeEntry is a file or directory of a tree
+type treeEntry struct {
+	kind string
+	sha  string
+	size int
+	path string
+}
+
+// entry returns the file or directory at the path of the revision
+func (r *bareRepository) entry(ref string, path string) (*treeEntry, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		out, err := r.git("rev-parse", ref+"^{tree}")
+		if err != nil {
+			return nil, err
+		}
+		return &treeEntry{kind: "tree", sha: strings.TrimSpace(string(out))}, nil
+	}
+	entries, err := r.tree(ref, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.path == path {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// tree returns the entries of the tree of the revision matching the path. The entries of a directory are listed by
+// adding a trailing slash to its path.
+func (r *bareRepository) tree(ref string, path string) ([]*treeEntry, error) {
+	args := []string{"ls-tree", "--long", "--full-tree", ref}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := r.git(args...)
+	if err != nil {
+		return nil, err
+	}
+	answer := []*treeEntry{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		paths := strings.SplitN(line, "\t", 2)
+		if len(paths) != 2 {
+			continue
+		}
+		fields := strings.Fields(paths[0])
+		if len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.Atoi(fields[3])
+		answer = append(answer, &treeEntry{
+			kind: fields[1],
+			sha:  fields[2],
+			size: size,
+			path: paths[1],
+		})
+	}
+	return answer, nil
+}
+
+// blob returns the content of a file
+func (r *bareRepository) blob(sha string) ([]byte, error) {
+	return r.git("cat-file", "blob", sha)
+}
+
+// tag creates a lightweight tag of the revision if the tag does not exist
+func (r *bareRepository) tag(tag string, ref string) error {
+	_, err := r.resolve("refs/tags/" + tag)
+	if err == nil {
+		return nil
+	}
+	sha, err := r.resolve(ref)
+	if err != nil {
+		return err
+	}
+	_, err = r.git("tag", tag, sha)
+	return err
+}
+
+// userEnv returns the environment variables which make the user the author and committer of commits
+func userEnv(user *User) []string {
+	email := user.Login + "@users.noreply.github.com"
+	return []string{
+		"GIT_AUTHOR_NAME=" + user.Login,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + user.Login,
+		"GIT_COMMITTER_EMAIL=" + email,
+	}
+}
+
+// initialCommit commits a file to the master branch of an empty repository
+func (r *bareRepository) initialCommit(path string, content string, message string, user *User) error {
+	env := userEnv(user)
+	out, err := gitWithInput(r.dir, env, []byte(content), "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	blob := strings.TrimSpace(string(out))
+	out, err = gitWithInput(r.dir, env, []byte(fmt.Sprintf("100644 blob %s\t%s\n", blob, path)), "mktree")
+	if err != nil {
+		return err
+	}
+	tree := strings.TrimSpace(string(out))
+	out, err = git(r.dir, env, "commit-tree", tree, "-m", message)
+	if err != nil {
+		return err
+	}
+	_, err = r.git("update-ref", "refs/heads/master", strings.TrimSpace(string(out)))
+	return err
+}
+
+// merge merges the head revision of a repository into the base branch, returning the SHA of the merge commit
+func (r *bareRepository) merge(base string, head *bareRepository, headSHA string, method string, message string, user *User) (string, error) {
+	dir, err := ioutil.TempDir("", "fake-github-merge-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating a temporary directory to merge in")
+	}
+	defer os.RemoveAll(dir)
+
+	env := userEnv(user)
+	_, err = git("", env, "clone", "--quiet", "--branch", base, r.dir, dir)
+	if err != nil {
+		return "", err
+	}
+	_, err = git(dir, env, "fetch", "--quiet", head.dir, headSHA)
+	if err != nil {
+		return "", err
+	}
+	switch method {
+	case "squash":
+		_, err = git(dir, env, "merge", "--quiet", "--squash", headSHA)
+		if err == nil {
+			_, err = git(dir, env, "commit", "--quiet", "--message", message)
+		}
+	default:
+		_, err = git(dir, env, "merge", "--quiet", "--no-ff", "--message", message, headSHA)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "merging %s into %s", headSHA, base)
+	}
+	_, err = git(dir, env, "push", "--quiet", "origin", "HEAD:refs/heads/"+base)
+	if err != nil {
+		return "", err
+	}
+	out, err := git(dir, env, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,513 @@
+// Package fakegithub provides a GitHub compatible API server backed by local bare git repositories so that commands
+// which use the GitHub REST API and clone, push and pull repositories can be tested end to end without network access.
+//
+// The server implements the subset of the GitHub REST API which jx uses: repositories, forks, pull requests, reviews,
+// issues, comments, labels, commit statuses, webhooks, releases and content. The git repositories are served over the
+// smart HTTP protocol by git http-backend and pushes to them deliver push events to their webhooks.
+// The metadata of the repositories is only kept in memory.
+package fakegithub
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// APIPath is the path of the REST API, which is the path GitHub Enterprise serves it from
+	APIPath = "/api/v3"
+
+	defaultPerPage = 30
+)
+
+// repository is the state of a repository
+type repository struct {
+	*Repository
+	git      *bareRepository
+	issues   map[int]*Issue
+	pulls    map[int]*PullRequest
+	comments map[int][]*IssueComment
+	reviews  map[int][]*Review
+	statuses map[string][]*RepoStatus
+	hooks    []*Hook
+	releases []*Release
+}
+
+// pendingDelivery is an event to deliver to a webhook once the request which caused it has been handled
+type pendingDelivery struct {
+	hook    *Hook
+	event   string
+	payload interface{}
+}
+
+// Server is a GitHub compatible API server backed by local bare git repositories
+type Server struct {
+	// Dir is the directory the bare git repositories are created in
+	Dir string
+	// Username is the login of the authenticated user
+	Username string
+	// Token is the API token requests must authenticate with, if it is blank requests are not authenticated
+	Token string
+	// URL is the URL the server is listening on which is set by Start. When the server is mounted on another listener
+	// such as an httptest.Server it must be set before any repositories are created.
+	URL string
+
+	lock       sync.Mutex
+	repos      map[string]*repository
+	users      map[string]*User
+	nextID     int64
+	pending    []*pendingDelivery
+	deliveries []*Delivery
+	listener   net.Listener
+	httpServer *http.Server
+	routes     []*route
+}
+
+// NewServer creates a server which creates its repositories in the directory
+func NewServer(dir string, username string, token string) *Server {
+	s := &Server{
+		Dir:      dir,
+		Username: username,
+		Token:    token,
+		repos:    map[string]*repository{},
+		users:    map[string]*User{},
+	}
+	s.routes = s.apiRoutes()
+	return s
+}
+
+// Start starts serving on the address such as 127.0.0.1:0 which picks a free port
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrapf(err, "listening on %s", address)
+	}
+	s.listener = listener
+	s.URL = "http://" + listener.Addr().String()
+	s.httpServer = &http.Server{Handler: s}
+	go s.httpServer.Serve(listener)
+	return nil
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
+
+// Deliveries returns the events delivered to webhooks so far
+func (s *Server) Deliveries() []*Delivery {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*Delivery{}, s.deliveries...)
+}
+
+// CreateRepository creates an empty repository
+func (s *Server) CreateRepository(owner string, name string) (*Repository, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	repo, err := s.createRepository(owner, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Repository, nil
+}
+
+// ServeHTTP serves the REST API below APIPath and the git repositories at /owner/name.git
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticated(r) {
+		if strings.HasPrefix(r.URL.Path, APIPath+"/") {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+		} else {
+			w.Header().Set("WWW-Authenticate", `Basic realm="GitHub"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, APIPath+"/") {
+		s.lock.Lock()
+		s.serveAPI(w, r)
+		s.lock.Unlock()
+	} else {
+		s.serveGit(w, r)
+	}
+	s.lock.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.lock.Unlock()
+
+	// webhooks are delivered without the lock so they can call back the server
+	for _, p := range pending {
+		delivery := s.deliver(p)
+		s.lock.Lock()
+		s.deliveries = append(s.deliveries, delivery)
+		s.lock.Unlock()
+	}
+}
+
+// authenticated checks the API token of the request is the token of the server
+func (s *Server) authenticated(r *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password == s.Token
+	}
+	header := r.Header.Get("Authorization")
+	for _, prefix := range []string{"token ", "Bearer ", "bearer "} {
+		if strings.HasPrefix(header, prefix) {
+			return strings.TrimPrefix(header, prefix) == s.Token
+		}
+	}
+	return false
+}
+
+// serveGit serves the bare repositories with git http-backend, delivering push events for the references a push
+// changes. The lock is only held while looking up the repository and its references so that slow clones and pushes
+// do not block other requests.
+func (s *Server) serveGit(w http.ResponseWriter, r *http.Request) {
+	paths := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(paths) < 2 || !strings.HasSuffix(paths[1], ".git") {
+		http.NotFound(w, r)
+		return
+	}
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		http.Error(w, "the git binary is not installed", http.StatusInternalServerError)
+		return
+	}
+	push := strings.HasSuffix(r.URL.Path, "/git-receive-pack")
+	s.lock.Lock()
+	repo := s.repos[paths[0]+"/"+strings.TrimSuffix(paths[1], ".git")]
+	var before map[string]string
+	if repo != nil && push {
+		before = repo.git.refs()
+	}
+	s.lock.Unlock()
+	if repo == nil {
+		http.NotFound(w, r)
+		return
+	}
+	handler := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + s.Dir,
+			"GIT_HTTP_EXPORT_ALL=1",
+			// pushes are only allowed to authenticated users
+			"REMOTE_USER=" + s.Username,
+		},
+	}
+	handler.ServeHTTP(w, r)
+	if !push {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	after := repo.git.refs()
+	names := []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if before[name] != after[name] {
+			s.queueEvent(repo, "push", s.pushEvent(repo, name, before[name], after[name]))
+		}
+	}
+}
+
+// route is an operation of the REST API. Segments of the pattern starting with a colon are parameters and a final
+// segment starting with an asterisk matches the rest of the path.
+type route struct {
+	method  string
+	pattern []string
+	handler func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, APIPath), "/")
+	segments := strings.Split(path, "/")
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Remaining", "5000")
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	pathMatched := false
+	for _, route := range s.routes {
+		params, ok := matchRoute(route.pattern, segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if route.method == r.Method {
+			route.handler(w, r, params)
+			return
+		}
+	}
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported by %s", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func matchRoute(pattern []string, segments []string) (map[string]string, bool) {
+	params := map[string]string{}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "*") {
+			params[p[1:]] = strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(p, ":") {
+			params[p[1:]] = segments[i]
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, len(pattern) == len(segments)
+}
+
+func (s *Server) handle(method string, pattern string, handler func(w http.ResponseWriter, r *http.Request, params map[string]string)) *route {
+	return &route{
+		method:  method,
+		pattern: strings.Split(pattern, "/"),
+		handler: handler,
+	}
+}
+
+// repoHandler wraps the handler of an operation on a repository so it is not found if the repository does not exist
+func (s *Server) repoHandler(handler func(w http.ResponseWriter, r *http.Request, repo *repository, params map[string]string)) func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		repo := s.repos[params["owner"]+"/"+params["repo"]]
+		if repo == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		handler(w, r, repo, params)
+	}
+}
+
+// createRepository creates a repository, forking the parent if there is one
+func (s *Server) createRepository(owner string, name string, parent *repository) (*repository, error) {
+	fullName := owner + "/" + name
+	if s.repos[fullName] != nil {
+		return nil, fmt.Errorf("repository %s already exists", fullName)
+	}
+	dir := filepath.Join(s.Dir, owner, name+".git")
+	var git *bareRepository
+	var err error
+	if parent != nil {
+		git, err = forkBareRepository(parent.git, dir)
+	} else {
+		git, err = initBareRepository(dir)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the git repository of %s", fullName)
+	}
+	now := time.Now()
+	repo := &repository{
+		Repository: &Repository{
+			ID:               s.newID(),
+			Name:             name,
+			FullName:         fullName,
+			Owner:            s.user(owner),
+			DefaultBranch:    "master",
+			AllowMergeCommit: true,
+			AllowSquashMerge: true,
+			HTMLURL:          s.URL + "/" + fullName,
+			CloneURL:         s.URL + "/" + fullName + ".git",
+			SSHURL:           fmt.Sprintf("git@%s:%s.git", strings.TrimPrefix(s.URL, "http://"), fullName),
+			URL:              s.apiURL("repos", owner, name),
+			CreatedAt:        &now,
+			UpdatedAt:        &now,
+		},
+		git:      git,
+		issues:   map[int]*Issue{},
+		pulls:    map[int]*PullRequest{},
+		comments: map[int][]*IssueComment{},
+		reviews:  map[int][]*Review{},
+		statuses: map[string][]*RepoStatus{},
+	}
+	if parent != nil {
+		repo.Fork = true
+		repo.Parent = parent.Repository
+		repo.DefaultBranch = parent.DefaultBranch
+	}
+	s.repos[fullName] = repo
+	return repo, nil
+}
+
+// user returns the user or organisation with the login, creating it if it is not known yet
+func (s *Server) user(login string) *User {
+	user := s.users[login]
+	if user == nil {
+		userType := "User"
+		if login != s.Username {
+			userType = "Organization"
+		}
+		user = &User{
+			ID:      s.newID(),
+			Login:   login,
+			Type:    userType,
+			HTMLURL: s.URL + "/" + login,
+			URL:     s.apiURL("users", login),
+		}
+		s.users[login] = user
+	}
+	return user
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) apiURL(paths ...string) string {
+	return s.URL + APIPath + "/" + strings.Join(paths, "/")
+}
+
+// queueEvent queues the delivery of an event to the webhooks of the repository which subscribe to it
+func (s *Server) queueEvent(repo *repository, event string, payload interface{}) {
+	for _, hook := range repo.hooks {
+		if !hook.Active {
+			continue
+		}
+		for _, e := range hook.Events {
+			if e == event || e == "*" {
+				s.pending = append(s.pending, &pendingDelivery{
+					hook:    hook,
+					event:   event,
+					payload: payload,
+				})
+				break
+			}
+		}
+	}
+}
+
+// deliver posts an event to a webhook signing it with the secret of the webhook
+func (s *Server) deliver(p *pendingDelivery) *Delivery {
+	s.lock.Lock()
+	delivery := &Delivery{
+		ID:    strconv.FormatInt(s.newID(), 10),
+		Event: p.event,
+	}
+	delivery.URL, _ = p.hook.Config["url"].(string)
+	secret, _ := p.hook.Config["secret"].(string)
+	s.lock.Unlock()
+
+	data, err := json.Marshal(p.payload)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	delivery.Payload = data
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, strings.NewReader(string(data)))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GitHub-Hookshot/fake")
+	req.Header.Set("X-GitHub-Event", p.event)
+	req.Header.Set("X-GitHub-Delivery", delivery.ID)
+	if secret != "" {
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write(data)
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Warnf("Failed to deliver the %s event to %s: %s\n", p.event, delivery.URL, err)
+		delivery.Error = err.Error()
+		return delivery
+	}
+	resp.Body.Close()
+	delivery.StatusCode = resp.StatusCode
+	return delivery
+}
+
+// paginate returns the bounds of the page of the request and adds the link to the next page to the response
+func paginate(w http.ResponseWriter, r *http.Request, count int) (int, int) {
+	query := r.URL.Query()
+	perPage, err := strconv.Atoi(query.Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * perPage
+	if start > count {
+		start = count
+	}
+	end := start + perPage
+	if end >= count {
+		end = count
+	} else {
+		next := *r.URL
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		next.Scheme = "http"
+		next.Host = r.Host
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+	return start, end
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(&errorResponse{
+		Message:          message,
+		DocumentationURL: "https://developer.github.com/v3",
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// readJSON reads the body of the request, writing an error response if it is invalid
+func readJSON(w http.ResponseWriter, r *http.Request, value interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,250 @@
+package fakegithub_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/gits/fakegithub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser  = "test-user"
+	testToken = "test-token"
+)
+
+// startServer starts a server in a temporary directory returning the server and a provider which uses it
+func startServer(t *testing.T) (*fakegithub.Server, gits.GitProvider, func()) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "test-fake-github-")
+	require.NoError(t, err)
+	server := fakegithub.NewServer(dir, testUser, testToken)
+	err = server.Start("127.0.0.1:0")
+	require.NoError(t, err)
+
+	provider, err := gits.NewGitHubProvider(&auth.AuthServer{URL: server.URL}, &auth.UserAuth{
+		Username: testUser,
+		ApiToken: testToken,
+	}, gits.NewGitCLI())
+	require.NoError(t, err)
+	return server, provider, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// runGit runs a git command in the directory as the test user
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=" + testUser, "-c", "user.email=" + testUser + "@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), string(out))
+}
+
+// pushFile commits a file to the branch of the working directory and pushes the branch
+func pushFile(t *testing.T, dir string, branch string, name string, content string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	require.NoError(t, err)
+	runGit(t, dir, "checkout", "-q", "-B", branch)
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", "update "+name)
+	runGit(t, dir, "push", "-q", "origin", branch)
+}
+
+// cloneRepository creates a repository and returns a working directory whose origin is the repository
+func cloneRepository(t *testing.T, server *fakegithub.Server, provider gits.GitProvider, owner string, name string) (*gits.GitRepository, string) {
+	repo, err := provider.CreateRepository(owner, name, false)
+	require.NoError(t, err)
+	repo.Organisation = owner
+
+	dir, err := ioutil.TempDir("", "test-fake-github-clone-")
+	require.NoError(t, err)
+	cloneURL := strings.Replace(repo.CloneURL, "://", "://"+testUser+":"+testToken+"@", 1)
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "remote", "add", "origin", cloneURL)
+	pushFile(t, dir, "master", "requirements.yaml", "dependencies:\n- name: myapp\n  version: 0.0.1\n")
+	return repo, dir
+}
+
+func TestPullRequestLifecycle(t *testing.T) {
+	server, provider, cleanup := startServer(t)
+	defer cleanup()
+
+	var lock sync.Mutex
+	events := []string{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, r.Header.Get("X-GitHub-Event"))
+	}))
+	defer receiver.Close()
+
+	repo, dir := cloneRepository(t, server, provider, "myorg", "environment-staging")
+	defer os.RemoveAll(dir)
+	assert.Equal(t, server.URL+"/myorg/environment-staging.git", repo.CloneURL)
+
+	err := provider.CreateWebHook(&gits.GitWebHookArguments{
+		Owner:  "myorg",
+		Repo:   repo,
+		URL:    receiver.URL,
+		Secret: "hmac",
+	})
+	require.NoError(t, err)
+
+	pushFile(t, dir, "promote-myapp-0.0.2", "requirements.yaml", "dependencies:\n- name: myapp\n  version: 0.0.2\n")
+	pr, err := provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title:         "chore: promote myapp to version 0.0.2",
+		Head:          "promote-myapp-0.0.2",
+		Base:          "master",
+		GitRepository: repo,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/myorg/environment-staging/pull/1", pr.URL)
+
+	_, err = provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title:         "chore: promote myapp to version 0.0.2",
+		Head:          "promote-myapp-0.0.2",
+		Base:          "master",
+		GitRepository: repo,
+	})
+	assert.Error(t, err, "a second pull request for the same branch")
+
+	err = provider.UpdatePullRequestStatus(pr)
+	require.NoError(t, err)
+	require.NotEmpty(t, pr.LastCommitSha)
+	_, err = provider.UpdateCommitStatus("myorg", "environment-staging", pr.LastCommitSha, &gits.GitRepoStatus{
+		State:   "success",
+		Context: "continuous-integration/jenkins-x",
+	})
+	require.NoError(t, err)
+	status, err := provider.PullRequestLastCommitStatus(pr)
+	require.NoError(t, err)
+	assert.Equal(t, "success", status)
+
+	err = provider.AddLabelsToIssue("myorg", "environment-staging", *pr.Number, []string{"approved"})
+	require.NoError(t, err)
+	err = provider.MergePullRequest(pr, "merging the promotion")
+	require.NoError(t, err)
+	err = provider.UpdatePullRequestStatus(pr)
+	require.NoError(t, err)
+	require.NotNil(t, pr.Merged)
+	assert.True(t, *pr.Merged)
+	assert.Equal(t, []string{"approved"}, labelNames(pr.Labels))
+
+	content, err := provider.GetContent("myorg", "environment-staging", "requirements.yaml", "master")
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "version: 0.0.2")
+
+	prs, err := provider.ListOpenPullRequests("myorg", "environment-staging")
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"ping", "push", "pull_request", "status", "pull_request", "push", "pull_request"}, events)
+	for _, delivery := range server.Deliveries() {
+		assert.Equal(t, http.StatusOK, delivery.StatusCode, "the status of the %s delivery", delivery.Event)
+	}
+}
+
+func labelNames(labels []*gits.Label) []string {
+	answer := []string{}
+	for _, label := range labels {
+		if label.Name != nil {
+			answer = append(answer, *label.Name)
+		}
+	}
+	return answer
+}
+
+func TestForkPullRequest(t *testing.T) {
+	server, provider, cleanup := startServer(t)
+	defer cleanup()
+
+	repo, dir := cloneRepository(t, server, provider, "myorg", "myapp")
+	defer os.RemoveAll(dir)
+
+	fork, err := provider.ForkRepository("myorg", "myapp", "")
+	require.NoError(t, err)
+	assert.True(t, fork.Fork)
+	assert.Equal(t, server.URL+"/"+testUser+"/myapp.git", fork.CloneURL)
+
+	runGit(t, dir, "remote", "set-url", "origin", strings.Replace(fork.CloneURL, "://", "://"+testUser+":"+testToken+"@", 1))
+	pushFile(t, dir, "fix", "README.md", "# myapp\n")
+	pr, err := provider.CreatePullRequest(&gits.GitPullRequestArguments{
+		Title:         "fix: add a readme",
+		Head:          testUser + ":fix",
+		Base:          "master",
+		GitRepository: repo,
+	})
+	require.NoError(t, err)
+
+	commits, err := provider.GetPullRequestCommits("myorg", repo, *pr.Number)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "update README.md", commits[0].Message)
+}
+
+func TestReleasesAndCommits(t *testing.T) {
+	server, provider, cleanup := startServer(t)
+	defer cleanup()
+
+	_, dir := cloneRepository(t, server, provider, "myorg", "myapp")
+	defer os.RemoveAll(dir)
+	pushFile(t, dir, "master", "README.md", "# myapp\n")
+
+	err := provider.UpdateRelease("myorg", "myapp", "v0.0.1", &gits.GitRelease{
+		Name:    "0.0.1",
+		TagName: "v0.0.1",
+		Body:    "the first release",
+	})
+	require.NoError(t, err)
+	releases, err := provider.ListReleases("myorg", "myapp")
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "v0.0.1", releases[0].TagName)
+	assert.Equal(t, "the first release", releases[0].Body)
+
+	commits, err := provider.ListCommits("myorg", "myapp", &gits.ListCommitsArguments{})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "update README.md", commits[0].Message)
+
+	commits, err = provider.ListCommits("myorg", "myapp", &gits.ListCommitsArguments{Path: "requirements.yaml"})
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "update requirements.yaml", commits[0].Message)
+}
+
+func TestAuthentication(t *testing.T) {
+	server, _, cleanup := startServer(t)
+	defer cleanup()
+
+	resp, err := http.Get(server.URL + fakegithub.APIPath + "/user")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+fakegithub.APIPath+"/user", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "token "+testToken)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,235 @@
+package fakegithub
+
+import (
+	"time"
+)
+
+// User is a GitHub user or organisation
+type User struct {
+	ID      int64  `json:"id"`
+	Login   string `json:"login"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Type    string `json:"type"`
+	HTMLURL string `json:"html_url"`
+	URL     string `json:"url"`
+}
+
+// Organization is an organisation the authenticated user belongs to
+type Organization struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	URL   string `json:"url"`
+}
+
+// Membership is the membership of the authenticated user of an organisation
+type Membership struct {
+	State        string        `json:"state"`
+	Role         string        `json:"role"`
+	Organization *Organization `json:"organization"`
+	User         *User         `json:"user"`
+}
+
+// Repository is a GitHub repository
+type Repository struct {
+	ID               int64       `json:"id"`
+	Name             string      `json:"name"`
+	FullName         string      `json:"full_name"`
+	Owner            *User       `json:"owner"`
+	Description      string      `json:"description"`
+	Private          bool        `json:"private"`
+	Fork             bool        `json:"fork"`
+	Parent           *Repository `json:"parent,omitempty"`
+	DefaultBranch    string      `json:"default_branch"`
+	AllowMergeCommit bool        `json:"allow_merge_commit"`
+	AllowSquashMerge bool        `json:"allow_squash_merge"`
+	HTMLURL          string      `json:"html_url"`
+	CloneURL         string      `json:"clone_url"`
+	SSHURL           string      `json:"ssh_url"`
+	URL              string      `json:"url"`
+	CreatedAt        *time.Time  `json:"created_at"`
+	UpdatedAt        *time.Time  `json:"updated_at"`
+}
+
+// Label is a label of an issue or pull request
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// PullRequestBranch is the head or base of a pull request
+type PullRequestBranch struct {
+	Label string      `json:"label"`
+	Ref   string      `json:"ref"`
+	SHA   string      `json:"sha"`
+	Repo  *Repository `json:"repo"`
+	User  *User       `json:"user"`
+}
+
+// PullRequest is a GitHub pull request
+type PullRequest struct {
+	ID                 int64              `json:"id"`
+	Number             int                `json:"number"`
+	State              string             `json:"state"`
+	Title              string             `json:"title"`
+	Body               string             `json:"body"`
+	User               *User              `json:"user"`
+	Head               *PullRequestBranch `json:"head"`
+	Base               *PullRequestBranch `json:"base"`
+	Merged             bool               `json:"merged"`
+	Mergeable          *bool              `json:"mergeable"`
+	MergeCommitSHA     string             `json:"merge_commit_sha,omitempty"`
+	Labels             []*Label           `json:"labels"`
+	Assignees          []*User            `json:"assignees"`
+	RequestedReviewers []*User            `json:"requested_reviewers"`
+	HTMLURL            string             `json:"html_url"`
+	URL                string             `json:"url"`
+	IssueURL           string             `json:"issue_url"`
+	DiffURL            string             `json:"diff_url"`
+	StatusesURL        string             `json:"statuses_url"`
+	CreatedAt          *time.Time         `json:"created_at"`
+	UpdatedAt          *time.Time         `json:"updated_at"`
+	ClosedAt           *time.Time         `json:"closed_at"`
+	MergedAt           *time.Time         `json:"merged_at"`
+}
+
+// IssuePullRequestLinks marks an issue which is a pull request
+type IssuePullRequestLinks struct {
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Issue is a GitHub issue. Pull requests are issues too.
+type Issue struct {
+	ID               int64                  `json:"id"`
+	Number           int                    `json:"number"`
+	State            string                 `json:"state"`
+	Title            string                 `json:"title"`
+	Body             string                 `json:"body"`
+	User             *User                  `json:"user"`
+	Labels           []*Label               `json:"labels"`
+	Assignees        []*User                `json:"assignees"`
+	HTMLURL          string                 `json:"html_url"`
+	URL              string                 `json:"url"`
+	PullRequestLinks *IssuePullRequestLinks `json:"pull_request,omitempty"`
+	CreatedAt        *time.Time             `json:"created_at"`
+	UpdatedAt        *time.Time             `json:"updated_at"`
+	ClosedAt         *time.Time             `json:"closed_at"`
+}
+
+// IssueComment is a comment on an issue or pull request
+type IssueComment struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	User      *User      `json:"user"`
+	HTMLURL   string     `json:"html_url"`
+	URL       string     `json:"url"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// Review is a review of a pull request
+type Review struct {
+	ID          int64      `json:"id"`
+	User        *User      `json:"user"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"`
+	CommitID    string     `json:"commit_id"`
+	HTMLURL     string     `json:"html_url"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+// RepoStatus is the status of a commit reported by a context such as a pipeline
+type RepoStatus struct {
+	ID          int64      `json:"id"`
+	State       string     `json:"state"`
+	TargetURL   string     `json:"target_url"`
+	Description string     `json:"description"`
+	Context     string     `json:"context"`
+	Creator     *User      `json:"creator"`
+	URL         string     `json:"url"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// Hook is a webhook of a repository
+type Hook struct {
+	ID        int64                  `json:"id"`
+	Name      string                 `json:"name"`
+	Active    bool                   `json:"active"`
+	Events    []string               `json:"events"`
+	Config    map[string]interface{} `json:"config"`
+	URL       string                 `json:"url"`
+	CreatedAt *time.Time             `json:"created_at"`
+	UpdatedAt *time.Time             `json:"updated_at"`
+}
+
+// Release is a release of a repository
+type Release struct {
+	ID              int64      `json:"id"`
+	TagName         string     `json:"tag_name"`
+	TargetCommitish string     `json:"target_commitish"`
+	Name            string     `json:"name"`
+	Body            string     `json:"body"`
+	Draft           bool       `json:"draft"`
+	Prerelease      bool       `json:"prerelease"`
+	Author          *User      `json:"author"`
+	HTMLURL         string     `json:"html_url"`
+	URL             string     `json:"url"`
+	CreatedAt       *time.Time `json:"created_at"`
+	PublishedAt     *time.Time `json:"published_at"`
+}
+
+// RepositoryContent is a file or directory of a repository
+type RepositoryContent struct {
+	Type        string `json:"type"`
+	Encoding    string `json:"encoding,omitempty"`
+	Size        int    `json:"size"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Content     string `json:"content,omitempty"`
+	SHA         string `json:"sha"`
+	URL         string `json:"url"`
+	HTMLURL     string `json:"html_url"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// CommitAuthor is the author or committer of a commit
+type CommitAuthor struct {
+	Name  string     `json:"name"`
+	Email string     `json:"email"`
+	Date  *time.Time `json:"date"`
+}
+
+// Commit is the git data of a commit
+type Commit struct {
+	Message   string        `json:"message"`
+	Author    *CommitAuthor `json:"author"`
+	Committer *CommitAuthor `json:"committer"`
+}
+
+// RepositoryCommit is a commit of a repository
+type RepositoryCommit struct {
+	SHA       string  `json:"sha"`
+	Commit    *Commit `json:"commit"`
+	Author    *User   `json:"author"`
+	Committer *User   `json:"committer"`
+	HTMLURL   string  `json:"html_url"`
+	URL       string  `json:"url"`
+}
+
+// Delivery records the delivery of an event to a webhook
+type Delivery struct {
+	ID         string
+	Event      string
+	URL        string
+	StatusCode int
+	Error      string
+	Payload    []byte
+}
+
+// errorResponse is the body of the response to a failed request
+type errorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
@@ -326,3 +326,33 @@ func (p *GerritProvider) ListCommits(owner, repo string, opt *ListCommitsArgumen
 func (p *GerritProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
 	return fmt.Errorf("Getting content not supported on gerrit")
 }
+
+// ListReviews lists the reviews of a pull request
+func (p *GerritProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	return nil, fmt.Errorf("Listing reviews not supported on gerrit")
+}
+
+// SubmitReview approves, requests changes to or comments on a pull request
+func (p *GerritProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	return nil, fmt.Errorf("Submitting reviews not supported on gerrit")
+}
+
+// RequestReviewers requests reviews of a pull request from the users
+func (p *GerritProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	return fmt.Errorf("Requesting reviewers not supported on gerrit")
+}
+
+// GetBranchProtection returns the protection of a branch
+func (p *GerritProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	return nil, fmt.Errorf("Getting branch protection not supported on gerrit")
+}
+
+// SetBranchProtection protects a branch
+func (p *GerritProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	return fmt.Errorf("Setting branch protection not supported on gerrit")
+}
+
+// RemoveBranchProtection removes the protection of a branch
+func (p *GerritProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	return fmt.Errorf("Removing branch protection not supported on gerrit")
+}
@@ -696,7 +696,7 @@ func (g *GitCLI) GetLatestCommitMessage(dir string) (string, error) {
 
 // FetchTags fetches all the tags
 func (g *GitCLI) FetchTags(dir string) error {
-	return g.gitCmd("", "fetch", "--tags", "-v")
+	return g.gitCmd(dir, "fetch", "--tags", "-v")
 }
 
 // Tags returns all tags from the repository at the given directory
@@ -710,9 +710,53 @@ func (g *GitCLI) Tags(dir string) ([]string, error) {
 	return strings.Split(text, "\n"), nil
 }
 
-// CreateTag creates a tag with the given name and message in the repository at the given directory
+// CreateTag creates a tag with the given name and message in the repository at the given directory. The tag is
+// signed if the repository is configured to sign tags.
 func (g *GitCLI) CreateTag(dir string, tag string, msg string) error {
-	return g.gitCmd("", "tag", "-fa", tag, "-m", msg)
+	if g.signsTags(dir) {
+		return g.gitCmd(dir, "tag", "-fs", tag, "-m", msg)
+	}
+	return g.gitCmd(dir, "tag", "-fa", tag, "-m", msg)
+}
+
+// signsTags returns true if the repository at the given directory is configured to sign tags, which older versions
+// of git only honour with an explicit -s
+func (g *GitCLI) signsTags(dir string) bool {
+	text, _ := g.gitCmdWithOutput(dir, "config", "--bool", "--get", "tag.gpgsign")
+	return strings.TrimSpace(text) == "true"
+}
+
+// ConfigureSigning configures the repository at the given directory to sign its commits and tags with the key
+func (g *GitCLI) ConfigureSigning(dir string, signing *GitSigning) error {
+	err := signing.Validate()
+	if err != nil {
+		return err
+	}
+	for _, setting := range signing.gitConfig() {
+		err = g.gitCmd(dir, "config", setting[0], setting[1])
+		if err != nil {
+			return errors.Wrapf(err, "failed to configure signing in %s", dir)
+		}
+	}
+	return nil
+}
+
+// VerifyTag verifies the tag in the repository at the given directory is signed by a trusted key
+func (g *GitCLI) VerifyTag(dir string, tag string) error {
+	err := g.gitCmd(dir, "verify-tag", tag)
+	if err != nil {
+		return errors.Wrapf(err, "tag %s is not signed by a trusted key", tag)
+	}
+	return nil
+}
+
+// VerifyCommit verifies the commit in the repository at the given directory is signed by a trusted key
+func (g *GitCLI) VerifyCommit(dir string, commitish string) error {
+	err := g.gitCmd(dir, "verify-commit", commitish)
+	if err != nil {
+		return errors.Wrapf(err, "commit %s is not signed by a trusted key", commitish)
+	}
+	return nil
 }
 
 // PrintCreateRepositoryGenerateAccessToken prints the access token URL of a Git repository
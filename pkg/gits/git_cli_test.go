@@ -1,10 +1,15 @@
 package gits_test
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type brancbNameData struct {
@@ -37,3 +42,41 @@ func Test(t *testing.T) {
 		assert.Equal(t, data.expected, actual, "Convert to valid branch name for %s", data.input)
 	}
 }
+
+func TestGitCLITagsInDirectory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("the git binary is not installed")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME":     "Jenkins X",
+		"GIT_AUTHOR_EMAIL":    "jenkins-x@example.com",
+		"GIT_COMMITTER_NAME":  "Jenkins X",
+		"GIT_COMMITTER_EMAIL": "jenkins-x@example.com",
+	} {
+		original := os.Getenv(k)
+		require.NoError(t, os.Setenv(k, v))
+		defer os.Setenv(k, original)
+	}
+	tmpDir, err := ioutil.TempDir("", "test-git-cli-tags-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	gitter := gits.NewGitCLI()
+	remote := filepath.Join(tmpDir, "remote")
+	dir := filepath.Join(tmpDir, "local")
+	for _, d := range []string{remote, dir} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+		require.NoError(t, gitter.Init(d))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(remote, "README.md"), []byte("hello"), 0644))
+	require.NoError(t, gitter.Add(remote, "README.md"))
+	require.NoError(t, gitter.CommitDir(remote, "initial commit"))
+
+	// neither repository is the working directory of the test so the tags are only found if the dir is used
+	require.NoError(t, gitter.CreateTag(remote, "v1.0.0", "release 1.0.0"))
+	require.NoError(t, gitter.AddRemote(dir, "origin", remote))
+	require.NoError(t, gitter.FetchTags(dir))
+	tags, err := gitter.Tags(dir)
+	require.NoError(t, err)
+	assert.Contains(t, tags, "v1.0.0")
+}
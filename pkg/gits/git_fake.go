@@ -23,6 +23,7 @@ type GitRemote struct {
 type GitTag struct {
 	Name    string
 	Message string
+	Signed  bool
 }
 
 // GitFake provides a fake Gitter
@@ -40,7 +41,10 @@ type GitFake struct {
 	Changes        bool
 	GitTags        []GitTag
 	Revision       string
-	serverURL      string
+	Signing        *GitSigning
+	// ChangedFilesFromBranch is returned by ListChangedFilesFromBranch
+	ChangedFilesFromBranch string
+	serverURL              string
 }
 
 // NewGitFake creates a new fake Gitter
@@ -477,11 +481,43 @@ func (g *GitFake) CreateTag(dir string, tag string, msg string) error {
 	t := GitTag{
 		Name:    tag,
 		Message: msg,
+		Signed:  g.Signing != nil,
 	}
 	g.GitTags = append(g.GitTags, t)
 	return nil
 }
 
+// ConfigureSigning signs the tags created afterwards
+func (g *GitFake) ConfigureSigning(dir string, signing *GitSigning) error {
+	err := signing.Validate()
+	if err != nil {
+		return err
+	}
+	g.Signing = signing
+	return nil
+}
+
+// VerifyTag fails if the tag does not exist or is not signed
+func (g *GitFake) VerifyTag(dir string, tag string) error {
+	for _, t := range g.GitTags {
+		if t.Name == tag {
+			if !t.Signed {
+				return fmt.Errorf("tag %s is not signed", tag)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("tag %s not found", tag)
+}
+
+// VerifyCommit fails if signing is not configured
+func (g *GitFake) VerifyCommit(dir string, commitish string) error {
+	if g.Signing == nil {
+		return fmt.Errorf("commit %s is not signed", commitish)
+	}
+	return nil
+}
+
 // GetRevisionBeforeDate get the revision before the date
 func (g *GitFake) GetRevisionBeforeDate(dir string, t time.Time) (string, error) {
 	return g.Revision, nil
@@ -499,7 +535,7 @@ func (g *GitFake) Diff(dir string) (string, error) {
 
 // ListChangedFilesFromBranch lists changes files between current checkout and a branch
 func (g *GitFake) ListChangedFilesFromBranch(dir string, branch string) (string, error) {
-	return "", nil
+	return g.ChangedFilesFromBranch, nil
 }
 
 // LoadFileFromBranch returns a files's contents from a branch
@@ -0,0 +1,908 @@
+package gits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+	gitcfg "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	formatcfg "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+const (
+	// GitImplementationEnvVar is the environment variable which selects the Gitter implementation
+	GitImplementationEnvVar = "JX_GIT_IMPLEMENTATION"
+	// GitImplementationCLI selects the Gitter which invokes the git binary
+	GitImplementationCLI = "cli"
+	// GitImplementationGo selects the Gitter which uses a pure Go git library for the common operations
+	GitImplementationGo = "go"
+
+	defaultRemoteName = "origin"
+)
+
+// GitImplementations are the names of the Gitter implementations
+var GitImplementations = []string{GitImplementationCLI, GitImplementationGo}
+
+// NewGitter creates the Gitter of the implementation, or of the implementation selected by the
+// $JX_GIT_IMPLEMENTATION environment variable if it is blank, defaulting to the git binary
+func NewGitter(implementation string) Gitter {
+	if implementation == "" {
+		implementation = os.Getenv(GitImplementationEnvVar)
+	}
+	switch implementation {
+	case GitImplementationGo:
+		return NewGitGo()
+	case GitImplementationCLI, "":
+		return NewGitCLI()
+	default:
+		log.Warnf("Unknown git implementation %s, using %s instead of one of %s\n", implementation, GitImplementationCLI, strings.Join(GitImplementations, ", "))
+		return NewGitCLI()
+	}
+}
+
+// GitGo implements the common Gitter operations such as clone, fetch, commit, push, tags, branches and remotes with
+// a pure Go git library so that they do not need the git binary. The other operations are delegated to the git
+// binary by the embedded GitCLI.
+type GitGo struct {
+	*GitCLI
+}
+
+// NewGitGo creates a new GitGo instance
+func NewGitGo() *GitGo {
+	return &GitGo{
+		GitCLI: NewGitCLI(),
+	}
+}
+
+// open opens the repository which contains the directory
+func (g *GitGo) open(dir string) (*git.Repository, error) {
+	root, err := findGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the git repository in %s", root)
+	}
+	return repo, nil
+}
+
+// findGitRoot finds the directory containing the .git directory in the directory or its parents, as the
+// repositories which have just been initialised have no .git/config file for FindGitConfigDir to find
+func findGitRoot(dir string) (string, error) {
+	d, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		exists, err := util.FileExists(filepath.Join(d, ".git"))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("no git repository found in %s", dir)
+		}
+		d = parent
+	}
+}
+
+// signs returns true if the repository is configured to sign its commits or tags, which GitGo delegates to the git
+// binary as signing needs the gpg or ssh-keygen binaries anyway
+func (g *GitGo) signs(repo *git.Repository, section string) bool {
+	cfg, err := repo.Config()
+	if err != nil || cfg.Raw == nil {
+		return false
+	}
+	return strings.EqualFold(cfg.Raw.Section(section).Option("gpgsign"), "true")
+}
+
+// worktree opens the repository which contains the directory and returns its worktree
+func (g *GitGo) worktree(dir string) (*git.Repository, *git.Worktree, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get the worktree of the git repository in %s", dir)
+	}
+	return repo, w, nil
+}
+
+// Init inits a git repository into the given directory
+func (g *GitGo) Init(dir string) error {
+	_, err := git.PlainInit(dir, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to init a git repository in %s", dir)
+	}
+	return nil
+}
+
+// Clone clones the given git URL into the given directory
+func (g *GitGo) Clone(url string, dir string) error {
+	return g.clone(url, dir, &git.CloneOptions{})
+}
+
+// ShallowCloneBranch clones a single branch of the given git URL into the given directory
+func (g *GitGo) ShallowCloneBranch(url string, branch string, dir string) error {
+	return g.clone(url, dir, &git.CloneOptions{
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+		Depth:         1,
+	})
+}
+
+func (g *GitGo) clone(gitURL string, dir string, opts *git.CloneOptions) error {
+	opts.URL = gitURL
+	opts.RemoteName = defaultRemoteName
+	opts.Auth = authForURL(gitURL)
+	_, err := git.PlainClone(dir, false, opts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone %s into %s", stripCredentials(gitURL), dir)
+	}
+	return nil
+}
+
+// CloneOrPull clones the given git URL or pulls if it already exists
+func (g *GitGo) CloneOrPull(url string, dir string) error {
+	empty, err := util.IsEmpty(dir)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return g.Pull(dir)
+	}
+	return g.Clone(url, dir)
+}
+
+// Pull fast forwards the current branch to its branch on the origin
+func (g *GitGo) Pull(dir string) error {
+	repo, w, err := g.worktree(dir)
+	if err != nil {
+		return err
+	}
+	err = w.Pull(&git.PullOptions{
+		RemoteName: defaultRemoteName,
+		Auth:       remoteAuth(repo, defaultRemoteName),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "failed to pull in %s", dir)
+	}
+	return nil
+}
+
+// FetchBranch fetches the refspecs from the repo, which is either the name of a remote or a URL
+func (g *GitGo) FetchBranch(dir string, repo string, refspecs ...string) error {
+	return g.fetch(dir, repo, 0, git.TagFollowing, refspecs...)
+}
+
+// FetchBranchShallow fetches the last commit of the refspecs from the repo
+func (g *GitGo) FetchBranchShallow(dir string, repo string, refspecs ...string) error {
+	return g.fetch(dir, repo, 1, git.TagFollowing, refspecs...)
+}
+
+// FetchTags fetches all the tags of the origin
+func (g *GitGo) FetchTags(dir string) error {
+	return g.fetch(dir, defaultRemoteName, 0, git.AllTags, "+refs/tags/*:refs/tags/*")
+}
+
+func (g *GitGo) fetch(dir string, remoteName string, depth int, tags git.TagMode, refspecs ...string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	remote, err := repo.Remote(remoteName)
+	if err == git.ErrRemoteNotFound {
+		// fetching from a URL rather than from a configured remote
+		remote = git.NewRemote(repo.Storer, &gitcfg.RemoteConfig{
+			Name: "anonymous",
+			URLs: []string{remoteName},
+		})
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to find the remote %s in %s", remoteName, dir)
+	}
+	specs := []gitcfg.RefSpec{}
+	for _, refspec := range refspecs {
+		specs = append(specs, fetchRefSpec(remote.Config().Name, refspec))
+	}
+	err = remote.Fetch(&git.FetchOptions{
+		RefSpecs: specs,
+		Depth:    depth,
+		Tags:     tags,
+		Auth:     authForURL(firstURL(remote.Config())),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "failed to fetch %s from %s in %s", strings.Join(refspecs, " "), stripCredentials(remoteName), dir)
+	}
+	return nil
+}
+
+// fetchRefSpec expands the branch names and refs which git fetch accepts into refspecs which update the remote
+// tracking branches
+func fetchRefSpec(remoteName string, refspec string) gitcfg.RefSpec {
+	if strings.Contains(refspec, ":") {
+		return gitcfg.RefSpec(refspec)
+	}
+	refspec = strings.TrimPrefix(refspec, "+")
+	if strings.HasPrefix(refspec, "refs/heads/") {
+		return gitcfg.RefSpec(fmt.Sprintf("+%s:refs/remotes/%s/%s", refspec, remoteName, strings.TrimPrefix(refspec, "refs/heads/")))
+	}
+	if strings.HasPrefix(refspec, "refs/") {
+		return gitcfg.RefSpec(fmt.Sprintf("+%s:%s", refspec, refspec))
+	}
+	return gitcfg.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", refspec, remoteName, refspec))
+}
+
+// Push pushes the current branch to the branch of the same name on the origin
+func (g *GitGo) Push(dir string) error {
+	branch, err := g.Branch(dir)
+	if err != nil {
+		return err
+	}
+	return g.push(dir, defaultRemoteName, fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))
+}
+
+// PushMaster pushes the master branch into the origin
+func (g *GitGo) PushMaster(dir string) error {
+	return g.push(dir, defaultRemoteName, "refs/heads/master:refs/heads/master")
+}
+
+// PushTag pushes the given tag into the origin
+func (g *GitGo) PushTag(dir string, tag string) error {
+	return g.push(dir, defaultRemoteName, fmt.Sprintf("refs/tags/%s:refs/tags/%s", tag, tag))
+}
+
+// ForcePushBranch does a force push of the local branch into the remote branch of the repository at the given directory
+func (g *GitGo) ForcePushBranch(dir string, localBranch string, remoteBranch string) error {
+	return g.push(dir, defaultRemoteName, fmt.Sprintf("+refs/heads/%s:refs/heads/%s", localBranch, remoteBranch))
+}
+
+// DeleteRemoteBranch deletes the remote branch in the given given directory
+func (g *GitGo) DeleteRemoteBranch(dir string, remoteName string, branch string) error {
+	return g.push(dir, remoteName, ":refs/heads/"+branch)
+}
+
+func (g *GitGo) push(dir string, remoteName string, refspec string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	err = repo.Push(&git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []gitcfg.RefSpec{gitcfg.RefSpec(refspec)},
+		Auth:       remoteAuth(repo, remoteName),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "failed to push %s to %s from %s", refspec, remoteName, dir)
+	}
+	return nil
+}
+
+// Add adds the files or directories to the index, where ".", "*", "-A" and "--all" add all the changes. Files are
+// added directly so that only directories and deleted files need the status of the worktree.
+func (g *GitGo) Add(dir string, args ...string) error {
+	repo, w, err := g.worktree(dir)
+	if err != nil {
+		return err
+	}
+	root := w.Filesystem.Root()
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the index of %s", dir)
+	}
+	var status git.Status
+	for _, arg := range args {
+		switch {
+		case arg == "." || arg == "*" || arg == "-A" || arg == "--all":
+			arg = "."
+		case strings.HasPrefix(arg, "-"):
+			continue
+		}
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(absRoot, absPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find %s in the git repository %s", arg, root)
+		}
+		prefix := filepath.ToSlash(rel)
+
+		info, err := w.Filesystem.Lstat(prefix)
+		if err == nil && !info.IsDir() {
+			err = addToIndex(repo, w, idx, prefix, info)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add %s to the index of %s", prefix, dir)
+			}
+			continue
+		}
+		if status == nil {
+			status, err = w.Status()
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the status of %s", dir)
+			}
+		}
+		for path, fileStatus := range status {
+			if fileStatus.Worktree == git.Unmodified || !matchesPathPrefix(path, []string{prefix}) {
+				continue
+			}
+			if fileStatus.Worktree == git.Deleted {
+				removeFromIndex(idx, path)
+				continue
+			}
+			info, err := w.Filesystem.Lstat(path)
+			if err == nil {
+				err = addToIndex(repo, w, idx, path, info)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to add %s to the index of %s", path, dir)
+			}
+		}
+	}
+	sort.Slice(idx.Entries, func(i, j int) bool {
+		return idx.Entries[i].Name < idx.Entries[j].Name
+	})
+	err = repo.Storer.SetIndex(idx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the index of %s", dir)
+	}
+	return nil
+}
+
+// addToIndex stores the content of the file of the worktree as a blob and points its index entry at the blob
+func addToIndex(repo *git.Repository, w *git.Worktree, idx *index.Index, path string, info os.FileInfo) error {
+	var content []byte
+	var err error
+	if info.Mode()&os.ModeSymlink != 0 {
+		var target string
+		target, err = w.Filesystem.Readlink(path)
+		content = []byte(target)
+	} else {
+		var file billy.File
+		file, err = w.Filesystem.Open(path)
+		if err == nil {
+			content, err = ioutil.ReadAll(file)
+			file.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+	writer, err := obj.Writer()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return err
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		return err
+	}
+
+	entry, err := idx.Entry(path)
+	if err != nil {
+		entry = &index.Entry{Name: path}
+		idx.Entries = append(idx.Entries, entry)
+	}
+	entry.Hash = hash
+	entry.Mode = mode
+	entry.ModifiedAt = info.ModTime()
+	entry.Size = uint32(info.Size())
+	return nil
+}
+
+// removeFromIndex removes the entry of the path from the index
+func removeFromIndex(idx *index.Index, path string) {
+	entries := idx.Entries[:0]
+	for _, entry := range idx.Entries {
+		if entry.Name != path {
+			entries = append(entries, entry)
+		}
+	}
+	idx.Entries = entries
+}
+
+func matchesPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == "." || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasChanges indicates if there are any changes in the repository from the given directory
+func (g *GitGo) HasChanges(dir string) (bool, error) {
+	_, w, err := g.worktree(dir)
+	if err != nil {
+		return false, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get the status of %s", dir)
+	}
+	return !status.IsClean(), nil
+}
+
+// CommitIfChanges does a commit if there are any changes in the repository at the given directory
+func (g *GitGo) CommitIfChanges(dir string, message string) error {
+	changed, err := g.HasChanges(dir)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	return g.CommitDir(dir, message)
+}
+
+// CommitDir commits the changes added to the index of the repository at the given directory
+func (g *GitGo) CommitDir(dir string, message string) error {
+	return g.commit(dir, message, false)
+}
+
+// AddCommit commits all the changes of the tracked files of the repository at the given directory
+func (g *GitGo) AddCommit(dir string, msg string) error {
+	return g.commit(dir, msg, true)
+}
+
+func (g *GitGo) commit(dir string, message string, all bool) error {
+	repo, w, err := g.worktree(dir)
+	if err != nil {
+		return err
+	}
+	if g.signs(repo, "commit") {
+		if all {
+			return g.GitCLI.AddCommit(dir, message)
+		}
+		return g.GitCLI.CommitDir(dir, message)
+	}
+	signature := g.signature(repo)
+	_, err = w.Commit(message, &git.CommitOptions{
+		All:       all,
+		Author:    signature,
+		Committer: signature,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit in %s", dir)
+	}
+	return nil
+}
+
+// signature returns the signature of the user of the repository from the $GIT_AUTHOR_NAME and $GIT_AUTHOR_EMAIL
+// environment variables, the repository configuration or the global git configuration
+func (g *GitGo) signature(repo *git.Repository) *object.Signature {
+	answer := &object.Signature{
+		Name:  os.Getenv("GIT_AUTHOR_NAME"),
+		Email: os.Getenv("GIT_AUTHOR_EMAIL"),
+		When:  time.Now(),
+	}
+	configs := []*formatcfg.Config{}
+	if cfg, err := repo.Config(); err == nil && cfg.Raw != nil {
+		configs = append(configs, cfg.Raw)
+	}
+	if global := globalGitConfig(); global != nil {
+		configs = append(configs, global)
+	}
+	for _, cfg := range configs {
+		user := cfg.Section("user")
+		if answer.Name == "" {
+			answer.Name = user.Option("name")
+		}
+		if answer.Email == "" {
+			answer.Email = user.Option("email")
+		}
+	}
+	return answer
+}
+
+// globalGitConfig loads the git configuration of the user if there is one
+func globalGitConfig() *formatcfg.Config {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(home, ".gitconfig"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	cfg := formatcfg.New()
+	err = formatcfg.NewDecoder(f).Decode(cfg)
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// Tags returns all tags from the repository at the given directory
+func (g *GitGo) Tags(dir string) ([]string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the tags in %s", dir)
+	}
+	answer := []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		answer = append(answer, ref.Name().Short())
+		return nil
+	})
+	sort.Strings(answer)
+	return answer, err
+}
+
+// CreateTag creates an annotated tag of the current commit with the given name and message, replacing any tag of
+// the same name. Signed tags are created with the git binary.
+func (g *GitGo) CreateTag(dir string, tag string, msg string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	if g.signs(repo, "tag") {
+		return g.GitCLI.CreateTag(dir, tag, msg)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the current commit in %s", dir)
+	}
+	annotated := &object.Tag{
+		Name:       tag,
+		Tagger:     *g.signature(repo),
+		Message:    msg,
+		TargetType: plumbing.CommitObject,
+		Target:     head.Hash(),
+	}
+	obj := repo.Storer.NewEncodedObject()
+	err = annotated.Encode(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode the tag %s", tag)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store the tag %s", tag)
+	}
+	err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(tag), hash))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the tag %s in %s", tag, dir)
+	}
+	return nil
+}
+
+// Branch returns the current branch of the repository located at the given directory, or HEAD if no branch is
+// checked out
+func (g *GitGo) Branch(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the current branch in %s", dir)
+	}
+	if !head.Name().IsBranch() {
+		return "HEAD", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// LocalBranches will list all local branches
+func (g *GitGo) LocalBranches(dir string) ([]string, error) {
+	return g.references(dir, func(name plumbing.ReferenceName) bool {
+		return name.IsBranch()
+	})
+}
+
+// RemoteBranches returns the remote branches such as origin/master
+func (g *GitGo) RemoteBranches(dir string) ([]string, error) {
+	return g.references(dir, func(name plumbing.ReferenceName) bool {
+		return name.IsRemote()
+	})
+}
+
+func (g *GitGo) references(dir string, filter func(name plumbing.ReferenceName) bool) ([]string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the references in %s", dir)
+	}
+	answer := []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if filter(ref.Name()) {
+			answer = append(answer, ref.Name().Short())
+		}
+		return nil
+	})
+	sort.Strings(answer)
+	return answer, err
+}
+
+// CreateBranch creates a branch with the given name from the current commit
+func (g *GitGo) CreateBranch(dir string, branch string) error {
+	return g.CreateBranchFrom(dir, branch, "HEAD")
+}
+
+// CreateBranchFrom creates a new branch called branchName from startPoint
+func (g *GitGo) CreateBranchFrom(dir string, branchName string, startPoint string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	name := plumbing.NewBranchReferenceName(branchName)
+	if _, err := repo.Reference(name, false); err == nil {
+		return fmt.Errorf("a branch named %s already exists in %s", branchName, dir)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(startPoint))
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s in %s", startPoint, dir)
+	}
+	err = repo.Storer.SetReference(plumbing.NewHashReference(name, *hash))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the branch %s in %s", branchName, dir)
+	}
+	return nil
+}
+
+// Checkout checks out the given branch, creating it from the branch of the same name of the origin if there is no
+// such local branch, or checks out the given commit
+func (g *GitGo) Checkout(dir string, branch string) error {
+	repo, w, err := g.worktree(dir)
+	if err != nil {
+		return err
+	}
+	name := plumbing.NewBranchReferenceName(branch)
+	opts := &git.CheckoutOptions{Branch: name}
+	if _, err := repo.Reference(name, false); err != nil {
+		remote, err := repo.Reference(plumbing.NewRemoteReferenceName(defaultRemoteName, branch), true)
+		if err == nil {
+			opts.Create = true
+			opts.Hash = remote.Hash()
+		} else {
+			hash, err := repo.ResolveRevision(plumbing.Revision(branch))
+			if err != nil {
+				return errors.Wrapf(err, "failed to find the branch %s in %s", branch, dir)
+			}
+			opts = &git.CheckoutOptions{Hash: *hash}
+		}
+	}
+	err = w.Checkout(opts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to checkout %s in %s", branch, dir)
+	}
+	return nil
+}
+
+// ResetHard performs a git reset --hard back to the commitish specified
+func (g *GitGo) ResetHard(dir string, commitish string) error {
+	repo, w, err := g.worktree(dir)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commitish))
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s in %s", commitish, dir)
+	}
+	err = w.Reset(&git.ResetOptions{
+		Commit: *hash,
+		Mode:   git.HardReset,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to reset %s to %s", dir, commitish)
+	}
+	return nil
+}
+
+// GetLatestCommitSha returns the sha of the last commit
+func (g *GitGo) GetLatestCommitSha(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the current commit in %s", dir)
+	}
+	return head.Hash().String(), nil
+}
+
+// GetLatestCommitMessage returns the latest git commit message
+func (g *GitGo) GetLatestCommitMessage(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the current commit in %s", dir)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load the commit %s in %s", head.Hash(), dir)
+	}
+	return strings.TrimSpace(commit.Message), nil
+}
+
+// AddRemote adds a remote repository at the given URL and with the given name
+func (g *GitGo) AddRemote(dir string, name string, url string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreateRemote(&gitcfg.RemoteConfig{
+		Name: name,
+		URLs: []string{url},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to add the remote %s in %s", name, dir)
+	}
+	return nil
+}
+
+// SetRemoteURL sets the remote URL of the remote with the given name, adding the remote if there is none
+func (g *GitGo) SetRemoteURL(dir string, name string, gitURL string) error {
+	repo, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the git configuration of %s", dir)
+	}
+	remote := cfg.Remotes[name]
+	if remote == nil {
+		return g.AddRemote(dir, name, gitURL)
+	}
+	remote.URLs = []string{gitURL}
+	err = repo.Storer.SetConfig(cfg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the URL of the remote %s in %s", name, dir)
+	}
+	return nil
+}
+
+// UpdateRemote updates the URL of the origin
+func (g *GitGo) UpdateRemote(dir, url string) error {
+	return g.SetRemoteURL(dir, defaultRemoteName, url)
+}
+
+// remoteAuth returns the credentials of the URL of the remote
+func remoteAuth(repo *git.Repository, remoteName string) transport.AuthMethod {
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return nil
+	}
+	return authForURL(firstURL(remote.Config()))
+}
+
+// authForURL returns the basic authentication credentials of an HTTP URL such as those created by CreatePushURL or,
+// if the URL has none, the credentials of the git credential store which 'jx step git credentials' and the 'store'
+// credential helper write. It returns nil to use the default authentication of the transport.
+func authForURL(gitURL string) transport.AuthMethod {
+	if !strings.HasPrefix(gitURL, "http://") && !strings.HasPrefix(gitURL, "https://") {
+		return nil
+	}
+	u, err := url.Parse(gitURL)
+	if err != nil {
+		return nil
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			return &githttp.BasicAuth{
+				Username: u.User.Username(),
+				Password: password,
+			}
+		}
+	}
+	for _, fileName := range credentialStoreFiles() {
+		auth := findStoredCredentials(fileName, u)
+		if auth != nil {
+			return auth
+		}
+	}
+	return nil
+}
+
+// credentialStoreFiles returns the files which the git 'store' credential helper reads in the order it reads them
+// followed by the default file of 'jx step git credentials'
+func credentialStoreFiles() []string {
+	home := util.HomeDir()
+	files := []string{filepath.Join(home, ".git-credentials")}
+	if cfgHome := os.Getenv("XDG_CONFIG_HOME"); cfgHome != "" {
+		files = append(files, filepath.Join(cfgHome, "git", "credentials"))
+	} else {
+		files = append(files, filepath.Join(home, ".config", "git", "credentials"), filepath.Join(home, "git", "credentials"))
+	}
+	return files
+}
+
+// findStoredCredentials returns the credentials of the credential store file which match the URL or nil if there are
+// none. Like git a stored URL matches if it has the same protocol and host, the same user if the URL has one and, if
+// the stored URL has a path, the same path.
+func findStoredCredentials(fileName string, u *url.URL) transport.AuthMethod {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		stored, err := url.Parse(strings.TrimSpace(line))
+		if err != nil || stored.User == nil || stored.Scheme != u.Scheme || stored.Host != u.Host {
+			continue
+		}
+		if u.User != nil && u.User.Username() != stored.User.Username() {
+			continue
+		}
+		storedPath := strings.Trim(stored.Path, "/")
+		if storedPath != "" && storedPath != strings.Trim(u.Path, "/") {
+			continue
+		}
+		password, _ := stored.User.Password()
+		return &githttp.BasicAuth{
+			Username: stored.User.Username(),
+			Password: password,
+		}
+	}
+	return nil
+}
+
+func firstURL(remote *gitcfg.RemoteConfig) string {
+	if remote == nil || len(remote.URLs) == 0 {
+		return ""
+	}
+	return remote.URLs[0]
+}
+
+// stripCredentials removes any credentials from the URL so that it can be logged
+func stripCredentials(gitURL string) string {
+	u, err := url.Parse(gitURL)
+	if err != nil || u.User == nil {
+		return gitURL
+	}
+	return util.StripCredentialsFromURL(u)
+}
@@ -314,6 +314,21 @@ func (g *GitLocal) CreateTag(dir string, tag string, msg string) error {
 	return g.GitCLI.CreateTag(dir, tag, msg)
 }
 
+// ConfigureSigning configures the repository at the given directory to sign its commits and tags with the key
+func (g *GitLocal) ConfigureSigning(dir string, signing *GitSigning) error {
+	return g.GitCLI.ConfigureSigning(dir, signing)
+}
+
+// VerifyTag verifies the tag in the repository at the given directory is signed by a trusted key
+func (g *GitLocal) VerifyTag(dir string, tag string) error {
+	return g.GitCLI.VerifyTag(dir, tag)
+}
+
+// VerifyCommit verifies the commit in the repository at the given directory is signed by a trusted key
+func (g *GitLocal) VerifyCommit(dir string, commitish string) error {
+	return g.GitCLI.VerifyCommit(dir, commitish)
+}
+
 // PrintCreateRepositoryGenerateAccessToken prints the access token URL of a Git repository
 func (g *GitLocal) PrintCreateRepositoryGenerateAccessToken(server *auth.AuthServer, username string, o io.Writer) {
 	g.GitCLI.PrintCreateRepositoryGenerateAccessToken(server, username, o)
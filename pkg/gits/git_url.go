@@ -3,6 +3,7 @@ package gits
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/jenkins-x/jx/pkg/util"
@@ -15,17 +16,32 @@ const (
 	gitPrefix = "git@"
 )
 
+// azureDevOpsSSHRegex matches the SSH URLs of Azure DevOps such as git@ssh.dev.azure.com:v3/org/project/repo or
+// org@vs-ssh.visualstudio.com:v3/org/project/repo
+var azureDevOpsSSHRegex = regexp.MustCompile(`^[^@/]+@(ssh\.dev\.azure\.com|vs-ssh\.visualstudio\.com):v3/([^/]+)/([^/]+)/([^/]+?)/?$`)
+
 func (i *GitRepository) IsGitHub() bool {
 	return GitHubHost == i.Host || strings.HasSuffix(i.URL, "https://github.com")
 }
 
+// IsAzureDevOps returns true if the repository is hosted on Azure DevOps
+func (i *GitRepository) IsAzureDevOps() bool {
+	return IsAzureDevOpsHost(i.Host)
+}
+
 // PullRequestURL returns the URL of a pull request of the given name/number
 func (i *GitRepository) PullRequestURL(prName string) string {
+	if i.IsAzureDevOps() {
+		return util.UrlJoin(i.HttpsURL(), "pullrequest", prName)
+	}
 	return util.UrlJoin("https://"+i.Host, i.Organisation, i.Name, "pull", prName)
 }
 
 // HttpCloneURL returns the HTTPS git URL this repository
 func (i *GitRepository) HttpCloneURL() string {
+	if i.IsAzureDevOps() {
+		return i.HttpsURL()
+	}
 	return i.HttpsURL() + ".git"
 }
 
@@ -44,11 +60,21 @@ func (i *GitRepository) HttpsURL() string {
 	if !strings.Contains(host, ":/") {
 		host = "https://" + host
 	}
+	if i.IsAzureDevOps() {
+		return util.UrlJoin(host, i.ServerPath, i.Organisation, "_git", i.Name)
+	}
 	return util.UrlJoin(host, i.Organisation, i.Name)
 }
 
-// HostURL returns the URL to the host
+// HostURL returns the URL to the host, including the path of the organization for hosts such as Azure DevOps
 func (i *GitRepository) HostURL() string {
+	if i.ServerPath != "" {
+		return util.UrlJoin(i.hostURL(), i.ServerPath)
+	}
+	return i.hostURL()
+}
+
+func (i *GitRepository) hostURL() string {
 	answer := i.Host
 	if !strings.Contains(answer, ":/") {
 		// lets find the scheme from the URL
@@ -72,7 +98,16 @@ func (i *GitRepository) HostURL() string {
 	return answer
 }
 
+// HostURLWithoutUser returns the URL to the host without any user information, including the path of the
+// organization for hosts such as Azure DevOps
 func (i *GitRepository) HostURLWithoutUser() string {
+	if i.ServerPath != "" {
+		return util.UrlJoin(i.hostURLWithoutUser(), i.ServerPath)
+	}
+	return i.hostURLWithoutUser()
+}
+
+func (i *GitRepository) hostURLWithoutUser() string {
 	u := i.URL
 	if u != "" {
 		u2, err := url.Parse(u)
@@ -114,9 +149,26 @@ func ParseGitURL(text string) (*GitRepository, error) {
 			answer.Scheme = "https"
 		}
 		answer.Scheme = u.Scheme
+		if IsAzureDevOpsHost(answer.Host) {
+			return parseAzureDevOpsPath(u.Path, &answer)
+		}
 		return parsePath(u.Path, &answer)
 	}
 
+	if arr := azureDevOpsSSHRegex.FindStringSubmatch(text); arr != nil {
+		answer.Scheme = "git"
+		answer.Host = AzureDevOpsHost
+		answer.ServerPath = arr[2]
+		if arr[1] != "ssh."+AzureDevOpsHost {
+			answer.Host = arr[2] + azureDevOpsLegacyHostSuffix
+			answer.ServerPath = ""
+		}
+		answer.Organisation = arr[3]
+		answer.Project = arr[3]
+		answer.Name = arr[4]
+		return &answer, nil
+	}
+
 	// handle git@ kinds of URIs
 	if strings.HasPrefix(text, gitPrefix) {
 		t := strings.TrimPrefix(text, gitPrefix)
@@ -166,6 +218,22 @@ func parsePath(path string, info *GitRepository) (*GitRepository, error) {
 	return info, fmt.Errorf("Invalid path %s could not determine organisation and repository name", path)
 }
 
+// parseAzureDevOpsPath parses the path of an Azure DevOps repository such as /org/project/_git/repo on dev.azure.com
+// or /project/_git/repo on org.visualstudio.com. The project is used as the organisation of the repository.
+func parseAzureDevOpsPath(path string, info *GitRepository) (*GitRepository, error) {
+	arr := strings.Split(strings.Trim(path, "/"), "/")
+	gitIndex := util.StringArrayIndex(arr, "_git")
+	if gitIndex < 1 || gitIndex+1 >= len(arr) {
+		return info, fmt.Errorf("Invalid Azure DevOps path %s could not determine project and repository name", path)
+	}
+	// the path before the project is the organization on dev.azure.com or an optional collection on visualstudio.com
+	info.ServerPath = strings.Join(arr[:gitIndex-1], "/")
+	info.Organisation = arr[gitIndex-1]
+	info.Project = arr[gitIndex-1]
+	info.Name = strings.TrimSuffix(arr[gitIndex+1], ".git")
+	return info, nil
+}
+
 // SaasGitKind returns the kind for SaaS Git providers or "" if the URL could not be deduced
 func SaasGitKind(gitServiceUrl string) string {
 	gitServiceUrl = strings.TrimSuffix(gitServiceUrl, "/")
@@ -186,6 +254,13 @@ func SaasGitKind(gitServiceUrl string) string {
 		if strings.HasPrefix(gitServiceUrl, "https://github") {
 			return KindGitHub
 		}
+		if strings.HasPrefix(gitServiceUrl, AzureDevOpsURL+"/") {
+			return KindAzureDevOps
+		}
+		u, err := url.Parse(gitServiceUrl)
+		if err == nil && strings.HasSuffix(u.Host, azureDevOpsLegacyHostSuffix) {
+			return KindAzureDevOps
+		}
 		return ""
 	}
 }
@@ -79,6 +79,14 @@ func TestSaasKind(t *testing.T) {
 			gitURL: "https://github.test.com",
 			kind:   gits.KindGitHub,
 		},
+		"Azure DevOps": {
+			gitURL: "https://dev.azure.com/myorg",
+			kind:   gits.KindAzureDevOps,
+		},
+		"Azure DevOps legacy URL": {
+			gitURL: "https://myorg.visualstudio.com",
+			kind:   gits.KindAzureDevOps,
+		},
 	}
 
 	for name, tc := range tests {
@@ -96,3 +104,36 @@ func TestGitInfoProviderURL(t *testing.T) {
 		assert.Equal(t, "https://github.com", info.ProviderURL(), "ProviderURL() for %s", u)
 	}
 }
+
+func TestParseAzureDevOpsURL(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		url         string
+		host        string
+		serverPath  string
+		providerURL string
+	}{
+		{"https://dev.azure.com/myorg/myproject/_git/myrepo", "dev.azure.com", "myorg", "https://dev.azure.com/myorg"},
+		{"https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", "dev.azure.com", "myorg", "https://dev.azure.com/myorg"},
+		{"https://dev.azure.com/myorg/myproject/_git/myrepo/pullrequest/12", "dev.azure.com", "myorg", "https://dev.azure.com/myorg"},
+		{"git@ssh.dev.azure.com:v3/myorg/myproject/myrepo", "dev.azure.com", "myorg", "https://dev.azure.com/myorg"},
+		{"https://myorg.visualstudio.com/myproject/_git/myrepo", "myorg.visualstudio.com", "", "https://myorg.visualstudio.com"},
+		{"https://myorg.visualstudio.com/DefaultCollection/myproject/_git/myrepo", "myorg.visualstudio.com", "DefaultCollection", "https://myorg.visualstudio.com/DefaultCollection"},
+		{"myorg@vs-ssh.visualstudio.com:v3/myorg/myproject/myrepo", "myorg.visualstudio.com", "", "https://myorg.visualstudio.com"},
+	}
+	for _, data := range testCases {
+		info, err := gits.ParseGitURL(data.url)
+		require.NoError(t, err, "for URL %s", data.url)
+		assert.Equal(t, data.host, info.Host, "Host does not match for input %s", data.url)
+		assert.Equal(t, data.serverPath, info.ServerPath, "ServerPath does not match for input %s", data.url)
+		assert.Equal(t, "myproject", info.Organisation, "Organisation does not match for input %s", data.url)
+		assert.Equal(t, "myrepo", info.Name, "Name does not match for input %s", data.url)
+		assert.Equal(t, data.providerURL, info.HostURLWithoutUser(), "HostURLWithoutUser() does not match for input %s", data.url)
+		assert.Equal(t, data.providerURL, info.ProviderURL(), "ProviderURL() does not match for input %s", data.url)
+		assert.Equal(t, data.providerURL+"/myproject/_git/myrepo", info.HttpCloneURL(), "HttpCloneURL() does not match for input %s", data.url)
+		assert.Equal(t, data.providerURL+"/myproject/_git/myrepo/pullrequest/3", info.PullRequestURL("3"), "PullRequestURL() does not match for input %s", data.url)
+	}
+
+	_, err := gits.ParseGitURL("https://dev.azure.com/myorg/myproject")
+	assert.Error(t, err, "an Azure DevOps URL without a repository")
+}
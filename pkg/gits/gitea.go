@@ -1,8 +1,10 @@
 package gits
 
 import (
-	"errors"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +19,10 @@ import (
 type GiteaProvider struct {
 	Username string
 	Client   *gitea.Client
+	// Transport sends the requests of the REST API client, caching the responses of GET requests
+	Transport http.RoundTripper
+	// Metrics records the calls made by the REST API client
+	Metrics *APIMetrics
 
 	Server auth.AuthServer
 	User   auth.UserAuth
@@ -26,12 +32,15 @@ type GiteaProvider struct {
 func NewGiteaProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	client := gitea.NewClient(server.URL, user.ApiToken)
 
+	metrics := NewAPIMetrics()
 	provider := GiteaProvider{
-		Client:   client,
-		Server:   *server,
-		User:     *user,
-		Username: user.Username,
-		Git:      git,
+		Client:    client,
+		Transport: NewCachingTransport(http.DefaultTransport, metrics),
+		Metrics:   metrics,
+		Server:    *server,
+		User:      *user,
+		Username:  user.Username,
+		Git:       git,
 	}
 
 	return &provider, nil
@@ -240,13 +249,69 @@ func (p *GiteaProvider) CreateWebHook(data *GitWebHookArguments) error {
 	return err
 }
 
+// ListWebHooks lists the webhooks of a repository
 func (p *GiteaProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	hooks, err := p.Client.ListRepoHooks(owner, repo)
+	if err != nil {
+		return webHooks, fmt.Errorf("Failed to list the webhooks of %s/%s due to: %s", owner, repo, err)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    hook.ID,
+			Owner: owner,
+			Repo:  &GitRepository{Organisation: owner, Name: repo},
+			URL:   hook.Config["url"],
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook changes the URL and secret of the webhook with the given ID or existing URL, creating the webhook if
+// there is no such webhook
 func (p *GiteaProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	owner := data.Owner
+	if owner == "" {
+		owner = p.Username
+	}
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("Missing property Repo")
+	}
+	repo := data.Repo.Name
+	hooks, err := p.Client.ListRepoHooks(owner, repo)
+	if err != nil {
+		return fmt.Errorf("Failed to list the webhooks of %s/%s due to: %s", owner, repo, err)
+	}
+	var existing *gitea.Hook
+	for _, hook := range hooks {
+		if (data.ID != 0 && hook.ID == data.ID) || (data.ExistingURL != "" && hook.Config["url"] == data.ExistingURL) {
+			existing = hook
+			break
+		}
+	}
+	if existing == nil {
+		return p.CreateWebHook(data)
+	}
+	config := map[string]string{}
+	for k, v := range existing.Config {
+		config[k] = v
+	}
+	config["url"] = data.URL
+	if data.Secret != "" {
+		config["secret"] = data.Secret
+	}
+	active := true
+	hook := gitea.EditHookOption{
+		Config: config,
+		Events: existing.Events,
+		Active: &active,
+	}
+	log.Infof("Updating Gitea webhook for %s/%s for url %s\n", util.ColorInfo(owner), util.ColorInfo(repo), util.ColorInfo(data.URL))
+	err = p.Client.EditRepoHook(owner, repo, existing.ID, hook)
+	if err != nil {
+		return fmt.Errorf("Failed to update webhook %d of %s/%s due to: %s", existing.ID, owner, repo, err)
+	}
+	return nil
 }
 
 func (p *GiteaProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPullRequest, error) {
@@ -316,9 +381,14 @@ func (p *GiteaProvider) updatePullRequest(pr *GitPullRequest, source *gitea.Pull
 	head := source.Head
 	if head != nil {
 		pr.LastCommitSha = head.Sha
+		pr.HeadRef = &head.Ref
 	} else {
 		pr.LastCommitSha = ""
 	}
+	if source.Base != nil {
+		pr.BaseRef = &source.Base.Ref
+		pr.BaseSha = source.Base.Sha
+	}
 	/*
 		TODO
 
@@ -555,7 +625,7 @@ func (p *GiteaProvider) ListCommitStatus(org string, repo string, sha string) ([
 	}
 	for _, result := range results {
 		status := &GitRepoStatus{
-			ID:          string(result.ID),
+			ID:          strconv.FormatInt(result.ID, 10),
 			Context:     result.Context,
 			URL:         result.URL,
 			TargetURL:   result.TargetURL,
@@ -567,8 +637,26 @@ func (p *GiteaProvider) ListCommitStatus(org string, repo string, sha string) ([
 	return answer, nil
 }
 
-func (b *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+// UpdateCommitStatus adds a status to a commit
+func (p *GiteaProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
+	options := gitea.CreateStatusOption{
+		State:       gitea.StatusState(status.State),
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+		Context:     status.Context,
+	}
+	result, err := p.Client.CreateStatus(org, repo, sha, options)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to update the status of commit %s of %s/%s due to: %s", sha, org, repo, err)
+	}
+	return &GitRepoStatus{
+		ID:          strconv.FormatInt(result.ID, 10),
+		Context:     result.Context,
+		URL:         result.URL,
+		TargetURL:   result.TargetURL,
+		State:       string(result.State),
+		Description: result.Description,
+	}, nil
 }
 
 func (p *GiteaProvider) RenameRepository(org string, name string, newName string) (*GitRepository, error) {
@@ -726,8 +814,27 @@ func (p *GiteaProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 	return &github.Response{}, nil
 }
 
+// GetContent returns the base64 encoded content of a file on the given ref, which defaults to master
 func (p *GiteaProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitea")
+	if ref == "" {
+		ref = "master"
+	}
+	path = strings.TrimPrefix(path, "/")
+	data, err := p.Client.GetFile(org, name, ref, path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the content of %s on %s of %s/%s due to: %s", path, ref, org, name, err)
+	}
+	return &GitFileContent{
+		Type:        "file",
+		Encoding:    "base64",
+		Size:        len(data),
+		Name:        path[strings.LastIndex(path, "/")+1:],
+		Path:        path,
+		Content:     base64.StdEncoding.EncodeToString(data),
+		Url:         util.UrlJoin(p.Server.URL, "api/v1/repos", org, name, "raw", ref, path),
+		HtmlUrl:     util.UrlJoin(p.Server.URL, org, name, "src", ref, path),
+		DownloadUrl: util.UrlJoin(p.Server.URL, org, name, "raw", ref, path),
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -736,11 +843,222 @@ func (p *GiteaProvider) ShouldForkForPullRequest(originalOwner string, repoName
 	return originalOwner != username
 }
 
+// giteaCommit is a commit returned by the commits API of Gitea which the client library does not support yet
+type giteaCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message   string           `json:"message"`
+		Author    *giteaCommitUser `json:"author"`
+		Committer *giteaCommitUser `json:"committer"`
+	} `json:"commit"`
+	Author    *gitea.User `json:"author"`
+	Committer *gitea.User `json:"committer"`
+}
+
+type giteaCommitUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// APIMetrics returns the metrics of the calls made to the Gitea API by the REST API client
+func (p *GiteaProvider) APIMetrics() *APIMetrics {
+	return p.Metrics
+}
+
+// rest returns the client of the REST API for the operations which the client library does not support
+func (p *GiteaProvider) rest() *restClient {
+	return newRestClient(util.UrlJoin(p.Server.URL, "api/v1"), p.Transport, headerAuth("Authorization", "token "+p.User.ApiToken))
+}
+
+// ListCommits lists the commits of a repository, starting from the branch or commit SHA of the arguments
 func (p *GiteaProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitea")
+	query := url.Values{}
+	if opt != nil {
+		if opt.SHA != "" {
+			query.Set("sha", opt.SHA)
+		}
+		if opt.Path != "" {
+			query.Set("path", opt.Path)
+		}
+		if opt.Page > 0 {
+			query.Set("page", strconv.Itoa(opt.Page))
+		}
+		if opt.PerPage > 0 {
+			query.Set("limit", strconv.Itoa(opt.PerPage))
+		}
+	}
+	commits := []*giteaCommit{}
+	err := p.rest().do(http.MethodGet, util.UrlJoin("repos", owner, repo, "commits"), query, nil, &commits)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the commits of %s/%s due to: %s", owner, repo, err)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range commits {
+		gitCommit := &GitCommit{
+			SHA:     commit.SHA,
+			Message: commit.Commit.Message,
+			URL:     commit.HTMLURL,
+		}
+		if commit.Commit.Author != nil {
+			gitCommit.Author = &GitUser{
+				Name:  commit.Commit.Author.Name,
+				Email: commit.Commit.Author.Email,
+			}
+			if commit.Author != nil {
+				gitCommit.Author.Login = commit.Author.UserName
+				gitCommit.Author.AvatarURL = commit.Author.AvatarURL
+			}
+		}
+		if commit.Commit.Committer != nil {
+			gitCommit.Committer = &GitUser{
+				Name:  commit.Commit.Committer.Name,
+				Email: commit.Commit.Committer.Email,
+			}
+			if commit.Committer != nil {
+				gitCommit.Committer.Login = commit.Committer.UserName
+				gitCommit.Committer.AvatarURL = commit.Committer.AvatarURL
+			}
+		}
+		answer = append(answer, gitCommit)
+	}
+	return answer, nil
+}
+
+type giteaReview struct {
+	ID          int64       `json:"id,omitempty"`
+	User        *gitea.User `json:"user,omitempty"`
+	Body        string      `json:"body"`
+	CommitID    string      `json:"commit_id,omitempty"`
+	State       string      `json:"state,omitempty"`
+	Event       string      `json:"event,omitempty"`
+	HTMLURL     string      `json:"html_url,omitempty"`
+	SubmittedAt *time.Time  `json:"submitted_at,omitempty"`
+}
+
+// giteaReviewStates maps the states of the Gitea reviews to the review states
+var giteaReviewStates = map[string]string{
+	"APPROVED":        ReviewStateApproved,
+	"REQUEST_CHANGES": ReviewStateChangesRequested,
+	"COMMENT":         ReviewStateCommented,
 }
 
-// AddLabelsToIssue adds labels to issues or pullrequests
+// ListReviews lists the submitted reviews of a pull request
+func (p *GiteaProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	reviews := []*giteaReview{}
+	err := p.rest().do(http.MethodGet, util.UrlJoin("repos", owner, repo, "pulls", strconv.Itoa(number), "reviews"), nil, nil, &reviews)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the reviews of pull request %d of %s/%s due to: %s", number, owner, repo, err)
+	}
+	answer := []*GitReview{}
+	for _, review := range reviews {
+		if giteaReviewStates[review.State] != "" {
+			answer = append(answer, toGiteaReview(review))
+		}
+	}
+	return answer, nil
+}
+
+// SubmitReview approves, requests changes to or comments on a pull request
+func (p *GiteaProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	request := &giteaReview{
+		Body:     review.Body,
+		CommitID: review.CommitSHA,
+	}
+	switch review.State {
+	case ReviewStateApproved:
+		request.Event = "APPROVED"
+	case ReviewStateChangesRequested:
+		request.Event = "REQUEST_CHANGES"
+	case ReviewStateCommented, "":
+		request.Event = "COMMENT"
+	default:
+		return nil, fmt.Errorf("unsupported review state %s", review.State)
+	}
+	result := &giteaReview{}
+	err := p.rest().do(http.MethodPost, util.UrlJoin("repos", owner, repo, "pulls", strconv.Itoa(number), "reviews"), nil, request, result)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to review pull request %d of %s/%s due to: %s", number, owner, repo, err)
+	}
+	return toGiteaReview(result), nil
+}
+
+// RequestReviewers requests reviews of a pull request from the users
+func (p *GiteaProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	request := map[string][]string{
+		"reviewers": reviewers,
+	}
+	err := p.rest().do(http.MethodPost, util.UrlJoin("repos", owner, repo, "pulls", strconv.Itoa(number), "requested_reviewers"), nil, request, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to request reviews of pull request %d of %s/%s from %s due to: %s", number, owner, repo, strings.Join(reviewers, ", "), err)
+	}
+	return nil
+}
+
+// GetBranchProtection is not supported as this version of the Gitea API has no branch protection
+func (p *GiteaProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	return nil, fmt.Errorf("Branch protection is not supported for Gitea")
+}
+
+// SetBranchProtection is not supported as this version of the Gitea API has no branch protection
+func (p *GiteaProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	return fmt.Errorf("Branch protection is not supported for Gitea")
+}
+
+// RemoveBranchProtection is not supported as this version of the Gitea API has no branch protection
+func (p *GiteaProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	return fmt.Errorf("Branch protection is not supported for Gitea")
+}
+
+func toGiteaReview(review *giteaReview) *GitReview {
+	answer := &GitReview{
+		ID:          strconv.FormatInt(review.ID, 10),
+		State:       giteaReviewStates[review.State],
+		Body:        review.Body,
+		CommitSHA:   review.CommitID,
+		SubmittedAt: review.SubmittedAt,
+		URL:         review.HTMLURL,
+	}
+	if review.User != nil {
+		answer.User = toGiteaUser(review.User)
+	}
+	return answer
+}
+
+// AddLabelsToIssue adds labels to issues or pullrequests, creating the labels which the repository does not have yet
 func (p *GiteaProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	return fmt.Errorf("Getting content not supported on gitea")
+	repoLabels, err := p.Client.ListRepoLabels(owner, repo)
+	if err != nil {
+		return fmt.Errorf("Failed to list the labels of %s/%s due to: %s", owner, repo, err)
+	}
+	ids := []int64{}
+	for _, name := range labels {
+		var label *gitea.Label
+		for _, l := range repoLabels {
+			if l.Name == name {
+				label = l
+				break
+			}
+		}
+		if label == nil {
+			label, err = p.Client.CreateLabel(owner, repo, gitea.CreateLabelOption{
+				Name:  name,
+				Color: "#ededed",
+			})
+			if err != nil {
+				return fmt.Errorf("Failed to create the label %s on %s/%s due to: %s", name, owner, repo, err)
+			}
+			repoLabels = append(repoLabels, label)
+		}
+		ids = append(ids, label.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err = p.Client.AddIssueLabels(owner, repo, int64(number), gitea.IssueLabelsOption{Labels: ids})
+	if err != nil {
+		return fmt.Errorf("Failed to add the labels %s to issue %d of %s/%s due to: %s", strings.Join(labels, ", "), number, owner, repo, err)
+	}
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -30,6 +31,9 @@ type GitHubProvider struct {
 	Server auth.AuthServer
 	User   auth.UserAuth
 	Git    Gitter
+
+	// Metrics records the calls made to the GitHub API
+	Metrics *APIMetrics
 }
 
 func NewGitHubProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
@@ -41,12 +45,15 @@ func NewGitHubProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter)
 		Context:  ctx,
 		Username: user.Username,
 		Git:      git,
+		Metrics:  NewAPIMetrics(),
 	}
 
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: user.ApiToken},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	// conditional requests answered from the cache do not count against the rate limit of GitHub
+	tc.Transport = NewCachingTransport(tc.Transport, provider.Metrics)
 
 	var err error
 	u := server.URL
@@ -59,6 +66,11 @@ func NewGitHubProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter)
 	return &provider, err
 }
 
+// APIMetrics returns the metrics of the calls made to the GitHub API
+func (p *GitHubProvider) APIMetrics() *APIMetrics {
+	return p.Metrics
+}
+
 func GitHubEnterpriseApiEndpointURL(u string) string {
 	if IsGitHubServerURL(u) {
 		return u
@@ -115,7 +127,11 @@ func (p *GitHubProvider) ListOrganisations() ([]GitOrganisation, error) {
 }
 
 func (p *GitHubProvider) IsUserInOrganisation(user string, org string) (bool, error) {
-	membership, _, err := p.Client.Organizations.GetOrgMembership(p.Context, user, org)
+	membership, resp, err := p.Client.Organizations.GetOrgMembership(p.Context, user, org)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// GitHub answers not found for users who are not members
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
@@ -557,6 +573,134 @@ func (p *GitHubProvider) AddLabelsToIssue(owner string, repo string, number int,
 	return nil
 }
 
+// ListReviews lists the reviews of a pull request
+func (p *GitHubProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	answer := []*GitReview{}
+	opt := &github.ListOptions{PerPage: pageSize}
+	for {
+		reviews, resp, err := p.Client.PullRequests.ListReviews(p.Context, owner, repo, number, opt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the reviews of the pull request %d of %s/%s", number, owner, repo)
+		}
+		for _, review := range reviews {
+			answer = append(answer, toGitHubReview(review))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return answer, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// SubmitReview approves, requests changes to or comments on a pull request
+func (p *GitHubProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	event, err := toGitHubReviewEvent(review.State)
+	if err != nil {
+		return nil, err
+	}
+	request := &github.PullRequestReviewRequest{
+		Event: &event,
+	}
+	if review.Body != "" {
+		request.Body = &review.Body
+	}
+	if review.CommitSHA != "" {
+		request.CommitID = &review.CommitSHA
+	}
+	result, _, err := p.Client.PullRequests.CreateReview(p.Context, owner, repo, number, request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to review the pull request %d of %s/%s", number, owner, repo)
+	}
+	return toGitHubReview(result), nil
+}
+
+// RequestReviewers requests reviews of a pull request from the users
+func (p *GitHubProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	_, _, err := p.Client.PullRequests.RequestReviewers(p.Context, owner, repo, number, github.ReviewersRequest{
+		Reviewers: reviewers,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to request reviews of the pull request %d of %s/%s from %s", number, owner, repo, strings.Join(reviewers, ", "))
+	}
+	return nil
+}
+
+// GetBranchProtection returns the protection of a branch or nil if the branch is not protected
+func (p *GitHubProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	protection, resp, err := p.Client.Repositories.GetBranchProtection(p.Context, owner, repo, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the protection of the branch %s of %s/%s", branch, owner, repo)
+	}
+	answer := &GitBranchProtection{}
+	if checks := protection.RequiredStatusChecks; checks != nil {
+		answer.RequiredContexts = checks.Contexts
+		answer.Strict = checks.Strict
+	}
+	if reviews := protection.RequiredPullRequestReviews; reviews != nil {
+		answer.RequiredApprovingReviewCount = reviews.RequiredApprovingReviewCount
+		answer.DismissStaleReviews = reviews.DismissStaleReviews
+		answer.RequireCodeOwnerReviews = reviews.RequireCodeOwnerReviews
+	}
+	if protection.EnforceAdmins != nil {
+		answer.EnforceAdmins = protection.EnforceAdmins.Enabled
+	}
+	return answer, nil
+}
+
+// SetBranchProtection protects a branch, keeping the users and teams the existing protection restricts pushes to
+func (p *GitHubProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	request := &github.ProtectionRequest{
+		EnforceAdmins: protection.EnforceAdmins,
+	}
+	if len(protection.RequiredContexts) > 0 || protection.Strict {
+		request.RequiredStatusChecks = &github.RequiredStatusChecks{
+			Strict:   protection.Strict,
+			Contexts: append([]string{}, protection.RequiredContexts...),
+		}
+	}
+	if protection.RequiredApprovingReviewCount > 0 || protection.DismissStaleReviews || protection.RequireCodeOwnerReviews {
+		request.RequiredPullRequestReviews = &github.PullRequestReviewsEnforcementRequest{
+			DismissStaleReviews:          protection.DismissStaleReviews,
+			RequireCodeOwnerReviews:      protection.RequireCodeOwnerReviews,
+			RequiredApprovingReviewCount: protection.RequiredApprovingReviewCount,
+		}
+	}
+	existing, resp, err := p.Client.Repositories.GetBranchProtection(p.Context, owner, repo, branch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return errors.Wrapf(err, "failed to get the protection of the branch %s of %s/%s", branch, owner, repo)
+	}
+	if existing != nil && existing.Restrictions != nil {
+		restrictions := &github.BranchRestrictionsRequest{
+			Users: []string{},
+			Teams: []string{},
+		}
+		for _, user := range existing.Restrictions.Users {
+			restrictions.Users = append(restrictions.Users, user.GetLogin())
+		}
+		for _, team := range existing.Restrictions.Teams {
+			restrictions.Teams = append(restrictions.Teams, team.GetSlug())
+		}
+		request.Restrictions = restrictions
+	}
+	_, _, err = p.Client.Repositories.UpdateBranchProtection(p.Context, owner, repo, branch, request)
+	if err != nil {
+		return errors.Wrapf(err, "failed to protect the branch %s of %s/%s", branch, owner, repo)
+	}
+	return nil
+}
+
+// RemoveBranchProtection removes the protection of a branch
+func (p *GitHubProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	resp, err := p.Client.Repositories.RemoveBranchProtection(p.Context, owner, repo, branch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return errors.Wrapf(err, "failed to remove the protection of the branch %s of %s/%s", branch, owner, repo)
+	}
+	return nil
+}
+
 // updatePullRequest updates the pr with the data from GitHub
 func (p *GitHubProvider) updatePullRequest(pr *GitPullRequest, source *github.PullRequest) {
 	head := source.Head
@@ -618,6 +762,10 @@ func (p *GitHubProvider) updatePullRequest(pr *GitPullRequest, source *github.Pu
 	if source.Head != nil {
 		pr.HeadRef = source.Head.Ref
 	}
+	if source.Base != nil {
+		pr.BaseRef = source.Base.Ref
+		pr.BaseSha = notNullString(source.Base.SHA)
+	}
 	if source.StatusesURL != nil {
 		pr.StatusesURL = source.StatusesURL
 	}
@@ -1128,6 +1276,35 @@ func toGitHubUser(user *github.User) *GitUser {
 	}
 }
 
+func toGitHubReview(review *github.PullRequestReview) *GitReview {
+	answer := &GitReview{
+		User:        toGitHubUser(review.User),
+		State:       review.GetState(),
+		Body:        review.GetBody(),
+		CommitSHA:   review.GetCommitID(),
+		SubmittedAt: review.SubmittedAt,
+		URL:         review.GetHTMLURL(),
+	}
+	if review.ID != nil {
+		answer.ID = strconv.FormatInt(*review.ID, 10)
+	}
+	return answer
+}
+
+// toGitHubReviewEvent returns the event of a GitHub review which results in the review state
+func toGitHubReviewEvent(state string) (string, error) {
+	switch state {
+	case ReviewStateApproved:
+		return "APPROVE", nil
+	case ReviewStateChangesRequested:
+		return "REQUEST_CHANGES", nil
+	case ReviewStateCommented, "":
+		return "COMMENT", nil
+	default:
+		return "", fmt.Errorf("unsupported review state %s", state)
+	}
+}
+
 func toGitHubLabel(label *github.Label) GitLabel {
 	return GitLabel{
 		Name:  asText(label.Name),
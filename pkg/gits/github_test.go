@@ -1,8 +1,13 @@
 package gits
 
 import (
-	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsOwnerGitHubUser_isOwner(t *testing.T) {
@@ -16,3 +21,33 @@ func TestIsOwnerGitHubUser_isNotOwner(t *testing.T) {
 	isOwnerGitHubUser := IsOwnerGitHubUser("owner", "notowner")
 	assert.False(t, isOwnerGitHubUser, "The owner must not be the same as the GitHubUser")
 }
+
+func TestIsUserInOrganisation(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/orgs/myorg/memberships/member":
+			w.Write([]byte(`{"state":"active","role":"member"}`))
+		case "/api/v3/orgs/myorg/memberships/stranger":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	provider, err := NewGitHubProvider(&auth.AuthServer{URL: server.URL}, &auth.UserAuth{Username: "bot", ApiToken: "token"}, nil)
+	require.NoError(t, err)
+	checker := provider.(OrganisationChecker)
+
+	member, err := checker.IsUserInOrganisation("member", "myorg")
+	require.NoError(t, err)
+	assert.True(t, member)
+
+	member, err = checker.IsUserInOrganisation("stranger", "myorg")
+	require.NoError(t, err, "users who are not members are not an error")
+	assert.False(t, member)
+
+	_, err = checker.IsUserInOrganisation("broken", "myorg")
+	assert.Error(t, err)
+}
@@ -2,8 +2,9 @@ package gits
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -23,17 +24,27 @@ type GitlabProvider struct {
 	Server auth.AuthServer
 	User   auth.UserAuth
 	Git    Gitter
+
+	// Metrics records the calls made to the GitLab API
+	Metrics *APIMetrics
 }
 
 func NewGitlabProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	u := server.URL
-	c := gitlab.NewClient(nil, user.ApiToken)
+	metrics := NewAPIMetrics()
+	httpClient := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, metrics)}
+	c := gitlab.NewClient(httpClient, user.ApiToken)
 	if !IsGitLabServerURL(u) {
 		if err := c.SetBaseURL(u); err != nil {
 			return nil, err
 		}
 	}
-	return WithGitlabClient(server, user, c, git)
+	provider, err := WithGitlabClient(server, user, c, git)
+	if err != nil {
+		return nil, err
+	}
+	provider.(*GitlabProvider).Metrics = metrics
+	return provider, nil
 }
 
 func IsGitLabServerURL(u string) bool {
@@ -53,6 +64,11 @@ func WithGitlabClient(server *auth.AuthServer, user *auth.UserAuth, client *gitl
 	return provider, nil
 }
 
+// APIMetrics returns the metrics of the calls made to the GitLab API
+func (g *GitlabProvider) APIMetrics() *APIMetrics {
+	return g.Metrics
+}
+
 func (g *GitlabProvider) ListRepositories(org string) ([]*GitRepository, error) {
 	result, _, err := getRepositories(g.Client, g.Username, org)
 	if err != nil {
@@ -66,9 +82,140 @@ func (g *GitlabProvider) ListRepositories(org string) ([]*GitRepository, error)
 	return repos, nil
 }
 
+// gitlabTag is a tag of a GitLab project along with its release notes
+type gitlabTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Release *struct {
+		TagName     string `json:"tag_name"`
+		Description string `json:"description"`
+	} `json:"release"`
+}
+
+// gitlabReleaseOptions are the options to create or update the release notes of a tag
+type gitlabReleaseOptions struct {
+	Description *string `json:"description,omitempty"`
+}
+
+// gitlabListCommitsOptions are the options to list the commits of a project, including the path of the files which
+// the commits change
+type gitlabListCommitsOptions struct {
+	gitlab.ListOptions
+	RefName *string    `url:"ref_name,omitempty" json:"ref_name,omitempty"`
+	Since   *time.Time `url:"since,omitempty" json:"since,omitempty"`
+	Until   *time.Time `url:"until,omitempty" json:"until,omitempty"`
+	Path    *string    `url:"path,omitempty" json:"path,omitempty"`
+}
+
+// gitlabUpdateLabelsOptions are the options to replace the labels of a merge request
+type gitlabUpdateLabelsOptions struct {
+	Labels *string `json:"labels,omitempty"`
+}
+
+// gitlabUser is a user as returned by the merge request approvals and reviewers APIs
+type gitlabUser struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	WebURL    string `json:"web_url"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// gitlabApprovals are the approvals of a merge request
+type gitlabApprovals struct {
+	ApprovedBy []struct {
+		User *gitlabUser `json:"user"`
+	} `json:"approved_by"`
+}
+
+// gitlabApproveOptions are the options to approve a merge request
+type gitlabApproveOptions struct {
+	SHA *string `json:"sha,omitempty"`
+}
+
+// gitlabReviewers are the reviewers of a merge request
+type gitlabReviewers struct {
+	Reviewers []*gitlabUser `json:"reviewers"`
+}
+
+// gitlabUpdateReviewersOptions are the options to replace the reviewers of a merge request
+type gitlabUpdateReviewersOptions struct {
+	ReviewerIDs []int `json:"reviewer_ids"`
+}
+
+const (
+	gitlabDeveloperAccess  = 30
+	gitlabMaintainerAccess = 40
+)
+
+// gitlabProtectBranchOptions are the options to protect a branch
+type gitlabProtectBranchOptions struct {
+	Name             string `json:"name"`
+	PushAccessLevel  int    `json:"push_access_level"`
+	MergeAccessLevel int    `json:"merge_access_level"`
+}
+
+// gitlabProtectedBranch is a protected branch of a project
+type gitlabProtectedBranch struct {
+	Name string `json:"name"`
+}
+
+// gitlabMergeSettings are the settings of a project which control when merge requests can be merged
+type gitlabMergeSettings struct {
+	OnlyAllowMergeIfPipelineSucceeds bool `json:"only_allow_merge_if_pipeline_succeeds"`
+}
+
+// gitlabApprovalSettings are the approval settings of a project, which are only available in GitLab EE
+type gitlabApprovalSettings struct {
+	ApprovalsBeforeMerge int  `json:"approvals_before_merge"`
+	ResetApprovalsOnPush bool `json:"reset_approvals_on_push"`
+}
+
+// do invokes a GitLab API which the client library does not support
+func (g *GitlabProvider) do(method string, path string, opt interface{}, result interface{}) error {
+	req, err := g.Client.NewRequest(method, path, opt, nil)
+	if err != nil {
+		return err
+	}
+	_, err = g.Client.Do(req, result)
+	return err
+}
+
+// isGitlabNotFound returns true if the error is the response of a GitLab API to a resource which does not exist
+func isGitlabNotFound(err error) bool {
+	errResp, ok := err.(*gitlab.ErrorResponse)
+	return ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// ListReleases lists the tags of a project which have release notes
 func (g *GitlabProvider) ListReleases(org string, name string) ([]*GitRelease, error) {
 	answer := []*GitRelease{}
-	// TODO
+	pid, err := g.projectId(org, g.Username, name)
+	if err != nil {
+		return answer, err
+	}
+	project, _, err := g.Client.Projects.GetProject(pid)
+	if err != nil {
+		return answer, fmt.Errorf("failed to get project %s due to: %s", pid, err)
+	}
+	tags := []*gitlabTag{}
+	err = g.do(http.MethodGet, "projects/"+pid+"/repository/tags", nil, &tags)
+	if err != nil {
+		return answer, fmt.Errorf("failed to list the tags of project %s due to: %s", pid, err)
+	}
+	for _, tag := range tags {
+		if tag.Release == nil {
+			continue
+		}
+		u := util.UrlJoin(project.WebURL, "tags", tag.Name)
+		answer = append(answer, &GitRelease{
+			Name:    tag.Name,
+			TagName: tag.Name,
+			Body:    tag.Release.Description,
+			URL:     u,
+			HTMLURL: u,
+		})
+	}
 	return answer, nil
 }
 
@@ -394,14 +541,51 @@ func (g *GitlabProvider) ListCommitStatus(org string, repo string, sha string) (
 
 // UpdateCommitStatus updates the commit status
 func (g *GitlabProvider) UpdateCommitStatus(org string, repo string, sha string, status *GitRepoStatus) (*GitRepoStatus, error) {
-	return &GitRepoStatus{}, errors.New("TODO")
+	pid, err := g.projectId(org, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	opt := &gitlab.SetCommitStatusOptions{
+		State:       toGitlabBuildState(status.State),
+		Name:        &status.Context,
+		TargetURL:   &status.TargetURL,
+		Description: &status.Description,
+	}
+	result, _, err := g.Client.Commits.SetCommitStatus(pid, sha, opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the status of commit %s of project %s due to: %s", sha, pid, err)
+	}
+	return fromCommitStatus(result), nil
+}
+
+// toGitlabBuildState converts the state of a commit status to the GitLab build state
+func toGitlabBuildState(state string) gitlab.BuildStateValue {
+	switch state {
+	case "success":
+		return gitlab.Success
+	case "failure", "error":
+		return gitlab.Failed
+	default:
+		return gitlab.Pending
+	}
 }
 
 func fromCommitStatus(status *gitlab.CommitStatus) *GitRepoStatus {
+	state := status.Status
+	switch state {
+	case "failed":
+		state = "failure"
+	case "running", "created":
+		state = "pending"
+	case "canceled":
+		state = "error"
+	}
 	return &GitRepoStatus{
-		ID:          string(status.ID),
+		ID:          strconv.Itoa(status.ID),
+		Context:     status.Name,
 		URL:         status.TargetURL,
-		State:       status.Status,
+		TargetURL:   status.TargetURL,
+		State:       state,
 		Description: status.Description,
 	}
 }
@@ -438,11 +622,61 @@ func (g *GitlabProvider) CreateWebHook(data *GitWebHookArguments) error {
 // ListWebHooks lists the webhooks
 func (g *GitlabProvider) ListWebHooks(owner string, repo string) ([]*GitWebHookArguments, error) {
 	webHooks := []*GitWebHookArguments{}
-	return webHooks, fmt.Errorf("not implemented!")
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return webHooks, err
+	}
+	hooks, _, err := g.Client.Projects.ListProjectHooks(pid, nil)
+	if err != nil {
+		return webHooks, fmt.Errorf("failed to list the webhooks of project %s due to: %s", pid, err)
+	}
+	for _, hook := range hooks {
+		webHooks = append(webHooks, &GitWebHookArguments{
+			ID:    int64(hook.ID),
+			Owner: owner,
+			Repo:  &GitRepository{Organisation: owner, Name: repo},
+			URL:   hook.URL,
+		})
+	}
+	return webHooks, nil
 }
 
+// UpdateWebHook changes the URL and secret of the webhook with the given ID or existing URL, creating the webhook if
+// there is no such webhook
 func (g *GitlabProvider) UpdateWebHook(data *GitWebHookArguments) error {
-	return fmt.Errorf("not implemented!")
+	if data.Repo == nil || data.Repo.Name == "" {
+		return fmt.Errorf("missing property Repo")
+	}
+	pid, err := g.projectId(data.Owner, g.Username, data.Repo.Name)
+	if err != nil {
+		return err
+	}
+	hooks, _, err := g.Client.Projects.ListProjectHooks(pid, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list the webhooks of project %s due to: %s", pid, err)
+	}
+	var existing *gitlab.ProjectHook
+	for _, hook := range hooks {
+		if (data.ID != 0 && int64(hook.ID) == data.ID) || (data.ExistingURL != "" && hook.URL == data.ExistingURL) {
+			existing = hook
+			break
+		}
+	}
+	if existing == nil {
+		return g.CreateWebHook(data)
+	}
+	opt := &gitlab.EditProjectHookOptions{
+		URL: &data.URL,
+	}
+	if data.Secret != "" {
+		opt.Token = &data.Secret
+	}
+	log.Infof("Updating GitLab webhook for %s/%s for url %s\n", util.ColorInfo(data.Owner), util.ColorInfo(data.Repo.Name), util.ColorInfo(data.URL))
+	_, _, err = g.Client.Projects.EditProjectHook(pid, existing.ID, opt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook %d of project %s due to: %s", existing.ID, pid, err)
+	}
+	return nil
 }
 
 func (g *GitlabProvider) SearchIssues(org, repo, query string) ([]*GitIssue, error) {
@@ -637,7 +871,27 @@ func (g *GitlabProvider) UserInfo(username string) *GitUser {
 	}
 }
 
+// UpdateRelease creates or updates the release notes of a tag
 func (g *GitlabProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	existing := &gitlabTag{}
+	path := "projects/" + pid + "/repository/tags/" + tag
+	err = g.do(http.MethodGet, path, nil, existing)
+	if err != nil {
+		return fmt.Errorf("failed to get tag %s of project %s due to: %s", tag, pid, err)
+	}
+	method := http.MethodPost
+	if existing.Release != nil {
+		method = http.MethodPut
+	}
+	opt := &gitlabReleaseOptions{Description: &releaseInfo.Body}
+	err = g.do(method, path+"/release", opt, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update the release of tag %s of project %s due to: %s", tag, pid, err)
+	}
 	return nil
 }
 
@@ -664,9 +918,29 @@ func (g *GitlabProvider) AcceptInvitation(ID int64) (*github.Response, error) {
 	return &github.Response{}, nil
 }
 
-// GetContent returns the content of a file
+// GetContent returns the content of a file on the given ref, which defaults to master
 func (g *GitlabProvider) GetContent(org string, name string, path string, ref string) (*GitFileContent, error) {
-	return nil, fmt.Errorf("Getting content not supported on gitlab")
+	pid, err := g.projectId(org, g.Username, name)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = "master"
+	}
+	path = strings.TrimPrefix(path, "/")
+	file, _, err := g.Client.RepositoryFiles.GetFile(pid, path, &gitlab.GetFileOptions{Ref: &ref})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the content of %s on %s of project %s due to: %s", path, ref, pid, err)
+	}
+	return &GitFileContent{
+		Type:     "file",
+		Encoding: file.Encoding,
+		Size:     file.Size,
+		Name:     file.FileName,
+		Path:     file.FilePath,
+		Content:  file.Content,
+		Sha:      file.BlobID,
+	}, nil
 }
 
 // ShouldForkForPullReques treturns true if we should create a personal fork of this repository
@@ -684,10 +958,309 @@ func GitlabAccessTokenURL(url string) string {
 
 // ListCommits lists the commits for the specified repo and owner
 func (g *GitlabProvider) ListCommits(owner, repo string, opt *ListCommitsArguments) ([]*GitCommit, error) {
-	return nil, fmt.Errorf("Listing commits not supported on gitlab")
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	options := &gitlabListCommitsOptions{}
+	if opt != nil {
+		options.Page = opt.Page
+		options.PerPage = opt.PerPage
+		if opt.SHA != "" {
+			options.RefName = &opt.SHA
+		}
+		if opt.Path != "" {
+			options.Path = &opt.Path
+		}
+		if !opt.Since.IsZero() {
+			options.Since = &opt.Since
+		}
+		if !opt.Until.IsZero() {
+			options.Until = &opt.Until
+		}
+	}
+	commits := []*gitlab.Commit{}
+	err = g.do(http.MethodGet, "projects/"+pid+"/repository/commits", options, &commits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits of project %s due to: %s", pid, err)
+	}
+	answer := []*GitCommit{}
+	for _, commit := range commits {
+		if opt != nil && opt.Author != "" && commit.AuthorName != opt.Author && commit.AuthorEmail != opt.Author {
+			continue
+		}
+		answer = append(answer, &GitCommit{
+			SHA:     commit.ID,
+			Message: commit.Message,
+			Author: &GitUser{
+				Name:  commit.AuthorName,
+				Email: commit.AuthorEmail,
+			},
+			Committer: &GitUser{
+				Name:  commit.CommitterName,
+				Email: commit.CommitterEmail,
+			},
+		})
+	}
+	return answer, nil
 }
 
 // AddLabelsToIssue adds labels to issues or pullrequests
 func (g *GitlabProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
-	return fmt.Errorf("Getting content not supported on gitlab yet")
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	mr, _, err := g.Client.MergeRequests.GetMergeRequest(pid, number)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request %d of project %s due to: %s", number, pid, err)
+	}
+	answer := append([]string{}, mr.Labels...)
+	for _, label := range labels {
+		if util.StringArrayIndex(answer, label) < 0 {
+			answer = append(answer, label)
+		}
+	}
+	text := strings.Join(answer, ",")
+	err = g.do(http.MethodPut, "projects/"+pid+"/merge_requests/"+strconv.Itoa(number), &gitlabUpdateLabelsOptions{Labels: &text}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add the labels %s to merge request %d of project %s due to: %s", strings.Join(labels, ", "), number, pid, err)
+	}
+	return nil
+}
+
+// ListReviews lists the approvals of a merge request as GitLab has no other kind of review
+func (g *GitlabProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	approvals := &gitlabApprovals{}
+	err = g.do(http.MethodGet, "projects/"+pid+"/merge_requests/"+strconv.Itoa(number)+"/approvals", nil, approvals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the approvals of merge request %d of project %s due to: %s", number, pid, err)
+	}
+	answer := []*GitReview{}
+	for _, approval := range approvals.ApprovedBy {
+		if approval.User == nil {
+			continue
+		}
+		answer = append(answer, &GitReview{
+			User:  fromGitlabUser(approval.User),
+			State: ReviewStateApproved,
+		})
+	}
+	return answer, nil
+}
+
+// SubmitReview approves a merge request or comments on it. GitLab has no way to request changes so such a review
+// fails rather than being submitted as a comment which would not block the merge request.
+func (g *GitlabProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	answer := &GitReview{
+		State:     review.State,
+		Body:      review.Body,
+		CommitSHA: review.CommitSHA,
+	}
+	switch review.State {
+	case ReviewStateApproved:
+		opt := &gitlabApproveOptions{}
+		if review.CommitSHA != "" {
+			opt.SHA = &review.CommitSHA
+		}
+		err = g.do(http.MethodPost, "projects/"+pid+"/merge_requests/"+strconv.Itoa(number)+"/approve", opt, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to approve merge request %d of project %s due to: %s", number, pid, err)
+		}
+		if review.Body == "" {
+			return answer, nil
+		}
+	case ReviewStateChangesRequested:
+		return nil, fmt.Errorf("cannot request changes to merge request %d of project %s as GitLab does not support it, comment on it instead", number, pid)
+	case ReviewStateCommented, "":
+		if review.Body == "" {
+			return nil, fmt.Errorf("a review of merge request %d of project %s which is not an approval needs a body", number, pid)
+		}
+		if answer.State == "" {
+			answer.State = ReviewStateCommented
+		}
+	default:
+		return nil, fmt.Errorf("unsupported review state %s", review.State)
+	}
+	note, _, err := g.Client.Notes.CreateMergeRequestNote(pid, number, &gitlab.CreateMergeRequestNoteOptions{Body: &review.Body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to comment on merge request %d of project %s due to: %s", number, pid, err)
+	}
+	answer.ID = strconv.Itoa(note.ID)
+	answer.SubmittedAt = note.CreatedAt
+	return answer, nil
+}
+
+// RequestReviewers adds the users to the reviewers of a merge request
+func (g *GitlabProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	path := "projects/" + pid + "/merge_requests/" + strconv.Itoa(number)
+	existing := &gitlabReviewers{}
+	err = g.do(http.MethodGet, path, nil, existing)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request %d of project %s due to: %s", number, pid, err)
+	}
+	ids := []int{}
+	for _, reviewer := range existing.Reviewers {
+		ids = append(ids, reviewer.ID)
+	}
+	for _, reviewer := range reviewers {
+		username := reviewer
+		users, _, err := g.Client.Users.ListUsers(&gitlab.ListUsersOptions{Username: &username})
+		if err != nil {
+			return fmt.Errorf("failed to find the user %s due to: %s", reviewer, err)
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no user %s found", reviewer)
+		}
+		found := false
+		for _, id := range ids {
+			if id == users[0].ID {
+				found = true
+			}
+		}
+		if !found {
+			ids = append(ids, users[0].ID)
+		}
+	}
+	err = g.do(http.MethodPut, path, &gitlabUpdateReviewersOptions{ReviewerIDs: ids}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to request reviews of merge request %d of project %s from %s due to: %s", number, pid, strings.Join(reviewers, ", "), err)
+	}
+	return nil
+}
+
+// GetBranchProtection returns the protection of a branch or nil if the branch is not protected. GitLab can only
+// require the pipeline of a merge request to succeed so any required contexts are returned as AnyStatusContext.
+func (g *GitlabProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return nil, err
+	}
+	err = g.do(http.MethodGet, "projects/"+pid+"/protected_branches/"+url.PathEscape(branch), nil, nil)
+	if err != nil {
+		if isGitlabNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get the protection of branch %s of project %s due to: %s", branch, pid, err)
+	}
+	return g.projectBranchProtection(pid)
+}
+
+// projectBranchProtection returns the protection which the pipeline and approval settings of a project give to all
+// of its protected branches
+func (g *GitlabProvider) projectBranchProtection(pid string) (*GitBranchProtection, error) {
+	answer := &GitBranchProtection{}
+	settings := &gitlabMergeSettings{}
+	err := g.do(http.MethodGet, "projects/"+pid, nil, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project %s due to: %s", pid, err)
+	}
+	if settings.OnlyAllowMergeIfPipelineSucceeds {
+		answer.RequiredContexts = []string{AnyStatusContext}
+	}
+	approvals := &gitlabApprovalSettings{}
+	err = g.do(http.MethodGet, "projects/"+pid+"/approvals", nil, approvals)
+	if err != nil && !isGitlabNotFound(err) {
+		return nil, fmt.Errorf("failed to get the approval settings of project %s due to: %s", pid, err)
+	}
+	answer.RequiredApprovingReviewCount = approvals.ApprovalsBeforeMerge
+	answer.DismissStaleReviews = approvals.ResetApprovalsOnPush
+	return answer, nil
+}
+
+// SetBranchProtection protects a branch so that only maintainers can push to it. Any required contexts require the
+// pipelines of merge requests to succeed. The pipeline and approval settings of GitLab apply to all the branches of
+// a project so they are only changed when no other branch is protected, otherwise the protection of the branch has
+// to agree with the current settings. The approval settings are only available in GitLab EE.
+func (g *GitlabProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	current, err := g.projectBranchProtection(pid)
+	if err != nil {
+		return err
+	}
+	if (len(current.RequiredContexts) > 0) != (len(protection.RequiredContexts) > 0) ||
+		current.RequiredApprovingReviewCount != protection.RequiredApprovingReviewCount ||
+		current.DismissStaleReviews != protection.DismissStaleReviews {
+		protected := []gitlabProtectedBranch{}
+		err = g.do(http.MethodGet, "projects/"+pid+"/protected_branches", nil, &protected)
+		if err != nil {
+			return fmt.Errorf("failed to list the protected branches of project %s due to: %s", pid, err)
+		}
+		others := []string{}
+		for _, b := range protected {
+			if b.Name != branch {
+				others = append(others, b.Name)
+			}
+		}
+		if len(others) > 0 {
+			return fmt.Errorf("cannot change the protection of branch %s of project %s as its pipeline and approval settings also apply to the protected branches %s", branch, pid, strings.Join(others, ", "))
+		}
+	}
+	err = g.do(http.MethodGet, "projects/"+pid+"/protected_branches/"+url.PathEscape(branch), nil, nil)
+	if isGitlabNotFound(err) {
+		err = g.do(http.MethodPost, "projects/"+pid+"/protected_branches", &gitlabProtectBranchOptions{
+			Name:             branch,
+			PushAccessLevel:  gitlabMaintainerAccess,
+			MergeAccessLevel: gitlabDeveloperAccess,
+		}, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to protect branch %s of project %s due to: %s", branch, pid, err)
+	}
+	err = g.do(http.MethodPut, "projects/"+pid, &gitlabMergeSettings{
+		OnlyAllowMergeIfPipelineSucceeds: len(protection.RequiredContexts) > 0,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update the merge settings of project %s due to: %s", pid, err)
+	}
+	approvals := &gitlabApprovalSettings{
+		ApprovalsBeforeMerge: protection.RequiredApprovingReviewCount,
+		ResetApprovalsOnPush: protection.DismissStaleReviews,
+	}
+	err = g.do(http.MethodPost, "projects/"+pid+"/approvals", approvals, nil)
+	if err != nil {
+		if isGitlabNotFound(err) && approvals.ApprovalsBeforeMerge == 0 && !approvals.ResetApprovalsOnPush {
+			return nil
+		}
+		return fmt.Errorf("failed to update the approval settings of project %s due to: %s", pid, err)
+	}
+	return nil
+}
+
+// RemoveBranchProtection removes the protection of a branch. The pipeline and approval settings of the project are
+// left as they are as they apply to all of its branches.
+func (g *GitlabProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	err = g.do(http.MethodDelete, "projects/"+pid+"/protected_branches/"+url.PathEscape(branch), nil, nil)
+	if err != nil && !isGitlabNotFound(err) {
+		return fmt.Errorf("failed to remove the protection of branch %s of project %s due to: %s", branch, pid, err)
+	}
+	return nil
+}
+
+func fromGitlabUser(user *gitlabUser) *GitUser {
+	return &GitUser{
+		Login:     user.Username,
+		Name:      user.Name,
+		URL:       user.WebURL,
+		AvatarURL: user.AvatarURL,
+	}
 }
@@ -97,6 +97,9 @@ func configureGitlabMock(suite *GitlabProviderSuite, mux *http.ServeMux) {
 		fmt.Sprintf("/api/v4/projects/%s", gitlabProjectID): util.MethodMap{
 			"GET": "project.json",
 		},
+		fmt.Sprintf("/api/v4/projects/%s/protected_branches", gitlabProjectID): util.MethodMap{
+			"GET": "protected-branches.json",
+		},
 	}
 	for path, methodMap := range gitlabRouter {
 		mux.HandleFunc(path, util.GetMockAPIResponseFromFile("test_data/gitlab", methodMap))
@@ -174,6 +177,15 @@ func (suite *GitlabProviderSuite) TestAcceptInvitations() {
 
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
+func (suite *GitlabProviderSuite) TestSetBranchProtectionKeepsSettingsOfOtherBranches() {
+	// the project does not require pipelines to succeed and master is protected too
+	err := suite.provider.SetBranchProtection(gitlabOrgName, gitlabProjectName, "release", &gits.GitBranchProtection{
+		RequiredContexts: []string{gits.AnyStatusContext},
+	})
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "master")
+}
+
 func TestGitlabProviderSuite(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping TestGitlabProviderSuite in short mode")
@@ -0,0 +1,314 @@
+package gits_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/suite"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+var gitterSuiteEnv = map[string]string{
+	"GIT_AUTHOR_NAME":     "Jenkins X",
+	"GIT_AUTHOR_EMAIL":    "jenkins-x@example.com",
+	"GIT_COMMITTER_NAME":  "Jenkins X",
+	"GIT_COMMITTER_EMAIL": "jenkins-x@example.com",
+}
+
+// GitterTestSuite verifies the behaviour which the Gitter implementations share using local repositories
+type GitterTestSuite struct {
+	suite.Suite
+	gitter gits.Gitter
+
+	originalEnv map[string]string
+	tmpDir      string
+	remote      string
+	dir         string
+}
+
+func (suite *GitterTestSuite) SetupSuite() {
+	suite.originalEnv = map[string]string{}
+	env := map[string]string{}
+	for k, v := range gitterSuiteEnv {
+		env[k] = v
+	}
+	home, err := ioutil.TempDir("", "gitter-home-")
+	suite.Require().NoError(err)
+	// isolate the tests from the git configuration of the user
+	env["HOME"] = home
+	for k, v := range env {
+		suite.originalEnv[k] = os.Getenv(k)
+		suite.Require().NoError(os.Setenv(k, v))
+	}
+}
+
+func (suite *GitterTestSuite) TearDownSuite() {
+	os.RemoveAll(os.Getenv("HOME"))
+	for k, v := range suite.originalEnv {
+		os.Setenv(k, v)
+	}
+}
+
+// SetupTest creates a bare remote repository and a repository which pushed an initial commit to it
+func (suite *GitterTestSuite) SetupTest() {
+	var err error
+	suite.tmpDir, err = ioutil.TempDir("", "gitter-")
+	suite.Require().NoError(err)
+
+	suite.remote = filepath.Join(suite.tmpDir, "remote.git")
+	_, err = git.PlainInit(suite.remote, true)
+	suite.Require().NoError(err)
+
+	suite.dir = filepath.Join(suite.tmpDir, "seed")
+	suite.Require().NoError(os.MkdirAll(suite.dir, 0755))
+	suite.Require().NoError(suite.gitter.Init(suite.dir))
+	suite.writeFile(suite.dir, "README.md", "hello")
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "."))
+	suite.Require().NoError(suite.gitter.CommitDir(suite.dir, "initial commit"))
+	suite.Require().NoError(suite.gitter.AddRemote(suite.dir, "origin", suite.remote))
+	suite.Require().NoError(suite.gitter.PushMaster(suite.dir))
+}
+
+func (suite *GitterTestSuite) TearDownTest() {
+	os.RemoveAll(suite.tmpDir)
+}
+
+func (suite *GitterTestSuite) writeFile(dir string, name string, text string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644)
+	suite.Require().NoError(err)
+}
+
+func (suite *GitterTestSuite) clone() string {
+	dir := filepath.Join(suite.tmpDir, "clone")
+	suite.Require().NoError(os.MkdirAll(dir, 0755))
+	suite.Require().NoError(suite.gitter.Clone(suite.remote, dir))
+	return dir
+}
+
+// remoteBranches returns the branches of the bare remote repository
+func (suite *GitterTestSuite) remoteBranches() []string {
+	repo, err := git.PlainOpen(suite.remote)
+	suite.Require().NoError(err)
+	refs, err := repo.References()
+	suite.Require().NoError(err)
+	answer := []string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() {
+			answer = append(answer, ref.Name().Short())
+		}
+		return nil
+	})
+	suite.Require().NoError(err)
+	return answer
+}
+
+func (suite *GitterTestSuite) TestClone() {
+	dir := suite.clone()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "README.md"))
+	suite.Require().NoError(err)
+	suite.Equal("hello", string(data))
+
+	branch, err := suite.gitter.Branch(dir)
+	suite.Require().NoError(err)
+	suite.Equal("master", branch)
+
+	message, err := suite.gitter.GetLatestCommitMessage(dir)
+	suite.Require().NoError(err)
+	suite.Equal("initial commit", message)
+
+	sha, err := suite.gitter.GetLatestCommitSha(dir)
+	suite.Require().NoError(err)
+	seedSha, err := suite.gitter.GetLatestCommitSha(suite.dir)
+	suite.Require().NoError(err)
+	suite.Equal(seedSha, sha)
+}
+
+func (suite *GitterTestSuite) TestCommitPushAndPull() {
+	dir := suite.clone()
+	suite.writeFile(dir, "README.md", "changed")
+	suite.writeFile(dir, "new.txt", "new")
+
+	changed, err := suite.gitter.HasChanges(dir)
+	suite.Require().NoError(err)
+	suite.True(changed)
+
+	suite.Require().NoError(suite.gitter.Add(dir, "."))
+	suite.Require().NoError(suite.gitter.CommitDir(dir, "second commit"))
+	suite.Require().NoError(suite.gitter.Push(dir))
+
+	changed, err = suite.gitter.HasChanges(dir)
+	suite.Require().NoError(err)
+	suite.False(changed)
+
+	suite.Require().NoError(suite.gitter.Pull(suite.dir))
+	message, err := suite.gitter.GetLatestCommitMessage(suite.dir)
+	suite.Require().NoError(err)
+	suite.Equal("second commit", message)
+	data, err := ioutil.ReadFile(filepath.Join(suite.dir, "new.txt"))
+	suite.Require().NoError(err)
+	suite.Equal("new", string(data))
+}
+
+func (suite *GitterTestSuite) TestAddFilesDirectoriesAndDeletions() {
+	suite.Require().NoError(os.MkdirAll(filepath.Join(suite.dir, "charts", "myapp"), 0755))
+	suite.writeFile(suite.dir, filepath.Join("charts", "myapp", "values.yaml"), "replicaCount: 1")
+	suite.writeFile(suite.dir, "new.txt", "new")
+	suite.Require().NoError(os.Remove(filepath.Join(suite.dir, "README.md")))
+
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "charts"))
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "README.md"))
+	suite.Require().NoError(suite.gitter.CommitDir(suite.dir, "add the chart"))
+	suite.Require().NoError(suite.gitter.PushMaster(suite.dir))
+
+	dir := suite.clone()
+	data, err := ioutil.ReadFile(filepath.Join(dir, "charts", "myapp", "values.yaml"))
+	suite.Require().NoError(err)
+	suite.Equal("replicaCount: 1", string(data))
+	_, err = os.Stat(filepath.Join(dir, "README.md"))
+	suite.True(os.IsNotExist(err), "the deleted file is removed")
+	_, err = os.Stat(filepath.Join(dir, "new.txt"))
+	suite.True(os.IsNotExist(err), "files which were not added are not committed")
+}
+
+func (suite *GitterTestSuite) TestCommitIfChangesAndResetHard() {
+	initialSha, err := suite.gitter.GetLatestCommitSha(suite.dir)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.gitter.CommitIfChanges(suite.dir, "nothing changed"))
+	sha, err := suite.gitter.GetLatestCommitSha(suite.dir)
+	suite.Require().NoError(err)
+	suite.Equal(initialSha, sha)
+
+	suite.writeFile(suite.dir, "README.md", "changed")
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "README.md"))
+	suite.Require().NoError(suite.gitter.CommitIfChanges(suite.dir, "changed the readme"))
+	sha, err = suite.gitter.GetLatestCommitSha(suite.dir)
+	suite.Require().NoError(err)
+	suite.NotEqual(initialSha, sha)
+
+	suite.Require().NoError(suite.gitter.ResetHard(suite.dir, initialSha))
+	sha, err = suite.gitter.GetLatestCommitSha(suite.dir)
+	suite.Require().NoError(err)
+	suite.Equal(initialSha, sha)
+	data, err := ioutil.ReadFile(filepath.Join(suite.dir, "README.md"))
+	suite.Require().NoError(err)
+	suite.Equal("hello", string(data))
+}
+
+func (suite *GitterTestSuite) TestBranches() {
+	suite.Require().NoError(suite.gitter.CreateBranch(suite.dir, "feature"))
+	suite.Require().NoError(suite.gitter.Checkout(suite.dir, "feature"))
+	branch, err := suite.gitter.Branch(suite.dir)
+	suite.Require().NoError(err)
+	suite.Equal("feature", branch)
+
+	suite.writeFile(suite.dir, "feature.txt", "feature")
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "feature.txt"))
+	suite.Require().NoError(suite.gitter.CommitDir(suite.dir, "add a feature"))
+	suite.Require().NoError(suite.gitter.ForcePushBranch(suite.dir, "feature", "feature"))
+
+	branches, err := suite.gitter.LocalBranches(suite.dir)
+	suite.Require().NoError(err)
+	suite.Contains(branches, "master")
+	suite.Contains(branches, "feature")
+	suite.Contains(suite.remoteBranches(), "feature")
+
+	dir := suite.clone()
+	suite.Require().NoError(suite.gitter.FetchBranch(dir, "origin", "feature"))
+	remoteBranches, err := suite.gitter.RemoteBranches(dir)
+	suite.Require().NoError(err)
+	suite.Contains(remoteBranches, "origin/master")
+	suite.Contains(remoteBranches, "origin/feature")
+
+	suite.Require().NoError(suite.gitter.Checkout(dir, "feature"))
+	message, err := suite.gitter.GetLatestCommitMessage(dir)
+	suite.Require().NoError(err)
+	suite.Equal("add a feature", message)
+
+	suite.Require().NoError(suite.gitter.DeleteRemoteBranch(suite.dir, "origin", "feature"))
+	suite.NotContains(suite.remoteBranches(), "feature")
+	suite.Contains(suite.remoteBranches(), "master")
+}
+
+func (suite *GitterTestSuite) TestTags() {
+	suite.Require().NoError(suite.gitter.CreateTag(suite.dir, "v1.0.0", "release 1.0.0"))
+	tags, err := suite.gitter.Tags(suite.dir)
+	suite.Require().NoError(err)
+	suite.Contains(tags, "v1.0.0")
+	suite.Require().NoError(suite.gitter.PushTag(suite.dir, "v1.0.0"))
+
+	dir := filepath.Join(suite.tmpDir, "tags")
+	suite.Require().NoError(os.MkdirAll(dir, 0755))
+	suite.Require().NoError(suite.gitter.Init(dir))
+	suite.Require().NoError(suite.gitter.AddRemote(dir, "origin", suite.remote))
+	suite.Require().NoError(suite.gitter.FetchTags(dir))
+	tags, err = suite.gitter.Tags(dir)
+	suite.Require().NoError(err)
+	suite.Contains(tags, "v1.0.0")
+}
+
+func (suite *GitterTestSuite) TestRemotes() {
+	suite.Require().NoError(suite.gitter.SetRemoteURL(suite.dir, "upstream", "https://github.com/jenkins-x/jx.git"))
+	suite.Require().NoError(suite.gitter.SetRemoteURL(suite.dir, "origin", "https://github.com/myorg/jx.git"))
+
+	repo, err := git.PlainOpen(suite.dir)
+	suite.Require().NoError(err)
+	cfg, err := repo.Config()
+	suite.Require().NoError(err)
+	suite.Require().Contains(cfg.Remotes, "upstream")
+	suite.Equal([]string{"https://github.com/jenkins-x/jx.git"}, cfg.Remotes["upstream"].URLs)
+	suite.Require().Contains(cfg.Remotes, "origin")
+	suite.Equal([]string{"https://github.com/myorg/jx.git"}, cfg.Remotes["origin"].URLs)
+
+	gitURL, err := suite.gitter.DiscoverRemoteGitURL(filepath.Join(suite.dir, ".git", "config"))
+	suite.Require().NoError(err)
+	suite.Equal("https://github.com/myorg/jx.git", gitURL)
+}
+
+func (suite *GitterTestSuite) TestSigning() {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		suite.T().Skip("the ssh-keygen binary is not installed")
+	}
+	suite.Require().NoError(suite.gitter.CreateTag(suite.dir, "v0.0.1", "unsigned release"))
+
+	key := filepath.Join(suite.tmpDir, "signing-key")
+	err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", gitterSuiteEnv["GIT_COMMITTER_EMAIL"], "-f", key).Run()
+	suite.Require().NoError(err)
+	publicKey, err := ioutil.ReadFile(key + ".pub")
+	suite.Require().NoError(err)
+	allowedSigners := filepath.Join(suite.tmpDir, "allowed-signers")
+	suite.writeFile(suite.tmpDir, "allowed-signers", gitterSuiteEnv["GIT_COMMITTER_EMAIL"]+" "+string(publicKey))
+
+	signing := &gits.GitSigning{
+		Format:             gits.SigningFormatSSH,
+		Key:                key,
+		AllowedSignersFile: allowedSigners,
+	}
+	suite.Require().NoError(suite.gitter.ConfigureSigning(suite.dir, signing))
+	suite.writeFile(suite.dir, "README.md", "signed")
+	suite.Require().NoError(suite.gitter.Add(suite.dir, "README.md"))
+	suite.Require().NoError(suite.gitter.CommitDir(suite.dir, "signed commit"))
+	suite.NoError(suite.gitter.VerifyCommit(suite.dir, "HEAD"))
+
+	suite.Require().NoError(suite.gitter.CreateTag(suite.dir, "v1.0.0", "signed release"))
+	suite.NoError(suite.gitter.VerifyTag(suite.dir, "v1.0.0"))
+	suite.Error(suite.gitter.VerifyTag(suite.dir, "v0.0.1"))
+	suite.Error(suite.gitter.VerifyCommit(suite.dir, "HEAD~1"))
+}
+
+func TestGitCLI(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("the git binary is not installed")
+	}
+	suite.Run(t, &GitterTestSuite{gitter: gits.NewGitCLI()})
+}
+
+func TestGitGo(t *testing.T) {
+	suite.Run(t, &GitterTestSuite{gitter: gits.NewGitGo()})
+}
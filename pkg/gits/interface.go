@@ -46,6 +46,24 @@ type GitProvider interface {
 
 	AddLabelsToIssue(owner, repo string, number int, labels []string) error
 
+	// ListReviews lists the reviews of a pull request
+	ListReviews(owner string, repo string, number int) ([]*GitReview, error)
+
+	// SubmitReview approves, requests changes to or comments on a pull request depending on the state of the review
+	SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error)
+
+	// RequestReviewers requests reviews of a pull request from the users
+	RequestReviewers(owner string, repo string, number int, reviewers []string) error
+
+	// GetBranchProtection returns the protection of a branch or nil if the branch is not protected
+	GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error)
+
+	// SetBranchProtection protects a branch, replacing any existing protection
+	SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error
+
+	// RemoveBranchProtection removes the protection of a branch
+	RemoveBranchProtection(owner string, repo string, branch string) error
+
 	GetPullRequest(owner string, repo *GitRepository, number int) (*GitPullRequest, error)
 
 	ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error)
@@ -240,6 +258,10 @@ type Gitter interface {
 	CreateTag(dir string, tag string, msg string) error
 	GetLatestCommitSha(dir string) (string, error)
 
+	ConfigureSigning(dir string, signing *GitSigning) error
+	VerifyTag(dir string, tag string) error
+	VerifyCommit(dir string, commitish string) error
+
 	GetRevisionBeforeDate(dir string, t time.Time) (string, error)
 	GetRevisionBeforeDateText(dir string, dateText string) (string, error)
 	DeleteRemoteBranch(dir string, remoteName string, branch string) error
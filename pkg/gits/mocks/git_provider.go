@@ -261,6 +261,25 @@ func (mock *MockGitProvider) GetContent(_param0 string, _param1 string, _param2
 	return ret0, ret1
 }
 
+func (mock *MockGitProvider) GetBranchProtection(_param0 string, _param1 string, _param2 string) (*gits.GitBranchProtection, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetBranchProtection", params, []reflect.Type{reflect.TypeOf((**gits.GitBranchProtection)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *gits.GitBranchProtection
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*gits.GitBranchProtection)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockGitProvider) GetIssue(_param0 string, _param1 string, _param2 int) (*gits.GitIssue, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -605,6 +624,25 @@ func (mock *MockGitProvider) ListReleases(_param0 string, _param1 string) ([]*gi
 	return ret0, ret1
 }
 
+func (mock *MockGitProvider) ListReviews(_param0 string, _param1 string, _param2 int) ([]*gits.GitReview, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ListReviews", params, []reflect.Type{reflect.TypeOf((*[]*gits.GitReview)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []*gits.GitReview
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]*gits.GitReview)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockGitProvider) ListRepositories(_param0 string) ([]*gits.GitRepository, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -677,6 +715,21 @@ func (mock *MockGitProvider) PullRequestLastCommitStatus(_param0 *gits.GitPullRe
 	return ret0, ret1
 }
 
+func (mock *MockGitProvider) RemoveBranchProtection(_param0 string, _param1 string, _param2 string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("RemoveBranchProtection", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockGitProvider) RenameRepository(_param0 string, _param1 string, _param2 string) (*gits.GitRepository, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -696,6 +749,21 @@ func (mock *MockGitProvider) RenameRepository(_param0 string, _param1 string, _p
 	return ret0, ret1
 }
 
+func (mock *MockGitProvider) RequestReviewers(_param0 string, _param1 string, _param2 int, _param3 []string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("RequestReviewers", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockGitProvider) SearchIssues(_param0 string, _param1 string, _param2 string) ([]*gits.GitIssue, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -749,6 +817,21 @@ func (mock *MockGitProvider) ServerURL() string {
 	return ret0
 }
 
+func (mock *MockGitProvider) SetBranchProtection(_param0 string, _param1 string, _param2 string, _param3 *gits.GitBranchProtection) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("SetBranchProtection", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockGitProvider) ShouldForkForPullRequest(_param0 string, _param1 string, _param2 string) bool {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -764,6 +847,25 @@ func (mock *MockGitProvider) ShouldForkForPullRequest(_param0 string, _param1 st
 	return ret0
 }
 
+func (mock *MockGitProvider) SubmitReview(_param0 string, _param1 string, _param2 int, _param3 *gits.GitReview) (*gits.GitReview, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("SubmitReview", params, []reflect.Type{reflect.TypeOf((**gits.GitReview)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *gits.GitReview
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*gits.GitReview)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockGitProvider) UpdateCommitStatus(_param0 string, _param1 string, _param2 string, _param3 *gits.GitRepoStatus) (*gits.GitRepoStatus, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGitProvider().")
@@ -1362,6 +1464,41 @@ func (c *MockGitProvider_GetContent_OngoingVerification) GetAllCapturedArguments
 	return
 }
 
+func (verifier *VerifierMockGitProvider) GetBranchProtection(_param0 string, _param1 string, _param2 string) *MockGitProvider_GetBranchProtection_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetBranchProtection", params, verifier.timeout)
+	return &MockGitProvider_GetBranchProtection_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_GetBranchProtection_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_GetBranchProtection_OngoingVerification) GetCapturedArguments() (string, string, string) {
+	_param0, _param1, _param2 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1]
+}
+
+func (c *MockGitProvider_GetBranchProtection_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) GetIssue(_param0 string, _param1 string, _param2 int) *MockGitProvider_GetIssue_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetIssue", params, verifier.timeout)
@@ -1870,6 +2007,41 @@ func (c *MockGitProvider_ListReleases_OngoingVerification) GetAllCapturedArgumen
 	return
 }
 
+func (verifier *VerifierMockGitProvider) ListReviews(_param0 string, _param1 string, _param2 int) *MockGitProvider_ListReviews_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListReviews", params, verifier.timeout)
+	return &MockGitProvider_ListReviews_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_ListReviews_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_ListReviews_OngoingVerification) GetCapturedArguments() (string, string, int) {
+	_param0, _param1, _param2 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1]
+}
+
+func (c *MockGitProvider_ListReviews_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []int) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]int, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(int)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) ListRepositories(_param0 string) *MockGitProvider_ListRepositories_OngoingVerification {
 	params := []pegomock.Param{_param0}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListRepositories", params, verifier.timeout)
@@ -1986,6 +2158,41 @@ func (c *MockGitProvider_PullRequestLastCommitStatus_OngoingVerification) GetAll
 	return
 }
 
+func (verifier *VerifierMockGitProvider) RemoveBranchProtection(_param0 string, _param1 string, _param2 string) *MockGitProvider_RemoveBranchProtection_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RemoveBranchProtection", params, verifier.timeout)
+	return &MockGitProvider_RemoveBranchProtection_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_RemoveBranchProtection_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_RemoveBranchProtection_OngoingVerification) GetCapturedArguments() (string, string, string) {
+	_param0, _param1, _param2 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1]
+}
+
+func (c *MockGitProvider_RemoveBranchProtection_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) RenameRepository(_param0 string, _param1 string, _param2 string) *MockGitProvider_RenameRepository_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RenameRepository", params, verifier.timeout)
@@ -2021,6 +2228,45 @@ func (c *MockGitProvider_RenameRepository_OngoingVerification) GetAllCapturedArg
 	return
 }
 
+func (verifier *VerifierMockGitProvider) RequestReviewers(_param0 string, _param1 string, _param2 int, _param3 []string) *MockGitProvider_RequestReviewers_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RequestReviewers", params, verifier.timeout)
+	return &MockGitProvider_RequestReviewers_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_RequestReviewers_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_RequestReviewers_OngoingVerification) GetCapturedArguments() (string, string, int, []string) {
+	_param0, _param1, _param2, _param3 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1], _param3[len(_param3)-1]
+}
+
+func (c *MockGitProvider_RequestReviewers_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []int, _param3 [][]string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]int, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(int)
+		}
+		_param3 = make([][]string, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.([]string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) SearchIssues(_param0 string, _param1 string, _param2 string) *MockGitProvider_SearchIssues_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SearchIssues", params, verifier.timeout)
@@ -2108,6 +2354,45 @@ func (c *MockGitProvider_ServerURL_OngoingVerification) GetCapturedArguments() {
 func (c *MockGitProvider_ServerURL_OngoingVerification) GetAllCapturedArguments() {
 }
 
+func (verifier *VerifierMockGitProvider) SetBranchProtection(_param0 string, _param1 string, _param2 string, _param3 *gits.GitBranchProtection) *MockGitProvider_SetBranchProtection_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SetBranchProtection", params, verifier.timeout)
+	return &MockGitProvider_SetBranchProtection_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_SetBranchProtection_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_SetBranchProtection_OngoingVerification) GetCapturedArguments() (string, string, string, *gits.GitBranchProtection) {
+	_param0, _param1, _param2, _param3 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1], _param3[len(_param3)-1]
+}
+
+func (c *MockGitProvider_SetBranchProtection_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 []*gits.GitBranchProtection) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([]*gits.GitBranchProtection, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(*gits.GitBranchProtection)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) ShouldForkForPullRequest(_param0 string, _param1 string, _param2 string) *MockGitProvider_ShouldForkForPullRequest_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ShouldForkForPullRequest", params, verifier.timeout)
@@ -2143,6 +2428,45 @@ func (c *MockGitProvider_ShouldForkForPullRequest_OngoingVerification) GetAllCap
 	return
 }
 
+func (verifier *VerifierMockGitProvider) SubmitReview(_param0 string, _param1 string, _param2 int, _param3 *gits.GitReview) *MockGitProvider_SubmitReview_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SubmitReview", params, verifier.timeout)
+	return &MockGitProvider_SubmitReview_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGitProvider_SubmitReview_OngoingVerification struct {
+	mock              *MockGitProvider
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGitProvider_SubmitReview_OngoingVerification) GetCapturedArguments() (string, string, int, *gits.GitReview) {
+	_param0, _param1, _param2, _param3 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1], _param3[len(_param3)-1]
+}
+
+func (c *MockGitProvider_SubmitReview_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []int, _param3 []*gits.GitReview) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]int, len(params[2]))
+		for u, param := range params[2] {
+			_param2[u] = param.(int)
+		}
+		_param3 = make([]*gits.GitReview, len(params[3]))
+		for u, param := range params[3] {
+			_param3[u] = param.(*gits.GitReview)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGitProvider) UpdateCommitStatus(_param0 string, _param1 string, _param2 string, _param3 *gits.GitRepoStatus) *MockGitProvider_UpdateCommitStatus_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2, _param3}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateCommitStatus", params, verifier.timeout)
@@ -33,6 +33,8 @@ type GitRepository struct {
 	Organisation     string
 	Project          string
 	Private          bool
+	// ServerPath is the path of the organization on hosts such as dev.azure.com which serve many organizations
+	ServerPath string
 }
 
 type GitPullRequest struct {
@@ -44,6 +46,8 @@ type GitPullRequest struct {
 	Mergeable          *bool
 	Merged             *bool
 	HeadRef            *string
+	BaseRef            *string
+	BaseSha            string
 	State              *string
 	StatusesURL        *string
 	IssueURL           *string
@@ -60,6 +64,42 @@ type GitPullRequest struct {
 	UpdatedAt          *time.Time
 }
 
+const (
+	// ReviewStateApproved is the state of a review which approves a pull request
+	ReviewStateApproved = "APPROVED"
+	// ReviewStateChangesRequested is the state of a review which requests changes to a pull request
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+	// ReviewStateCommented is the state of a review which only comments on a pull request
+	ReviewStateCommented = "COMMENTED"
+)
+
+// GitReview represents a review of a pull request
+type GitReview struct {
+	ID          string
+	User        *GitUser
+	State       string
+	Body        string
+	CommitSHA   string
+	SubmittedAt *time.Time
+	URL         string
+}
+
+// GitBranchProtection represents the rules which protect a branch from changes which have not been checked or reviewed
+type GitBranchProtection struct {
+	// RequiredContexts are the status contexts which must succeed before a pull request can be merged
+	RequiredContexts []string
+	// Strict requires pull requests to be up to date with the branch before they can be merged
+	Strict bool
+	// RequiredApprovingReviewCount is the number of approving reviews needed to merge a pull request
+	RequiredApprovingReviewCount int
+	// DismissStaleReviews dismisses the approvals of a pull request when new commits are pushed to it
+	DismissStaleReviews bool
+	// RequireCodeOwnerReviews requires the approval of the code owners of the changed files
+	RequireCodeOwnerReviews bool
+	// EnforceAdmins applies the protection to the administrators of the repository too
+	EnforceAdmins bool
+}
+
 // Label represents a label on an Issue
 type Label struct {
 	ID          *int64
@@ -210,23 +250,33 @@ func (pr *GitPullRequest) NumberString() string {
 	return "#" + strconv.Itoa(*n)
 }
 
+// CreateProvider creates the git provider for the kind of the server. The provider caches the results of its read
+// only calls, see CachingProvider.
 func CreateProvider(server *auth.AuthServer, user *auth.UserAuth, git Gitter) (GitProvider, error) {
 	if server.Kind == "" {
 		server.Kind = SaasGitKind(server.URL)
 	}
+	var provider GitProvider
+	var err error
 	if server.Kind == KindBitBucketCloud {
-		return NewBitbucketCloudProvider(server, user, git)
+		provider, err = NewBitbucketCloudProvider(server, user, git)
 	} else if server.Kind == KindBitBucketServer {
-		return NewBitbucketServerProvider(server, user, git)
+		provider, err = NewBitbucketServerProvider(server, user, git)
 	} else if server.Kind == KindGitea {
-		return NewGiteaProvider(server, user, git)
+		provider, err = NewGiteaProvider(server, user, git)
 	} else if server.Kind == KindGitlab {
-		return NewGitlabProvider(server, user, git)
+		provider, err = NewGitlabProvider(server, user, git)
+	} else if server.Kind == KindAzureDevOps {
+		provider, err = NewAzureDevOpsProvider(server, user, git)
 	} else if server.Kind == KindGitFake {
 		return NewFakeProvider(), nil
 	} else {
-		return NewGitHubProvider(server, user, git)
+		provider, err = NewGitHubProvider(server, user, git)
 	}
+	if err != nil {
+		return provider, err
+	}
+	return WithCache(provider), nil
 }
 
 // GetHost returns the Git Provider hostname, e.g github.com
@@ -256,6 +306,8 @@ func ProviderAccessTokenURL(kind string, url string, username string) string {
 		return GiteaAccessTokenURL(url)
 	case KindGitlab:
 		return GitlabAccessTokenURL(url)
+	case KindAzureDevOps:
+		return AzureDevOpsAccessTokenURL(url)
 	default:
 		return GitHubAccessTokenURL(url)
 	}
@@ -406,6 +458,9 @@ func (i *GitRepository) ProviderURL() string {
 	if !strings.HasPrefix(scheme, "http") {
 		scheme = "https"
 	}
+	if i.ServerPath != "" {
+		return util.UrlJoin(scheme+"://"+i.Host, i.ServerPath)
+	}
 	return scheme + "://" + i.Host
 }
 
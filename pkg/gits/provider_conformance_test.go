@@ -0,0 +1,530 @@
+package gits_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/suite"
+	bitbucketcloud "github.com/wbrefvem/go-bitbucket"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	conformanceOwner      = "test-org"
+	conformanceRepo       = "test-repo"
+	conformanceSHA        = "d6f24ee03d76a2caf0a4e1975fb43e8f61759b9c"
+	conformanceHookURL    = "http://hook.jx.example.com/hook"
+	conformanceNewHookURL = "http://hook.jx.example.com/hook/updated"
+	conformanceContent    = "dependencies:\n- name: myapp\n  version: 0.0.2\n"
+)
+
+// conformanceProvider describes how to fake the REST API of a git provider for the conformance tests
+type conformanceProvider struct {
+	kind             string
+	router           util.Router
+	create           func(serverURL string) (gits.GitProvider, error)
+	supportsReleases bool
+	// rejectsChangeRequests is true if the provider cannot submit reviews which request changes
+	rejectsChangeRequests bool
+	// branchProtection is the protection of the master branch, or nil if the provider cannot protect branches
+	branchProtection *gits.GitBranchProtection
+}
+
+var conformanceUser = auth.UserAuth{
+	Username: "test-user",
+	ApiToken: "test",
+}
+
+var conformanceProviders = []conformanceProvider{
+	{
+		kind: gits.KindGitea,
+		router: util.Router{
+			"/api/v1/repos/test-org/test-repo/hooks": util.MethodMap{
+				"GET":  "hooks.json",
+				"POST": "hook.json",
+			},
+			"/api/v1/repos/test-org/test-repo/hooks/1": util.MethodMap{
+				"PATCH": "hook.json",
+			},
+			"/api/v1/repos/test-org/test-repo/statuses/" + conformanceSHA: util.MethodMap{
+				"POST": "status.json",
+			},
+			"/api/v1/repos/test-org/test-repo/commits/" + conformanceSHA + "/statuses": util.MethodMap{
+				"GET": "statuses.json",
+			},
+			"/api/v1/repos/test-org/test-repo/commits": util.MethodMap{
+				"GET": "commits.json",
+			},
+			"/api/v1/repos/test-org/test-repo/raw/master/requirements.yaml": util.MethodMap{
+				"GET": "requirements.yaml",
+			},
+			"/api/v1/repos/test-org/test-repo/releases": util.MethodMap{
+				"GET":  "releases.json",
+				"POST": "release.json",
+			},
+			"/api/v1/repos/test-org/test-repo/releases/1": util.MethodMap{
+				"PATCH": "release.json",
+			},
+			"/api/v1/repos/test-org/test-repo/labels": util.MethodMap{
+				"GET":  "labels.json",
+				"POST": "label.json",
+			},
+			"/api/v1/repos/test-org/test-repo/issues/1/labels": util.MethodMap{
+				"POST": "issue-labels.json",
+			},
+			"/api/v1/repos/test-org/test-repo/pulls/1/reviews": util.MethodMap{
+				"GET":  "reviews.json",
+				"POST": "review.json",
+			},
+			"/api/v1/repos/test-org/test-repo/pulls/1/requested_reviewers": util.MethodMap{
+				"POST": "requested-reviewers.json",
+			},
+		},
+		create: func(serverURL string) (gits.GitProvider, error) {
+			return gits.NewGiteaProvider(&auth.AuthServer{URL: serverURL}, &conformanceUser, gits.NewGitCLI())
+		},
+		supportsReleases: true,
+	},
+	{
+		kind: gits.KindGitlab,
+		router: util.Router{
+			"/api/v4/groups/test-org/projects": util.MethodMap{
+				"GET": "group-projects.json",
+			},
+			"/api/v4/projects/42": util.MethodMap{
+				"GET": "project.json",
+				"PUT": "project.json",
+			},
+			"/api/v4/projects/42/protected_branches": util.MethodMap{
+				"GET": "protected-branches.json",
+			},
+			"/api/v4/projects/42/protected_branches/master": util.MethodMap{
+				"GET": "protected-branch.json",
+			},
+			"/api/v4/projects/42/approvals": util.MethodMap{
+				"GET":  "project-approvals.json",
+				"POST": "project-approvals.json",
+			},
+			"/api/v4/projects/42/hooks": util.MethodMap{
+				"GET":  "hooks.json",
+				"POST": "hook.json",
+			},
+			"/api/v4/projects/42/hooks/1": util.MethodMap{
+				"PUT": "hook.json",
+			},
+			"/api/v4/projects/42/statuses/" + conformanceSHA: util.MethodMap{
+				"POST": "status.json",
+			},
+			"/api/v4/projects/42/repository/commits/" + conformanceSHA + "/statuses": util.MethodMap{
+				"GET": "statuses.json",
+			},
+			"/api/v4/projects/42/repository/commits": util.MethodMap{
+				"GET": "commits.json",
+			},
+			"/api/v4/projects/42/repository/files/requirements.yaml": util.MethodMap{
+				"GET": "file.json",
+			},
+			"/api/v4/projects/42/repository/tags": util.MethodMap{
+				"GET": "tags.json",
+			},
+			"/api/v4/projects/42/repository/tags/v1.0.0": util.MethodMap{
+				"GET": "tag.json",
+			},
+			"/api/v4/projects/42/repository/tags/v1.0.0/release": util.MethodMap{
+				"POST": "release.json",
+				"PUT":  "release.json",
+			},
+			"/api/v4/projects/42/merge_requests/1": util.MethodMap{
+				"GET": "merge-request.json",
+				"PUT": "merge-request-labelled.json",
+			},
+			"/api/v4/projects/42/merge_requests/1/approvals": util.MethodMap{
+				"GET": "approvals.json",
+			},
+			"/api/v4/projects/42/merge_requests/1/approve": util.MethodMap{
+				"POST": "approvals.json",
+			},
+			"/api/v4/projects/42/merge_requests/1/notes": util.MethodMap{
+				"POST": "note.json",
+			},
+			"/api/v4/users": util.MethodMap{
+				"GET": "users.json",
+			},
+		},
+		create: func(serverURL string) (gits.GitProvider, error) {
+			client := gitlab.NewClient(nil, conformanceUser.ApiToken)
+			err := client.SetBaseURL(serverURL)
+			if err != nil {
+				return nil, err
+			}
+			return gits.WithGitlabClient(&auth.AuthServer{URL: serverURL}, &conformanceUser, client, gits.NewGitCLI())
+		},
+		supportsReleases:      true,
+		rejectsChangeRequests: true,
+		branchProtection: &gits.GitBranchProtection{
+			RequiredContexts:             []string{gits.AnyStatusContext},
+			RequiredApprovingReviewCount: 1,
+			DismissStaleReviews:          true,
+		},
+	},
+	{
+		kind: gits.KindBitBucketCloud,
+		router: util.Router{
+			"/repositories/test-org/test-repo/hooks": util.MethodMap{
+				"GET":  "hooks.json",
+				"POST": "hook.json",
+			},
+			"/repositories/test-org/test-repo/hooks/{6a5e4a61-2f04-4d7b-8e3b-1a2c3f4b5d6e}": util.MethodMap{
+				"PUT": "hook.json",
+			},
+			"/repositories/test-org/test-repo/commit/" + conformanceSHA + "/statuses/build": util.MethodMap{
+				"POST": "status.json",
+			},
+			"/repositories/test-org/test-repo/commit/" + conformanceSHA + "/statuses": util.MethodMap{
+				"GET": "statuses.json",
+			},
+			"/repositories/test-org/test-repo/commits/master": util.MethodMap{
+				"GET": "commits.json",
+			},
+			"/repositories/test-org/test-repo/src/master/requirements.yaml": util.MethodMap{
+				"GET": "requirements.yaml",
+			},
+			"/repositories/test-org/test-repo/pullrequests/1": util.MethodMap{
+				"GET": "pullrequest.json",
+				"PUT": "pullrequest.json",
+			},
+			"/repositories/test-org/test-repo/pullrequests/1/approve": util.MethodMap{
+				"POST": "participant.json",
+			},
+			"/repositories/test-org/test-repo/pullrequests/1/comments": util.MethodMap{
+				"POST": "comment.json",
+			},
+			"/repositories/test-org/test-repo/branch-restrictions": util.MethodMap{
+				"GET": "branch-restrictions.json",
+			},
+		},
+		create: func(serverURL string) (gits.GitProvider, error) {
+			provider, err := gits.NewBitbucketCloudProvider(&auth.AuthServer{URL: serverURL}, &conformanceUser, gits.NewGitCLI())
+			if err != nil {
+				return nil, err
+			}
+			bp := provider.(*gits.BitbucketCloudProvider)
+			cfg := bitbucketcloud.NewConfiguration()
+			cfg.BasePath = serverURL
+			bp.Client = bitbucketcloud.NewAPIClient(cfg)
+			bp.BaseURL = serverURL
+			return bp, nil
+		},
+		branchProtection: &gits.GitBranchProtection{
+			RequiredContexts:             []string{gits.AnyStatusContext},
+			RequiredApprovingReviewCount: 1,
+			DismissStaleReviews:          true,
+		},
+	},
+	{
+		kind: gits.KindBitBucketServer,
+		router: util.Router{
+			"/rest/api/1.0/projects/test-org/repos/test-repo/webhooks": util.MethodMap{
+				"GET":  "webhooks.json",
+				"POST": "webhook.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/webhooks/123": util.MethodMap{
+				"PUT": "webhook.json",
+			},
+			"/rest/build-status/1.0/commits/" + conformanceSHA: util.MethodMap{
+				"GET":  "build-statuses.json",
+				"POST": "build-status.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/commits": util.MethodMap{
+				"GET": "commits.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/raw/requirements.yaml": util.MethodMap{
+				"GET": "requirements.yaml",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/pull-requests/1": util.MethodMap{
+				"GET": "pull-request.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/pull-requests/1/participants": util.MethodMap{
+				"POST": "participant.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/pull-requests/1/participants/test-user": util.MethodMap{
+				"PUT": "participant.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/pull-requests/1/comments": util.MethodMap{
+				"POST": "comment.json",
+			},
+			"/rest/branch-permissions/2.0/projects/test-org/repos/test-repo/restrictions": util.MethodMap{
+				"GET": "restrictions.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/settings/hooks/com.atlassian.bitbucket.server.bitbucket-bundled:requiredApproversMergeHook": util.MethodMap{
+				"GET": "required-approvers.json",
+			},
+			"/rest/api/1.0/projects/test-org/repos/test-repo/settings/hooks/com.atlassian.bitbucket.server.bitbucket-bundled:requiredApproversMergeHook/settings": util.MethodMap{
+				"GET": "required-approvers-settings.json",
+			},
+		},
+		create: func(serverURL string) (gits.GitProvider, error) {
+			return gits.NewBitbucketServerProvider(&auth.AuthServer{URL: serverURL}, &conformanceUser, gits.NewGitCLI())
+		},
+		branchProtection: &gits.GitBranchProtection{
+			RequiredApprovingReviewCount: 1,
+		},
+	},
+}
+
+// ProviderConformanceTestSuite runs the same checks against every git provider with a faked REST API
+type ProviderConformanceTestSuite struct {
+	suite.Suite
+	conformance conformanceProvider
+	server      *httptest.Server
+	provider    gits.GitProvider
+	// transport is given to the REST API client of the providers which use one
+	transport *countingTransport
+}
+
+// countingTransport counts the requests sent with it
+type countingTransport struct {
+	lock     sync.Mutex
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.lock.Lock()
+	t.requests++
+	t.lock.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *countingTransport) count() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.requests
+}
+
+func (suite *ProviderConformanceTestSuite) SetupSuite() {
+	mux := http.NewServeMux()
+	dataDir := filepath.Join("test_data", "conformance", suite.conformance.kind)
+	for path, methodMap := range suite.conformance.router {
+		mux.HandleFunc(path, util.GetMockAPIResponseFromFile(dataDir, methodMap))
+	}
+	suite.server = httptest.NewServer(mux)
+
+	provider, err := suite.conformance.create(suite.server.URL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(suite.conformance.kind, provider.Kind())
+	suite.provider = provider
+
+	transport := &countingTransport{}
+	switch p := provider.(type) {
+	case *gits.GiteaProvider:
+		suite.IsType(&gits.CachingTransport{}, p.Transport)
+		p.Transport = transport
+	case *gits.BitbucketCloudProvider:
+		suite.IsType(&gits.CachingTransport{}, p.Transport)
+		p.Transport = transport
+	case *gits.BitbucketServerProvider:
+		suite.IsType(&gits.CachingTransport{}, p.Transport)
+		p.Transport = transport
+	default:
+		transport = nil
+	}
+	suite.transport = transport
+}
+
+func (suite *ProviderConformanceTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *ProviderConformanceTestSuite) repository() *gits.GitRepository {
+	return &gits.GitRepository{
+		URL:          util.UrlJoin(suite.server.URL, "projects", conformanceOwner, "repos", conformanceRepo),
+		Organisation: conformanceOwner,
+		Project:      conformanceOwner,
+		Name:         conformanceRepo,
+	}
+}
+
+func (suite *ProviderConformanceTestSuite) TestListWebHooks() {
+	hooks, err := suite.provider.ListWebHooks(conformanceOwner, conformanceRepo)
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 1)
+	suite.Equal(conformanceHookURL, hooks[0].URL)
+}
+
+func (suite *ProviderConformanceTestSuite) TestUpdateWebHook() {
+	hooks, err := suite.provider.ListWebHooks(conformanceOwner, conformanceRepo)
+	suite.Require().NoError(err)
+	suite.Require().Len(hooks, 1)
+
+	err = suite.provider.UpdateWebHook(&gits.GitWebHookArguments{
+		ID:          hooks[0].ID,
+		Owner:       conformanceOwner,
+		Repo:        suite.repository(),
+		URL:         conformanceNewHookURL,
+		ExistingURL: conformanceHookURL,
+		Secret:      "abc123",
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *ProviderConformanceTestSuite) TestUpdateCommitStatus() {
+	status, err := suite.provider.UpdateCommitStatus(conformanceOwner, conformanceRepo, conformanceSHA, &gits.GitRepoStatus{
+		Context:     "jenkins-x",
+		State:       "success",
+		TargetURL:   "http://jenkins.jx.example.com/job/1",
+		Description: "the build passed",
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(status)
+	suite.Equal("success", status.State)
+	suite.Equal("jenkins-x", status.Context)
+	suite.Equal("http://jenkins.jx.example.com/job/1", status.TargetURL)
+}
+
+func (suite *ProviderConformanceTestSuite) TestListCommitStatus() {
+	statuses, err := suite.provider.ListCommitStatus(conformanceOwner, conformanceRepo, conformanceSHA)
+	suite.Require().NoError(err)
+	suite.Require().Len(statuses, 1)
+	suite.Equal("success", statuses[0].State)
+	suite.Equal("the build passed", statuses[0].Description)
+}
+
+func (suite *ProviderConformanceTestSuite) TestListCommits() {
+	commits, err := suite.provider.ListCommits(conformanceOwner, conformanceRepo, &gits.ListCommitsArguments{
+		SHA: "master",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(commits, 1)
+	suite.Equal(conformanceSHA, commits[0].SHA)
+	suite.Contains(commits[0].Message, "initial commit")
+	suite.Require().NotNil(commits[0].Author)
+	suite.Equal("Jane Doe", commits[0].Author.Name)
+}
+
+func (suite *ProviderConformanceTestSuite) TestRestClientUsesTheTransport() {
+	if suite.transport == nil {
+		suite.T().Skip("the provider has no REST API client")
+	}
+	before := suite.transport.count()
+	_, err := suite.provider.ListCommits(conformanceOwner, conformanceRepo, &gits.ListCommitsArguments{
+		SHA: "master",
+	})
+	suite.Require().NoError(err)
+	suite.True(suite.transport.count() > before, "the REST API requests are sent with the transport of the provider")
+}
+
+func (suite *ProviderConformanceTestSuite) TestGetContent() {
+	content, err := suite.provider.GetContent(conformanceOwner, conformanceRepo, "requirements.yaml", "master")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(content)
+	suite.Equal("base64", content.Encoding)
+	suite.Equal("requirements.yaml", content.Path)
+
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	suite.Require().NoError(err)
+	suite.Equal(conformanceContent, string(data))
+}
+
+func (suite *ProviderConformanceTestSuite) TestListReleases() {
+	releases, err := suite.provider.ListReleases(conformanceOwner, conformanceRepo)
+	suite.Require().NoError(err)
+	if !suite.conformance.supportsReleases {
+		suite.Empty(releases)
+		return
+	}
+	suite.Require().Len(releases, 1)
+	suite.Equal("v1.0.0", releases[0].TagName)
+	suite.Equal("first release", releases[0].Body)
+}
+
+func (suite *ProviderConformanceTestSuite) TestUpdateRelease() {
+	err := suite.provider.UpdateRelease(conformanceOwner, conformanceRepo, "v1.0.0", &gits.GitRelease{
+		Name:    "v1.0.0",
+		TagName: "v1.0.0",
+		Body:    "first release",
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *ProviderConformanceTestSuite) TestAddLabelsToIssue() {
+	err := suite.provider.AddLabelsToIssue(conformanceOwner, conformanceRepo, 1, []string{"updatebot"})
+	suite.Require().NoError(err)
+}
+
+func (suite *ProviderConformanceTestSuite) TestListReviews() {
+	reviews, err := suite.provider.ListReviews(conformanceOwner, conformanceRepo, 1)
+	suite.Require().NoError(err)
+	suite.Require().Len(reviews, 1)
+	suite.Equal(gits.ReviewStateApproved, reviews[0].State)
+	suite.Require().NotNil(reviews[0].User)
+	suite.Equal("reviewer", reviews[0].User.Login)
+}
+
+func (suite *ProviderConformanceTestSuite) TestSubmitReview() {
+	review, err := suite.provider.SubmitReview(conformanceOwner, conformanceRepo, 1, &gits.GitReview{
+		State: gits.ReviewStateApproved,
+		Body:  "looks good",
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(review)
+	suite.Equal(gits.ReviewStateApproved, review.State)
+	suite.Equal("looks good", review.Body)
+	suite.NotEmpty(review.ID)
+}
+
+func (suite *ProviderConformanceTestSuite) TestSubmitReviewRequestingChanges() {
+	if !suite.conformance.rejectsChangeRequests {
+		suite.T().Skip("the provider supports reviews which request changes")
+	}
+	_, err := suite.provider.SubmitReview(conformanceOwner, conformanceRepo, 1, &gits.GitReview{
+		State: gits.ReviewStateChangesRequested,
+		Body:  "please fix",
+	})
+	suite.Error(err)
+}
+
+func (suite *ProviderConformanceTestSuite) TestRequestReviewers() {
+	err := suite.provider.RequestReviewers(conformanceOwner, conformanceRepo, 1, []string{"reviewer"})
+	suite.Require().NoError(err)
+}
+
+func (suite *ProviderConformanceTestSuite) TestGetBranchProtection() {
+	protection, err := suite.provider.GetBranchProtection(conformanceOwner, conformanceRepo, "master")
+	if suite.conformance.branchProtection == nil {
+		suite.Error(err)
+		return
+	}
+	suite.Require().NoError(err)
+	suite.Equal(suite.conformance.branchProtection, protection)
+}
+
+func (suite *ProviderConformanceTestSuite) TestSetBranchProtection() {
+	expected := suite.conformance.branchProtection
+	if expected == nil {
+		expected = &gits.GitBranchProtection{RequiredApprovingReviewCount: 1}
+	}
+	err := suite.provider.SetBranchProtection(conformanceOwner, conformanceRepo, "master", expected)
+	if suite.conformance.branchProtection == nil {
+		suite.Error(err)
+		return
+	}
+	suite.Require().NoError(err)
+}
+
+func TestProviderConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping ProviderConformanceTestSuite in short mode")
+	}
+	for _, conformance := range conformanceProviders {
+		t.Run(conformance.kind, func(t *testing.T) {
+			suite.Run(t, &ProviderConformanceTestSuite{conformance: conformance})
+		})
+	}
+}
@@ -3,6 +3,7 @@ package gits
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -45,6 +46,7 @@ type FakePullRequest struct {
 	PullRequest *GitPullRequest
 	Commits     []*FakeCommit
 	Comment     string
+	Reviews     []*GitReview
 }
 
 type FakeIssue struct {
@@ -61,6 +63,7 @@ type FakeRepository struct {
 	issueCount         int
 	Releases           map[string]*GitRelease
 	PullRequestCounter int
+	BranchProtections  map[string]*GitBranchProtection
 }
 
 type FakeProvider struct {
@@ -176,6 +179,8 @@ func (f *FakeProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPul
 
 	repo.issueCount += 1
 	number := repo.issueCount
+	head := data.Head
+	base := data.Base
 	pr := &GitPullRequest{
 		URL: fmt.Sprintf("https://fake.git/%s/%s/pulls/%d", org, repoName, number),
 		Author: &GitUser{
@@ -190,7 +195,8 @@ func (f *FakeProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPul
 		Number:         &number,
 		Mergeable:      nil,
 		Merged:         nil,
-		HeadRef:        nil,
+		HeadRef:        &head,
+		BaseRef:        &base,
 		State:          nil,
 		StatusesURL:    nil,
 		IssueURL:       nil,
@@ -277,7 +283,22 @@ func (f *FakeProvider) GetPullRequest(owner string, repo *GitRepository, number
 }
 
 func (f *FakeProvider) ListOpenPullRequests(owner string, repo string) ([]*GitPullRequest, error) {
-	return nil, nil
+	answer := []*GitPullRequest{}
+	for _, r := range f.Repositories[owner] {
+		if r.GitRepo.Name != repo {
+			continue
+		}
+		for _, pr := range r.PullRequests {
+			merged := pr.PullRequest.Merged
+			if merged == nil || !*merged {
+				answer = append(answer, pr.PullRequest)
+			}
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return *answer[i].Number < *answer[j].Number
+	})
+	return answer, nil
 }
 
 func (f *FakeProvider) GetPullRequestCommits(owner string, repo *GitRepository, number int) ([]*GitCommit, error) {
@@ -749,3 +770,100 @@ func (f *FakeProvider) ListCommits(owner, name string, opt *ListCommitsArguments
 func (f *FakeProvider) AddLabelsToIssue(owner, repo string, number int, labels []string) error {
 	return nil
 }
+
+// ListReviews lists the reviews of a pull request
+func (f *FakeProvider) ListReviews(owner string, repo string, number int) ([]*GitReview, error) {
+	pr, err := f.fakePullRequest(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return pr.Reviews, nil
+}
+
+// SubmitReview adds a review to a pull request
+func (f *FakeProvider) SubmitReview(owner string, repo string, number int, review *GitReview) (*GitReview, error) {
+	pr, err := f.fakePullRequest(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	answer := *review
+	answer.ID = strconv.Itoa(len(pr.Reviews) + 1)
+	if answer.State == "" {
+		answer.State = ReviewStateCommented
+	}
+	if answer.User == nil {
+		answer.User = &GitUser{Login: f.User.Username}
+	}
+	pr.Reviews = append(pr.Reviews, &answer)
+	return &answer, nil
+}
+
+// RequestReviewers adds the users to the requested reviewers of a pull request
+func (f *FakeProvider) RequestReviewers(owner string, repo string, number int, reviewers []string) error {
+	pr, err := f.fakePullRequest(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	for _, reviewer := range reviewers {
+		pr.PullRequest.RequestedReviewers = append(pr.PullRequest.RequestedReviewers, &GitUser{Login: reviewer})
+	}
+	return nil
+}
+
+// GetBranchProtection returns the protection of a branch or nil if the branch is not protected
+func (f *FakeProvider) GetBranchProtection(owner string, repo string, branch string) (*GitBranchProtection, error) {
+	r, err := f.fakeRepository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return r.BranchProtections[branch], nil
+}
+
+// SetBranchProtection protects a branch
+func (f *FakeProvider) SetBranchProtection(owner string, repo string, branch string, protection *GitBranchProtection) error {
+	r, err := f.fakeRepository(owner, repo)
+	if err != nil {
+		return err
+	}
+	if r.BranchProtections == nil {
+		r.BranchProtections = map[string]*GitBranchProtection{}
+	}
+	stored := *protection
+	r.BranchProtections[branch] = &stored
+	return nil
+}
+
+// RemoveBranchProtection removes the protection of a branch
+func (f *FakeProvider) RemoveBranchProtection(owner string, repo string, branch string) error {
+	r, err := f.fakeRepository(owner, repo)
+	if err != nil {
+		return err
+	}
+	delete(r.BranchProtections, branch)
+	return nil
+}
+
+func (f *FakeProvider) fakeRepository(owner string, repoName string) (*FakeRepository, error) {
+	repos, ok := f.Repositories[owner]
+	if !ok {
+		return nil, fmt.Errorf("no repositories found for '%s'", owner)
+	}
+	for _, r := range repos {
+		if r.GitRepo.Name == repoName {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("repository with name '%s' not found", repoName)
+}
+
+func (f *FakeProvider) fakePullRequest(owner string, repoName string, number int) (*FakePullRequest, error) {
+	r, err := f.fakeRepository(owner, repoName)
+	if err != nil {
+		return nil, err
+	}
+	pr, ok := r.PullRequests[number]
+	if !ok {
+		return nil, fmt.Errorf("pull request with id '%d' not found", number)
+	}
+	return pr, nil
+}
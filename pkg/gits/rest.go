@@ -0,0 +1,140 @@
+package gits
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// restClient invokes the REST API of a git server for the operations which the client library of its provider does
+// not support. Every provider creates its client with newRestClient so that the requests of all the providers go
+// through the transport the provider is given.
+type restClient struct {
+	client    *http.Client
+	baseURL   string
+	authorize func(req *http.Request)
+}
+
+// RestError is the response of a git server to a REST API request which failed
+type RestError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *RestError) Error() string {
+	return fmt.Sprintf("%s %s returned status %d: %s", e.Method, e.URL, e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// newRestClient creates a client of the REST API at the base URL which sends requests with the transport, or
+// http.DefaultTransport if it is nil. The transport is wrapped with a CachingTransport unless it already is one, so
+// providers keep their CachingTransport to share the cache and rate limit between their clients.
+func newRestClient(baseURL string, transport http.RoundTripper, authorize func(req *http.Request)) *restClient {
+	if _, ok := transport.(*CachingTransport); !ok {
+		transport = NewCachingTransport(transport, nil)
+	}
+	return &restClient{
+		client:    &http.Client{Transport: transport, Timeout: 60 * time.Second},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		authorize: authorize,
+	}
+}
+
+// basicAuth returns a function which authorizes requests with basic authentication
+func basicAuth(username string, password string) func(req *http.Request) {
+	return func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+// headerAuth returns a function which authorizes requests with a header such as Authorization: Bearer token
+func headerAuth(name string, value string) func(req *http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set(name, value)
+	}
+}
+
+// url returns the URL of the path relative to the base URL. Absolute URLs, such as the links to the next page of a
+// paginated response, are used as they are.
+func (c *restClient) url(path string, query url.Values) string {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = util.UrlJoin(c.baseURL, path)
+	}
+	if len(query) > 0 {
+		separator := "?"
+		if strings.Contains(u, "?") {
+			separator = "&"
+		}
+		u += separator + query.Encode()
+	}
+	return u
+}
+
+// send invokes the REST API and returns the body of a successful response. The body of the request, if not nil, is
+// sent as JSON.
+func (c *restClient) send(method string, path string, query url.Values, body interface{}) ([]byte, error) {
+	u := c.url(path, query)
+	var req *http.Request
+	var err error
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the request to %s", u)
+		}
+		req, err = http.NewRequest(method, u, bytes.NewReader(data))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequest(method, u, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the request to %s", u)
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to invoke %s %s", method, u)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the response of %s %s", method, u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &RestError{
+			Method:     method,
+			URL:        u,
+			StatusCode: resp.StatusCode,
+			Body:       string(data),
+		}
+	}
+	return data, nil
+}
+
+// do invokes the REST API and unmarshals the JSON response into the result, if not nil
+func (c *restClient) do(method string, path string, query url.Values, body interface{}, result interface{}) error {
+	data, err := c.send(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	if result != nil && len(data) > 0 {
+		err = json.Unmarshal(data, result)
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the response of %s %s", method, c.url(path, query))
+		}
+	}
+	return nil
+}
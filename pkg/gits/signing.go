@@ -0,0 +1,218 @@
+package gits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SigningFormatGPG signs commits and tags with a GPG key
+	SigningFormatGPG = "gpg"
+	// SigningFormatSSH signs commits and tags with an SSH key, which requires git 2.34 or later
+	SigningFormatSSH = "ssh"
+)
+
+// MinTrustLevel is the minimum trust of the GPG key of a signature which GitVerification accepts
+const MinTrustLevel = "fully"
+
+// gitFormat returns the value of the gpg.format git configuration for the signing format, git calls GPG openpgp
+func gitFormat(format string) string {
+	if format == SigningFormatGPG {
+		return "openpgp"
+	}
+	return format
+}
+
+// SigningFormats are the formats of the signatures of commits and tags
+var SigningFormats = []string{SigningFormatGPG, SigningFormatSSH}
+
+// GitSigning describes the key used to sign commits and tags and the keys trusted to verify them
+type GitSigning struct {
+	// Format is the format of the signatures, either gpg or ssh
+	Format string
+	// Key is the ID of the GPG key or the path of the SSH key used to sign. If it is blank git picks the GPG key of
+	// the committer.
+	Key string
+	// AllowedSignersFile is the file of the SSH public keys trusted to verify signatures
+	AllowedSignersFile string
+}
+
+// Validate checks the format of the signatures is supported and an SSH key is specified
+func (s *GitSigning) Validate() error {
+	if util.StringArrayIndex(SigningFormats, s.Format) < 0 {
+		return fmt.Errorf("unknown signing format %s, it should be one of %s", s.Format, strings.Join(SigningFormats, ", "))
+	}
+	if s.Format == SigningFormatSSH && s.Key == "" {
+		return fmt.Errorf("a key is required to sign with %s", s.Format)
+	}
+	return nil
+}
+
+// gitConfig returns the git configuration which signs commits and tags with the key
+func (s *GitSigning) gitConfig() [][]string {
+	answer := [][]string{
+		{"gpg.format", gitFormat(s.Format)},
+		{"commit.gpgsign", "true"},
+		{"tag.gpgsign", "true"},
+	}
+	if s.Key != "" {
+		answer = append(answer, []string{"user.signingkey", s.Key})
+	}
+	if s.AllowedSignersFile != "" {
+		answer = append(answer, []string{"gpg.ssh.allowedSignersFile", s.AllowedSignersFile})
+	}
+	return answer
+}
+
+// FindGPGSigningKey returns the ID of the first secret key of the GPG keyring, such as the one generated from the
+// pipeline's GPG credentials by jx step gpg credentials
+func FindGPGSigningKey() (string, error) {
+	cmd := util.Command{
+		Name: "gpg",
+		Args: []string{"--list-secret-keys", "--with-colons"},
+	}
+	output, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the GPG secret keys: %s", output)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 4 && fields[0] == "sec" && fields[4] != "" {
+			return fields[4], nil
+		}
+	}
+	return "", fmt.Errorf("no GPG secret key found, use jx step gpg credentials to import the pipeline's GPG credentials")
+}
+
+// SigningConfigureGitFn returns a ConfigureGitFn which configures the repository to sign its commits and tags with
+// the key after applying any configuration of the given function
+func SigningConfigureGitFn(configureGit ConfigureGitFn, signing *GitSigning) ConfigureGitFn {
+	return func(dir string, gitInfo *GitRepository, gitter Gitter) error {
+		if configureGit != nil {
+			err := configureGit(dir, gitInfo, gitter)
+			if err != nil {
+				return err
+			}
+		}
+		return gitter.ConfigureSigning(dir, signing)
+	}
+}
+
+// GitVerification describes the keys trusted to verify the signatures of tags and commits
+type GitVerification struct {
+	// Format is the format of the signatures, either gpg or ssh
+	Format string
+	// TrustedKeysFile is the file of the ASCII armored GPG public keys trusted to verify signatures
+	TrustedKeysFile string
+	// AllowedSignersFile is the file of the SSH public keys trusted to verify signatures
+	AllowedSignersFile string
+}
+
+// Validate checks the format of the signatures is supported and the trusted keys of the format are specified
+func (v *GitVerification) Validate() error {
+	if util.StringArrayIndex(SigningFormats, v.Format) < 0 {
+		return fmt.Errorf("unknown signing format %s, it should be one of %s", v.Format, strings.Join(SigningFormats, ", "))
+	}
+	if v.Format == SigningFormatGPG && v.TrustedKeysFile == "" {
+		return errors.New("a file of trusted GPG public keys is required to verify gpg signatures")
+	}
+	if v.Format == SigningFormatSSH && v.AllowedSignersFile == "" {
+		return errors.New("an allowed signers file is required to verify ssh signatures")
+	}
+	return nil
+}
+
+// VerifyTag verifies the tag in the repository at the given directory is signed by one of the trusted keys. GPG
+// signatures are verified with a temporary keyring which only contains the trusted keys, so a signature of any other
+// key in the keyring of the user is rejected, and the signing key must be trusted at least MinTrustLevel.
+func (v *GitVerification) VerifyTag(dir string, tag string) error {
+	err := v.Validate()
+	if err != nil {
+		return err
+	}
+	args := []string{"-c", "gpg.format=" + gitFormat(v.Format)}
+	env := map[string]string{}
+	if v.Format == SigningFormatSSH {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+v.AllowedSignersFile)
+	} else {
+		gnupgHome, err := ioutil.TempDir("", "jx-gnupg-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create a temporary GPG keyring")
+		}
+		defer os.RemoveAll(gnupgHome)
+		err = importTrustedKeys(gnupgHome, v.TrustedKeysFile)
+		if err != nil {
+			return err
+		}
+		env["GNUPGHOME"] = gnupgHome
+		args = append(args, "-c", "gpg.minTrustLevel="+MinTrustLevel)
+	}
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "git",
+		Args: append(args, "verify-tag", tag),
+		Env:  env,
+	}
+	output, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "tag %s is not signed by a trusted key: %s", tag, output)
+	}
+	return nil
+}
+
+// importTrustedKeys imports the GPG public keys of the file into the keyring and trusts them ultimately
+func importTrustedKeys(gnupgHome string, keysFile string) error {
+	env := map[string]string{"GNUPGHOME": gnupgHome}
+	cmd := util.Command{
+		Name: "gpg",
+		Args: []string{"--batch", "--import", keysFile},
+		Env:  env,
+	}
+	output, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "failed to import the trusted GPG keys of %s: %s", keysFile, output)
+	}
+	cmd = util.Command{
+		Name: "gpg",
+		Args: []string{"--batch", "--with-colons", "--list-keys"},
+		Env:  env,
+	}
+	output, err = cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the trusted GPG keys: %s", output)
+	}
+	ownerTrust := ""
+	previous := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		// the fingerprint of a primary key follows its pub record
+		if fields[0] == "fpr" && previous == "pub" && len(fields) > 9 {
+			ownerTrust += fields[9] + ":6:\n"
+		}
+		previous = fields[0]
+	}
+	if ownerTrust == "" {
+		return fmt.Errorf("no GPG public keys found in %s", keysFile)
+	}
+	ownerTrustFile := filepath.Join(gnupgHome, "ownertrust.txt")
+	err = ioutil.WriteFile(ownerTrustFile, []byte(ownerTrust), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the trust of the GPG keys")
+	}
+	cmd = util.Command{
+		Name: "gpg",
+		Args: []string{"--batch", "--import-ownertrust", ownerTrustFile},
+		Env:  env,
+	}
+	output, err = cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "failed to trust the GPG keys of %s: %s", keysFile, output)
+	}
+	return nil
+}
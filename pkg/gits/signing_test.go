@@ -0,0 +1,137 @@
+package gits_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitSigningValidate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, (&gits.GitSigning{Format: gits.SigningFormatGPG}).Validate())
+	assert.NoError(t, (&gits.GitSigning{Format: gits.SigningFormatSSH, Key: "/keys/id_ed25519"}).Validate())
+	assert.Error(t, (&gits.GitSigning{Format: gits.SigningFormatSSH}).Validate())
+	assert.Error(t, (&gits.GitSigning{Format: "x509", Key: "mykey"}).Validate())
+}
+
+func TestGitFakeSigning(t *testing.T) {
+	t.Parallel()
+	gitter := &gits.GitFake{}
+	assert.NoError(t, gitter.CreateTag("", "v0.0.1", "unsigned release"))
+	assert.Error(t, gitter.VerifyTag("", "v0.0.1"))
+	assert.Error(t, gitter.VerifyCommit("", "HEAD"))
+
+	assert.Error(t, gitter.ConfigureSigning("", &gits.GitSigning{Format: gits.SigningFormatSSH}))
+	assert.NoError(t, gitter.ConfigureSigning("", &gits.GitSigning{Format: gits.SigningFormatGPG, Key: "ABCDEF"}))
+	assert.NoError(t, gitter.CreateTag("", "v1.0.0", "signed release"))
+	assert.NoError(t, gitter.VerifyTag("", "v1.0.0"))
+	assert.NoError(t, gitter.VerifyCommit("", "HEAD"))
+	assert.Error(t, gitter.VerifyTag("", "v2.0.0"))
+}
+
+func TestGitVerificationValidate(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, (&gits.GitVerification{Format: gits.SigningFormatGPG, TrustedKeysFile: "keys.asc"}).Validate())
+	assert.NoError(t, (&gits.GitVerification{Format: gits.SigningFormatSSH, AllowedSignersFile: "allowed-signers"}).Validate())
+	assert.Error(t, (&gits.GitVerification{Format: gits.SigningFormatGPG}).Validate(), "any key of the keyring would be trusted")
+	assert.Error(t, (&gits.GitVerification{Format: gits.SigningFormatSSH}).Validate())
+}
+
+// runSigningCommand runs a command in the directory with the environment failing the test if it fails
+func runSigningCommand(t *testing.T, dir string, env []string, name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s %s: %s", name, strings.Join(args, " "), string(out))
+	return string(out)
+}
+
+// signedTagRepository creates a repository in the directory with a commit
+func signedTagRepository(t *testing.T, dir string) string {
+	repo := filepath.Join(dir, "repo")
+	runSigningCommand(t, dir, nil, "git", "init", "-q", repo)
+	runSigningCommand(t, repo, nil, "git", "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial commit")
+	return repo
+}
+
+func TestGitVerificationRejectsUntrustedGPGKeys(t *testing.T) {
+	for _, binary := range []string{"git", "gpg"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("the %s binary is not installed", binary)
+		}
+	}
+	dir, err := ioutil.TempDir("", "test-gpg-verification-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the keyring of the user has both keys so that only the trusted keys file decides which is trusted
+	gnupgHome := filepath.Join(dir, "gnupg")
+	require.NoError(t, os.Mkdir(gnupgHome, 0700))
+	env := []string{"GNUPGHOME=" + gnupgHome}
+	fingerprints := map[string]string{}
+	for _, name := range []string{"trusted", "untrusted"} {
+		userID := name + "@example.com"
+		runSigningCommand(t, dir, env, "gpg", "--batch", "--passphrase", "", "--quick-gen-key", userID, "ed25519", "sign", "never")
+		output := runSigningCommand(t, dir, env, "gpg", "--batch", "--with-colons", "--list-keys", userID)
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Split(line, ":")
+			if fields[0] == "fpr" && fingerprints[name] == "" {
+				fingerprints[name] = fields[9]
+			}
+		}
+	}
+	keys := runSigningCommand(t, dir, env, "gpg", "--batch", "--armor", "--export", fingerprints["trusted"])
+	trustedKeysFile := filepath.Join(dir, "trusted.asc")
+	require.NoError(t, ioutil.WriteFile(trustedKeysFile, []byte(keys), 0600))
+
+	repo := signedTagRepository(t, dir)
+	for _, name := range []string{"trusted", "untrusted"} {
+		runSigningCommand(t, repo, env, "git", "-c", "user.name=test", "-c", "user.email=test@example.com",
+			"-c", "user.signingkey="+fingerprints[name], "tag", "-s", "v-"+name, "-m", name+" release")
+	}
+
+	verification := &gits.GitVerification{
+		Format:          gits.SigningFormatGPG,
+		TrustedKeysFile: trustedKeysFile,
+	}
+	assert.NoError(t, verification.VerifyTag(repo, "v-trusted"))
+	assert.Error(t, verification.VerifyTag(repo, "v-untrusted"), "the untrusted key is in the keyring of the user")
+}
+
+func TestGitVerificationRejectsUntrustedSSHKeys(t *testing.T) {
+	for _, binary := range []string{"git", "ssh-keygen"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("the %s binary is not installed", binary)
+		}
+	}
+	dir, err := ioutil.TempDir("", "test-ssh-verification-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repo := signedTagRepository(t, dir)
+	for _, name := range []string{"trusted", "untrusted"} {
+		key := filepath.Join(dir, name)
+		runSigningCommand(t, dir, nil, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", key)
+		runSigningCommand(t, repo, nil, "git", "-c", "user.name=test", "-c", "user.email=test@example.com",
+			"-c", "gpg.format=ssh", "-c", "user.signingkey="+key, "tag", "-s", "v-"+name, "-m", name+" release")
+	}
+	publicKey, err := ioutil.ReadFile(filepath.Join(dir, "trusted.pub"))
+	require.NoError(t, err)
+	allowedSigners := filepath.Join(dir, "allowed-signers")
+	require.NoError(t, ioutil.WriteFile(allowedSigners, []byte("test@example.com "+string(publicKey)), 0600))
+
+	verification := &gits.GitVerification{
+		Format:             gits.SigningFormatSSH,
+		AllowedSignersFile: allowedSigners,
+	}
+	assert.NoError(t, verification.VerifyTag(repo, "v-trusted"))
+	assert.Error(t, verification.VerifyTag(repo, "v-untrusted"))
+}
@@ -0,0 +1,301 @@
+package gitresolver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HashPrefix is the prefix of the content hashes in lock files
+	HashPrefix = "sha256:"
+
+	// contentHashFile is the file in the .git directory of a checkout which caches the hash of its HEAD commit
+	contentHashFile = "jx-content-hash"
+)
+
+// LockOptions configures how a build pack and its modules are resolved against a lock file
+type LockOptions struct {
+	// Lock pins the build pack and its modules to the commits it records
+	Lock *jenkinsfile.BuildPackLock
+	// Frozen fails the resolution if the build pack or one of its modules is not pinned by the lock
+	Frozen bool
+	// CacheDir contains a checkout of each locked commit in a <host>/<path>/<commit> directory. Defaults to a directory
+	// inside the draft directory
+	CacheDir string
+	// Offline fails the resolution rather than cloning a locked commit which is not in the cache directory
+	Offline bool
+}
+
+// ResolvedBuildPack is a build pack resolved to a directory along with a lock of the commits which were used
+type ResolvedBuildPack struct {
+	PacksDir string
+	Resolver jenkinsfile.ImportFileResolver
+	Lock     *jenkinsfile.BuildPackLock
+}
+
+// ResolveBuildPack resolves the build pack and its modules. The build pack and modules pinned by the lock are checked
+// out at their locked commits and their content is verified against the locked hashes; any others are resolved at
+// their git refs.
+func ResolveBuildPack(gitter gits.Gitter, packURL string, packRef string, options *LockOptions) (*ResolvedBuildPack, error) {
+	if options == nil {
+		options = &LockOptions{}
+	}
+	var locked *jenkinsfile.LockedSource
+	if options.Lock != nil {
+		locked = options.Lock.BuildPack
+	}
+	if locked != nil && !locked.Matches(packURL, packRef) {
+		return nil, fmt.Errorf("the lock file pins the build pack %s at %s but the project uses %s at %s. Run 'jx step buildpack update' to update the lock file",
+			locked.GitURL, locked.GitRef, packURL, packRef)
+	}
+	source, dir, err := options.resolveSource("build pack", "", packURL, packRef, locked, gitter)
+	if err != nil {
+		return nil, err
+	}
+	packsDir := filepath.Join(dir, "packs")
+	answer := &ResolvedBuildPack{
+		PacksDir: packsDir,
+		Lock: &jenkinsfile.BuildPackLock{
+			BuildPack: source,
+		},
+	}
+
+	modules, err := LoadModules(packsDir)
+	if err != nil {
+		return nil, err
+	}
+	modulesResolver := &ModulesResolver{
+		Modules: map[string]*ModuleResolver{},
+	}
+	for _, module := range modules.Modules {
+		err = module.Validate()
+		if err != nil {
+			return nil, err
+		}
+		locked := options.Lock.FindModule(module.Name)
+		if locked != nil && !locked.Matches(module.GitURL, module.GitRef) {
+			if options.Frozen {
+				return nil, fmt.Errorf("the lock file pins the module %s at %s but the build pack imports it at %s. Run 'jx step buildpack update' to update the lock file",
+					module.Name, locked.GitRef, module.GitRef)
+			}
+			locked = nil
+		}
+		source, dir, err := options.resolveSource("module "+module.Name, module.Name, module.GitURL, module.GitRef, locked, gitter)
+		if err != nil {
+			return nil, err
+		}
+		answer.Lock.Modules = append(answer.Lock.Modules, source)
+		modulesResolver.Modules[module.Name] = &ModuleResolver{
+			Module:   module,
+			PacksDir: filepath.Join(dir, "packs"),
+		}
+	}
+	answer.Resolver = modulesResolver.AsImportResolver()
+	return answer, nil
+}
+
+// resolveSource returns the locked source and the directory of the git repository it was checked out in
+func (o *LockOptions) resolveSource(description string, name string, gitURL string, gitRef string, locked *jenkinsfile.LockedSource, gitter gits.Gitter) (*jenkinsfile.LockedSource, string, error) {
+	if locked == nil {
+		if o.Frozen {
+			return nil, "", fmt.Errorf("the lock file does not pin the %s. Run 'jx step buildpack lock' to update the lock file", description)
+		}
+		if o.Offline {
+			return nil, "", fmt.Errorf("cannot resolve the %s offline as it is not pinned by the lock file", description)
+		}
+		packsDir, err := InitBuildPack(gitter, gitURL, gitRef)
+		if err != nil {
+			return nil, "", err
+		}
+		dir := filepath.Dir(packsDir)
+		commit, err := gitter.GetLatestCommitSha(dir)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get the commit of the %s in %s", description, dir)
+		}
+		hash, err := HashCheckout(dir, commit)
+		if err != nil {
+			return nil, "", err
+		}
+		return &jenkinsfile.LockedSource{
+			Name:   name,
+			GitURL: gitURL,
+			GitRef: gitRef,
+			Commit: commit,
+			Hash:   hash,
+		}, dir, nil
+	}
+
+	dir, err := o.checkoutCommit(gitter, locked)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to check out the %s at commit %s", description, locked.Commit)
+	}
+	hash, err := HashDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if hash != locked.Hash {
+		return nil, "", fmt.Errorf("the content of the %s at commit %s in %s has hash %s but the lock file records %s",
+			description, locked.Commit, dir, hash, locked.Hash)
+	}
+	return locked, dir, nil
+}
+
+// checkoutCommit returns the directory of a checkout of the locked commit, cloning it into the cache directory if it is
+// not already there. As each commit is checked out in its own directory the checkouts never change once they exist.
+// The commit is checked out in a temporary directory which is renamed to the directory of the commit once it is
+// complete, so a concurrent resolution never sees a partial checkout.
+func (o *LockOptions) checkoutCommit(gitter gits.Gitter, locked *jenkinsfile.LockedSource) (string, error) {
+	cacheDir := o.CacheDir
+	if cacheDir == "" {
+		draftDir, err := util.DraftDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(draftDir, "locked-packs")
+	}
+	u, err := url.Parse(strings.TrimSuffix(locked.GitURL, ".git"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse git URL %s", locked.GitURL)
+	}
+	dir := filepath.Join(cacheDir, u.Host, u.Path, locked.Commit)
+	exists, err := util.DirExists(dir)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return dir, nil
+	}
+	if o.Offline {
+		return "", fmt.Errorf("commit %s of %s is not in the cache directory %s", locked.Commit, locked.GitURL, cacheDir)
+	}
+	parentDir := filepath.Dir(dir)
+	err = os.MkdirAll(parentDir, util.DefaultWritePermissions)
+	if err != nil {
+		return "", err
+	}
+	tempDir, err := ioutil.TempDir(parentDir, locked.Commit+"-")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create a temporary directory in %s", parentDir)
+	}
+	defer os.RemoveAll(tempDir)
+	err = gitter.Clone(locked.GitURL, tempDir)
+	if err == nil {
+		err = gitter.Checkout(tempDir, locked.Commit)
+		if err != nil {
+			// the commit may not be on the default branch so lets fetch it directly
+			err = gitter.FetchBranch(tempDir, "origin", locked.Commit)
+			if err == nil {
+				err = gitter.Checkout(tempDir, locked.Commit)
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(tempDir, dir)
+	if err != nil {
+		// another resolution may have checked out the commit first
+		exists, existsErr := util.DirExists(dir)
+		if existsErr == nil && exists {
+			return dir, nil
+		}
+		return "", errors.Wrapf(err, "failed to move the checkout of commit %s to %s", locked.Commit, dir)
+	}
+	return dir, nil
+}
+
+// HashCheckout returns the hash of the content of the checkout of the commit in a directory. The hash is cached by
+// commit in the .git directory so that it is only computed again when HEAD changes.
+func HashCheckout(dir string, commit string) (string, error) {
+	cacheFile := filepath.Join(dir, ".git", contentHashFile)
+	data, err := ioutil.ReadFile(cacheFile)
+	if err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] == commit {
+			return fields[1], nil
+		}
+	}
+	hash, err := HashDir(dir)
+	if err != nil {
+		return "", err
+	}
+	// the cache only saves hashing the checkout again so lets ignore failing to write it
+	ioutil.WriteFile(cacheFile, []byte(commit+" "+hash+"\n"), util.DefaultWritePermissions)
+	return hash, nil
+}
+
+// HashDir returns the hash of the content of the files in a directory, ignoring the .git directory so that a
+// checkout of a commit always has the same hash
+func HashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the files in %s", dir)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		fileHash, err := hashFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%x  %s\n", fileHash, file)
+	}
+	return fmt.Sprintf("%s%x", HashPrefix, hash.Sum(nil)), nil
+}
+
+func hashFile(fileName string) ([]byte, error) {
+	hash := sha256.New()
+	info, err := os.Lstat(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat file %s", fileName)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		// lets hash the target of a symlink rather than what it points to
+		target, err := os.Readlink(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read link %s", fileName)
+		}
+		io.WriteString(hash, target)
+		return hash.Sum(nil), nil
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %s", fileName)
+	}
+	defer f.Close()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %s", fileName)
+	}
+	return hash.Sum(nil), nil
+}
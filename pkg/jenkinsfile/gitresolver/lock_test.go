@@ -0,0 +1,177 @@
+package gitresolver_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile/gitresolver"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPackURL    = "https://github.com/jenkins-x-buildpacks/jenkins-x-kubernetes.git"
+	testPackCommit = "0123456789abcdef0123456789abcdef01234567"
+	testModuleURL  = "https://github.com/jenkins-x-buildpacks/jenkins-x-classic.git"
+	testModCommit  = "fedcba9876543210fedcba9876543210fedcba98"
+)
+
+func writeFile(t *testing.T, fileName string, text string) {
+	err := os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(fileName, []byte(text), util.DefaultWritePermissions)
+	require.NoError(t, err)
+}
+
+// createCache creates checkouts of a build pack which imports a module in a cache directory and returns a lock of them
+func createCache(t *testing.T, cacheDir string) *jenkinsfile.BuildPackLock {
+	packDir := filepath.Join(cacheDir, "github.com", "jenkins-x-buildpacks", "jenkins-x-kubernetes", testPackCommit)
+	writeFile(t, filepath.Join(packDir, "packs", "imports.yaml"), "modules:\n- name: classic\n  gitUrl: "+testModuleURL+"\n  gitRef: v1.0.0\n")
+	writeFile(t, filepath.Join(packDir, "packs", "maven", "pipeline.yaml"), "extends:\n  import: classic\n  file: maven/pipeline.yaml\n")
+	writeFile(t, filepath.Join(packDir, ".git", "HEAD"), testPackCommit)
+	moduleDir := filepath.Join(cacheDir, "github.com", "jenkins-x-buildpacks", "jenkins-x-classic", testModCommit)
+	writeFile(t, filepath.Join(moduleDir, "packs", "maven", "pipeline.yaml"), "agent:\n  image: maven\n")
+
+	packHash, err := gitresolver.HashDir(packDir)
+	require.NoError(t, err)
+	moduleHash, err := gitresolver.HashDir(moduleDir)
+	require.NoError(t, err)
+	return &jenkinsfile.BuildPackLock{
+		BuildPack: &jenkinsfile.LockedSource{
+			GitURL: testPackURL,
+			GitRef: "master",
+			Commit: testPackCommit,
+			Hash:   packHash,
+		},
+		Modules: []*jenkinsfile.LockedSource{
+			{
+				Name:   "classic",
+				GitURL: testModuleURL,
+				GitRef: "v1.0.0",
+				Commit: testModCommit,
+				Hash:   moduleHash,
+			},
+		},
+	}
+}
+
+func TestResolveLockedBuildPackOffline(t *testing.T) {
+	t.Parallel()
+	cacheDir, err := ioutil.TempDir("", "test-locked-packs")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	lock := createCache(t, cacheDir)
+
+	resolved, err := gitresolver.ResolveBuildPack(gits.NewGitFake(), testPackURL, "master", &gitresolver.LockOptions{
+		Lock:     lock,
+		Frozen:   true,
+		CacheDir: cacheDir,
+		Offline:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "github.com", "jenkins-x-buildpacks", "jenkins-x-kubernetes", testPackCommit, "packs"), resolved.PacksDir)
+	assert.Equal(t, lock, resolved.Lock)
+
+	file, err := resolved.Resolver(&jenkinsfile.ImportFile{Import: "classic", File: "maven/pipeline.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "github.com", "jenkins-x-buildpacks", "jenkins-x-classic", testModCommit, "packs", "maven", "pipeline.yaml"), file)
+}
+
+func TestResolveLockedBuildPackHashMismatch(t *testing.T) {
+	t.Parallel()
+	cacheDir, err := ioutil.TempDir("", "test-locked-packs")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	lock := createCache(t, cacheDir)
+
+	moduleDir := filepath.Join(cacheDir, "github.com", "jenkins-x-buildpacks", "jenkins-x-classic", testModCommit)
+	writeFile(t, filepath.Join(moduleDir, "packs", "maven", "pipeline.yaml"), "agent:\n  image: evil\n")
+
+	_, err = gitresolver.ResolveBuildPack(gits.NewGitFake(), testPackURL, "master", &gitresolver.LockOptions{
+		Lock:     lock,
+		Frozen:   true,
+		CacheDir: cacheDir,
+		Offline:  true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the content of the module classic at commit "+testModCommit)
+}
+
+func TestResolveLockedBuildPackFrozen(t *testing.T) {
+	t.Parallel()
+	cacheDir, err := ioutil.TempDir("", "test-locked-packs")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	lock := createCache(t, cacheDir)
+	lock.Modules = nil
+
+	_, err = gitresolver.ResolveBuildPack(gits.NewGitFake(), testPackURL, "master", &gitresolver.LockOptions{
+		Lock:     lock,
+		Frozen:   true,
+		CacheDir: cacheDir,
+		Offline:  true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the lock file does not pin the module classic")
+
+	_, err = gitresolver.ResolveBuildPack(gits.NewGitFake(), testPackURL, "v2.0.0", &gitresolver.LockOptions{
+		Lock:     lock,
+		Frozen:   true,
+		CacheDir: cacheDir,
+		Offline:  true,
+	})
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "the lock file pins the build pack"), err.Error())
+}
+
+func TestHashDirIgnoresGitDir(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-hash-dir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "packs", "maven", "pipeline.yaml"), "agent:\n  image: maven\n")
+
+	hash, err := gitresolver.HashDir(dir)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, gitresolver.HashPrefix), hash)
+
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/master")
+	gitHash, err := gitresolver.HashDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, hash, gitHash)
+
+	writeFile(t, filepath.Join(dir, "packs", "maven", "Jenkinsfile"), "pipeline {}")
+	changedHash, err := gitresolver.HashDir(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changedHash)
+}
+
+func TestHashCheckoutCachesHashByCommit(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-hash-checkout")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "packs", "maven", "pipeline.yaml"), "agent:\n  image: maven\n")
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), testPackCommit)
+
+	hash, err := gitresolver.HashCheckout(dir, testPackCommit)
+	require.NoError(t, err)
+	dirHash, err := gitresolver.HashDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dirHash, hash, "the cached hash does not change the hash of the directory")
+
+	writeFile(t, filepath.Join(dir, "packs", "maven", "pipeline.yaml"), "agent:\n  image: maven:3\n")
+	cachedHash, err := gitresolver.HashCheckout(dir, testPackCommit)
+	require.NoError(t, err)
+	assert.Equal(t, hash, cachedHash, "the hash is cached while HEAD is the same commit")
+
+	changedHash, err := gitresolver.HashCheckout(dir, testModCommit)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changedHash, "the hash is computed again when HEAD changes")
+}
@@ -0,0 +1,107 @@
+package jenkinsfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LockFileSuffix is appended to the name of a project configuration file to get the name of its lock file
+	LockFileSuffix = "-lock"
+)
+
+// BuildPackLock records the commits and content hashes of the build pack and the modules used by a project so that
+// the pipeline does not change when the branches of the build pack or modules move
+type BuildPackLock struct {
+	BuildPack *LockedSource   `json:"buildPack,omitempty"`
+	Modules   []*LockedSource `json:"modules,omitempty"`
+}
+
+// LockedSource is a git repository resolved to a commit
+type LockedSource struct {
+	Name   string `json:"name,omitempty"`
+	GitURL string `json:"gitUrl"`
+	GitRef string `json:"gitRef,omitempty"`
+	Commit string `json:"commit"`
+	Hash   string `json:"hash"`
+}
+
+// String returns a short description of the locked source
+func (s *LockedSource) String() string {
+	name := s.GitURL
+	if s.Name != "" {
+		name = s.Name
+	}
+	return fmt.Sprintf("%s@%s", name, shortCommit(s.Commit))
+}
+
+// Matches returns true if the source was locked from the given git URL and ref
+func (s *LockedSource) Matches(gitURL string, gitRef string) bool {
+	return strings.TrimSuffix(s.GitURL, ".git") == strings.TrimSuffix(gitURL, ".git") && normalizeRef(s.GitRef) == normalizeRef(gitRef)
+}
+
+// FindModule returns the locked module with the given name or nil if the module is not locked
+func (l *BuildPackLock) FindModule(name string) *LockedSource {
+	if l == nil {
+		return nil
+	}
+	for _, module := range l.Modules {
+		if module.Name == name {
+			return module
+		}
+	}
+	return nil
+}
+
+// LockFileName returns the name of the lock file for the given project configuration file
+func LockFileName(projectConfigFile string) string {
+	ext := filepath.Ext(projectConfigFile)
+	return strings.TrimSuffix(projectConfigFile, ext) + LockFileSuffix + ext
+}
+
+// LoadBuildPackLock loads the lock file, returning nil if it does not exist
+func LoadBuildPackLock(fileName string) (*BuildPackLock, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to load file %s", fileName)
+	}
+	lock := &BuildPackLock{}
+	err = yaml.Unmarshal(data, lock)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal file %s", fileName)
+	}
+	return lock, nil
+}
+
+// SaveConfig saves the lock file
+func (l *BuildPackLock) SaveConfig(fileName string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+}
+
+func normalizeRef(ref string) string {
+	if ref == "" {
+		return "master"
+	}
+	return ref
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
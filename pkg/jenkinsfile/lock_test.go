@@ -0,0 +1,55 @@
+package jenkinsfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFileName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, filepath.Join("myapp", "jenkins-x-lock.yml"), jenkinsfile.LockFileName(filepath.Join("myapp", "jenkins-x.yml")))
+	assert.Equal(t, "jenkins-x-arm-lock.yml", jenkinsfile.LockFileName("jenkins-x-arm.yml"))
+}
+
+func TestSaveAndLoadBuildPackLock(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-buildpack-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "jenkins-x-lock.yml")
+
+	lock, err := jenkinsfile.LoadBuildPackLock(fileName)
+	require.NoError(t, err)
+	assert.Nil(t, lock, "there is no lock file")
+
+	lock = &jenkinsfile.BuildPackLock{
+		BuildPack: &jenkinsfile.LockedSource{
+			GitURL: "https://github.com/jenkins-x-buildpacks/jenkins-x-kubernetes",
+			GitRef: "master",
+			Commit: "0123456789abcdef",
+			Hash:   "sha256:abc",
+		},
+		Modules: []*jenkinsfile.LockedSource{
+			{Name: "classic", GitURL: "https://github.com/jenkins-x-buildpacks/jenkins-x-classic", Commit: "fedcba9876543210", Hash: "sha256:def"},
+		},
+	}
+	err = lock.SaveConfig(fileName)
+	require.NoError(t, err)
+
+	loaded, err := jenkinsfile.LoadBuildPackLock(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	classic := loaded.FindModule("classic")
+	require.NotNil(t, classic)
+	assert.Equal(t, "classic@fedcba9", classic.String())
+	assert.True(t, classic.Matches("https://github.com/jenkins-x-buildpacks/jenkins-x-classic.git", "master"), "an empty ref is master")
+	assert.False(t, classic.Matches("https://github.com/jenkins-x-buildpacks/jenkins-x-classic", "v1.0.0"))
+	assert.Nil(t, loaded.FindModule("missing"))
+}
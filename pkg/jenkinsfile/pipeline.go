@@ -493,6 +493,12 @@ func LoadPipelineConfig(fileName string, resolver ImportFileResolver, jenkinsfil
 
 // LoadPipelineConfigAndMaybeValidate returns the pipeline configuration, optionally after validating the YAML.
 func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileResolver, jenkinsfileRunner bool, clearContainer bool, skipYamlValidation bool) (*PipelineConfig, error) {
+	return LoadPipelineConfigWithOrigins(fileName, resolver, jenkinsfileRunner, clearContainer, skipYamlValidation, nil, nil)
+}
+
+// LoadPipelineConfigWithOrigins returns the pipeline configuration, optionally after validating the YAML. If origins
+// is not nil the origin of each of its steps is recorded, including the steps inherited from the pipelines it extends.
+func LoadPipelineConfigWithOrigins(fileName string, resolver ImportFileResolver, jenkinsfileRunner bool, clearContainer bool, skipYamlValidation bool, origin *StepOrigin, origins StepOrigins) (*PipelineConfig, error) {
 	config := PipelineConfig{}
 	exists, err := util.FileExists(fileName)
 	if err != nil || !exists {
@@ -517,6 +523,9 @@ func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileReso
 	}
 	pipelines := &config.Pipelines
 	pipelines.RemoveWhenStatements(jenkinsfileRunner)
+	if origins != nil {
+		origins.RecordPipelineConfig(&config, origin)
+	}
 	if clearContainer {
 		// lets force any agent for prow / jenkinsfile runner
 		config.Agent = clearContainerAndLabel(config.Agent)
@@ -546,7 +555,11 @@ func LoadPipelineConfigAndMaybeValidate(fileName string, resolver ImportFileReso
 	if !exists {
 		return &config, fmt.Errorf("base pipeline file does not exist %s", file)
 	}
-	basePipeline, err := LoadPipelineConfig(file, resolver, jenkinsfileRunner, clearContainer)
+	var baseOrigin *StepOrigin
+	if origins != nil {
+		baseOrigin = origin.extendedOrigin(config.Extends)
+	}
+	basePipeline, err := LoadPipelineConfigWithOrigins(file, resolver, jenkinsfileRunner, clearContainer, true, baseOrigin, origins)
 	if err != nil {
 		return &config, errors.Wrapf(err, "Failed to base pipeline file %s", file)
 	}
@@ -0,0 +1,336 @@
+package jenkinsfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+)
+
+const (
+	// StepOriginPack is the origin of a step defined in the pipeline of a build pack
+	StepOriginPack = "pack"
+
+	// StepOriginImport is the origin of a step defined in a pipeline imported from a module of a build pack
+	StepOriginImport = "import"
+
+	// StepOriginFile is the origin of a step defined in a pipeline file which is extended by path or in the project
+	// configuration
+	StepOriginFile = "file"
+
+	// StepOriginOverride is the origin of a step which replaced a named step using an override
+	StepOriginOverride = "override"
+)
+
+// StepOrigin describes where a step of a merged pipeline was defined
+type StepOrigin struct {
+	Kind string `json:"kind"`
+	// Pack is the name of the build pack which the step came from
+	Pack string `json:"pack,omitempty"`
+	// Import is the name of the module which the step was imported from
+	Import string `json:"import,omitempty"`
+	// File is the pipeline file which defines the step, relative to the pack, the module or the project
+	File string `json:"file,omitempty"`
+	// Override is the name of the step which was replaced by an override
+	Override string `json:"override,omitempty"`
+}
+
+// String returns a short description of the origin
+func (o *StepOrigin) String() string {
+	if o == nil {
+		return "generated"
+	}
+	switch o.Kind {
+	case StepOriginPack, StepOriginImport:
+		return o.location()
+	case StepOriginOverride:
+		return fmt.Sprintf("override of %s in %s", o.Override, o.location())
+	default:
+		answer := "file " + o.File
+		if o.Import != "" {
+			answer += " of import " + o.Import
+		} else if o.Pack != "" {
+			answer += " of pack " + o.Pack
+		}
+		return answer
+	}
+}
+
+// location describes the file which defines the step
+func (o *StepOrigin) location() string {
+	switch {
+	case o.Import != "":
+		return fmt.Sprintf("import %s: %s", o.Import, o.File)
+	case o.Pack != "":
+		return fmt.Sprintf("pack %s: %s", o.Pack, o.File)
+	default:
+		return "file " + o.File
+	}
+}
+
+// extendedOrigin returns the origin of the steps of the pipeline extended by the pipeline of this origin
+func (o *StepOrigin) extendedOrigin(extends *PipelineExtends) *StepOrigin {
+	if extends.Import != "" {
+		return &StepOrigin{
+			Kind:   StepOriginImport,
+			Pack:   o.Pack,
+			Import: extends.Import,
+			File:   extends.File,
+		}
+	}
+	file := extends.File
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(o.File), file)
+	}
+	return &StepOrigin{
+		Kind:   StepOriginFile,
+		Pack:   o.Pack,
+		Import: o.Import,
+		File:   file,
+	}
+}
+
+// StepOrigins records the origin of each step of some pipeline configurations so that the origins are known once the
+// configurations have been merged
+type StepOrigins map[*syntax.Step]*StepOrigin
+
+// RecordPipelineConfig records the origin of all the steps of a pipeline configuration and the steps of its overrides
+func (o StepOrigins) RecordPipelineConfig(config *PipelineConfig, origin *StepOrigin) {
+	pipelines := &config.Pipelines
+	for _, lifecycles := range pipelines.All() {
+		if lifecycles != nil {
+			for _, named := range lifecycles.All() {
+				o.recordLifecycle(named.Lifecycle, origin)
+			}
+		}
+	}
+	o.recordLifecycle(pipelines.Post, origin)
+	for _, override := range pipelines.Overrides {
+		overrideOrigin := &StepOrigin{
+			Kind:     StepOriginOverride,
+			Pack:     origin.Pack,
+			Import:   origin.Import,
+			File:     origin.File,
+			Override: override.Name,
+		}
+		if override.Step != nil {
+			o.recordSteps([]*syntax.Step{override.Step}, overrideOrigin)
+		}
+		o.recordSteps(override.Steps, overrideOrigin)
+	}
+}
+
+func (o StepOrigins) recordLifecycle(lifecycle *PipelineLifecycle, origin *StepOrigin) {
+	if lifecycle != nil {
+		o.recordSteps(lifecycle.PreSteps, origin)
+		o.recordSteps(lifecycle.Steps, origin)
+	}
+}
+
+func (o StepOrigins) recordSteps(steps []*syntax.Step, origin *StepOrigin) {
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		if _, ok := o[step]; !ok {
+			o[step] = origin
+		}
+		o.recordSteps(loopSteps(step), origin)
+		o.recordSteps(step.Steps, origin)
+	}
+}
+
+// Origin returns the origin of a step. A step which was generated around other steps, such as to default their
+// container or directory, has the origin of the steps it contains if they all have the same origin.
+func (o StepOrigins) Origin(step *syntax.Step) *StepOrigin {
+	if origin := o[step]; origin != nil {
+		return origin
+	}
+	var answer *StepOrigin
+	for _, child := range step.Steps {
+		origin := o.Origin(child)
+		if origin == nil || (answer != nil && *origin != *answer) {
+			return nil
+		}
+		answer = origin
+	}
+	return answer
+}
+
+// EffectiveStep is a step of a merged pipeline along with where it was defined
+type EffectiveStep struct {
+	Description string
+	Origin      *StepOrigin
+	Steps       []*EffectiveStep
+}
+
+// EffectiveLifecycle is a lifecycle of a merged pipeline
+type EffectiveLifecycle struct {
+	Pipeline  string
+	Lifecycle string
+	Steps     []*EffectiveStep
+}
+
+// EffectivePipeline returns the steps of each lifecycle of the merged pipeline configuration annotated with their
+// origins. If pipelineKind is not empty only the lifecycles of that kind of pipeline are returned.
+func EffectivePipeline(config *PipelineConfig, origins StepOrigins, pipelineKind string) []*EffectiveLifecycle {
+	var answer []*EffectiveLifecycle
+	pipelines := config.Pipelines
+	for _, kind := range PipelineKinds {
+		if pipelineKind != "" && pipelineKind != kind {
+			continue
+		}
+		lifecycles, _ := pipelines.GetPipeline(kind, false)
+		if lifecycles == nil {
+			continue
+		}
+		for _, named := range lifecycles.All() {
+			if named.Lifecycle == nil || len(named.Lifecycle.Steps) == 0 {
+				continue
+			}
+			answer = append(answer, &EffectiveLifecycle{
+				Pipeline:  kind,
+				Lifecycle: named.Name,
+				Steps:     effectiveSteps(named.Lifecycle.Steps, origins),
+			})
+		}
+	}
+	if pipelineKind == "" && pipelines.Post != nil && len(pipelines.Post.Steps) > 0 {
+		answer = append(answer, &EffectiveLifecycle{
+			Pipeline:  "post",
+			Lifecycle: "post",
+			Steps:     effectiveSteps(pipelines.Post.Steps, origins),
+		})
+	}
+	return answer
+}
+
+func effectiveSteps(steps []*syntax.Step, origins StepOrigins) []*EffectiveStep {
+	var answer []*EffectiveStep
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		effective := &EffectiveStep{
+			Description: DescribeStep(step),
+			Origin:      origins.Origin(step),
+			Steps:       effectiveSteps(step.Steps, origins),
+		}
+		effective.Steps = append(effective.Steps, effectiveSteps(loopSteps(step), origins)...)
+		answer = append(answer, effective)
+	}
+	return answer
+}
+
+// loopSteps returns pointers to the steps of the loop of a step
+func loopSteps(step *syntax.Step) []*syntax.Step {
+	if step.Loop == nil {
+		return nil
+	}
+	answer := make([]*syntax.Step, 0, len(step.Loop.Steps))
+	for i := range step.Loop.Steps {
+		answer = append(answer, &step.Loop.Steps[i])
+	}
+	return answer
+}
+
+// DescribeStep returns a one line description of a step without the steps it contains
+func DescribeStep(step *syntax.Step) string {
+	var parts []string
+	add := func(key string, value string) {
+		if value != "" {
+			parts = append(parts, key+": "+value)
+		}
+	}
+	add("name", step.Name)
+	command := step.Command
+	if command != "" && len(step.Arguments) > 0 {
+		command += " " + strings.Join(step.Arguments, " ")
+	}
+	add("command", command)
+	add("sh", step.Sh)
+	add("step", step.Step)
+	add("groovy", step.Groovy)
+	if step.Loop != nil {
+		add("loop", step.Loop.Variable)
+	}
+	add("image", step.Image)
+	add("container", step.Container)
+	add("dir", step.Dir)
+	if len(parts) == 0 {
+		return "steps"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Lines returns the lines of the lifecycle as indented YAML with the origin of each step as a comment
+func (l *EffectiveLifecycle) Lines(withOrigins bool) []string {
+	answer := []string{fmt.Sprintf("%s/%s:", l.Pipeline, l.Lifecycle)}
+	return appendStepLines(answer, l.Steps, "  ", withOrigins)
+}
+
+func appendStepLines(lines []string, steps []*EffectiveStep, indent string, withOrigins bool) []string {
+	for _, step := range steps {
+		line := indent + "- " + step.Description
+		if withOrigins {
+			line += "  # " + step.Origin.String()
+		}
+		lines = append(lines, line)
+		lines = appendStepLines(lines, step.Steps, indent+"  ", withOrigins)
+	}
+	return lines
+}
+
+// EffectivePipelineLines returns the lines of all the lifecycles of a merged pipeline
+func EffectivePipelineLines(lifecycles []*EffectiveLifecycle, withOrigins bool) []string {
+	var answer []string
+	for _, lifecycle := range lifecycles {
+		answer = append(answer, lifecycle.Lines(withOrigins)...)
+	}
+	return answer
+}
+
+// DiffLines returns the lines of a line based diff from the old lines to the new lines. Removed lines are prefixed
+// with "- ", added lines with "+ " and unchanged lines with "  ".
+func DiffLines(oldLines []string, newLines []string) []string {
+	// lengths[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lengths := make([][]int, len(oldLines)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	var answer []string
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			answer = append(answer, "  "+oldLines[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			answer = append(answer, "- "+oldLines[i])
+			i++
+		default:
+			answer = append(answer, "+ "+newLines[j])
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		answer = append(answer, "- "+oldLines[i])
+	}
+	for ; j < len(newLines); j++ {
+		answer = append(answer, "+ "+newLines[j])
+	}
+	return answer
+}
@@ -0,0 +1,90 @@
+package jenkinsfile_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadProvenancePipeline(t *testing.T) (*jenkinsfile.PipelineConfig, jenkinsfile.StepOrigins) {
+	resolver := func(importFile *jenkinsfile.ImportFile) (string, error) {
+		return filepath.Join("test_data", "provenance", importFile.Import, importFile.File), nil
+	}
+	origins := jenkinsfile.StepOrigins{}
+	packOrigin := &jenkinsfile.StepOrigin{
+		Kind: jenkinsfile.StepOriginPack,
+		Pack: "maven",
+		File: "pipeline.yaml",
+	}
+	packConfig, err := jenkinsfile.LoadPipelineConfigWithOrigins(filepath.Join("test_data", "provenance", "packs", "maven", "pipeline.yaml"), resolver, true, false, false, packOrigin, origins)
+	require.NoError(t, err)
+
+	projectConfig := &jenkinsfile.PipelineConfig{
+		Pipelines: jenkinsfile.Pipelines{
+			Overrides: []*jenkinsfile.PipelineOverride{
+				{
+					Name: "deploy",
+					Step: &syntax.Step{Name: "helm-deploy", Sh: "jx step helm release"},
+				},
+			},
+		},
+	}
+	origins.RecordPipelineConfig(projectConfig, &jenkinsfile.StepOrigin{
+		Kind: jenkinsfile.StepOriginFile,
+		File: "jenkins-x.yml",
+	})
+	err = projectConfig.ExtendPipeline(packConfig, false)
+	require.NoError(t, err)
+	return projectConfig, origins
+}
+
+func TestEffectivePipelineOrigins(t *testing.T) {
+	t.Parallel()
+	projectConfig, origins := loadProvenancePipeline(t)
+
+	lines := jenkinsfile.EffectivePipelineLines(jenkinsfile.EffectivePipeline(projectConfig, origins, ""), true)
+	assert.Equal(t, []string{
+		"release/build:",
+		"  - image: maven  # import classic: base/pipeline.yaml",
+		"    - name: base-build, sh: make build  # import classic: base/pipeline.yaml",
+		"  - image: maven  # override of deploy in file jenkins-x.yml",
+		"    - name: helm-deploy, sh: jx step helm release  # override of deploy in file jenkins-x.yml",
+		"pullRequest/build:",
+		"  - image: maven  # override of base-test in pack maven: pipeline.yaml",
+		"    - name: mvn-test, sh: mvn test  # override of base-test in pack maven: pipeline.yaml",
+	}, lines)
+}
+
+func TestEffectivePipelineOfKind(t *testing.T) {
+	t.Parallel()
+	projectConfig, origins := loadProvenancePipeline(t)
+
+	lifecycles := jenkinsfile.EffectivePipeline(projectConfig, origins, jenkinsfile.PipelineKindPullRequest)
+	require.Len(t, lifecycles, 1)
+	assert.Equal(t, []string{
+		"pullRequest/build:",
+		"  - image: maven",
+		"    - name: mvn-test, sh: mvn test",
+	}, lifecycles[0].Lines(false))
+}
+
+func TestStepOriginString(t *testing.T) {
+	t.Parallel()
+	var generated *jenkinsfile.StepOrigin
+	assert.Equal(t, "generated", generated.String())
+	assert.Equal(t, "file base.yaml of pack maven", (&jenkinsfile.StepOrigin{
+		Kind: jenkinsfile.StepOriginFile,
+		Pack: "maven",
+		File: "base.yaml",
+	}).String())
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+	diff := jenkinsfile.DiffLines([]string{"a", "b", "c", "d"}, []string{"a", "c", "e", "d"})
+	assert.Equal(t, []string{"  a", "- b", "  c", "+ e", "  d"}, diff)
+}
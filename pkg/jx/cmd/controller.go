@@ -46,8 +46,10 @@ func NewCmdController(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdControllerBuild(commonOpts))
 	cmd.AddCommand(NewCmdControllerBuildNumbers(commonOpts))
 	cmd.AddCommand(NewCmdControllerEnvironment(commonOpts))
+	cmd.AddCommand(NewCmdControllerPeriodic(commonOpts))
 	cmd.AddCommand(NewCmdControllerPipelineRunner(commonOpts))
 	cmd.AddCommand(NewCmdControllerRole(commonOpts))
+	cmd.AddCommand(NewCmdControllerSchedulerDrift(commonOpts))
 	cmd.AddCommand(NewCmdControllerTeam(commonOpts))
 	cmd.AddCommand(NewCmdControllerWorkflow(commonOpts))
 	cmd.AddCommand(NewCmdControllerCommitStatus(commonOpts))
@@ -602,6 +602,10 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 }
 
 func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kubernetes.Interface, ns string, activity *v1.PipelineActivity, pri *tekton.PipelineRunInfo, pod *corev1.Pod) bool {
+	// the pods of a cancelled pipeline fail as they are stopped so keep the cancelled status
+	if activity.Spec.Status == v1.ActivityStatusTypeCancelled {
+		return false
+	}
 	originYaml := toYamlString(activity)
 	for _, stage := range pri.Stages {
 		updateForStage(stage, activity)
@@ -613,10 +617,14 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 	allCompleted := true
 	failed := false
 	running := true
+	waitingForApproval := false
 	for i := range spec.Steps {
 		step := &spec.Steps[i]
 		stage := step.Stage
 		if stage != nil {
+			if stage.Status == v1.ActivityStatusTypeWaitingForApproval {
+				waitingForApproval = true
+			}
 			stageFinished := spec.Status.IsTerminated()
 			if stage.StartedTimestamp != nil && spec.StartedTimestamp == nil {
 				spec.StartedTimestamp = stage.StartedTimestamp
@@ -696,7 +704,9 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 		}
 
 	} else {
-		if running {
+		if waitingForApproval {
+			spec.Status = v1.ActivityStatusTypeWaitingForApproval
+		} else if running {
 			spec.Status = v1.ActivityStatusTypeRunning
 		} else {
 			spec.Status = v1.ActivityStatusTypePending
@@ -895,6 +905,11 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 			}
 		}
 	}
+
+	// the approval step keeps running until the approval is decided
+	if stage.Approval != nil && stage.Approval.IsPending() && stage.Status == v1.ActivityStatusTypeRunning {
+		stage.Status = v1.ActivityStatusTypeWaitingForApproval
+	}
 }
 
 // toYamlString returns the YAML string or error when marshalling the given resource
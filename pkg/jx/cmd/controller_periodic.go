@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+// ControllerPeriodicOptions the options for the periodic controller
+type ControllerPeriodicOptions struct {
+	ControllerOptions
+
+	Interval              time.Duration
+	QueueTimeout          time.Duration
+	NoGitCredeentialsInit bool
+
+	tracker *pipelinescheduler.PeriodicTracker
+	runner  *ControllerPipelineRunnerOptions
+}
+
+var (
+	controllerPeriodicLong = templates.LongDesc(`
+		Runs the controller which triggers the periodic jobs of the Schedulers of the repositories on their cron schedules.
+
+		A periodic job runs the pipeline of its branch with its parameters as environment variables and is recorded as a
+		PipelineActivity like any other pipeline. Runs which were scheduled while the controller was not running are
+		skipped. The last run of each periodic job is recorded on the SourceRepository of its repository so that a run
+		is only triggered once when several replicas of the controller are running.
+`)
+
+	controllerPeriodicExample = templates.Examples(`
+		# run the periodic controller
+		jx controller periodic
+`)
+)
+
+// NewCmdControllerPeriodic creates the command
+func NewCmdControllerPeriodic(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerPeriodicOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "periodic",
+		Short:   "Runs the controller which triggers the periodic jobs of the Schedulers on their cron schedules",
+		Long:    controllerPeriodicLong,
+		Example: controllerPeriodicExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+		Aliases: []string{"periodics", "cron"},
+	}
+
+	cmd.Flags().DurationVarP(&options.Interval, "interval", "", 30*time.Second, "How often the schedules of the periodic jobs are checked")
+	cmd.Flags().DurationVarP(&options.QueueTimeout, "queue-timeout", "", defaultQueueTimeout, "How long a pipeline waits for the concurrency limits of its project to allow it to start")
+	cmd.Flags().StringVarP(&options.ServiceAccount, "service-account", "", "tekton-bot", "The Kubernetes ServiceAccount to use to run the pipeline")
+	cmd.Flags().BoolVarP(&options.NoGitCredeentialsInit, "no-git-init", "", false, "Disables checking we have setup git credentials on startup")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerPeriodicOptions) Run() error {
+	if !o.NoGitCredeentialsInit {
+		err := o.InitGitConfigAndUser()
+		if err != nil {
+			return err
+		}
+	}
+	o.tracker = pipelinescheduler.NewPeriodicTracker()
+	o.runner = &ControllerPipelineRunnerOptions{
+		CommonOptions:         o.CommonOptions,
+		NoGitCredeentialsInit: o.NoGitCredeentialsInit,
+		QueueTimeout:          o.QueueTimeout,
+	}
+
+	log.Infof("Checking the schedules of periodic jobs every %s\n", o.Interval.String())
+	for {
+		err := o.triggerDuePeriodics(time.Now())
+		if err != nil {
+			log.Warnf("Failed to trigger the due periodic jobs: %s\n", err)
+		}
+		time.Sleep(o.Interval)
+	}
+}
+
+// triggerDuePeriodics triggers a pipeline for each periodic job which was scheduled since the last check
+func (o *ControllerPeriodicOptions) triggerDuePeriodics(now time.Time) error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return err
+	}
+	leaves, err := pipelinescheduler.EffectiveSchedulers(jxClient, ns, teamSettings.DefaultScheduler.Name)
+	if err != nil {
+		return err
+	}
+	for _, due := range o.tracker.Due(leaves, now) {
+		request := periodicPipelineRunRequest(due)
+		go func(due *pipelinescheduler.DuePeriodic) {
+			claimed, err := pipelinescheduler.ClaimPeriodicRun(jxClient, ns, due)
+			if err != nil {
+				log.Warnf("Failed to claim periodic %s of %s/%s: %s\n", pipelinescheduler.JobName(due.Periodic.JobBase), due.Org, due.Repo, err)
+				return
+			}
+			if !claimed {
+				return
+			}
+			log.Infof("Triggering periodic %s of %s/%s scheduled at %s\n", pipelinescheduler.JobName(due.Periodic.JobBase), due.Org, due.Repo, due.Scheduled.Format(time.RFC3339))
+			_, err = o.runner.triggerPipelineRun(request)
+			if err != nil {
+				log.Warnf("Failed to trigger periodic %s of %s/%s: %s\n", pipelinescheduler.JobName(due.Periodic.JobBase), due.Org, due.Repo, err)
+			}
+		}(due)
+	}
+	return nil
+}
+
+// periodicPipelineRunRequest returns the request to trigger the pipeline of a periodic job
+func periodicPipelineRunRequest(due *pipelinescheduler.DuePeriodic) *PipelineRunRequest {
+	job := due.Periodic
+	refs := &prowapi.Refs{
+		Org:     due.Org,
+		Repo:    due.Repo,
+		BaseRef: pipelinescheduler.PeriodicBranch(job),
+	}
+	if due.Provider != "" {
+		refs.CloneURI = util.UrlJoin(due.Provider, due.Org, due.Repo) + ".git"
+	}
+	request := &PipelineRunRequest{
+		ProwJobSpec: prowapi.ProwJobSpec{
+			Type:    prowapi.PeriodicJob,
+			Job:     pipelinescheduler.JobName(job.JobBase),
+			Context: pipelinescheduler.PeriodicContext(job),
+			Refs:    refs,
+		},
+	}
+	if job.Parameters != nil {
+		request.Envs = job.Parameters.Items
+	}
+	return request
+}
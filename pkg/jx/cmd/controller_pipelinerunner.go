@@ -7,18 +7,21 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/jenkinsfile"
 	"github.com/jenkins-x/jx/pkg/log"
 
 	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/webhooks"
 	"github.com/pkg/errors"
 
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
 	"github.com/spf13/cobra"
 	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
 )
@@ -28,6 +31,11 @@ const (
 	HealthPath = "/health"
 	// ReadyPath URL path for the HTTP endpoint that returns ready status.
 	ReadyPath = "/ready"
+
+	// pipelineRunnerSecretHMACKey is the key of the HMAC secret in the Secret of the pipeline runner
+	pipelineRunnerSecretHMACKey = "hmac"
+	// pipelineRunnerSecretTokenKey is the key of the shared token in the Secret of the pipeline runner
+	pipelineRunnerSecretTokenKey = "token"
 )
 
 // ControllerPipelineRunnerOptions holds the command line arguments
@@ -35,13 +43,25 @@ type ControllerPipelineRunnerOptions struct {
 	*opts.CommonOptions
 	BindAddress           string
 	Path                  string
+	HookPath              string
 	Port                  int
 	NoGitCredeentialsInit bool
+	QueueTimeout          time.Duration
+	AuthSecret            string
+	MaxBodySize           int64
+	DeliveryTTL           time.Duration
+	HookWorkers           int
+
+	concurrencyLock sync.Mutex
+	hookQueue       chan *hookDelivery
+	hookLock        sync.Mutex
+	hookDeliveries  map[string]time.Time
 }
 
 // PipelineRunRequest the request to trigger a pipeline run
 type PipelineRunRequest struct {
 	Labels      map[string]string   `json:"labels,omitempty"`
+	Envs        map[string]string   `json:"envs,omitempty"`
 	ProwJobSpec prowapi.ProwJobSpec `json:"prowJobSpec,omitempty"`
 }
 
@@ -62,17 +82,47 @@ type ObjectReference struct {
 }
 
 var (
-	controllerPipelineRunnersLong = templates.LongDesc(`Runs the service to generate Tekton PipelineRun resources from source code webhooks such as from Prow`)
+	controllerPipelineRunnersLong = templates.LongDesc(`
+		Runs the service to generate Tekton PipelineRun resources from source code webhooks such as from Prow
+
+		The 'concurrency' section of the jenkins-x.yml of a project limits how many of its pipelines run at the same time.
+		A pipeline which would exceed the limits is queued with a Pending PipelineActivity and the request returns straight
+		away. The pipeline starts in the background once one of the running pipelines completes. The limits are checked
+		under a lock ConfigMap per repository so that they hold across replicas of this service. When a newer commit
+		is pushed to a pull request the running pipelines of older commits are cancelled and the release pipelines of a
+		branch never overlap unless the project configures otherwise.
+
+		If an auth Secret is specified requests must either be signed with its 'hmac' key in a X-Hub-Signature-256 or
+		X-Gitea-Signature header or pass its 'token' key as a bearer token. Bitbucket Cloud cannot sign webhooks so
+		its webhooks are rejected when an auth Secret is specified. A delivery which is retried with the same X-Delivery-ID or
+		X-GitHub-Delivery header gets the response of the first delivery instead of triggering another pipeline.
+
+		Push, pull request and comment webhooks of GitHub, Gitea, GitLab and Bitbucket can be sent directly to the
+		--hook-path without Prow, which requires an auth Secret. The presubmits and postsubmits of the Scheduler of the
+		repository decide which pipelines run and the repository is cloned from the URL of its SourceRepository.
+		Webhooks are acknowledged with 202 Accepted as soon as they are validated and their pipelines are triggered in
+		the background. A webhook which is delivered again with the same delivery ID is ignored.
+		Only pushes and pull requests of trusted users, the owner of the repository and the members of the trusted
+		organisation of the Scheduler, trigger pipelines. A trusted user can comment '/ok-to-test' on the pull request
+		of another user to run its presubmits, and '/test all' or '/test <job>' to run its presubmits and '/retest'
+		to run the presubmits which failed again.
+`)
 
 	controllerPipelineRunnersExample = templates.Examples(`
 			# run the pipeline runner controller
 			jx controller pipelinerunner
+
+			# only accept requests authenticated with the keys of a Secret
+			jx controller pipelinerunner --auth-secret pipelinerunner-auth
+
+			# receive authenticated webhooks from git providers at /hook
+			jx controller pipelinerunner --auth-secret pipelinerunner-auth --hook-path /hook
 		`)
 )
 
 // NewCmdControllerPipelineRunner creates the command
 func NewCmdControllerPipelineRunner(commonOpts *opts.CommonOptions) *cobra.Command {
-	options := ControllerPipelineRunnerOptions{
+	options := &ControllerPipelineRunnerOptions{
 		CommonOptions: commonOpts,
 	}
 	cmd := &cobra.Command{
@@ -93,8 +143,15 @@ func NewCmdControllerPipelineRunner(commonOpts *opts.CommonOptions) *cobra.Comma
 		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
 	cmd.Flags().StringVarP(&options.Path, "path", "p", "/",
 		"The path to listen on for requests to trigger a pipeline run.")
+	cmd.Flags().StringVarP(&options.HookPath, "hook-path", "", "",
+		"The path to listen on for push, pull request and comment webhooks from git providers, which requires --auth-secret. If empty webhooks from git providers are not handled")
 	cmd.Flags().StringVarP(&options.ServiceAccount, "service-account", "", "tekton-bot", "The Kubernetes ServiceAccount to use to run the pipeline")
 	cmd.Flags().BoolVarP(&options.NoGitCredeentialsInit, "no-git-init", "", false, "Disables checking we have setup git credentials on startup")
+	cmd.Flags().DurationVarP(&options.QueueTimeout, "queue-timeout", "", defaultQueueTimeout, "How long a pipeline waits for the concurrency limits of its project to allow it to start")
+	cmd.Flags().StringVarP(&options.AuthSecret, "auth-secret", "", "", "The name of the Secret with the 'hmac' and/or 'token' keys requests must authenticate with. If not specified requests are not authenticated")
+	cmd.Flags().Int64VarP(&options.MaxBodySize, "max-body-size", "", webhooks.DefaultMaxBodySize, "The maximum size of a request body in bytes")
+	cmd.Flags().DurationVarP(&options.DeliveryTTL, "delivery-ttl", "", webhooks.DefaultDeliveryTTL, "How long the response to a delivery is replayed when the delivery is retried to the same replica")
+	cmd.Flags().IntVarP(&options.HookWorkers, "hook-workers", "", defaultHookWorkers, "How many webhooks from git providers are handled at the same time")
 	return cmd
 }
 
@@ -106,8 +163,24 @@ func (o *ControllerPipelineRunnerOptions) Run() error {
 			return err
 		}
 	}
+	guardOptions, err := o.webhookGuardOptions()
+	if err != nil {
+		return err
+	}
+	guard := webhooks.NewGuard(http.HandlerFunc(o.pipelineRunMethods), guardOptions)
+	if !guard.IsAuthenticated() {
+		if o.HookPath != "" {
+			return errors.Errorf("webhooks from git providers cannot be handled at %s without authenticating them. Use the --auth-secret option to authenticate them", o.HookPath)
+		}
+		log.Warnf("Requests to trigger pipelines are not authenticated. Use the --auth-secret option to authenticate them\n")
+	}
 	mux := http.NewServeMux()
-	mux.Handle(o.Path, http.HandlerFunc(o.pipelineRunMethods))
+	mux.Handle(o.Path, guard)
+	if o.HookPath != "" {
+		o.startHookWorkers()
+		mux.Handle(o.HookPath, webhooks.NewGuard(http.HandlerFunc(o.handleGitHook), guardOptions))
+		log.Infof("Waiting for git provider webhooks at http://%s:%d%s", o.BindAddress, o.Port, o.HookPath)
+	}
 	mux.Handle(HealthPath, http.HandlerFunc(o.health))
 	mux.Handle(ReadyPath, http.HandlerFunc(o.ready))
 	log.Infof("Waiting for dynamic Tekton Pipelines at http://%s:%d%s", o.BindAddress, o.Port, o.Path)
@@ -147,10 +220,6 @@ func (o *ControllerPipelineRunnerOptions) pipelineRunMethods(w http.ResponseWrit
 
 // handle request for pipeline runs
 func (o *ControllerPipelineRunnerOptions) startPipelineRun(w http.ResponseWriter, r *http.Request) {
-	err := o.stepGitCredentials()
-	if err != nil {
-		log.Warn(err.Error())
-	}
 	arguments := &PipelineRunRequest{}
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -162,23 +231,38 @@ func (o *ControllerPipelineRunnerOptions) startPipelineRun(w http.ResponseWriter
 		o.returnError(err, "failed to unmarshal the JSON request body: "+err.Error(), w, r)
 		return
 	}
+	if o.Verbose {
+		log.Infof("got payload %#v", arguments)
+	}
+	if arguments.ProwJobSpec.Refs == nil {
+		err = fmt.Errorf("no prowJobSpec.refs passed in so cannot determine git repository")
+		o.returnError(err, err.Error()+". Input: "+string(data), w, r)
+		return
+	}
+
+	results, err := o.triggerPipelineRun(arguments)
 	if err != nil {
-		o.returnError(err, "could not parse body: "+err.Error(), w, r)
+		o.returnError(err, err.Error(), w, r)
 		return
 	}
-	if o.Verbose {
-		log.Infof("got payload %#v", arguments)
+	err = o.marshalPayload(w, r, results)
+	if err != nil {
+		o.returnError(err, "failed to marshal payload", w, r)
+	}
+	return
+}
+
+// triggerPipelineRun creates the PipelineRun for the request
+func (o *ControllerPipelineRunnerOptions) triggerPipelineRun(arguments *PipelineRunRequest) (*PipelineRunResponse, error) {
+	err := o.stepGitCredentials()
+	if err != nil {
+		log.Warn(err.Error())
 	}
 	pj := arguments.ProwJobSpec
 
 	var revision string
 	var prNumber string
 
-	if pj.Refs == nil {
-		o.returnError(err, "no prowJobSpec.refs passed in so cannot determine git repository. Input: "+string(data), w, r)
-		return
-	}
-
 	// lets change this to support new pipelineresource type that handles batches
 	if len(pj.Refs.Pulls) > 0 {
 		revision = pj.Refs.Pulls[0].SHA
@@ -189,21 +273,22 @@ func (o *ControllerPipelineRunnerOptions) startPipelineRun(w http.ResponseWriter
 
 	envs, err := downwardapi.EnvForSpec(downwardapi.NewJobSpec(pj, "", ""))
 	if err != nil {
-		o.returnError(err, "failed to get env vars from prowjob", w, r)
-		return
+		return nil, errors.Wrap(err, "failed to get env vars from prowjob")
 	}
 
-	sourceURL := fmt.Sprintf("https://github.com/%s/%s.git", pj.Refs.Org, pj.Refs.Repo)
+	sourceURL := pj.Refs.CloneURI
 	if sourceURL == "" {
-		o.returnError(err, "missing sourceURL property", w, r)
-		return
+		sourceURL = fmt.Sprintf("https://github.com/%s/%s.git", pj.Refs.Org, pj.Refs.Repo)
+	}
+	if revision == "" {
+		revision = pj.Refs.BaseRef
 	}
 	if revision == "" {
 		revision = "master"
 	}
 
 	pr := &StepCreateTaskOptions{}
-	if pj.Type == prowapi.PostsubmitJob {
+	if pj.Type == prowapi.PostsubmitJob || pj.Type == prowapi.PeriodicJob {
 		pr.PipelineKind = jenkinsfile.PipelineKindRelease
 	} else {
 		pr.PipelineKind = jenkinsfile.PipelineKindPullRequest
@@ -228,6 +313,9 @@ func (o *ControllerPipelineRunnerOptions) startPipelineRun(w http.ResponseWriter
 	pr.Branch = branch
 	pr.Revision = revision
 	pr.ServiceAccount = o.ServiceAccount
+	pr.QueueTimeout = o.QueueTimeout
+	pr.ConcurrencyLock = &o.concurrencyLock
+	pr.QueueInBackground = true
 
 	// turn map into string array with = separator to match type of custom labels which are CLI flags
 	for key, value := range arguments.Labels {
@@ -238,23 +326,44 @@ func (o *ControllerPipelineRunnerOptions) startPipelineRun(w http.ResponseWriter
 	for key, value := range envs {
 		pr.CustomEnvs = append(pr.CustomEnvs, fmt.Sprintf("%s=%s", key, value))
 	}
+	for key, value := range arguments.Envs {
+		pr.CustomEnvs = append(pr.CustomEnvs, fmt.Sprintf("%s=%s", key, value))
+	}
 
 	log.Infof("triggering pipeline for repo %s branch %s revision %s context %s\n", sourceURL, branch, revision, pj.Context)
 
 	err = pr.Run()
 	if err != nil {
-		o.returnError(err, err.Error(), w, r)
-		return
+		return nil, err
 	}
-
-	results := &PipelineRunResponse{
+	return &PipelineRunResponse{
 		Resources: pr.Results.ObjectReferences(),
+	}, nil
+}
+
+// webhookGuardOptions returns the options to authenticate, limit and deduplicate requests
+func (o *ControllerPipelineRunnerOptions) webhookGuardOptions() (webhooks.GuardOptions, error) {
+	guardOptions := webhooks.GuardOptions{
+		MaxBodySize: o.MaxBodySize,
+		DeliveryTTL: o.DeliveryTTL,
 	}
-	err = o.marshalPayload(w, r, results)
+	if o.AuthSecret == "" {
+		return guardOptions, nil
+	}
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
 	if err != nil {
-		o.returnError(err, "failed to marshal payload", w, r)
+		return guardOptions, err
 	}
-	return
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(o.AuthSecret, metav1.GetOptions{})
+	if err != nil {
+		return guardOptions, errors.Wrapf(err, "failed to load the auth Secret %s in namespace %s", o.AuthSecret, ns)
+	}
+	guardOptions.HMACSecret = secret.Data[pipelineRunnerSecretHMACKey]
+	guardOptions.Token = string(secret.Data[pipelineRunnerSecretTokenKey])
+	if len(guardOptions.HMACSecret) == 0 && guardOptions.Token == "" {
+		return guardOptions, fmt.Errorf("the auth Secret %s in namespace %s has neither a %s nor a %s key", o.AuthSecret, ns, pipelineRunnerSecretHMACKey, pipelineRunnerSecretTokenKey)
+	}
+	return guardOptions, nil
 }
 
 func (o *ControllerPipelineRunnerOptions) isReady() bool {
@@ -317,7 +426,7 @@ func (o *ControllerPipelineRunnerOptions) stepGitCredentials() error {
 
 func getBranch(spec prowapi.ProwJobSpec) string {
 	branch := spec.Refs.BaseRef
-	if spec.Type == prowapi.PostsubmitJob {
+	if spec.Type == prowapi.PostsubmitJob || spec.Type == prowapi.PeriodicJob {
 		return branch
 	}
 	if spec.Type == prowapi.BatchJob {
@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/webhooks"
+	"github.com/pkg/errors"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+const (
+	// defaultHookWorkers is how many webhooks are handled at the same time by default
+	defaultHookWorkers = 4
+	// hookQueueSize is how many webhooks can wait to be handled
+	hookQueueSize = 100
+
+	// hookStatusQueued is the status of a webhook which has been queued to be handled
+	hookStatusQueued = "queued"
+	// hookStatusDuplicate is the status of a webhook whose delivery has already been received
+	hookStatusDuplicate = "duplicate"
+
+	// okToTestLabel is the label of a pull request from an untrusted user whose presubmits a trusted user approved
+	okToTestLabel = "ok-to-test"
+)
+
+// hookDelivery is a webhook waiting to be handled
+type hookDelivery struct {
+	id    string
+	event *webhooks.Event
+}
+
+// HookResponse is the response to a webhook from a git provider
+type HookResponse struct {
+	Delivery string `json:"delivery"`
+	Status   string `json:"status"`
+}
+
+// handleGitHook validates a push, pull request or comment webhook from a git provider and acknowledges it straight
+// away. The presubmits and postsubmits of the Scheduler of the repository are triggered in the background so that the
+// git provider does not time out and retry the delivery while pipelines are being created.
+func (o *ControllerPipelineRunnerOptions) handleGitHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		fmt.Fprintf(w, "Please POST webhooks from your git provider to this endpoint!\n")
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		o.returnError(err, "could not read the webhook request body: "+err.Error(), w, r)
+		return
+	}
+	event, err := webhooks.ParseEvent(r, data)
+	if err != nil {
+		if webhooks.IsIgnoredEvent(err) {
+			log.Infof("%s\n", err.Error())
+			o.onError(o.marshalPayload(w, r, &PipelineRunResponse{}))
+			return
+		}
+		o.returnError(err, err.Error(), w, r)
+		return
+	}
+
+	id := webhooks.DeliveryID(r)
+	if id == "" {
+		// deliveries without an ID are identified by their content
+		sum := sha256.Sum256(data)
+		id = hex.EncodeToString(sum[:])
+	}
+	response := &HookResponse{
+		Delivery: id,
+		Status:   hookStatusQueued,
+	}
+	if !o.startHookDelivery(id) {
+		log.Infof("ignoring the duplicate delivery %s of a %s event of %s/%s\n", id, event.Kind, event.Owner, event.Repo)
+		response.Status = hookStatusDuplicate
+		o.onError(o.marshalPayload(w, r, response))
+		return
+	}
+	select {
+	case o.hookQueue <- &hookDelivery{id: id, event: event}:
+	default:
+		o.forgetHookDelivery(id)
+		http.Error(w, "too many webhooks are waiting to be handled", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	o.onError(o.marshalPayload(w, r, response))
+}
+
+// startHookWorkers creates the queue of webhooks and starts the workers which handle them
+func (o *ControllerPipelineRunnerOptions) startHookWorkers() {
+	o.hookQueue = make(chan *hookDelivery, hookQueueSize)
+	o.hookDeliveries = map[string]time.Time{}
+	workers := o.HookWorkers
+	if workers <= 0 {
+		workers = defaultHookWorkers
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for delivery := range o.hookQueue {
+				o.handleHookDelivery(delivery)
+			}
+		}()
+	}
+}
+
+// startHookDelivery records the delivery returning false if it has already been received within the delivery TTL
+func (o *ControllerPipelineRunnerOptions) startHookDelivery(id string) bool {
+	o.hookLock.Lock()
+	defer o.hookLock.Unlock()
+
+	now := time.Now()
+	ttl := o.DeliveryTTL
+	if ttl <= 0 {
+		ttl = webhooks.DefaultDeliveryTTL
+	}
+	for deliveryID, received := range o.hookDeliveries {
+		if now.Sub(received) >= ttl {
+			delete(o.hookDeliveries, deliveryID)
+		}
+	}
+	if _, ok := o.hookDeliveries[id]; ok {
+		return false
+	}
+	o.hookDeliveries[id] = now
+	return true
+}
+
+// forgetHookDelivery forgets a delivery which could not be queued so that it can be retried
+func (o *ControllerPipelineRunnerOptions) forgetHookDelivery(id string) {
+	o.hookLock.Lock()
+	defer o.hookLock.Unlock()
+	delete(o.hookDeliveries, id)
+}
+
+// handleHookDelivery triggers the pipelines of a queued webhook. A pipeline which fails to trigger does not stop the
+// other pipelines of the webhook from being triggered.
+func (o *ControllerPipelineRunnerOptions) handleHookDelivery(delivery *hookDelivery) {
+	event := delivery.event
+	requests, err := o.pipelineRunRequestsForEvent(event)
+	if err != nil {
+		log.Errorf("failed to handle the delivery %s of a %s event of %s/%s: %s\n", delivery.id, event.Kind, event.Owner, event.Repo, err)
+		return
+	}
+	for _, request := range requests {
+		_, err := o.triggerPipelineRun(request)
+		if err != nil {
+			log.Errorf("failed to trigger the job %s for the delivery %s of %s/%s: %s\n", request.ProwJobSpec.Job, delivery.id, event.Owner, event.Repo, err)
+		}
+	}
+}
+
+// pipelineRunRequestsForEvent returns a request to trigger a pipeline for each job of the Scheduler of the repository
+// which should run for the event
+func (o *ControllerPipelineRunnerOptions) pipelineRunRequestsForEvent(event *webhooks.Event) ([]*PipelineRunRequest, error) {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return nil, err
+	}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := pipelinescheduler.EffectiveScheduler(jxClient, ns, teamSettings.DefaultScheduler.Name, event.Owner, event.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		log.Infof("ignoring %s event as the repository %s/%s has no scheduler\n", event.Kind, event.Owner, event.Repo)
+		return nil, nil
+	}
+	scheduler := leaf.SchedulerSpec
+
+	// the repository is cloned from its SourceRepository rather than from the URL in the webhook
+	cloneURL, err := sourceRepositoryCloneURL(leaf)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := o.GitProviderForURL(cloneURL, "git provider")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the git provider for %s", cloneURL)
+	}
+	refs := prowapi.Refs{
+		Org:      leaf.Org,
+		Repo:     leaf.Repo,
+		CloneURI: cloneURL,
+	}
+	var requests []*PipelineRunRequest
+	if event.Kind == webhooks.EventKindPush {
+		trusted, err := isTrustedUser(provider, scheduler.Trigger, leaf.Org, event.Sender)
+		if err != nil {
+			return nil, err
+		}
+		if !trusted {
+			log.Infof("ignoring push to %s of %s/%s as %s is not a trusted user\n", event.Branch, event.Owner, event.Repo, event.Sender)
+			return nil, nil
+		}
+		jobs, err := pipelinescheduler.PostsubmitsToRun(scheduler, event.Branch, event.ChangedFiles)
+		if err != nil {
+			return nil, err
+		}
+		refs.BaseRef = event.Branch
+		refs.BaseSHA = event.Sha
+		for _, job := range jobs {
+			jobRefs := refs
+			requests = append(requests, &PipelineRunRequest{
+				ProwJobSpec: prowapi.ProwJobSpec{
+					Type:    prowapi.PostsubmitJob,
+					Job:     pipelinescheduler.JobName(job.JobBase),
+					Context: pipelinescheduler.PostsubmitContext(job),
+					Refs:    &jobRefs,
+				},
+			})
+		}
+		return requests, nil
+	}
+
+	user := event.PullRequest.Author
+	if event.Kind == webhooks.EventKindComment {
+		user = event.Sender
+	}
+	trusted, err := isTrustedUser(provider, scheduler.Trigger, leaf.Org, user)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted && event.Kind == webhooks.EventKindPullRequest {
+		// a trusted user approves the pull requests of other users by commenting /ok-to-test
+		trusted, err = isOkToTest(provider, scheduler.Trigger, leaf.Org, leaf.Repo, event.PullRequest.Number)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !trusted {
+		log.Infof("ignoring %s event on pull request %d of %s/%s as %s is not a trusted user\n", event.Kind, event.PullRequest.Number, event.Owner, event.Repo, user)
+		return nil, nil
+	}
+	pr, err := completePullRequest(provider, event)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*v1.Presubmit
+	switch {
+	case event.Kind == webhooks.EventKindPullRequest:
+		jobs, err = pipelinescheduler.PresubmitsToRun(scheduler, pr.BaseRef, nil)
+	case pipelinescheduler.IsOkToTestComment(event.Comment):
+		if ignoreOkToTest(scheduler.Trigger) {
+			log.Infof("ignoring /ok-to-test on pull request %d of %s/%s as the trigger ignores it\n", pr.Number, event.Owner, event.Repo)
+			return nil, nil
+		}
+		// the label approves the later commits of the pull request too
+		err = provider.AddLabelsToIssue(leaf.Org, leaf.Repo, pr.Number, []string{okToTestLabel})
+		if err != nil {
+			log.Warnf("failed to add the %s label to pull request %d of %s/%s: %s\n", okToTestLabel, pr.Number, event.Owner, event.Repo, err)
+		}
+		jobs, err = pipelinescheduler.PresubmitsToRun(scheduler, pr.BaseRef, nil)
+	case pipelinescheduler.IsRetestComment(event.Comment):
+		var contexts []string
+		contexts, err = failedContexts(provider, event.Owner, event.Repo, pr.HeadSha)
+		if err == nil {
+			jobs, err = pipelinescheduler.PresubmitsForContexts(scheduler, pr.BaseRef, contexts)
+		}
+	default:
+		jobs, err = pipelinescheduler.PresubmitsForComment(scheduler, pr.BaseRef, event.Comment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	refs.BaseRef = pr.BaseRef
+	refs.BaseSHA = pr.BaseSha
+	refs.Pulls = []prowapi.Pull{
+		{
+			Number: pr.Number,
+			Author: pr.Author,
+			SHA:    pr.HeadSha,
+			Link:   pr.Link,
+		},
+	}
+	for _, job := range jobs {
+		jobRefs := refs
+		requests = append(requests, &PipelineRunRequest{
+			ProwJobSpec: prowapi.ProwJobSpec{
+				Type:    prowapi.PresubmitJob,
+				Job:     pipelinescheduler.JobName(job.JobBase),
+				Context: pipelinescheduler.PresubmitContext(job),
+				Refs:    &jobRefs,
+			},
+		})
+	}
+	return requests, nil
+}
+
+// isTrustedUser returns true if the user is the owner of the repository or a member of the trusted organisation of
+// the trigger. Git providers which cannot check memberships only trust the owner, so the pull requests of other users
+// need a trusted user to comment /ok-to-test.
+func isTrustedUser(provider gits.GitProvider, trigger *v1.Trigger, owner string, user string) (bool, error) {
+	if user != "" && strings.EqualFold(user, owner) {
+		return true, nil
+	}
+	checker, ok := provider.(gits.OrganisationChecker)
+	if !ok || user == "" {
+		return false, nil
+	}
+	org := owner
+	if trigger != nil && trigger.TrustedOrg != nil && *trigger.TrustedOrg != "" {
+		org = *trigger.TrustedOrg
+	}
+	member, err := checker.IsUserInOrganisation(user, org)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check if %s is a member of %s", user, org)
+	}
+	return member, nil
+}
+
+// isOkToTest returns true if a trusted user has approved testing the pull request with /ok-to-test, which adds the
+// ok-to-test label to it
+func isOkToTest(provider gits.GitProvider, trigger *v1.Trigger, owner string, repo string, number int) (bool, error) {
+	if ignoreOkToTest(trigger) {
+		return false, nil
+	}
+	pr, err := provider.GetPullRequest(owner, &gits.GitRepository{Name: repo}, number)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get pull request %d of %s/%s", number, owner, repo)
+	}
+	for _, label := range pr.Labels {
+		if label != nil && label.Name != nil && *label.Name == okToTestLabel {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ignoreOkToTest returns true if the trigger only runs the presubmits of pull requests from trusted users
+func ignoreOkToTest(trigger *v1.Trigger) bool {
+	return trigger != nil && trigger.IgnoreOkToTest != nil && *trigger.IgnoreOkToTest
+}
+
+// sourceRepositoryCloneURL returns the URL to clone the repository of a scheduler from its SourceRepository
+func sourceRepositoryCloneURL(leaf *pipelinescheduler.SchedulerLeaf) (string, error) {
+	if leaf.Provider == "" {
+		return "", fmt.Errorf("the SourceRepository %s of %s/%s has no git provider", leaf.SourceRepository, leaf.Org, leaf.Repo)
+	}
+	return util.UrlJoin(leaf.Provider, leaf.Org, leaf.Repo) + ".git", nil
+}
+
+// completePullRequest returns the pull request of the event, looking up the refs from the git provider if the event
+// does not include them
+func completePullRequest(provider gits.GitProvider, event *webhooks.Event) (*webhooks.EventPullRequest, error) {
+	if event.PullRequest.Complete {
+		return event.PullRequest, nil
+	}
+	number := event.PullRequest.Number
+	pr, err := provider.GetPullRequest(event.Owner, &gits.GitRepository{Name: event.Repo}, number)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pull request %d of %s/%s", number, event.Owner, event.Repo)
+	}
+	answer := &webhooks.EventPullRequest{
+		Number:   number,
+		HeadSha:  pr.LastCommitSha,
+		BaseSha:  pr.BaseSha,
+		Link:     pr.URL,
+		Complete: true,
+	}
+	if pr.Author != nil {
+		answer.Author = pr.Author.Login
+	}
+	if pr.HeadRef != nil {
+		answer.HeadRef = *pr.HeadRef
+	}
+	if pr.BaseRef != nil {
+		answer.BaseRef = *pr.BaseRef
+	}
+	if answer.BaseRef == "" {
+		answer.BaseRef = "master"
+	}
+	return answer, nil
+}
+
+// failedContexts returns the status contexts which failed for the revision
+func failedContexts(provider gits.GitProvider, owner string, repo string, sha string) ([]string, error) {
+	statuses, err := provider.ListCommitStatus(owner, repo, sha)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the statuses of commit %s of %s/%s", sha, owner, repo)
+	}
+	var answer []string
+	for _, status := range statuses {
+		if status.State == "failure" || status.State == "error" {
+			answer = append(answer, status.Context)
+		}
+	}
+	return answer, nil
+}
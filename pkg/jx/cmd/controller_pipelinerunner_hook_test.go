@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPushHook = `{
+	"ref": "refs/heads/master",
+	"after": "abc123",
+	"repository": {"name": "myapp", "clone_url": "https://github.com/myorg/myapp.git", "owner": {"login": "myorg"}},
+	"sender": {"login": "jstrachan"}
+}`
+
+func postHook(o *ControllerPipelineRunnerOptions, delivery string, body string) (*httptest.ResponseRecorder, *HookResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "push")
+	if delivery != "" {
+		req.Header.Set("X-GitHub-Delivery", delivery)
+	}
+	w := httptest.NewRecorder()
+	o.handleGitHook(w, req)
+	response := &HookResponse{}
+	json.Unmarshal(w.Body.Bytes(), response)
+	return w, response
+}
+
+func TestHandleGitHookQueuesDeliveries(t *testing.T) {
+	t.Parallel()
+	o := &ControllerPipelineRunnerOptions{
+		hookQueue:      make(chan *hookDelivery, 1),
+		hookDeliveries: map[string]time.Time{},
+	}
+
+	w, response := postHook(o, "delivery-1", testPushHook)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, hookStatusQueued, response.Status)
+	require.Len(t, o.hookQueue, 1)
+	queued := <-o.hookQueue
+	assert.Equal(t, "delivery-1", queued.id)
+	assert.Equal(t, webhooks.EventKindPush, queued.event.Kind)
+	assert.Equal(t, "myapp", queued.event.Repo)
+
+	w, response = postHook(o, "delivery-1", testPushHook)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, hookStatusDuplicate, response.Status, "a redelivered webhook is not handled again")
+	assert.Len(t, o.hookQueue, 0)
+
+	// deliveries without an ID are identified by their content
+	w, _ = postHook(o, "", testPushHook)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	w, response = postHook(o, "", testPushHook)
+	assert.Equal(t, hookStatusDuplicate, response.Status)
+
+	// a delivery which cannot be queued can be retried
+	w, _ = postHook(o, "delivery-2", testPushHook)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	<-o.hookQueue
+	w, _ = postHook(o, "delivery-2", testPushHook)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w, _ = postHook(o, "delivery-3", `{"ref": "refs/tags/v1.0.0", "after": "abc123"}`)
+	assert.Equal(t, http.StatusOK, w.Code, "ignored events are acknowledged")
+}
+
+// orgCheckingProvider is a fake git provider which can check the members of organisations
+type orgCheckingProvider struct {
+	*gits.FakeProvider
+	members map[string][]string
+}
+
+func (p *orgCheckingProvider) IsUserInOrganisation(user string, organisation string) (bool, error) {
+	for _, member := range p.members[organisation] {
+		if member == user {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestIsTrustedUser(t *testing.T) {
+	t.Parallel()
+	fakeProvider := gits.NewFakeProvider()
+	trustedOrg := "trustedorg"
+	provider := &orgCheckingProvider{
+		FakeProvider: fakeProvider,
+		members: map[string][]string{
+			"myorg":      {"jstrachan"},
+			"trustedorg": {"rawlingsj"},
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		provider gits.GitProvider
+		trigger  *v1.Trigger
+		user     string
+		trusted  bool
+	}{
+		{name: "owner", provider: fakeProvider, user: "MyOrg", trusted: true},
+		{name: "no membership checks", provider: fakeProvider, user: "jstrachan", trusted: false},
+		{name: "no user", provider: provider, user: "", trusted: false},
+		{name: "member", provider: provider, user: "jstrachan", trusted: true},
+		{name: "not a member", provider: provider, user: "someone", trusted: false},
+		{name: "trusted org member", provider: provider, trigger: &v1.Trigger{TrustedOrg: &trustedOrg}, user: "rawlingsj", trusted: true},
+		{name: "not a trusted org member", provider: provider, trigger: &v1.Trigger{TrustedOrg: &trustedOrg}, user: "jstrachan", trusted: false},
+	} {
+		trusted, err := isTrustedUser(test.provider, test.trigger, "myorg", test.user)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.trusted, trusted, test.name)
+	}
+}
+
+func TestIsOkToTest(t *testing.T) {
+	t.Parallel()
+	label := okToTestLabel
+	provider := gits.NewFakeProvider(&gits.FakeRepository{
+		Owner:   "myorg",
+		GitRepo: &gits.GitRepository{Name: "myapp"},
+		PullRequests: map[int]*gits.FakePullRequest{
+			1: {PullRequest: &gits.GitPullRequest{Labels: []*gits.Label{{Name: &label}}}},
+			2: {PullRequest: &gits.GitPullRequest{}},
+		},
+	})
+
+	okToTest, err := isOkToTest(provider, nil, "myorg", "myapp", 1)
+	require.NoError(t, err)
+	assert.True(t, okToTest)
+
+	okToTest, err = isOkToTest(provider, nil, "myorg", "myapp", 2)
+	require.NoError(t, err)
+	assert.False(t, okToTest, "the pull request has not been approved")
+
+	ignore := true
+	okToTest, err = isOkToTest(provider, &v1.Trigger{IgnoreOkToTest: &ignore}, "myorg", "myapp", 1)
+	require.NoError(t, err)
+	assert.False(t, okToTest, "the trigger ignores /ok-to-test")
+}
+
+func TestSourceRepositoryCloneURL(t *testing.T) {
+	t.Parallel()
+	cloneURL, err := sourceRepositoryCloneURL(&pipelinescheduler.SchedulerLeaf{
+		Org:      "myorg",
+		Repo:     "myapp",
+		Provider: "https://github.com/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/myorg/myapp.git", cloneURL)
+
+	_, err = sourceRepositoryCloneURL(&pipelinescheduler.SchedulerLeaf{Org: "myorg", Repo: "myapp", SourceRepository: "myorg-myapp"})
+	assert.Error(t, err)
+}
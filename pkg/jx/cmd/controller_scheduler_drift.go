@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/spf13/cobra"
+)
+
+// ControllerSchedulerDriftOptions the options for the scheduler drift controller
+type ControllerSchedulerDriftOptions struct {
+	ControllerOptions
+
+	Interval time.Duration
+	Restore  bool
+
+	lastSummary  string
+	lastRestored string
+}
+
+var (
+	controllerSchedulerDriftLong = templates.LongDesc(`
+		Runs the controller which checks whether the live Prow configuration has drifted from the configuration generated
+		from the Schedulers, such as when the config or plugins ConfigMaps are edited directly.
+
+		An event is recorded on the config ConfigMap when the configuration drifts. With --restore the generated
+		configuration is applied whenever a drift is found, with a pull request on the environment repository when the
+		team uses GitOps. A drift is not restored again until it has been resolved, so only one pull request is created
+		for it.
+`)
+
+	controllerSchedulerDriftExample = templates.Examples(`
+		# report drift of the Prow configuration
+		jx controller scheduler-drift
+
+		# restore the generated Prow configuration whenever it drifts
+		jx controller scheduler-drift --restore
+`)
+)
+
+// NewCmdControllerSchedulerDrift creates the command
+func NewCmdControllerSchedulerDrift(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerSchedulerDriftOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "scheduler-drift",
+		Short:   "Runs the controller which detects and restores drift of the Prow configuration from the Schedulers",
+		Long:    controllerSchedulerDriftLong,
+		Example: controllerSchedulerDriftExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+		Aliases: []string{"drift"},
+	}
+
+	cmd.Flags().DurationVarP(&options.Interval, "interval", "", 5*time.Minute, "How often the live Prow configuration is compared with the Schedulers")
+	cmd.Flags().BoolVarP(&options.Restore, "restore", "", false, "Applies the generated Prow configuration whenever the live configuration has drifted")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerSchedulerDriftOptions) Run() error {
+	log.Infof("Checking the Prow configuration for drift every %s\n", o.Interval.String())
+	for {
+		err := o.checkDrift()
+		if err != nil {
+			log.Warnf("Failed to check the Prow configuration for drift: %s\n", err)
+		}
+		time.Sleep(o.Interval)
+	}
+}
+
+// checkDrift compares the live Prow configuration with the Schedulers. An event is recorded when the configuration is
+// restored or drifts in a different way from the last check, so an unrestored drift is only reported once.
+func (o *ControllerSchedulerDriftOptions) checkDrift() error {
+	drift, restored, err := detectAndRestoreProwDrift(o.CommonOptions, func(drift *pipelinescheduler.ProwConfigDrift) bool {
+		return o.Restore && drift.Summary() != o.lastRestored
+	})
+	if err != nil {
+		return err
+	}
+	if !drift.HasDrifted() {
+		o.lastSummary = ""
+		o.lastRestored = ""
+		return nil
+	}
+	summary := drift.Summary()
+	if restored {
+		log.Infof("Restored the generated Prow configuration as %s\n", summary)
+		recordProwDrift(o.CommonOptions, drift, true)
+		o.lastSummary = summary
+		o.lastRestored = summary
+		return nil
+	}
+	if summary != o.lastSummary {
+		log.Warnf("%s\n", summary)
+		recordProwDrift(o.CommonOptions, drift, false)
+		o.lastSummary = summary
+	}
+	return nil
+}
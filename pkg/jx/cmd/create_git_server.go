@@ -22,6 +22,9 @@ var (
 		# Add a new Git server with a name
 		jx create git server -k bitbucketcloud -u http://bitbucket.org -n MyBitBucket 
 
+		# Add an Azure DevOps organization
+		jx create git server -k azuredevops -u https://dev.azure.com/myorg
+
 		For more documentation see: [https://jenkins-x.io/developing/git/](https://jenkins-x.io/developing/git/)
 
 	`)
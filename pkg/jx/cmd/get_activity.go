@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
 	"strings"
 	"time"
@@ -216,11 +218,47 @@ func addStageRow(table *tbl.Table, stage *v1.StageActivityStep, indent string) {
 	addStepRowItem(table, &stage.CoreActivityStep, indent, name, "")
 
 	indent += indentation
+	if stage.Approval != nil {
+		addApprovalRow(table, stage.Approval, indent)
+	}
 	for _, step := range stage.Steps {
 		addStepRowItem(table, &step, indent, "", "")
 	}
 }
 
+func addApprovalRow(table *tbl.Table, approval *v1.ApprovalActivityStep, indent string) {
+	step := &v1.CoreActivityStep{
+		Name:               "Approval",
+		Description:        approval.Message,
+		StartedTimestamp:   approval.RequestedTimestamp,
+		CompletedTimestamp: approval.DecidedTimestamp,
+		Status:             v1.ActivityStatusTypeWaitingForApproval,
+	}
+	description := ""
+	if approval.IsPending() {
+		if approval.DeadlineTimestamp != nil {
+			description = fmt.Sprintf("%s in %s", strings.ToLower(string(approval.DefaultOutcome)), util.ColorWarning(timeUntilString(approval.DeadlineTimestamp)))
+		}
+		if approval.Environment != "" {
+			description = strings.TrimSpace(description + " Environment: " + util.ColorInfo(approval.Environment))
+		}
+	} else {
+		step.Status = v1.ActivityStatusTypeSucceeded
+		if approval.Outcome == v1.ApprovalOutcomeTypeRejected {
+			step.Status = v1.ActivityStatusTypeFailed
+		}
+		decidedBy := approval.DecidedBy
+		if decidedBy == "" {
+			decidedBy = "default"
+		}
+		description = string(approval.Outcome) + " by " + util.ColorInfo(decidedBy)
+		if approval.Comment != "" {
+			description += ": " + approval.Comment
+		}
+	}
+	addStepRowItem(table, step, indent, "", description)
+}
+
 func addPreviewRow(table *tbl.Table, parent *v1.PreviewActivityStep, indent string) {
 	pullRequestURL := parent.PullRequestURL
 	if pullRequestURL == "" {
@@ -285,6 +323,8 @@ func statusString(statusType v1.ActivityStatusType) string {
 		return util.ColorInfo(text)
 	case v1.ActivityStatusTypeRunning:
 		return util.ColorStatus(text)
+	case v1.ActivityStatusTypeWaitingForApproval, v1.ActivityStatusTypeCancelled:
+		return util.ColorWarning(text)
 	}
 	return text
 }
@@ -342,6 +382,15 @@ func timeToString(t *metav1.Time) string {
 	return durationString(t, now)
 }
 
+// timeUntilString returns how long it is until the given time, or zero if it has passed
+func timeUntilString(t *metav1.Time) string {
+	d := time.Until(t.Time).Round(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	return d.String()
+}
+
 func (o *GetActivityOptions) matches(activity *v1.PipelineActivity) bool {
 	answer := true
 	filter := o.Filter
@@ -54,6 +54,7 @@ func NewCmdGetBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.AddCommand(NewCmdGetBuildLogs(commonOpts))
 	cmd.AddCommand(NewCmdGetBuildPods(commonOpts))
+	cmd.AddCommand(NewCmdGetBuildTimes(commonOpts))
 	return cmd
 }
 
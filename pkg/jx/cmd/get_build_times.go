@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	tbl "github.com/jenkins-x/jx/pkg/table"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetBuildTimesOptions the command line options
+type GetBuildTimesOptions struct {
+	GetOptions
+
+	BuildTimes builds.BuildTimesOptions
+	Threshold  int
+}
+
+var (
+	getBuildTimesLong = templates.LongDesc(`
+		Displays how long the stages and steps of a pipeline take across its recent runs.
+
+		The durations are read from the PipelineActivity of each run. For every stage and step the p50 and p90 durations
+		of the recent runs are shown along with the trend compared with the baseline runs before them. A step is a
+		regression if its p50 has grown by more than the threshold compared with the baseline.
+
+`)
+
+	getBuildTimesExample = templates.Examples(`
+		# Display the build times of the last 20 runs of a pipeline
+		jx get build times myorg/myapp/master
+
+		# Compare the last 10 runs with the 50 runs before them
+		jx get build times myorg/myapp/master --runs 10 --baseline 50
+
+		# Output the build times as JSON
+		jx get build times myorg/myapp/master -o json
+	`)
+)
+
+// NewCmdGetBuildTimes creates the command
+func NewCmdGetBuildTimes(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetBuildTimesOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "times [pipeline]",
+		Short:   "Displays the p50 and p90 durations of the stages and steps of a pipeline across its recent runs",
+		Long:    getBuildTimesLong,
+		Example: getBuildTimesExample,
+		Aliases: []string{"time", "timings"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addGetFlags(cmd)
+	cmd.Flags().IntVarP(&options.BuildTimes.Runs, "runs", "n", 20, "The number of recent runs to analyse")
+	cmd.Flags().IntVarP(&options.BuildTimes.BaselineRuns, "baseline", "b", 20, "The number of runs before the recent runs to compare with")
+	cmd.Flags().BoolVarP(&options.BuildTimes.IncludeFailed, "include-failed", "", false, "Includes the runs which did not succeed")
+	cmd.Flags().IntVarP(&options.Threshold, "threshold", "t", 20, "The percentage by which the p50 has to grow to be a regression")
+	cmd.Flags().DurationVarP(&options.BuildTimes.MinRegression, "min-regression", "", 10*time.Second, "The smallest growth of the p50 which is reported as a regression")
+	cmd.Flags().IntVarP(&options.BuildTimes.Slowest, "slowest", "", 5, "The number of slowest steps to display")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetBuildTimesOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+
+	pipeline := ""
+	if len(o.Args) > 0 {
+		pipeline = o.Args[0]
+	} else {
+		names := map[string]string{}
+		for _, a := range list.Items {
+			if a.Spec.Pipeline != "" {
+				names[a.Spec.Pipeline] = a.Spec.Pipeline
+			}
+		}
+		pipelines := util.SortedMapKeys(names)
+		if len(pipelines) == 0 {
+			return outputEmptyListWarning(o.Out)
+		}
+		if o.BatchMode {
+			return util.MissingArgument("pipeline")
+		}
+		pipeline, err = util.PickName(pipelines, "Pick the pipeline:", "", o.In, o.Out, o.Err)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.BuildTimes.RegressionThreshold = float64(o.Threshold) / 100
+	report := builds.AnalyzeBuildTimes(pipeline, list.Items, o.BuildTimes)
+	if o.Output != "" {
+		return o.renderResult(report, o.Output)
+	}
+	if report.Total == nil {
+		return fmt.Errorf("no completed runs of pipeline %s", pipeline)
+	}
+
+	fmt.Fprintf(o.Out, "Pipeline %s: %d runs (builds %s to %s)\n\n", util.ColorInfo(pipeline), len(report.Builds),
+		report.Builds[0], report.Builds[len(report.Builds)-1])
+
+	table := o.CreateTable()
+	table.AddRow("STAGE / STEP", "RUNS", "P50", "P90", "MAX", "BASELINE", "TREND", "RECENT")
+	addBuildTimesRow(&table, report.Total, "")
+	for _, stage := range report.Stages {
+		addBuildTimesRow(&table, stage, indentation)
+		for _, step := range report.Steps {
+			if step.Stage == stage.Stage {
+				addBuildTimesRow(&table, step, indentation+indentation)
+			}
+		}
+	}
+	table.Render()
+
+	if len(report.Slowest) > 0 {
+		fmt.Fprintf(o.Out, "\nSlowest steps:\n")
+		table = o.CreateTable()
+		table.AddRow("STEP", "P50", "P90")
+		for _, step := range report.Slowest {
+			table.AddRow(step.Name, step.P50.String(), step.P90.String())
+		}
+		table.Render()
+	}
+
+	if len(report.Regressions) > 0 {
+		sort.SliceStable(report.Regressions, func(i, j int) bool {
+			return report.Regressions[i].Trend > report.Regressions[j].Trend
+		})
+		fmt.Fprintf(o.Out, "\n%s\n", util.ColorWarning("Regressions:"))
+		table = o.CreateTable()
+		table.AddRow("STAGE / STEP", "BASELINE P50", "P50", "TREND")
+		for _, r := range report.Regressions {
+			table.AddRow(r.Name, r.BaselineP50.String(), r.P50.String(), util.ColorError(formatTrend(r.Trend)))
+		}
+		table.Render()
+	}
+	return nil
+}
+
+func addBuildTimesRow(table *tbl.Table, bt *builds.BuildTimes, indent string) {
+	name := bt.Step
+	if name == "" {
+		name = bt.Name
+	}
+	baseline := ""
+	trend := ""
+	if bt.BaselineRuns > 0 {
+		baseline = bt.BaselineP50.String()
+		trend = formatTrend(bt.Trend)
+		if bt.Regression {
+			trend = util.ColorError(trend)
+		}
+	}
+	table.AddRow(indent+name, fmt.Sprintf("%d", bt.Runs), bt.P50.String(), bt.P90.String(), bt.Max.String(),
+		baseline, trend, builds.Sparkline(bt.Durations))
+}
+
+// formatTrend formats the relative change of a duration as a percentage with an arrow
+func formatTrend(trend float64) string {
+	arrow := "→"
+	if trend > 0.005 {
+		arrow = "↑"
+	} else if trend < -0.005 {
+		arrow = "↓"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%+.0f%% %s", trend*100, arrow))
+}
@@ -2,12 +2,17 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
 	"net/url"
 	"sort"
 
 	gojenkins "github.com/jenkins-x/golang-jenkins"
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/tekton/graph"
 
 	"github.com/jenkins-x/jx/pkg/prow"
 
@@ -19,6 +24,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/table"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // GetPipelineOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
@@ -29,6 +35,9 @@ type GetPipelineOptions struct {
 	JenkinsSelector opts.JenkinsSelectorOptions
 
 	ProwOptions prow.Options
+
+	Graph       bool
+	GraphFormat string
 }
 
 var (
@@ -43,6 +52,12 @@ var (
 
 		# Lists all the pipelines in a custom Jenkins App
 		jx get pipeline -m
+
+		# Renders the stages of the latest Tekton pipeline run for a repository as a DOT graph coloured by status
+		jx get pipeline --graph myorg-myrepo-master | dot -Tsvg > pipeline.svg
+
+		# Renders a pipeline run as a Mermaid flowchart
+		jx get pipeline --graph myorg-myrepo-master-3 --graph-format mermaid
 	`)
 )
 
@@ -72,6 +87,8 @@ func NewCmdGetPipeline(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().BoolVarP(&options.JenkinsSelector.UseCustomJenkins, "custom", "m", false, "List the pipelines in custom Jenkins App instead of the default execution engine in Jenkins X")
 	cmd.Flags().StringVarP(&options.JenkinsSelector.CustomJenkinsName, "name", "n", "", "The name of the custom Jenkins App if you don't wish to list the pipelines in the default execution engine in Jenkins X")
+	cmd.Flags().BoolVarP(&options.Graph, "graph", "", false, "Renders the stages of the Tekton pipeline run matching the argument as a graph coloured by the status of its PipelineActivity")
+	cmd.Flags().StringVarP(&options.GraphFormat, "graph-format", "", graph.FormatDOT, "The graph format, one of: "+strings.Join(graph.Formats, ", "))
 
 	return cmd
 }
@@ -82,6 +99,9 @@ func (o *GetPipelineOptions) Run() error {
 	if jo.CustomJenkinsName != "" {
 		jo.UseCustomJenkins = true
 	}
+	if o.Graph {
+		return o.renderGraph()
+	}
 
 	_, _, err := o.JXClient()
 	if err != nil {
@@ -161,6 +181,64 @@ func (o *GetPipelineOptions) Run() error {
 	return nil
 }
 
+// renderGraph renders the PipelineStructure with the given name, or the latest one whose name contains the argument
+func (o *GetPipelineOptions) renderGraph() error {
+	if len(o.Args) == 0 {
+		return errors.New("please specify the name of the pipeline run to render")
+	}
+	filter := o.Args[0]
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	structures, err := jxClient.JenkinsV1().PipelineStructures(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PipelineStructures in namespace %s: %s", ns, err)
+	}
+	var structure *v1.PipelineStructure
+	for i := range structures.Items {
+		ps := &structures.Items[i]
+		if ps.Name == filter {
+			structure = ps
+			break
+		}
+		if strings.Contains(ps.Name, filter) && (structure == nil || structure.CreationTimestamp.Before(&ps.CreationTimestamp)) {
+			structure = ps
+		}
+	}
+	if structure == nil {
+		return fmt.Errorf("no pipeline runs found matching %s in namespace %s", filter, ns)
+	}
+
+	g := graph.FromPipelineStructure(structure)
+	activity, err := o.findStructureActivity(jxClient, ns, structure)
+	if err != nil {
+		log.Warnf("Failed to find the PipelineActivity for %s so the graph has no status: %s\n", structure.Name, err)
+	} else if activity == nil {
+		log.Warnf("No PipelineActivity found for %s so the graph has no status\n", structure.Name)
+	} else {
+		g.SetStatus(activity)
+	}
+	return writeGraph(o.Out, g, o.GraphFormat, "")
+}
+
+// findStructureActivity finds the PipelineActivity of the PipelineRun of a PipelineStructure
+func (o *GetPipelineOptions) findStructureActivity(jxClient versioned.Interface, ns string, structure *v1.PipelineStructure) (*v1.PipelineActivity, error) {
+	tektonClient, _, err := o.TektonClient()
+	if err != nil {
+		return nil, err
+	}
+	prName := structure.Name
+	if structure.PipelineRunRef != nil && *structure.PipelineRunRef != "" {
+		prName = *structure.PipelineRunRef
+	}
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get(prName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun %s in namespace %s: %s", prName, ns, err)
+	}
+	return tekton.FindPipelineActivity(jxClient, ns, pr)
+}
+
 func createTable(o *GetPipelineOptions) table.Table {
 	table := o.CreateTable()
 	table.AddRow("Name", "URL", "LAST_BUILD", "STATUS", "DURATION")
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GitSigningFlags are the flags of the commands which sign the git commits and tags they create
+type GitSigningFlags struct {
+	Sign               bool
+	Format             string
+	Key                string
+	AllowedSignersFile string
+}
+
+// AddFlags adds the flags which configure signing
+func (f *GitSigningFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&f.Sign, "sign", "", false, "Signs the git commits and tags with the pipeline's GPG credentials or the --signing-key")
+	cmd.Flags().StringVarP(&f.Format, "signing-format", "", gits.SigningFormatGPG, "The format of the signatures, one of: "+strings.Join(gits.SigningFormats, ", "))
+	cmd.Flags().StringVarP(&f.Key, "signing-key", "", "", "The ID of the GPG key or the path of the SSH key to sign with. Defaults to the GPG key of the pipeline's GPG credentials")
+	cmd.Flags().StringVarP(&f.AllowedSignersFile, "allowed-signers-file", "", "", "The file of the SSH public keys trusted to verify signatures")
+}
+
+// GitSigning returns how to sign commits and tags, or nil if they are not signed. If no GPG key is specified the
+// secret key of the pipeline's GPG credentials is used, importing the credentials if the keyring has no secret key.
+func (f *GitSigningFlags) GitSigning(commonOpts *opts.CommonOptions) (*gits.GitSigning, error) {
+	if !f.Sign {
+		return nil, nil
+	}
+	signing := &gits.GitSigning{
+		Format:             f.Format,
+		Key:                f.Key,
+		AllowedSignersFile: f.AllowedSignersFile,
+	}
+	if signing.Format == gits.SigningFormatGPG && signing.Key == "" {
+		key, err := gits.FindGPGSigningKey()
+		if err != nil {
+			log.Infof("Importing the pipeline's GPG credentials as the keyring has no secret key\n")
+			credentials := &StepGpgCredentialsOptions{
+				StepOptions: StepOptions{
+					CommonOptions: commonOpts,
+				},
+			}
+			err = credentials.Run()
+			if err != nil {
+				return nil, errors.Wrapf(err, "importing the pipeline's GPG credentials")
+			}
+			key, err = gits.FindGPGSigningKey()
+			if err != nil {
+				return nil, err
+			}
+		}
+		signing.Key = key
+	}
+	return signing, signing.Validate()
+}
@@ -44,17 +44,18 @@ import (
 )
 
 const (
-	OptionServerName       = "name"
-	OptionServerURL        = "url"
-	OptionBatchMode        = "batch-mode"
-	OptionVerbose          = "verbose"
-	OptionLogLevel         = "log-level"
-	OptionNoBrew           = "no-brew"
-	OptionInstallDeps      = "install-dependencies"
-	OptionSkipAuthSecMerge = "skip-auth-secrets-merge"
-	OptionEnvironment      = "env"
-	OptionApplication      = "app"
-	OptionTimeout          = "timeout"
+	OptionServerName        = "name"
+	OptionServerURL         = "url"
+	OptionBatchMode         = "batch-mode"
+	OptionVerbose           = "verbose"
+	OptionLogLevel          = "log-level"
+	OptionNoBrew            = "no-brew"
+	OptionInstallDeps       = "install-dependencies"
+	OptionSkipAuthSecMerge  = "skip-auth-secrets-merge"
+	OptionEnvironment       = "env"
+	OptionApplication       = "app"
+	OptionTimeout           = "timeout"
+	OptionGitImplementation = "git-implementation"
 )
 
 // ModifyDevEnvironmentFn a callback to create/update the development Environment
@@ -73,6 +74,7 @@ type CommonOptions struct {
 	Domain                 string
 	Err                    io.Writer
 	ExternalJenkinsBaseURL string
+	GitImplementation      string
 	In                     terminal.FileReader
 	InstallDependencies    bool
 	LogLevel               string
@@ -190,6 +192,7 @@ func (o *CommonOptions) AddCommonFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVarP(&o.NoBrew, OptionNoBrew, "", false, "Disables brew package manager on MacOS when installing binary dependencies")
 	cmd.PersistentFlags().BoolVarP(&o.InstallDependencies, OptionInstallDeps, "", false, "Enables automatic dependencies installation when required")
 	cmd.PersistentFlags().BoolVarP(&o.SkipAuthSecretsMerge, OptionSkipAuthSecMerge, "", false, "Skips merging the secrets from local files with the secrets from Kubernetes cluster")
+	cmd.PersistentFlags().StringVarP(&o.GitImplementation, OptionGitImplementation, "", os.Getenv(gits.GitImplementationEnvVar), fmt.Sprintf("The implementation of the git operations, one of: %s. Defaults to $%s or %s", strings.Join(gits.GitImplementations, ", "), gits.GitImplementationEnvVar, gits.GitImplementationCLI))
 
 	o.Cmd = cmd
 }
@@ -393,7 +396,7 @@ func (o *CommonOptions) JXClientDevAndAdminNamespace() (versioned.Interface, str
 // Git returns the git client
 func (o *CommonOptions) Git() gits.Gitter {
 	if o.git == nil {
-		o.git = gits.NewGitCLI()
+		o.git = gits.NewGitter(o.GitImplementation)
 	}
 	return o.git
 }
@@ -66,6 +66,9 @@ type PromoteOptions struct {
 	PullRequestPollTime     string
 	Filter                  string
 	Alias                   string
+	VerifyTag               bool
+	TrustedKeysFile         string
+	Signing                 GitSigningFlags
 
 	// allow git to be configured externally before a PR is created
 	ConfigureGitCallback gits.ConfigureGitFn
@@ -92,6 +95,11 @@ var (
 	promote_long = templates.LongDesc(`
 		Promotes a version of an application to zero to many permanent environments.
 
+		Use --verify-tag to reject versions whose release tag is not signed by a trusted key and --sign to sign the
+		commits of the promotion with the pipeline's GPG credentials or an SSH key. GPG signatures must be made by one
+		of the keys of --trusted-keys-file, which are the only keys trusted, and SSH signatures by one of the keys of
+		--allowed-signers-file.
+
 		For more documentation see: [https://jenkins-x.io/about/features/#promotion](https://jenkins-x.io/about/features/#promotion)
 
 `)
@@ -111,6 +119,9 @@ var (
 		# To promote a postgres chart using an alias
 		jx promote -f postgres --alias mydb
 
+		# Promote a version whose release tag is signed by a trusted GPG key, signing the commits of the promotion
+		jx promote --version 1.2.3 --env production --verify-tag --trusted-keys-file release-keys.asc --sign
+
 		# To create or update a Preview Environment please see the 'jx preview' command
 		jx preview
 	`)
@@ -139,6 +150,9 @@ func NewCmdPromote(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.AllAutomatic, "all-auto", "", false, "Promote to all automatic environments in order")
 
 	options.addPromoteOptions(cmd)
+	cmd.Flags().BoolVarP(&options.VerifyTag, "verify-tag", "", false, "Rejects the promotion unless the release tag v$VERSION of the current directory is signed by a trusted key")
+	cmd.Flags().StringVarP(&options.TrustedKeysFile, "trusted-keys-file", "", "", "The file of the ASCII armored GPG public keys trusted to sign release tags")
+	options.Signing.AddFlags(cmd)
 	return cmd
 }
 
@@ -183,6 +197,20 @@ func (o *PromoteOptions) Run() error {
 	}
 	o.Application = app
 
+	if o.VerifyTag {
+		err := o.verifyReleaseTag()
+		if err != nil {
+			return err
+		}
+	}
+	signing, err := o.Signing.GitSigning(o.CommonOptions)
+	if err != nil {
+		return err
+	}
+	if signing != nil {
+		o.ConfigureGitCallback = gits.SigningConfigureGitFn(o.ConfigureGitCallback, signing)
+	}
+
 	jxClient, ns, err := o.JXClientAndDevNamespace()
 	if err != nil {
 		return err
@@ -494,6 +522,25 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 	return err
 }
 
+// verifyReleaseTag rejects unsigned release tags before they are promoted
+func (o *PromoteOptions) verifyReleaseTag() error {
+	if o.Version == "" {
+		return util.MissingOption("version")
+	}
+	tag := "v" + o.Version
+	verification := &gits.GitVerification{
+		Format:             o.Signing.Format,
+		TrustedKeysFile:    o.TrustedKeysFile,
+		AllowedSignersFile: o.Signing.AllowedSignersFile,
+	}
+	err := verification.VerifyTag("", tag)
+	if err != nil {
+		return errors.Wrapf(err, "verifying the release tag %s before promoting %s", tag, o.Application)
+	}
+	log.Infof("Verified the signature of the release tag %s\n", util.ColorInfo(tag))
+	return nil
+}
+
 func (o *PromoteOptions) GetTargetNamespace(ns string, env string) (string, *v1.Environment, error) {
 	kubeClient, currentNs, err := o.KubeClientAndNamespace()
 	if err != nil {
@@ -33,6 +33,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(NewCmdStepApprove(commonOpts))
 	cmd.AddCommand(NewCmdStepBuildPack(commonOpts))
 	cmd.AddCommand(NewCmdStepBDD(commonOpts))
 	cmd.AddCommand(NewCmdStepBlog(commonOpts))
@@ -53,16 +54,19 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepPR(commonOpts))
 	cmd.AddCommand(NewCmdStepPost(commonOpts))
 	cmd.AddCommand(NewCmdStepRelease(commonOpts))
+	cmd.AddCommand(NewCmdStepReject(commonOpts))
 	cmd.AddCommand(NewCmdStepSplitMonorepo(commonOpts))
 	cmd.AddCommand(NewCmdStepSyntax(commonOpts))
 	cmd.AddCommand(NewCmdStepTag(commonOpts))
 	cmd.AddCommand(NewCmdStepValidate(commonOpts))
 	cmd.AddCommand(NewCmdStepVerify(commonOpts))
 	cmd.AddCommand(NewCmdStepWaitForArtifact(commonOpts))
+	cmd.AddCommand(NewCmdStepWaitForApproval(commonOpts))
 	cmd.AddCommand(NewCmdStepStash(commonOpts))
 	cmd.AddCommand(NewCmdStepUnstash(commonOpts))
 	cmd.AddCommand(NewCmdStepValuesSchemaTemplate(commonOpts))
 	cmd.AddCommand(NewCmdStepScheduler(commonOpts))
+	cmd.AddCommand(NewCmdStepServices(commonOpts))
 
 	return cmd
 }
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	stepApproveLong = templates.LongDesc(`
+		Approves a pipeline which is waiting in an approval stage so that the pipeline continues.
+
+		Only users bound to the environment of the approval stage by an EnvironmentRoleBinding can approve it. If the
+		approval stage specifies roles the user must be bound to one of those roles. Use 'jx edit userrole' to change
+		the roles of a user. The user is the one the Kubernetes API server authenticates the current kube context as,
+		which is found with a SelfSubjectReview.
+
+		If no PipelineActivity is specified you are prompted to pick one of the activities waiting for an approval.
+`)
+
+	stepApproveExample = templates.Examples(`
+		# pick a pipeline waiting for approval and approve it
+		jx step approve
+
+		# approve a stage of a specific pipeline
+		jx step approve myorg-myapp-master-12 --stage "Approve production" --comment "change CR-1234"
+			`)
+)
+
+// StepApproveOptions contains the command line flags for approving or rejecting an approval stage
+type StepApproveOptions struct {
+	StepOptions
+
+	Stage   string
+	Comment string
+
+	outcome v1.ApprovalOutcomeType
+}
+
+// NewCmdStepApprove Creates a new Command object
+func NewCmdStepApprove(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepApproveOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+		outcome: v1.ApprovalOutcomeTypeApproved,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "approve [activity]",
+		Short:   "Approves a pipeline which is waiting in an approval stage",
+		Long:    stepApproveLong,
+		Example: stepApproveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *StepApproveOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Stage, "stage", "s", "", "The name of the approval stage. Only required if the pipeline has more than one approval stage")
+	cmd.Flags().StringVarP(&o.Comment, "comment", "c", "", "A comment recorded with the decision")
+}
+
+// Run implements this command
+func (o *StepApproveOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+
+	name := ""
+	if len(o.Args) > 0 {
+		name = kube.ToValidName(o.Args[0])
+	} else {
+		name, err = o.pickPendingActivity(jxClient, ns)
+		if err != nil {
+			return err
+		}
+	}
+	activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find PipelineActivity %s in namespace %s", name, ns)
+	}
+	stage, err := kube.FindApprovalStage(activity, o.Stage)
+	if err != nil {
+		return err
+	}
+	if !stage.Approval.IsPending() {
+		return fmt.Errorf("stage %s of %s was already %s", stage.Name, name, stage.Approval.Outcome)
+	}
+
+	userName, err := kube.AuthenticatedUser(kubeClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to find the authenticated user deciding the approval")
+	}
+	adminNs, err := kube.GetAdminNamespace(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	approver, err := kube.IsApprover(jxClient, ns, adminNs, userName, stage.Approval)
+	if err != nil {
+		return err
+	}
+	if !approver {
+		return fmt.Errorf("user %s is not allowed to decide stage %s of %s as they are not bound to %s", userName, stage.Name, name, describeApprovers(stage.Approval))
+	}
+
+	_, err = kube.DecideApproval(jxClient, ns, name, stage.Name, o.outcome, userName, o.Comment)
+	if err != nil {
+		return err
+	}
+	log.Infof("Stage %s of %s is now %s\n", util.ColorInfo(stage.Name), util.ColorInfo(name), util.ColorInfo(o.outcome))
+	return nil
+}
+
+// pickPendingActivity returns the only activity waiting for an approval or lets the user pick one
+func (o *StepApproveOptions) pickPendingActivity(jxClient versioned.Interface, ns string) (string, error) {
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+	var names []string
+	for i := range activities.Items {
+		if len(kube.PendingApprovals(&activities.Items[i])) > 0 {
+			names = append(names, activities.Items[i].Name)
+		}
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) == 0:
+		return "", errors.New("there are no pipelines waiting for approval")
+	case len(names) == 1:
+		return names[0], nil
+	case o.BatchMode:
+		return "", fmt.Errorf("please specify one of the pipelines waiting for approval: %s", strings.Join(names, ", "))
+	}
+	return util.PickName(names, "Pick the pipeline:", "", o.In, o.Out, o.Err)
+}
+
+// describeApprovers describes the EnvironmentRoleBindings allowed to decide an approval
+func describeApprovers(approval *v1.ApprovalActivityStep) string {
+	text := "an EnvironmentRoleBinding"
+	if len(approval.Roles) > 0 {
+		text = fmt.Sprintf("one of the roles %v", approval.Roles)
+	}
+	if approval.Environment != "" {
+		text += " of environment " + approval.Environment
+	}
+	return text
+}
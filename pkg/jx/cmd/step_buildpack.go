@@ -31,6 +31,9 @@ func NewCmdStepBuildPack(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.AddCommand(NewCmdStepBuildPackApply(commonOpts))
+	cmd.AddCommand(NewCmdStepBuildPackEffective(commonOpts))
+	cmd.AddCommand(NewCmdStepBuildPackLock(commonOpts))
+	cmd.AddCommand(NewCmdStepBuildPackUpdate(commonOpts))
 	return cmd
 }
 
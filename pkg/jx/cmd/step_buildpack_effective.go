@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepBuildPackEffectiveLong = templates.LongDesc(`
+		Displays the effective pipeline of a project after the build pack inheritance and the overrides have been
+		applied. Each step is annotated with where it was defined: the build pack, a module imported by the build pack,
+		a pipeline file which was extended or an override which replaced a named step.
+
+		Use --diff-ref to compare the effective pipeline with the one generated from another Git reference of the build
+		packs, such as before upgrading the build packs of a team.
+`)
+
+	stepBuildPackEffectiveExample = templates.Examples(`
+		# displays the effective pull request pipeline of the current project
+		jx step buildpack effective
+
+		# displays the effective release pipeline generated by the 'maven' build pack
+		jx step buildpack effective --kind release --pack maven
+
+		# displays how the effective pipeline changes between two versions of the build packs
+		jx step buildpack effective --diff-ref v1.0.0 --ref master
+`)
+)
+
+// StepBuildPackEffectiveOptions contains the command line flags
+type StepBuildPackEffectiveOptions struct {
+	StepOptions
+	EffectivePipelineFlags
+
+	DiffRef   string
+	NoOrigins bool
+}
+
+// NewCmdStepBuildPackEffective Creates a new Command object
+func NewCmdStepBuildPackEffective(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepBuildPackEffectiveOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "effective",
+		Short:   "Displays the effective pipeline of a project and where each of its steps was defined",
+		Long:    stepBuildPackEffectiveLong,
+		Example: stepBuildPackEffectiveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.EffectivePipelineFlags.AddFlags(cmd)
+	cmd.Flags().StringVarP(&options.DiffRef, "diff-ref", "", "", "The Git reference of the build packs to compare the effective pipeline with")
+	cmd.Flags().BoolVarP(&options.NoOrigins, "no-origins", "", false, "Do not annotate the steps with where they were defined")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepBuildPackEffectiveOptions) Run() error {
+	var err error
+	if o.Dir == "" {
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.DiffRef == "" {
+		lines, err := o.effectivePipelineLines(o.EffectivePipelineFlags)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, strings.Join(lines, "\n"))
+		return nil
+	}
+
+	// the build packs are loaded one after another as both references may be checked out in the same directory
+	oldFlags := o.EffectivePipelineFlags
+	oldFlags.BuildPackRef = o.DiffRef
+	oldLines, err := o.effectivePipelineLines(oldFlags)
+	if err != nil {
+		return err
+	}
+	newLines, err := o.effectivePipelineLines(o.EffectivePipelineFlags)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, line := range jenkinsfile.DiffLines(oldLines, newLines) {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			line = util.ColorError(line)
+			changed = true
+		case strings.HasPrefix(line, "+ "):
+			line = util.ColorInfo(line)
+			changed = true
+		}
+		fmt.Fprintln(o.Out, line)
+	}
+	if !changed {
+		log.Infof("The effective pipeline is the same for the build packs at %s\n", util.ColorInfo(o.DiffRef))
+	}
+	return nil
+}
+
+func (o *StepBuildPackEffectiveOptions) effectivePipelineLines(flags EffectivePipelineFlags) ([]string, error) {
+	pipelineConfig, origins, err := flags.LoadPipelineConfigWithOrigins(o.CommonOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the effective pipeline for %s", flags.Dir)
+	}
+	lifecycles := jenkinsfile.EffectivePipeline(pipelineConfig, origins, flags.PipelineKind)
+	return jenkinsfile.EffectivePipelineLines(lifecycles, !o.NoOrigins), nil
+}
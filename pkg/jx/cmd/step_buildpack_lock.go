@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile/gitresolver"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepBuildPackLockLong = templates.LongDesc(`
+		Creates or completes the lock file of a project which records the commit and content hash of the build pack and
+		each module it imports.
+
+		The lock file is written next to the project configuration, such as jenkins-x-lock.yml for jenkins-x.yml, and
+		should be committed. When a project has a lock file its pipelines are generated from the locked commits and the
+		build fails if the content of a build pack or module does not match its locked hash.
+
+		The build pack and modules which are already locked keep their locked commits. Use 'jx step buildpack update' to
+		move them to the latest commits of their git refs.
+`)
+
+	stepBuildPackLockExample = templates.Examples(`
+		# locks the build pack and modules used by the current project
+		jx step buildpack lock
+
+		# verifies the lock file using only the checkouts in a cache directory
+		jx step buildpack lock --buildpack-cache-dir /cache/packs --offline
+`)
+
+	stepBuildPackUpdateLong = templates.LongDesc(`
+		Updates the lock file of a project to the latest commits of the git refs of the build pack and its modules.
+
+		If the names of modules are given only those modules are updated.
+`)
+
+	stepBuildPackUpdateExample = templates.Examples(`
+		# updates the build pack and all the modules to their latest commits
+		jx step buildpack update
+
+		# updates only the 'classic' module
+		jx step buildpack update classic
+`)
+)
+
+// BuildPackLockFlags contains the command line flags used to resolve build packs against a lock file
+type BuildPackLockFlags struct {
+	CacheDir string
+	Offline  bool
+}
+
+// AddFlags adds the flags for resolving build packs against a lock file to the given command
+func (f *BuildPackLockFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.CacheDir, "buildpack-cache-dir", "", "", "The directory containing checkouts of the locked build pack and module commits. Defaults to a directory in ~/.jx/draft")
+	cmd.Flags().BoolVarP(&f.Offline, "offline", "", false, "Resolves locked build packs and modules from the cache directory without cloning them")
+}
+
+// ResolveLockedBuildPack resolves the build pack of a project. If there is a lock file next to the project
+// configuration the build pack and its modules are checked out at their locked commits and verified against their
+// locked hashes.
+func (f *BuildPackLockFlags) ResolveLockedBuildPack(gitter gits.Gitter, projectConfigFile string, packURL string, packRef string) (*gitresolver.ResolvedBuildPack, error) {
+	lockFile := jenkinsfile.LockFileName(projectConfigFile)
+	lock, err := jenkinsfile.LoadBuildPackLock(lockFile)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := gitresolver.ResolveBuildPack(gitter, packURL, packRef, &gitresolver.LockOptions{
+		Lock:     lock,
+		Frozen:   lock != nil,
+		CacheDir: f.CacheDir,
+		Offline:  f.Offline,
+	})
+	if err != nil && lock != nil {
+		return nil, errors.Wrapf(err, "failed to verify the build pack against %s", lockFile)
+	}
+	return resolved, err
+}
+
+// StepBuildPackLockOptions contains the command line flags
+type StepBuildPackLockOptions struct {
+	StepOptions
+	BuildPackLockFlags
+
+	Dir          string
+	Context      string
+	BuildPackURL string
+	BuildPackRef string
+
+	// update is true to update the locked commits
+	update bool
+}
+
+// NewCmdStepBuildPackLock Creates a new Command object
+func NewCmdStepBuildPackLock(commonOpts *opts.CommonOptions) *cobra.Command {
+	return newCmdStepBuildPackLock(commonOpts, false)
+}
+
+// NewCmdStepBuildPackUpdate Creates a new Command object
+func NewCmdStepBuildPackUpdate(commonOpts *opts.CommonOptions) *cobra.Command {
+	return newCmdStepBuildPackLock(commonOpts, true)
+}
+
+func newCmdStepBuildPackLock(commonOpts *opts.CommonOptions, update bool) *cobra.Command {
+	options := &StepBuildPackLockOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+		update: update,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "lock",
+		Short:   "Locks the build pack and modules used by a project to their current commits",
+		Long:    stepBuildPackLockLong,
+		Example: stepBuildPackLockExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	if update {
+		cmd.Use = "update [module]..."
+		cmd.Short = "Updates the lock file of a project to the latest commits of the build pack and modules"
+		cmd.Long = stepBuildPackUpdateLong
+		cmd.Example = stepBuildPackUpdateExample
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory containing the project. Defaults to the current directory")
+	cmd.Flags().StringVarP(&options.Context, "context", "c", "", "The pipeline context if there are multiple separate pipelines for a given branch")
+	cmd.Flags().StringVarP(&options.BuildPackURL, "url", "u", "", "The URL for the build pack Git repository. Defaults to the one in the project or the team settings")
+	cmd.Flags().StringVarP(&options.BuildPackRef, "ref", "r", "", "The Git reference (branch,tag,sha) in the Git repository to use")
+	options.BuildPackLockFlags.AddFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepBuildPackLockOptions) Run() error {
+	var err error
+	if o.Dir == "" {
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+	createTask := &StepCreateTaskOptions{
+		Dir:     o.Dir,
+		Context: o.Context,
+	}
+	projectConfig, projectConfigFile, err := createTask.loadProjectConfig()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load project config in dir %s", o.Dir)
+	}
+	packURL, packRef := o.BuildPackURL, o.BuildPackRef
+	if packURL == "" {
+		packURL = projectConfig.BuildPackGitURL
+	}
+	if packRef == "" {
+		packRef = projectConfig.BuildPackGitURef
+	}
+	if packURL == "" || packRef == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			log.Warnf("failed to load the team settings: %s\n", err)
+			settings = nil
+		}
+		if packURL == "" {
+			packURL = builds.KubernetesWorkloadBuildPackURL
+			if settings != nil && settings.BuildPackURL != "" {
+				packURL = settings.BuildPackURL
+			}
+		}
+		if packRef == "" {
+			packRef = builds.KubernetesWorkloadBuildPackRef
+			if settings != nil && settings.BuildPackRef != "" {
+				packRef = settings.BuildPackRef
+			}
+		}
+	}
+
+	lockFile := jenkinsfile.LockFileName(projectConfigFile)
+	oldLock, err := jenkinsfile.LoadBuildPackLock(lockFile)
+	if err != nil {
+		return err
+	}
+	lock := o.lockToKeep(oldLock)
+	resolved, err := gitresolver.ResolveBuildPack(o.Git(), packURL, packRef, &gitresolver.LockOptions{
+		Lock:     lock,
+		CacheDir: o.CacheDir,
+		Offline:  o.Offline,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve the build pack %s at %s", packURL, packRef)
+	}
+	err = resolved.Lock.SaveConfig(lockFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", lockFile)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("SOURCE", "REF", "COMMIT", "")
+	sources := append([]*jenkinsfile.LockedSource{resolved.Lock.BuildPack}, resolved.Lock.Modules...)
+	for _, source := range sources {
+		name := source.Name
+		if name == "" {
+			name = source.GitURL
+		}
+		table.AddRow(name, source.GitRef, source.Commit, o.describeLockChange(oldLock, source))
+	}
+	table.Render()
+	log.Infof("Saved the lock file %s\n", util.ColorInfo(lockFile))
+	return nil
+}
+
+// lockToKeep returns the part of the existing lock which should keep its locked commits
+func (o *StepBuildPackLockOptions) lockToKeep(lock *jenkinsfile.BuildPackLock) *jenkinsfile.BuildPackLock {
+	if lock == nil || !o.update {
+		return lock
+	}
+	if len(o.Args) == 0 {
+		return nil
+	}
+	answer := &jenkinsfile.BuildPackLock{
+		BuildPack: lock.BuildPack,
+	}
+	for _, module := range lock.Modules {
+		if util.StringArrayIndex(o.Args, module.Name) < 0 {
+			answer.Modules = append(answer.Modules, module)
+		}
+	}
+	return answer
+}
+
+func (o *StepBuildPackLockOptions) describeLockChange(oldLock *jenkinsfile.BuildPackLock, source *jenkinsfile.LockedSource) string {
+	var old *jenkinsfile.LockedSource
+	if oldLock != nil {
+		if source.Name == "" {
+			old = oldLock.BuildPack
+		} else {
+			old = oldLock.FindModule(source.Name)
+		}
+	}
+	switch {
+	case old == nil:
+		return util.ColorInfo("added")
+	case old.Commit != source.Commit:
+		return util.ColorInfo("updated from " + old.String())
+	default:
+		return ""
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/apis/jenkins.io"
@@ -17,10 +18,10 @@ import (
 
 	"github.com/ghodss/yaml"
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/jenkinsfile"
-	"github.com/jenkins-x/jx/pkg/jenkinsfile/gitresolver"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/kube"
@@ -31,9 +32,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -42,6 +45,7 @@ const (
 	kanikoSecretName      = "kaniko-secret"
 	kanikoSecretKey       = "kaniko-secret"
 	defaultContainerImage = "gcr.io/jenkinsxio/builder-maven"
+	defaultQueueTimeout   = 30 * time.Minute
 )
 
 var (
@@ -67,6 +71,7 @@ var (
 // StepCreateTaskOptions contains the command line flags
 type StepCreateTaskOptions struct {
 	StepOptions
+	BuildPackLockFlags
 
 	Pack              string
 	Dir               string
@@ -91,6 +96,7 @@ type StepCreateTaskOptions struct {
 	ViewSteps         bool
 	NoReleasePrepare  bool
 	Duration          time.Duration
+	QueueTimeout      time.Duration
 	FromRepo          bool
 	NoKaniko          bool
 	KanikoImage       string
@@ -108,11 +114,18 @@ type StepCreateTaskOptions struct {
 	GitInfo              *gits.GitRepository
 	BuildNumber          string
 	labels               map[string]string
+	vaultKeyRefs         []syntax.VaultKeySelector
 	envVars              []corev1.EnvVar
 	Results              StepCreateTaskResults
 	version              string
 	previewVersionPrefix string
 	VersionResolver      *opts.VersionResolver
+
+	// ConcurrencyLock if specified is held while checking the concurrency limits and creating the PipelineRun so that
+	// concurrent triggers in the same process do not contend for the cluster wide concurrency lock
+	ConcurrencyLock sync.Locker
+	// QueueInBackground if set returns as soon as a pipeline which has to wait for the concurrency limits is queued
+	QueueInBackground bool
 }
 
 // StepCreateTaskResults stores the generated results
@@ -187,6 +200,8 @@ func (o *StepCreateTaskOptions) AddCommonFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.DockerRegistry, "docker-registry", "", "", "The Docker Registry host name to use which is added as a prefix to docker images")
 	cmd.Flags().StringVarP(&o.DockerRegistryOrg, "docker-registry-org", "", "", "The Docker registry organisation. If blank the git repository owner is used")
 	cmd.Flags().DurationVarP(&o.Duration, "duration", "", time.Second*30, "Retry duration when trying to create a PipelineRun")
+	cmd.Flags().DurationVarP(&o.QueueTimeout, "queue-timeout", "", defaultQueueTimeout, "How long to wait for the concurrency limits of the project to allow the PipelineRun to start")
+	o.BuildPackLockFlags.AddFlags(cmd)
 }
 
 // Run implements this command
@@ -390,15 +405,11 @@ func (o *StepCreateTaskOptions) Run() error {
 	}
 	o.MissingPodTemplates = map[string]bool{}
 
-	packsDir, err := gitresolver.InitBuildPack(o.Git(), o.BuildPackURL, o.BuildPackRef)
-	if err != nil {
-		return err
-	}
-
-	resolver, err := gitresolver.CreateResolver(packsDir, o.Git())
+	resolved, err := o.ResolveLockedBuildPack(o.Git(), projectConfigFile, o.BuildPackURL, o.BuildPackRef)
 	if err != nil {
 		return err
 	}
+	packsDir, resolver := resolved.PacksDir, resolved.Resolver
 
 	if o.Verbose {
 		log.Infof("about to create the tekton CRDs\n")
@@ -438,7 +449,9 @@ func (o *StepCreateTaskOptions) Run() error {
 		}
 	} else {
 		log.Infof("Applying changes ")
-		err := o.applyPipeline(pipeline, tasks, resources, structure, run, o.GitInfo, o.Branch, activityKey)
+		err := o.applyWithinConcurrencyLimits(projectConfig.Concurrency, activityKey, func() error {
+			return o.applyPipeline(pipeline, tasks, resources, structure, run, o.GitInfo, o.Branch, activityKey)
+		})
 		if err != nil {
 			return errors.Wrapf(err, "failed to apply Tekton CRDs")
 		}
@@ -475,6 +488,50 @@ func (o *StepCreateTaskOptions) GeneratePipelineActivity(ns string) *kube.Promot
 
 // GenerateTektonCRDs creates the Pipeline, Task, PipelineResource, PipelineRun, and PipelineStructure CRDs that will be applied to actually kick off the pipeline
 func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfig *config.ProjectConfig, projectConfigFile string, resolver jenkinsfile.ImportFileResolver, ns string) (*pipelineapi.Pipeline, []*pipelineapi.Task, []*pipelineapi.PipelineResource, *pipelineapi.PipelineRun, *v1.PipelineStructure, error) {
+	ctx := context.Background()
+
+	parsed, pipelineConfig, err := o.GenerateParsedPipeline(packsDir, projectConfig, projectConfigFile, resolver, ns)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	o.vaultKeyRefs = parsed.VaultKeyRefs()
+
+	var tasks []*pipelineapi.Task
+	var pipeline *pipelineapi.Pipeline
+	var run *pipelineapi.PipelineRun
+	var resources []*pipelineapi.PipelineResource
+	var structure *v1.PipelineStructure
+
+	pipelineResourceName := tekton.PipelineResourceName(o.GitInfo, o.Branch, o.Context)
+
+	pipeline, tasks, structure, err = parsed.GenerateCRDs(pipelineResourceName, o.BuildNumber, ns, o.PodTemplates, o.GetDefaultTaskInputs().Params, o.SourceName)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrapf(err, "Generation failed for Pipeline")
+	}
+
+	resources = append(resources, o.generateSourceRepoResource(pipelineResourceName))
+	tasks, pipeline = o.EnhanceTasksAndPipeline(tasks, pipeline, pipelineConfig)
+	run = o.CreatePipelineRun(pipeline, resources)
+
+	if validateErr := pipeline.Spec.Validate(ctx); validateErr != nil {
+		return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated Pipeline")
+	}
+	for _, task := range tasks {
+		if validateErr := task.Spec.Validate(ctx); validateErr != nil {
+			data, _ := yaml.Marshal(task)
+			return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated Task: %s %s", task.Name, string(data))
+		}
+	}
+	if validateErr := run.Spec.Validate(ctx); validateErr != nil {
+		return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated PipelineRun")
+	}
+
+	return pipeline, tasks, resources, run, structure, nil
+}
+
+// GenerateParsedPipeline creates the effective ParsedPipeline for the project, after applying build pack inheritance
+// and any overrides in the project configuration, along with the merged PipelineConfig it was generated from
+func (o *StepCreateTaskOptions) GenerateParsedPipeline(packsDir string, projectConfig *config.ProjectConfig, projectConfigFile string, resolver jenkinsfile.ImportFileResolver, ns string) (*syntax.ParsedPipeline, *jenkinsfile.PipelineConfig, error) {
 	name := o.Pack
 	packDir := filepath.Join(packsDir, name)
 
@@ -484,32 +541,32 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 		pipelineFile := filepath.Join(packDir, jenkinsfile.PipelineConfigFileName)
 		exists, err := util.FileExists(pipelineFile)
 		if err != nil {
-			return nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to find build pack pipeline YAML: %s", pipelineFile)
+			return nil, nil, errors.Wrapf(err, "failed to find build pack pipeline YAML: %s", pipelineFile)
 		}
 		if !exists {
-			return nil, nil, nil, nil, nil, fmt.Errorf("no build pack for %s exists at directory %s", name, packDir)
+			return nil, nil, fmt.Errorf("no build pack for %s exists at directory %s", name, packDir)
 		}
 		pipelineConfig, err = jenkinsfile.LoadPipelineConfig(pipelineFile, resolver, true, false)
 		if err != nil {
-			return nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to load build pack pipeline YAML: %s", pipelineFile)
+			return nil, nil, errors.Wrapf(err, "failed to load build pack pipeline YAML: %s", pipelineFile)
 		}
 		localPipelineConfig := projectConfig.PipelineConfig
 		if localPipelineConfig != nil {
 			err = localPipelineConfig.ExtendPipeline(pipelineConfig, false)
 			if err != nil {
-				return nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to override PipelineConfig using configuration in file %s", projectConfigFile)
+				return nil, nil, errors.Wrapf(err, "failed to override PipelineConfig using configuration in file %s", projectConfigFile)
 			}
 			pipelineConfig = localPipelineConfig
 		}
 	}
 
 	if pipelineConfig == nil {
-		return nil, nil, nil, nil, nil, fmt.Errorf("failed to find PipelineConfig in file %s", projectConfigFile)
+		return nil, nil, fmt.Errorf("failed to find PipelineConfig in file %s", projectConfigFile)
 	}
 
 	err := o.combineEnvVars(pipelineConfig)
 	if err != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to combine env vars")
+		return nil, nil, errors.Wrapf(err, "failed to combine env vars")
 	}
 
 	// lets allow a `jenkins-x.yml` to specify we want to disable release prepare mode which can be useful for
@@ -519,7 +576,7 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 	}
 	err = o.setVersionOnReleasePipelines(pipelineConfig)
 	if err != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(err, "failed to set the version on release pipelines")
+		return nil, nil, errors.Wrapf(err, "failed to set the version on release pipelines")
 	}
 
 	var lifecycles *jenkinsfile.PipelineLifecycles
@@ -546,20 +603,12 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 	case jenkinsfile.PipelineKindFeature:
 		lifecycles = pipelines.Feature
 	default:
-		return nil, nil, nil, nil, nil, fmt.Errorf("Unknown pipeline kind %s. Supported values are %s", kind, strings.Join(jenkinsfile.PipelineKinds, ", "))
+		return nil, nil, fmt.Errorf("Unknown pipeline kind %s. Supported values are %s", kind, strings.Join(jenkinsfile.PipelineKinds, ", "))
 	}
 
-	var tasks []*pipelineapi.Task
-	var pipeline *pipelineapi.Pipeline
-	var run *pipelineapi.PipelineRun
-	var resources []*pipelineapi.PipelineResource
-	var structure *v1.PipelineStructure
-
-	pipelineResourceName := tekton.PipelineResourceName(o.GitInfo, o.Branch, o.Context)
-
 	err = o.setBuildValues()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, err
 	}
 
 	var parsed *syntax.ParsedPipeline
@@ -569,7 +618,7 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 	} else {
 		stage, err := o.CreateStageForBuildPack(name, projectConfig, pipelineConfig, lifecycles, kind, ns)
 		if err != nil {
-			return nil, nil, nil, nil, nil, errors.Wrapf(err, "Failed to generate stage from build pack")
+			return nil, nil, errors.Wrapf(err, "Failed to generate stage from build pack")
 		}
 
 		parsed = &syntax.ParsedPipeline{
@@ -601,7 +650,7 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 	if pipelineConfig.ContainerOptions != nil {
 		mergedContainer, err := syntax.MergeContainers(pipelineConfig.ContainerOptions, parsed.Options.ContainerOptions)
 		if err != nil {
-			return nil, nil, nil, nil, nil, errors.Wrapf(err, "Could not merge containerOptions from parent")
+			return nil, nil, errors.Wrapf(err, "Could not merge containerOptions from parent")
 		}
 		parsed.Options.ContainerOptions = mergedContainer
 	}
@@ -609,32 +658,10 @@ func (o *StepCreateTaskOptions) GenerateTektonCRDs(packsDir string, projectConfi
 	// TODO: Seeing weird behavior seemingly related to https://golang.org/doc/faq#nil_error
 	// if err is reused, maybe we need to switch return types (perhaps upstream in build-pipeline)?
 	if validateErr := parsed.Validate(ctx); validateErr != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for Pipeline")
-	}
-
-	pipeline, tasks, structure, err = parsed.GenerateCRDs(pipelineResourceName, o.BuildNumber, ns, o.PodTemplates, o.GetDefaultTaskInputs().Params, o.SourceName)
-	if err != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(err, "Generation failed for Pipeline")
-	}
-
-	resources = append(resources, o.generateSourceRepoResource(pipelineResourceName))
-	tasks, pipeline = o.EnhanceTasksAndPipeline(tasks, pipeline, pipelineConfig)
-	run = o.CreatePipelineRun(pipeline, resources)
-
-	if validateErr := pipeline.Spec.Validate(ctx); validateErr != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated Pipeline")
-	}
-	for _, task := range tasks {
-		if validateErr := task.Spec.Validate(ctx); validateErr != nil {
-			data, _ := yaml.Marshal(task)
-			return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated Task: %s %s", task.Name, string(data))
-		}
-	}
-	if validateErr := run.Spec.Validate(ctx); validateErr != nil {
-		return nil, nil, nil, nil, nil, errors.Wrapf(validateErr, "Validation failed for generated PipelineRun")
+		return nil, nil, errors.Wrapf(validateErr, "Validation failed for Pipeline")
 	}
 
-	return pipeline, tasks, resources, run, structure, nil
+	return parsed, pipelineConfig, nil
 }
 
 func (o *StepCreateTaskOptions) loadProjectConfig() (*config.ProjectConfig, string, error) {
@@ -770,8 +797,9 @@ func (o *StepCreateTaskOptions) CreatePipelineRun(pipeline *pipelineapi.Pipeline
 			Kind:       "PipelineRun",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   pipeline.Name,
-			Labels: util.MergeMaps(o.labels),
+			Name: pipeline.Name,
+			// the build label finds the PipelineActivity of the run as the name of the run may be truncated
+			Labels: util.MergeMaps(o.labels, map[string]string{v1.LabelBuild: o.BuildNumber}),
 		},
 		Spec: pipelineapi.PipelineRunSpec{
 			ServiceAccount: o.ServiceAccount,
@@ -1020,6 +1048,10 @@ func (o *StepCreateTaskOptions) applyPipeline(pipeline *pipelineapi.Pipeline, ta
 	info := util.ColorInfo
 
 	var activityOwnerReference *metav1.OwnerReference
+	annotations := map[string]string{}
+	if o.Revision != "" {
+		annotations[tekton.RevisionAnnotation] = o.Revision
+	}
 
 	if activityKey != nil {
 
@@ -1038,6 +1070,12 @@ func (o *StepCreateTaskOptions) applyPipeline(pipeline *pipelineapi.Pipeline, ta
 			Name:       activity.Name,
 			UID:        activity.UID,
 		}
+		annotations[tekton.ActivityAnnotation] = activity.Name
+	}
+
+	err = o.syncVaultSecrets(ns)
+	if err != nil {
+		return err
 	}
 
 	for _, resource := range resources {
@@ -1083,6 +1121,7 @@ func (o *StepCreateTaskOptions) applyPipeline(pipeline *pipelineapi.Pipeline, ta
 
 	structure.OwnerReferences = []metav1.OwnerReference{pipelineOwnerReference}
 	run.OwnerReferences = []metav1.OwnerReference{pipelineOwnerReference}
+	run.Annotations = util.MergeMaps(run.Annotations, annotations)
 
 	_, err = tekton.CreatePipelineRun(tektonClient, ns, run)
 	if err != nil {
@@ -1115,6 +1154,63 @@ func (o *StepCreateTaskOptions) applyPipeline(pipeline *pipelineapi.Pipeline, ta
 	return nil
 }
 
+// syncVaultSecrets copies the Vault keys referenced by env vars in the pipeline into the Secrets named by
+// syntax.VaultSecretName so that the steps can reference them
+func (o *StepCreateTaskOptions) syncVaultSecrets(ns string) error {
+	if len(o.vaultKeyRefs) == 0 {
+		return nil
+	}
+	kubeClient, _, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	vaultClient, err := o.SystemVaultClient("")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the Vault client to resolve vaultKeyRef env vars")
+	}
+
+	keysByPath := map[string][]string{}
+	var paths []string
+	for _, ref := range o.vaultKeyRefs {
+		if keysByPath[ref.Path] == nil {
+			paths = append(paths, ref.Path)
+		}
+		keysByPath[ref.Path] = append(keysByPath[ref.Path], ref.Key)
+	}
+	for _, path := range paths {
+		data, err := vaultClient.Read(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the secret %s from Vault", path)
+		}
+		name := syntax.VaultSecretName(path)
+		_, err = kube.DefaultModifySecret(kubeClient, ns, name, func(secret *corev1.Secret) error {
+			if secret.Labels == nil {
+				secret.Labels = map[string]string{}
+			}
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Labels[syntax.LabelVaultSecret] = "true"
+			secret.Annotations[syntax.AnnotationVaultPath] = path
+			for _, key := range keysByPath[path] {
+				value, ok := data[key]
+				if !ok {
+					return fmt.Errorf("the secret %s in Vault has no key %s", path, key)
+				}
+				secret.Data[key] = []byte(fmt.Sprintf("%v", value))
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy the Vault secret %s to the Secret %s", path, name)
+		}
+	}
+	return nil
+}
+
 func (o *StepCreateTaskOptions) createSteps(languageName string, projectConfig *config.ProjectConfig, pipelineConfig *jenkinsfile.PipelineConfig, templateKind string, step *syntax.Step, containerName string, dir string, prefixPath string) []syntax.Step {
 	steps := []syntax.Step{}
 
@@ -1773,3 +1869,174 @@ func (r *StepCreateTaskResults) ObjectReferences() []kube.ObjectReference {
 	}
 	return resources
 }
+
+// applyWithinConcurrencyLimits cancels the older pull request pipelines superseded by this revision and then calls fn to
+// start the pipeline once the concurrency limits of the project allow another pipeline to start. While it waits the
+// PipelineActivity is marked as Pending, and it is aborted if a newer pipeline of the branch starts in the meantime.
+// If QueueInBackground is set the wait happens in the background so that the caller is not blocked.
+func (o *StepCreateTaskOptions) applyWithinConcurrencyLimits(concurrency *config.ConcurrencyConfig, activityKey *kube.PromoteStepActivityKey, fn func() error) error {
+	if concurrency == nil {
+		concurrency = &config.ConcurrencyConfig{}
+	}
+	limits := tekton.ConcurrencyLimits{
+		MaxRuns:          concurrency.MaxRuns,
+		MaxRunsPerBranch: concurrency.MaxRunsPerBranch,
+	}
+	if o.PipelineKind == jenkinsfile.PipelineKindRelease && !concurrency.ParallelReleases {
+		limits.MaxRunsPerBranch = 1
+	}
+	cancelSuperseded := o.PipelineKind == jenkinsfile.PipelineKindPullRequest && !concurrency.KeepSuperseded && o.Revision != ""
+	if limits.IsUnlimited() && !cancelSuperseded {
+		return fn()
+	}
+
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	tektonClient, _, err := o.TektonClient()
+	if err != nil {
+		return err
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	owner := o.GitInfo.Organisation
+	repo := o.GitInfo.Name
+	start := func() (string, error) {
+		return o.startIfAllowed(kubeClient, tektonClient, jxClient, ns, owner, repo, limits, cancelSuperseded, fn)
+	}
+	reason, err := start()
+	if err != nil || reason == "" {
+		return o.abortIfSuperseded(jxClient, ns, activityKey, err)
+	}
+
+	log.Infof("Queueing the pipeline for %s/%s branch %s as %s\n", owner, repo, o.Branch, reason)
+	err = o.updateActivityStatus(jxClient, ns, activityKey, v1.ActivityStatusTypePending)
+	if err != nil {
+		return err
+	}
+	if o.QueueInBackground {
+		go func() {
+			err := o.waitToStart(jxClient, ns, activityKey, start)
+			if err != nil {
+				log.Errorf("%s\n", err)
+			}
+		}()
+		return nil
+	}
+	return o.waitToStart(jxClient, ns, activityKey, start)
+}
+
+// waitToStart retries start until it starts the pipeline or the queue timeout expires, in which case the queued
+// PipelineActivity is aborted
+func (o *StepCreateTaskOptions) waitToStart(jxClient versioned.Interface, ns string, activityKey *kube.PromoteStepActivityKey, start func() (string, error)) error {
+	timeout := o.QueueTimeout
+	if timeout <= 0 {
+		timeout = defaultQueueTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		time.Sleep(5 * time.Second)
+		reason, err := start()
+		if err != nil || reason == "" {
+			return o.abortIfSuperseded(jxClient, ns, activityKey, err)
+		}
+		if time.Now().After(deadline) {
+			err = o.updateActivityStatus(jxClient, ns, activityKey, v1.ActivityStatusTypeAborted)
+			if err != nil {
+				log.Warnf("%s\n", err)
+			}
+			return fmt.Errorf("gave up waiting after %s to start the pipeline for %s/%s branch %s as %s", timeout.String(), o.GitInfo.Organisation, o.GitInfo.Name, o.Branch, reason)
+		}
+	}
+}
+
+// supersededError is returned when a pipeline is not started as a newer pipeline of the branch has already started
+type supersededError struct {
+	run string
+}
+
+func (e *supersededError) Error() string {
+	return fmt.Sprintf("the pipeline is superseded by PipelineRun %s", e.run)
+}
+
+// abortIfSuperseded marks the PipelineActivity as aborted if err is a supersededError, as the pipeline does not need to
+// run, otherwise it returns err
+func (o *StepCreateTaskOptions) abortIfSuperseded(jxClient versioned.Interface, ns string, activityKey *kube.PromoteStepActivityKey, err error) error {
+	superseded, ok := err.(*supersededError)
+	if !ok {
+		return err
+	}
+	log.Infof("Not starting the pipeline for %s/%s branch %s as it is superseded by the newer PipelineRun %s\n", o.GitInfo.Organisation, o.GitInfo.Name, o.Branch, util.ColorInfo(superseded.run))
+	return o.updateActivityStatus(jxClient, ns, activityKey, v1.ActivityStatusTypeAborted)
+}
+
+// updateActivityStatus sets the status of the PipelineActivity, creating it if it does not exist yet
+func (o *StepCreateTaskOptions) updateActivityStatus(jxClient versioned.Interface, ns string, activityKey *kube.PromoteStepActivityKey, status v1.ActivityStatusType) error {
+	if activityKey == nil {
+		return nil
+	}
+	activity, _, err := activityKey.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get PipelineActivity %s", activityKey.Name)
+	}
+	activity.Spec.Status = status
+	if status.IsTerminated() && activity.Spec.CompletedTimestamp == nil {
+		now := metav1.Now()
+		activity.Spec.CompletedTimestamp = &now
+	}
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to mark PipelineActivity %s as %s", activity.Name, string(status))
+	}
+	return nil
+}
+
+// startIfAllowed calls fn if the concurrency limits allow another pipeline to start otherwise it returns the reason
+// why the pipeline has to wait. The limits are checked while holding the cluster wide concurrency lock of the
+// repository so that the replicas of the pipeline runner cannot exceed them between them. If superseded pipelines are
+// cancelled and a newer pipeline of the branch has already started a supersededError is returned instead.
+func (o *StepCreateTaskOptions) startIfAllowed(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, owner string, repo string, limits tekton.ConcurrencyLimits, cancelSuperseded bool, fn func() error) (string, error) {
+	if o.ConcurrencyLock != nil {
+		o.ConcurrencyLock.Lock()
+		defer o.ConcurrencyLock.Unlock()
+	}
+	holder, _ := os.Hostname()
+	release, err := tekton.LockConcurrency(kubeClient, ns, owner, repo, holder)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	active, err := tekton.ActivePipelineRuns(tektonClient, ns, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	if cancelSuperseded {
+		newer := tekton.NewerPipelineRun(active, o.Branch, o.Context, o.Revision, o.BuildNumber)
+		if newer != nil {
+			return "", &supersededError{run: newer.Name}
+		}
+		superseded := tekton.SupersededPipelineRuns(active, o.Branch, o.Context, o.Revision, o.BuildNumber)
+		for _, run := range superseded {
+			err = tekton.CancelPipelineRun(tektonClient, jxClient, ns, run.Name)
+			if err != nil {
+				return "", err
+			}
+			log.Infof("Cancelled PipelineRun %s as it is superseded by revision %s\n", util.ColorInfo(run.Name), util.ColorInfo(o.Revision))
+		}
+		if len(superseded) > 0 {
+			active, err = tekton.ActivePipelineRuns(tektonClient, ns, owner, repo)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	reason := tekton.ConcurrencyLimitReached(active, o.Branch, limits)
+	if reason != "" {
+		return reason, nil
+	}
+	return "", fn()
+}
@@ -41,6 +41,8 @@ func NewCmdStepGit(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepGitEnvs(commonOpts))
 	cmd.AddCommand(NewCmdStepGitMerge(commonOpts))
 	cmd.AddCommand(NewCmdStepGitForkAndClone(commonOpts))
+	cmd.AddCommand(NewCmdStepGitProtect(commonOpts))
+	cmd.AddCommand(NewCmdStepGitCampaign(commonOpts))
 	return cmd
 }
 
@@ -0,0 +1,460 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CampaignResultCreated is the result of a campaign which opened a Pull Request
+	CampaignResultCreated = "created"
+	// CampaignResultUpdated is the result of a campaign which pushed a change to its existing Pull Request
+	CampaignResultUpdated = "updated"
+	// CampaignResultUnchanged is the result of a campaign whose script changed nothing
+	CampaignResultUnchanged = "unchanged"
+	// CampaignResultStale is the result of a campaign whose script changed nothing while its Pull Request is still open
+	CampaignResultStale = "stale"
+	// CampaignResultChanged is the result of a dry run whose script changed the repository
+	CampaignResultChanged = "changed"
+	// CampaignResultFailed is the result of a campaign which could not be applied to a repository
+	CampaignResultFailed = "failed"
+)
+
+// StepGitCampaignOptions contains the command line flags
+type StepGitCampaignOptions struct {
+	StepOptions
+	Name       string
+	Groups     []string
+	Selector   string
+	Includes   []string
+	Excludes   []string
+	Script     string
+	Title      string
+	Message    string
+	Base       string
+	Dir        string
+	ReportFile string
+	DryRun     bool
+}
+
+// CampaignResult is the outcome of a campaign for a repository
+type CampaignResult struct {
+	Repository  string `json:"repository"`
+	Result      string `json:"result"`
+	PullRequest string `json:"pullRequest,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+var (
+	stepGitCampaignLong = templates.LongDesc(`
+		Applies a scripted change to many repositories, opening a Pull Request on each repository which it changes.
+
+		The repositories are the SourceRepositories of the given SourceRepositoryGroups, those matching the label selector
+		and those whose 'owner/name' matches the filters. Each repository is cloned and the script is run in the clone
+		with the $CAMPAIGN_NAME, $REPO_OWNER and $REPO_NAME environment variables.
+
+		Changes are pushed to the branch 'campaign-$CAMPAIGN_NAME' of the repository so the git user needs push access.
+		Running the campaign again applies the script to the latest base branch and, if the result differs from the
+		branch of an open Pull Request of the campaign, force pushes it to rebase that Pull Request rather than opening
+		another one. If the script no longer changes a repository, e.g. because the change was made on the base branch,
+		its open Pull Request is reported as stale so that it can be closed. The checkouts in --dir are fetched and reset so a campaign can be run again with the same --dir.
+
+		The result of the campaign and the status of the Pull Request of each repository are reported in a table and
+		optionally written to a YAML file.
+`)
+	stepGitCampaignExample = templates.Examples(`
+		# update the base image of the repositories of a SourceRepositoryGroup
+		jx step git campaign --name java-11 --group myapps --script "sed -i 's/jdk8/jdk11/' Dockerfile"
+
+		# report the repositories labelled as go services which a script would change without opening Pull Requests
+		jx step git campaign --name go-1.12 --selector language=go --script ./upgrade-go.sh --dry-run
+
+		# update a shared config across the repositories of an organisation writing a report of the Pull Requests
+		jx step git campaign --name lint-config --filter "myorg/*" --script "cp /config/.golangci.yml ." --report-file campaign.yaml
+`)
+)
+
+// NewCmdStepGitCampaign creates the command
+func NewCmdStepGitCampaign(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepGitCampaignOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "campaign",
+		Short:   "Applies a scripted change to many repositories via Pull Requests",
+		Long:    stepGitCampaignLong,
+		Example: stepGitCampaignExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the campaign which is used to name its branches")
+	cmd.Flags().StringArrayVarP(&options.Groups, "group", "g", nil, "The SourceRepositoryGroups whose repositories are changed")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "The label selector of the SourceRepositories to change")
+	cmd.Flags().StringArrayVarP(&options.Includes, "filter", "f", nil, "The 'owner/name' patterns of the repositories to change - such as 'myorg/*'")
+	cmd.Flags().StringArrayVarP(&options.Excludes, "excludes", "x", nil, "The 'owner/name' patterns of the repositories to exclude")
+	cmd.Flags().StringVarP(&options.Script, "script", "s", "", "The shell script which changes a clone of each repository")
+	cmd.Flags().StringVarP(&options.Title, "title", "t", "", "The title of the commits and Pull Requests. Defaults to the name of the campaign")
+	cmd.Flags().StringVarP(&options.Message, "message", "m", "", "The body of the Pull Requests. Defaults to the title")
+	cmd.Flags().StringVarP(&options.Base, "base", "", "master", "The branch the Pull Requests are merged into")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory the repositories are cloned into. Defaults to a temporary directory which is removed afterwards")
+	cmd.Flags().StringVarP(&options.ReportFile, "report-file", "", "", "The YAML file the results of the campaign are written to")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Only reports which repositories the script changes without pushing the changes")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepGitCampaignOptions) Run() error {
+	if o.Name == "" {
+		return util.MissingOption("name")
+	}
+	if o.Script == "" {
+		return util.MissingOption("script")
+	}
+	if len(o.Groups) == 0 && o.Selector == "" && len(o.Includes) == 0 {
+		return fmt.Errorf("no repositories selected, use --group, --selector or --filter to select the repositories of the campaign")
+	}
+	if o.Title == "" {
+		o.Title = fmt.Sprintf("Campaign %s", o.Name)
+	}
+	if o.Message == "" {
+		o.Message = o.Title
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	sourceRepos, err := o.campaignRepositories(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	if len(sourceRepos) == 0 {
+		log.Warnf("No SourceRepositories match the campaign %s\n", o.Name)
+		return nil
+	}
+
+	baseDir := o.Dir
+	if baseDir == "" {
+		baseDir, err = ioutil.TempDir("", "campaign-")
+		if err != nil {
+			return errors.Wrap(err, "creating a temporary directory to clone the repositories into")
+		}
+		defer os.RemoveAll(baseDir)
+	}
+	branch := o.Git().ConvertToValidBranchName("campaign-" + o.Name)
+
+	results := []*CampaignResult{}
+	failed := 0
+	stale := []string{}
+	for _, sourceRepo := range sourceRepos {
+		result := &CampaignResult{
+			Repository: sourceRepo.Spec.Org + "/" + sourceRepo.Spec.Repo,
+		}
+		err = o.applyCampaign(&sourceRepo, branch, baseDir, result)
+		if err != nil {
+			log.Warnf("Failed to apply the campaign %s to %s: %s\n", o.Name, result.Repository, err)
+			result.Result = CampaignResultFailed
+			result.Error = err.Error()
+			failed++
+		}
+		if result.Result == CampaignResultStale {
+			stale = append(stale, result.PullRequest)
+		}
+		results = append(results, result)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("REPOSITORY", "RESULT", "PULL REQUEST", "STATUS")
+	for _, result := range results {
+		table.AddRow(result.Repository, result.Result, result.PullRequest, result.Status)
+	}
+	table.Render()
+	if len(stale) > 0 {
+		log.Warnf("The script of the campaign %s no longer changes the repositories of these open Pull Requests which can be closed: %s\n", o.Name, strings.Join(stale, ", "))
+	}
+
+	if o.ReportFile != "" {
+		data, err := yaml.Marshal(results)
+		if err != nil {
+			return errors.Wrap(err, "marshalling the results of the campaign")
+		}
+		err = ioutil.WriteFile(o.ReportFile, data, util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "writing the results of the campaign to %s", o.ReportFile)
+		}
+		log.Infof("Wrote the results of the campaign to %s\n", util.ColorInfo(o.ReportFile))
+	}
+	if failed > 0 {
+		return fmt.Errorf("the campaign %s failed for %d of %d repositories", o.Name, failed, len(results))
+	}
+	return nil
+}
+
+// campaignRepositories returns the SourceRepositories selected by the groups, label selector and filters sorted by name
+func (o *StepGitCampaignOptions) campaignRepositories(jxClient versioned.Interface, ns string) ([]v1.SourceRepository, error) {
+	selected := map[string]v1.SourceRepository{}
+	for _, name := range o.Groups {
+		group, err := jxClient.JenkinsV1().SourceRepositoryGroups(ns).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the SourceRepositoryGroup %s", name)
+		}
+		for _, ref := range group.Spec.SourceRepositorySpec {
+			sourceRepo, err := jxClient.JenkinsV1().SourceRepositories(ns).Get(ref.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting the SourceRepository %s of the SourceRepositoryGroup %s", ref.Name, name)
+			}
+			selected[sourceRepo.Name] = *sourceRepo
+		}
+	}
+	if o.Selector != "" || len(o.Groups) == 0 {
+		sourceRepos, err := jxClient.JenkinsV1().SourceRepositories(ns).List(metav1.ListOptions{
+			LabelSelector: o.Selector,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the SourceRepositories matching %q", o.Selector)
+		}
+		for _, sourceRepo := range sourceRepos.Items {
+			selected[sourceRepo.Name] = sourceRepo
+		}
+	}
+
+	answer := []v1.SourceRepository{}
+	for _, sourceRepo := range selected {
+		if util.StringMatchesAny(sourceRepo.Spec.Org+"/"+sourceRepo.Spec.Repo, o.Includes, o.Excludes) {
+			answer = append(answer, sourceRepo)
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer, nil
+}
+
+// applyCampaign runs the script in a clone of the repository, pushing any change to the branch of the campaign and
+// opening a Pull Request unless the campaign already has one
+func (o *StepGitCampaignOptions) applyCampaign(sourceRepo *v1.SourceRepository, branch string, baseDir string, result *CampaignResult) (err error) {
+	owner := sourceRepo.Spec.Org
+	repoName := sourceRepo.Spec.Repo
+	serverURL := sourceRepo.Spec.Provider
+	if serverURL == "" {
+		serverURL = gits.GitHubURL
+	}
+	provider, err := o.GitProviderForURL(util.UrlJoin(serverURL, owner, repoName), "user name to push the changes of the campaign")
+	if err != nil {
+		return errors.Wrap(err, "creating the git provider")
+	}
+	repo, err := provider.GetRepository(owner, repoName)
+	if err != nil {
+		return errors.Wrap(err, "getting the repository")
+	}
+	pr, err := findCampaignPullRequest(provider, owner, repoName, branch)
+	if err != nil {
+		return err
+	}
+
+	gitter := o.Git()
+	dir := filepath.Join(baseDir, owner, repoName)
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating the directory %s", dir)
+	}
+	userAuth := provider.UserAuth()
+	// git commands include the push URL in their errors so lets make sure the token does not end up in the report
+	defer func() {
+		if err != nil && userAuth.ApiToken != "" {
+			err = errors.New(strings.Replace(err.Error(), userAuth.ApiToken, "*****", -1))
+		}
+	}()
+	cloneURL, err := gitter.CreatePushURL(repo.CloneURL, &userAuth)
+	if err != nil {
+		return errors.Wrapf(err, "creating the push URL of %s", repo.CloneURL)
+	}
+	exists, err := util.DirExists(filepath.Join(dir, ".git"))
+	if err != nil {
+		return err
+	}
+	if exists {
+		// lets reuse the checkout of a previous run of the campaign
+		err = gitter.SetRemoteURL(dir, "origin", cloneURL)
+		if err != nil {
+			return errors.Wrapf(err, "setting the remote of %s", dir)
+		}
+		err = gitter.FetchBranch(dir, "origin")
+		if err != nil {
+			return errors.Wrapf(err, "fetching %s", repo.CloneURL)
+		}
+	} else {
+		err = gitter.Clone(cloneURL, dir)
+		if err != nil {
+			return errors.Wrapf(err, "cloning %s", repo.CloneURL)
+		}
+	}
+	err = gitter.Checkout(dir, o.Base)
+	if err != nil {
+		return errors.Wrapf(err, "checking out the branch %s", o.Base)
+	}
+	err = gitter.ResetHard(dir, "origin/"+o.Base)
+	if err != nil {
+		return errors.Wrapf(err, "resetting to the branch %s", o.Base)
+	}
+	err = gitter.CleanForce(dir, ".")
+	if err != nil {
+		return errors.Wrapf(err, "cleaning %s", dir)
+	}
+
+	// lets always build the branch of the campaign from the latest base branch so that the script is applied to the
+	// current code and any Pull Request of the campaign is rebased
+	localBranches, err := gitter.LocalBranches(dir)
+	if err != nil {
+		return err
+	}
+	if util.StringArrayIndex(localBranches, branch) < 0 {
+		err = gitter.CreateBranch(dir, branch)
+		if err != nil {
+			return errors.Wrapf(err, "creating the branch %s", branch)
+		}
+	}
+	err = gitter.Checkout(dir, branch)
+	if err != nil {
+		return errors.Wrapf(err, "checking out the branch %s", branch)
+	}
+	err = gitter.ResetHard(dir, "origin/"+o.Base)
+	if err != nil {
+		return errors.Wrapf(err, "resetting the branch %s to %s", branch, o.Base)
+	}
+	if pr != nil {
+		result.PullRequest = pr.URL
+	}
+
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "sh",
+		Args: []string{"-c", o.Script},
+		Env: map[string]string{
+			"CAMPAIGN_NAME": o.Name,
+			"REPO_OWNER":    owner,
+			"REPO_NAME":     repoName,
+		},
+	}
+	output, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "running the script of the campaign: %s", output)
+	}
+	if o.Verbose && output != "" {
+		log.Infof("%s\n", output)
+	}
+	err = gitter.Add(dir, "-A")
+	if err != nil {
+		return err
+	}
+	scriptChanged, err := gitter.HasChanges(dir)
+	if err != nil {
+		return err
+	}
+	changed := scriptChanged
+	if changed && pr != nil {
+		// the Pull Request only needs updating if the rebased change differs from its branch
+		diff, err := gitter.ListChangedFilesFromBranch(dir, "origin/"+branch)
+		if err != nil {
+			return errors.Wrapf(err, "comparing with the branch %s of the Pull Request %s", branch, pr.URL)
+		}
+		changed = strings.TrimSpace(diff) != ""
+	}
+
+	switch {
+	case !scriptChanged && pr != nil:
+		// the Pull Request would no longer change the base branch so lets not leave it open silently
+		result.Result = CampaignResultStale
+	case !changed:
+		result.Result = CampaignResultUnchanged
+	case o.DryRun:
+		result.Result = CampaignResultChanged
+	default:
+		err = gitter.CommitDir(dir, o.Title)
+		if err != nil {
+			return err
+		}
+		if pr != nil {
+			err = gitter.ForcePushBranch(dir, branch, branch)
+			if err != nil {
+				return errors.Wrapf(err, "pushing to the branch %s of the Pull Request %s", branch, pr.URL)
+			}
+			result.Result = CampaignResultUpdated
+			log.Infof("Updated the Pull Request %s\n", util.ColorInfo(pr.URL))
+		} else {
+			// lets replace the branch of any closed Pull Request of the campaign
+			err = gitter.ForcePushBranch(dir, branch, branch)
+			if err != nil {
+				return errors.Wrapf(err, "pushing the branch %s", branch)
+			}
+			pr, err = provider.CreatePullRequest(&gits.GitPullRequestArguments{
+				GitRepository: repo,
+				Title:         o.Title,
+				Body:          o.Message,
+				Base:          o.Base,
+				Head:          branch,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "creating a Pull Request from the branch %s", branch)
+			}
+			result.Result = CampaignResultCreated
+			result.PullRequest = pr.URL
+			log.Infof("Created the Pull Request %s\n", util.ColorInfo(pr.URL))
+		}
+	}
+	if pr != nil {
+		status, err := provider.PullRequestLastCommitStatus(pr)
+		if err != nil {
+			log.Warnf("Failed to get the status of the Pull Request %s: %s\n", pr.URL, err)
+		}
+		result.Status = status
+	}
+	return nil
+}
+
+// findCampaignPullRequest returns the open Pull Request from the branch of the campaign or nil if there is none
+func findCampaignPullRequest(provider gits.GitProvider, owner string, repo string, branch string) (*gits.GitPullRequest, error) {
+	prs, err := provider.ListOpenPullRequests(owner, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the open Pull Requests of %s/%s", owner, repo)
+	}
+	for _, pr := range prs {
+		if pr.HeadRef == nil {
+			continue
+		}
+		head := *pr.HeadRef
+		paths := strings.SplitN(head, ":", 2)
+		if len(paths) > 1 {
+			// the Pull Request is from a fork
+			continue
+		}
+		if head == branch {
+			return pr, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,170 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	helm_test "github.com/jenkins-x/jx/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/pkg/jx/cmd"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	resources_test "github.com/jenkins-x/jx/pkg/kube/resources/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func campaignSourceRepository(repo string, labels map[string]string) *v1.SourceRepository {
+	return &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-" + repo,
+			Namespace: "jx",
+			Labels:    labels,
+		},
+		Spec: v1.SourceRepositorySpec{
+			Org:      "myorg",
+			Repo:     repo,
+			Provider: gits.GitHubURL,
+		},
+	}
+}
+
+func stepGitCampaignOptions(t *testing.T, provider *gits.FakeProvider, gitter *gits.GitFake) *cmd.StepGitCampaignOptions {
+	group := &v1.SourceRepositoryGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapps",
+			Namespace: "jx",
+		},
+		Spec: v1.SourceRepositoryGroupSpec{
+			SourceRepositorySpec: []v1.ResourceReference{{Name: "myorg-app1"}},
+		},
+	}
+	dir, err := ioutil.TempDir("", "test-step-git-campaign-")
+	require.NoError(t, err)
+
+	options := &cmd.StepGitCampaignOptions{
+		StepOptions: cmd.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		Name:       "java-11",
+		Groups:     []string{"myapps"},
+		Selector:   "language=go",
+		Script:     "echo $REPO_NAME > campaign.txt",
+		Base:       "master",
+		Dir:        dir,
+		ReportFile: filepath.Join(dir, "report.yaml"),
+	}
+	cmd.ConfigureTestOptionsWithResources(options.CommonOptions,
+		[]runtime.Object{},
+		[]runtime.Object{
+			group,
+			campaignSourceRepository("app1", nil),
+			campaignSourceRepository("app2", map[string]string{"language": "go"}),
+			campaignSourceRepository("app3", map[string]string{"language": "java"}),
+		},
+		gitter,
+		provider,
+		helm_test.NewMockHelmer(),
+		resources_test.NewMockInstaller(),
+	)
+	return options
+}
+
+func campaignResults(t *testing.T, options *cmd.StepGitCampaignOptions) []*cmd.CampaignResult {
+	data, err := ioutil.ReadFile(options.ReportFile)
+	require.NoError(t, err)
+	results := []*cmd.CampaignResult{}
+	err = yaml.Unmarshal(data, &results)
+	require.NoError(t, err)
+	return results
+}
+
+func TestStepGitCampaign(t *testing.T) {
+	provider := gits.NewFakeProvider(
+		gits.NewFakeRepository("myorg", "app1"),
+		gits.NewFakeRepository("myorg", "app2"),
+		gits.NewFakeRepository("myorg", "app3"),
+	)
+	gitter := &gits.GitFake{Changes: true}
+	options := stepGitCampaignOptions(t, provider, gitter)
+	defer os.RemoveAll(options.Dir)
+
+	err := options.Run()
+	require.NoError(t, err)
+	results := campaignResults(t, options)
+	require.Len(t, results, 2)
+	assert.Equal(t, "myorg/app1", results[0].Repository)
+	assert.Equal(t, cmd.CampaignResultCreated, results[0].Result)
+	assert.Equal(t, "https://fake.git/myorg/app1/pulls/1", results[0].PullRequest)
+	assert.Equal(t, string(gits.CommitStatusPending), results[0].Status)
+	assert.Equal(t, "myorg/app2", results[1].Repository)
+	assert.Equal(t, cmd.CampaignResultCreated, results[1].Result)
+
+	data, err := ioutil.ReadFile(filepath.Join(options.Dir, "myorg", "app2", "campaign.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "app2\n", string(data))
+
+	// running the campaign again rebuilds the branches from the base branch and leaves the pull requests alone
+	err = options.Run()
+	require.NoError(t, err)
+	results = campaignResults(t, options)
+	require.Len(t, results, 2)
+	assert.Equal(t, cmd.CampaignResultUnchanged, results[0].Result)
+	assert.Equal(t, "https://fake.git/myorg/app1/pulls/1", results[0].PullRequest)
+
+	// unless the rebuilt branch differs from the branch of the pull request
+	gitter.ChangedFilesFromBranch = "M\tcampaign.txt"
+	err = options.Run()
+	require.NoError(t, err)
+	results = campaignResults(t, options)
+	require.Len(t, results, 2)
+	assert.Equal(t, cmd.CampaignResultUpdated, results[0].Result)
+	assert.Equal(t, "https://fake.git/myorg/app1/pulls/1", results[0].PullRequest)
+	prs, err := provider.ListOpenPullRequests("myorg", "app1")
+	require.NoError(t, err)
+	assert.Len(t, prs, 1)
+
+	// the open pull requests are reported as stale once the script no longer changes their repositories
+	gitter.Changes = false
+	err = options.Run()
+	require.NoError(t, err)
+	results = campaignResults(t, options)
+	require.Len(t, results, 2)
+	assert.Equal(t, cmd.CampaignResultStale, results[1].Result)
+	assert.Equal(t, "https://fake.git/myorg/app2/pulls/1", results[1].PullRequest)
+	prs, err = provider.ListOpenPullRequests("myorg", "app2")
+	require.NoError(t, err)
+	assert.Len(t, prs, 1)
+}
+
+func TestStepGitCampaignDryRun(t *testing.T) {
+	provider := gits.NewFakeProvider(
+		gits.NewFakeRepository("myorg", "app1"),
+		gits.NewFakeRepository("myorg", "app2"),
+		gits.NewFakeRepository("myorg", "app3"),
+	)
+	options := stepGitCampaignOptions(t, provider, &gits.GitFake{Changes: true})
+	defer os.RemoveAll(options.Dir)
+	options.Groups = nil
+	options.Selector = ""
+	options.Includes = []string{"myorg/*"}
+	options.Excludes = []string{"myorg/app3"}
+	options.DryRun = true
+
+	err := options.Run()
+	require.NoError(t, err)
+	results := campaignResults(t, options)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, cmd.CampaignResultChanged, result.Result)
+		assert.Empty(t, result.PullRequest)
+	}
+	prs, err := provider.ListOpenPullRequests("myorg", "app1")
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepGitProtectOptions contains the command line flags
+type StepGitProtectOptions struct {
+	StepOptions
+	Dir      string
+	Owner    string
+	Repo     string
+	Branches []string
+	All      bool
+	DryRun   bool
+}
+
+// branchProtectionDrift is the drift of the protection of a branch from the protection its Scheduler requires
+type branchProtectionDrift struct {
+	provider    gits.GitProvider
+	owner       string
+	repo        string
+	branch      string
+	expected    *gits.GitBranchProtection
+	unsupported []string
+	differences []string
+}
+
+var (
+	stepGitProtectLong = templates.LongDesc(`
+		Applies the branch protection policy of the effective Scheduler of repositories directly to their git provider.
+
+		The protection of each branch is compared with the policy and any drift is reported before the policy is applied.
+		Branches for which the policy does not set protect, and which do not protect tested contexts, are left as they are.
+		Settings which the git provider cannot enforce are reported as warnings.
+`)
+	stepGitProtectExample = templates.Examples(`
+		# report and fix the drift of the protection of the master branch of the current repository
+		jx step git protect
+
+		# report the drift of the protection of some branches of a repository without changing them
+		jx step git protect --owner myorg --repo myrepo --branch master --branch release --dry-run
+
+		# protect the master branch of every SourceRepository
+		jx step git protect --all
+`)
+)
+
+// NewCmdStepGitProtect creates the command
+func NewCmdStepGitProtect(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepGitProtectOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "protect",
+		Short:   "Applies the branch protection policy of the Scheduler of repositories to their git provider",
+		Long:    stepGitProtectLong,
+		Example: stepGitProtectExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory used to find the git repository if no owner and repository are specified")
+	cmd.Flags().StringVarP(&options.Owner, "owner", "o", "", "The git owner or organisation of the repository")
+	cmd.Flags().StringVarP(&options.Repo, "repo", "r", "", "The name of the git repository")
+	cmd.Flags().StringArrayVarP(&options.Branches, "branch", "b", []string{"master"}, "The branches to protect")
+	cmd.Flags().BoolVarP(&options.All, "all", "", false, "Protects the branches of every SourceRepository which has a Scheduler")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Only reports the drift of the protection of the branches without changing them")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepGitProtectOptions) Run() error {
+	var gitInfo *gits.GitRepository
+	if !o.All && (o.Owner == "" || o.Repo == "") {
+		var err error
+		gitInfo, err = o.FindGitInfo(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "finding the git repository, use --owner and --repo or --all to specify the repositories")
+		}
+		if o.Owner == "" {
+			o.Owner = gitInfo.Organisation
+		}
+		if o.Repo == "" {
+			o.Repo = gitInfo.Name
+		}
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return err
+	}
+	leaves, err := pipelinescheduler.EffectiveSchedulers(jxClient, ns, teamSettings.DefaultScheduler.Name)
+	if err != nil {
+		return errors.Wrapf(err, "finding the schedulers of the source repositories")
+	}
+	if !o.All {
+		var matched []*pipelinescheduler.SchedulerLeaf
+		for _, leaf := range leaves {
+			if strings.EqualFold(leaf.Org, o.Owner) && strings.EqualFold(leaf.Repo, o.Repo) {
+				matched = append(matched, leaf)
+			}
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("no Scheduler applies to %s/%s", o.Owner, o.Repo)
+		}
+		leaves = matched
+	}
+
+	drifts := []*branchProtectionDrift{}
+	for _, leaf := range leaves {
+		serverURL := leaf.Provider
+		if serverURL == "" && gitInfo != nil {
+			serverURL = gitInfo.HostURL()
+		}
+		if serverURL == "" {
+			serverURL = gits.GitHubURL
+		}
+		provider, err := o.GitProviderForURL(util.UrlJoin(serverURL, leaf.Org, leaf.Repo), "user name to protect the branches")
+		if err != nil {
+			return errors.Wrapf(err, "creating the git provider of %s/%s", leaf.Org, leaf.Repo)
+		}
+		for _, branch := range o.Branches {
+			drift, err := o.branchProtectionDrift(provider, leaf, branch)
+			if err != nil {
+				return err
+			}
+			if drift != nil {
+				drifts = append(drifts, drift)
+			}
+		}
+	}
+
+	table := o.CreateTable()
+	table.AddRow("REPOSITORY", "BRANCH", "STATUS", "DRIFT")
+	for _, drift := range drifts {
+		status := util.ColorInfo("in sync")
+		if len(drift.differences) > 0 {
+			status = util.ColorWarning("drifted")
+		}
+		table.AddRow(drift.owner+"/"+drift.repo, drift.branch, status, strings.Join(drift.differences, "; "))
+	}
+	table.Render()
+
+	for _, drift := range drifts {
+		if len(drift.unsupported) > 0 {
+			log.Warnf("%s of %s/%s cannot enforce %s\n", drift.provider.Kind(), drift.owner, drift.repo, strings.Join(drift.unsupported, ", "))
+		}
+	}
+	if o.DryRun {
+		return nil
+	}
+	for _, drift := range drifts {
+		if len(drift.differences) == 0 {
+			continue
+		}
+		if drift.expected == nil {
+			err = drift.provider.RemoveBranchProtection(drift.owner, drift.repo, drift.branch)
+		} else {
+			err = drift.provider.SetBranchProtection(drift.owner, drift.repo, drift.branch, drift.expected)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "updating the protection of the branch %s of %s/%s", drift.branch, drift.owner, drift.repo)
+		}
+		log.Infof("Updated the protection of the branch %s of %s\n", util.ColorInfo(drift.branch), util.ColorInfo(drift.owner+"/"+drift.repo))
+	}
+	return nil
+}
+
+// branchProtectionDrift compares the protection of a branch with the protection its Scheduler requires, returning
+// nil if the Scheduler does not manage the protection of the branch
+func (o *StepGitProtectOptions) branchProtectionDrift(provider gits.GitProvider, leaf *pipelinescheduler.SchedulerLeaf, branch string) (*branchProtectionDrift, error) {
+	expected, managed, err := pipelinescheduler.BranchProtection(leaf.SchedulerSpec, branch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding the protection policy of the branch %s of %s/%s", branch, leaf.Org, leaf.Repo)
+	}
+	if !managed {
+		log.Infof("The Scheduler of %s/%s does not manage the protection of the branch %s\n", leaf.Org, leaf.Repo, branch)
+		return nil, nil
+	}
+	drift := &branchProtectionDrift{
+		provider: provider,
+		owner:    leaf.Org,
+		repo:     leaf.Repo,
+		branch:   branch,
+	}
+	drift.expected, drift.unsupported = gits.SupportedBranchProtection(provider.Kind(), expected)
+	actual, err := provider.GetBranchProtection(leaf.Org, leaf.Repo, branch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the protection of the branch %s of %s/%s", branch, leaf.Org, leaf.Repo)
+	}
+	drift.differences = gits.DiffBranchProtection(drift.expected, actual)
+	return drift, nil
+}
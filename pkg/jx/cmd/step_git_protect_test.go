@@ -0,0 +1,102 @@
+package cmd_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	helm_test "github.com/jenkins-x/jx/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/pkg/jx/cmd"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	resources_test "github.com/jenkins-x/jx/pkg/kube/resources/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func stepGitProtectOptions(provider *gits.FakeProvider) *cmd.StepGitProtectOptions {
+	protect := true
+	approvals := 1
+	context := "unit"
+	scheduler := &v1.Scheduler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "protected-scheduler",
+			Namespace: "jx",
+		},
+		Spec: v1.SchedulerSpec{
+			Policy: &v1.GlobalProtectionPolicy{
+				ProtectionPolicy: &v1.ProtectionPolicy{
+					Protect: &protect,
+					RequiredStatusChecks: &v1.BranchProtectionContextPolicy{
+						Contexts: &v1.ReplaceableSliceOfStrings{Items: []string{context}},
+					},
+					RequiredPullRequestReviews: &v1.ReviewPolicy{
+						Approvals: &approvals,
+					},
+				},
+			},
+		},
+	}
+	sourceRepo := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myrepo",
+			Namespace: "jx",
+		},
+		Spec: v1.SourceRepositorySpec{
+			Org:       "myorg",
+			Repo:      "myrepo",
+			Provider:  gits.GitHubURL,
+			Scheduler: v1.ResourceReference{Name: scheduler.Name},
+		},
+	}
+
+	options := &cmd.StepGitProtectOptions{
+		StepOptions: cmd.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		Owner:    "myorg",
+		Repo:     "myrepo",
+		Branches: []string{"master"},
+	}
+	cmd.ConfigureTestOptionsWithResources(options.CommonOptions,
+		[]runtime.Object{},
+		[]runtime.Object{scheduler, sourceRepo},
+		&gits.GitFake{},
+		provider,
+		helm_test.NewMockHelmer(),
+		resources_test.NewMockInstaller(),
+	)
+	return options
+}
+
+func TestStepGitProtect(t *testing.T) {
+	repo := gits.NewFakeRepository("myorg", "myrepo")
+	provider := gits.NewFakeProvider(repo)
+	options := stepGitProtectOptions(provider)
+
+	options.DryRun = true
+	err := options.Run()
+	require.NoError(t, err)
+	protection, err := provider.GetBranchProtection("myorg", "myrepo", "master")
+	require.NoError(t, err)
+	assert.Nil(t, protection, "a dry run should not protect the branch")
+
+	options.DryRun = false
+	err = options.Run()
+	require.NoError(t, err)
+	protection, err = provider.GetBranchProtection("myorg", "myrepo", "master")
+	require.NoError(t, err)
+	assert.Equal(t, &gits.GitBranchProtection{
+		RequiredContexts:             []string{"unit"},
+		RequiredApprovingReviewCount: 1,
+	}, protection)
+}
+
+func TestStepGitProtectUnknownRepository(t *testing.T) {
+	provider := gits.NewFakeProvider(gits.NewFakeRepository("myorg", "myrepo"))
+	options := stepGitProtectOptions(provider)
+	options.Repo = "another"
+
+	assert.Error(t, options.Run())
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +19,15 @@ type StepPROptions struct {
 	StepOptions
 }
 
+// StepPRTargetFlags are the flags of the "step pr" commands which select a pull request
+type StepPRTargetFlags struct {
+	Owner      string
+	Repository string
+	PR         string
+	GitServer  string
+	GitKind    string
+}
+
 // NewCmdStepPR Steps a command object for the "step pr" command
 func NewCmdStepPR(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &StepPROptions{
@@ -33,6 +49,9 @@ func NewCmdStepPR(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.AddCommand(NewCmdStepPRComment(commonOpts))
 	cmd.AddCommand(NewCmdStepPRLabels(commonOpts))
+	cmd.AddCommand(NewCmdStepPRReview(commonOpts))
+	cmd.AddCommand(NewCmdStepPRReviewers(commonOpts))
+	cmd.AddCommand(NewCmdStepPRReviews(commonOpts))
 
 	return cmd
 }
@@ -41,3 +60,84 @@ func NewCmdStepPR(commonOpts *opts.CommonOptions) *cobra.Command {
 func (o *StepPROptions) Run() error {
 	return o.Cmd.Help()
 }
+
+func (f *StepPRTargetFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Owner, "owner", "o", "", "Git organisation / owner, defaults to the owner of the git repository in the current directory")
+	cmd.Flags().StringVarP(&f.Repository, "repository", "r", "", "Git repository, defaults to the git repository in the current directory")
+	cmd.Flags().StringVarP(&f.PR, "pull-request", "p", "", "Git Pull Request number, defaults to the number of the $BRANCH_NAME of the pipeline")
+	cmd.Flags().StringVarP(&f.GitServer, "git-server", "", "", "The URL of the git server of the --owner and --repository, defaults to the current git server")
+	cmd.Flags().StringVarP(&f.GitKind, "git-kind", "", "", "The kind of the git server of the --owner and --repository, defaults to the kind registered for the git server")
+}
+
+// pullRequestProvider returns the git provider of the pull request selected by the flags. If the owner or repository
+// is not specified they default to those of the git repository in the current directory and its git provider is used,
+// otherwise the provider of the git server of the flags is used.
+func (o *StepPROptions) pullRequestProvider(flags *StepPRTargetFlags, message string) (gits.GitProvider, int, error) {
+	if flags.PR == "" {
+		flags.PR = strings.TrimPrefix(os.Getenv("BRANCH_NAME"), "PR-")
+	}
+	if flags.PR == "" {
+		return nil, 0, fmt.Errorf("no Pull Request number provided")
+	}
+	number, err := strconv.Atoi(flags.PR)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "invalid Pull Request number %s", flags.PR)
+	}
+	if flags.Owner != "" && flags.Repository != "" {
+		provider, err := o.gitServerProvider(flags)
+		if err != nil {
+			return nil, 0, err
+		}
+		return provider, number, nil
+	}
+
+	gitInfo, err := o.Git().Info("")
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to find the git repository in the current directory, use --owner and --repository to select the pull request")
+	}
+	if flags.Owner == "" {
+		flags.Owner = gitInfo.Organisation
+	}
+	if flags.Repository == "" {
+		flags.Repository = gitInfo.Name
+	}
+	if flags.Owner == "" {
+		return nil, 0, fmt.Errorf("no Git owner provided")
+	}
+	if flags.Repository == "" {
+		return nil, 0, fmt.Errorf("no Git repository provided")
+	}
+	provider, err := o.GitProviderForURL(gitInfo.URL, message)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to create the git provider for %s", gitInfo.URL)
+	}
+	return provider, number, nil
+}
+
+// gitServerProvider returns the git provider of the git server of the flags, defaulting to the current git server
+func (o *StepPROptions) gitServerProvider(flags *StepPRTargetFlags) (gits.GitProvider, error) {
+	serverURL := flags.GitServer
+	if serverURL == "" {
+		authConfigSvc, err := o.CreateGitAuthConfigService()
+		if err != nil {
+			return nil, err
+		}
+		serverURL = authConfigSvc.Config().CurrentServer
+	}
+	if serverURL == "" {
+		serverURL = gits.GitHubURL
+	}
+	kind := flags.GitKind
+	if kind == "" {
+		var err error
+		kind, err = o.GitServerHostURLKind(serverURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the kind of the git server %s, use --git-kind to specify it", serverURL)
+		}
+	}
+	provider, err := o.GitProviderForGitServerURL(serverURL, kind)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the git provider for %s", serverURL)
+	}
+	return provider, nil
+}
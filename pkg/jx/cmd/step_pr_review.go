@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// StepPRReviewOptions contains the command line flags
+type StepPRReviewOptions struct {
+	StepPROptions
+	Flags StepPRTargetFlags
+
+	Approve        bool
+	RequestChanges bool
+	Body           string
+}
+
+var (
+	stepPRReviewLong = templates.LongDesc(`
+		Submits a review of a pull request which approves it, requests changes to it or only comments on it.
+
+		Requesting changes fails on git providers which do not support it, such as GitLab, so comment on the pull request instead.
+`)
+
+	stepPRReviewExample = templates.Examples(`
+		# approve the pull request of the current pipeline
+		jx step pr review --approve
+
+		# request changes to a pull request
+		jx step pr review -o myorg -r myrepo -p 12 --request-changes --body "please add some tests"
+
+		# comment on a pull request
+		jx step pr review -p 12 --body "looks good so far"
+`)
+)
+
+// NewCmdStepPRReview creates the command
+func NewCmdStepPRReview(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepPRReviewOptions{
+		StepPROptions: StepPROptions{
+			StepOptions: StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "review",
+		Short:   "Approves, requests changes to or comments on a pull request",
+		Long:    stepPRReviewLong,
+		Example: stepPRReviewExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.Flags.addFlags(cmd)
+	cmd.Flags().BoolVarP(&options.Approve, "approve", "", false, "Approves the pull request")
+	cmd.Flags().BoolVarP(&options.RequestChanges, "request-changes", "", false, "Requests changes to the pull request")
+	cmd.Flags().StringVarP(&options.Body, "body", "b", "", "The body of the review")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepPRReviewOptions) Run() error {
+	state := gits.ReviewStateCommented
+	switch {
+	case o.Approve && o.RequestChanges:
+		return fmt.Errorf("a review cannot both approve and request changes")
+	case o.Approve:
+		state = gits.ReviewStateApproved
+	case o.RequestChanges:
+		state = gits.ReviewStateChangesRequested
+	case o.Body == "":
+		return util.MissingOption("body")
+	}
+
+	provider, number, err := o.pullRequestProvider(&o.Flags, "user name to review the pull request as")
+	if err != nil {
+		return err
+	}
+	review, err := provider.SubmitReview(o.Flags.Owner, o.Flags.Repository, number, &gits.GitReview{
+		State: state,
+		Body:  o.Body,
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Submitted a %s review of the pull request %s on %s\n", util.ColorInfo(review.State), util.ColorInfo(fmt.Sprintf("%d", number)), util.ColorInfo(o.Flags.Owner+"/"+o.Flags.Repository))
+	return nil
+}
@@ -0,0 +1,176 @@
+package cmd_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/jx/cmd"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/clients"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakePullRequestOptions() (*opts.CommonOptions, *gits.FakeProvider) {
+	repo := gits.NewFakeRepository("myorg", "myrepo")
+	number := 1
+	repo.PullRequests[number] = &gits.FakePullRequest{
+		PullRequest: &gits.GitPullRequest{
+			Owner:  "myorg",
+			Repo:   "myrepo",
+			Number: &number,
+		},
+	}
+	provider := gits.NewFakeProvider(repo)
+
+	commonOpts := opts.NewCommonOptionsWithFactory(clients.NewFactory())
+	commonOpts.BatchMode = true
+	commonOpts.SetGit(&gits.GitFake{
+		RepoInfo: *repo.GitRepo,
+	})
+	commonOpts.SetFakeGitProvider(provider)
+	return &commonOpts, provider
+}
+
+func TestStepPRReviewAndReviewers(t *testing.T) {
+	commonOpts, provider := fakePullRequestOptions()
+
+	review := &cmd.StepPRReviewOptions{
+		StepPROptions: cmd.StepPROptions{
+			StepOptions: cmd.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+		Flags:   cmd.StepPRTargetFlags{PR: "1"},
+		Approve: true,
+		Body:    "looks good",
+	}
+	err := review.Run()
+	require.NoError(t, err)
+
+	reviewers := &cmd.StepPRReviewersOptions{
+		StepPROptions: cmd.StepPROptions{
+			StepOptions: cmd.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+		Flags: cmd.StepPRTargetFlags{PR: "1"},
+	}
+	reviewers.Args = []string{"alice", "bob"}
+	err = reviewers.Run()
+	require.NoError(t, err)
+
+	reviews, err := provider.ListReviews("myorg", "myrepo", 1)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, gits.ReviewStateApproved, reviews[0].State)
+	assert.Equal(t, "looks good", reviews[0].Body)
+
+	pr, err := provider.GetPullRequest("myorg", &gits.GitRepository{Name: "myrepo"}, 1)
+	require.NoError(t, err)
+	require.Len(t, pr.RequestedReviewers, 2)
+	assert.Equal(t, "alice", pr.RequestedReviewers[0].Login)
+	assert.Equal(t, "bob", pr.RequestedReviewers[1].Login)
+}
+
+// noRepositoryGitter is a gitter used outside of a git repository
+type noRepositoryGitter struct {
+	*gits.GitFake
+}
+
+// Info fails as there is no git repository
+func (g *noRepositoryGitter) Info(dir string) (*gits.GitRepository, error) {
+	return nil, errors.New("not a git repository")
+}
+
+func TestStepPRReviewOutsideGitRepository(t *testing.T) {
+	commonOpts, provider := fakePullRequestOptions()
+	commonOpts.SetGit(&noRepositoryGitter{GitFake: &gits.GitFake{}})
+
+	review := &cmd.StepPRReviewOptions{
+		StepPROptions: cmd.StepPROptions{
+			StepOptions: cmd.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+		Flags: cmd.StepPRTargetFlags{
+			Owner:      "myorg",
+			Repository: "myrepo",
+			PR:         "1",
+			GitServer:  gits.GitHubURL,
+			GitKind:    gits.KindGitHub,
+		},
+		Approve: true,
+	}
+	err := review.Run()
+	require.NoError(t, err)
+
+	reviews, err := provider.ListReviews("myorg", "myrepo", 1)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+
+	review.Flags.Owner = ""
+	assert.Error(t, review.Run(), "the owner defaults to the git repository in the current directory")
+}
+
+func TestStepPRReviewValidatesFlags(t *testing.T) {
+	commonOpts, provider := fakePullRequestOptions()
+
+	review := &cmd.StepPRReviewOptions{
+		StepPROptions: cmd.StepPROptions{
+			StepOptions: cmd.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+		Flags:          cmd.StepPRTargetFlags{PR: "1"},
+		Approve:        true,
+		RequestChanges: true,
+	}
+	assert.Error(t, review.Run())
+
+	// a comment needs a body
+	review.Approve = false
+	review.RequestChanges = false
+	assert.Error(t, review.Run())
+
+	reviews, err := provider.ListReviews("myorg", "myrepo", 1)
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+}
+
+func TestStepPRReviews(t *testing.T) {
+	commonOpts, provider := fakePullRequestOptions()
+	_, err := provider.SubmitReview("myorg", "myrepo", 1, &gits.GitReview{
+		State: gits.ReviewStateChangesRequested,
+		Body:  "please add some tests\nand docs",
+		User:  &gits.GitUser{Login: "alice"},
+	})
+	require.NoError(t, err)
+
+	r, fakeStdout, err := os.Pipe()
+	require.NoError(t, err)
+	commonOpts.Out = fakeStdout
+
+	options := &cmd.StepPRReviewsOptions{
+		StepPROptions: cmd.StepPROptions{
+			StepOptions: cmd.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+		Flags: cmd.StepPRTargetFlags{PR: "1"},
+	}
+	err = options.Run()
+	require.NoError(t, err)
+
+	fakeStdout.Close()
+	out, err := ioutil.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "alice")
+	assert.Contains(t, string(out), gits.ReviewStateChangesRequested)
+	assert.Contains(t, string(out), "please add some tests")
+	assert.NotContains(t, string(out), "and docs")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// StepPRReviewersOptions contains the command line flags
+type StepPRReviewersOptions struct {
+	StepPROptions
+	Flags StepPRTargetFlags
+}
+
+var (
+	stepPRReviewersLong = templates.LongDesc(`
+		Requests reviews of a pull request from the given users.
+`)
+
+	stepPRReviewersExample = templates.Examples(`
+		# request reviews of the pull request of the current pipeline
+		jx step pr reviewers alice bob
+
+		# request a review of a pull request of another repository
+		jx step pr reviewers -o myorg -r myrepo -p 12 alice
+`)
+)
+
+// NewCmdStepPRReviewers creates the command
+func NewCmdStepPRReviewers(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepPRReviewersOptions{
+		StepPROptions: StepPROptions{
+			StepOptions: StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "reviewers [user]...",
+		Short:   "Requests reviews of a pull request",
+		Long:    stepPRReviewersLong,
+		Example: stepPRReviewersExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.Flags.addFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepPRReviewersOptions) Run() error {
+	if len(o.Args) == 0 {
+		return fmt.Errorf("no reviewers provided")
+	}
+	provider, number, err := o.pullRequestProvider(&o.Flags, "user name to request the reviews as")
+	if err != nil {
+		return err
+	}
+	err = provider.RequestReviewers(o.Flags.Owner, o.Flags.Repository, number, o.Args)
+	if err != nil {
+		return err
+	}
+	log.Infof("Requested reviews of the pull request %s on %s from %s\n", util.ColorInfo(fmt.Sprintf("%d", number)), util.ColorInfo(o.Flags.Owner+"/"+o.Flags.Repository), util.ColorInfo(strings.Join(o.Args, ", ")))
+	return nil
+}
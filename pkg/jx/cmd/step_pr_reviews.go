@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/spf13/cobra"
+)
+
+// StepPRReviewsOptions contains the command line flags
+type StepPRReviewsOptions struct {
+	StepPROptions
+	Flags StepPRTargetFlags
+}
+
+var (
+	stepPRReviewsLong = templates.LongDesc(`
+		Lists the reviews of a pull request which approve it or request changes to it.
+`)
+
+	stepPRReviewsExample = templates.Examples(`
+		# list the reviews of the pull request of the current pipeline
+		jx step pr reviews
+
+		# list the reviews of a pull request of another repository
+		jx step pr reviews -o myorg -r myrepo -p 12
+`)
+)
+
+// NewCmdStepPRReviews creates the command
+func NewCmdStepPRReviews(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepPRReviewsOptions{
+		StepPROptions: StepPROptions{
+			StepOptions: StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "reviews",
+		Short:   "Lists the reviews of a pull request",
+		Long:    stepPRReviewsLong,
+		Example: stepPRReviewsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.Flags.addFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepPRReviewsOptions) Run() error {
+	provider, number, err := o.pullRequestProvider(&o.Flags, "user name to list the reviews as")
+	if err != nil {
+		return err
+	}
+	reviews, err := provider.ListReviews(o.Flags.Owner, o.Flags.Repository, number)
+	if err != nil {
+		return err
+	}
+	table := o.CreateTable()
+	table.AddRow("USER", "STATE", "SUBMITTED", "BODY")
+	for _, review := range reviews {
+		user := ""
+		if review.User != nil {
+			user = review.User.Login
+		}
+		submitted := ""
+		if review.SubmittedAt != nil {
+			submitted = review.SubmittedAt.Format("2006-01-02 15:04:05")
+		}
+		table.AddRow(user, review.State, submitted, strings.SplitN(review.Body, "\n", 2)[0])
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepRejectLong = templates.LongDesc(`
+		Rejects a pipeline which is waiting in an approval stage so that the stage fails and the pipeline stops.
+
+		Only users bound to the environment of the approval stage by an EnvironmentRoleBinding can reject it. If the
+		approval stage specifies roles the user must be bound to one of those roles.
+
+		If no PipelineActivity is specified you are prompted to pick one of the activities waiting for an approval.
+`)
+
+	stepRejectExample = templates.Examples(`
+		# pick a pipeline waiting for approval and reject it
+		jx step reject --comment "not during the change freeze"
+
+		# reject a stage of a specific pipeline
+		jx step reject myorg-myapp-master-12 --stage "Approve production"
+			`)
+)
+
+// NewCmdStepReject Creates a new Command object
+func NewCmdStepReject(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepApproveOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+		outcome: v1.ApprovalOutcomeTypeRejected,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "reject [activity]",
+		Short:   "Rejects a pipeline which is waiting in an approval stage",
+		Long:    stepRejectLong,
+		Example: stepRejectExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
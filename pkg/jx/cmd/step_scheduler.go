@@ -19,6 +19,9 @@ var (
 		* jx step scheduler config apply
 		* jx step scheduler config generate
 		* jx step scheduler config create pr
+		* jx step scheduler diff
+		* jx step scheduler explain
+		* jx step scheduler import
 `)
 )
 
@@ -42,6 +45,9 @@ func NewCmdStepScheduler(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.AddCommand(NewCmdStepSchedulerConfig(commonOpts))
+	cmd.AddCommand(NewCmdStepSchedulerDiff(commonOpts))
+	cmd.AddCommand(NewCmdStepSchedulerExplain(commonOpts))
+	cmd.AddCommand(NewCmdStepSchedulerImport(commonOpts))
 	return cmd
 }
 
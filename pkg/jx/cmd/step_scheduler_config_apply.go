@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
@@ -8,6 +9,8 @@ import (
 	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
 )
 
 // StepSchedulerConfigApplyOptions contains the command line flags
@@ -72,41 +75,55 @@ func (o *StepSchedulerConfigApplyOptions) Run() error {
 		if err != nil {
 			return errors.Wrapf(err, "generating Prow config")
 		}
-		if gitOps {
-			opts := pipelinescheduler.GitOpsOptions{
-				Verbose: o.Verbose,
-				DevEnv:  devEnv,
-			}
-			environmentsDir, err := o.EnvironmentsDir()
-			if err != nil {
-				return errors.Wrapf(err, "getting environments dir")
-			}
-			opts.EnvironmentsDir = environmentsDir
-
-			gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(devEnv.Spec.Source.URL)
-			if err != nil {
-				return errors.Wrapf(err, "creating git provider for %s", devEnv.Spec.Source.URL)
-			}
-			opts.GitProvider = gitProvider
-			opts.ConfigureGitFn = o.ConfigureGitCallback
-			opts.Gitter = o.Git()
-			opts.Helmer = o.Helm()
-			err = opts.AddToEnvironmentRepo(cfg, plugs)
-			if err != nil {
-				return errors.Wrapf(err, "adding Prow config to environment repo")
-			}
-		} else {
-			kubeClient, ns, err := o.KubeClientAndNamespace()
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			err = pipelinescheduler.ApplyDirectly(kubeClient, ns, cfg, plugs)
-			if err != nil {
-				return errors.Wrapf(err, "applying Prow config")
-			}
+		_, currentNs, err := o.KubeClientAndNamespace()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = applyProwConfig(o.CommonOptions, gitOps, devEnv, currentNs, cfg, plugs, o.ConfigureGitCallback)
+		if err != nil {
+			return err
 		}
 	default:
 		return errors.Errorf("%s is an unsupported agent. Available agents are: prow", o.Agent)
 	}
 	return nil
 }
+
+// applyProwConfig applies the Prow configuration with a pull request on the environment repository when using GitOps
+// and directly to the config and plugins ConfigMaps in the namespace otherwise
+func applyProwConfig(o *opts.CommonOptions, gitOps bool, devEnv *jenkinsv1.Environment, ns string, cfg *config.Config, plugs *plugins.Configuration, configureGit gits.ConfigureGitFn) error {
+	if gitOps {
+		opts := pipelinescheduler.GitOpsOptions{
+			Verbose: o.Verbose,
+			DevEnv:  devEnv,
+		}
+		environmentsDir, err := o.EnvironmentsDir()
+		if err != nil {
+			return errors.Wrapf(err, "getting environments dir")
+		}
+		opts.EnvironmentsDir = environmentsDir
+
+		gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(devEnv.Spec.Source.URL)
+		if err != nil {
+			return errors.Wrapf(err, "creating git provider for %s", devEnv.Spec.Source.URL)
+		}
+		opts.GitProvider = gitProvider
+		opts.ConfigureGitFn = configureGit
+		opts.Gitter = o.Git()
+		opts.Helmer = o.Helm()
+		err = opts.AddToEnvironmentRepo(cfg, plugs)
+		if err != nil {
+			return errors.Wrapf(err, "adding Prow config to environment repo")
+		}
+		return nil
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = pipelinescheduler.ApplyDirectly(kubeClient, ns, cfg, plugs)
+	if err != nil {
+		return errors.Wrapf(err, "applying Prow config")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepSchedulerDiffOptions contains the command line flags
+type StepSchedulerDiffOptions struct {
+	StepOptions
+	Restore     bool
+	FailOnDrift bool
+}
+
+var (
+	stepSchedulerDiffLong = templates.LongDesc(`
+		Compares the live Prow configuration in the config and plugins ConfigMaps with the configuration generated from
+		the Schedulers and reports the differences of each repository.
+
+		The configurations are compared per repository so that differences which do not change the behaviour of Prow,
+		such as the order of the entries, are not reported. Settings which a scheduler cannot represent, such as Prow
+		periodics, are not compared and are reported as warnings.
+
+		Use --restore to replace the live configuration with the generated configuration, which records an event on the
+		config ConfigMap. When the team uses GitOps the configuration is restored with a pull request on the environment
+		repository like 'jx step scheduler config apply'.
+`)
+	stepSchedulerDiffExample = templates.Examples(`
+		# report how the live Prow configuration has drifted from the schedulers
+		jx step scheduler diff
+
+		# restore the generated Prow configuration if it has drifted
+		jx step scheduler diff --restore
+`)
+)
+
+// NewCmdStepSchedulerDiff Steps a command object for the "step" command
+func NewCmdStepSchedulerDiff(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSchedulerDiffOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Compares the live Prow configuration with the configuration generated from the Schedulers",
+		Long:    stepSchedulerDiffLong,
+		Example: stepSchedulerDiffExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVarP(&options.Restore, "restore", "", false, "Replaces the live Prow configuration with the generated configuration if it has drifted")
+	cmd.Flags().BoolVarP(&options.FailOnDrift, "fail-on-drift", "", false, "Returns an error if the live Prow configuration has drifted")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSchedulerDiffOptions) Run() error {
+	drift, restored, err := detectAndRestoreProwDrift(o.CommonOptions, func(drift *pipelinescheduler.ProwConfigDrift) bool {
+		return o.Restore
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range drift.Warnings {
+		log.Warnf("Not compared: %s\n", warning)
+	}
+	if !drift.HasDrifted() {
+		log.Infof("The Prow configuration matches the schedulers\n")
+		return nil
+	}
+
+	table := o.CreateTable()
+	table.AddRow("REPOSITORY", "SETTING", "EXPECTED", "ACTUAL")
+	for _, difference := range drift.Global {
+		table.AddRow("", difference.Path, difference.Expected, difference.Actual)
+	}
+	for _, repo := range drift.Repositories {
+		name := repo.Org + "/" + repo.Repo
+		switch {
+		case repo.Missing:
+			table.AddRow(name, "", "configured", util.ColorWarning("missing"))
+		case repo.Unexpected:
+			table.AddRow(name, "", "no scheduler", util.ColorWarning("configured"))
+		}
+		for _, difference := range repo.Differences {
+			table.AddRow(name, difference.Path, difference.Expected, difference.Actual)
+		}
+	}
+	table.Render()
+
+	recordProwDrift(o.CommonOptions, drift, restored)
+	if restored {
+		log.Infof("Restored the Prow configuration generated from the schedulers\n")
+		return nil
+	}
+	if o.FailOnDrift {
+		return errors.New(drift.Summary())
+	}
+	return nil
+}
+
+// detectAndRestoreProwDrift compares the live Prow configuration with the configuration generated from the schedulers
+// and, if it has drifted and restore returns true for the drift, applies the generated configuration
+func detectAndRestoreProwDrift(o *opts.CommonOptions, restore func(drift *pipelinescheduler.ProwConfigDrift) bool) (*pipelinescheduler.ProwConfigDrift, bool, error) {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return nil, false, err
+	}
+	gitOps, devEnv := o.GetDevEnv()
+	drift, cfg, plugs, err := pipelinescheduler.DetectDrift(gitOps, kubeClient, jxClient, ns, teamSettings.DefaultScheduler.Name, devEnv)
+	if err != nil {
+		return nil, false, err
+	}
+	if !drift.HasDrifted() {
+		return drift, false, nil
+	}
+	restored := false
+	if restore(drift) {
+		err = applyProwConfig(o, gitOps, devEnv, ns, cfg, plugs, nil)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "restoring the generated Prow config")
+		}
+		restored = true
+	}
+	return drift, restored, nil
+}
+
+// recordProwDrift records an event for the drift of the Prow configuration, logging rather than returning a failure
+func recordProwDrift(o *opts.CommonOptions, drift *pipelinescheduler.ProwConfigDrift, restored bool) {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err == nil {
+		err = pipelinescheduler.RecordDriftEvent(kubeClient, ns, drift, restored)
+	}
+	if err != nil {
+		log.Warnf("Failed to record the Prow configuration drift: %s\n", err)
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepSchedulerExplainOptions contains the command line flags
+type StepSchedulerExplainOptions struct {
+	StepOptions
+	Dir          string
+	Owner        string
+	Repo         string
+	Branch       string
+	PullRequest  bool
+	ChangedFiles []string
+	Comment      string
+	NoSpec       bool
+}
+
+var (
+	stepSchedulerExplainLong = templates.LongDesc(`
+		Explains how the Schedulers of a repository are merged and which jobs would run for a push or a pull request.
+
+		The effective Scheduler merged from the team, repository group and repository Schedulers is displayed along with
+		the Scheduler each setting came from. For a push the postsubmits are explained and for a pull request the
+		presubmits are explained, including the branch, changed file and comment decisions which apply to each job.
+`)
+	stepSchedulerExplainExample = templates.Examples(`
+		# explain which postsubmits run for a push to master of the current repository
+		jx step scheduler explain
+
+		# explain which presubmits run for a pull request changing some files
+		jx step scheduler explain --owner myorg --repo myrepo --pr --changed docs/README.md --changed pkg/foo.go
+
+		# explain which presubmits a comment on a pull request would trigger
+		jx step scheduler explain --pr --comment "/test integration"
+`)
+)
+
+// NewCmdStepSchedulerExplain Steps a command object for the "step" command
+func NewCmdStepSchedulerExplain(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSchedulerExplainOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "explain",
+		Short:   "Explains the effective Scheduler of a repository and which jobs would run for an event",
+		Long:    stepSchedulerExplainLong,
+		Example: stepSchedulerExplainExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "The directory used to find the git repository if no owner and repository are specified")
+	cmd.Flags().StringVarP(&options.Owner, "owner", "o", "", "The git owner or organisation of the repository")
+	cmd.Flags().StringVarP(&options.Repo, "repo", "r", "", "The name of the git repository")
+	cmd.Flags().StringVarP(&options.Branch, "branch", "", "master", "The branch pushed to or the base branch of the pull request")
+	cmd.Flags().BoolVarP(&options.PullRequest, "pr", "", false, "Explains the presubmits of a pull request rather than the postsubmits of a push")
+	cmd.Flags().StringArrayVarP(&options.ChangedFiles, "changed", "c", nil, "The files changed by the push or pull request. If none are specified the changed files are treated as unknown")
+	cmd.Flags().StringVarP(&options.Comment, "comment", "", "", "A comment on the pull request such as '/test all'")
+	cmd.Flags().BoolVarP(&options.NoSpec, "no-spec", "", false, "Does not display the YAML of the effective Scheduler")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSchedulerExplainOptions) Run() error {
+	if o.Comment != "" {
+		o.PullRequest = true
+	}
+	if o.Owner == "" || o.Repo == "" {
+		gitInfo, err := o.FindGitInfo(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "finding the git repository, use --owner and --repo to specify it")
+		}
+		if o.Owner == "" {
+			o.Owner = gitInfo.Organisation
+		}
+		if o.Repo == "" {
+			o.Repo = gitInfo.Name
+		}
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	teamSettings, err := o.TeamSettings()
+	if err != nil {
+		return err
+	}
+	gitOps, devEnv := o.GetDevEnv()
+	sources, err := pipelinescheduler.SchedulerSources(gitOps, jxClient, ns, teamSettings.DefaultScheduler.Name, devEnv, o.Owner, o.Repo)
+	if err != nil {
+		return errors.Wrapf(err, "finding the schedulers of %s/%s", o.Owner, o.Repo)
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no Scheduler applies to %s/%s", o.Owner, o.Repo)
+	}
+	event := &pipelinescheduler.ExplainEvent{
+		Branch:      o.Branch,
+		PullRequest: o.PullRequest,
+		Comment:     o.Comment,
+	}
+	if len(o.ChangedFiles) > 0 {
+		event.ChangedFiles = o.ChangedFiles
+	}
+	explanation, err := pipelinescheduler.Explain(sources, event)
+	if err != nil {
+		return errors.Wrapf(err, "explaining the schedulers of %s/%s", o.Owner, o.Repo)
+	}
+
+	fmt.Fprintf(o.Out, "Schedulers of %s merged from the least to the most specific:\n", util.ColorInfo(o.Owner+"/"+o.Repo))
+	for _, source := range explanation.Sources {
+		fmt.Fprintf(o.Out, "  %s\n", source.String())
+	}
+	if !o.NoSpec {
+		data, err := yaml.Marshal(explanation.Scheduler)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling the effective scheduler")
+		}
+		fmt.Fprintf(o.Out, "\nEffective Scheduler:\n%s", string(data))
+	}
+
+	fmt.Fprintf(o.Out, "\nSettings:\n")
+	table := o.CreateTable()
+	table.AddRow("SETTING", "VALUE", "SOURCE", "OVERRIDES")
+	for _, setting := range explanation.Settings {
+		table.AddRow(setting.Path, setting.Value, setting.Source, strings.Join(setting.Overrides, ", "))
+	}
+	table.Render()
+
+	kind := "push to"
+	if o.PullRequest {
+		kind = "pull request to"
+	}
+	fmt.Fprintf(o.Out, "\nJobs for a %s %s:\n", kind, util.ColorInfo(o.Branch))
+	table = o.CreateTable()
+	table.AddRow("JOB", "KIND", "CONTEXT", "RUNS", "SOURCE", "REASONS")
+	for _, job := range explanation.Jobs {
+		runs := util.ColorWarning("no")
+		if job.Run {
+			runs = util.ColorInfo("yes")
+		}
+		table.AddRow(job.Name, job.Kind, job.Context, runs, strings.Join(job.Sources, ", "), strings.Join(job.Reasons, "; "))
+	}
+	table.Render()
+
+	fmt.Fprintf(o.Out, "\nMerge policy:\n")
+	policy := explanation.MergePolicy()
+	if len(policy) == 0 {
+		fmt.Fprintf(o.Out, "  no merge policy is configured\n")
+		return nil
+	}
+	table = o.CreateTable()
+	table.AddRow("SETTING", "VALUE", "SOURCE")
+	for _, setting := range policy {
+		table.AddRow(setting.Path, setting.Value, setting.Source)
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// StepSchedulerImportOptions contains the command line flags
+type StepSchedulerImportOptions struct {
+	StepOptions
+	ConfigFile    string
+	PluginsFile   string
+	TeamScheduler string
+	Provider      string
+	OutputDir     string
+	Apply         bool
+}
+
+var (
+	stepSchedulerImportLong = templates.LongDesc(`
+		Imports an existing Prow configuration into Schedulers, SourceRepositoryGroups and SourceRepositories.
+
+		The settings shared by every repository are put in the team Scheduler and the settings shared by the repositories
+		of an org are put in a Scheduler of a SourceRepositoryGroup for the org, so a repository only has a Scheduler of
+		its own if some of its settings differ. Generating the Prow configuration from the imported resources reproduces
+		the original configuration, apart from the parts which are reported as not imported.
+
+		If no files are specified the Prow configuration is read from the config and plugins ConfigMaps of the dev
+		namespace.
+
+		The imported resources are written to YAML files in the --output-dir directory so that they can be reviewed.
+		Use --apply to create or update them in the dev namespace and make the team Scheduler the default scheduler of
+		the team instead.
+`)
+	stepSchedulerImportExample = templates.Examples(`
+		# write the resources imported from the Prow configuration of the cluster to a directory to review them
+		jx step scheduler import --output-dir imported
+
+		# import the Prow configuration of the cluster into the resources of the dev namespace
+		jx step scheduler import --apply
+
+		# write the resources imported from Prow configuration files
+		jx step scheduler import --config config.yaml --plugins plugins.yaml
+`)
+)
+
+// NewCmdStepSchedulerImport Steps a command object for the "step" command
+func NewCmdStepSchedulerImport(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSchedulerImportOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Imports an existing Prow configuration into Schedulers",
+		Long:    stepSchedulerImportLong,
+		Example: stepSchedulerImportExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.ConfigFile, "config", "", "", "The Prow config.yaml file to import. Defaults to the config ConfigMap")
+	cmd.Flags().StringVarP(&options.PluginsFile, "plugins", "", "", "The Prow plugins.yaml file to import. Defaults to the plugins ConfigMap")
+	cmd.Flags().StringVarP(&options.TeamScheduler, "team-scheduler", "", "default-scheduler", "The name of the Scheduler for the settings shared by every repository")
+	cmd.Flags().StringVarP(&options.Provider, "provider", "", "https://github.com", "The git provider URL of the imported repositories")
+	cmd.Flags().StringVarP(&options.OutputDir, "output-dir", "", "imported-schedulers", "The directory the imported resources are written to as YAML files")
+	cmd.Flags().BoolVarP(&options.Apply, "apply", "", false, "Creates or updates the imported resources in the dev namespace rather than writing them to the output directory")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSchedulerImportOptions) Run() error {
+	cfg, plugs, err := o.loadProwConfig()
+	if err != nil {
+		return err
+	}
+	imported, err := pipelinescheduler.ImportProwConfig(cfg, plugs, o.TeamScheduler, o.Provider)
+	if err != nil {
+		return errors.Wrapf(err, "importing Prow config")
+	}
+	for _, warning := range imported.Warnings {
+		log.Warnf("%s\n", warning)
+	}
+	if o.Apply {
+		return o.applyResources(imported)
+	}
+	if o.OutputDir == "" {
+		return util.MissingOption("output-dir")
+	}
+	return o.writeResources(imported)
+}
+
+func (o *StepSchedulerImportOptions) loadProwConfig() (*config.Config, *plugins.Configuration, error) {
+	cfgData, plugsData := "", ""
+	if o.ConfigFile == "" || o.PluginsFile == "" {
+		kubeClient, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		if o.ConfigFile == "" {
+			cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get("config", metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "getting the Prow config ConfigMap in namespace %s", ns)
+			}
+			cfgData = cm.Data["config.yaml"]
+		}
+		if o.PluginsFile == "" {
+			cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get("plugins", metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "getting the Prow plugins ConfigMap in namespace %s", ns)
+			}
+			plugsData = cm.Data["plugins.yaml"]
+		}
+	}
+	if o.ConfigFile != "" {
+		data, err := ioutil.ReadFile(o.ConfigFile)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "reading %s", o.ConfigFile)
+		}
+		cfgData = string(data)
+	}
+	if o.PluginsFile != "" {
+		data, err := ioutil.ReadFile(o.PluginsFile)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "reading %s", o.PluginsFile)
+		}
+		plugsData = string(data)
+	}
+
+	cfg := &config.Config{}
+	err := yaml.Unmarshal([]byte(cfgData), cfg)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unmarshalling the Prow config")
+	}
+	plugs := &plugins.Configuration{}
+	err = yaml.Unmarshal([]byte(plugsData), plugs)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unmarshalling the Prow plugins")
+	}
+	return cfg, plugs, nil
+}
+
+func (o *StepSchedulerImportOptions) writeResources(imported *pipelinescheduler.ImportedSchedulers) error {
+	err := os.MkdirAll(o.OutputDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating directory %s", o.OutputDir)
+	}
+	write := func(kind string, name string, resource interface{}) error {
+		data, err := yaml.Marshal(resource)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling %s %s", kind, name)
+		}
+		fileName := filepath.Join(o.OutputDir, fmt.Sprintf("%s-%s.yaml", strings.ToLower(kind), name))
+		err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "writing %s", fileName)
+		}
+		log.Infof("Wrote %s\n", util.ColorInfo(fileName))
+		return nil
+	}
+	for _, scheduler := range imported.Schedulers {
+		err = write("Scheduler", scheduler.Name, scheduler)
+		if err != nil {
+			return err
+		}
+	}
+	for _, group := range imported.SourceRepositoryGroups {
+		err = write("SourceRepositoryGroup", group.Name, group)
+		if err != nil {
+			return err
+		}
+	}
+	for _, sourceRepo := range imported.SourceRepositories {
+		err = write("SourceRepository", sourceRepo.Name, sourceRepo)
+		if err != nil {
+			return err
+		}
+	}
+	if imported.TeamScheduler != "" {
+		log.Infof("Use %s as the default scheduler of the team\n", util.ColorInfo(imported.TeamScheduler))
+	}
+	return nil
+}
+
+func (o *StepSchedulerImportOptions) applyResources(imported *pipelinescheduler.ImportedSchedulers) error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, scheduler := range imported.Schedulers {
+		scheduler.Namespace = ns
+		existing, err := jxClient.JenkinsV1().Schedulers(ns).Get(scheduler.Name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			_, err = jxClient.JenkinsV1().Schedulers(ns).Create(scheduler)
+		} else if err == nil {
+			existing.Spec = scheduler.Spec
+			_, err = jxClient.JenkinsV1().Schedulers(ns).Update(existing)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "saving Scheduler %s", scheduler.Name)
+		}
+		log.Infof("Saved Scheduler %s\n", util.ColorInfo(scheduler.Name))
+	}
+	for _, group := range imported.SourceRepositoryGroups {
+		group.Namespace = ns
+		existing, err := jxClient.JenkinsV1().SourceRepositoryGroups(ns).Get(group.Name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			_, err = jxClient.JenkinsV1().SourceRepositoryGroups(ns).Create(group)
+		} else if err == nil {
+			existing.Spec = group.Spec
+			_, err = jxClient.JenkinsV1().SourceRepositoryGroups(ns).Update(existing)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "saving SourceRepositoryGroup %s", group.Name)
+		}
+		log.Infof("Saved SourceRepositoryGroup %s\n", util.ColorInfo(group.Name))
+	}
+	for _, sourceRepo := range imported.SourceRepositories {
+		sourceRepo.Namespace = ns
+		existing, err := jxClient.JenkinsV1().SourceRepositories(ns).Get(sourceRepo.Name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			_, err = jxClient.JenkinsV1().SourceRepositories(ns).Create(sourceRepo)
+		} else if err == nil {
+			// only the scheduler of an existing repository is imported
+			existing.Spec.Scheduler = sourceRepo.Spec.Scheduler
+			_, err = jxClient.JenkinsV1().SourceRepositories(ns).Update(existing)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "saving SourceRepository %s", sourceRepo.Name)
+		}
+		log.Infof("Saved SourceRepository %s\n", util.ColorInfo(sourceRepo.Name))
+	}
+	if imported.TeamScheduler == "" {
+		return nil
+	}
+	err = o.ModifyDevEnvironment(func(env *jenkinsv1.Environment) error {
+		env.Spec.TeamSettings.DefaultScheduler = jenkinsv1.ResourceReference{
+			Kind: "Scheduler",
+			Name: imported.TeamScheduler,
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "setting the default scheduler of the team to %s", imported.TeamScheduler)
+	}
+	log.Infof("Set the default scheduler of the team to %s\n", util.ColorInfo(imported.TeamScheduler))
+	return nil
+}
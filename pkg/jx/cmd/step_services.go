@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/spf13/cobra"
+)
+
+// StepServicesOptions contains the command line flags
+type StepServicesOptions struct {
+	StepOptions
+}
+
+// StepServicesFlags contains the command line flags used to find the directory shared by the services of a stage
+type StepServicesFlags struct {
+	Dir string
+}
+
+// NewCmdStepServices Creates a new Command object
+func NewCmdStepServices(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepServicesOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "services",
+		Short: "Starts and stops the services of a pipeline stage",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepServicesStart(commonOpts))
+	cmd.AddCommand(NewCmdStepServicesRun(commonOpts))
+	cmd.AddCommand(NewCmdStepServicesStop(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepServicesOptions) Run() error {
+	return o.Cmd.Help()
+}
+
+// AddFlags adds the flags for the services directory to the given command
+func (f *StepServicesFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Dir, "dir", "", syntax.ServicesDir, "The directory shared by the steps which start, run and stop the services")
+}
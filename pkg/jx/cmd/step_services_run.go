@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepServicesRunLong = templates.LongDesc(`
+		Runs a service of a pipeline stage, such as a database or message broker, next to the steps of the stage.
+
+		This step is added to the Task generated for a stage with services, so it is not usually invoked directly.
+		Tekton runs the steps of a Task one after another, so once the service is ready this step lets the next step
+		start and keeps the service running until 'jx step services stop' runs after the steps of the stage.
+`)
+
+	stepServicesRunExample = templates.Examples(`
+		# runs the service in the JX_SERVICE env var
+		jx step services run
+			`)
+)
+
+// StepServicesRunOptions contains the command line flags
+type StepServicesRunOptions struct {
+	StepOptions
+	StepServicesFlags
+
+	Timeout time.Duration
+}
+
+// NewCmdStepServicesRun Creates a new Command object
+func NewCmdStepServicesRun(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepServicesRunOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "run",
+		Short:   "Runs a service of a pipeline stage",
+		Long:    stepServicesRunLong,
+		Example: stepServicesRunExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.StepServicesFlags.AddFlags(cmd)
+	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "t", 5*time.Minute, "The maximum time to wait for the service to be ready")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepServicesRunOptions) Run() error {
+	text := os.Getenv(syntax.ServiceEnvVar)
+	if text == "" {
+		return fmt.Errorf("no service defined in $%s", syntax.ServiceEnvVar)
+	}
+	service := &syntax.Service{}
+	err := json.Unmarshal([]byte(text), service)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the service in $%s", syntax.ServiceEnvVar)
+	}
+	postFile, err := tekton.StepPostFile()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Starting service %s\n", util.ColorInfo(service.Name))
+	return tekton.RunService(service, o.Dir, postFile, o.Timeout, o.Out)
+}
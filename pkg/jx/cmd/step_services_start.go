@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepServicesStartLong = templates.LongDesc(`
+		Prepares the services of a pipeline stage, such as databases or message brokers, to be started.
+
+		This step is added to the Task generated for a stage with services, so it is not usually invoked directly.
+		Each service runs as a container of the pod of the Task, so the steps can reach it on localhost at its ports.
+		This step copies jx into the directory shared with the services, as the services are run by
+		'jx step services run' using the images of the services.
+`)
+
+	stepServicesStartExample = templates.Examples(`
+		# prepares the services of the stage
+		jx step services start
+			`)
+)
+
+// StepServicesStartOptions contains the command line flags
+type StepServicesStartOptions struct {
+	StepOptions
+	StepServicesFlags
+}
+
+// NewCmdStepServicesStart Creates a new Command object
+func NewCmdStepServicesStart(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepServicesStartOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "start",
+		Short:   "Starts the services of a pipeline stage",
+		Long:    stepServicesStartLong,
+		Example: stepServicesStartExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.StepServicesFlags.AddFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepServicesStartOptions) Run() error {
+	err := tekton.PlaceServiceRunner(o.Dir)
+	if err != nil {
+		return err
+	}
+	log.Infof("Prepared the services in %s\n", util.ColorInfo(o.Dir))
+	return nil
+}
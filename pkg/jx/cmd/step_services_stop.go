@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepServicesStopLong = templates.LongDesc(`
+		Stops the services of a pipeline stage which are run by 'jx step services run'.
+
+		This step is added to the end of the Task generated for a stage with services, so that the pod of the Task
+		completes once the steps of the stage have completed.
+`)
+
+	stepServicesStopExample = templates.Examples(`
+		# stops the services of the stage
+		jx step services stop
+			`)
+)
+
+// StepServicesStopOptions contains the command line flags
+type StepServicesStopOptions struct {
+	StepOptions
+	StepServicesFlags
+}
+
+// NewCmdStepServicesStop Creates a new Command object
+func NewCmdStepServicesStop(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepServicesStopOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "stop",
+		Short:   "Stops the services of a pipeline stage",
+		Long:    stepServicesStopLong,
+		Example: stepServicesStopExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.StepServicesFlags.AddFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepServicesStopOptions) Run() error {
+	err := tekton.StopServices(o.Dir)
+	if err != nil {
+		return err
+	}
+	log.Infof("Stopping the services in %s\n", util.ColorInfo(o.Dir))
+	return nil
+}
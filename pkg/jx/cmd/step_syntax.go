@@ -1,8 +1,20 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +43,9 @@ func NewCmdStepSyntax(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	cmd.AddCommand(NewCmdStepSyntaxValidate(commonOpts))
 	cmd.AddCommand(NewCmdStepSyntaxSchema(commonOpts))
+	cmd.AddCommand(NewCmdStepSyntaxRun(commonOpts))
+	cmd.AddCommand(NewCmdStepSyntaxLint(commonOpts))
+	cmd.AddCommand(NewCmdStepSyntaxGraph(commonOpts))
 	return cmd
 }
 
@@ -38,3 +53,190 @@ func NewCmdStepSyntax(commonOpts *opts.CommonOptions) *cobra.Command {
 func (o *StepSyntaxOptions) Run() error {
 	return o.Cmd.Help()
 }
+
+// EffectivePipelineFlags contains the command line flags used to find the effective pipeline of a project
+type EffectivePipelineFlags struct {
+	BuildPackLockFlags
+
+	Dir          string
+	Context      string
+	PipelineKind string
+	Pack         string
+	BuildPackURL string
+	BuildPackRef string
+}
+
+// AddFlags adds the flags for finding the effective pipeline to the given command
+func (f *EffectivePipelineFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Dir, "dir", "d", "", "The directory containing the project. Defaults to the current directory")
+	cmd.Flags().StringVarP(&f.Context, "context", "c", "", "The pipeline context if there are multiple separate pipelines for a given branch")
+	cmd.Flags().StringVarP(&f.PipelineKind, "kind", "k", jenkinsfile.PipelineKindPullRequest, "The kind of pipeline such as: "+strings.Join(jenkinsfile.PipelineKinds, ", "))
+	cmd.Flags().StringVarP(&f.Pack, "pack", "p", "", "The build pack name. If none is specified its discovered from the source code")
+	cmd.Flags().StringVarP(&f.BuildPackURL, "url", "u", "", "The URL for the build pack Git repository. Defaults to the one in the project or the default build packs")
+	cmd.Flags().StringVarP(&f.BuildPackRef, "ref", "r", "", "The Git reference (branch,tag,sha) in the Git repository to use")
+	f.BuildPackLockFlags.AddFlags(cmd)
+}
+
+// effectiveBuildPack is the build pack resolved for a project
+type effectiveBuildPack struct {
+	createTask        *StepCreateTaskOptions
+	projectConfig     *config.ProjectConfig
+	projectConfigFile string
+	packsDir          string
+	resolver          jenkinsfile.ImportFileResolver
+}
+
+// LoadEffectivePipeline loads the ParsedPipeline for a project after applying build pack inheritance and overrides,
+// without requiring access to a cluster
+func (f *EffectivePipelineFlags) LoadEffectivePipeline(commonOpts *opts.CommonOptions) (*syntax.ParsedPipeline, *jenkinsfile.PipelineConfig, error) {
+	pack, err := f.resolveBuildPack(commonOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pack.createTask.GenerateParsedPipeline(pack.packsDir, pack.projectConfig, pack.projectConfigFile, pack.resolver, "")
+}
+
+// LoadBuildPackPipelineConfig loads the PipelineConfig of the build pack used by the project, without any of the
+// overrides in the project configuration
+func (f *EffectivePipelineFlags) LoadBuildPackPipelineConfig(commonOpts *opts.CommonOptions) (*jenkinsfile.PipelineConfig, error) {
+	pack, err := f.resolveBuildPack(commonOpts)
+	if err != nil {
+		return nil, err
+	}
+	return pack.loadPipelineConfig(true, nil)
+}
+
+// LoadPipelineConfigWithOrigins loads the PipelineConfig of the project after applying build pack inheritance and
+// overrides along with the origin of each of its steps
+func (f *EffectivePipelineFlags) LoadPipelineConfigWithOrigins(commonOpts *opts.CommonOptions) (*jenkinsfile.PipelineConfig, jenkinsfile.StepOrigins, error) {
+	pack, err := f.resolveBuildPack(commonOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	origins := jenkinsfile.StepOrigins{}
+	pipelineConfig := pack.projectConfig.PipelineConfig
+	if pipelineConfig != nil {
+		origins.RecordPipelineConfig(pipelineConfig, &jenkinsfile.StepOrigin{
+			Kind: jenkinsfile.StepOriginFile,
+			File: filepath.Base(pack.projectConfigFile),
+		})
+	}
+	if pack.createTask.Pack != "none" {
+		packConfig, err := pack.loadPipelineConfig(false, origins)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pipelineConfig == nil {
+			pipelineConfig = packConfig
+		} else {
+			err = pipelineConfig.ExtendPipeline(packConfig, false)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to override PipelineConfig using configuration in file %s", pack.projectConfigFile)
+			}
+		}
+	}
+	if pipelineConfig == nil {
+		return nil, nil, fmt.Errorf("failed to find PipelineConfig in file %s", pack.projectConfigFile)
+	}
+	return pipelineConfig, origins, nil
+}
+
+// loadPipelineConfig loads the PipelineConfig of the build pack, recording the origin of each of its steps if origins
+// is not nil
+func (p *effectiveBuildPack) loadPipelineConfig(skipYamlValidation bool, origins jenkinsfile.StepOrigins) (*jenkinsfile.PipelineConfig, error) {
+	name := p.createTask.Pack
+	pipelineFile := filepath.Join(p.packsDir, name, jenkinsfile.PipelineConfigFileName)
+	exists, err := util.FileExists(pipelineFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find build pack pipeline YAML: %s", pipelineFile)
+	}
+	if !exists {
+		return nil, fmt.Errorf("no build pack for %s exists at directory %s", name, p.packsDir)
+	}
+	origin := &jenkinsfile.StepOrigin{
+		Kind: jenkinsfile.StepOriginPack,
+		Pack: name,
+		File: jenkinsfile.PipelineConfigFileName,
+	}
+	pipelineConfig, err := jenkinsfile.LoadPipelineConfigWithOrigins(pipelineFile, p.resolver, true, false, skipYamlValidation, origin, origins)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load build pack pipeline YAML: %s", pipelineFile)
+	}
+	return pipelineConfig, nil
+}
+
+func (f *EffectivePipelineFlags) resolveBuildPack(commonOpts *opts.CommonOptions) (*effectiveBuildPack, error) {
+	var err error
+	dir := f.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	createTask := &StepCreateTaskOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+		Dir:              dir,
+		Context:          f.Context,
+		Pack:             f.Pack,
+		BuildPackURL:     f.BuildPackURL,
+		BuildPackRef:     f.BuildPackRef,
+		PipelineKind:     f.PipelineKind,
+		SourceName:       "source",
+		DefaultImage:     defaultContainerImage,
+		BuildNumber:      "1",
+		NoReleasePrepare: true,
+		NoKaniko:         true,
+		ViewSteps:        true,
+	}
+
+	createTask.GitInfo, err = commonOpts.FindGitInfo(dir)
+	if err != nil {
+		log.Warnf("could not find the git repository for %s: %s\n", dir, err)
+	}
+	createTask.Branch, err = commonOpts.Git().Branch(dir)
+	if err != nil {
+		createTask.Branch = "master"
+	}
+
+	projectConfig, projectConfigFile, err := createTask.loadProjectConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load project config in dir %s", dir)
+	}
+	if createTask.BuildPackURL == "" {
+		createTask.BuildPackURL = projectConfig.BuildPackGitURL
+		if createTask.BuildPackURL == "" {
+			createTask.BuildPackURL = builds.KubernetesWorkloadBuildPackURL
+		}
+	}
+	if createTask.BuildPackRef == "" {
+		createTask.BuildPackRef = projectConfig.BuildPackGitURef
+		if createTask.BuildPackRef == "" {
+			createTask.BuildPackRef = builds.KubernetesWorkloadBuildPackRef
+		}
+	}
+	if createTask.Pack == "" {
+		createTask.Pack = projectConfig.BuildPack
+	}
+	if createTask.Pack == "" {
+		createTask.Pack, err = commonOpts.DiscoverBuildPack(dir, projectConfig, createTask.Pack)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to discover the build pack")
+		}
+	}
+
+	resolved, err := f.ResolveLockedBuildPack(commonOpts.Git(), projectConfigFile, createTask.BuildPackURL, createTask.BuildPackRef)
+	if err != nil {
+		return nil, err
+	}
+	return &effectiveBuildPack{
+		createTask:        createTask,
+		projectConfig:     projectConfig,
+		projectConfigFile: projectConfigFile,
+		packsDir:          resolved.PacksDir,
+		resolver:          resolved.Resolver,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/tekton/graph"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepSyntaxGraphLong = templates.LongDesc(`
+		Renders the effective pipeline for the current project as a graph of its stages and steps.
+
+		The graph can be written in the Graphviz DOT language or as a Mermaid flowchart. Nested and parallel stages
+		are drawn as groups. If a PipelineActivity file is specified, such as the one written by 'jx step syntax run',
+		the stages are coloured by their status.
+`)
+
+	stepSyntaxGraphExample = templates.Examples(`
+		# renders the pull request pipeline for the current directory as a PNG using Graphviz
+		jx step syntax graph | dot -Tpng > pipeline.png
+
+		# renders the release pipeline as a Mermaid flowchart
+		jx step syntax graph --kind release --format mermaid
+
+		# colours the graph with the result of a local run
+		jx step syntax run --activity-file activity.yml
+		jx step syntax graph --activity-file activity.yml
+			`)
+)
+
+// StepSyntaxGraphOptions contains the command line flags
+type StepSyntaxGraphOptions struct {
+	StepOptions
+	EffectivePipelineFlags
+
+	Format       string
+	OutputFile   string
+	ActivityFile string
+}
+
+// NewCmdStepSyntaxGraph Creates a new Command object
+func NewCmdStepSyntaxGraph(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSyntaxGraphOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "graph",
+		Short:   "Renders the effective pipeline of a project as a DOT or Mermaid graph",
+		Long:    stepSyntaxGraphLong,
+		Example: stepSyntaxGraphExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.EffectivePipelineFlags.AddFlags(cmd)
+	cmd.Flags().StringVarP(&options.Format, "format", "f", graph.FormatDOT, "The graph format, one of: "+strings.Join(graph.Formats, ", "))
+	cmd.Flags().StringVarP(&options.OutputFile, "output-file", "", "", "The file to write the graph to. Defaults to the console")
+	cmd.Flags().StringVarP(&options.ActivityFile, "activity-file", "", "", "A PipelineActivity YAML file used to colour the stages by their status")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSyntaxGraphOptions) Run() error {
+	var err error
+	if o.Dir == "" {
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	parsed, _, err := o.LoadEffectivePipeline(o.CommonOptions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the effective pipeline for %s", o.Dir)
+	}
+
+	g := graph.FromParsedPipeline(filepath.Base(o.Dir)+"-"+o.PipelineKind, parsed)
+	if o.ActivityFile != "" {
+		data, err := ioutil.ReadFile(o.ActivityFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", o.ActivityFile)
+		}
+		activity := &v1.PipelineActivity{}
+		err = yaml.Unmarshal(data, activity)
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmarshal file %s", o.ActivityFile)
+		}
+		g.SetStatus(activity)
+	}
+	return writeGraph(o.Out, g, o.Format, o.OutputFile)
+}
+
+// writeGraph writes the graph to the output file, or the console if there is no output file
+func writeGraph(console io.Writer, g *graph.Graph, format string, outputFile string) error {
+	out := console
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create output file %s", outputFile)
+		}
+		defer file.Close()
+		out = file
+	}
+	err := g.Write(out, format)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the pipeline graph")
+	}
+	return nil
+}
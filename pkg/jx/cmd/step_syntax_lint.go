@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton/lint"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultLintConfigFileName the name of the optional linter configuration file in a project
+	defaultLintConfigFileName = "jx-lint.yml"
+)
+
+var (
+	stepSyntaxLintLong = templates.LongDesc(`
+		Lints the pipeline YAML in the current directory, or a build pack pipeline, against a set of named rules.
+
+		Rules can be disabled or have their severity changed in a jx-lint.yml file next to the pipeline YAML:
+
+		    rules:
+		      unused-env:
+		        disabled: true
+		      image-tag:
+		        severity: error
+		    maxTimeout: 2h
+		    podTemplates:
+		    - maven
+
+		The names in podTemplates are pod templates rather than images so they are not reported by the image-tag rule.
+
+		The available rules are:
+` + lintRulesDescription())
+
+	stepSyntaxLintExample = templates.Examples(`
+		# lints the jenkins-x.yml in the current directory
+		jx step syntax lint
+
+		# lints a build pack, writing SARIF output so it can annotate a pull request
+		jx step syntax lint --pack-file packs/maven/pipeline.yaml --format sarif --output-file lint.sarif
+
+		# lints the jenkins-x-bdd.yml file ignoring unused env vars
+		jx step syntax lint --context bdd --disable unused-env
+			`)
+)
+
+// StepSyntaxLintOptions contains the command line flags
+type StepSyntaxLintOptions struct {
+	StepOptions
+	EffectivePipelineFlags
+
+	PackFile   string
+	ConfigFile string
+	Format     string
+	OutputFile string
+	Disable    []string
+	NoParent   bool
+}
+
+// NewCmdStepSyntaxLint Creates a new Command object
+func NewCmdStepSyntaxLint(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSyntaxLintOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   "Lints a pipeline YAML file or build pack against a set of rules",
+		Long:    stepSyntaxLintLong,
+		Example: stepSyntaxLintExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory containing the pipeline YAML file")
+	cmd.Flags().StringVarP(&options.Context, "context", "c", "", "The context for the pipeline YAML to lint instead of the default")
+	cmd.Flags().StringVarP(&options.Pack, "pack", "p", "", "The build pack name the project inherits from, used to check overrides. If none is specified its discovered from the source code")
+	cmd.Flags().StringVarP(&options.BuildPackURL, "url", "u", "", "The URL for the build pack Git repository used to check overrides")
+	cmd.Flags().StringVarP(&options.BuildPackRef, "ref", "r", "", "The Git reference (branch,tag,sha) in the build pack Git repository")
+	cmd.Flags().StringVarP(&options.PackFile, "pack-file", "", "", "Lint this build pack pipeline.yaml file rather than the project pipeline YAML")
+	cmd.Flags().StringVarP(&options.ConfigFile, "config", "", "", "The linter configuration file. Defaults to "+defaultLintConfigFileName+" in the directory if it exists")
+	cmd.Flags().StringVarP(&options.Format, "format", "f", lint.FormatText, "The output format, one of: "+strings.Join(lint.Formats, ", "))
+	cmd.Flags().StringVarP(&options.OutputFile, "output-file", "", "", "The file to write the findings to. Defaults to the console")
+	cmd.Flags().StringArrayVarP(&options.Disable, "disable", "", nil, "The IDs of rules to disable")
+	cmd.Flags().BoolVarP(&options.NoParent, "no-parent", "", false, "Do not load the parent build pack, so overrides are not checked")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSyntaxLintOptions) Run() error {
+	var err error
+	if o.Dir == "" {
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	linter, err := o.createLinter()
+	if err != nil {
+		return err
+	}
+
+	var findings []lint.Finding
+	if o.PackFile != "" {
+		findings, err = o.lintPackFile(linter)
+	} else {
+		findings, err = o.lintProject(linter)
+	}
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = o.Out
+	if o.OutputFile != "" {
+		file, err := os.Create(o.OutputFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create output file %s", o.OutputFile)
+		}
+		defer file.Close()
+		out = file
+	}
+	err = lint.Write(out, o.Format, findings, linter.EnabledRules())
+	if err != nil {
+		return errors.Wrap(err, "failed to write the lint findings")
+	}
+
+	if lint.HasErrors(findings) {
+		return errors.New("the pipeline has lint errors")
+	}
+	if o.OutputFile != "" || o.Format == lint.FormatText || o.Format == "" {
+		log.Successf("Linted with %d findings", len(findings))
+	}
+	return nil
+}
+
+func (o *StepSyntaxLintOptions) createLinter() (*lint.Linter, error) {
+	configFile := o.ConfigFile
+	if configFile == "" {
+		configFile = filepath.Join(o.Dir, defaultLintConfigFileName)
+	}
+	lintConfig, err := lint.LoadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range o.Disable {
+		if lintConfig.Rules == nil {
+			lintConfig.Rules = map[string]lint.RuleConfig{}
+		}
+		ruleConfig := lintConfig.Rules[id]
+		ruleConfig.Disabled = true
+		lintConfig.Rules[id] = ruleConfig
+	}
+	return lint.NewLinter(lintConfig)
+}
+
+func (o *StepSyntaxLintOptions) lintProject(linter *lint.Linter) ([]lint.Finding, error) {
+	pipelineFileName := config.ProjectConfigFileName
+	if o.Context != "" {
+		pipelineFileName = fmt.Sprintf("jenkins-x-%s.yml", o.Context)
+	}
+	pipelineFile := filepath.Join(o.Dir, pipelineFileName)
+	exists, err := util.FileExists(pipelineFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading pipeline file %s", pipelineFile)
+	}
+	if !exists {
+		return nil, fmt.Errorf("pipeline file %s does not exist or is not a file", pipelineFile)
+	}
+	data, err := ioutil.ReadFile(pipelineFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", pipelineFile)
+	}
+	projectConfig, err := config.LoadProjectConfigFile(pipelineFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load pipeline file %s", pipelineFile)
+	}
+	if projectConfig.PipelineConfig == nil {
+		log.Infof("No pipelineConfig defined in %s\n", pipelineFile)
+		return nil, nil
+	}
+
+	var parent *jenkinsfile.PipelineConfig
+	if !o.NoParent && len(projectConfig.PipelineConfig.Pipelines.Overrides) > 0 {
+		parent, err = o.LoadBuildPackPipelineConfig(o.CommonOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the parent build pack to check overrides")
+		}
+	}
+
+	findings := linter.LintPipelineConfig("pipelineConfig", projectConfig.PipelineConfig, parent)
+	return o.withFile(findings, pipelineFile, data)
+}
+
+func (o *StepSyntaxLintOptions) lintPackFile(linter *lint.Linter) ([]lint.Finding, error) {
+	data, err := ioutil.ReadFile(o.PackFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", o.PackFile)
+	}
+	packConfig := &jenkinsfile.PipelineConfig{}
+	err = yaml.Unmarshal(data, packConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal file %s", o.PackFile)
+	}
+
+	var parent *jenkinsfile.PipelineConfig
+	extends := packConfig.Extends
+	if !o.NoParent && extends != nil && extends.File != "" {
+		if extends.Import != "" {
+			log.Warnf("not checking overrides as the parent of %s is imported from module %s\n", o.PackFile, extends.Import)
+		} else {
+			parentFile := extends.File
+			if !filepath.IsAbs(parentFile) {
+				parentFile = filepath.Join(filepath.Dir(o.PackFile), parentFile)
+			}
+			noImports := func(importFile *jenkinsfile.ImportFile) (string, error) {
+				return "", fmt.Errorf("cannot resolve the import of %s from module %s when linting a build pack file", importFile.File, importFile.Import)
+			}
+			parent, err = jenkinsfile.LoadPipelineConfig(parentFile, noImports, true, false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to load the parent pipeline %s", parentFile)
+			}
+		}
+	}
+
+	findings := linter.LintPipelineConfig("", packConfig, parent)
+	return o.withFile(findings, o.PackFile, data)
+}
+
+// withFile sets the file of the findings relative to the root of the git repository, or the directory if the file is
+// not in one, along with the line of each finding in the file contents
+func (o *StepSyntaxLintOptions) withFile(findings []lint.Finding, file string, data []byte) ([]lint.Finding, error) {
+	err := lint.AddLines(data, findings)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the lines of the findings in %s", file)
+	}
+	path := file
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	root, _, err := o.Git().FindGitConfigDir(filepath.Dir(absFile))
+	if err != nil || root == "" {
+		root, err = filepath.Abs(o.Dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rel, err := filepath.Rel(root, absFile); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	for i := range findings {
+		findings[i].File = path
+	}
+	return findings, nil
+}
+
+func lintRulesDescription() string {
+	var lines []string
+	for _, r := range lint.Rules {
+		lines = append(lines, fmt.Sprintf("    * %s (%s): %s", r.ID, r.Severity, r.Description))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	stepSyntaxRunLong = templates.LongDesc(`
+		Runs the effective pipeline for the current project locally without a cluster.
+
+		The pipeline is loaded from the jenkins-x.yml after applying build pack inheritance. The source code is copied
+		into a scratch workspace and the command and args of each step are run as local processes, with the same env var
+		and dir semantics as the generated Tekton Tasks. Parallel stages are run concurrently.
+
+		Images and container options are ignored, so any tools the steps use need to be installed locally.
+`)
+
+	stepSyntaxRunExample = templates.Examples(`
+		# runs the pull request pipeline for the current directory
+		jx step syntax run
+
+		# runs only the stage called 'build' of the release pipeline
+		jx step syntax run --kind release --stage build
+
+		# runs the pipeline in the given workspace directory, writing the activity summary to a file
+		jx step syntax run --workspace /tmp/ws --activity-file activity.yml
+			`)
+)
+
+// StepSyntaxRunOptions contains the command line flags
+type StepSyntaxRunOptions struct {
+	StepOptions
+	EffectivePipelineFlags
+
+	Workspace    string
+	Stage        string
+	Sequential   bool
+	NoCopy       bool
+	ActivityFile string
+	Version      string
+}
+
+// NewCmdStepSyntaxRun Creates a new Command object
+func NewCmdStepSyntaxRun(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSyntaxRunOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "run",
+		Short:   "Runs the effective pipeline of a project locally without a cluster",
+		Long:    stepSyntaxRunLong,
+		Example: stepSyntaxRunExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.EffectivePipelineFlags.AddFlags(cmd)
+	cmd.Flags().StringVarP(&options.Workspace, "workspace", "w", "", "The scratch workspace directory to run the pipeline in. Defaults to a new temporary directory")
+	cmd.Flags().StringVarP(&options.Stage, "stage", "s", "", "Only run the stage with this name, including any nested stages")
+	cmd.Flags().BoolVarP(&options.Sequential, "sequential", "", false, "Run parallel stages one after the other rather than concurrently")
+	cmd.Flags().BoolVarP(&options.NoCopy, "no-copy", "", false, "Do not copy the source code into the workspace")
+	cmd.Flags().StringVarP(&options.ActivityFile, "activity-file", "", "", "The file to write the PipelineActivity summary to. Defaults to pipeline-activity.yml in the workspace")
+	cmd.Flags().StringVarP(&options.Version, "version", "", "", "The version passed to the pipeline. Defaults to the VERSION file or a snapshot version")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSyntaxRunOptions) Run() error {
+	var err error
+	if o.Dir == "" {
+		o.Dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	parsed, _, err := o.LoadEffectivePipeline(o.CommonOptions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the effective pipeline for %s", o.Dir)
+	}
+
+	if o.Workspace == "" {
+		o.Workspace, err = ioutil.TempDir("", "jx-syntax-run-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create the scratch workspace")
+		}
+	}
+	sourceDir := filepath.Join(o.Workspace, "source")
+	if !o.NoCopy {
+		err = util.CopyDir(o.Dir, sourceDir, true)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy %s to the workspace %s", o.Dir, sourceDir)
+		}
+	}
+
+	version := o.Version
+	if version == "" {
+		version, err = getVersionFromFile(o.Dir)
+		if err != nil {
+			version = "0.0.0-SNAPSHOT-local"
+		}
+	}
+
+	log.Infof("Running the %s pipeline in workspace %s\n", util.ColorInfo(o.PipelineKind), util.ColorInfo(o.Workspace))
+	runner := &syntax.LocalRunner{
+		Workspace:  o.Workspace,
+		SourceDir:  "source",
+		Stage:      o.Stage,
+		Sequential: o.Sequential,
+		Params: map[string]string{
+			"version":  version,
+			"build_id": "1",
+		},
+		Out: o.Out,
+	}
+	activity, runErr := runner.Run(parsed)
+	if activity == nil {
+		return runErr
+	}
+
+	activity.TypeMeta = metav1.TypeMeta{
+		APIVersion: "jenkins.io/v1",
+		Kind:       "PipelineActivity",
+	}
+	activity.Name = "local-" + o.PipelineKind
+	activity.Spec.Pipeline = o.PipelineKind
+	activity.Spec.Build = "1"
+	activity.Spec.Version = version
+
+	activityFile := o.ActivityFile
+	if activityFile == "" {
+		activityFile = filepath.Join(o.Workspace, "pipeline-activity.yml")
+	}
+	data, err := yaml.Marshal(activity)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the PipelineActivity")
+	}
+	err = ioutil.WriteFile(activityFile, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the PipelineActivity to %s", activityFile)
+	}
+	log.Infof("Wrote the PipelineActivity summary to %s\n", util.ColorInfo(activityFile))
+
+	if runErr != nil {
+		return runErr
+	}
+	log.Successf("Pipeline %s succeeded", o.PipelineKind)
+	return nil
+}
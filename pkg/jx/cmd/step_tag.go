@@ -32,7 +32,8 @@ const (
 type StepTagOptions struct {
 	StepOptions
 
-	Flags StepTagFlags
+	Flags   StepTagFlags
+	Signing GitSigningFlags
 }
 
 type StepTagFlags struct {
@@ -55,12 +56,20 @@ var (
 		git tag -fa v$(VERSION) -m "Release version $(VERSION)"
 		git push origin v$(VERSION)
 
+		With --sign the commit and tag are signed with the pipeline's GPG credentials or the --signing-key.
+
 `)
 
 	stepTagExample = templates.Examples(`
 
 		jx step tag --version 1.0.0
 
+		# sign the release commit and tag with the pipeline's GPG credentials
+		jx step tag --version 1.0.0 --sign
+
+		# sign the release commit and tag with an SSH key
+		jx step tag --version 1.0.0 --sign --signing-format ssh --signing-key ~/.ssh/id_ed25519
+
 `)
 )
 
@@ -91,6 +100,7 @@ func NewCmdStepTag(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Flags.ChartValueRepository, "charts-value-repository", "r", "", "the fully qualified image name without the version tag. e.g. 'dockerregistry/myorg/myapp'")
 
 	cmd.Flags().BoolVarP(&options.Flags.NoApply, "no-apply", "", false, "Do not push the tag to the server, this is used for example in dry runs")
+	options.Signing.AddFlags(cmd)
 
 	return cmd
 }
@@ -142,6 +152,17 @@ func (o *StepTagOptions) Run() error {
 
 	tag := "v" + o.Flags.Version
 
+	signing, err := o.Signing.GitSigning(o.CommonOptions)
+	if err != nil {
+		return err
+	}
+	if signing != nil {
+		err = o.Git().ConfigureSigning("", signing)
+		if err != nil {
+			return err
+		}
+	}
+
 	if o.Verbose {
 		log.Infof("performing git commit\n")
 	}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/jx/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	stepWaitForApprovalLong = templates.LongDesc(`
+		Waits for the approval stage of a pipeline to be approved or rejected.
+
+		This step is generated for each approval stage of a pipeline so it is not usually invoked directly. It moves the
+		stage of the PipelineActivity into the WaitingForApproval state and then waits until a user approves or rejects
+		it with 'jx step approve' or 'jx step reject'. If nobody does so before the timeout the default outcome is used.
+		The step fails if the stage is rejected which stops the pipeline.
+
+		Note that the timeout of the PipelineRun still applies so approvals cannot wait for longer than the pipeline.
+`)
+
+	stepWaitForApprovalExample = templates.Examples(`
+		# waits up to an hour for the production deployment to be approved
+		jx step wait-for-approval --stage "Approve production" --environment production --timeout 1h
+			`)
+)
+
+// StepWaitForApprovalOptions contains the command line flags
+type StepWaitForApprovalOptions struct {
+	StepOptions
+
+	Activity       string
+	Stage          string
+	Message        string
+	Environment    string
+	Roles          []string
+	Timeout        time.Duration
+	DefaultOutcome string
+	PollTime       time.Duration
+}
+
+// NewCmdStepWaitForApproval Creates a new Command object
+func NewCmdStepWaitForApproval(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepWaitForApprovalOptions{
+		StepOptions: StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "wait-for-approval",
+		Short:   "Waits for the approval stage of a pipeline to be approved or rejected",
+		Long:    stepWaitForApprovalLong,
+		Example: stepWaitForApprovalExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Activity, "activity", "a", "", "The name of the PipelineActivity. Defaults to the activity of the current build")
+	cmd.Flags().StringVarP(&options.Stage, "stage", "s", "", "The name of the approval stage in the PipelineActivity")
+	cmd.Flags().StringVarP(&options.Message, "message", "m", "", "The message shown to the approvers")
+	cmd.Flags().StringVarP(&options.Environment, "environment", "e", "", "The environment whose EnvironmentRoleBindings determine who can approve")
+	cmd.Flags().StringArrayVarP(&options.Roles, "role", "r", nil, "Restricts the approvers to the users bound to one of these roles")
+	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "t", time.Hour, "How long to wait before using the default outcome")
+	cmd.Flags().StringVarP(&options.DefaultOutcome, "default-outcome", "", string(syntax.ApprovalOutcomeReject), "The outcome if nobody approves or rejects before the timeout. One of: approve, reject")
+	cmd.Flags().DurationVarP(&options.PollTime, "poll-time", "", 10*time.Second, "How often to check whether the stage has been approved or rejected")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepWaitForApprovalOptions) Run() error {
+	if o.Stage == "" {
+		return util.MissingOption("stage")
+	}
+	defaultOutcome, err := toApprovalOutcomeType(o.DefaultOutcome)
+	if err != nil {
+		return err
+	}
+	if o.Activity == "" {
+		o.Activity, err = currentActivityName()
+		if err != nil {
+			return err
+		}
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	_, err = kube.ModifyApprovalStage(jxClient, ns, o.Activity, o.Stage, func(activity *v1.PipelineActivity, stage *v1.StageActivityStep) error {
+		if stage.Approval == nil {
+			now := metav1.Now()
+			deadline := metav1.NewTime(now.Add(o.Timeout))
+			stage.Approval = &v1.ApprovalActivityStep{
+				Message:            o.Message,
+				Environment:        o.Environment,
+				Roles:              o.Roles,
+				DefaultOutcome:     defaultOutcome,
+				RequestedTimestamp: &now,
+				DeadlineTimestamp:  &deadline,
+			}
+		}
+		if stage.Approval.IsPending() {
+			stage.Status = v1.ActivityStatusTypeWaitingForApproval
+			activity.Spec.Status = v1.ActivityStatusTypeWaitingForApproval
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to request the approval of stage %s", o.Stage)
+	}
+
+	log.Infof("Waiting for stage %s of %s to be approved or rejected for up to %s\n", util.ColorInfo(o.Stage), util.ColorInfo(o.Activity), o.Timeout)
+	log.Infof("To approve it run: %s\n", util.ColorInfo(fmt.Sprintf("jx step approve %s --stage \"%s\"", o.Activity, o.Stage)))
+
+	for {
+		activity, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(o.Activity, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get PipelineActivity %s in namespace %s", o.Activity, ns)
+		}
+		stage, err := kube.FindApprovalStage(activity, o.Stage)
+		if err != nil {
+			return err
+		}
+		approval := stage.Approval
+		if !approval.IsPending() {
+			return approvalResult(o.Stage, approval)
+		}
+		if approval.DeadlineTimestamp != nil && time.Now().After(approval.DeadlineTimestamp.Time) {
+			log.Warnf("Nobody approved or rejected stage %s in time so using the default outcome: %s\n", o.Stage, approval.DefaultOutcome)
+			activity, err = kube.ModifyApprovalStage(jxClient, ns, o.Activity, o.Stage, func(activity *v1.PipelineActivity, stage *v1.StageActivityStep) error {
+				if stage.Approval.IsPending() {
+					now := metav1.Now()
+					stage.Approval.Outcome = stage.Approval.DefaultOutcome
+					stage.Approval.DecidedTimestamp = &now
+					stage.Approval.Comment = "timed out"
+				}
+				return nil
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to use the default outcome for stage %s", o.Stage)
+			}
+			stage, err = kube.FindApprovalStage(activity, o.Stage)
+			if err != nil {
+				return err
+			}
+			return approvalResult(o.Stage, stage.Approval)
+		}
+		time.Sleep(o.PollTime)
+	}
+}
+
+// approvalResult returns an error if the approval was rejected so that the approval step fails
+func approvalResult(stageName string, approval *v1.ApprovalActivityStep) error {
+	decidedBy := approval.DecidedBy
+	if decidedBy == "" {
+		decidedBy = "default"
+	}
+	if approval.Outcome == v1.ApprovalOutcomeTypeApproved {
+		log.Infof("Stage %s was approved by %s\n", util.ColorInfo(stageName), util.ColorInfo(decidedBy))
+		return nil
+	}
+	message := fmt.Sprintf("stage %s was rejected by %s", stageName, decidedBy)
+	if approval.Comment != "" {
+		message += ": " + approval.Comment
+	}
+	return errors.New(message)
+}
+
+// toApprovalOutcomeType converts an approval outcome of the pipeline syntax to the outcome of a PipelineActivity
+func toApprovalOutcomeType(outcome string) (v1.ApprovalOutcomeType, error) {
+	switch syntax.ApprovalOutcome(strings.ToLower(outcome)) {
+	case syntax.ApprovalOutcomeApprove:
+		return v1.ApprovalOutcomeTypeApproved, nil
+	case syntax.ApprovalOutcomeReject:
+		return v1.ApprovalOutcomeTypeRejected, nil
+	}
+	return v1.ApprovalOutcomeTypeNone, util.InvalidOption("default-outcome", outcome, []string{string(syntax.ApprovalOutcomeApprove), string(syntax.ApprovalOutcomeReject)})
+}
+
+// currentActivityName returns the name of the PipelineActivity of the current build using the env vars of the
+// build pod
+func currentActivityName() (string, error) {
+	var values []string
+	for _, name := range []string{"REPO_OWNER", "REPO_NAME", "BRANCH_NAME", "BUILD_NUMBER"} {
+		value := os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("no $%s so cannot find the PipelineActivity of the current build. Please specify it with --activity", name)
+		}
+		values = append(values, value)
+	}
+	return kube.ToValidName(strings.Join(values, "-")), nil
+}
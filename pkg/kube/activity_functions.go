@@ -152,3 +152,31 @@ func FailedPromotionUpdate(a *v1.PipelineActivity, s *v1.PipelineActivityStep, p
 	p.Status = v1.ActivityStatusTypeFailed
 	return nil
 }
+
+// CancelActivity marks the activity and any of its stages and steps which have not completed as cancelled
+func CancelActivity(a *v1.PipelineActivity) {
+	now := &metav1.Time{
+		Time: time.Now(),
+	}
+	cancel := func(step *v1.CoreActivityStep) {
+		if step.Status.IsTerminated() || step.Status == v1.ActivityStatusTypeNotExecuted {
+			return
+		}
+		step.Status = v1.ActivityStatusTypeCancelled
+		if step.CompletedTimestamp == nil {
+			step.CompletedTimestamp = now
+		}
+	}
+	for _, s := range a.Spec.Steps {
+		if s.Stage != nil {
+			cancel(&s.Stage.CoreActivityStep)
+			for i := range s.Stage.Steps {
+				cancel(&s.Stage.Steps[i])
+			}
+		}
+	}
+	a.Spec.Status = v1.ActivityStatusTypeCancelled
+	if a.Spec.CompletedTimestamp == nil {
+		a.Spec.CompletedTimestamp = now
+	}
+}
@@ -0,0 +1,129 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxApprovalUpdateAttempts is how many times an approval is retried when the activity is updated concurrently
+const maxApprovalUpdateAttempts = 5
+
+// PendingApprovals returns the stages of the activity which are waiting for an approval
+func PendingApprovals(activity *v1.PipelineActivity) []*v1.StageActivityStep {
+	var answer []*v1.StageActivityStep
+	for i := range activity.Spec.Steps {
+		stage := activity.Spec.Steps[i].Stage
+		if stage != nil && stage.Approval != nil && stage.Approval.IsPending() && !stage.Status.IsTerminated() {
+			answer = append(answer, stage)
+		}
+	}
+	return answer
+}
+
+// FindApprovalStage returns the approval stage with the given name, or the only approval stage of the activity if
+// no name is given
+func FindApprovalStage(activity *v1.PipelineActivity, stageName string) (*v1.StageActivityStep, error) {
+	var stages []*v1.StageActivityStep
+	for i := range activity.Spec.Steps {
+		stage := activity.Spec.Steps[i].Stage
+		if stage != nil && stage.Approval != nil && (stageName == "" || stage.Name == stageName) {
+			stages = append(stages, stage)
+		}
+	}
+	switch len(stages) {
+	case 0:
+		if stageName != "" {
+			return nil, fmt.Errorf("PipelineActivity %s has no approval stage %s", activity.Name, stageName)
+		}
+		return nil, fmt.Errorf("PipelineActivity %s has no approval stages", activity.Name)
+	case 1:
+		return stages[0], nil
+	}
+	pending := PendingApprovals(activity)
+	if len(pending) == 1 {
+		return pending[0], nil
+	}
+	return nil, fmt.Errorf("PipelineActivity %s has %d approval stages so please specify the stage", activity.Name, len(stages))
+}
+
+// IsApprover returns true if the user is a subject of an EnvironmentRoleBinding which applies to the environment of
+// the approval and, if the approval specifies roles, binds one of those roles
+func IsApprover(jxClient versioned.Interface, ns string, adminNs string, userName string, approval *v1.ApprovalActivityStep) (bool, error) {
+	var env *v1.Environment
+	if approval.Environment != "" {
+		var err error
+		env, err = jxClient.JenkinsV1().Environments(ns).Get(approval.Environment, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to find the Environment %s in namespace %s", approval.Environment, ns)
+		}
+	}
+	envRoles, _, err := GetEnvironmentRoles(jxClient, ns)
+	if err != nil {
+		return false, err
+	}
+	for _, envRole := range envRoles {
+		if env != nil && !EnvironmentMatchesAny(env, envRole.Spec.Environments) {
+			continue
+		}
+		if len(approval.Roles) > 0 && util.StringArrayIndex(approval.Roles, envRole.Name) < 0 &&
+			util.StringArrayIndex(approval.Roles, envRole.Spec.RoleRef.Name) < 0 {
+			continue
+		}
+		for _, subject := range envRole.Spec.Subjects {
+			if subject.Kind == "User" && subject.Name == userName && (subject.Namespace == "" || subject.Namespace == adminNs) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ModifyApprovalStage applies the given function to the approval stage of an activity and updates the activity,
+// retrying if the activity is modified concurrently, such as by the build controller
+func ModifyApprovalStage(jxClient versioned.Interface, ns string, activityName string, stageName string, fn func(activity *v1.PipelineActivity, stage *v1.StageActivityStep) error) (*v1.PipelineActivity, error) {
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	for i := 0; ; i++ {
+		activity, err := activities.Get(activityName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get PipelineActivity %s in namespace %s", activityName, ns)
+		}
+		_, stage, _ := GetOrCreateStage(activity, stageName)
+		err = fn(activity, stage)
+		if err != nil {
+			return nil, err
+		}
+		answer, err := activities.Update(activity)
+		if err == nil {
+			return answer, nil
+		}
+		if !apierrors.IsConflict(err) || i >= maxApprovalUpdateAttempts {
+			return nil, errors.Wrapf(err, "failed to update PipelineActivity %s in namespace %s", activityName, ns)
+		}
+	}
+}
+
+// DecideApproval records the outcome of a pending approval. The approval stage step picks up the outcome and
+// completes the stage.
+func DecideApproval(jxClient versioned.Interface, ns string, activityName string, stageName string, outcome v1.ApprovalOutcomeType, userName string, comment string) (*v1.PipelineActivity, error) {
+	return ModifyApprovalStage(jxClient, ns, activityName, stageName, func(activity *v1.PipelineActivity, stage *v1.StageActivityStep) error {
+		approval := stage.Approval
+		if approval == nil {
+			return fmt.Errorf("stage %s of PipelineActivity %s is not waiting for an approval", stageName, activityName)
+		}
+		if !approval.IsPending() {
+			return fmt.Errorf("stage %s of PipelineActivity %s was already %s", stageName, activityName, approval.Outcome)
+		}
+		now := metav1.Now()
+		approval.Outcome = outcome
+		approval.DecidedBy = userName
+		approval.DecidedTimestamp = &now
+		approval.Comment = comment
+		return nil
+	})
+}
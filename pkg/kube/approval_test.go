@@ -0,0 +1,104 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsApprover(t *testing.T) {
+	t.Parallel()
+	binding := func(name string, role string, user string, envs ...string) *v1.EnvironmentRoleBinding {
+		return &v1.EnvironmentRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx"},
+			Spec: v1.EnvironmentRoleBindingSpec{
+				Subjects:     []rbacv1.Subject{{Kind: "User", Name: user, Namespace: "jx"}},
+				RoleRef:      rbacv1.RoleRef{Kind: "Role", Name: role},
+				Environments: []v1.EnvironmentFilter{{Includes: envs}},
+			},
+		}
+	}
+	jxClient := jxfake.NewSimpleClientset(
+		kube.NewPermanentEnvironment("staging"),
+		kube.NewPermanentEnvironment("production"),
+		binding("owners", "owner", "alice", "production"),
+		binding("viewers", "viewer", "bob", "*"),
+		binding("staging-owners", "owner", "carol", "staging"),
+	)
+
+	testCases := []struct {
+		user     string
+		approval v1.ApprovalActivityStep
+		expected bool
+	}{
+		{"alice", v1.ApprovalActivityStep{Environment: "production"}, true},
+		{"bob", v1.ApprovalActivityStep{Environment: "production"}, true},
+		{"carol", v1.ApprovalActivityStep{Environment: "production"}, false},
+		{"bob", v1.ApprovalActivityStep{Environment: "production", Roles: []string{"owner"}}, false},
+		{"alice", v1.ApprovalActivityStep{Environment: "production", Roles: []string{"owners"}}, true},
+		{"carol", v1.ApprovalActivityStep{Roles: []string{"owner"}}, true},
+		{"dave", v1.ApprovalActivityStep{}, false},
+	}
+	for _, tc := range testCases {
+		actual, err := kube.IsApprover(jxClient, "jx", "jx", tc.user, &tc.approval)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, actual, "user %s for approval %#v", tc.user, tc.approval)
+	}
+
+	_, err := kube.IsApprover(jxClient, "jx", "jx", "alice", &v1.ApprovalActivityStep{Environment: "missing"})
+	assert.Error(t, err)
+}
+
+func TestDecideApproval(t *testing.T) {
+	t.Parallel()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1", Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "Build", Status: v1.ActivityStatusTypeSucceeded},
+					},
+				},
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "Approve", Status: v1.ActivityStatusTypeWaitingForApproval},
+						Approval: &v1.ApprovalActivityStep{
+							Environment:    "production",
+							DefaultOutcome: v1.ApprovalOutcomeTypeRejected,
+						},
+					},
+				},
+			},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset(activity)
+
+	pending := kube.PendingApprovals(activity)
+	require.Len(t, pending, 1)
+	stage, err := kube.FindApprovalStage(activity, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Approve", stage.Name)
+	_, err = kube.FindApprovalStage(activity, "Build")
+	assert.Error(t, err)
+
+	updated, err := kube.DecideApproval(jxClient, "jx", activity.Name, "Approve", v1.ApprovalOutcomeTypeApproved, "alice", "looks good")
+	require.NoError(t, err)
+	approval := updated.Spec.Steps[1].Stage.Approval
+	assert.Equal(t, v1.ApprovalOutcomeTypeApproved, approval.Outcome)
+	assert.Equal(t, "alice", approval.DecidedBy)
+	assert.Equal(t, "looks good", approval.Comment)
+	assert.NotNil(t, approval.DecidedTimestamp)
+	assert.Empty(t, kube.PendingApprovals(updated))
+
+	_, err = kube.DecideApproval(jxClient, "jx", activity.Name, "Approve", v1.ApprovalOutcomeTypeRejected, "bob", "")
+	assert.Error(t, err, "an approval can only be decided once")
+}
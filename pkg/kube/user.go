@@ -0,0 +1,60 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// selfSubjectReviewVersions are the versions of the SelfSubjectReview API to try, newest first
+var selfSubjectReviewVersions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// selfSubjectReview is a SelfSubjectReview of the authentication.k8s.io API group, which is newer than the vendored
+// client-go
+type selfSubjectReview struct {
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Status     selfSubjectReviewStatus `json:"status,omitempty"`
+}
+
+type selfSubjectReviewStatus struct {
+	UserInfo authenticationv1.UserInfo `json:"userInfo,omitempty"`
+}
+
+// AuthenticatedUser returns the name of the user the Kubernetes API server authenticates the client as, using a
+// SelfSubjectReview. Unlike the operating system user or a command line flag the caller cannot choose the name, and
+// as the API server resolves the credentials it works for every kind of credential, including the exec and auth
+// provider plugins of a kube config, and for an impersonated user only if the caller may impersonate them.
+func AuthenticatedUser(kubeClient kubernetes.Interface) (string, error) {
+	restClient := kubeClient.AuthenticationV1().RESTClient()
+	for _, version := range selfSubjectReviewVersions {
+		body, err := json.Marshal(&selfSubjectReview{
+			APIVersion: authenticationv1.GroupName + "/" + version,
+			Kind:       "SelfSubjectReview",
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal the SelfSubjectReview")
+		}
+		data, err := restClient.Post().AbsPath("/apis", authenticationv1.GroupName, version, "selfsubjectreviews").Body(body).DoRaw()
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", errors.Wrapf(err, "failed to create a SelfSubjectReview %s", version)
+		}
+		review := &selfSubjectReview{}
+		err = json.Unmarshal(data, review)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal the SelfSubjectReview %s", version)
+		}
+		if review.Status.UserInfo.Username == "" {
+			return "", fmt.Errorf("the SelfSubjectReview %s has no user name", version)
+		}
+		return review.Status.UserInfo.Username, nil
+	}
+	return "", errors.New("the Kubernetes API server does not support SelfSubjectReviews so the authenticated user cannot be found")
+}
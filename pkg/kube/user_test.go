@@ -0,0 +1,47 @@
+package kube_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestAuthenticatedUser(t *testing.T) {
+	t.Parallel()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Method != http.MethodPost || r.URL.Path != "/apis/authentication.k8s.io/v1beta1/selfsubjectreviews" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer valid-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"apiVersion":"authentication.k8s.io/v1beta1","kind":"SelfSubjectReview","status":{"userInfo":{"username":"jstrachan"}}}`))
+	}))
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL, BearerToken: "valid-token"})
+	require.NoError(t, err)
+	user, err := kube.AuthenticatedUser(kubeClient)
+	require.NoError(t, err)
+	assert.Equal(t, "jstrachan", user)
+	assert.Equal(t, []string{
+		"/apis/authentication.k8s.io/v1/selfsubjectreviews",
+		"/apis/authentication.k8s.io/v1beta1/selfsubjectreviews",
+	}, paths, "the versions of the API are tried until one is supported")
+
+	kubeClient, err = kubernetes.NewForConfig(&rest.Config{Host: server.URL, BearerToken: "forged-token"})
+	require.NoError(t, err)
+	_, err = kube.AuthenticatedUser(kubeClient)
+	assert.Error(t, err)
+}
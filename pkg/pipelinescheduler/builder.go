@@ -71,6 +71,14 @@ func Build(schedulers []*jenkinsv1.SchedulerSpec) (*jenkinsv1.SchedulerSpec, err
 			} else if parent.Merger != nil {
 				applyToMerger(parent.Merger, answer.Merger)
 			}
+			if answer.Periodics == nil {
+				answer.Periodics = parent.Periodics
+			} else if !answer.Periodics.Replace && parent.Periodics != nil {
+				err := applyToPeriodics(parent.Periodics, answer.Periodics)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
 		}
 	}
 	return answer, nil
@@ -403,6 +411,52 @@ func applyToPostSubmits(parentPostsubmits *jenkinsv1.Postsubmits, childPostsubmi
 	return nil
 }
 
+func applyToPeriodics(parentPeriodics *jenkinsv1.Periodics, childPeriodics *jenkinsv1.Periodics) error {
+	if childPeriodics.Items == nil {
+		childPeriodics.Items = make([]*jenkinsv1.Periodic, 0)
+	}
+	// Work through each of the periodics in the parent. If we can find a name based match in child,
+	// we apply it to the child, otherwise we append it
+	for _, parent := range parentPeriodics.Items {
+		var found []*jenkinsv1.Periodic
+		for _, periodic := range childPeriodics.Items {
+			if periodic.Name != nil && parent.Name != nil && *periodic.Name == *parent.Name {
+				found = append(found, periodic)
+			}
+		}
+		if len(found) > 1 {
+			return errors.Errorf("more than one periodic with name %v in %s", *parent.Name, spew.Sdump(childPeriodics))
+		} else if len(found) == 1 {
+			child := found[0]
+			applyToJobBase(parent.JobBase, child.JobBase)
+			if child.Cron == nil {
+				child.Cron = parent.Cron
+			}
+			if child.Branch == nil {
+				child.Branch = parent.Branch
+			}
+			if child.Context == nil {
+				child.Context = parent.Context
+			}
+			if child.Parameters == nil {
+				child.Parameters = parent.Parameters
+			} else if !child.Parameters.Replace && parent.Parameters != nil {
+				if child.Parameters.Items == nil {
+					child.Parameters.Items = make(map[string]string)
+				}
+				for pk, pv := range parent.Parameters.Items {
+					if _, ok := child.Parameters.Items[pk]; !ok {
+						child.Parameters.Items[pk] = pv
+					}
+				}
+			}
+		} else {
+			childPeriodics.Items = append(childPeriodics.Items, parent)
+		}
+	}
+	return nil
+}
+
 func applyToPreSubmits(parentPresubmits *jenkinsv1.Presubmits, childPresubmits *jenkinsv1.Presubmits) error {
 	if childPresubmits.Items == nil {
 		childPresubmits.Items = make([]*jenkinsv1.Presubmit, 0)
@@ -386,3 +386,47 @@ func TestPreSubmitMultipleChildrenWithSameNameFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "more than one presubmit with name ")
 	assert.Nil(t, merged)
 }
+
+func TestPeriodicWithEmptyChildPeriodic(t *testing.T) {
+	t.Parallel()
+	child := testhelpers.CompleteScheduler()
+	parent := testhelpers.CompleteScheduler()
+	child.Periodics = &v1.Periodics{
+		Items: []*v1.Periodic{
+			{
+				JobBase: &v1.JobBase{
+					Name: parent.Periodics.Items[0].Name,
+				},
+				Parameters: &v1.ReplaceableMapOfStringString{
+					Items: map[string]string{"SOAK_DURATION": "1h"},
+				},
+			},
+		},
+	}
+
+	merged, err := pipelinescheduler.Build([]*v1.SchedulerSpec{parent, child})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(merged.Periodics.Items))
+	periodic := merged.Periodics.Items[0]
+	assert.Equal(t, parent.Periodics.Items[0].Cron, periodic.Cron)
+	assert.Equal(t, parent.Periodics.Items[0].Branch, periodic.Branch)
+	assert.Equal(t, parent.Periodics.Items[0].Context, periodic.Context)
+	assert.Equal(t, "1h", periodic.Parameters.Items["SOAK_DURATION"])
+	assert.Equal(t, len(parent.Periodics.Items[0].Parameters.Items)+1, len(periodic.Parameters.Items))
+}
+
+func TestPeriodicAppendedFromParent(t *testing.T) {
+	t.Parallel()
+	child := testhelpers.CompleteScheduler()
+	parent := testhelpers.CompleteScheduler()
+
+	merged, err := pipelinescheduler.Build([]*v1.SchedulerSpec{parent, child})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(merged.Periodics.Items))
+
+	child = testhelpers.CompleteScheduler()
+	child.Periodics.Replace = true
+	merged, err = pipelinescheduler.Build([]*v1.SchedulerSpec{parent, child})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(merged.Periodics.Items))
+}
@@ -0,0 +1,46 @@
+package pipelinescheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+	// a Friday
+	now := time.Date(2019, 5, 10, 13, 37, 20, 0, time.UTC)
+	testCases := map[string]time.Time{
+		"* * * * *":          time.Date(2019, 5, 10, 13, 38, 0, 0, time.UTC),
+		"*/15 * * * *":       time.Date(2019, 5, 10, 13, 45, 0, 0, time.UTC),
+		"5/20 * * * *":       time.Date(2019, 5, 10, 13, 45, 0, 0, time.UTC),
+		"0 2 * * *":          time.Date(2019, 5, 11, 2, 0, 0, 0, time.UTC),
+		"@daily":             time.Date(2019, 5, 11, 0, 0, 0, 0, time.UTC),
+		"@weekly":            time.Date(2019, 5, 12, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":          time.Date(2019, 5, 12, 0, 0, 0, 0, time.UTC),
+		"0 9 * * mon-fri":    time.Date(2019, 5, 13, 9, 0, 0, 0, time.UTC),
+		"30 4 1,15 * 5":      time.Date(2019, 5, 15, 4, 30, 0, 0, time.UTC),
+		"0 12 * jan,jun sun": time.Date(2019, 6, 2, 12, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":         time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+	}
+	for expression, expected := range testCases {
+		schedule, err := pipelinescheduler.ParseCron(expression)
+		require.NoError(t, err, expression)
+		assert.Equal(t, expected, schedule.Next(now), expression)
+	}
+
+	schedule, err := pipelinescheduler.ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(now).IsZero(), "February 30th never happens")
+}
+
+func TestParseCronErrors(t *testing.T) {
+	t.Parallel()
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "x * * * *", "0 0 0 * *"} {
+		_, err := pipelinescheduler.ParseCron(expression)
+		assert.Error(t, err, expression)
+	}
+}
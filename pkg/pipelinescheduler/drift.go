@@ -0,0 +1,318 @@
+package pipelinescheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+const (
+	// DriftEventReasonDrifted is the reason of the event recorded when the live Prow configuration has drifted
+	DriftEventReasonDrifted = "ProwConfigDrifted"
+	// DriftEventReasonRestored is the reason of the event recorded when the generated Prow configuration is restored
+	DriftEventReasonRestored = "ProwConfigRestored"
+
+	driftEventComponent = "jx-scheduler-drift"
+)
+
+// SettingDifference is a setting whose live value differs from the value generated from the schedulers
+type SettingDifference struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// RepositoryDrift describes how the live Prow configuration of a repository differs from the configuration generated
+// from its schedulers
+type RepositoryDrift struct {
+	Org  string
+	Repo string
+	// Missing is true if the live configuration does not configure the repository
+	Missing bool
+	// Unexpected is true if the live configuration configures a repository which has no scheduler
+	Unexpected  bool
+	Differences []*SettingDifference
+}
+
+// String returns a one line description of the drift of the repository
+func (d *RepositoryDrift) String() string {
+	name := orgSlashRepo(d.Org, d.Repo)
+	switch {
+	case d.Missing:
+		return name + " is not configured"
+	case d.Unexpected:
+		return name + " has no scheduler"
+	case len(d.Differences) == 1:
+		return name + " has 1 changed setting"
+	default:
+		return fmt.Sprintf("%s has %d changed settings", name, len(d.Differences))
+	}
+}
+
+// ProwConfigDrift describes how the live Prow configuration differs from the configuration generated from the
+// schedulers
+type ProwConfigDrift struct {
+	// Global are the differences of the settings which do not belong to a repository
+	Global       []*SettingDifference
+	Repositories []*RepositoryDrift
+	// Warnings describe the settings of either configuration which a scheduler cannot represent so are not compared
+	Warnings []string
+}
+
+// HasDrifted returns true if the live Prow configuration differs from the generated configuration
+func (d *ProwConfigDrift) HasDrifted() bool {
+	return len(d.Global) > 0 || len(d.Repositories) > 0
+}
+
+// Summary returns a one line description of the drift
+func (d *ProwConfigDrift) Summary() string {
+	if !d.HasDrifted() {
+		return "the Prow configuration matches the schedulers"
+	}
+	var parts []string
+	if len(d.Global) > 0 {
+		paths := make([]string, 0, len(d.Global))
+		for _, difference := range d.Global {
+			paths = append(paths, difference.Path)
+		}
+		parts = append(parts, "changed "+strings.Join(paths, ", "))
+	}
+	for _, repo := range d.Repositories {
+		parts = append(parts, repo.String())
+	}
+	return "the Prow configuration has drifted: " + strings.Join(parts, "; ")
+}
+
+// DiffProwConfig compares the live Prow configuration with the expected configuration. The configurations are split
+// into the scheduler of each repository before they are compared so that differences which do not change the
+// behaviour of Prow, such as the order of the entries or whether a setting is configured for an org or each of its
+// repositories, are not reported.
+func DiffProwConfig(expectedCfg *config.Config, expectedPlugs *plugins.Configuration, actualCfg *config.Config, actualPlugs *plugins.Configuration) (*ProwConfigDrift, error) {
+	if expectedCfg == nil {
+		expectedCfg = &config.Config{}
+	}
+	if actualCfg == nil {
+		actualCfg = &config.Config{}
+	}
+	answer := &ProwConfigDrift{
+		Global: diffValues(map[string]string{
+			"pod_namespace":     expectedCfg.PodNamespace,
+			"prowjob_namespace": expectedCfg.ProwJobNamespace,
+		}, map[string]string{
+			"pod_namespace":     actualCfg.PodNamespace,
+			"prowjob_namespace": actualCfg.ProwJobNamespace,
+		}),
+	}
+
+	expectedLeaves, warnings := SchedulerLeavesFromProwConfig(expectedCfg, expectedPlugs)
+	for _, warning := range warnings {
+		answer.Warnings = append(answer.Warnings, "generated configuration: "+warning)
+	}
+	actualLeaves, warnings := SchedulerLeavesFromProwConfig(actualCfg, actualPlugs)
+	for _, warning := range warnings {
+		answer.Warnings = append(answer.Warnings, "live configuration: "+warning)
+	}
+	expected := make(map[string]*SchedulerLeaf)
+	keys := []string{}
+	for _, leaf := range expectedLeaves {
+		key := orgSlashRepo(leaf.Org, leaf.Repo)
+		expected[key] = leaf
+		keys = append(keys, key)
+	}
+	actual := make(map[string]*SchedulerLeaf)
+	for _, leaf := range actualLeaves {
+		key := orgSlashRepo(leaf.Org, leaf.Repo)
+		actual[key] = leaf
+		if expected[key] == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		expectedLeaf, actualLeaf := expected[key], actual[key]
+		switch {
+		case actualLeaf == nil:
+			answer.Repositories = append(answer.Repositories, &RepositoryDrift{
+				Org:     expectedLeaf.Org,
+				Repo:    expectedLeaf.Repo,
+				Missing: true,
+			})
+		case expectedLeaf == nil:
+			answer.Repositories = append(answer.Repositories, &RepositoryDrift{
+				Org:        actualLeaf.Org,
+				Repo:       actualLeaf.Repo,
+				Unexpected: true,
+			})
+		default:
+			expectedValues, err := flattenJobSettings(expectedLeaf.SchedulerSpec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "flattening the expected settings of %s", key)
+			}
+			actualValues, err := flattenJobSettings(actualLeaf.SchedulerSpec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "flattening the live settings of %s", key)
+			}
+			differences := diffValues(expectedValues, actualValues)
+			if len(differences) > 0 {
+				answer.Repositories = append(answer.Repositories, &RepositoryDrift{
+					Org:         expectedLeaf.Org,
+					Repo:        expectedLeaf.Repo,
+					Differences: differences,
+				})
+			}
+		}
+	}
+	return answer, nil
+}
+
+// flattenJobSettings flattens the settings of a scheduler including the settings of its jobs, which are keyed by the
+// name of the job so that the order of the jobs does not matter
+func flattenJobSettings(spec *jenkinsv1.SchedulerSpec) (map[string]string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling scheduler")
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling scheduler")
+	}
+	answer := map[string]string{}
+	for _, field := range jobListFields {
+		jobs, _ := values[field].(map[string]interface{})
+		delete(values, field)
+		items, _ := jobs["entries"].([]interface{})
+		for i, item := range items {
+			job, _ := item.(map[string]interface{})
+			name, _ := job["name"].(string)
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			flatten(field+"."+name, job, answer)
+		}
+	}
+	flatten("", values, answer)
+	return answer, nil
+}
+
+// diffValues returns the differences between the values sorted by path
+func diffValues(expected map[string]string, actual map[string]string) []*SettingDifference {
+	var answer []*SettingDifference
+	for path, value := range expected {
+		if actual[path] != value {
+			answer = append(answer, &SettingDifference{Path: path, Expected: value, Actual: actual[path]})
+		}
+	}
+	for path, value := range actual {
+		if _, ok := expected[path]; !ok && value != "" {
+			answer = append(answer, &SettingDifference{Path: path, Actual: value})
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Path < answer[j].Path
+	})
+	return answer
+}
+
+// LoadProwConfig loads the live Prow configuration from the config and plugins ConfigMaps. A missing ConfigMap is
+// treated as an empty configuration.
+func LoadProwConfig(kubeClient kubernetes.Interface, namespace string) (*config.Config, *plugins.Configuration, error) {
+	cfg := &config.Config{}
+	err := loadConfigMapYaml(kubeClient, namespace, "config", "config.yaml", cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	plugs := &plugins.Configuration{}
+	err = loadConfigMapYaml(kubeClient, namespace, "plugins", "plugins.yaml", plugs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, plugs, nil
+}
+
+func loadConfigMapYaml(kubeClient kubernetes.Interface, namespace string, name string, key string, value interface{}) error {
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if kubeerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "getting ConfigMap %s in namespace %s", name, namespace)
+	}
+	err = yaml.Unmarshal([]byte(cm.Data[key]), value)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshalling %s of ConfigMap %s", key, name)
+	}
+	return nil
+}
+
+// DetectDrift compares the live Prow configuration of the namespace with the configuration generated from the
+// schedulers. The generated configuration is returned so that it can be restored.
+func DetectDrift(gitOps bool, kubeClient kubernetes.Interface, jxClient versioned.Interface, namespace string, teamSchedulerName string, devEnv *jenkinsv1.Environment) (*ProwConfigDrift, *config.Config, *plugins.Configuration, error) {
+	expectedCfg, expectedPlugs, err := GenerateProw(gitOps, jxClient, namespace, teamSchedulerName, devEnv)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "generating Prow config")
+	}
+	actualCfg, actualPlugs, err := LoadProwConfig(kubeClient, namespace)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "loading the live Prow config")
+	}
+	drift, err := DiffProwConfig(expectedCfg, expectedPlugs, actualCfg, actualPlugs)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "comparing the live Prow config with the generated config")
+	}
+	return drift, expectedCfg, expectedPlugs, nil
+}
+
+// RecordDriftEvent records an event on the config ConfigMap which describes the drift and whether it was restored
+func RecordDriftEvent(kubeClient kubernetes.Interface, namespace string, drift *ProwConfigDrift, restored bool) error {
+	reason := DriftEventReasonDrifted
+	eventType := corev1.EventTypeWarning
+	message := drift.Summary()
+	if restored {
+		reason = DriftEventReasonRestored
+		eventType = corev1.EventTypeNormal
+		message = "restored the generated Prow configuration as " + message
+	}
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "config.",
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "config",
+			Namespace:  namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Source: corev1.EventSource{
+			Component: driftEventComponent,
+		},
+	}
+	_, err := kubeClient.CoreV1().Events(namespace).Create(event)
+	if err != nil {
+		return errors.Wrapf(err, "recording event %s in namespace %s", reason, namespace)
+	}
+	return nil
+}
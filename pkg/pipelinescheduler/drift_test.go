@@ -0,0 +1,89 @@
+package pipelinescheduler_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestDiffProwConfigNoDrift(t *testing.T) {
+	t.Parallel()
+	expectedCfg, expectedPlugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+	actualCfg, actualPlugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+
+	// configuring the plugins of an org rather than each of its repositories does not change the behaviour of Prow
+	delete(actualPlugs.Plugins, "acme/app")
+	delete(actualPlugs.Plugins, "acme/dummy")
+	delete(actualPlugs.Plugins, "acme/lib")
+	actualPlugs.Plugins["acme"] = []string{"approve", "lgtm", "trigger"}
+
+	drift, err := pipelinescheduler.DiffProwConfig(expectedCfg, expectedPlugs, actualCfg, actualPlugs)
+	require.NoError(t, err)
+	assert.False(t, drift.HasDrifted(), drift.Summary())
+}
+
+func TestDiffProwConfigDrift(t *testing.T) {
+	t.Parallel()
+	expectedCfg, expectedPlugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+	actualCfg, actualPlugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+
+	actualCfg.Presubmits["acme/lib"][0].RerunCommand = "/test again"
+	delete(actualCfg.Postsubmits, "roadrunner/site")
+	delete(actualPlugs.Plugins, "roadrunner/site")
+	actualPlugs.Triggers = actualPlugs.Triggers[:3]
+	actualPlugs.Approve = actualPlugs.Approve[:3]
+	actualPlugs.Plugins["wile/coyote"] = []string{"approve"}
+	actualCfg.PodNamespace = "test"
+	actualCfg.Periodics = append(actualCfg.Periodics, config.Periodic{JobBase: config.JobBase{Name: "nightly"}})
+
+	drift, err := pipelinescheduler.DiffProwConfig(expectedCfg, expectedPlugs, actualCfg, actualPlugs)
+	require.NoError(t, err)
+	assert.True(t, drift.HasDrifted())
+
+	require.Len(t, drift.Global, 1)
+	assert.Equal(t, "pod_namespace", drift.Global[0].Path)
+	assert.Equal(t, "test", drift.Global[0].Actual)
+
+	require.Len(t, drift.Repositories, 3)
+	lib := drift.Repositories[0]
+	assert.Equal(t, "acme/lib has 1 changed setting", lib.String())
+	assert.Equal(t, &pipelinescheduler.SettingDifference{
+		Path:     "presubmits.unit.rerunCommand",
+		Expected: "/test unit",
+		Actual:   "/test again",
+	}, lib.Differences[0])
+
+	site := drift.Repositories[1]
+	assert.True(t, site.Missing, "roadrunner/site is no longer configured")
+	coyote := drift.Repositories[2]
+	assert.True(t, coyote.Unexpected)
+	assert.Equal(t, "wile/coyote has no scheduler", coyote.String())
+
+	assert.Contains(t, drift.Warnings, "live configuration: periodic nightly is not imported as Prow periodics do not belong to a repository")
+}
+
+func TestRecordDriftEvent(t *testing.T) {
+	t.Parallel()
+	kubeClient := kubefake.NewSimpleClientset()
+	drift := &pipelinescheduler.ProwConfigDrift{
+		Repositories: []*pipelinescheduler.RepositoryDrift{{Org: "acme", Repo: "app", Missing: true}},
+	}
+	err := pipelinescheduler.RecordDriftEvent(kubeClient, "jx", drift, true)
+	require.NoError(t, err)
+
+	events, err := kubeClient.CoreV1().Events("jx").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, pipelinescheduler.DriftEventReasonRestored, event.Reason)
+	assert.Equal(t, corev1.EventTypeNormal, event.Type)
+	assert.Equal(t, "config", event.InvolvedObject.Name)
+	assert.Contains(t, event.Message, "acme/app is not configured")
+}
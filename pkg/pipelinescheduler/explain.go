@@ -0,0 +1,400 @@
+package pipelinescheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SchedulerLevelTeam is the level of the default scheduler of the team
+	SchedulerLevelTeam = "team"
+	// SchedulerLevelGroup is the level of the scheduler of a SourceRepositoryGroup
+	SchedulerLevelGroup = "group"
+	// SchedulerLevelRepository is the level of the scheduler of a SourceRepository
+	SchedulerLevelRepository = "repository"
+	// SchedulerLevelEnvironment is the level of the config-updater of the repository of the dev environment
+	SchedulerLevelEnvironment = "environment"
+)
+
+// SchedulerSource is a scheduler which applies to a repository
+type SchedulerSource struct {
+	// Level is the level the scheduler applies at: team, group or repository
+	Level string
+	// Name is the name of the Scheduler
+	Name string
+	// ReferencedBy is the name of the SourceRepositoryGroup or SourceRepository which references the Scheduler
+	ReferencedBy string
+	Spec         *jenkinsv1.SchedulerSpec
+}
+
+// String returns a description of the scheduler and where it applies
+func (s *SchedulerSource) String() string {
+	if s.ReferencedBy == "" {
+		return fmt.Sprintf("%s scheduler %s", s.Level, s.Name)
+	}
+	return fmt.Sprintf("%s scheduler %s of %s", s.Level, s.Name, s.ReferencedBy)
+}
+
+// SchedulerSources returns the schedulers which apply to a git repository from the least to the most specific, in the
+// order they are merged by Build and GenerateProw. It returns nil if the repository has no SourceRepository.
+func SchedulerSources(gitOps bool, jxClient versioned.Interface, namespace string, teamSchedulerName string, devEnv *jenkinsv1.Environment, org string, repo string) ([]*SchedulerSource, error) {
+	repositories, err := findRepositorySchedulers(gitOps, jxClient, namespace, teamSchedulerName, devEnv, func(spec *jenkinsv1.SourceRepositorySpec) bool {
+		return strings.EqualFold(spec.Org, org) && strings.EqualFold(spec.Repo, repo)
+	})
+	if err != nil || len(repositories) == 0 {
+		return nil, err
+	}
+	return repositories[0].Sources, nil
+}
+
+// ExplainEvent is a repository event to explain the scheduling decisions for
+type ExplainEvent struct {
+	// Branch is the branch pushed to or the base branch of the pull request
+	Branch string
+	// PullRequest is true if the event is for a pull request, otherwise it is a push
+	PullRequest bool
+	// ChangedFiles are the changed files or nil if they are not known
+	ChangedFiles []string
+	// Comment is a comment on the pull request
+	Comment string
+}
+
+// SettingExplanation is an effective setting of a merged scheduler and the schedulers it came from
+type SettingExplanation struct {
+	// Path is the JSON path of the setting such as merger.mergeType
+	Path  string
+	Value string
+	// Source is the most specific scheduler which defines the setting
+	Source string
+	// Overrides are the less specific schedulers which also define the setting
+	Overrides []string
+}
+
+// JobExplanation explains whether a job would run for an event
+type JobExplanation struct {
+	Kind    string
+	Name    string
+	Context string
+	Run     bool
+	Reasons []string
+	// Sources are the schedulers which define the job
+	Sources []string
+}
+
+// Explanation explains how the schedulers of a repository are merged and which jobs would run for an event
+type Explanation struct {
+	Sources   []*SchedulerSource
+	Scheduler *jenkinsv1.SchedulerSpec
+	Settings  []*SettingExplanation
+	Jobs      []*JobExplanation
+}
+
+// MergePolicy returns the settings of the merger and the branch protection policy
+func (e *Explanation) MergePolicy() []*SettingExplanation {
+	var answer []*SettingExplanation
+	for _, setting := range e.Settings {
+		if strings.HasPrefix(setting.Path, "merger.") || strings.HasPrefix(setting.Path, "policy.") {
+			answer = append(answer, setting)
+		}
+	}
+	return answer
+}
+
+// jobListFields are the fields of a SchedulerSpec which are explained per job rather than as settings
+var jobListFields = []string{"presubmits", "postsubmits", "periodics"}
+
+// Explain merges the schedulers and explains which jobs would run for the event
+func Explain(sources []*SchedulerSource, event *ExplainEvent) (*Explanation, error) {
+	explanation := &Explanation{Sources: sources}
+	if len(sources) == 0 {
+		return explanation, nil
+	}
+	specs := make([]*jenkinsv1.SchedulerSpec, 0, len(sources))
+	for _, source := range sources {
+		specs = append(specs, source.Spec.DeepCopy())
+	}
+	merged, err := Build(specs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "merging schedulers")
+	}
+	explanation.Scheduler = merged
+
+	explanation.Settings, err = explainSettings(sources, merged)
+	if err != nil {
+		return nil, err
+	}
+	if event.PullRequest {
+		explanation.Jobs, err = explainPresubmits(sources, merged, event)
+	} else {
+		explanation.Jobs, err = explainPostsubmits(sources, merged, event)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
+
+func explainSettings(sources []*SchedulerSource, merged *jenkinsv1.SchedulerSpec) ([]*SettingExplanation, error) {
+	defined := map[string][]string{}
+	for _, source := range sources {
+		values, err := flattenSettings(source.Spec)
+		if err != nil {
+			return nil, err
+		}
+		for path := range values {
+			defined[path] = append(defined[path], source.String())
+		}
+	}
+	values, err := flattenSettings(merged)
+	if err != nil {
+		return nil, err
+	}
+	var answer []*SettingExplanation
+	for path, value := range values {
+		setting := &SettingExplanation{Path: path, Value: value}
+		if sourceNames := defined[path]; len(sourceNames) > 0 {
+			setting.Source = sourceNames[len(sourceNames)-1]
+			setting.Overrides = sourceNames[:len(sourceNames)-1]
+		}
+		answer = append(answer, setting)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Path < answer[j].Path
+	})
+	return answer, nil
+}
+
+// flattenSettings returns the leaf values of the scheduler other than its jobs keyed by their JSON path
+func flattenSettings(spec *jenkinsv1.SchedulerSpec) (map[string]string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling scheduler")
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling scheduler")
+	}
+	for _, field := range jobListFields {
+		delete(values, field)
+	}
+	answer := map[string]string{}
+	flatten("", values, answer)
+	return answer, nil
+}
+
+func flatten(prefix string, value interface{}, answer map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, child, answer)
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			data, _ := json.Marshal(item)
+			items = append(items, strings.Trim(string(data), `"`))
+		}
+		answer[prefix] = strings.Join(items, ", ")
+	case nil:
+	default:
+		data, _ := json.Marshal(v)
+		answer[prefix] = strings.Trim(string(data), `"`)
+	}
+}
+
+// jobSources returns the schedulers which define a job with the name
+func jobSources(sources []*SchedulerSource, name string, names func(*jenkinsv1.SchedulerSpec) []string) []string {
+	var answer []string
+	for _, source := range sources {
+		for _, n := range names(source.Spec) {
+			if n == name {
+				answer = append(answer, source.String())
+				break
+			}
+		}
+	}
+	return answer
+}
+
+func presubmitNames(spec *jenkinsv1.SchedulerSpec) []string {
+	var answer []string
+	if spec.Presubmits != nil {
+		for _, job := range spec.Presubmits.Items {
+			answer = append(answer, JobName(job.JobBase))
+		}
+	}
+	return answer
+}
+
+func postsubmitNames(spec *jenkinsv1.SchedulerSpec) []string {
+	var answer []string
+	if spec.Postsubmits != nil {
+		for _, job := range spec.Postsubmits.Items {
+			answer = append(answer, JobName(job.JobBase))
+		}
+	}
+	return answer
+}
+
+func explainPresubmits(sources []*SchedulerSource, merged *jenkinsv1.SchedulerSpec, event *ExplainEvent) ([]*JobExplanation, error) {
+	var answer []*JobExplanation
+	if merged.Presubmits == nil {
+		return answer, nil
+	}
+	for _, job := range merged.Presubmits.Items {
+		if job == nil {
+			continue
+		}
+		name := JobName(job.JobBase)
+		explanation := &JobExplanation{
+			Kind:    "presubmit",
+			Name:    name,
+			Context: PresubmitContext(job),
+			Sources: jobSources(sources, name, presubmitNames),
+		}
+		answer = append(answer, explanation)
+
+		matches, reason, err := explainBrancher(job.Brancher, event.Branch)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Reasons = append(explanation.Reasons, reason)
+		if !matches {
+			continue
+		}
+		if event.Comment != "" {
+			if IsRetestComment(event.Comment) {
+				explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("/retest runs the job again if its context %s failed", explanation.Context))
+				continue
+			}
+			requested, trigger, err := presubmitRequestedByComment(job, event.Comment)
+			if err != nil {
+				return nil, err
+			}
+			explanation.Run = requested
+			if trigger == "" {
+				explanation.Reasons = append(explanation.Reasons, "the comment requests all jobs with /test all")
+			} else if requested {
+				explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("the comment matches the trigger %s", trigger))
+			} else {
+				explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("the comment does not match the trigger %s", trigger))
+			}
+			continue
+		}
+		run, reason, err := explainPresubmitRuns(job, event.ChangedFiles)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Run = run
+		explanation.Reasons = append(explanation.Reasons, reason)
+	}
+	return answer, nil
+}
+
+func explainPostsubmits(sources []*SchedulerSource, merged *jenkinsv1.SchedulerSpec, event *ExplainEvent) ([]*JobExplanation, error) {
+	var answer []*JobExplanation
+	if merged.Postsubmits == nil {
+		return answer, nil
+	}
+	for _, job := range merged.Postsubmits.Items {
+		if job == nil {
+			continue
+		}
+		name := JobName(job.JobBase)
+		explanation := &JobExplanation{
+			Kind:    "postsubmit",
+			Name:    name,
+			Context: PostsubmitContext(job),
+			Sources: jobSources(sources, name, postsubmitNames),
+		}
+		answer = append(answer, explanation)
+
+		matches, reason, err := explainBrancher(job.Brancher, event.Branch)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Reasons = append(explanation.Reasons, reason)
+		if !matches {
+			continue
+		}
+		changed, reason, err := explainChanges(job.RegexpChangeMatcher, event.ChangedFiles)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Run = changed
+		explanation.Reasons = append(explanation.Reasons, reason)
+	}
+	return answer, nil
+}
+
+// explainPresubmitRuns returns whether a presubmit runs automatically for a pull request with the changed files and why
+func explainPresubmitRuns(job *jenkinsv1.Presubmit, changedFiles []string) (bool, string, error) {
+	if job.RegexpChangeMatcher != nil && job.RunIfChanged != nil && *job.RunIfChanged != "" {
+		return explainChanges(job.RegexpChangeMatcher, changedFiles)
+	}
+	switch {
+	case job.AlwaysRun == nil:
+		return true, "alwaysRun defaults to true", nil
+	case *job.AlwaysRun:
+		return true, "alwaysRun is true", nil
+	}
+	rerun := "/test " + JobName(job.JobBase)
+	if job.RerunCommand != nil && *job.RerunCommand != "" {
+		rerun = *job.RerunCommand
+	}
+	return false, fmt.Sprintf("alwaysRun is false so it only runs when requested with %s", rerun), nil
+}
+
+// explainBrancher returns whether the brancher matches the branch and why
+func explainBrancher(brancher *jenkinsv1.Brancher, branch string) (bool, string, error) {
+	matches, err := BranchMatches(brancher, branch)
+	if err != nil {
+		return false, "", err
+	}
+	if brancher != nil && brancher.SkipBranches != nil {
+		skipped, err := anyPatternMatches(brancher.SkipBranches.Items, branch)
+		if err != nil {
+			return false, "", err
+		}
+		if skipped {
+			return false, fmt.Sprintf("branch %s is skipped by skipBranches %s", branch, strings.Join(brancher.SkipBranches.Items, ", ")), nil
+		}
+	}
+	if brancher == nil || brancher.Branches == nil || len(brancher.Branches.Items) == 0 {
+		return matches, fmt.Sprintf("branch %s matches as the job runs against all branches", branch), nil
+	}
+	if matches {
+		return true, fmt.Sprintf("branch %s matches branches %s", branch, strings.Join(brancher.Branches.Items, ", ")), nil
+	}
+	return false, fmt.Sprintf("branch %s does not match branches %s", branch, strings.Join(brancher.Branches.Items, ", ")), nil
+}
+
+// explainChanges returns whether the changed files match the change matcher and why
+func explainChanges(matcher *jenkinsv1.RegexpChangeMatcher, changedFiles []string) (bool, string, error) {
+	matches, err := ChangesMatch(matcher, changedFiles)
+	if err != nil {
+		return false, "", err
+	}
+	if matcher == nil || matcher.RunIfChanged == nil || *matcher.RunIfChanged == "" {
+		return matches, "the job has no runIfChanged expression", nil
+	}
+	expression := *matcher.RunIfChanged
+	if changedFiles == nil {
+		return matches, fmt.Sprintf("the changed files are not known so runIfChanged %s is assumed to match", expression), nil
+	}
+	if matches {
+		return true, fmt.Sprintf("a changed file matches runIfChanged %s", expression), nil
+	}
+	return false, fmt.Sprintf("no changed file matches runIfChanged %s", expression), nil
+}
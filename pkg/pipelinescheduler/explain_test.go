@@ -0,0 +1,212 @@
+package pipelinescheduler_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testExplainSources() []*pipelinescheduler.SchedulerSource {
+	team := testJobsScheduler()
+	team.Merger = &v1.Merger{
+		MergeType:    strPtr("squash"),
+		BlockerLabel: strPtr("blocked"),
+	}
+	repo := &v1.SchedulerSpec{
+		Merger: &v1.Merger{MergeType: strPtr("rebase")},
+		Postsubmits: &v1.Postsubmits{Items: []*v1.Postsubmit{
+			{JobBase: &v1.JobBase{Name: strPtr("release")}, Brancher: &v1.Brancher{Branches: &v1.ReplaceableSliceOfStrings{Items: []string{"master"}}}},
+		}},
+	}
+	return []*pipelinescheduler.SchedulerSource{
+		{Level: pipelinescheduler.SchedulerLevelTeam, Name: "team", Spec: team},
+		{Level: pipelinescheduler.SchedulerLevelRepository, Name: "myapp", ReferencedBy: "myorg-myapp", Spec: repo},
+	}
+}
+
+func findJob(jobs []*pipelinescheduler.JobExplanation, name string) *pipelinescheduler.JobExplanation {
+	for _, job := range jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func TestExplainSettings(t *testing.T) {
+	t.Parallel()
+	sources := testExplainSources()
+	explanation, err := pipelinescheduler.Explain(sources, &pipelinescheduler.ExplainEvent{Branch: "master"})
+	require.NoError(t, err)
+
+	policy := explanation.MergePolicy()
+	require.Len(t, policy, 2)
+	assert.Equal(t, "merger.blockerLabel", policy[0].Path)
+	assert.Equal(t, "blocked", policy[0].Value)
+	assert.Equal(t, "team scheduler team", policy[0].Source)
+	assert.Empty(t, policy[0].Overrides)
+
+	assert.Equal(t, "merger.mergeMethod", policy[1].Path)
+	assert.Equal(t, "rebase", policy[1].Value)
+	assert.Equal(t, "repository scheduler myapp of myorg-myapp", policy[1].Source)
+	assert.Equal(t, []string{"team scheduler team"}, policy[1].Overrides)
+
+	assert.Equal(t, "squash", *sources[0].Spec.Merger.MergeType, "the schedulers are not modified")
+}
+
+func TestExplainPostsubmits(t *testing.T) {
+	t.Parallel()
+	explanation, err := pipelinescheduler.Explain(testExplainSources(), &pipelinescheduler.ExplainEvent{
+		Branch:       "feature",
+		ChangedFiles: []string{"charts/myapp/values.yaml"},
+	})
+	require.NoError(t, err)
+	require.Len(t, explanation.Jobs, 2)
+
+	release := findJob(explanation.Jobs, "release")
+	require.NotNil(t, release)
+	assert.False(t, release.Run)
+	assert.Equal(t, []string{"branch feature does not match branches master"}, release.Reasons)
+	assert.Equal(t, []string{"team scheduler team", "repository scheduler myapp of myorg-myapp"}, release.Sources)
+
+	charts := findJob(explanation.Jobs, "charts")
+	require.NotNil(t, charts)
+	assert.True(t, charts.Run)
+	assert.Contains(t, charts.Reasons, "a changed file matches runIfChanged ^charts/")
+}
+
+func TestExplainPresubmits(t *testing.T) {
+	t.Parallel()
+	explanation, err := pipelinescheduler.Explain(testExplainSources(), &pipelinescheduler.ExplainEvent{
+		Branch:       "release-old",
+		PullRequest:  true,
+		ChangedFiles: []string{"pkg/main.go"},
+	})
+	require.NoError(t, err)
+	require.Len(t, explanation.Jobs, 4)
+
+	runs := map[string]bool{}
+	for _, job := range explanation.Jobs {
+		runs[job.Name] = job.Run
+	}
+	assert.Equal(t, map[string]bool{"unit": true, "integration": false, "docs": false, "e2e": false}, runs)
+	assert.Contains(t, findJob(explanation.Jobs, "integration").Reasons, "branch release-old is skipped by skipBranches release-old")
+	assert.Contains(t, findJob(explanation.Jobs, "docs").Reasons, "no changed file matches runIfChanged ^docs/")
+	assert.Equal(t, "integration-tests", findJob(explanation.Jobs, "integration").Context)
+}
+
+func TestExplainComment(t *testing.T) {
+	t.Parallel()
+	explanation, err := pipelinescheduler.Explain(testExplainSources(), &pipelinescheduler.ExplainEvent{
+		Branch:      "master",
+		PullRequest: true,
+		Comment:     "/e2e",
+	})
+	require.NoError(t, err)
+
+	e2e := findJob(explanation.Jobs, "e2e")
+	require.NotNil(t, e2e)
+	assert.True(t, e2e.Run)
+	assert.False(t, findJob(explanation.Jobs, "unit").Run)
+}
+
+func TestSchedulerSources(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	team := &v1.Scheduler{ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: ns}}
+	group := &v1.Scheduler{ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: ns}}
+	repoScheduler := &v1.Scheduler{ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns}}
+	sourceRepo := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp", Namespace: ns},
+		Spec: v1.SourceRepositorySpec{
+			Org:       "myorg",
+			Repo:      "myapp",
+			Scheduler: v1.ResourceReference{Name: "myapp"},
+		},
+	}
+	sourceGroup := &v1.SourceRepositoryGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend-repos", Namespace: ns},
+		Spec: v1.SourceRepositoryGroupSpec{
+			SourceRepositorySpec: []v1.ResourceReference{{Name: "myorg-myapp"}},
+			Scheduler:            v1.ResourceReference{Name: "frontend"},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset(team, group, repoScheduler, sourceRepo, sourceGroup)
+
+	sources, err := pipelinescheduler.SchedulerSources(false, jxClient, ns, "team", nil, "myorg", "myapp")
+	require.NoError(t, err)
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, source.String())
+	}
+	assert.Equal(t, []string{
+		"team scheduler team",
+		"group scheduler frontend of frontend-repos",
+		"repository scheduler myapp of myorg-myapp",
+	}, names)
+}
+
+func TestExplainMatchesGeneratedProwConfig(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	team := &v1.Scheduler{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: ns},
+		Spec:       *testhelpers.CompleteScheduler(),
+	}
+	repoScheduler := &v1.Scheduler{
+		ObjectMeta: metav1.ObjectMeta{Name: "environment", Namespace: ns},
+		Spec: v1.SchedulerSpec{
+			Merger:  &v1.Merger{MergeType: strPtr("rebase")},
+			Plugins: &v1.ReplaceableSliceOfStrings{Items: []string{"approve"}},
+		},
+	}
+	sourceRepo := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-environment-dev", Namespace: ns},
+		Spec: v1.SourceRepositorySpec{
+			Org:       "myorg",
+			Repo:      "environment-dev",
+			Scheduler: v1.ResourceReference{Name: "environment"},
+		},
+	}
+	devEnv := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: ns},
+		Spec: v1.EnvironmentSpec{
+			Source: v1.EnvironmentRepository{URL: "https://github.com/myorg/environment-dev.git"},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset(team, repoScheduler, sourceRepo)
+
+	cfg, plugs, err := pipelinescheduler.GenerateProw(true, jxClient, ns, "team", devEnv)
+	require.NoError(t, err)
+
+	sources, err := pipelinescheduler.SchedulerSources(true, jxClient, ns, "team", devEnv, "myorg", "environment-dev")
+	require.NoError(t, err)
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, source.String())
+	}
+	assert.Equal(t, []string{
+		"team scheduler team",
+		"repository scheduler environment of myorg-environment-dev",
+		"environment scheduler config-updater of dev",
+	}, names)
+
+	explanation, err := pipelinescheduler.Explain(sources, &pipelinescheduler.ExplainEvent{Branch: "master"})
+	require.NoError(t, err)
+	explainedCfg, explainedPlugs, err := pipelinescheduler.BuildProwConfig([]*pipelinescheduler.SchedulerLeaf{
+		{SchedulerSpec: explanation.Scheduler, Org: "myorg", Repo: "environment-dev"},
+	})
+	require.NoError(t, err)
+	explainedCfg.PodNamespace = ns
+	explainedCfg.ProwJobNamespace = ns
+
+	assert.Equal(t, cfg, explainedCfg, "the explained scheduler generates the same Prow config")
+	assert.Equal(t, plugs, explainedPlugs, "the explained scheduler generates the same Prow plugins")
+	assert.Contains(t, plugs.Plugins["myorg/environment-dev"], "config-updater")
+}
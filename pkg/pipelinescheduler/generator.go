@@ -32,10 +32,8 @@ import (
 // GenerateProw will generate the prow config for the namespace
 func GenerateProw(gitOps bool, jxClient versioned.Interface, namespace string, teamSchedulerName string, devEnv *jenkinsv1.Environment) (*config.Config,
 	*plugins.Configuration, error) {
-	var defaultScheduler *jenkinsv1.Scheduler
-	var err error
 	if teamSchedulerName != "" {
-		defaultScheduler, err = jxClient.JenkinsV1().Schedulers(namespace).Get(teamSchedulerName, metav1.GetOptions{})
+		_, err := jxClient.JenkinsV1().Schedulers(namespace).Get(teamSchedulerName, metav1.GetOptions{})
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "Error finding default scheduler in team settings")
 		}
@@ -47,46 +45,11 @@ func GenerateProw(gitOps bool, jxClient versioned.Interface, namespace string, t
 	if len(schedulers.Items) == 0 {
 		return nil, nil, errors.New("No pipeline schedulers are configured")
 	}
-	leaves := make([]*SchedulerLeaf, 0)
-	lookup := make(map[string]*jenkinsv1.Scheduler)
-	for _, item := range schedulers.Items {
-		lookup[item.Name] = item.DeepCopy()
-	}
-	// Process Schedulers linked to SourceRepositoryGroups
-	sourceRepoGroups, err := jxClient.JenkinsV1().SourceRepositoryGroups(namespace).List(metav1.ListOptions{})
-	if err != nil {
-		return nil, nil, errors.Wrapf(err, "Error finding source repository groups")
-	}
-	// Process Schedulers linked to SourceRepositoryGroups
-	sourceRepos, err := jxClient.JenkinsV1().SourceRepositories(namespace).List(metav1.ListOptions{})
+	leaves, err := effectiveSchedulers(gitOps, jxClient, namespace, teamSchedulerName, devEnv, func(spec *jenkinsv1.SourceRepositorySpec) bool {
+		return true
+	})
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "Error finding source repositories")
-	}
-	for _, sourceRepo := range sourceRepos.Items {
-		applicableSchedulers := []*jenkinsv1.SchedulerSpec{}
-		// Apply config-updater to devEnv
-		applicableSchedulers = addConfigUpdaterToDevEnv(gitOps, applicableSchedulers, devEnv, &sourceRepo.Spec)
-		// Apply repo scheduler
-		applicableSchedulers = addRepositoryScheduler(sourceRepo, lookup, applicableSchedulers)
-		// Apply project schedulers
-		applicableSchedulers = addProjectSchedulers(sourceRepoGroups, sourceRepo, lookup, applicableSchedulers)
-		// Apply team scheduler
-		applicableSchedulers = addTeamScheduler(teamSchedulerName, defaultScheduler, applicableSchedulers)
-		if len(applicableSchedulers) < 1 {
-			continue
-		}
-		merged, err := Build(applicableSchedulers)
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "building scheduler")
-		}
-		leaves = append(leaves, &SchedulerLeaf{
-			Repo:          sourceRepo.Spec.Repo,
-			Org:           sourceRepo.Spec.Org,
-			SchedulerSpec: merged,
-		})
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "building prow config")
-		}
+		return nil, nil, err
 	}
 	cfg, plugs, err := BuildProwConfig(leaves)
 	if err != nil {
@@ -99,67 +62,118 @@ func GenerateProw(gitOps bool, jxClient versioned.Interface, namespace string, t
 	return cfg, plugs, nil
 }
 
-func addTeamScheduler(defaultSchedulerName string, defaultScheduler *jenkinsv1.Scheduler, applicableSchedulers []*jenkinsv1.SchedulerSpec) []*jenkinsv1.SchedulerSpec {
-	if defaultSchedulerName != "" {
-		if defaultScheduler != nil {
-			applicableSchedulers = append([]*jenkinsv1.SchedulerSpec{&defaultScheduler.Spec}, applicableSchedulers...)
-		} else {
-			log.Warnf("A team pipeline scheduler named %s was configured but could not be found", defaultSchedulerName)
+// repositorySchedulers are the schedulers which apply to a SourceRepository
+type repositorySchedulers struct {
+	SourceRepository jenkinsv1.SourceRepository
+	Sources          []*SchedulerSource
+}
+
+// findRepositorySchedulers returns the schedulers which apply to each SourceRepository accepted by the filter. When
+// using GitOps the repository of the dev environment also gets the config-updater.
+func findRepositorySchedulers(gitOps bool, jxClient versioned.Interface, namespace string, teamSchedulerName string, devEnv *jenkinsv1.Environment, filter func(*jenkinsv1.SourceRepositorySpec) bool) ([]*repositorySchedulers, error) {
+	sourceRepos, err := jxClient.JenkinsV1().SourceRepositories(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error finding source repositories")
+	}
+	var matched []jenkinsv1.SourceRepository
+	for _, sourceRepo := range sourceRepos.Items {
+		if filter(&sourceRepo.Spec) {
+			matched = append(matched, sourceRepo)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	schedulers, err := jxClient.JenkinsV1().Schedulers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sourceRepoGroups, err := jxClient.JenkinsV1().SourceRepositoryGroups(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error finding source repository groups")
+	}
+	var answer []*repositorySchedulers
+	for _, sourceRepo := range matched {
+		// Build modifies the schedulers so each repository gets its own copies
+		lookup := make(map[string]*jenkinsv1.Scheduler)
+		for _, item := range schedulers.Items {
+			lookup[item.Name] = item.DeepCopy()
 		}
+		sources := schedulerSourcesOf(sourceRepo, sourceRepoGroups, lookup, teamSchedulerName)
+		if gitOps && isDevEnvRepository(devEnv, &sourceRepo.Spec) {
+			sources = append(sources, configUpdaterSource(devEnv))
+		}
+		answer = append(answer, &repositorySchedulers{
+			SourceRepository: sourceRepo,
+			Sources:          sources,
+		})
 	}
-	return applicableSchedulers
+	return answer, nil
 }
 
-func addRepositoryScheduler(sourceRepo jenkinsv1.SourceRepository, lookup map[string]*jenkinsv1.Scheduler, applicableSchedulers []*jenkinsv1.SchedulerSpec) []*jenkinsv1.SchedulerSpec {
-	if sourceRepo.Spec.Scheduler.Name != "" {
-		scheduler := lookup[sourceRepo.Spec.Scheduler.Name]
-		if scheduler != nil {
-			applicableSchedulers = append([]*jenkinsv1.SchedulerSpec{&scheduler.Spec}, applicableSchedulers...)
+// schedulerSourcesOf returns the schedulers which apply to a SourceRepository from the least to the most specific
+func schedulerSourcesOf(sourceRepo jenkinsv1.SourceRepository, sourceRepoGroups *jenkinsv1.SourceRepositoryGroupList, lookup map[string]*jenkinsv1.Scheduler, teamSchedulerName string) []*SchedulerSource {
+	sources := []*SchedulerSource{}
+	if name := sourceRepo.Spec.Scheduler.Name; name != "" {
+		if scheduler := lookup[name]; scheduler != nil {
+			sources = append(sources, &SchedulerSource{Level: SchedulerLevelRepository, Name: name, ReferencedBy: sourceRepo.Name, Spec: &scheduler.Spec})
 		} else {
-			log.Warnf("A scheduler named %s is referenced by repository(%s) but could not be found", sourceRepo.Spec.Scheduler.Name, sourceRepo.Name)
+			log.Warnf("A scheduler named %s is referenced by repository(%s) but could not be found\n", name, sourceRepo.Name)
 		}
 	}
-	return applicableSchedulers
-}
-
-func addProjectSchedulers(sourceRepoGroups *jenkinsv1.SourceRepositoryGroupList, sourceRepo jenkinsv1.SourceRepository, lookup map[string]*jenkinsv1.Scheduler, applicableSchedulers []*jenkinsv1.SchedulerSpec) []*jenkinsv1.SchedulerSpec {
 	for _, sourceGroup := range sourceRepoGroups.Items {
 		for _, groupRepo := range sourceGroup.Spec.SourceRepositorySpec {
-			if groupRepo.Name == sourceRepo.Name {
-				if sourceGroup.Spec.Scheduler.Name != "" {
-					scheduler := lookup[sourceGroup.Spec.Scheduler.Name]
-					if scheduler != nil {
-						applicableSchedulers = append([]*jenkinsv1.SchedulerSpec{&scheduler.Spec}, applicableSchedulers...)
-					} else {
-						log.Warnf("A scheduler named %s is referenced by repository group(%s) but could not be found", sourceGroup.Spec.Scheduler.Name, sourceGroup.Name)
-					}
-				}
+			name := sourceGroup.Spec.Scheduler.Name
+			if groupRepo.Name != sourceRepo.Name || name == "" {
+				continue
+			}
+			if scheduler := lookup[name]; scheduler != nil {
+				source := &SchedulerSource{Level: SchedulerLevelGroup, Name: name, ReferencedBy: sourceGroup.Name, Spec: &scheduler.Spec}
+				sources = append([]*SchedulerSource{source}, sources...)
+			} else {
+				log.Warnf("A scheduler named %s is referenced by repository group(%s) but could not be found\n", name, sourceGroup.Name)
 			}
 		}
 	}
-	return applicableSchedulers
+	if teamSchedulerName != "" {
+		if scheduler := lookup[teamSchedulerName]; scheduler != nil {
+			source := &SchedulerSource{Level: SchedulerLevelTeam, Name: teamSchedulerName, Spec: &scheduler.Spec}
+			sources = append([]*SchedulerSource{source}, sources...)
+		} else {
+			log.Warnf("A team pipeline scheduler named %s was configured but could not be found\n", teamSchedulerName)
+		}
+	}
+	return sources
 }
 
-func addConfigUpdaterToDevEnv(gitOps bool, applicableSchedulers []*jenkinsv1.SchedulerSpec, devEnv *jenkinsv1.Environment, sourceRepo *jenkinsv1.SourceRepositorySpec) []*jenkinsv1.SchedulerSpec {
-	if gitOps && strings.Contains(devEnv.Spec.Source.URL, sourceRepo.Org+"/"+sourceRepo.Repo) {
-		maps := make(map[string]jenkinsv1.ConfigMapSpec)
-		maps["env/prow/config.yaml"] = jenkinsv1.ConfigMapSpec{
-			Name: "config",
-		}
-		maps["env/prow/plugins.yaml"] = jenkinsv1.ConfigMapSpec{
-			Name: "plugins",
-		}
-		environmentUpdaterSpec := &jenkinsv1.SchedulerSpec{
+// isDevEnvRepository returns true if the SourceRepository is the repository of the dev environment
+func isDevEnvRepository(devEnv *jenkinsv1.Environment, sourceRepo *jenkinsv1.SourceRepositorySpec) bool {
+	return devEnv != nil && strings.Contains(devEnv.Spec.Source.URL, sourceRepo.Org+"/"+sourceRepo.Repo)
+}
+
+// configUpdaterSource returns the scheduler which makes the config-updater plugin apply the Prow configuration in the
+// repository of the dev environment
+func configUpdaterSource(devEnv *jenkinsv1.Environment) *SchedulerSource {
+	maps := make(map[string]jenkinsv1.ConfigMapSpec)
+	maps["env/prow/config.yaml"] = jenkinsv1.ConfigMapSpec{
+		Name: "config",
+	}
+	maps["env/prow/plugins.yaml"] = jenkinsv1.ConfigMapSpec{
+		Name: "plugins",
+	}
+	return &SchedulerSource{
+		Level:        SchedulerLevelEnvironment,
+		Name:         "config-updater",
+		ReferencedBy: devEnv.Name,
+		Spec: &jenkinsv1.SchedulerSpec{
 			ConfigUpdater: &jenkinsv1.ConfigUpdater{
 				Map: maps,
 			},
 			Plugins: &jenkinsv1.ReplaceableSliceOfStrings{
 				Items: []string{"config-updater"},
 			},
-		}
-		applicableSchedulers = append([]*jenkinsv1.SchedulerSpec{environmentUpdaterSpec}, applicableSchedulers...)
+		},
 	}
-	return applicableSchedulers
 }
 
 //ApplyDirectly directly applies the prow config to the cluster
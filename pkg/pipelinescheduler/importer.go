@@ -0,0 +1,845 @@
+package pipelinescheduler
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	jenkinsio "github.com/jenkins-x/jx/pkg/apis/jenkins.io"
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// ImportedSchedulers are the resources which reproduce an imported Prow configuration
+type ImportedSchedulers struct {
+	Schedulers             []*jenkinsv1.Scheduler
+	SourceRepositoryGroups []*jenkinsv1.SourceRepositoryGroup
+	SourceRepositories     []*jenkinsv1.SourceRepository
+	// TeamScheduler is the name of the scheduler to use as the default scheduler of the team or empty if the
+	// repositories share no settings
+	TeamScheduler string
+	// Warnings describe the parts of the Prow configuration which could not be imported
+	Warnings []string
+}
+
+// ImportProwConfig creates the Schedulers, SourceRepositoryGroups and SourceRepositories which reproduce the Prow
+// configuration. Settings shared by every repository are moved to the team scheduler and settings shared by the
+// repositories of an org are moved to the scheduler of a SourceRepositoryGroup for the org, so that a repository only
+// has a scheduler of its own if some of its settings differ.
+func ImportProwConfig(cfg *config.Config, plugs *plugins.Configuration, teamSchedulerName string, providerURL string) (*ImportedSchedulers, error) {
+	if teamSchedulerName == "" {
+		return nil, errors.New("no name was specified for the team scheduler")
+	}
+	leaves, warnings := SchedulerLeavesFromProwConfig(cfg, plugs)
+	answer := &ImportedSchedulers{
+		Warnings: warnings,
+	}
+	if len(leaves) == 0 {
+		return answer, nil
+	}
+
+	specs := make([]*jenkinsv1.SchedulerSpec, 0, len(leaves))
+	sourceRepoNames := make([]string, 0, len(leaves))
+	for _, leaf := range leaves {
+		specs = append(specs, leaf.SchedulerSpec)
+		sourceRepoNames = append(sourceRepoNames, kube.ToValidName(leaf.Org+"-"+leaf.Repo))
+	}
+	team := factorSchedulerSpecs(specs)
+	if !isEmptySchedulerSpec(team) {
+		answer.TeamScheduler = teamSchedulerName
+		answer.Schedulers = append(answer.Schedulers, newScheduler(teamSchedulerName, team))
+	}
+
+	// the leaves are sorted so the repositories of an org are next to each other
+	for start, end := 0, 0; start < len(leaves); start = end {
+		org := leaves[start].Org
+		end = start + 1
+		for end < len(leaves) && leaves[end].Org == org {
+			end++
+		}
+		if end-start < 2 {
+			continue
+		}
+		group := factorSchedulerSpecs(specs[start:end])
+		if isEmptySchedulerSpec(group) {
+			continue
+		}
+		name := kube.ToValidName(org)
+		refs := make([]jenkinsv1.ResourceReference, 0, end-start)
+		for _, sourceRepoName := range sourceRepoNames[start:end] {
+			refs = append(refs, jenkinsv1.ResourceReference{
+				Kind: "SourceRepository",
+				Name: sourceRepoName,
+			})
+		}
+		answer.Schedulers = append(answer.Schedulers, newScheduler(name, group))
+		answer.SourceRepositoryGroups = append(answer.SourceRepositoryGroups, &jenkinsv1.SourceRepositoryGroup{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: jenkinsio.GroupAndVersion,
+				Kind:       "SourceRepositoryGroup",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: jenkinsv1.SourceRepositoryGroupSpec{
+				SourceRepositorySpec: refs,
+				Scheduler: jenkinsv1.ResourceReference{
+					Kind: "Scheduler",
+					Name: name,
+				},
+			},
+		})
+	}
+
+	providerName := kube.ToProviderName(providerURL)
+	for i, leaf := range leaves {
+		name := sourceRepoNames[i]
+		sourceRepo := &jenkinsv1.SourceRepository{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: jenkinsio.GroupAndVersion,
+				Kind:       "SourceRepository",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					jenkinsv1.LabelProvider:   providerName,
+					jenkinsv1.LabelOwner:      leaf.Org,
+					jenkinsv1.LabelRepository: leaf.Repo,
+				},
+			},
+			Spec: jenkinsv1.SourceRepositorySpec{
+				Description:  fmt.Sprintf("Imported application for %s/%s", leaf.Org, leaf.Repo),
+				Org:          leaf.Org,
+				Repo:         leaf.Repo,
+				Provider:     providerURL,
+				ProviderName: providerName,
+			},
+		}
+		if !isEmptySchedulerSpec(leaf.SchedulerSpec) {
+			answer.Schedulers = append(answer.Schedulers, newScheduler(name, leaf.SchedulerSpec))
+			sourceRepo.Spec.Scheduler = jenkinsv1.ResourceReference{
+				Kind: "Scheduler",
+				Name: name,
+			}
+		}
+		answer.SourceRepositories = append(answer.SourceRepositories, sourceRepo)
+	}
+
+	names := make(map[string]bool)
+	for _, scheduler := range answer.Schedulers {
+		if names[scheduler.Name] {
+			return nil, errors.Errorf("more than one imported scheduler is named %s", scheduler.Name)
+		}
+		names[scheduler.Name] = true
+	}
+	return answer, nil
+}
+
+func newScheduler(name string, spec *jenkinsv1.SchedulerSpec) *jenkinsv1.Scheduler {
+	return &jenkinsv1.Scheduler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: jenkinsio.GroupAndVersion,
+			Kind:       "Scheduler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: *spec,
+	}
+}
+
+func isEmptySchedulerSpec(spec *jenkinsv1.SchedulerSpec) bool {
+	return reflect.DeepEqual(spec, &jenkinsv1.SchedulerSpec{})
+}
+
+// uninheritedSchedulerFields are the fields of a SchedulerSpec which Build does not inherit from a parent spec
+var uninheritedSchedulerFields = map[string]bool{
+	"ConfigUpdater": true,
+}
+
+// factorSchedulerSpecs moves the most common value of each field of the specs to a parent spec. A value is only moved
+// if all the specs share it, or if it is shared by several specs and the field can replace the value of the parent in
+// the others. Build merges the parent and the specs back into the original specs.
+func factorSchedulerSpecs(specs []*jenkinsv1.SchedulerSpec) *jenkinsv1.SchedulerSpec {
+	parent := &jenkinsv1.SchedulerSpec{}
+	parentValue := reflect.ValueOf(parent).Elem()
+	for field := 0; field < parentValue.NumField(); field++ {
+		if uninheritedSchedulerFields[parentValue.Type().Field(field).Name] {
+			continue
+		}
+		values := make([]reflect.Value, 0, len(specs))
+		for _, spec := range specs {
+			values = append(values, reflect.ValueOf(spec).Elem().Field(field))
+		}
+		shared, count := mostCommonValue(values)
+		if shared == nil {
+			continue
+		}
+		if count < len(specs) && (count < 2 || !canReplaceParent(values)) {
+			continue
+		}
+		parentValue.Field(field).Set(reflect.ValueOf(shared))
+		for _, value := range values {
+			if reflect.DeepEqual(value.Interface(), shared) {
+				value.Set(reflect.Zero(value.Type()))
+			} else {
+				value.Elem().FieldByName("Replace").SetBool(true)
+			}
+		}
+	}
+	return parent
+}
+
+// mostCommonValue returns the most common value which is set and how many times it occurs
+func mostCommonValue(values []reflect.Value) (interface{}, int) {
+	var answer interface{}
+	max := 0
+	for i, value := range values {
+		if value.IsNil() {
+			continue
+		}
+		count := 0
+		for _, other := range values[i:] {
+			if reflect.DeepEqual(value.Interface(), other.Interface()) {
+				count++
+			}
+		}
+		if count > max {
+			answer = value.Interface()
+			max = count
+		}
+	}
+	return answer, max
+}
+
+// canReplaceParent returns true if all the values are set and have a Replace field to stop Build merging the value of
+// the parent
+func canReplaceParent(values []reflect.Value) bool {
+	for _, value := range values {
+		if value.IsNil() {
+			return false
+		}
+		replace := value.Elem().FieldByName("Replace")
+		if !replace.IsValid() || replace.Kind() != reflect.Bool {
+			return false
+		}
+	}
+	return true
+}
+
+// SchedulerLeavesFromProwConfig splits a Prow configuration into the scheduler of each repository it configures,
+// ordered by org and repository. The configuration of an org is applied to each of its repositories which the
+// configuration mentions. The warnings describe the configuration which a scheduler cannot represent.
+func SchedulerLeavesFromProwConfig(cfg *config.Config, plugs *plugins.Configuration) ([]*SchedulerLeaf, []string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if plugs == nil {
+		plugs = &plugins.Configuration{}
+	}
+	i := &prowImporter{
+		cfg:             cfg,
+		plugs:           plugs,
+		leaves:          make(map[string]*SchedulerLeaf),
+		queries:         make(map[string][]*jenkinsv1.Query),
+		mergeTypes:      make(map[string]*string),
+		policies:        make(map[string]*jenkinsv1.ProtectionPolicies),
+		contextPolicies: make(map[string]*jenkinsv1.RepoContextPolicy),
+	}
+	i.findRepositories()
+	i.importJobs()
+	i.importPlugins()
+	i.importTide()
+	i.importBranchProtection()
+	i.attachRepositorySettings()
+	for _, periodic := range cfg.Periodics {
+		i.warnf("periodic %s is not imported as Prow periodics do not belong to a repository", periodic.Name)
+	}
+
+	answer := make([]*SchedulerLeaf, 0, len(i.leaves))
+	for _, key := range i.repositoryKeys() {
+		answer = append(answer, i.leaves[key])
+	}
+	return answer, i.warnings
+}
+
+// prowImporter splits a Prow configuration into the schedulers of its repositories
+type prowImporter struct {
+	cfg      *config.Config
+	plugs    *plugins.Configuration
+	leaves   map[string]*SchedulerLeaf
+	warnings []string
+
+	// the settings of each repository which BuildProwConfig reads from its presubmits
+	queries         map[string][]*jenkinsv1.Query
+	mergeTypes      map[string]*string
+	policies        map[string]*jenkinsv1.ProtectionPolicies
+	contextPolicies map[string]*jenkinsv1.RepoContextPolicy
+}
+
+func (i *prowImporter) warnf(format string, args ...interface{}) {
+	i.warnings = append(i.warnings, fmt.Sprintf(format, args...))
+}
+
+func (i *prowImporter) addRepository(key string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return
+	}
+	if _, ok := i.leaves[key]; !ok {
+		i.leaves[key] = &SchedulerLeaf{
+			SchedulerSpec: &jenkinsv1.SchedulerSpec{},
+			Org:           parts[0],
+			Repo:          parts[1],
+		}
+	}
+}
+
+// repositoryKeys returns the org/repo keys of the repositories in order
+func (i *prowImporter) repositoryKeys() []string {
+	keys := make([]string, 0, len(i.leaves))
+	for key := range i.leaves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// repositoriesOf returns the repositories an org/repo or org key of the configuration applies to
+func (i *prowImporter) repositoriesOf(key string) []string {
+	if strings.Contains(key, "/") {
+		if i.leaves[key] == nil {
+			return nil
+		}
+		return []string{key}
+	}
+	var answer []string
+	for _, repoKey := range i.repositoryKeys() {
+		if i.leaves[repoKey].Org == key {
+			answer = append(answer, repoKey)
+		}
+	}
+	if len(answer) == 0 {
+		i.warnf("the configuration of %s is not imported as none of its repositories are configured", key)
+	}
+	return answer
+}
+
+// repositoriesOfEntry returns the repositories a plugin configuration entry applies to. The repositories of an org are
+// only returned if they are not configured by another entry.
+func (i *prowImporter) repositoriesOfEntry(keys []string, configured func(*SchedulerLeaf) bool) []string {
+	var answer []string
+	for _, key := range keys {
+		if strings.Contains(key, "/") {
+			answer = append(answer, i.repositoriesOf(key)...)
+		}
+	}
+	for _, key := range keys {
+		if strings.Contains(key, "/") {
+			continue
+		}
+		for _, repoKey := range i.repositoriesOf(key) {
+			if !configured(i.leaves[repoKey]) && util.StringArrayIndex(answer, repoKey) < 0 {
+				answer = append(answer, repoKey)
+			}
+		}
+	}
+	return answer
+}
+
+func (i *prowImporter) findRepositories() {
+	for key := range i.cfg.Presubmits {
+		i.addRepository(key)
+	}
+	for key := range i.cfg.Postsubmits {
+		i.addRepository(key)
+	}
+	for key := range i.plugs.Plugins {
+		i.addRepository(key)
+	}
+	for key := range i.plugs.ExternalPlugins {
+		i.addRepository(key)
+	}
+	for _, approve := range i.plugs.Approve {
+		for _, key := range approve.Repos {
+			i.addRepository(key)
+		}
+	}
+	for _, lgtm := range i.plugs.Lgtm {
+		for _, key := range lgtm.Repos {
+			i.addRepository(key)
+		}
+	}
+	for _, trigger := range i.plugs.Triggers {
+		for _, key := range trigger.Repos {
+			i.addRepository(key)
+		}
+	}
+	for _, query := range i.cfg.Tide.Queries {
+		for _, key := range query.Repos {
+			i.addRepository(key)
+		}
+	}
+	for key := range i.cfg.Tide.MergeType {
+		i.addRepository(key)
+	}
+	for org, orgPolicy := range i.cfg.Tide.ContextOptions.Orgs {
+		for repo := range orgPolicy.Repos {
+			i.addRepository(orgSlashRepo(org, repo))
+		}
+	}
+	for org, orgPolicy := range i.cfg.BranchProtection.Orgs {
+		for repo := range orgPolicy.Repos {
+			i.addRepository(orgSlashRepo(org, repo))
+		}
+	}
+}
+
+func (i *prowImporter) importJobs() {
+	for key, jobs := range i.cfg.Presubmits {
+		leaf := i.leaves[key]
+		if leaf == nil {
+			i.warnf("the presubmits of %s are not imported as they do not belong to a repository", key)
+			continue
+		}
+		leaf.Presubmits = &jenkinsv1.Presubmits{}
+		for _, job := range jobs {
+			leaf.Presubmits.Items = append(leaf.Presubmits.Items, importPresubmit(job))
+		}
+	}
+	for key, jobs := range i.cfg.Postsubmits {
+		leaf := i.leaves[key]
+		if leaf == nil {
+			i.warnf("the postsubmits of %s are not imported as they do not belong to a repository", key)
+			continue
+		}
+		leaf.Postsubmits = &jenkinsv1.Postsubmits{}
+		for _, job := range jobs {
+			leaf.Postsubmits.Items = append(leaf.Postsubmits.Items, importPostsubmit(job))
+		}
+	}
+}
+
+func (i *prowImporter) importPlugins() {
+	// the plugins of an org sort before the plugins of its repositories so they are listed first like Prow does
+	pluginKeys := make([]string, 0, len(i.plugs.Plugins))
+	for key := range i.plugs.Plugins {
+		pluginKeys = append(pluginKeys, key)
+	}
+	sort.Strings(pluginKeys)
+	for _, key := range pluginKeys {
+		for _, repoKey := range i.repositoriesOf(key) {
+			leaf := i.leaves[repoKey]
+			if leaf.Plugins == nil {
+				leaf.Plugins = &jenkinsv1.ReplaceableSliceOfStrings{}
+			}
+			for _, plugin := range i.plugs.Plugins[key] {
+				if util.StringArrayIndex(leaf.Plugins.Items, plugin) < 0 {
+					leaf.Plugins.Items = append(leaf.Plugins.Items, plugin)
+				}
+			}
+		}
+	}
+	externalPluginKeys := make([]string, 0, len(i.plugs.ExternalPlugins))
+	for key := range i.plugs.ExternalPlugins {
+		externalPluginKeys = append(externalPluginKeys, key)
+	}
+	sort.Strings(externalPluginKeys)
+	for _, key := range externalPluginKeys {
+		for _, repoKey := range i.repositoriesOf(key) {
+			leaf := i.leaves[repoKey]
+			if leaf.ExternalPlugins == nil {
+				leaf.ExternalPlugins = &jenkinsv1.ReplaceableSliceOfExternalPlugins{}
+			}
+			for _, plugin := range i.plugs.ExternalPlugins[key] {
+				leaf.ExternalPlugins.Items = append(leaf.ExternalPlugins.Items, importExternalPlugin(plugin))
+			}
+		}
+	}
+
+	for _, approve := range i.plugs.Approve {
+		for _, repoKey := range i.repositoriesOfEntry(approve.Repos, func(leaf *SchedulerLeaf) bool { return leaf.Approve != nil }) {
+			i.leaves[repoKey].Approve = importApprove(approve)
+		}
+	}
+	for _, lgtm := range i.plugs.Lgtm {
+		for _, repoKey := range i.repositoriesOfEntry(lgtm.Repos, func(leaf *SchedulerLeaf) bool { return leaf.LGTM != nil }) {
+			i.leaves[repoKey].LGTM = importLgtm(lgtm)
+		}
+	}
+	for _, trigger := range i.plugs.Triggers {
+		for _, repoKey := range i.repositoriesOfEntry(trigger.Repos, func(leaf *SchedulerLeaf) bool { return leaf.Trigger != nil }) {
+			leaf := i.leaves[repoKey]
+			leaf.Trigger = importTrigger(trigger, leaf.Org)
+		}
+	}
+
+	// Prow has a single config updater which is not inherited from a parent scheduler so it is imported into the
+	// scheduler of the first repository
+	keys := i.repositoryKeys()
+	if !reflect.DeepEqual(i.plugs.ConfigUpdater, plugins.ConfigUpdater{}) && len(keys) > 0 {
+		i.leaves[keys[0]].ConfigUpdater = importConfigUpdater(i.plugs.ConfigUpdater)
+	}
+}
+
+func (i *prowImporter) importTide() {
+	tide := i.cfg.Tide
+	merger := &jenkinsv1.Merger{
+		TargetURL:       stringPointer(tide.TargetURL),
+		PRStatusBaseURL: stringPointer(tide.PRStatusBaseURL),
+		BlockerLabel:    stringPointer(tide.BlockerLabel),
+		SquashLabel:     stringPointer(tide.SquashLabel),
+	}
+	if tide.SyncPeriod != 0 {
+		syncPeriod := tide.SyncPeriod
+		merger.SyncPeriod = &syncPeriod
+	}
+	if tide.StatusUpdatePeriod != 0 {
+		statusUpdatePeriod := tide.StatusUpdatePeriod
+		merger.StatusUpdatePeriod = &statusUpdatePeriod
+	}
+	if tide.MaxGoroutines != 0 {
+		maxGoroutines := tide.MaxGoroutines
+		merger.MaxGoroutines = &maxGoroutines
+	}
+	if !reflect.DeepEqual(tide.ContextOptions.TideContextPolicy, config.TideContextPolicy{}) {
+		merger.ContextPolicy = importContextPolicy(tide.ContextOptions.TideContextPolicy)
+	}
+	if !reflect.DeepEqual(merger, &jenkinsv1.Merger{}) {
+		for _, leaf := range i.leaves {
+			leaf.Merger = merger.DeepCopy()
+		}
+	}
+
+	for _, query := range tide.Queries {
+		if len(query.Orgs) > 0 {
+			i.warnf("the tide query for the orgs %s is not imported as schedulers only configure queries for repositories", strings.Join(query.Orgs, ", "))
+		}
+		for _, key := range query.Repos {
+			if i.leaves[key] != nil {
+				i.queries[key] = append(i.queries[key], importQuery(query))
+			}
+		}
+	}
+	for key, mergeType := range tide.MergeType {
+		if i.leaves[key] == nil {
+			i.warnf("the tide merge method of %s is not imported as schedulers only configure merge methods for repositories", key)
+			continue
+		}
+		i.mergeTypes[key] = stringPointer(string(mergeType))
+	}
+	for org, orgPolicy := range tide.ContextOptions.Orgs {
+		if !reflect.DeepEqual(orgPolicy.TideContextPolicy, config.TideContextPolicy{}) {
+			i.warnf("the tide context policy of %s is not imported as schedulers only configure context policies for repositories", org)
+		}
+		for repo, repoPolicy := range orgPolicy.Repos {
+			// BuildProwConfig requires the context policy of a repository to be set
+			policy := &jenkinsv1.RepoContextPolicy{
+				ContextPolicy: importContextPolicy(repoPolicy.TideContextPolicy),
+			}
+			if len(repoPolicy.Branches) > 0 {
+				policy.Branches = &jenkinsv1.ReplaceableMapOfStringContextPolicy{
+					Items: make(map[string]*jenkinsv1.ContextPolicy),
+				}
+				for branch, branchPolicy := range repoPolicy.Branches {
+					policy.Branches.Items[branch] = importContextPolicy(branchPolicy)
+				}
+			}
+			i.contextPolicies[orgSlashRepo(org, repo)] = policy
+		}
+	}
+}
+
+func (i *prowImporter) importBranchProtection() {
+	branchProtection := i.cfg.BranchProtection
+	global := &jenkinsv1.GlobalProtectionPolicy{}
+	if branchProtection.ProtectTested {
+		global.ProtectTested = boolPointer(true)
+	}
+	if !reflect.DeepEqual(branchProtection.Policy, config.Policy{}) {
+		global.ProtectionPolicy = importPolicy(branchProtection.Policy)
+	}
+	if !reflect.DeepEqual(global, &jenkinsv1.GlobalProtectionPolicy{}) {
+		for _, leaf := range i.leaves {
+			leaf.Policy = global.DeepCopy()
+		}
+	}
+
+	for org, orgPolicy := range branchProtection.Orgs {
+		if !reflect.DeepEqual(orgPolicy.Policy, config.Policy{}) {
+			i.warnf("the branch protection policy of %s is not imported as schedulers only configure policies for repositories", org)
+		}
+		for repo, repoPolicy := range orgPolicy.Repos {
+			policies := &jenkinsv1.ProtectionPolicies{}
+			if !reflect.DeepEqual(repoPolicy.Policy, config.Policy{}) {
+				policies.ProtectionPolicy = importPolicy(repoPolicy.Policy)
+			}
+			for branch, branchPolicy := range repoPolicy.Branches {
+				if policies.Items == nil {
+					policies.Items = make(map[string]*jenkinsv1.ProtectionPolicy)
+				}
+				policies.Items[branch] = importPolicy(branchPolicy.Policy)
+			}
+			i.policies[orgSlashRepo(org, repo)] = policies
+		}
+	}
+}
+
+// attachRepositorySettings adds the tide and branch protection settings of each repository to its presubmits which
+// is where BuildProwConfig reads them from
+func (i *prowImporter) attachRepositorySettings() {
+	for _, key := range i.repositoryKeys() {
+		queries := i.queries[key]
+		mergeType := i.mergeTypes[key]
+		policy := i.policies[key]
+		contextPolicy := i.contextPolicies[key]
+		if len(queries) == 0 && mergeType == nil && policy == nil && contextPolicy == nil {
+			continue
+		}
+		leaf := i.leaves[key]
+		if leaf.Presubmits == nil || len(leaf.Presubmits.Items) == 0 {
+			i.warnf("the tide and branch protection settings of %s are not imported as it has no presubmits", key)
+			continue
+		}
+		jobs := leaf.Presubmits.Items
+		for idx, query := range queries {
+			if idx == len(jobs) {
+				i.warnf("%d of the tide queries of %s are not imported as it has %d presubmits", len(queries)-idx, key, len(jobs))
+				break
+			}
+			jobs[idx].Query = query
+		}
+		jobs[0].MergeType = mergeType
+		jobs[0].Policy = policy
+		jobs[0].ContextPolicy = contextPolicy
+	}
+}
+
+func importPresubmit(job config.Presubmit) *jenkinsv1.Presubmit {
+	answer := &jenkinsv1.Presubmit{
+		JobBase:             importJobBase(job.JobBase),
+		Brancher:            importBrancher(job.Brancher),
+		RegexpChangeMatcher: importRegexpChangeMatcher(job.RegexpChangeMatcher),
+		// the jobs of a scheduler always run if this is unset so it is always imported
+		AlwaysRun:    boolPointer(job.AlwaysRun),
+		Context:      stringPointer(job.Context),
+		Trigger:      stringPointer(job.Trigger),
+		RerunCommand: stringPointer(job.RerunCommand),
+	}
+	if job.Optional {
+		answer.Optional = boolPointer(true)
+	}
+	if job.SkipReport {
+		answer.Report = boolPointer(false)
+	}
+	return answer
+}
+
+func importPostsubmit(job config.Postsubmit) *jenkinsv1.Postsubmit {
+	answer := &jenkinsv1.Postsubmit{
+		JobBase:             importJobBase(job.JobBase),
+		Brancher:            importBrancher(job.Brancher),
+		RegexpChangeMatcher: importRegexpChangeMatcher(job.RegexpChangeMatcher),
+		Context:             stringPointer(job.Context),
+	}
+	// BuildProwConfig reports a postsubmit if its report setting is false
+	if job.Report {
+		answer.Report = boolPointer(false)
+	}
+	return answer
+}
+
+func importJobBase(job config.JobBase) *jenkinsv1.JobBase {
+	answer := &jenkinsv1.JobBase{
+		Name:    stringPointer(job.Name),
+		Agent:   stringPointer(job.Agent),
+		Cluster: stringPointer(job.Cluster),
+	}
+	if job.Labels != nil {
+		answer.Labels = &jenkinsv1.ReplaceableMapOfStringString{
+			Items: job.Labels,
+		}
+	}
+	if job.MaxConcurrency != 0 {
+		maxConcurrency := job.MaxConcurrency
+		answer.MaxConcurrency = &maxConcurrency
+	}
+	if job.Namespace != nil {
+		answer.Namespace = stringPointer(*job.Namespace)
+	}
+	return answer
+}
+
+func importBrancher(brancher config.Brancher) *jenkinsv1.Brancher {
+	if brancher.Branches == nil && brancher.SkipBranches == nil {
+		return nil
+	}
+	return &jenkinsv1.Brancher{
+		Branches:     importStrings(brancher.Branches),
+		SkipBranches: importStrings(brancher.SkipBranches),
+	}
+}
+
+func importRegexpChangeMatcher(matcher config.RegexpChangeMatcher) *jenkinsv1.RegexpChangeMatcher {
+	if matcher.RunIfChanged == "" {
+		return nil
+	}
+	return &jenkinsv1.RegexpChangeMatcher{
+		RunIfChanged: stringPointer(matcher.RunIfChanged),
+	}
+}
+
+func importExternalPlugin(plugin plugins.ExternalPlugin) *jenkinsv1.ExternalPlugin {
+	return &jenkinsv1.ExternalPlugin{
+		Name:     stringPointer(plugin.Name),
+		Endpoint: stringPointer(plugin.Endpoint),
+		Events:   importStrings(plugin.Events),
+	}
+}
+
+func importApprove(approve plugins.Approve) *jenkinsv1.Approve {
+	answer := &jenkinsv1.Approve{
+		RequireSelfApproval: approve.RequireSelfApproval,
+		IgnoreReviewState:   approve.IgnoreReviewState,
+	}
+	if approve.IssueRequired {
+		answer.IssueRequired = boolPointer(true)
+	}
+	if approve.LgtmActsAsApprove {
+		answer.LgtmActsAsApprove = boolPointer(true)
+	}
+	return answer
+}
+
+func importLgtm(lgtm plugins.Lgtm) *jenkinsv1.Lgtm {
+	answer := &jenkinsv1.Lgtm{
+		StickyLgtmTeam: stringPointer(lgtm.StickyLgtmTeam),
+	}
+	if lgtm.ReviewActsAsLgtm {
+		answer.ReviewActsAsLgtm = boolPointer(true)
+	}
+	if lgtm.StoreTreeHash {
+		answer.StoreTreeHash = boolPointer(true)
+	}
+	return answer
+}
+
+func importTrigger(trigger plugins.Trigger, org string) *jenkinsv1.Trigger {
+	answer := &jenkinsv1.Trigger{
+		JoinOrgURL: stringPointer(trigger.JoinOrgURL),
+	}
+	// BuildProwConfig trusts the org of the repository unless another org is set
+	if trigger.TrustedOrg != org {
+		answer.TrustedOrg = stringPointer(trigger.TrustedOrg)
+	}
+	if trigger.OnlyOrgMembers {
+		answer.OnlyOrgMembers = boolPointer(true)
+	}
+	if trigger.IgnoreOkToTest {
+		answer.IgnoreOkToTest = boolPointer(true)
+	}
+	return answer
+}
+
+func importConfigUpdater(configUpdater plugins.ConfigUpdater) *jenkinsv1.ConfigUpdater {
+	answer := &jenkinsv1.ConfigUpdater{
+		ConfigFile: configUpdater.ConfigFile,
+		PluginFile: configUpdater.PluginFile,
+	}
+	if configUpdater.Maps != nil {
+		answer.Map = make(map[string]jenkinsv1.ConfigMapSpec)
+		for key, value := range configUpdater.Maps {
+			answer.Map[key] = jenkinsv1.ConfigMapSpec{
+				Name:                 value.Name,
+				Namespace:            value.Namespace,
+				Key:                  value.Key,
+				AdditionalNamespaces: value.AdditionalNamespaces,
+			}
+		}
+	}
+	return answer
+}
+
+func importQuery(query config.TideQuery) *jenkinsv1.Query {
+	answer := &jenkinsv1.Query{
+		ExcludedBranches: importStrings(query.ExcludedBranches),
+		IncludedBranches: importStrings(query.IncludedBranches),
+		Labels:           importStrings(query.Labels),
+		MissingLabels:    importStrings(query.MissingLabels),
+		Milestone:        stringPointer(query.Milestone),
+	}
+	if query.ReviewApprovedRequired {
+		answer.ReviewApprovedRequired = boolPointer(true)
+	}
+	return answer
+}
+
+func importContextPolicy(policy config.TideContextPolicy) *jenkinsv1.ContextPolicy {
+	return &jenkinsv1.ContextPolicy{
+		SkipUnknownContexts:       policy.SkipUnknownContexts,
+		FromBranchProtection:      policy.FromBranchProtection,
+		RequiredContexts:          importStrings(policy.RequiredContexts),
+		RequiredIfPresentContexts: importStrings(policy.RequiredIfPresentContexts),
+		OptionalContexts:          importStrings(policy.OptionalContexts),
+	}
+}
+
+func importPolicy(policy config.Policy) *jenkinsv1.ProtectionPolicy {
+	answer := &jenkinsv1.ProtectionPolicy{
+		Protect:      policy.Protect,
+		Admins:       policy.Admins,
+		Restrictions: importRestrictions(policy.Restrictions),
+	}
+	if policy.RequiredStatusChecks != nil {
+		answer.RequiredStatusChecks = &jenkinsv1.BranchProtectionContextPolicy{
+			Contexts: importStrings(policy.RequiredStatusChecks.Contexts),
+			Strict:   policy.RequiredStatusChecks.Strict,
+		}
+	}
+	if reviews := policy.RequiredPullRequestReviews; reviews != nil {
+		answer.RequiredPullRequestReviews = &jenkinsv1.ReviewPolicy{
+			DismissalRestrictions: importRestrictions(reviews.DismissalRestrictions),
+			DismissStale:          reviews.DismissStale,
+			RequireOwners:         reviews.RequireOwners,
+			Approvals:             reviews.Approvals,
+		}
+	}
+	return answer
+}
+
+func importRestrictions(restrictions *config.Restrictions) *jenkinsv1.Restrictions {
+	if restrictions == nil {
+		return nil
+	}
+	return &jenkinsv1.Restrictions{
+		Users: importStrings(restrictions.Users),
+		Teams: importStrings(restrictions.Teams),
+	}
+}
+
+func importStrings(items []string) *jenkinsv1.ReplaceableSliceOfStrings {
+	if items == nil {
+		return nil
+	}
+	return &jenkinsv1.ReplaceableSliceOfStrings{
+		Items: items,
+	}
+}
+
+// stringPointer returns a pointer to the string or nil if it is empty
+func stringPointer(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func boolPointer(b bool) *bool {
+	return &b
+}
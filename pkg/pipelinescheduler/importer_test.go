@@ -0,0 +1,176 @@
+package pipelinescheduler_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func loadProwConfig(t *testing.T, dir string) (*config.Config, *plugins.Configuration) {
+	cfg := &config.Config{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, cfg))
+
+	plugs := &plugins.Configuration{}
+	data, err = ioutil.ReadFile(filepath.Join(dir, "plugins.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, plugs))
+	return cfg, plugs
+}
+
+// leavesOfImport merges the imported schedulers of each repository the same way as the pipeline scheduler does
+func leavesOfImport(t *testing.T, imported *pipelinescheduler.ImportedSchedulers) []*pipelinescheduler.SchedulerLeaf {
+	schedulers := make(map[string]*v1.Scheduler)
+	for _, scheduler := range imported.Schedulers {
+		schedulers[scheduler.Name] = scheduler
+	}
+	var leaves []*pipelinescheduler.SchedulerLeaf
+	for _, sourceRepo := range imported.SourceRepositories {
+		var specs []*v1.SchedulerSpec
+		if imported.TeamScheduler != "" {
+			specs = append(specs, schedulers[imported.TeamScheduler].Spec.DeepCopy())
+		}
+		for _, group := range imported.SourceRepositoryGroups {
+			for _, ref := range group.Spec.SourceRepositorySpec {
+				if ref.Name == sourceRepo.Name {
+					specs = append(specs, schedulers[group.Spec.Scheduler.Name].Spec.DeepCopy())
+				}
+			}
+		}
+		if sourceRepo.Spec.Scheduler.Name != "" {
+			specs = append(specs, schedulers[sourceRepo.Spec.Scheduler.Name].Spec.DeepCopy())
+		}
+		require.NotEmpty(t, specs, "no schedulers apply to %s", sourceRepo.Name)
+		spec, err := pipelinescheduler.Build(specs)
+		require.NoError(t, err)
+		leaves = append(leaves, &pipelinescheduler.SchedulerLeaf{
+			SchedulerSpec: spec,
+			Org:           sourceRepo.Spec.Org,
+			Repo:          sourceRepo.Spec.Repo,
+		})
+	}
+	return leaves
+}
+
+func assertSameYaml(t *testing.T, expected interface{}, actual interface{}) {
+	expectedData, err := yaml.Marshal(expected)
+	require.NoError(t, err)
+	actualData, err := yaml.Marshal(actual)
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedData), string(actualData))
+}
+
+func TestImportProwConfig(t *testing.T) {
+	t.Parallel()
+	cfg, plugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+
+	imported, err := pipelinescheduler.ImportProwConfig(cfg, plugs, "default-scheduler", "https://github.com")
+	require.NoError(t, err)
+	assert.Empty(t, imported.Warnings)
+	assert.Equal(t, "default-scheduler", imported.TeamScheduler)
+
+	schedulers := make(map[string]*v1.SchedulerSpec)
+	names := []string{}
+	for _, scheduler := range imported.Schedulers {
+		names = append(names, scheduler.Name)
+		schedulers[scheduler.Name] = scheduler.Spec.DeepCopy()
+	}
+	assert.Equal(t, []string{"default-scheduler", "acme", "acme-app", "acme-lib", "roadrunner-site"}, names)
+
+	team := schedulers["default-scheduler"]
+	assert.NotNil(t, team.Postsubmits, "the postsubmits of every repository are shared")
+	assert.Nil(t, team.Presubmits, "roadrunner/site has no presubmits")
+	assert.NotNil(t, team.Approve)
+	assert.Nil(t, team.ConfigUpdater, "the config updater is not inherited")
+	assert.Equal(t, []string{"approve", "lgtm", "trigger"}, team.Plugins.Items)
+	require.NotNil(t, team.Trigger)
+	assert.Nil(t, team.Trigger.TrustedOrg, "each repository trusts its own org")
+
+	group := schedulers["acme"]
+	require.NotNil(t, group.Presubmits)
+	assert.Equal(t, "integration", pipelinescheduler.JobName(group.Presubmits.Items[0].JobBase))
+	assert.NotNil(t, group.LGTM)
+	assert.Nil(t, group.Plugins)
+
+	lib := schedulers["acme-lib"]
+	require.NotNil(t, lib.Presubmits)
+	assert.True(t, lib.Presubmits.Replace, "the presubmits of the group are replaced")
+	assert.Equal(t, "squash", *lib.Presubmits.Items[0].MergeType)
+
+	site := schedulers["roadrunner-site"]
+	require.NotNil(t, site.Plugins)
+	assert.True(t, site.Plugins.Replace, "the plugins of the team are replaced")
+	assert.Equal(t, []string{"approve", "trigger"}, site.Plugins.Items)
+
+	assert.NotNil(t, schedulers["acme-app"].ExternalPlugins)
+	assert.NotNil(t, schedulers["acme-app"].ConfigUpdater, "the config updater belongs to the first repository")
+
+	require.Len(t, imported.SourceRepositoryGroups, 1)
+	refs := []string{}
+	for _, ref := range imported.SourceRepositoryGroups[0].Spec.SourceRepositorySpec {
+		refs = append(refs, ref.Name)
+	}
+	assert.Equal(t, []string{"acme-app", "acme-dummy", "acme-lib"}, refs)
+
+	repoSchedulers := make(map[string]string)
+	for _, sourceRepo := range imported.SourceRepositories {
+		repoSchedulers[sourceRepo.Name] = sourceRepo.Spec.Scheduler.Name
+		assert.Equal(t, "github", sourceRepo.Labels[v1.LabelProvider])
+	}
+	assert.Equal(t, map[string]string{
+		"acme-app":        "acme-app",
+		"acme-dummy":      "",
+		"acme-lib":        "acme-lib",
+		"roadrunner-site": "roadrunner-site",
+	}, repoSchedulers)
+}
+
+func TestImportProwConfigRoundTrip(t *testing.T) {
+	t.Parallel()
+	cfg, plugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+
+	imported, err := pipelinescheduler.ImportProwConfig(cfg, plugs, "default-scheduler", "https://github.com")
+	require.NoError(t, err)
+
+	expectedCfg, expectedPlugs := loadProwConfig(t, filepath.Join("test_data", "import"))
+	actualCfg, actualPlugs, err := pipelinescheduler.BuildProwConfig(leavesOfImport(t, imported))
+	require.NoError(t, err)
+	assertSameYaml(t, expectedCfg, actualCfg)
+	assertSameYaml(t, expectedPlugs, actualPlugs)
+}
+
+func TestImportProwConfigOrgSettings(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{}
+	cfg.Presubmits = map[string][]config.Presubmit{
+		"acme/app": {{JobBase: config.JobBase{Name: "integration"}, AlwaysRun: true}},
+		"acme/lib": {{JobBase: config.JobBase{Name: "unit"}, AlwaysRun: true}},
+	}
+	cfg.Periodics = []config.Periodic{{JobBase: config.JobBase{Name: "cleanup"}}}
+	plugs := &plugins.Configuration{
+		Plugins: map[string][]string{
+			"acme":     {"approve", "trigger"},
+			"acme/lib": {"size"},
+		},
+	}
+
+	leaves, warnings := pipelinescheduler.SchedulerLeavesFromProwConfig(cfg, plugs)
+	require.Len(t, leaves, 2)
+	assert.Equal(t, "app", leaves[0].Repo)
+	assert.Equal(t, []string{"approve", "trigger"}, leaves[0].Plugins.Items)
+	assert.Equal(t, "lib", leaves[1].Repo)
+	assert.Equal(t, []string{"approve", "trigger", "size"}, leaves[1].Plugins.Items)
+	assert.Len(t, warnings, 1, "the periodic cannot be imported")
+
+	_, err := pipelinescheduler.ImportProwConfig(cfg, plugs, "", "https://github.com")
+	assert.Error(t, err)
+}
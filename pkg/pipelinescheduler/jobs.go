@@ -0,0 +1,254 @@
+package pipelinescheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+)
+
+var (
+	testAllRegex  = regexp.MustCompile(`(?m)^/test all,?($|\s.*)`)
+	retestRegex   = regexp.MustCompile(`(?m)^/retest\s*$`)
+	okToTestRegex = regexp.MustCompile(`(?m)^/ok-to-test\s*$`)
+)
+
+// EffectiveScheduler returns the scheduler of a git repository merged from the schedulers of its SourceRepository,
+// the SourceRepositoryGroups it belongs to and the team, along with the git provider of the SourceRepository. It
+// returns nil if the repository has no scheduler.
+func EffectiveScheduler(jxClient versioned.Interface, namespace string, teamSchedulerName string, org string, repo string) (*SchedulerLeaf, error) {
+	leaves, err := effectiveSchedulers(false, jxClient, namespace, teamSchedulerName, nil, func(spec *jenkinsv1.SourceRepositorySpec) bool {
+		return strings.EqualFold(spec.Org, org) && strings.EqualFold(spec.Repo, repo)
+	})
+	if err != nil || len(leaves) == 0 {
+		return nil, err
+	}
+	return leaves[0], nil
+}
+
+// EffectiveSchedulers returns the merged scheduler of every SourceRepository which has one
+func EffectiveSchedulers(jxClient versioned.Interface, namespace string, teamSchedulerName string) ([]*SchedulerLeaf, error) {
+	return effectiveSchedulers(false, jxClient, namespace, teamSchedulerName, nil, func(spec *jenkinsv1.SourceRepositorySpec) bool {
+		return true
+	})
+}
+
+// effectiveSchedulers returns the merged scheduler of every SourceRepository accepted by the filter which has one
+func effectiveSchedulers(gitOps bool, jxClient versioned.Interface, namespace string, teamSchedulerName string, devEnv *jenkinsv1.Environment, filter func(*jenkinsv1.SourceRepositorySpec) bool) ([]*SchedulerLeaf, error) {
+	repositories, err := findRepositorySchedulers(gitOps, jxClient, namespace, teamSchedulerName, devEnv, filter)
+	if err != nil {
+		return nil, err
+	}
+	var leaves []*SchedulerLeaf
+	for _, repository := range repositories {
+		if len(repository.Sources) < 1 {
+			continue
+		}
+		applicableSchedulers := make([]*jenkinsv1.SchedulerSpec, 0, len(repository.Sources))
+		for _, source := range repository.Sources {
+			applicableSchedulers = append(applicableSchedulers, source.Spec)
+		}
+		sourceRepo := repository.SourceRepository
+		merged, err := Build(applicableSchedulers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building scheduler for %s/%s", sourceRepo.Spec.Org, sourceRepo.Spec.Repo)
+		}
+		leaves = append(leaves, &SchedulerLeaf{
+			SchedulerSpec:    merged,
+			Org:              sourceRepo.Spec.Org,
+			Repo:             sourceRepo.Spec.Repo,
+			Provider:         sourceRepo.Spec.Provider,
+			SourceRepository: sourceRepo.Name,
+		})
+	}
+	return leaves, nil
+}
+
+// BranchMatches returns true if the branch is one of the branches of the brancher and is not skipped. The branches
+// are regular expressions which must match the whole branch name.
+func BranchMatches(brancher *jenkinsv1.Brancher, branch string) (bool, error) {
+	if brancher == nil {
+		return true, nil
+	}
+	if brancher.SkipBranches != nil {
+		matches, err := anyPatternMatches(brancher.SkipBranches.Items, branch)
+		if err != nil || matches {
+			return false, err
+		}
+	}
+	if brancher.Branches == nil || len(brancher.Branches.Items) == 0 {
+		return true, nil
+	}
+	return anyPatternMatches(brancher.Branches.Items, branch)
+}
+
+func anyPatternMatches(patterns []string, value string) (bool, error) {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid branch pattern %s", pattern)
+		}
+		if re.MatchString(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ChangesMatch returns true if the job has no RunIfChanged expression or one of the changed files matches it. If the
+// changed files are not known the job always runs.
+func ChangesMatch(matcher *jenkinsv1.RegexpChangeMatcher, changedFiles []string) (bool, error) {
+	if matcher == nil || matcher.RunIfChanged == nil || *matcher.RunIfChanged == "" || changedFiles == nil {
+		return true, nil
+	}
+	re, err := regexp.Compile(*matcher.RunIfChanged)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid runIfChanged expression %s", *matcher.RunIfChanged)
+	}
+	for _, file := range changedFiles {
+		if re.MatchString(file) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// JobName returns the name of the job of a presubmit or postsubmit
+func JobName(jobBase *jenkinsv1.JobBase) string {
+	if jobBase == nil || jobBase.Name == nil {
+		return ""
+	}
+	return *jobBase.Name
+}
+
+// PresubmitContext returns the status context of a presubmit which defaults to its name
+func PresubmitContext(job *jenkinsv1.Presubmit) string {
+	if job.Context != nil && *job.Context != "" {
+		return *job.Context
+	}
+	return JobName(job.JobBase)
+}
+
+// PostsubmitContext returns the status context of a postsubmit which defaults to its name
+func PostsubmitContext(job *jenkinsv1.Postsubmit) string {
+	if job.Context != nil && *job.Context != "" {
+		return *job.Context
+	}
+	return JobName(job.JobBase)
+}
+
+// PresubmitsToRun returns the presubmits which run automatically for a pull request to the base branch
+func PresubmitsToRun(scheduler *jenkinsv1.SchedulerSpec, baseBranch string, changedFiles []string) ([]*jenkinsv1.Presubmit, error) {
+	return filterPresubmits(scheduler, baseBranch, func(job *jenkinsv1.Presubmit) (bool, error) {
+		run, _, err := explainPresubmitRuns(job, changedFiles)
+		return run, err
+	})
+}
+
+// PresubmitsForComment returns the presubmits requested by the '/test all' or '/test <job>' commands of a comment on a
+// pull request to the base branch
+func PresubmitsForComment(scheduler *jenkinsv1.SchedulerSpec, baseBranch string, comment string) ([]*jenkinsv1.Presubmit, error) {
+	return filterPresubmits(scheduler, baseBranch, func(job *jenkinsv1.Presubmit) (bool, error) {
+		requested, _, err := presubmitRequestedByComment(job, comment)
+		return requested, err
+	})
+}
+
+// presubmitRequestedByComment returns true if the comment requests the presubmit along with the trigger expression it
+// matched, which is empty if the comment requests all jobs
+func presubmitRequestedByComment(job *jenkinsv1.Presubmit, comment string) (bool, string, error) {
+	if testAllRegex.MatchString(comment) {
+		return true, "", nil
+	}
+	trigger := fmt.Sprintf(`(?m)^/test (?:.*? )?%s(?: .*?)?$`, regexp.QuoteMeta(JobName(job.JobBase)))
+	if job.Trigger != nil && *job.Trigger != "" {
+		trigger = *job.Trigger
+	}
+	re, err := regexp.Compile(trigger)
+	if err != nil {
+		return false, trigger, errors.Wrapf(err, "invalid trigger %s of job %s", trigger, JobName(job.JobBase))
+	}
+	return re.MatchString(comment), trigger, nil
+}
+
+// IsRetestComment returns true if the comment asks for the failed presubmits to be run again
+func IsRetestComment(comment string) bool {
+	return retestRegex.MatchString(comment)
+}
+
+// IsOkToTestComment returns true if the comment approves running the presubmits of a pull request from an untrusted
+// user with '/ok-to-test'
+func IsOkToTestComment(comment string) bool {
+	return okToTestRegex.MatchString(comment)
+}
+
+// PresubmitsForContexts returns the presubmits of the base branch with one of the status contexts
+func PresubmitsForContexts(scheduler *jenkinsv1.SchedulerSpec, baseBranch string, contexts []string) ([]*jenkinsv1.Presubmit, error) {
+	return filterPresubmits(scheduler, baseBranch, func(job *jenkinsv1.Presubmit) (bool, error) {
+		context := PresubmitContext(job)
+		for _, c := range contexts {
+			if c == context {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func filterPresubmits(scheduler *jenkinsv1.SchedulerSpec, baseBranch string, filter func(*jenkinsv1.Presubmit) (bool, error)) ([]*jenkinsv1.Presubmit, error) {
+	var answer []*jenkinsv1.Presubmit
+	if scheduler == nil || scheduler.Presubmits == nil {
+		return answer, nil
+	}
+	for _, job := range scheduler.Presubmits.Items {
+		if job == nil {
+			continue
+		}
+		matches, err := BranchMatches(job.Brancher, baseBranch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "matching branches of job %s", JobName(job.JobBase))
+		}
+		if !matches {
+			continue
+		}
+		matches, err = filter(job)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			answer = append(answer, job)
+		}
+	}
+	return answer, nil
+}
+
+// PostsubmitsToRun returns the postsubmits which run for a push of the changed files to the branch
+func PostsubmitsToRun(scheduler *jenkinsv1.SchedulerSpec, branch string, changedFiles []string) ([]*jenkinsv1.Postsubmit, error) {
+	var answer []*jenkinsv1.Postsubmit
+	if scheduler == nil || scheduler.Postsubmits == nil {
+		return answer, nil
+	}
+	for _, job := range scheduler.Postsubmits.Items {
+		if job == nil {
+			continue
+		}
+		matches, err := BranchMatches(job.Brancher, branch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "matching branches of job %s", JobName(job.JobBase))
+		}
+		if !matches {
+			continue
+		}
+		matches, err = ChangesMatch(job.RegexpChangeMatcher, changedFiles)
+		if err != nil {
+			return nil, errors.Wrapf(err, "matching changes of job %s", JobName(job.JobBase))
+		}
+		if matches {
+			answer = append(answer, job)
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,190 @@
+package pipelinescheduler_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func presubmit(name string) *v1.Presubmit {
+	return &v1.Presubmit{
+		JobBase: &v1.JobBase{Name: strPtr(name)},
+	}
+}
+
+func jobNames(presubmits []*v1.Presubmit) []string {
+	answer := []string{}
+	for _, job := range presubmits {
+		answer = append(answer, pipelinescheduler.JobName(job.JobBase))
+	}
+	return answer
+}
+
+func testJobsScheduler() *v1.SchedulerSpec {
+	unit := presubmit("unit")
+	integration := presubmit("integration")
+	integration.Context = strPtr("integration-tests")
+	integration.Brancher = &v1.Brancher{
+		Branches:     &v1.ReplaceableSliceOfStrings{Items: []string{"master", "release-.*"}},
+		SkipBranches: &v1.ReplaceableSliceOfStrings{Items: []string{"release-old"}},
+	}
+	docs := presubmit("docs")
+	docs.RegexpChangeMatcher = &v1.RegexpChangeMatcher{RunIfChanged: strPtr(`^docs/`)}
+	e2e := presubmit("e2e")
+	e2e.AlwaysRun = boolPtr(false)
+	e2e.Trigger = strPtr(`(?m)^/e2e\s*$`)
+
+	return &v1.SchedulerSpec{
+		Presubmits: &v1.Presubmits{Items: []*v1.Presubmit{unit, integration, docs, e2e}},
+		Postsubmits: &v1.Postsubmits{Items: []*v1.Postsubmit{
+			{JobBase: &v1.JobBase{Name: strPtr("release")}, Brancher: &v1.Brancher{Branches: &v1.ReplaceableSliceOfStrings{Items: []string{"master"}}}},
+			{JobBase: &v1.JobBase{Name: strPtr("charts")}, RegexpChangeMatcher: &v1.RegexpChangeMatcher{RunIfChanged: strPtr(`^charts/`)}},
+		}},
+	}
+}
+
+func TestBranchMatches(t *testing.T) {
+	t.Parallel()
+	brancher := &v1.Brancher{
+		Branches:     &v1.ReplaceableSliceOfStrings{Items: []string{"master", "release-.*"}},
+		SkipBranches: &v1.ReplaceableSliceOfStrings{Items: []string{"release-old"}},
+	}
+	testCases := map[string]bool{
+		"master":         true,
+		"release-1.0":    true,
+		"release-old":    false,
+		"feature-master": false,
+		"develop":        false,
+	}
+	for branch, expected := range testCases {
+		actual, err := pipelinescheduler.BranchMatches(brancher, branch)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, branch)
+	}
+	actual, err := pipelinescheduler.BranchMatches(nil, "anything")
+	require.NoError(t, err)
+	assert.True(t, actual)
+
+	_, err = pipelinescheduler.BranchMatches(&v1.Brancher{Branches: &v1.ReplaceableSliceOfStrings{Items: []string{"("}}}, "master")
+	assert.Error(t, err)
+}
+
+func TestPresubmitsToRun(t *testing.T) {
+	t.Parallel()
+	scheduler := testJobsScheduler()
+
+	jobs, err := pipelinescheduler.PresubmitsToRun(scheduler, "master", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unit", "integration", "docs"}, jobNames(jobs), "jobs run if the changed files are unknown")
+
+	jobs, err = pipelinescheduler.PresubmitsToRun(scheduler, "develop", []string{"pkg/main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unit"}, jobNames(jobs))
+
+	jobs, err = pipelinescheduler.PresubmitsToRun(scheduler, "release-1.0", []string{"docs/README.md"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unit", "integration", "docs"}, jobNames(jobs))
+}
+
+func TestPresubmitsForComment(t *testing.T) {
+	t.Parallel()
+	scheduler := testJobsScheduler()
+
+	testCases := []struct {
+		comment  string
+		branch   string
+		expected []string
+	}{
+		{"/test all", "master", []string{"unit", "integration", "docs", "e2e"}},
+		{"/test all", "develop", []string{"unit", "docs", "e2e"}},
+		{"/test unit", "master", []string{"unit"}},
+		{"looks good\n/test docs unit", "master", []string{"unit", "docs"}},
+		{"/test integration", "develop", []string{}},
+		{"/e2e", "master", []string{"e2e"}},
+		{"/test e2e", "master", []string{}},
+		{"please test unit", "master", []string{}},
+	}
+	for _, tc := range testCases {
+		jobs, err := pipelinescheduler.PresubmitsForComment(scheduler, tc.branch, tc.comment)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, jobNames(jobs), tc.comment)
+	}
+
+	assert.True(t, pipelinescheduler.IsRetestComment("/retest"))
+	assert.True(t, pipelinescheduler.IsRetestComment("flaky\n/retest\n"))
+	assert.False(t, pipelinescheduler.IsRetestComment("/retest unit"))
+
+	assert.True(t, pipelinescheduler.IsOkToTestComment("looks safe\n/ok-to-test\n"))
+	assert.False(t, pipelinescheduler.IsOkToTestComment("is this /ok-to-test?"))
+
+	jobs, err := pipelinescheduler.PresubmitsForContexts(scheduler, "master", []string{"integration-tests", "e2e"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"integration", "e2e"}, jobNames(jobs))
+}
+
+func TestPostsubmitsToRun(t *testing.T) {
+	t.Parallel()
+	scheduler := testJobsScheduler()
+
+	jobs, err := pipelinescheduler.PostsubmitsToRun(scheduler, "master", []string{"pkg/main.go"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "release", pipelinescheduler.JobName(jobs[0].JobBase))
+	assert.Equal(t, "release", pipelinescheduler.PostsubmitContext(jobs[0]))
+
+	jobs, err = pipelinescheduler.PostsubmitsToRun(scheduler, "develop", []string{"charts/myapp/values.yaml"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "charts", pipelinescheduler.JobName(jobs[0].JobBase))
+}
+
+func TestEffectiveScheduler(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	team := &v1.Scheduler{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: ns},
+		Spec:       v1.SchedulerSpec{Presubmits: &v1.Presubmits{Items: []*v1.Presubmit{presubmit("unit")}}},
+	}
+	repoScheduler := &v1.Scheduler{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec: v1.SchedulerSpec{Postsubmits: &v1.Postsubmits{Items: []*v1.Postsubmit{
+			{JobBase: &v1.JobBase{Name: strPtr("release")}},
+		}}},
+	}
+	sourceRepo := &v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp", Namespace: ns},
+		Spec: v1.SourceRepositorySpec{
+			Org:       "myorg",
+			Repo:      "myapp",
+			Provider:  "https://github.com",
+			Scheduler: v1.ResourceReference{Name: "myapp"},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset(team, repoScheduler, sourceRepo)
+
+	scheduler, err := pipelinescheduler.EffectiveScheduler(jxClient, ns, "team", "MyOrg", "myapp")
+	require.NoError(t, err)
+	require.NotNil(t, scheduler)
+	assert.Equal(t, "myorg", scheduler.Org)
+	assert.Equal(t, "https://github.com", scheduler.Provider)
+	assert.Equal(t, []string{"unit"}, jobNames(scheduler.Presubmits.Items), "presubmits come from the team scheduler")
+	require.Len(t, scheduler.Postsubmits.Items, 1)
+	assert.Equal(t, "release", pipelinescheduler.JobName(scheduler.Postsubmits.Items[0].JobBase))
+
+	scheduler, err = pipelinescheduler.EffectiveScheduler(jxClient, ns, "team", "myorg", "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, scheduler)
+}
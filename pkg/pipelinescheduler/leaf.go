@@ -7,4 +7,8 @@ type SchedulerLeaf struct {
 	*jenkinsv1.SchedulerSpec
 	Org  string
 	Repo string
+	// Provider is the URL of the git provider of the repository if known, such as https://github.com
+	Provider string
+	// SourceRepository is the name of the SourceRepository of the repository if known
+	SourceRepository string
 }
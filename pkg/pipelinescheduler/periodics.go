@@ -0,0 +1,145 @@
+package pipelinescheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// lastPeriodicRunAnnotationPrefix prefixes the annotations of a SourceRepository which record when its periodics
+	// last ran
+	lastPeriodicRunAnnotationPrefix = "periodic.jenkins-x.io/"
+
+	// maxClaimAttempts is how many times claiming a periodic run is retried when its SourceRepository is updated
+	// concurrently
+	maxClaimAttempts = 5
+)
+
+// DuePeriodic is a periodic job of a repository which is due to run
+type DuePeriodic struct {
+	*SchedulerLeaf
+	Periodic *jenkinsv1.Periodic
+	// Scheduled is the time the job was scheduled to run
+	Scheduled time.Time
+}
+
+// PeriodicBranch returns the branch the periodic builds which defaults to master
+func PeriodicBranch(job *jenkinsv1.Periodic) string {
+	if job.Branch != nil && *job.Branch != "" {
+		return *job.Branch
+	}
+	return "master"
+}
+
+// PeriodicContext returns the pipeline context of the periodic which defaults to its name
+func PeriodicContext(job *jenkinsv1.Periodic) string {
+	if job.Context != nil && *job.Context != "" {
+		return *job.Context
+	}
+	return JobName(job.JobBase)
+}
+
+// PeriodicTracker finds the periodics which are due by remembering when the periodics of each repository were last
+// checked
+type PeriodicTracker struct {
+	lock        sync.Mutex
+	lastChecked map[string]time.Time
+}
+
+// NewPeriodicTracker creates a PeriodicTracker
+func NewPeriodicTracker() *PeriodicTracker {
+	return &PeriodicTracker{
+		lastChecked: map[string]time.Time{},
+	}
+}
+
+// Due returns the periodics of the schedulers which were scheduled to run since they were last checked. A periodic
+// seen for the first time is not due until its next scheduled time so that restarts do not trigger missed runs.
+// Periodics with invalid cron expressions are skipped with a warning.
+func (t *PeriodicTracker) Due(leaves []*SchedulerLeaf, now time.Time) []*DuePeriodic {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var answer []*DuePeriodic
+	checked := map[string]time.Time{}
+	for _, leaf := range leaves {
+		if leaf.SchedulerSpec == nil || leaf.Periodics == nil {
+			continue
+		}
+		for _, job := range leaf.Periodics.Items {
+			if job == nil || job.Cron == nil {
+				continue
+			}
+			name := JobName(job.JobBase)
+			key := leaf.Org + "/" + leaf.Repo + "/" + name + "/" + *job.Cron
+			checked[key] = now
+			last, ok := t.lastChecked[key]
+			if !ok {
+				continue
+			}
+			schedule, err := ParseCron(*job.Cron)
+			if err != nil {
+				log.Warnf("Ignoring periodic %s of %s/%s: %s\n", name, leaf.Org, leaf.Repo, err)
+				continue
+			}
+			next := schedule.Next(last)
+			if next.IsZero() || next.After(now) {
+				continue
+			}
+			answer = append(answer, &DuePeriodic{
+				SchedulerLeaf: leaf,
+				Periodic:      job,
+				Scheduled:     next,
+			})
+		}
+	}
+	t.lastChecked = checked
+	return answer
+}
+
+// LastPeriodicRunAnnotation returns the annotation of a SourceRepository which records when the periodic last ran
+func LastPeriodicRunAnnotation(job *jenkinsv1.Periodic) string {
+	return lastPeriodicRunAnnotationPrefix + kube.ToValidNameTruncated(JobName(job.JobBase), 63)
+}
+
+// ClaimPeriodicRun records the scheduled time of the due periodic as its last run on the SourceRepository of its
+// repository. It returns false if the run was already recorded, such as by another replica of the periodic controller,
+// so that each scheduled run is triggered once however many controllers find it due.
+func ClaimPeriodicRun(jxClient versioned.Interface, ns string, due *DuePeriodic) (bool, error) {
+	if due.SourceRepository == "" {
+		return false, fmt.Errorf("no SourceRepository for the periodic %s of %s/%s", JobName(due.Periodic.JobBase), due.Org, due.Repo)
+	}
+	sourceRepositories := jxClient.JenkinsV1().SourceRepositories(ns)
+	annotation := LastPeriodicRunAnnotation(due.Periodic)
+	scheduled := due.Scheduled.UTC().Format(time.RFC3339)
+	for i := 0; ; i++ {
+		sourceRepo, err := sourceRepositories.Get(due.SourceRepository, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get SourceRepository %s in namespace %s", due.SourceRepository, ns)
+		}
+		lastRun, err := time.Parse(time.RFC3339, sourceRepo.Annotations[annotation])
+		if err == nil && !lastRun.Before(due.Scheduled.Truncate(time.Second)) {
+			return false, nil
+		}
+		if sourceRepo.Annotations == nil {
+			sourceRepo.Annotations = map[string]string{}
+		}
+		sourceRepo.Annotations[annotation] = scheduled
+		_, err = sourceRepositories.Update(sourceRepo)
+		if err == nil {
+			return true, nil
+		}
+		if !apierrors.IsConflict(err) || i >= maxClaimAttempts {
+			return false, errors.Wrapf(err, "failed to record the last run of the periodic %s on SourceRepository %s", JobName(due.Periodic.JobBase), due.SourceRepository)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pipelinescheduler_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPeriodicTrackerDue(t *testing.T) {
+	t.Parallel()
+	leaves := []*pipelinescheduler.SchedulerLeaf{
+		{
+			Org:  "myorg",
+			Repo: "myapp",
+			SchedulerSpec: &v1.SchedulerSpec{
+				Periodics: &v1.Periodics{Items: []*v1.Periodic{
+					{JobBase: &v1.JobBase{Name: strPtr("nightly")}, Cron: strPtr("0 2 * * *")},
+					{JobBase: &v1.JobBase{Name: strPtr("hourly")}, Cron: strPtr("@hourly"), Branch: strPtr("develop")},
+					{JobBase: &v1.JobBase{Name: strPtr("broken")}, Cron: strPtr("not a cron")},
+				}},
+			},
+		},
+		{Org: "myorg", Repo: "other", SchedulerSpec: &v1.SchedulerSpec{}},
+	}
+	tracker := pipelinescheduler.NewPeriodicTracker()
+	start := time.Date(2019, 5, 10, 1, 59, 30, 0, time.UTC)
+
+	assert.Empty(t, tracker.Due(leaves, start), "nothing is due the first time periodics are seen")
+	assert.Empty(t, tracker.Due(leaves, start.Add(20*time.Second)))
+
+	due := tracker.Due(leaves, start.Add(40*time.Second))
+	require.Len(t, due, 2)
+	assert.Equal(t, "nightly", pipelinescheduler.JobName(due[0].Periodic.JobBase))
+	assert.Equal(t, "master", pipelinescheduler.PeriodicBranch(due[0].Periodic))
+	assert.Equal(t, "nightly", pipelinescheduler.PeriodicContext(due[0].Periodic))
+	assert.Equal(t, time.Date(2019, 5, 10, 2, 0, 0, 0, time.UTC), due[0].Scheduled)
+	assert.Equal(t, "hourly", pipelinescheduler.JobName(due[1].Periodic.JobBase))
+	assert.Equal(t, "develop", pipelinescheduler.PeriodicBranch(due[1].Periodic))
+	assert.Equal(t, "myapp", due[1].Repo)
+
+	assert.Empty(t, tracker.Due(leaves, start.Add(time.Minute)), "periodics only run once per scheduled time")
+	due = tracker.Due(leaves, start.Add(time.Hour+time.Minute))
+	require.Len(t, due, 1)
+	assert.Equal(t, "hourly", pipelinescheduler.JobName(due[0].Periodic.JobBase))
+}
+
+func TestClaimPeriodicRun(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	jxClient := jxfake.NewSimpleClientset(&v1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp", Namespace: ns},
+		Spec:       v1.SourceRepositorySpec{Org: "myorg", Repo: "myapp"},
+	})
+	leaf := &pipelinescheduler.SchedulerLeaf{Org: "myorg", Repo: "myapp", SourceRepository: "myorg-myapp"}
+	nightly := &v1.Periodic{JobBase: &v1.JobBase{Name: strPtr("nightly")}, Cron: strPtr("0 2 * * *")}
+	scheduled := time.Date(2019, 5, 10, 2, 0, 0, 0, time.UTC)
+	due := &pipelinescheduler.DuePeriodic{SchedulerLeaf: leaf, Periodic: nightly, Scheduled: scheduled}
+
+	claimed, err := pipelinescheduler.ClaimPeriodicRun(jxClient, ns, due)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = pipelinescheduler.ClaimPeriodicRun(jxClient, ns, due)
+	require.NoError(t, err)
+	assert.False(t, claimed, "another replica already triggered the run")
+
+	sourceRepo, err := jxClient.JenkinsV1().SourceRepositories(ns).Get("myorg-myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "2019-05-10T02:00:00Z", sourceRepo.Annotations[pipelinescheduler.LastPeriodicRunAnnotation(nightly)])
+
+	next := &pipelinescheduler.DuePeriodic{SchedulerLeaf: leaf, Periodic: nightly, Scheduled: scheduled.Add(24 * time.Hour)}
+	claimed, err = pipelinescheduler.ClaimPeriodicRun(jxClient, ns, next)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	_, err = pipelinescheduler.ClaimPeriodicRun(jxClient, ns, &pipelinescheduler.DuePeriodic{
+		SchedulerLeaf: &pipelinescheduler.SchedulerLeaf{Org: "myorg", Repo: "missing"},
+		Periodic:      nightly,
+		Scheduled:     scheduled,
+	})
+	assert.Error(t, err)
+}
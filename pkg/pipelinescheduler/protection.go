@@ -0,0 +1,117 @@
+package pipelinescheduler
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/util"
+)
+
+// BranchProtectionPolicy returns the protection policy of a branch merged from the global policy of the scheduler,
+// the policies of its presubmits and their policies for the branch, from the least to the most specific
+func BranchProtectionPolicy(spec *jenkinsv1.SchedulerSpec, branch string) *jenkinsv1.ProtectionPolicy {
+	answer := &jenkinsv1.ProtectionPolicy{}
+	if spec.Presubmits != nil {
+		// the presubmits are in the order they are merged so the policies of the later ones are more specific
+		for i := len(spec.Presubmits.Items) - 1; i >= 0; i-- {
+			policies := spec.Presubmits.Items[i].Policy
+			if policies == nil {
+				continue
+			}
+			if policy, ok := policies.Items[branch]; ok && policy != nil {
+				applyToBranchProtectionPolicy(policy.DeepCopy(), answer)
+			}
+		}
+		for i := len(spec.Presubmits.Items) - 1; i >= 0; i-- {
+			policies := spec.Presubmits.Items[i].Policy
+			if policies != nil && policies.ProtectionPolicy != nil {
+				applyToBranchProtectionPolicy(policies.ProtectionPolicy.DeepCopy(), answer)
+			}
+		}
+	}
+	if spec.Policy != nil && spec.Policy.ProtectionPolicy != nil {
+		applyToBranchProtectionPolicy(spec.Policy.ProtectionPolicy.DeepCopy(), answer)
+	}
+	return answer
+}
+
+// applyToBranchProtectionPolicy applies a less specific policy to the policy of a branch. Unlike Build, which keeps
+// the required status checks of a child scheduler in place of those of its parent as the generated Prow config
+// always has, the contexts required by the global, presubmit and branch policies of a scheduler are all required.
+func applyToBranchProtectionPolicy(parent *jenkinsv1.ProtectionPolicy, child *jenkinsv1.ProtectionPolicy) {
+	applyToProtectionPolicy(parent, child)
+	if child.RequiredStatusChecks == nil {
+		child.RequiredStatusChecks = parent.RequiredStatusChecks
+	} else if parent.RequiredStatusChecks != nil {
+		checks := child.RequiredStatusChecks
+		if checks.Contexts == nil {
+			checks.Contexts = parent.RequiredStatusChecks.Contexts
+		} else if parent.RequiredStatusChecks.Contexts != nil {
+			applyToReplaceableSliceOfStrings(parent.RequiredStatusChecks.Contexts, checks.Contexts)
+		}
+		if checks.Strict == nil {
+			checks.Strict = parent.RequiredStatusChecks.Strict
+		}
+	}
+}
+
+// BranchProtection returns the protection the scheduler requires for a branch, or nil if the branch should not be
+// protected. It returns false if the scheduler does not manage the protection of the branch.
+//
+// If the scheduler protects tested branches the status contexts of the presubmits which run against the branch and
+// report their status are required unless they are optional.
+func BranchProtection(spec *jenkinsv1.SchedulerSpec, branch string) (*gits.GitBranchProtection, bool, error) {
+	policy := BranchProtectionPolicy(spec, branch)
+
+	contexts := []string{}
+	if policy.RequiredStatusChecks != nil && policy.RequiredStatusChecks.Contexts != nil {
+		contexts = append(contexts, policy.RequiredStatusChecks.Contexts.Items...)
+	}
+	tested := false
+	if spec.Policy != nil && spec.Policy.ProtectTested != nil && *spec.Policy.ProtectTested && spec.Presubmits != nil {
+		for _, job := range spec.Presubmits.Items {
+			if (job.Optional != nil && *job.Optional) || (job.Report != nil && !*job.Report) {
+				continue
+			}
+			matches, err := BranchMatches(job.Brancher, branch)
+			if err != nil {
+				return nil, false, err
+			}
+			if matches {
+				tested = true
+				contexts = append(contexts, PresubmitContext(job))
+			}
+		}
+	}
+
+	switch {
+	case policy.Protect != nil && !*policy.Protect:
+		return nil, true, nil
+	case policy.Protect == nil && !tested:
+		return nil, false, nil
+	}
+
+	answer := &gits.GitBranchProtection{}
+	for _, context := range contexts {
+		if util.StringArrayIndex(answer.RequiredContexts, context) < 0 {
+			answer.RequiredContexts = append(answer.RequiredContexts, context)
+		}
+	}
+	if policy.RequiredStatusChecks != nil && policy.RequiredStatusChecks.Strict != nil {
+		answer.Strict = *policy.RequiredStatusChecks.Strict
+	}
+	if policy.Admins != nil {
+		answer.EnforceAdmins = *policy.Admins
+	}
+	if reviews := policy.RequiredPullRequestReviews; reviews != nil {
+		if reviews.Approvals != nil {
+			answer.RequiredApprovingReviewCount = *reviews.Approvals
+		}
+		if reviews.DismissStale != nil {
+			answer.DismissStaleReviews = *reviews.DismissStale
+		}
+		if reviews.RequireOwners != nil {
+			answer.RequireCodeOwnerReviews = *reviews.RequireOwners
+		}
+	}
+	return answer, true, nil
+}
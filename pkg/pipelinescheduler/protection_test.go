@@ -0,0 +1,140 @@
+package pipelinescheduler_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/pipelinescheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func testProtectionScheduler() *v1.SchedulerSpec {
+	spec := testJobsScheduler()
+	spec.Policy = &v1.GlobalProtectionPolicy{
+		ProtectTested: boolPtr(true),
+		ProtectionPolicy: &v1.ProtectionPolicy{
+			RequiredStatusChecks: &v1.BranchProtectionContextPolicy{
+				Contexts: &v1.ReplaceableSliceOfStrings{Items: []string{"license"}},
+			},
+			RequiredPullRequestReviews: &v1.ReviewPolicy{
+				Approvals:    intPtr(1),
+				DismissStale: boolPtr(true),
+			},
+		},
+	}
+	spec.Presubmits.Items[3].Optional = boolPtr(true)
+	spec.Presubmits.Items[0].Policy = &v1.ProtectionPolicies{
+		ProtectionPolicy: &v1.ProtectionPolicy{
+			Admins: boolPtr(true),
+		},
+		Items: map[string]*v1.ProtectionPolicy{
+			"master": {
+				RequiredPullRequestReviews: &v1.ReviewPolicy{
+					Approvals: intPtr(2),
+				},
+			},
+			"gh-pages": {
+				Protect: boolPtr(false),
+			},
+		},
+	}
+	return spec
+}
+
+func TestBranchProtection(t *testing.T) {
+	t.Parallel()
+	spec := testProtectionScheduler()
+
+	protection, managed, err := pipelinescheduler.BranchProtection(spec, "master")
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.Equal(t, &gits.GitBranchProtection{
+		RequiredContexts:             []string{"license", "unit", "integration-tests", "docs"},
+		RequiredApprovingReviewCount: 2,
+		DismissStaleReviews:          true,
+		EnforceAdmins:                true,
+	}, protection)
+
+	protection, managed, err = pipelinescheduler.BranchProtection(spec, "feature")
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.Equal(t, []string{"license", "unit", "docs"}, protection.RequiredContexts)
+	assert.Equal(t, 1, protection.RequiredApprovingReviewCount)
+
+	protection, managed, err = pipelinescheduler.BranchProtection(spec, "gh-pages")
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.Nil(t, protection)
+
+	// the policies are not modified
+	assert.Equal(t, testProtectionScheduler(), spec)
+}
+
+func TestBranchProtectionUnmanaged(t *testing.T) {
+	t.Parallel()
+	spec := testJobsScheduler()
+
+	protection, managed, err := pipelinescheduler.BranchProtection(spec, "master")
+	require.NoError(t, err)
+	assert.False(t, managed)
+	assert.Nil(t, protection)
+
+	spec.Policy = &v1.GlobalProtectionPolicy{
+		ProtectionPolicy: &v1.ProtectionPolicy{
+			Protect: boolPtr(true),
+			RequiredStatusChecks: &v1.BranchProtectionContextPolicy{
+				Contexts: &v1.ReplaceableSliceOfStrings{Items: []string{"license"}},
+				Strict:   boolPtr(true),
+			},
+		},
+	}
+	protection, managed, err = pipelinescheduler.BranchProtection(spec, "master")
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.Equal(t, &gits.GitBranchProtection{
+		RequiredContexts: []string{"license"},
+		Strict:           true,
+	}, protection)
+}
+
+func TestBranchProtectionMergesRequiredStatusChecks(t *testing.T) {
+	t.Parallel()
+	spec := testJobsScheduler()
+	spec.Policy = &v1.GlobalProtectionPolicy{
+		ProtectionPolicy: &v1.ProtectionPolicy{
+			Protect: boolPtr(true),
+			RequiredStatusChecks: &v1.BranchProtectionContextPolicy{
+				Contexts: &v1.ReplaceableSliceOfStrings{Items: []string{"license"}},
+				Strict:   boolPtr(true),
+			},
+		},
+	}
+	spec.Presubmits.Items[0].Policy = &v1.ProtectionPolicies{
+		Items: map[string]*v1.ProtectionPolicy{
+			"master": {
+				RequiredStatusChecks: &v1.BranchProtectionContextPolicy{
+					Contexts: &v1.ReplaceableSliceOfStrings{Items: []string{"lint"}},
+				},
+			},
+		},
+	}
+
+	protection, managed, err := pipelinescheduler.BranchProtection(spec, "master")
+	require.NoError(t, err)
+	assert.True(t, managed)
+	assert.Equal(t, &gits.GitBranchProtection{
+		RequiredContexts: []string{"lint", "license"},
+		Strict:           true,
+	}, protection)
+
+	spec.Presubmits.Items[0].Policy.Items["master"].RequiredStatusChecks.Contexts.Replace = true
+	protection, _, err = pipelinescheduler.BranchProtection(spec, "master")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lint"}, protection.RequiredContexts)
+}
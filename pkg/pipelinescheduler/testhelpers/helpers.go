@@ -89,6 +89,17 @@ func CompleteScheduler() *v1.SchedulerSpec {
 				},
 			},
 		},
+		Periodics: &v1.Periodics{
+			Items: []*v1.Periodic{
+				{
+					JobBase:    pointerToJobBase(),
+					Cron:       pointerToUUID(),
+					Branch:     pointerToUUID(),
+					Context:    pointerToUUID(),
+					Parameters: PointerToReplaceableMapOfStringString(),
+				},
+			},
+		},
 		Trigger: &v1.Trigger{
 			IgnoreOkToTest: pointerToTrue(),
 			JoinOrgURL:     pointerToUUID(),
@@ -0,0 +1,283 @@
+package tekton
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxClient "github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	knativeapis "github.com/knative/pkg/apis"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// maxCancelAttempts is how many times cancelling is retried when a resource is updated concurrently
+	maxCancelAttempts = 5
+
+	// ConcurrencyLockTTL is how long a concurrency lock is held before it is treated as abandoned by a crashed holder
+	ConcurrencyLockTTL = time.Minute
+
+	// ConcurrencyLockTimeout is how long LockConcurrency waits for a concurrency lock
+	ConcurrencyLockTimeout = 2 * time.Minute
+
+	// concurrencyLockHolderAnnotation records the holder of a concurrency lock
+	concurrencyLockHolderAnnotation = "jenkins-x.io/lock-holder"
+	// concurrencyLockAcquiredAnnotation records when a concurrency lock was acquired
+	concurrencyLockAcquiredAnnotation = "jenkins-x.io/lock-acquired"
+)
+
+// concurrencyLockRetryInterval is how long LockConcurrency waits before retrying a held lock
+var concurrencyLockRetryInterval = 500 * time.Millisecond
+
+// ConcurrencyLimits limits how many PipelineRuns of a repository run at the same time. Zero means unlimited.
+type ConcurrencyLimits struct {
+	MaxRuns          int
+	MaxRunsPerBranch int
+}
+
+// IsUnlimited returns true if no limits are set
+func (l ConcurrencyLimits) IsUnlimited() bool {
+	return l.MaxRuns <= 0 && l.MaxRunsPerBranch <= 0
+}
+
+// IsPipelineRunActive returns true if the PipelineRun has neither completed nor been cancelled
+func IsPipelineRunActive(run *v1alpha1.PipelineRun) bool {
+	if run.Spec.Status == v1alpha1.PipelineRunSpecStatusCancelled {
+		return false
+	}
+	condition := run.Status.GetCondition(knativeapis.ConditionSucceeded)
+	return condition == nil || condition.Status == corev1.ConditionUnknown
+}
+
+// ActivePipelineRuns returns the active PipelineRuns of a git repository, oldest first
+func ActivePipelineRuns(tektonClient tektonclient.Interface, ns string, owner string, repo string) ([]*v1alpha1.PipelineRun, error) {
+	selector := labels.Set{"owner": owner, "repo": repo}.AsSelector().String()
+	list, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineRuns in namespace %s with selector %s", ns, selector)
+	}
+	var answer []*v1alpha1.PipelineRun
+	for i := range list.Items {
+		run := &list.Items[i]
+		if IsPipelineRunActive(run) {
+			answer = append(answer, run)
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].CreationTimestamp.Before(&answer[j].CreationTimestamp)
+	})
+	return answer, nil
+}
+
+// ConcurrencyLimitReached returns a description of the limit which stops another PipelineRun of the branch from
+// starting, or an empty string if it can start
+func ConcurrencyLimitReached(active []*v1alpha1.PipelineRun, branch string, limits ConcurrencyLimits) string {
+	if limits.MaxRuns > 0 && len(active) >= limits.MaxRuns {
+		return fmt.Sprintf("%d pipelines of the repository are running which is the maximum", len(active))
+	}
+	if limits.MaxRunsPerBranch > 0 {
+		count := 0
+		for _, run := range active {
+			if run.Labels["branch"] == branch {
+				count++
+			}
+		}
+		if count >= limits.MaxRunsPerBranch {
+			return fmt.Sprintf("%d pipelines of branch %s are running which is the maximum", count, branch)
+		}
+	}
+	return ""
+}
+
+// SupersededPipelineRuns returns the active PipelineRuns of the branch and context which build a different revision
+// and are superseded by the given build of the revision. Only runs with an earlier build number are superseded, as the
+// build numbers are assigned when the pipelines are triggered, so a pipeline which waited in the queue does not
+// supersede the runs triggered after it.
+func SupersededPipelineRuns(active []*v1alpha1.PipelineRun, branch string, context string, revision string, build string) []*v1alpha1.PipelineRun {
+	var answer []*v1alpha1.PipelineRun
+	for _, run := range sameBranchOtherRevision(active, branch, context, revision) {
+		if compareBuildNumbers(PipelineRunBuildNumber(run), build) < 0 {
+			answer = append(answer, run)
+		}
+	}
+	return answer
+}
+
+// NewerPipelineRun returns an active PipelineRun of the branch and context which builds a different revision and has
+// a later build number than the given build, so supersedes it, or nil if there is none
+func NewerPipelineRun(active []*v1alpha1.PipelineRun, branch string, context string, revision string, build string) *v1alpha1.PipelineRun {
+	for _, run := range sameBranchOtherRevision(active, branch, context, revision) {
+		if compareBuildNumbers(PipelineRunBuildNumber(run), build) > 0 {
+			return run
+		}
+	}
+	return nil
+}
+
+// PipelineRunBuildNumber returns the build number of a PipelineRun from its build label, or from its build_id
+// parameter for runs created before the label was added
+func PipelineRunBuildNumber(run *v1alpha1.PipelineRun) string {
+	if build := run.Labels[v1.LabelBuild]; build != "" {
+		return build
+	}
+	for _, param := range run.Spec.Params {
+		if param.Name == "build_id" {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+func sameBranchOtherRevision(active []*v1alpha1.PipelineRun, branch string, context string, revision string) []*v1alpha1.PipelineRun {
+	var answer []*v1alpha1.PipelineRun
+	for _, run := range active {
+		if run.Labels["branch"] != branch || run.Labels["context"] != context {
+			continue
+		}
+		runRevision := run.Annotations[RevisionAnnotation]
+		if runRevision != "" && runRevision != revision {
+			answer = append(answer, run)
+		}
+	}
+	return answer
+}
+
+// compareBuildNumbers returns -1, 0 or 1 if build a is before, the same as or after build b. Builds which are not
+// numbers cannot be ordered so are treated as the same.
+func compareBuildNumbers(a string, b string) int {
+	x, err := strconv.Atoi(a)
+	if err != nil {
+		return 0
+	}
+	y, err := strconv.Atoi(b)
+	if err != nil {
+		return 0
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// CancelPipelineRun cancels the PipelineRun and marks its PipelineActivity as cancelled
+func CancelPipelineRun(tektonClient tektonclient.Interface, jxClient jxClient.Interface, ns string, name string) error {
+	runs := tektonClient.TektonV1alpha1().PipelineRuns(ns)
+	activityName := ""
+	for i := 0; ; i++ {
+		run, err := runs.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get PipelineRun %s in namespace %s", name, ns)
+		}
+		activityName = run.Annotations[ActivityAnnotation]
+		if !IsPipelineRunActive(run) {
+			return nil
+		}
+		run.Spec.Status = v1alpha1.PipelineRunSpecStatusCancelled
+		_, err = runs.Update(run)
+		if err == nil {
+			break
+		}
+		if !apierrors.IsConflict(err) || i >= maxCancelAttempts {
+			return errors.Wrapf(err, "failed to cancel PipelineRun %s in namespace %s", name, ns)
+		}
+	}
+	if activityName == "" {
+		return nil
+	}
+
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	for i := 0; ; i++ {
+		activity, err := activities.Get(activityName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.Wrapf(err, "failed to get PipelineActivity %s in namespace %s", activityName, ns)
+		}
+		if activity.Spec.Status.IsTerminated() {
+			return nil
+		}
+		kube.CancelActivity(activity)
+		_, err = activities.Update(activity)
+		if err == nil {
+			return nil
+		}
+		if !apierrors.IsConflict(err) || i >= maxCancelAttempts {
+			return errors.Wrapf(err, "failed to update PipelineActivity %s in namespace %s", activityName, ns)
+		}
+	}
+}
+
+// ConcurrencyLockName returns the name of the ConfigMap which locks the concurrency limits of a git repository
+func ConcurrencyLockName(owner string, repo string) string {
+	return kube.ToValidName("jx-concurrency-" + owner + "-" + repo)
+}
+
+// LockConcurrency takes the lock on the concurrency limits of a git repository. The lock is a ConfigMap so that it is
+// shared by every process using the namespace, such as the replicas of the pipeline runner. A lock which has been held
+// for longer than ConcurrencyLockTTL is taken over. The returned function releases the lock.
+func LockConcurrency(kubeClient kubernetes.Interface, ns string, owner string, repo string, holder string) (func(), error) {
+	configMaps := kubeClient.CoreV1().ConfigMaps(ns)
+	name := ConcurrencyLockName(owner, repo)
+	deadline := time.Now().Add(ConcurrencyLockTimeout)
+	for {
+		lock := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"owner": owner, "repo": repo},
+				Annotations: map[string]string{
+					concurrencyLockHolderAnnotation:   holder,
+					concurrencyLockAcquiredAnnotation: time.Now().UTC().Format(time.RFC3339),
+				},
+			},
+		}
+		created, err := configMaps.Create(lock)
+		if err == nil {
+			return func() {
+				uid := created.UID
+				err := configMaps.Delete(name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+				if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+					log.Warnf("failed to release the concurrency lock %s in namespace %s: %s\n", name, ns, err)
+				}
+			}, nil
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, errors.Wrapf(err, "failed to create the concurrency lock %s in namespace %s", name, ns)
+		}
+
+		existing, err := configMaps.Get(name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get the concurrency lock %s in namespace %s", name, ns)
+		}
+		acquired, err := time.Parse(time.RFC3339, existing.Annotations[concurrencyLockAcquiredAnnotation])
+		if err != nil || time.Since(acquired) > ConcurrencyLockTTL {
+			uid := existing.UID
+			err = configMaps.Delete(name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+			if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+				return nil, errors.Wrapf(err, "failed to take over the abandoned concurrency lock %s in namespace %s", name, ns)
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the concurrency lock %s in namespace %s held by %s", name, ns, existing.Annotations[concurrencyLockHolderAnnotation])
+		}
+		time.Sleep(concurrencyLockRetryInterval)
+	}
+}
@@ -0,0 +1,176 @@
+package tekton_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func concurrencyTestRun(name string, branch string, revision string, age time.Duration) *v1alpha1.PipelineRun {
+	return &v1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "jx",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Labels: map[string]string{
+				"owner":       "myorg",
+				"repo":        "myapp",
+				"branch":      branch,
+				v1.LabelBuild: name[strings.LastIndex(name, "-")+1:],
+			},
+			Annotations: map[string]string{
+				tekton.RevisionAnnotation: revision,
+				tekton.ActivityAnnotation: "myorg-myapp-" + name,
+			},
+		},
+	}
+}
+
+func TestConcurrencyLimitReached(t *testing.T) {
+	t.Parallel()
+	active := []*v1alpha1.PipelineRun{
+		concurrencyTestRun("pr-1-1", "PR-1", "abc", time.Minute),
+		concurrencyTestRun("pr-1-2", "PR-1", "def", time.Second),
+		concurrencyTestRun("master-1", "master", "123", time.Second),
+	}
+
+	assert.Empty(t, tekton.ConcurrencyLimitReached(active, "PR-1", tekton.ConcurrencyLimits{}))
+	assert.Empty(t, tekton.ConcurrencyLimitReached(active, "PR-2", tekton.ConcurrencyLimits{MaxRunsPerBranch: 1}))
+	assert.NotEmpty(t, tekton.ConcurrencyLimitReached(active, "master", tekton.ConcurrencyLimits{MaxRunsPerBranch: 1}))
+	assert.Empty(t, tekton.ConcurrencyLimitReached(active, "PR-1", tekton.ConcurrencyLimits{MaxRunsPerBranch: 3}))
+	assert.NotEmpty(t, tekton.ConcurrencyLimitReached(active, "PR-2", tekton.ConcurrencyLimits{MaxRuns: 3}))
+}
+
+func TestSupersededPipelineRuns(t *testing.T) {
+	t.Parallel()
+	active := []*v1alpha1.PipelineRun{
+		concurrencyTestRun("pr-1-1", "PR-1", "abc", time.Minute),
+		concurrencyTestRun("pr-1-2", "PR-1", "def", time.Second),
+		concurrencyTestRun("pr-2-1", "PR-2", "abc", time.Second),
+	}
+
+	superseded := tekton.SupersededPipelineRuns(active, "PR-1", "", "def", "3")
+	require.Len(t, superseded, 1)
+	assert.Equal(t, "pr-1-1", superseded[0].Name)
+	assert.Empty(t, tekton.SupersededPipelineRuns(active, "PR-1", "integration", "xyz", "3"), "other contexts are not superseded")
+
+	// a pipeline which waited in the queue does not supersede the runs triggered after it
+	superseded = tekton.SupersededPipelineRuns(active, "PR-1", "", "xyz", "2")
+	require.Len(t, superseded, 1)
+	assert.Equal(t, "pr-1-1", superseded[0].Name)
+	assert.Empty(t, tekton.SupersededPipelineRuns(active, "PR-1", "", "xyz", "1"))
+}
+
+func TestNewerPipelineRun(t *testing.T) {
+	t.Parallel()
+	active := []*v1alpha1.PipelineRun{
+		concurrencyTestRun("pr-1-1", "PR-1", "abc", time.Minute),
+		concurrencyTestRun("pr-1-3", "PR-1", "def", time.Second),
+	}
+
+	newer := tekton.NewerPipelineRun(active, "PR-1", "", "xyz", "2")
+	require.NotNil(t, newer)
+	assert.Equal(t, "pr-1-3", newer.Name)
+	assert.Nil(t, tekton.NewerPipelineRun(active, "PR-1", "", "def", "2"), "a newer run of the same revision does not supersede the pipeline")
+	assert.Nil(t, tekton.NewerPipelineRun(active, "PR-1", "", "xyz", "4"))
+	assert.Nil(t, tekton.NewerPipelineRun(active, "PR-2", "", "xyz", "2"))
+}
+
+func TestCancelPipelineRun(t *testing.T) {
+	t.Parallel()
+	older := concurrencyTestRun("pr-1-1", "PR-1", "abc", time.Minute)
+	newer := concurrencyTestRun("pr-1-2", "PR-1", "def", time.Second)
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-pr-1-1", Namespace: "jx"},
+		Spec: v1.PipelineActivitySpec{
+			Status: v1.ActivityStatusTypeRunning,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "Build", Status: v1.ActivityStatusTypeRunning},
+						Steps: []v1.CoreActivityStep{
+							{Name: "compile", Status: v1.ActivityStatusTypeSucceeded},
+							{Name: "test", Status: v1.ActivityStatusTypeRunning},
+							{Name: "deploy", Status: v1.ActivityStatusTypePending},
+						},
+					},
+				},
+			},
+		},
+	}
+	tektonClient := tektonfake.NewSimpleClientset(older, newer)
+	jxClient := jxfake.NewSimpleClientset(activity)
+
+	active, err := tekton.ActivePipelineRuns(tektonClient, "jx", "myorg", "myapp")
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	assert.Equal(t, "pr-1-1", active[0].Name, "runs should be sorted oldest first")
+
+	err = tekton.CancelPipelineRun(tektonClient, jxClient, "jx", older.Name)
+	require.NoError(t, err)
+
+	run, err := tektonClient.TektonV1alpha1().PipelineRuns("jx").Get(older.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PipelineRunSpecStatusCancelled, run.Spec.Status)
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities("jx").Get(activity.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ActivityStatusTypeCancelled, updated.Spec.Status)
+	assert.NotNil(t, updated.Spec.CompletedTimestamp)
+	stage := updated.Spec.Steps[0].Stage
+	assert.Equal(t, v1.ActivityStatusTypeCancelled, stage.Status)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, stage.Steps[0].Status)
+	assert.Equal(t, v1.ActivityStatusTypeCancelled, stage.Steps[1].Status)
+	assert.Equal(t, v1.ActivityStatusTypeCancelled, stage.Steps[2].Status)
+
+	active, err = tekton.ActivePipelineRuns(tektonClient, "jx", "myorg", "myapp")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "pr-1-2", active[0].Name)
+}
+
+func TestLockConcurrency(t *testing.T) {
+	t.Parallel()
+	kubeClient := kubefake.NewSimpleClientset()
+	name := tekton.ConcurrencyLockName("myorg", "myapp")
+
+	release, err := tekton.LockConcurrency(kubeClient, "jx", "myorg", "myapp", "pipelinerunner-1")
+	require.NoError(t, err)
+	lock, err := kubeClient.CoreV1().ConfigMaps("jx").Get(name, metav1.GetOptions{})
+	require.NoError(t, err, "the lock is held in the cluster so other replicas see it")
+	assert.Equal(t, "pipelinerunner-1", lock.Annotations["jenkins-x.io/lock-holder"])
+
+	release()
+	_, err = kubeClient.CoreV1().ConfigMaps("jx").Get(name, metav1.GetOptions{})
+	assert.Error(t, err, "releasing deletes the lock")
+
+	// a lock abandoned by a crashed holder is taken over
+	_, err = kubeClient.CoreV1().ConfigMaps("jx").Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				"jenkins-x.io/lock-holder":   "pipelinerunner-2",
+				"jenkins-x.io/lock-acquired": time.Now().Add(-2 * tekton.ConcurrencyLockTTL).UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	require.NoError(t, err)
+	release, err = tekton.LockConcurrency(kubeClient, "jx", "myorg", "myapp", "pipelinerunner-1")
+	require.NoError(t, err)
+	lock, err = kubeClient.CoreV1().ConfigMaps("jx").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "pipelinerunner-1", lock.Annotations["jenkins-x.io/lock-holder"])
+	release()
+}
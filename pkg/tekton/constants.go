@@ -3,4 +3,10 @@ package tekton
 const (
 	// LastBuildNumberAnnotationPrefix used to annotate SourceRepository with the latest build number for a branch
 	LastBuildNumberAnnotationPrefix = "jenkins.io/last-build-number-for-"
+
+	// RevisionAnnotation is the annotation on a PipelineRun with the git revision it builds
+	RevisionAnnotation = "jenkins.io/revision"
+
+	// ActivityAnnotation is the annotation on a PipelineRun with the name of its PipelineActivity
+	ActivityAnnotation = "jenkins.io/pipeline-activity"
 )
@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+)
+
+const (
+	// FormatDOT renders the graph in the Graphviz DOT language
+	FormatDOT = "dot"
+	// FormatMermaid renders the graph as a Mermaid flowchart
+	FormatMermaid = "mermaid"
+)
+
+// Formats the available output formats
+var Formats = []string{FormatDOT, FormatMermaid}
+
+// Node is a stage of a pipeline. Stages with steps, or which run as a Task, are drawn as nodes while stages
+// which only contain nested sequential or parallel stages are drawn as groups.
+type Node struct {
+	Name string
+	// Parents are the names of the enclosing stages with the top-level stage first
+	Parents  []string
+	Steps    []*Step
+	Stages   []*Node
+	Parallel []*Node
+	Status   v1.ActivityStatusType
+
+	id string
+}
+
+// Step is a step in a stage
+type Step struct {
+	Name   string
+	Status v1.ActivityStatusType
+}
+
+// Graph is the stages of a pipeline in execution order
+type Graph struct {
+	Name   string
+	Stages []*Node
+}
+
+// IsGroup returns true if the stage only contains nested stages
+func (n *Node) IsGroup() bool {
+	return len(n.Steps) == 0 && (len(n.Stages) > 0 || len(n.Parallel) > 0)
+}
+
+// FullName returns the name of the stage including its parents, as used for the stages of a PipelineActivity
+func (n *Node) FullName() string {
+	return strings.NewReplacer("-", " ").Replace(strings.Join(append(append([]string{}, n.Parents...), n.Name), " / "))
+}
+
+// FromPipelineStructure creates a graph from the PipelineStructure generated for a PipelineRun
+func FromPipelineStructure(ps *v1.PipelineStructure) *Graph {
+	g := &Graph{
+		Name: ps.Name,
+	}
+	for _, psc := range ps.GetAllStagesAndChildren() {
+		g.Stages = append(g.Stages, fromStageAndChildren(psc, nil))
+	}
+	g.assignIDs()
+	return g
+}
+
+func fromStageAndChildren(psc *v1.PipelineStageAndChildren, parents []string) *Node {
+	n := &Node{
+		Name:    psc.Stage.Name,
+		Parents: parents,
+	}
+	childParents := append(append([]string{}, parents...), psc.Stage.Name)
+	for i := range psc.Stages {
+		n.Stages = append(n.Stages, fromStageAndChildren(&psc.Stages[i], childParents))
+	}
+	for i := range psc.Parallel {
+		n.Parallel = append(n.Parallel, fromStageAndChildren(&psc.Parallel[i], childParents))
+	}
+	return n
+}
+
+// FromParsedPipeline creates a graph from a pipeline, including the steps of each stage
+func FromParsedPipeline(name string, p *syntax.ParsedPipeline) *Graph {
+	g := &Graph{
+		Name: name,
+	}
+	for i := range p.Stages {
+		g.Stages = append(g.Stages, fromStage(&p.Stages[i], nil))
+	}
+	g.assignIDs()
+	return g
+}
+
+func fromStage(s *syntax.Stage, parents []string) *Node {
+	n := &Node{
+		Name:    s.Name,
+		Parents: parents,
+	}
+	for _, step := range s.Steps {
+		n.Steps = append(n.Steps, fromSteps(step)...)
+	}
+	childParents := append(append([]string{}, parents...), s.Name)
+	for i := range s.Stages {
+		n.Stages = append(n.Stages, fromStage(&s.Stages[i], childParents))
+	}
+	for i := range s.Parallel {
+		n.Parallel = append(n.Parallel, fromStage(&s.Parallel[i], childParents))
+	}
+	return n
+}
+
+func fromSteps(step syntax.Step) []*Step {
+	if step.Loop != nil {
+		var answer []*Step
+		for _, nested := range step.Loop.Steps {
+			for _, s := range fromSteps(nested) {
+				s.Name = fmt.Sprintf("%s (for each %s)", s.Name, step.Loop.Variable)
+				answer = append(answer, s)
+			}
+		}
+		return answer
+	}
+	if len(step.Steps) > 0 {
+		var answer []*Step
+		for _, nested := range step.Steps {
+			answer = append(answer, fromSteps(*nested)...)
+		}
+		return answer
+	}
+	name := step.Name
+	if name == "" {
+		name = strings.TrimSpace(step.GetFullCommand())
+	}
+	return []*Step{{Name: name}}
+}
+
+// SetStatus colours the graph using the status of the stages and steps of the activity. Stages are matched by their
+// full name as used for Tekton builds, or by their own name as used when running pipelines locally.
+func (g *Graph) SetStatus(activity *v1.PipelineActivity) {
+	stages := map[string]*v1.StageActivityStep{}
+	for _, step := range activity.Spec.Steps {
+		if step.Stage != nil {
+			stages[strings.ToLower(step.Stage.Name)] = step.Stage
+		}
+	}
+	g.walk(func(n *Node) {
+		stage := stages[strings.ToLower(n.FullName())]
+		if stage == nil {
+			stage = stages[strings.ToLower(n.Name)]
+		}
+		if stage == nil {
+			return
+		}
+		n.Status = stage.Status
+		for _, s := range n.Steps {
+			for _, as := range stage.Steps {
+				if strings.EqualFold(as.Name, s.Name) {
+					s.Status = as.Status
+				}
+			}
+		}
+	})
+}
+
+// Write renders the graph in the given format
+func (g *Graph) Write(out io.Writer, format string) error {
+	switch format {
+	case FormatDOT, "":
+		return g.WriteDOT(out)
+	case FormatMermaid:
+		return g.WriteMermaid(out)
+	default:
+		return fmt.Errorf("unknown graph format %s, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func (g *Graph) walk(fn func(n *Node)) {
+	var visit func(nodes []*Node)
+	visit = func(nodes []*Node) {
+		for _, n := range nodes {
+			fn(n)
+			visit(n.Stages)
+			visit(n.Parallel)
+		}
+	}
+	visit(g.Stages)
+}
+
+func (g *Graph) assignIDs() {
+	i := 0
+	g.walk(func(n *Node) {
+		n.id = fmt.Sprintf("stage%d", i)
+		i++
+	})
+}
+
+// edges returns the edges between the nodes of the graph in execution order
+func (g *Graph) edges() [][2]*Node {
+	var answer [][2]*Node
+	sequence(g.Stages, &answer)
+	return answer
+}
+
+// sequence links each of the nodes to the next returning the entry and exit nodes of the sequence
+func sequence(nodes []*Node, edges *[][2]*Node) ([]*Node, []*Node) {
+	var entries, exits []*Node
+	for i, n := range nodes {
+		nodeEntries, nodeExits := entriesAndExits(n, edges)
+		if i == 0 {
+			entries = nodeEntries
+		} else {
+			for _, from := range exits {
+				for _, to := range nodeEntries {
+					*edges = append(*edges, [2]*Node{from, to})
+				}
+			}
+		}
+		exits = nodeExits
+	}
+	return entries, exits
+}
+
+func entriesAndExits(n *Node, edges *[][2]*Node) ([]*Node, []*Node) {
+	if !n.IsGroup() {
+		return []*Node{n}, []*Node{n}
+	}
+	if len(n.Stages) > 0 {
+		return sequence(n.Stages, edges)
+	}
+	var entries, exits []*Node
+	for _, p := range n.Parallel {
+		pEntries, pExits := entriesAndExits(p, edges)
+		entries = append(entries, pEntries...)
+		exits = append(exits, pExits...)
+	}
+	return entries, exits
+}
+
+// statusColor returns the fill colour for a status or an empty string if the node should not be filled
+func statusColor(status v1.ActivityStatusType) string {
+	switch status {
+	case v1.ActivityStatusTypeSucceeded:
+		return "#a5d6a7"
+	case v1.ActivityStatusTypeFailed, v1.ActivityStatusTypeError:
+		return "#ef9a9a"
+	case v1.ActivityStatusTypeRunning:
+		return "#90caf9"
+	case v1.ActivityStatusTypePending, v1.ActivityStatusTypeWaitingForApproval:
+		return "#fff59d"
+	case v1.ActivityStatusTypeAborted, v1.ActivityStatusTypeNotExecuted:
+		return "#e0e0e0"
+	default:
+		return ""
+	}
+}
+
+// labelLines returns the lines of the label for a node
+func labelLines(n *Node) []string {
+	lines := []string{n.Name}
+	for _, s := range n.Steps {
+		line := s.Name
+		if s.Status != "" {
+			line += " (" + string(s.Status) + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
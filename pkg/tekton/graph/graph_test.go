@@ -0,0 +1,112 @@
+package graph_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/tekton/graph"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPipeline() *syntax.ParsedPipeline {
+	return &syntax.ParsedPipeline{
+		Stages: []syntax.Stage{
+			{
+				Name:  "Build",
+				Steps: []syntax.Step{{Name: "compile", Command: "make"}, {Command: "make", Arguments: []string{"test"}}},
+			},
+			{
+				Name: "Tests",
+				Parallel: []syntax.Stage{
+					{Name: "Unit", Steps: []syntax.Step{{Command: "make unit"}}},
+					{Name: "Integration", Steps: []syntax.Step{{Command: "make \"integration\""}}},
+				},
+			},
+			{
+				Name:  "Deploy",
+				Steps: []syntax.Step{{Command: "make deploy"}},
+			},
+		},
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := graph.FromParsedPipeline("my-pipeline", testPipeline())
+	g.SetStatus(&v1.PipelineActivity{
+		Spec: v1.PipelineActivitySpec{
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "Build", Status: v1.ActivityStatusTypeSucceeded},
+						Steps:            []v1.CoreActivityStep{{Name: "compile", Status: v1.ActivityStatusTypeSucceeded}},
+					},
+				},
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "Tests / Unit", Status: v1.ActivityStatusTypeFailed},
+					},
+				},
+			},
+		},
+	})
+
+	out := &bytes.Buffer{}
+	err := g.Write(out, graph.FormatDOT)
+	require.NoError(t, err)
+	dot := out.String()
+
+	assert.Contains(t, dot, "digraph \"my-pipeline\" {")
+	assert.Contains(t, dot, "stage0 [label=\"Build\\lcompile (Succeeded)\\lmake test\\l\", style=\"rounded,filled\", fillcolor=\"#a5d6a7\"];")
+	assert.Contains(t, dot, "subgraph cluster_stage1 {")
+	assert.Contains(t, dot, "stage2 [label=\"Unit\\lmake unit\\l\", style=\"rounded,filled\", fillcolor=\"#ef9a9a\"];")
+	assert.Contains(t, dot, "stage3 [label=\"Integration\\lmake \\\"integration\\\"\\l\"];")
+	for _, edge := range []string{"stage0 -> stage2;", "stage0 -> stage3;", "stage2 -> stage4;", "stage3 -> stage4;"} {
+		assert.Contains(t, dot, edge)
+	}
+	assert.NotContains(t, dot, "stage1 ->")
+}
+
+func TestWriteMermaidFromPipelineStructure(t *testing.T) {
+	tests := "tests"
+	unit := "unit"
+	ps := &v1.PipelineStructure{
+		Stages: []v1.PipelineStructureStage{
+			{Name: "build", Depth: 0},
+			{Name: "tests", Depth: 0, Previous: &[]string{"build"}[0], Stages: []string{"unit", "lint"}},
+			{Name: "unit", Depth: 1, Parent: &tests},
+			{Name: "lint", Depth: 1, Parent: &tests, Previous: &unit},
+		},
+	}
+	g := graph.FromPipelineStructure(ps)
+	g.SetStatus(&v1.PipelineActivity{
+		Spec: v1.PipelineActivitySpec{
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{Name: "tests / lint", Status: v1.ActivityStatusTypeRunning},
+					},
+				},
+			},
+		},
+	})
+
+	out := &bytes.Buffer{}
+	err := g.Write(out, graph.FormatMermaid)
+	require.NoError(t, err)
+	mermaid := out.String()
+
+	assert.Contains(t, mermaid, "graph LR\n")
+	assert.Contains(t, mermaid, "subgraph stage1 [\"tests\"]")
+	assert.Contains(t, mermaid, "stage0 --> stage2\n")
+	assert.Contains(t, mermaid, "stage2 --> stage3\n")
+	assert.Contains(t, mermaid, "style stage3 fill:#90caf9\n")
+
+	err = g.Write(out, "png")
+	assert.Error(t, err)
+}
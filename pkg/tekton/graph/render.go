@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT renders the graph in the Graphviz DOT language with nested and parallel stages drawn as clusters
+func (g *Graph) WriteDOT(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "digraph %s {\n", dotQuote(g.Name))
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=rounded];")
+	for _, n := range g.Stages {
+		writeDOTNode(w, n, "  ")
+	}
+	for _, e := range g.edges() {
+		fmt.Fprintf(w, "  %s -> %s;\n", e[0].id, e[1].id)
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+func writeDOTNode(w io.Writer, n *Node, indent string) {
+	if n.IsGroup() {
+		fmt.Fprintf(w, "%ssubgraph cluster_%s {\n", indent, n.id)
+		fmt.Fprintf(w, "%s  label=%s;\n", indent, dotQuote(n.Name))
+		if color := statusColor(n.Status); color != "" {
+			fmt.Fprintf(w, "%s  style=filled;\n%s  fillcolor=%s;\n", indent, indent, dotQuote(color))
+		}
+		for _, child := range n.Stages {
+			writeDOTNode(w, child, indent+"  ")
+		}
+		for _, child := range n.Parallel {
+			writeDOTNode(w, child, indent+"  ")
+		}
+		fmt.Fprintf(w, "%s}\n", indent)
+		return
+	}
+	lines := labelLines(n)
+	for i := range lines {
+		lines[i] = dotEscape(lines[i])
+	}
+	attributes := fmt.Sprintf("label=\"%s\"", strings.Join(lines, "\\l")+dotLabelSuffix(lines))
+	if color := statusColor(n.Status); color != "" {
+		attributes += fmt.Sprintf(", style=\"rounded,filled\", fillcolor=%s", dotQuote(color))
+	}
+	fmt.Fprintf(w, "%s%s [%s];\n", indent, n.id, attributes)
+}
+
+// dotLabelSuffix left justifies the last line of a multi line label
+func dotLabelSuffix(lines []string) string {
+	if len(lines) > 1 {
+		return "\\l"
+	}
+	return ""
+}
+
+func dotEscape(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", " ").Replace(text)
+}
+
+func dotQuote(text string) string {
+	return "\"" + dotEscape(text) + "\""
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart with nested and parallel stages drawn as subgraphs
+func (g *Graph) WriteMermaid(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "graph LR")
+	for _, n := range g.Stages {
+		writeMermaidNode(w, n, "  ")
+	}
+	for _, e := range g.edges() {
+		fmt.Fprintf(w, "  %s --> %s\n", e[0].id, e[1].id)
+	}
+	g.walk(func(n *Node) {
+		if color := statusColor(n.Status); color != "" {
+			fmt.Fprintf(w, "  style %s fill:%s\n", n.id, color)
+		}
+	})
+	return w.Flush()
+}
+
+func writeMermaidNode(w io.Writer, n *Node, indent string) {
+	if n.IsGroup() {
+		fmt.Fprintf(w, "%ssubgraph %s [%s]\n", indent, n.id, mermaidQuote(n.Name))
+		for _, child := range n.Stages {
+			writeMermaidNode(w, child, indent+"  ")
+		}
+		for _, child := range n.Parallel {
+			writeMermaidNode(w, child, indent+"  ")
+		}
+		fmt.Fprintf(w, "%send\n", indent)
+		return
+	}
+	lines := labelLines(n)
+	for i := range lines {
+		lines[i] = mermaidEscape(lines[i])
+	}
+	fmt.Fprintf(w, "%s%s[\"%s\"]\n", indent, n.id, strings.Join(lines, "<br/>"))
+}
+
+func mermaidEscape(text string) string {
+	return strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(text)
+}
+
+func mermaidQuote(text string) string {
+	return "\"" + mermaidEscape(text) + "\""
+}
@@ -0,0 +1,73 @@
+package lint
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var pathIndex = regexp.MustCompile(`\[(\d+)\]`)
+
+// AddLines sets the line of each finding from the position of its path in the YAML document the findings were
+// reported for. Findings whose path is not in the document, such as a missing timeout, are given the line of the
+// closest element which is.
+func AddLines(data []byte, findings []Finding) error {
+	doc := &yaml.Node{}
+	err := yaml.Unmarshal(data, doc)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the YAML to find the lines of the findings")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	for i := range findings {
+		findings[i].Line = lineOf(doc.Content[0], findings[i].Path)
+	}
+	return nil
+}
+
+// lineOf returns the line of the element at the path, such as stages[0].steps[1].image, in the node
+func lineOf(node *yaml.Node, path string) int {
+	line := node.Line
+	if path == "" {
+		return line
+	}
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		if idx := strings.Index(segment, "["); idx >= 0 {
+			key = segment[:idx]
+		}
+		if key != "" {
+			keyNode, value := mappingValue(node, key)
+			if value == nil {
+				return line
+			}
+			line = keyNode.Line
+			node = value
+		}
+		for _, m := range pathIndex.FindAllStringSubmatch(segment, -1) {
+			i, _ := strconv.Atoi(m[1])
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return line
+			}
+			node = node.Content[i]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
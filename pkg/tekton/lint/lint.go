@@ -0,0 +1,199 @@
+package lint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Severity is the severity of a finding, using the same levels as SARIF
+type Severity string
+
+const (
+	// SeverityError a finding which should fail the build
+	SeverityError Severity = "error"
+	// SeverityWarning a finding which is likely to be a problem
+	SeverityWarning Severity = "warning"
+	// SeverityNote a finding which may be a problem
+	SeverityNote Severity = "note"
+)
+
+// Finding is a single problem found by a rule
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// File is the file the finding was found in relative to the root of the repository, if known
+	File string `json:"file,omitempty"`
+	// Path is the YAML path to the element the finding applies to
+	Path string `json:"path,omitempty"`
+	// Line is the line of the element in the file, if known
+	Line int `json:"line,omitempty"`
+}
+
+// String returns a human readable description of the finding
+func (f *Finding) String() string {
+	location := f.Path
+	if f.File != "" {
+		file := f.File
+		if f.Line > 0 {
+			file += ":" + strconv.Itoa(f.Line)
+		}
+		location = file + ": " + location
+	}
+	return fmt.Sprintf("%s: %s [%s] %s", f.Severity, location, f.RuleID, f.Message)
+}
+
+// RuleConfig configures a single rule
+type RuleConfig struct {
+	Disabled bool     `json:"disabled,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// Config configures the rules used by a Linter
+type Config struct {
+	// Rules configures individual rules by ID
+	Rules map[string]RuleConfig `json:"rules,omitempty"`
+	// MaxTimeout is the longest timeout allowed by the unbounded-timeout rule, 24h by default
+	MaxTimeout string `json:"maxTimeout,omitempty"`
+	// PodTemplates are the names of the pod templates, such as maven, which are used as images without a tag
+	PodTemplates []string `json:"podTemplates,omitempty"`
+}
+
+// LoadConfig loads the linter configuration from the given YAML file
+func LoadConfig(fileName string) (*Config, error) {
+	config := &Config{}
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return config, err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to unmarshal file %s", fileName)
+	}
+	return config, nil
+}
+
+// Linter checks pipelines against a set of rules
+type Linter struct {
+	Config     Config
+	maxTimeout time.Duration
+}
+
+// NewLinter creates a new Linter with the given configuration
+func NewLinter(config *Config) (*Linter, error) {
+	l := &Linter{
+		maxTimeout: 24 * time.Hour,
+	}
+	if config != nil {
+		l.Config = *config
+	}
+	for id, rule := range l.Config.Rules {
+		if FindRule(id) == nil {
+			return nil, fmt.Errorf("unknown lint rule %s", id)
+		}
+		switch rule.Severity {
+		case "", SeverityError, SeverityWarning, SeverityNote:
+		default:
+			return nil, fmt.Errorf("unknown severity %s of lint rule %s, it should be one of %s, %s or %s", rule.Severity, id, SeverityError, SeverityWarning, SeverityNote)
+		}
+	}
+	if l.Config.MaxTimeout != "" {
+		d, err := time.ParseDuration(l.Config.MaxTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid maxTimeout %s", l.Config.MaxTimeout)
+		}
+		l.maxTimeout = d
+	}
+	return l, nil
+}
+
+// EnabledRules returns the rules which are not disabled in the configuration
+func (l *Linter) EnabledRules() []*Rule {
+	var answer []*Rule
+	for _, r := range Rules {
+		if !l.Config.Rules[r.ID].Disabled {
+			answer = append(answer, r)
+		}
+	}
+	return answer
+}
+
+// LintPipeline checks a ParsedPipeline, using the path as the prefix of the location of any findings
+func (l *Linter) LintPipeline(path string, p *syntax.ParsedPipeline) []Finding {
+	var findings []Finding
+	for _, r := range l.EnabledRules() {
+		if r.checkPipeline != nil {
+			findings = append(findings, l.withSeverity(r, r.checkPipeline(l, path, p))...)
+		}
+	}
+	return sortFindings(findings)
+}
+
+// LintPipelineConfig checks a PipelineConfig from a jenkins-x.yml or build pack, along with any pipelines it defines.
+// The parent is the configuration of the build pack it inherits from, which may be nil.
+func (l *Linter) LintPipelineConfig(path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding {
+	var findings []Finding
+	for _, r := range l.EnabledRules() {
+		if r.checkConfig != nil {
+			findings = append(findings, l.withSeverity(r, r.checkConfig(l, path, c, parent))...)
+		}
+	}
+	for name, lifecycles := range c.Pipelines.AllMap() {
+		if lifecycles != nil && lifecycles.Pipeline != nil {
+			findings = append(findings, l.LintPipeline(joinPath(path, "pipelines."+name+".pipeline"), lifecycles.Pipeline)...)
+		}
+	}
+	return sortFindings(findings)
+}
+
+func (l *Linter) withSeverity(r *Rule, findings []Finding) []Finding {
+	severity := r.Severity
+	if s := l.Config.Rules[r.ID].Severity; s != "" {
+		severity = s
+	}
+	for i := range findings {
+		findings[i].RuleID = r.ID
+		findings[i].Severity = severity
+	}
+	return findings
+}
+
+// HasErrors returns true if any of the findings are errors
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func sortFindings(findings []Finding) []Finding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].RuleID < findings[j].RuleID
+	})
+	return findings
+}
+
+func joinPath(prefix string, path string) string {
+	if prefix == "" {
+		return path
+	}
+	return prefix + "." + path
+}
@@ -0,0 +1,226 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/tekton/lint"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func findingsForRule(findings []lint.Finding, ruleID string) []lint.Finding {
+	var answer []lint.Finding
+	for _, f := range findings {
+		if f.RuleID == ruleID {
+			answer = append(answer, f)
+		}
+	}
+	return answer
+}
+
+func testPipeline() *syntax.ParsedPipeline {
+	return &syntax.ParsedPipeline{
+		Agent: syntax.Agent{Image: "gcr.io/jenkinsxio/builder-go"},
+		Env: []syntax.EnvVar{
+			{Name: "USED", Value: "value"},
+			{Name: "UNUSED", Value: "value"},
+			{Name: "GIT_TOKEN", Value: "abcdef123456"},
+			{Name: "SECRET_REF", Value: "$(OTHER)"},
+		},
+		Stages: []syntax.Stage{
+			{
+				Name: "Build",
+				Steps: []syntax.Step{
+					{Name: "compile", Command: "make", Arguments: []string{"$USED", "${GIT_TOKEN}", "${SECRET_REF}"}},
+					{Name: "Compile", Command: "make", Image: "golang:1.11"},
+				},
+			},
+			{
+				Name: "build",
+				Steps: []syntax.Step{
+					{Command: "echo", Image: "gcr.io/jenkinsxio/jx:latest"},
+					{Command: "echo", Image: "gcr.io/jenkinsxio/jx@sha256:1234"},
+				},
+			},
+		},
+	}
+}
+
+func TestLintPipelineUntaggedImages(t *testing.T) {
+	linter, err := lint.NewLinter(&lint.Config{PodTemplates: []string{"maven"}})
+	require.NoError(t, err)
+
+	pipeline := &syntax.ParsedPipeline{
+		Agent: syntax.Agent{Image: "maven"},
+		Stages: []syntax.Stage{
+			{
+				Name: "Build",
+				Steps: []syntax.Step{
+					{Command: "make", Image: "golang"},
+					{Command: "make", Image: "localhost:5000/builder"},
+					{Command: "make", Image: "localhost:5000/builder:1.0"},
+					{Command: "make", Image: "gcr.io/jenkinsxio/builder-go:${inputs.params.version}"},
+				},
+			},
+		},
+	}
+	images := findingsForRule(linter.LintPipeline("", pipeline), lint.RuleImageTag)
+	require.Len(t, images, 2)
+	assert.Equal(t, "stages[0].steps[0].image", images[0].Path)
+	assert.Equal(t, "stages[0].steps[1].image", images[1].Path)
+}
+
+func TestLintPipeline(t *testing.T) {
+	linter, err := lint.NewLinter(nil)
+	require.NoError(t, err)
+
+	findings := linter.LintPipeline("", testPipeline())
+
+	images := findingsForRule(findings, lint.RuleImageTag)
+	require.Len(t, images, 2)
+	assert.Equal(t, "agent.image", images[0].Path)
+	assert.Equal(t, "stages[1].steps[0].image", images[1].Path)
+
+	dupes := findingsForRule(findings, lint.RuleDuplicateNames)
+	require.Len(t, dupes, 2)
+	assert.Equal(t, lint.SeverityError, dupes[0].Severity)
+	assert.Contains(t, dupes[0].Message, "'Build', 'build'")
+	assert.Contains(t, dupes[1].Message, "'Compile', 'compile'")
+
+	unused := findingsForRule(findings, lint.RuleUnusedEnv)
+	require.Len(t, unused, 1)
+	assert.Equal(t, "env[1]", unused[0].Path)
+
+	secrets := findingsForRule(findings, lint.RuleHardcodedSecret)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "env[2].value", secrets[0].Path)
+
+	timeouts := findingsForRule(findings, lint.RuleUnboundedTimeout)
+	require.Len(t, timeouts, 1)
+	assert.True(t, lint.HasErrors(findings))
+}
+
+func TestLintPipelineConfiguredRules(t *testing.T) {
+	linter, err := lint.NewLinter(&lint.Config{
+		Rules: map[string]lint.RuleConfig{
+			lint.RuleDuplicateNames:  {Disabled: true},
+			lint.RuleHardcodedSecret: {Severity: lint.SeverityWarning},
+		},
+		MaxTimeout: "1h",
+	})
+	require.NoError(t, err)
+
+	p := testPipeline()
+	p.Options.Timeout = syntax.Timeout{Time: 2, Unit: syntax.TimeoutUnitHours}
+	findings := linter.LintPipeline("", p)
+
+	assert.Empty(t, findingsForRule(findings, lint.RuleDuplicateNames))
+	timeouts := findingsForRule(findings, lint.RuleUnboundedTimeout)
+	require.Len(t, timeouts, 1)
+	assert.Contains(t, timeouts[0].Message, "longer than the maximum")
+	assert.False(t, lint.HasErrors(findings))
+
+	_, err = lint.NewLinter(&lint.Config{Rules: map[string]lint.RuleConfig{"no-such-rule": {}}})
+	assert.Error(t, err)
+
+	_, err = lint.NewLinter(&lint.Config{Rules: map[string]lint.RuleConfig{lint.RuleImageTag: {Severity: "eror"}}})
+	assert.Error(t, err)
+}
+
+func TestAddLines(t *testing.T) {
+	data := []byte(`pipelineConfig:
+  env:
+  - name: DOCKER_PASSWORD
+    value: hunter2
+  pipelines:
+    release:
+      pipeline:
+        stages:
+        - name: build
+          steps:
+          - command: make
+          - command: echo
+            image: golang
+`)
+	findings := []lint.Finding{
+		{Path: "pipelineConfig.env[0].value"},
+		{Path: "pipelineConfig.pipelines.release.pipeline.stages[0].steps[1].image"},
+		{Path: "pipelineConfig.pipelines.release.pipeline.options.timeout"},
+		{Path: "pipelineConfig.pipelines.release.pipeline.stages[3]"},
+	}
+	err := lint.AddLines(data, findings)
+	require.NoError(t, err)
+	assert.Equal(t, 4, findings[0].Line)
+	assert.Equal(t, 13, findings[1].Line)
+	// elements which are not in the file are reported at their closest parent
+	assert.Equal(t, 7, findings[2].Line)
+	assert.Equal(t, 8, findings[3].Line)
+}
+
+func TestLintPipelineConfigOverrides(t *testing.T) {
+	linter, err := lint.NewLinter(nil)
+	require.NoError(t, err)
+
+	parent := &jenkinsfile.PipelineConfig{
+		Pipelines: jenkinsfile.Pipelines{
+			Release: &jenkinsfile.PipelineLifecycles{
+				Build: &jenkinsfile.PipelineLifecycle{
+					Steps: []*syntax.Step{{Name: "mvn-deploy", Command: "mvn deploy"}},
+				},
+			},
+		},
+	}
+	config := &jenkinsfile.PipelineConfig{
+		Env: []corev1.EnvVar{{Name: "DOCKER_PASSWORD", Value: "hunter2"}},
+		Pipelines: jenkinsfile.Pipelines{
+			Overrides: []*jenkinsfile.PipelineOverride{
+				{Name: "mvn-deploy", Stages: []string{"build"}, Step: &syntax.Step{Command: "mvn install"}},
+				{Name: "mvn-deploy", Stages: []string{"preBuild"}},
+				{Name: "removed-step"},
+			},
+		},
+	}
+	findings := linter.LintPipelineConfig("pipelineConfig", config, parent)
+
+	overrides := findingsForRule(findings, lint.RuleMissingOverride)
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "pipelineConfig.pipelines.overrides[1].name", overrides[0].Path)
+	assert.Equal(t, "pipelineConfig.pipelines.overrides[2].name", overrides[1].Path)
+
+	secrets := findingsForRule(findings, lint.RuleHardcodedSecret)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "pipelineConfig.env[0].value", secrets[0].Path)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings := []lint.Finding{
+		{RuleID: lint.RuleImageTag, Severity: lint.SeverityWarning, Message: "bad image", File: "jenkins-x.yml", Path: "agent.image", Line: 3},
+	}
+	out := &bytes.Buffer{}
+	err := lint.Write(out, lint.FormatSARIF, findings, lint.Rules)
+	require.NoError(t, err)
+
+	sarif := map[string]interface{}{}
+	err = json.Unmarshal(out.Bytes(), &sarif)
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", sarif["version"])
+
+	runs := sarif["runs"].([]interface{})
+	require.Len(t, runs, 1)
+	results := runs[0].(map[string]interface{})["results"].([]interface{})
+	require.Len(t, results, 1)
+	result := results[0].(map[string]interface{})
+	assert.Equal(t, lint.RuleImageTag, result["ruleId"])
+	assert.Equal(t, "warning", result["level"])
+	location := result["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Equal(t, "jenkins-x.yml", location["artifactLocation"].(map[string]interface{})["uri"])
+	assert.Equal(t, float64(3), location["region"].(map[string]interface{})["startLine"])
+
+	err = lint.Write(out, "xml", findings, lint.Rules)
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+const (
+	// FormatText writes one line per finding
+	FormatText = "text"
+	// FormatJSON writes the findings as a JSON array
+	FormatJSON = "json"
+	// FormatSARIF writes the findings as a SARIF 2.1.0 log so they can annotate pull requests
+	FormatSARIF = "sarif"
+
+	sarifSchema  = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.4.json"
+	sarifVersion = "2.1.0"
+	toolName     = "jx-pipeline-lint"
+)
+
+// Formats the available output formats
+var Formats = []string{FormatText, FormatJSON, FormatSARIF}
+
+// Write writes the findings to the writer in the given format
+func Write(out io.Writer, format string, findings []Finding, rules []*Rule) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(out, findings)
+	case FormatJSON:
+		return WriteJSON(out, findings)
+	case FormatSARIF:
+		return WriteSARIF(out, findings, rules)
+	default:
+		return fmt.Errorf("unknown output format %s", format)
+	}
+}
+
+// WriteText writes the findings as human readable lines
+func WriteText(out io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		_, err := fmt.Fprintln(out, f.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON array
+func WriteJSON(out io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes the findings as a SARIF log describing the given rules
+func WriteSARIF(out io.Writer, findings []Finding, rules []*Rule) error {
+	driver := sarifDriver{
+		Name:  toolName,
+		Rules: []sarifRule{},
+	}
+	for _, r := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: string(r.Severity)},
+		})
+	}
+
+	run := sarifRun{
+		Tool:    sarifTool{Driver: driver},
+		Results: []sarifResult{},
+	}
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   string(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+		}
+		location := sarifLocation{}
+		if f.File != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+			}
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+		}
+		if f.Path != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: f.Path}}
+		}
+		if location.PhysicalLocation != nil || len(location.LogicalLocations) > 0 {
+			result.Locations = []sarifLocation{location}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
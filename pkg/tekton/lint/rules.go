@@ -0,0 +1,505 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/jenkins-x/jx/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Rule is a named check which can be applied to pipelines
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+
+	checkPipeline func(l *Linter, path string, p *syntax.ParsedPipeline) []Finding
+	checkConfig   func(l *Linter, path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding
+}
+
+const (
+	// RuleImageTag checks that images are pinned to a tag or digest
+	RuleImageTag = "image-tag"
+	// RuleDuplicateNames checks that stage and step names are unique after mangling
+	RuleDuplicateNames = "duplicate-names"
+	// RuleUnusedEnv checks that defined env vars are referenced by a step
+	RuleUnusedEnv = "unused-env"
+	// RuleUnboundedTimeout checks that pipelines have a bounded timeout
+	RuleUnboundedTimeout = "unbounded-timeout"
+	// RuleHardcodedSecret checks that env values do not contain credentials
+	RuleHardcodedSecret = "hardcoded-secret"
+	// RuleMissingOverride checks that overridden steps exist in the parent build pack
+	RuleMissingOverride = "missing-override"
+)
+
+// Rules are all the available rules
+var Rules = []*Rule{
+	{
+		ID:            RuleImageTag,
+		Description:   "Images should be pinned to a tag or digest other than latest so that builds are repeatable",
+		Severity:      SeverityWarning,
+		checkPipeline: checkPipelineImageTags,
+		checkConfig:   checkConfigImageTags,
+	},
+	{
+		ID:            RuleDuplicateNames,
+		Description:   "Stage names in a pipeline and step names in a stage must be unique once mangled into Kubernetes names",
+		Severity:      SeverityError,
+		checkPipeline: checkDuplicateNames,
+	},
+	{
+		ID:            RuleUnusedEnv,
+		Description:   "Environment variables which are defined but never referenced by a step are probably left over",
+		Severity:      SeverityNote,
+		checkPipeline: checkPipelineUnusedEnv,
+		checkConfig:   checkConfigUnusedEnv,
+	},
+	{
+		ID:            RuleUnboundedTimeout,
+		Description:   "Pipelines should have a timeout no longer than the configured maximum",
+		Severity:      SeverityWarning,
+		checkPipeline: checkUnboundedTimeout,
+	},
+	{
+		ID:            RuleHardcodedSecret,
+		Description:   "Environment variable values should not contain credentials; use a secret instead",
+		Severity:      SeverityError,
+		checkPipeline: checkPipelineSecrets,
+		checkConfig:   checkConfigSecrets,
+	},
+	{
+		ID:          RuleMissingOverride,
+		Description: "Overrides should refer to steps which exist in the parent build pack",
+		Severity:    SeverityWarning,
+		checkConfig: checkMissingOverrides,
+	},
+}
+
+// FindRule returns the rule with the given ID or nil if there is no such rule
+func FindRule(id string) *Rule {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// lifecycleKeys maps the names of PipelineLifecycles.All() to the YAML keys, which are also the stage names
+// matched by overrides
+var lifecycleKeys = map[string]string{
+	"setup":      "setup",
+	"setversion": "setVersion",
+	"prebuild":   "preBuild",
+	"build":      "build",
+	"postbuild":  "postBuild",
+	"promote":    "promote",
+}
+
+// visitStages calls the function for every stage in the pipeline, including nested stages
+func visitStages(path string, stages []syntax.Stage, fn func(path string, s *syntax.Stage)) {
+	for i := range stages {
+		s := &stages[i]
+		stagePath := fmt.Sprintf("%s[%d]", path, i)
+		fn(stagePath, s)
+		visitStages(stagePath+".stages", s.Stages, fn)
+		visitStages(stagePath+".parallel", s.Parallel, fn)
+	}
+}
+
+// visitSteps calls the function for every step in the stages, including steps in loops
+func visitSteps(path string, steps []syntax.Step, fn func(path string, s *syntax.Step)) {
+	for i := range steps {
+		s := &steps[i]
+		stepPath := fmt.Sprintf("%s[%d]", path, i)
+		fn(stepPath, s)
+		if s.Loop != nil {
+			visitSteps(stepPath+".loop.steps", s.Loop.Steps, fn)
+		}
+	}
+}
+
+// visitConfigSteps calls the function for every step in the build pack lifecycles, including nested legacy steps
+func visitConfigSteps(path string, c *jenkinsfile.PipelineConfig, fn func(path string, s *syntax.Step)) {
+	var visit func(path string, steps []*syntax.Step)
+	visit = func(path string, steps []*syntax.Step) {
+		for i, s := range steps {
+			if s == nil {
+				continue
+			}
+			stepPath := fmt.Sprintf("%s[%d]", path, i)
+			fn(stepPath, s)
+			visit(stepPath+".steps", s.Steps)
+		}
+	}
+	for name, lifecycles := range c.Pipelines.AllMap() {
+		if lifecycles == nil {
+			continue
+		}
+		for _, n := range lifecycles.All() {
+			if n.Lifecycle != nil {
+				lifecyclePath := joinPath(path, "pipelines."+name+"."+lifecycleKeys[n.Name])
+				visit(lifecyclePath+".preSteps", n.Lifecycle.PreSteps)
+				visit(lifecyclePath+".steps", n.Lifecycle.Steps)
+			}
+		}
+	}
+	if c.Pipelines.Post != nil {
+		visit(joinPath(path, "pipelines.post.steps"), c.Pipelines.Post.Steps)
+	}
+	for i, o := range c.Pipelines.Overrides {
+		overridePath := joinPath(path, fmt.Sprintf("pipelines.overrides[%d]", i))
+		if o.Step != nil {
+			visit(overridePath+".step", []*syntax.Step{o.Step})
+		}
+		visit(overridePath+".steps", o.Steps)
+	}
+}
+
+// visitPipelineSteps calls the function for every step in the pipeline
+func visitPipelineSteps(path string, p *syntax.ParsedPipeline, fn func(path string, s *syntax.Step)) {
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		visitSteps(stagePath+".steps", s.Steps, fn)
+	})
+}
+
+// hasTagOrDigest returns false if the image has no tag or digest, or uses the latest tag. Pod template names and
+// images which cannot be parsed, such as those using parameters, are ignored.
+func (l *Linter) hasTagOrDigest(image string) bool {
+	if image == "" || util.StringArrayIndex(l.Config.PodTemplates, image) >= 0 {
+		return true
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return true
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return true
+	}
+	tagged, ok := named.(reference.Tagged)
+	return ok && tagged.Tag() != "latest"
+}
+
+func (l *Linter) imageFinding(path string, image string) []Finding {
+	if l.hasTagOrDigest(image) {
+		return nil
+	}
+	return []Finding{{
+		Path:    path,
+		Message: fmt.Sprintf("image %s should use a tag other than latest or a digest", image),
+	}}
+}
+
+func checkPipelineImageTags(l *Linter, path string, p *syntax.ParsedPipeline) []Finding {
+	findings := l.imageFinding(joinPath(path, "agent.image"), p.Agent.Image)
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		findings = append(findings, l.imageFinding(stagePath+".agent.image", s.Agent.Image)...)
+		visitSteps(stagePath+".steps", s.Steps, func(stepPath string, step *syntax.Step) {
+			findings = append(findings, l.imageFinding(stepPath+".image", step.Image)...)
+			if step.Agent != nil {
+				findings = append(findings, l.imageFinding(stepPath+".agent.image", step.Agent.Image)...)
+			}
+		})
+	})
+	return findings
+}
+
+func checkConfigImageTags(l *Linter, path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding {
+	var findings []Finding
+	if c.Agent != nil {
+		findings = append(findings, l.imageFinding(joinPath(path, "agent.image"), c.Agent.GetImage())...)
+	}
+	visitConfigSteps(path, c, func(stepPath string, step *syntax.Step) {
+		findings = append(findings, l.imageFinding(stepPath+".image", step.GetImage())...)
+	})
+	return findings
+}
+
+func duplicates(names []string) []string {
+	counts := map[string]int{}
+	for _, n := range names {
+		counts[syntax.MangleToRfc1035Label(n, "")]++
+	}
+	var answer []string
+	seen := map[string]bool{}
+	for _, n := range names {
+		if counts[syntax.MangleToRfc1035Label(n, "")] > 1 && !seen[n] {
+			seen[n] = true
+			answer = append(answer, "'"+n+"'")
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+func checkDuplicateNames(l *Linter, path string, p *syntax.ParsedPipeline) []Finding {
+	var findings []Finding
+	var stageNames []string
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		stageNames = append(stageNames, s.Name)
+
+		var stepNames []string
+		for _, step := range s.Steps {
+			if step.Name != "" {
+				stepNames = append(stepNames, step.Name)
+			}
+		}
+		if dupes := duplicates(stepNames); len(dupes) > 0 {
+			findings = append(findings, Finding{
+				Path:    stagePath + ".steps",
+				Message: fmt.Sprintf("step names are not unique in stage %s: %s", s.Name, strings.Join(dupes, ", ")),
+			})
+		}
+	})
+	if dupes := duplicates(stageNames); len(dupes) > 0 {
+		findings = append(findings, Finding{
+			Path:    joinPath(path, "stages"),
+			Message: "stage names are not unique: " + strings.Join(dupes, ", "),
+		})
+	}
+	return findings
+}
+
+var envReference = regexp.MustCompile(`\$\{?\(?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// addReferences adds the names of all env vars referenced in the text
+func addReferences(refs map[string]bool, texts ...string) {
+	for _, text := range texts {
+		for _, m := range envReference.FindAllStringSubmatch(text, -1) {
+			refs[m[1]] = true
+		}
+	}
+}
+
+func addStepReferences(refs map[string]bool, s *syntax.Step) {
+	addReferences(refs, s.Command, s.Sh, s.Dir, s.Groovy)
+	addReferences(refs, s.Arguments...)
+	for _, o := range s.Options {
+		addReferences(refs, o)
+	}
+	for _, e := range s.Env {
+		addReferences(refs, e.Value)
+	}
+	if s.Loop != nil {
+		addReferences(refs, s.Loop.Values...)
+	}
+}
+
+func unusedEnvFindings(path string, env []syntax.EnvVar, refs map[string]bool) []Finding {
+	var findings []Finding
+	for i, e := range env {
+		if !refs[e.Name] {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("%s[%d]", path, i),
+				Message: fmt.Sprintf("env var %s is not referenced by any step", e.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func checkPipelineUnusedEnv(l *Linter, path string, p *syntax.ParsedPipeline) []Finding {
+	refs := map[string]bool{}
+	visitPipelineSteps(path, p, func(stepPath string, s *syntax.Step) {
+		addStepReferences(refs, s)
+		if s.Loop != nil {
+			refs[s.Loop.Variable] = true
+		}
+	})
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		if s.WorkingDir != nil {
+			addReferences(refs, *s.WorkingDir)
+		}
+	})
+
+	findings := unusedEnvFindings(joinPath(path, "env"), p.GetEnv(), refs)
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		findings = append(findings, unusedEnvFindings(stagePath+".env", s.GetEnv(), refs)...)
+		visitSteps(stagePath+".steps", s.Steps, func(stepPath string, step *syntax.Step) {
+			findings = append(findings, unusedEnvFindings(stepPath+".env", step.Env, refs)...)
+		})
+	})
+	return findings
+}
+
+func checkConfigUnusedEnv(l *Linter, path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding {
+	refs := map[string]bool{}
+	visitConfigSteps(path, c, func(stepPath string, s *syntax.Step) {
+		addStepReferences(refs, s)
+	})
+	for _, lifecycles := range c.Pipelines.All() {
+		if lifecycles != nil && lifecycles.Pipeline != nil {
+			visitPipelineSteps("", lifecycles.Pipeline, func(stepPath string, s *syntax.Step) {
+				addStepReferences(refs, s)
+			})
+		}
+	}
+	if parent != nil {
+		// the env vars may be used by the steps inherited from the build pack
+		visitConfigSteps("", parent, func(stepPath string, s *syntax.Step) {
+			addStepReferences(refs, s)
+		})
+	}
+	var findings []Finding
+	for i, e := range c.Env {
+		if e.ValueFrom == nil {
+			addReferences(refs, e.Value)
+		}
+		if !refs[e.Name] {
+			findings = append(findings, Finding{
+				Path:    joinPath(path, fmt.Sprintf("env[%d]", i)),
+				Message: fmt.Sprintf("env var %s is not referenced by any step", e.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func timeoutDuration(t syntax.Timeout) time.Duration {
+	unit := time.Second
+	switch t.Unit {
+	case syntax.TimeoutUnitMinutes:
+		unit = time.Minute
+	case syntax.TimeoutUnitHours:
+		unit = time.Hour
+	case syntax.TimeoutUnitDays:
+		unit = 24 * time.Hour
+	}
+	return time.Duration(t.Time) * unit
+}
+
+func checkUnboundedTimeout(l *Linter, path string, p *syntax.ParsedPipeline) []Finding {
+	timeout := p.Options.Timeout
+	if timeout.Time <= 0 {
+		return []Finding{{
+			Path:    joinPath(path, "options.timeout"),
+			Message: "the pipeline has no timeout so a hung step will run forever",
+		}}
+	}
+	if d := timeoutDuration(timeout); d > l.maxTimeout {
+		return []Finding{{
+			Path:    joinPath(path, "options.timeout"),
+			Message: fmt.Sprintf("the pipeline timeout %s is longer than the maximum of %s", d, l.maxTimeout),
+		}}
+	}
+	return nil
+}
+
+var secretName = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|credentials?)`)
+
+// looksLikeCredential returns true if the env var is a literal value which looks like a credential. This is looser
+// than syntax.LooksLikeCredential as any literal value of an env var whose name implies a secret is reported unless it
+// is a reference to another env var.
+func looksLikeCredential(name string, value string) bool {
+	if value == "" {
+		return false
+	}
+	if syntax.LooksLikeCredential(name, value) {
+		return true
+	}
+	if !secretName.MatchString(name) || strings.Contains(value, "$") {
+		return false
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no":
+		return false
+	}
+	return true
+}
+
+func secretFindings(path string, env []syntax.EnvVar) []Finding {
+	var findings []Finding
+	for i, e := range env {
+		if looksLikeCredential(e.Name, e.Value) {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("%s[%d].value", path, i),
+				Message: fmt.Sprintf("env var %s looks like it contains a hardcoded credential", e.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func checkPipelineSecrets(l *Linter, path string, p *syntax.ParsedPipeline) []Finding {
+	findings := secretFindings(joinPath(path, "env"), p.GetEnv())
+	visitStages(joinPath(path, "stages"), p.Stages, func(stagePath string, s *syntax.Stage) {
+		findings = append(findings, secretFindings(stagePath+".env", s.GetEnv())...)
+		visitSteps(stagePath+".steps", s.Steps, func(stepPath string, step *syntax.Step) {
+			findings = append(findings, secretFindings(stepPath+".env", step.Env)...)
+		})
+	})
+	return findings
+}
+
+func checkConfigSecrets(l *Linter, path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding {
+	var env []syntax.EnvVar
+	for _, e := range c.Env {
+		if e.ValueFrom == nil {
+			env = append(env, syntax.EnvVar{Name: e.Name, Value: e.Value})
+		}
+	}
+	findings := secretFindings(joinPath(path, "env"), env)
+	visitConfigSteps(path, c, func(stepPath string, step *syntax.Step) {
+		findings = append(findings, secretFindings(stepPath+".env", step.Env)...)
+	})
+	if c.ContainerOptions != nil {
+		findings = append(findings, containerSecretFindings(joinPath(path, "containerOptions.env"), c.ContainerOptions.Env)...)
+	}
+	return findings
+}
+
+func containerSecretFindings(path string, env []corev1.EnvVar) []Finding {
+	var literal []syntax.EnvVar
+	for _, e := range env {
+		if e.ValueFrom == nil {
+			literal = append(literal, syntax.EnvVar{Name: e.Name, Value: e.Value})
+		}
+	}
+	return secretFindings(path, literal)
+}
+
+func checkMissingOverrides(l *Linter, path string, c *jenkinsfile.PipelineConfig, parent *jenkinsfile.PipelineConfig) []Finding {
+	if parent == nil {
+		return nil
+	}
+	var findings []Finding
+	for i, o := range c.Pipelines.Overrides {
+		if o == nil || o.Name == "" {
+			continue
+		}
+		found := false
+		for name, lifecycles := range parent.Pipelines.AllMap() {
+			if lifecycles == nil || !o.MatchesPipeline(name) {
+				continue
+			}
+			for _, n := range lifecycles.All() {
+				if n.Lifecycle != nil && o.MatchesStage(lifecycleKeys[n.Name]) && containsStepNamed(n.Lifecycle.Steps, o.Name) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			findings = append(findings, Finding{
+				Path:    joinPath(path, fmt.Sprintf("pipelines.overrides[%d].name", i)),
+				Message: fmt.Sprintf("the override of step %s does not match any step in the parent build pack", o.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func containsStepNamed(steps []*syntax.Step, name string) bool {
+	for _, s := range steps {
+		if s != nil && (s.Name == name || containsStepNamed(s.Steps, name)) {
+			return true
+		}
+	}
+	return false
+}
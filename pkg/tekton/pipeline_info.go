@@ -10,6 +10,7 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/builds"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
@@ -19,6 +20,8 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
 	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // PipelineRunInfo provides information on a PipelineRun and its stages for use in getting logs and populating activity
@@ -465,6 +468,32 @@ func (pri *PipelineRunInfo) MatchesPipeline(activity *v1.PipelineActivity) bool
 	return d.GitOwner == pri.Organisation && d.GitRepository == pri.Repository && d.Build == pri.Build && strings.ToLower(d.BranchName) == strings.ToLower(pri.Branch)
 }
 
+// FindPipelineActivity returns the PipelineActivity of a PipelineRun from the owner, repository, branch and build labels of
+// the run, as the names of PipelineRuns and PipelineStructures are truncated. It returns nil if there is no activity.
+func FindPipelineActivity(jxClient versioned.Interface, ns string, pr *tektonv1alpha1.PipelineRun) (*v1.PipelineActivity, error) {
+	build := PipelineRunBuildNumber(pr)
+	owner := pr.Labels["owner"]
+	repo := pr.Labels["repo"]
+	branch := pr.Labels["branch"]
+	if owner == "" || repo == "" || branch == "" || build == "" {
+		return nil, fmt.Errorf("PipelineRun %s has no owner, repo, branch or build label", pr.Name)
+	}
+	selector := labels.Set{
+		v1.LabelOwner:      owner,
+		v1.LabelRepository: repo,
+		v1.LabelBranch:     branch,
+		v1.LabelBuild:      build,
+	}.AsSelector().String()
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s with selector %s", ns, selector)
+	}
+	if len(activities.Items) == 0 {
+		return nil, nil
+	}
+	return &activities.Items[0], nil
+}
+
 // Status returns the build status
 func (pri *PipelineRunInfo) Status() string {
 	pod := pri.FindFirstStagePod()
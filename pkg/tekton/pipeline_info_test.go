@@ -6,11 +6,16 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/tekton"
 	"github.com/jenkins-x/jx/pkg/tekton/syntax"
 	"github.com/jenkins-x/jx/pkg/tekton/tekton_helpers_test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -245,3 +250,53 @@ func scrubPods(s *tekton.StageInfo) {
 		scrubPods(child)
 	}
 }
+
+func TestFindPipelineActivity(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	owner := "jenkins-x-quickstarts"
+	repo := "golang-http-from-jenkins-x-yml"
+	branch := "feature-with-a-long-name"
+	activity := func(build string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      kube.ToValidName(owner + "-" + repo + "-" + branch + "-" + build),
+				Namespace: ns,
+				Labels: map[string]string{
+					v1.LabelOwner:      owner,
+					v1.LabelRepository: repo,
+					v1.LabelBranch:     branch,
+					v1.LabelBuild:      build,
+				},
+			},
+			Spec: v1.PipelineActivitySpec{Build: build},
+		}
+	}
+	jxClient := jxfake.NewSimpleClientset(activity("3"), activity("31"))
+
+	// the names of PipelineRuns and PipelineStructures are truncated so they do not match the activity
+	pr := &v1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jenkins-x-quickstarts-golang-http-3",
+			Namespace: ns,
+			Labels:    map[string]string{"owner": owner, "repo": repo, "branch": branch, v1.LabelBuild: "3"},
+		},
+	}
+	found, err := tekton.FindPipelineActivity(jxClient, ns, pr)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "3", found.Spec.Build)
+
+	// older runs only have the build number as a parameter
+	delete(pr.Labels, v1.LabelBuild)
+	pr.Spec.Params = []v1alpha1.Param{{Name: "build_id", Value: "31"}}
+	found, err = tekton.FindPipelineActivity(jxClient, ns, pr)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, "31", found.Spec.Build)
+
+	pr.Spec.Params[0].Value = "4"
+	found, err = tekton.FindPipelineActivity(jxClient, ns, pr)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,245 @@
+package tekton
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	servicesStopFile  = "stop"
+	servicesPollDelay = time.Second
+	serviceStopGrace  = 10 * time.Second
+)
+
+// PlaceServiceRunner copies the running jx binary into the services directory shared by the steps of a stage, so
+// that the steps which run the services with the images of the services can run it
+func PlaceServiceRunner(dir string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "failed to find the jx binary")
+	}
+	err = util.CopyFile(executable, filepath.Join(dir, "jx"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy the jx binary to %s", dir)
+	}
+	return nil
+}
+
+// StopServices tells the services of a stage to stop by creating the stop file in the services directory
+func StopServices(dir string) error {
+	err := ioutil.WriteFile(filepath.Join(dir, servicesStopFile), []byte{}, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the stop file in %s", dir)
+	}
+	return nil
+}
+
+// StepPostFile returns the file which the Tekton entrypoint running the current process writes when the process
+// exits, which the next step of the Task waits for before it starts
+func StepPostFile() (string, error) {
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", os.Getppid()))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the command line of the Tekton entrypoint")
+	}
+	return ParsePostFile(cmdline)
+}
+
+// ParsePostFile returns the value of the -post_file argument of the null separated command line of a Tekton
+// entrypoint
+func ParsePostFile(cmdline []byte) (string, error) {
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		flag := strings.TrimLeft(arg, "-")
+		if flag == "post_file" && i+1 < len(args) {
+			return args[i+1], nil
+		}
+		if strings.HasPrefix(flag, "post_file=") {
+			return strings.TrimPrefix(flag, "post_file="), nil
+		}
+	}
+	return "", fmt.Errorf("no -post_file argument in the command line %q, the service must be run by a Tekton step", cmdline)
+}
+
+// RunService starts a service and waits for it to be ready, then writes the post file so that the next step of the
+// Task starts. The service then runs until the stop file is created in the services directory, when it is stopped and
+// nil is returned. An error is returned if the service is not ready within the timeout or exits before it is stopped.
+func RunService(service *syntax.Service, dir string, postFile string, timeout time.Duration, out io.Writer) error {
+	if len(service.Command) == 0 {
+		return fmt.Errorf("service %s has no command", service.Name)
+	}
+	args := append(append([]string{}, service.Command[1:]...), service.Args...)
+	cmd := exec.Command(service.Command[0], args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Start()
+	if err != nil {
+		return errors.Wrapf(err, "failed to start service %s", service.Name)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	err = waitForService(service, timeout, exited)
+	if err != nil {
+		stopService(cmd, exited)
+		return err
+	}
+	err = ioutil.WriteFile(postFile, []byte{}, 0666)
+	if err != nil {
+		stopService(cmd, exited)
+		return errors.Wrapf(err, "failed to write the post file %s", postFile)
+	}
+	log.Infof("Service %s is ready\n", util.ColorInfo(service.Name))
+
+	stopFile := filepath.Join(dir, servicesStopFile)
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return errors.Wrapf(err, "service %s exited before the steps of the stage completed", service.Name)
+		case <-time.After(servicesPollDelay):
+			if _, err := os.Stat(stopFile); err == nil {
+				stopService(cmd, exited)
+				log.Infof("Stopped service %s\n", util.ColorInfo(service.Name))
+				return nil
+			}
+		}
+	}
+}
+
+// waitForService waits until the readiness probe of the service succeeds
+func waitForService(service *syntax.Service, timeout time.Duration, exited chan error) error {
+	probe := service.GetReadinessProbe()
+	if probe == nil {
+		return nil
+	}
+	period := time.Duration(probe.PeriodSeconds) * time.Second
+	if period <= 0 {
+		period = 10 * time.Second
+	}
+	deadline := time.After(timeout)
+	delay := time.Duration(probe.InitialDelaySeconds) * time.Second
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return errors.Wrapf(err, "service %s exited before it was ready", service.Name)
+		case <-deadline:
+			return fmt.Errorf("service %s was not ready within %s", service.Name, timeout)
+		case <-time.After(delay):
+			err := ProbeService(probe)
+			if err == nil {
+				return nil
+			}
+			log.Infof("Waiting for service %s to be ready: %s\n", service.Name, err)
+			delay = period
+		}
+	}
+}
+
+// ProbeService runs a readiness probe against a service running in the same pod, returning an error if the service
+// is not ready
+func ProbeService(probe *corev1.Probe) error {
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	switch {
+	case probe.Exec != nil:
+		if len(probe.Exec.Command) == 0 {
+			return errors.New("the exec probe has no command")
+		}
+		return exec.Command(probe.Exec.Command[0], probe.Exec.Command[1:]...).Run()
+	case probe.HTTPGet != nil:
+		get := probe.HTTPGet
+		port, err := probePort(get.Port.String())
+		if err != nil {
+			return err
+		}
+		host := get.Host
+		if host == "" {
+			host = "localhost"
+		}
+		scheme := "http"
+		if get.Scheme == corev1.URISchemeHTTPS {
+			scheme = "https"
+		}
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, port), get.Path), nil)
+		if err != nil {
+			return err
+		}
+		for _, header := range get.HTTPHeaders {
+			req.Header.Add(header.Name, header.Value)
+		}
+		resp, err := (&http.Client{Timeout: timeout}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("%s returned status %d", req.URL, resp.StatusCode)
+		}
+		return nil
+	case probe.TCPSocket != nil:
+		port, err := probePort(probe.TCPSocket.Port.String())
+		if err != nil {
+			return err
+		}
+		host := probe.TCPSocket.Host
+		if host == "" {
+			host = "localhost"
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	return errors.New("the probe has no exec, httpGet or tcpSocket handler")
+}
+
+// probePort returns the port of a probe, which must be a number as the ports of services are not named
+func probePort(port string) (string, error) {
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", fmt.Errorf("the probe port %s is not a number", port)
+	}
+	return port, nil
+}
+
+// stopService sends SIGTERM to the service and kills it if it has not exited after a grace period
+func stopService(cmd *exec.Cmd, exited chan error) {
+	err := cmd.Process.Signal(syscall.SIGTERM)
+	if err != nil {
+		cmd.Process.Kill()
+		return
+	}
+	select {
+	case <-exited:
+	case <-time.After(serviceStopGrace):
+		cmd.Process.Kill()
+	}
+}
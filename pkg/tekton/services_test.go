@@ -0,0 +1,136 @@
+package tekton_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParsePostFile(t *testing.T) {
+	t.Parallel()
+	cmdline := []byte("/builder/tools/entrypoint\x00-wait_file\x00/builder/tools/2\x00-post_file\x00/builder/tools/3\x00-entrypoint\x00/jx-services/jx\x00--\x00step\x00services\x00run\x00")
+	postFile, err := tekton.ParsePostFile(cmdline)
+	require.NoError(t, err)
+	assert.Equal(t, "/builder/tools/3", postFile)
+
+	postFile, err = tekton.ParsePostFile([]byte("/builder/tools/entrypoint\x00--post_file=/builder/tools/0\x00"))
+	require.NoError(t, err)
+	assert.Equal(t, "/builder/tools/0", postFile)
+
+	_, err = tekton.ParsePostFile([]byte("/bin/sh\x00-c\x00jx step services run\x00"))
+	assert.Error(t, err)
+}
+
+func TestProbeService(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	tcpProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.Parse(port)},
+		},
+	}
+	assert.NoError(t, tekton.ProbeService(tcpProbe))
+	listener.Close()
+	assert.Error(t, tekton.ProbeService(tcpProbe), "nothing is listening on the port")
+
+	var ready int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&ready) == 0 || r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	httpProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: intstr.Parse(serverURL.Port())},
+		},
+	}
+	assert.Error(t, tekton.ProbeService(httpProbe))
+	atomic.StoreInt32(&ready, 1)
+	assert.NoError(t, tekton.ProbeService(httpProbe))
+
+	httpProbe.HTTPGet.Port = intstr.FromString("http")
+	assert.Error(t, tekton.ProbeService(httpProbe), "the ports of services are not named")
+}
+
+func TestRunService(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-run-service-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	postFile := filepath.Join(dir, "0")
+
+	service := &syntax.Service{
+		Name:    "sleeper",
+		Image:   "busybox",
+		Command: []string{"sleep"},
+		Args:    []string{"60"},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- tekton.RunService(service, dir, postFile, time.Minute, &bytes.Buffer{})
+	}()
+
+	// the next step starts once the post file is written, while the service keeps running until it is stopped
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(postFile); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NoError(t, err, "the post file is written when the service is ready")
+	select {
+	case err := <-done:
+		t.Fatalf("the service exited before it was stopped: %v", err)
+	default:
+	}
+
+	require.NoError(t, tekton.StopServices(dir))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(20 * time.Second):
+		t.Fatal("the service was not stopped")
+	}
+}
+
+func TestRunServiceFailsWhenTheServiceExits(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-run-service-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	postFile := filepath.Join(dir, "0")
+
+	service := &syntax.Service{
+		Name:    "broken",
+		Image:   "busybox",
+		Command: []string{"false"},
+		Ports:   []int32{1},
+	}
+	err = tekton.RunService(service, dir, postFile, time.Minute, &bytes.Buffer{})
+	assert.Error(t, err)
+	_, err = os.Stat(postFile)
+	assert.True(t, os.IsNotExist(err), "the next step does not start when the service is not ready")
+}
@@ -7,6 +7,33 @@ const (
 	// LabelStageName - the name for the label that will have the stage name on the Task.
 	LabelStageName = "jenkins.io/task-stage-name"
 
+	// LabelVaultSecret - the label on the Secrets which Vault keys referenced by pipeline env vars are copied to.
+	LabelVaultSecret = "jenkins.io/vault-secret"
+
+	// AnnotationVaultPath - the annotation with the Vault path a Secret labelled with LabelVaultSecret is copied from.
+	AnnotationVaultPath = "jenkins.io/vault-path"
+
+	// ServiceEnvVar - the env var containing the JSON of the service run by a step named with ServiceStepNamePrefix.
+	ServiceEnvVar = "JX_SERVICE"
+
+	// ServicesVolumeName - the name of the volume shared by the steps which start, run and stop the services of a stage.
+	ServicesVolumeName = "jx-services"
+
+	// ServicesDir - the directory the ServicesVolumeName volume is mounted at.
+	ServicesDir = "/jx-services"
+
+	// StartServicesStepName - the name of the step which starts the services of a stage before its steps.
+	StartServicesStepName = "start-services"
+
+	// ServiceStepNamePrefix - the prefix of the names of the steps which run the services of a stage.
+	ServiceStepNamePrefix = "service-"
+
+	// StopServicesStepName - the name of the step which stops the services of a stage after its steps.
+	StopServicesStepName = "stop-services"
+
+	// ApprovalStepName - the name of the step which waits for the approval of an approval stage.
+	ApprovalStepName = "wait-for-approval"
+
 	// DefaultStageNameForBuildPack - the name we use for the single stage created from build packs currently.
 	DefaultStageNameForBuildPack = "from-build-pack"
 )
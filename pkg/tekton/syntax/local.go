@@ -0,0 +1,364 @@
+package syntax
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LocalRunner executes the command steps of a ParsedPipeline as local processes, mapping the Tekton workspace
+// onto a local directory. It is intended for testing pipelines without a cluster, so images and container options
+// are ignored and env vars which reference a secret, configmap or Vault key are taken from the local environment.
+type LocalRunner struct {
+	// Workspace is the local directory standing in for WorkingDirRoot
+	Workspace string
+	// SourceDir is the directory inside the workspace containing the source, "source" by default
+	SourceDir string
+	// Stage if specified only runs the stage with this name, including any nested stages
+	Stage string
+	// Sequential disables running parallel stages concurrently
+	Sequential bool
+	// Params are used to replace references to Tekton task parameters such as ${inputs.params.version}
+	Params map[string]string
+	// Out is where the prefixed output of the steps is written, os.Stdout by default
+	Out io.Writer
+
+	lock sync.Mutex
+}
+
+// Run executes the pipeline, returning a PipelineActivity summarising the status and timings of the stages
+// and steps that were run. The activity is returned even if a step fails.
+func (r *LocalRunner) Run(j *ParsedPipeline) (*v1.PipelineActivity, error) {
+	if r.Workspace == "" {
+		return nil, errors.New("no workspace directory specified")
+	}
+	if r.SourceDir == "" {
+		r.SourceDir = "source"
+	}
+	if r.Out == nil {
+		r.Out = os.Stdout
+	}
+
+	stages := j.Stages
+	workingDir := j.WorkingDir
+	env := j.toStepEnvVars()
+	if r.Stage != "" {
+		var stage *Stage
+		stage, workingDir, env = findStageByName(stages, r.Stage, workingDir, env)
+		if stage == nil {
+			return nil, fmt.Errorf("no stage named %s in the pipeline", r.Stage)
+		}
+		stages = []Stage{*stage}
+	}
+
+	activity := &v1.PipelineActivity{
+		Spec: v1.PipelineActivitySpec{
+			Status:           v1.ActivityStatusTypeRunning,
+			StartedTimestamp: nowTime(),
+		},
+	}
+
+	var err error
+	for _, s := range stages {
+		err = r.runStage(s, workingDir, env, activity)
+		if err != nil {
+			break
+		}
+	}
+
+	activity.Spec.CompletedTimestamp = nowTime()
+	activity.Spec.Status = v1.ActivityStatusTypeSucceeded
+	if err != nil {
+		activity.Spec.Status = v1.ActivityStatusTypeFailed
+	}
+	return activity, err
+}
+
+func (r *LocalRunner) runStage(s Stage, baseWorkingDir *string, parentEnv []corev1.EnvVar, activity *v1.PipelineActivity) error {
+	if s.WorkingDir != nil {
+		baseWorkingDir = s.WorkingDir
+	}
+	env := scopedEnv(toContainerEnvVars(s.GetEnv()), parentEnv)
+
+	if len(s.Services) > 0 {
+		fmt.Fprintf(r.Out, "[%s] the services of the stage are not started when running locally\n", s.Name)
+	}
+
+	if s.Approval != nil {
+		fmt.Fprintf(r.Out, "[%s] approval stages are approved automatically when running locally\n", s.Name)
+		now := nowTime()
+		r.lock.Lock()
+		activity.Spec.Steps = append(activity.Spec.Steps, v1.PipelineActivityStep{
+			Kind: v1.ActivityStepKindTypeStage,
+			Stage: &v1.StageActivityStep{
+				CoreActivityStep: v1.CoreActivityStep{
+					Name:               s.Name,
+					Status:             v1.ActivityStatusTypeSucceeded,
+					StartedTimestamp:   now,
+					CompletedTimestamp: now,
+				},
+			},
+		})
+		r.lock.Unlock()
+		return nil
+	}
+
+	if len(s.Steps) > 0 {
+		stageStep := &v1.StageActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				Name:             s.Name,
+				Status:           v1.ActivityStatusTypeRunning,
+				StartedTimestamp: nowTime(),
+			},
+		}
+		var err error
+		for _, step := range s.Steps {
+			err = r.runStep(s.Name, step, baseWorkingDir, env, stageStep)
+			if err != nil {
+				break
+			}
+		}
+		stageStep.CompletedTimestamp = nowTime()
+		stageStep.Status = v1.ActivityStatusTypeSucceeded
+		if err != nil {
+			stageStep.Status = v1.ActivityStatusTypeFailed
+		}
+
+		r.lock.Lock()
+		activity.Spec.Steps = append(activity.Spec.Steps, v1.PipelineActivityStep{
+			Kind:  v1.ActivityStepKindTypeStage,
+			Stage: stageStep,
+		})
+		r.lock.Unlock()
+		return err
+	}
+
+	for _, nested := range s.Stages {
+		err := r.runStage(nested, baseWorkingDir, env, activity)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.Parallel) > 0 {
+		if r.Sequential {
+			for _, nested := range s.Parallel {
+				err := r.runStage(nested, baseWorkingDir, env, activity)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, len(s.Parallel))
+		for i := range s.Parallel {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = r.runStage(s.Parallel[i], baseWorkingDir, env, activity)
+			}(i)
+		}
+		wg.Wait()
+
+		var messages []string
+		for _, err := range errs {
+			if err != nil {
+				messages = append(messages, err.Error())
+			}
+		}
+		if len(messages) > 0 {
+			return fmt.Errorf("parallel stage %s failed: %s", s.Name, strings.Join(messages, ", "))
+		}
+	}
+	return nil
+}
+
+func (r *LocalRunner) runStep(stageName string, step Step, baseWorkingDir *string, env []corev1.EnvVar, stageStep *v1.StageActivityStep) error {
+	if step.Loop != nil {
+		for _, value := range step.Loop.Values {
+			loopEnv := scopedEnv([]corev1.EnvVar{{Name: step.Loop.Variable, Value: value}}, env)
+			for _, s := range step.Loop.Steps {
+				err := r.runStep(stageName, s, baseWorkingDir, loopEnv, stageStep)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	command := step.GetCommand()
+	if command == "" {
+		return fmt.Errorf("step %s in stage %s has no command; syntactic sugar steps are not supported locally", step.Name, stageName)
+	}
+
+	stepName := step.Name
+	if stepName == "" {
+		stepName = fmt.Sprintf("step%d", len(stageStep.Steps)+1)
+	}
+	workingDir := r.localWorkingDir(step.Dir, baseWorkingDir)
+
+	// the env is passed to each command rather than set on this process as steps can run concurrently
+	environ := os.Environ()
+	for _, e := range scopedEnv(toContainerEnvVars(step.Env), env) {
+		if e.ValueFrom != nil {
+			// secrets are not available locally so use the value from the local environment if there is one
+			environ = append(environ, e.Name+"="+os.Getenv(e.Name))
+			continue
+		}
+		environ = append(environ, e.Name+"="+r.replaceParams(e.Value))
+	}
+
+	cmdStr := command
+	if len(step.Arguments) > 0 {
+		cmdStr += " " + strings.Join(step.Arguments, " ")
+	}
+	cmdStr = r.replaceParams(cmdStr)
+
+	coreStep := v1.CoreActivityStep{
+		Name:             stepName,
+		Description:      cmdStr,
+		StartedTimestamp: nowTime(),
+	}
+
+	err := os.MkdirAll(workingDir, util.DefaultWritePermissions)
+	if err == nil {
+		out := r.prefixWriter(stageName + "/" + stepName)
+		cmd := exec.Command("/bin/sh", "-c", cmdStr)
+		cmd.Dir = workingDir
+		cmd.Env = environ
+		cmd.Stdout = out
+		cmd.Stderr = out
+		err = cmd.Run()
+		out.Flush()
+	}
+
+	coreStep.CompletedTimestamp = nowTime()
+	coreStep.Status = v1.ActivityStatusTypeSucceeded
+	if err != nil {
+		coreStep.Status = v1.ActivityStatusTypeFailed
+		err = errors.Wrapf(err, "step %s in stage %s failed", stepName, stageName)
+	}
+	stageStep.Steps = append(stageStep.Steps, coreStep)
+	return err
+}
+
+// localWorkingDir applies the same dir semantics as generateSteps, but relative to the local workspace
+func (r *LocalRunner) localWorkingDir(stepDir string, baseWorkingDir *string) string {
+	workingDir := filepath.Join(WorkingDirRoot, r.SourceDir)
+	if stepDir != "" {
+		workingDir = stepDir
+	} else if baseWorkingDir != nil {
+		workingDir = *baseWorkingDir
+	}
+	if !filepath.IsAbs(workingDir) {
+		workingDir = filepath.Join(WorkingDirRoot, r.SourceDir, workingDir)
+	}
+
+	rel, err := filepath.Rel(WorkingDirRoot, workingDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		// outside of the workspace so lets keep it inside the scratch workspace
+		rel = strings.TrimPrefix(workingDir, string(filepath.Separator))
+	}
+	return filepath.Join(r.Workspace, rel)
+}
+
+func (r *LocalRunner) replaceParams(text string) string {
+	for k, v := range r.Params {
+		text = strings.Replace(text, "${inputs.params."+k+"}", v, -1)
+		text = strings.Replace(text, "$(inputs.params."+k+")", v, -1)
+	}
+	return text
+}
+
+func (r *LocalRunner) prefixWriter(prefix string) *linePrefixWriter {
+	return &linePrefixWriter{
+		prefix: "[" + prefix + "] ",
+		out:    r.Out,
+		lock:   &r.lock,
+	}
+}
+
+// linePrefixWriter writes complete lines to the underlying writer with a prefix, so that the output of
+// concurrently running steps can be told apart
+type linePrefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	for {
+		idx := bytes.IndexByte(w.buffer.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := w.buffer.Next(idx + 1)
+		err := w.writeLine(line)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line
+func (w *linePrefixWriter) Flush() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	line := append(w.buffer.Bytes(), '\n')
+	w.buffer.Reset()
+	return w.writeLine(line)
+}
+
+func (w *linePrefixWriter) writeLine(line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
+
+// findStageByName returns the stage with the given name, searching nested sequential and parallel stages, along with
+// the working dir and env it inherits from the stages it is nested in
+func findStageByName(stages []Stage, name string, workingDir *string, env []corev1.EnvVar) (*Stage, *string, []corev1.EnvVar) {
+	for i := range stages {
+		s := &stages[i]
+		if s.Name == name || s.stageLabelName() == name {
+			return s, workingDir, env
+		}
+		nestedDir := workingDir
+		if s.WorkingDir != nil {
+			nestedDir = s.WorkingDir
+		}
+		nestedEnv := scopedEnv(toContainerEnvVars(s.GetEnv()), env)
+		if found, dir, foundEnv := findStageByName(s.Stages, name, nestedDir, nestedEnv); found != nil {
+			return found, dir, foundEnv
+		}
+		if found, dir, foundEnv := findStageByName(s.Parallel, name, nestedDir, nestedEnv); found != nil {
+			return found, dir, foundEnv
+		}
+	}
+	return nil, nil, nil
+}
+
+func nowTime() *metav1.Time {
+	t := metav1.NewTime(time.Now())
+	return &t
+}
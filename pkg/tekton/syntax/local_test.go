@@ -0,0 +1,146 @@
+package syntax_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/tekton/syntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRunner(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "test-local-runner")
+	require.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	subDir := "sub"
+	pipeline := &syntax.ParsedPipeline{
+		Agent: syntax.Agent{Image: "some-image"},
+		Env:   []syntax.EnvVar{{Name: "GREETING", Value: "hello"}},
+		Stages: []syntax.Stage{
+			{
+				Name: "First Stage",
+				Env:  []syntax.EnvVar{{Name: "TARGET", Value: "world"}},
+				Steps: []syntax.Step{
+					{
+						Name:      "write",
+						Command:   "echo",
+						Arguments: []string{"$GREETING $TARGET ${inputs.params.version}", ">", "greeting.txt"},
+					},
+					{
+						Name: "loop",
+						Loop: &syntax.Loop{
+							Variable: "NAME",
+							Values:   []string{"a", "b"},
+							Steps:    []syntax.Step{{Command: "touch", Arguments: []string{"$NAME.txt"}}},
+						},
+					},
+				},
+			},
+			{
+				Name:       "Second Stage",
+				WorkingDir: &subDir,
+				Parallel: []syntax.Stage{
+					{Name: "Left", Steps: []syntax.Step{{Name: "left", Command: "echo left > left.txt"}}},
+					{Name: "Right", Steps: []syntax.Step{{Name: "right", Command: "echo right > right.txt"}}},
+				},
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	runner := &syntax.LocalRunner{
+		Workspace: workspace,
+		Params:    map[string]string{"version": "1.0.0"},
+		Out:       out,
+	}
+	activity, err := runner.Run(pipeline)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(workspace, "source", "greeting.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world 1.0.0", strings.TrimSpace(string(data)))
+
+	for _, f := range []string{"source/a.txt", "source/b.txt", "source/sub/left.txt", "source/sub/right.txt"} {
+		assert.FileExists(t, filepath.Join(workspace, f))
+	}
+
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, activity.Spec.Status)
+	require.Len(t, activity.Spec.Steps, 3)
+	assert.Equal(t, "First Stage", activity.Spec.Steps[0].Stage.Name)
+	assert.Len(t, activity.Spec.Steps[0].Stage.Steps, 3)
+	assert.Contains(t, out.String(), "[Right/right] ")
+}
+
+func TestLocalRunnerStageFilterAndFailure(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "test-local-runner")
+	require.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	pipeline := &syntax.ParsedPipeline{
+		Agent: syntax.Agent{Image: "some-image"},
+		Stages: []syntax.Stage{
+			{Name: "Build", Steps: []syntax.Step{{Name: "ok", Command: "true"}}},
+			{Name: "Test", Steps: []syntax.Step{{Name: "fail", Command: "exit 1"}, {Name: "skipped", Command: "true"}}},
+		},
+	}
+
+	runner := &syntax.LocalRunner{
+		Workspace: workspace,
+		Stage:     "Test",
+		Out:       &bytes.Buffer{},
+	}
+	activity, err := runner.Run(pipeline)
+	require.Error(t, err)
+
+	assert.Equal(t, v1.ActivityStatusTypeFailed, activity.Spec.Status)
+	require.Len(t, activity.Spec.Steps, 1)
+	stage := activity.Spec.Steps[0].Stage
+	assert.Equal(t, "Test", stage.Name)
+	assert.Equal(t, v1.ActivityStatusTypeFailed, stage.Status)
+	assert.Len(t, stage.Steps, 1)
+
+	runner.Stage = "Missing"
+	_, err = runner.Run(pipeline)
+	assert.Error(t, err)
+}
+
+func TestLocalRunnerStageInheritsParentEnvAndDir(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "test-local-runner")
+	require.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	subDir := "sub"
+	pipeline := &syntax.ParsedPipeline{
+		Agent: syntax.Agent{Image: "some-image"},
+		Env:   []syntax.EnvVar{{Name: "GREETING", Value: "hello"}},
+		Stages: []syntax.Stage{
+			{
+				Name:       "Parent",
+				WorkingDir: &subDir,
+				Env:        []syntax.EnvVar{{Name: "TARGET", Value: "world"}},
+				Stages: []syntax.Stage{
+					{Name: "Child", Steps: []syntax.Step{{Name: "write", Command: "echo $GREETING $TARGET > greeting.txt"}}},
+				},
+			},
+		},
+	}
+
+	runner := &syntax.LocalRunner{
+		Workspace: workspace,
+		Stage:     "Child",
+		Out:       &bytes.Buffer{},
+	}
+	_, err = runner.Run(pipeline)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(workspace, "source", "sub", "greeting.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", strings.TrimSpace(string(data)))
+}
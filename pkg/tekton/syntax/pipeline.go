@@ -3,6 +3,8 @@ package syntax
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -21,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
@@ -59,7 +62,24 @@ type Agent struct {
 // EnvVar is a key/value pair defining an environment variable
 type EnvVar struct {
 	Name  string `json:"name"`
-	Value string `json:"value"`
+	Value string `json:"value,omitempty"`
+	// ValueFrom references a secret, configmap or Vault key to use as the value instead of a literal value
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource is the source of the value of an EnvVar. Only one of its fields may be set.
+type EnvVarSource struct {
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	VaultKeyRef     *VaultKeySelector            `json:"vaultKeyRef,omitempty"`
+}
+
+// VaultKeySelector selects a key of a secret stored in Vault
+type VaultKeySelector struct {
+	// Path is the path of the secret in Vault
+	Path string `json:"path"`
+	// Key is the key of the value within the secret
+	Key string `json:"key"`
 }
 
 // TimeoutUnit is used for calculating timeout duration
@@ -208,11 +228,68 @@ type Stage struct {
 	Parallel   []Stage      `json:"parallel,omitempty"`
 	Post       []Post       `json:"post,omitempty"`
 	WorkingDir *string      `json:"dir,omitempty"`
+	Services   []Service    `json:"services,omitempty"`
+	Approval   *Approval    `json:"approval,omitempty"`
 
 	// Replaced by Env, retained for backwards compatibility
 	Environment []EnvVar `json:"environment,omitempty"`
 }
 
+// ApprovalOutcome is the outcome of an approval stage
+type ApprovalOutcome string
+
+// The available approval outcomes.
+const (
+	ApprovalOutcomeApprove ApprovalOutcome = "approve"
+	ApprovalOutcomeReject  ApprovalOutcome = "reject"
+)
+
+// DefaultApprovalTimeout is how long an approval stage waits when it has no timeout
+var DefaultApprovalTimeout = Timeout{Time: 1, Unit: TimeoutUnitHours}
+
+// Approval pauses the pipeline until a user bound to the environment through an EnvironmentRoleBinding approves or
+// rejects it with 'jx step approve' or 'jx step reject'. If nobody does so before the timeout the default outcome is
+// used, which is to reject unless specified otherwise.
+type Approval struct {
+	Message string `json:"message,omitempty"`
+	// Environment is the environment whose EnvironmentRoleBindings determine who can approve
+	Environment string `json:"environment,omitempty"`
+	// Roles if specified restricts the approvers to the users bound to one of these roles
+	Roles          []string        `json:"roles,omitempty"`
+	Timeout        Timeout         `json:"timeout,omitempty"`
+	DefaultOutcome ApprovalOutcome `json:"defaultOutcome,omitempty"`
+}
+
+// Service is a container, such as a database or message broker, which runs next to the steps of a stage in the pod of
+// its Task. The service is started before the steps, which wait until it is ready and reach it on localhost at its
+// ports, and it is stopped after them. As the service is started by jx the command of the image must be specified.
+type Service struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Env     []EnvVar `json:"env,omitempty"`
+	Ports   []int32  `json:"ports,omitempty"`
+	// ReadinessProbe is used to wait for the service to be ready. It defaults to a TCP check of the first port.
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+}
+
+// GetReadinessProbe returns the probe used to wait for the service to be ready, or nil if the service is ready as soon
+// as it is started
+func (s *Service) GetReadinessProbe() *corev1.Probe {
+	if s.ReadinessProbe != nil || len(s.Ports) == 0 {
+		return s.ReadinessProbe
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt(int(s.Ports[0])),
+			},
+		},
+		PeriodSeconds: 2,
+	}
+}
+
 // PostCondition is used to specify under what condition a post action should be executed.
 type PostCondition string
 
@@ -487,6 +564,10 @@ func (j *ParsedPipeline) Validate(context context.Context) *apis.FieldError {
 		return err
 	}
 
+	if err := validateEnv(j.GetEnv()); err != nil {
+		return err
+	}
+
 	if err := validateStages(j.Stages, j.Agent); err != nil {
 		return err
 	}
@@ -534,8 +615,12 @@ func validateAgent(a Agent) *apis.FieldError {
 var containsASCIILetter = regexp.MustCompile(`[a-zA-Z]`).MatchString
 
 func validateStage(s Stage, parentAgent Agent) *apis.FieldError {
-	if len(s.Steps) == 0 && len(s.Stages) == 0 && len(s.Parallel) == 0 {
-		return apis.ErrMissingOneOf("steps", "stages", "parallel")
+	if s.Approval != nil {
+		if len(s.Steps) > 0 || len(s.Stages) > 0 || len(s.Parallel) > 0 {
+			return apis.ErrMultipleOneOf("steps", "stages", "parallel", "approval")
+		}
+	} else if len(s.Steps) == 0 && len(s.Stages) == 0 && len(s.Parallel) == 0 {
+		return apis.ErrMissingOneOf("steps", "stages", "parallel", "approval")
 	}
 
 	if !containsASCIILetter(s.Name) {
@@ -545,6 +630,10 @@ func validateStage(s Stage, parentAgent Agent) *apis.FieldError {
 		}
 	}
 
+	if err := validateEnv(s.GetEnv()); err != nil {
+		return err
+	}
+
 	stageAgent := s.Agent
 	if equality.Semantic.DeepEqual(stageAgent, Agent{}) {
 		stageAgent = parentAgent
@@ -591,6 +680,30 @@ func validateStage(s Stage, parentAgent Agent) *apis.FieldError {
 		}
 	}
 
+	if len(s.Services) > 0 {
+		if len(s.Steps) == 0 {
+			return &apis.FieldError{
+				Message: "services can only be specified on stages with steps",
+				Paths:   []string{"services"},
+			}
+		}
+		if err := validateServices(s.Services); err != nil {
+			return err
+		}
+	}
+
+	if s.Approval != nil {
+		if len(s.Services) > 0 {
+			return &apis.FieldError{
+				Message: "services can only be specified on stages with steps",
+				Paths:   []string{"services"},
+			}
+		}
+		if err := validateApproval(*s.Approval).ViaField("approval"); err != nil {
+			return err
+		}
+	}
+
 	if len(s.Stages) > 0 {
 		if len(s.Parallel) > 0 {
 			return apis.ErrMultipleOneOf("steps", "stages", "parallel")
@@ -690,12 +803,184 @@ func validateStep(s Step) *apis.FieldError {
 		return err.ViaField("loop")
 	}
 
+	if err := validateEnv(s.Env); err != nil {
+		return err
+	}
+
 	if s.Agent != nil {
 		return validateAgent(*s.Agent).ViaField("agent")
 	}
 	return nil
 }
 
+var (
+	credentialName    = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|credentials?)`)
+	credentialPattern = regexp.MustCompile(`(AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|xox[abprs]-[A-Za-z0-9-]{10,}|-----BEGIN [A-Z ]*PRIVATE KEY-----)`)
+	resourceName      = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	containsDigit     = regexp.MustCompile(`[0-9]`).MatchString
+)
+
+// LooksLikeCredential returns true if the literal value of an env var looks like a credential, either because it
+// matches a well known token format or because the name implies a secret and the value looks generated. It is used by
+// the hardcoded-secret lint rule rather than by validation as it can have false positives.
+func LooksLikeCredential(name string, value string) bool {
+	if credentialPattern.MatchString(value) {
+		return true
+	}
+	if !credentialName.MatchString(name) || len(value) < 12 || strings.ContainsAny(value, "$ /") {
+		return false
+	}
+	// the name of a secret rather than its value
+	if resourceName.MatchString(value) && strings.ContainsAny(value, "-.") {
+		return false
+	}
+	return containsASCIILetter(value) && containsDigit(value)
+}
+
+func validateEnv(env []EnvVar) *apis.FieldError {
+	for i, e := range env {
+		if err := validateEnvVar(e).ViaFieldIndex("env", i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateEnvVar(e EnvVar) *apis.FieldError {
+	if e.Name == "" {
+		return apis.ErrMissingField("name")
+	}
+
+	if e.ValueFrom != nil {
+		if e.Value != "" {
+			return apis.ErrMultipleOneOf("value", "valueFrom")
+		}
+		return validateEnvVarSource(e.ValueFrom).ViaField("valueFrom")
+	}
+
+	return nil
+}
+
+func validateEnvVarSource(s *EnvVarSource) *apis.FieldError {
+	if s.SecretKeyRef == nil && s.ConfigMapKeyRef == nil && s.VaultKeyRef == nil {
+		return apis.ErrMissingOneOf("secretKeyRef", "configMapKeyRef", "vaultKeyRef")
+	}
+
+	if moreThanOneAreTrue(s.SecretKeyRef != nil, s.ConfigMapKeyRef != nil, s.VaultKeyRef != nil) {
+		return apis.ErrMultipleOneOf("secretKeyRef", "configMapKeyRef", "vaultKeyRef")
+	}
+
+	if s.SecretKeyRef != nil {
+		if s.SecretKeyRef.Name == "" {
+			return apis.ErrMissingField("name").ViaField("secretKeyRef")
+		}
+		if s.SecretKeyRef.Key == "" {
+			return apis.ErrMissingField("key").ViaField("secretKeyRef")
+		}
+	}
+
+	if s.ConfigMapKeyRef != nil {
+		if s.ConfigMapKeyRef.Name == "" {
+			return apis.ErrMissingField("name").ViaField("configMapKeyRef")
+		}
+		if s.ConfigMapKeyRef.Key == "" {
+			return apis.ErrMissingField("key").ViaField("configMapKeyRef")
+		}
+	}
+
+	if s.VaultKeyRef != nil {
+		if s.VaultKeyRef.Path == "" {
+			return apis.ErrMissingField("path").ViaField("vaultKeyRef")
+		}
+		if s.VaultKeyRef.Key == "" {
+			return apis.ErrMissingField("key").ViaField("vaultKeyRef")
+		}
+	}
+
+	return nil
+}
+
+var serviceName = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+const maxServiceNameLength = 63 - len("build-step-"+ServiceStepNamePrefix)
+
+func validateServices(services []Service) *apis.FieldError {
+	seen := map[string]bool{}
+	ports := map[int32]string{}
+	for i, svc := range services {
+		if err := validateService(svc).ViaFieldIndex("services", i); err != nil {
+			return err
+		}
+		if seen[svc.Name] {
+			return &apis.FieldError{
+				Message: fmt.Sprintf("service names within a stage must be unique but %s is used more than once", svc.Name),
+				Paths:   []string{"services"},
+			}
+		}
+		seen[svc.Name] = true
+		// the services share the network of the pod so they cannot listen on the same port
+		for _, port := range svc.Ports {
+			if other, ok := ports[port]; ok {
+				return &apis.FieldError{
+					Message: fmt.Sprintf("services %s and %s both use port %d", other, svc.Name, port),
+					Paths:   []string{"services"},
+				}
+			}
+			ports[port] = svc.Name
+		}
+	}
+
+	return nil
+}
+
+func validateApproval(a Approval) *apis.FieldError {
+	switch a.DefaultOutcome {
+	case "", ApprovalOutcomeApprove, ApprovalOutcomeReject:
+	default:
+		return &apis.FieldError{
+			Message: fmt.Sprintf("%s is not a valid approval outcome. Valid outcomes are %s, %s", a.DefaultOutcome,
+				ApprovalOutcomeApprove, ApprovalOutcomeReject),
+			Paths: []string{"defaultOutcome"},
+		}
+	}
+	for i, role := range a.Roles {
+		if role == "" {
+			return apis.ErrMissingField(fmt.Sprintf("roles[%d]", i))
+		}
+	}
+	return validateTimeout(a.Timeout).ViaField("timeout")
+}
+
+func validateService(s Service) *apis.FieldError {
+	if s.Name == "" {
+		return apis.ErrMissingField("name")
+	}
+	// the name of the container running the service is the name with the prefixes of Tekton and ServiceStepNamePrefix
+	if len(s.Name) > maxServiceNameLength || !serviceName.MatchString(s.Name) {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("the service name must be at most %d lower case letters, digits and hyphens", maxServiceNameLength),
+			Paths:   []string{"name"},
+		}
+	}
+	if s.Image == "" {
+		return apis.ErrMissingField("image")
+	}
+	if len(s.Command) == 0 {
+		return apis.ErrMissingField("command")
+	}
+	for i, port := range s.Ports {
+		if port < 1 || port > 65535 {
+			return &apis.FieldError{
+				Message: fmt.Sprintf("%d is not a valid port number", port),
+				Paths:   []string{fmt.Sprintf("ports[%d]", i)},
+			}
+		}
+	}
+
+	return validateEnv(s.Env)
+}
+
 func validateLoop(l *Loop) *apis.FieldError {
 	if l != nil {
 		if l.Variable == "" {
@@ -920,13 +1205,90 @@ func EnvMapToSlice(envMap map[string]corev1.EnvVar) []corev1.EnvVar {
 func toContainerEnvVars(origEnv []EnvVar) []corev1.EnvVar {
 	env := make([]corev1.EnvVar, 0, len(origEnv))
 	for _, e := range origEnv {
-		env = append(env, corev1.EnvVar{
+		env = append(env, toContainerEnvVar(e))
+	}
+
+	return env
+}
+
+// toContainerEnvVar converts the env var to a container env var. Vault keys are referenced from the Secret named by
+// VaultSecretName, which is populated from Vault when the pipeline is created.
+func toContainerEnvVar(e EnvVar) corev1.EnvVar {
+	if e.ValueFrom == nil {
+		return corev1.EnvVar{
 			Name:  e.Name,
 			Value: e.Value,
-		})
+		}
+	}
+	source := &corev1.EnvVarSource{
+		SecretKeyRef:    e.ValueFrom.SecretKeyRef,
+		ConfigMapKeyRef: e.ValueFrom.ConfigMapKeyRef,
+	}
+	if v := e.ValueFrom.VaultKeyRef; v != nil {
+		source.SecretKeyRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: VaultSecretName(v.Path),
+			},
+			Key: v.Key,
+		}
 	}
+	return corev1.EnvVar{
+		Name:      e.Name,
+		ValueFrom: source,
+	}
+}
 
-	return env
+// VaultSecretName returns the name of the Secret that the keys of the Vault secret at the given path are copied to
+func VaultSecretName(path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return MangleToRfc1035Label("jx-vault-"+strings.Replace(strings.Trim(path, "/"), "/", "-", -1), hex.EncodeToString(hash[:])[:8])
+}
+
+// VaultKeyRefs returns the Vault keys referenced by the env vars of the pipeline, its stages, services and steps
+func (j *ParsedPipeline) VaultKeyRefs() []VaultKeySelector {
+	refs := map[VaultKeySelector]bool{}
+	addEnv := func(env []EnvVar) {
+		for _, e := range env {
+			if e.ValueFrom != nil && e.ValueFrom.VaultKeyRef != nil {
+				refs[*e.ValueFrom.VaultKeyRef] = true
+			}
+		}
+	}
+	var addSteps func(steps []Step)
+	addSteps = func(steps []Step) {
+		for _, step := range steps {
+			addEnv(step.Env)
+			if step.Loop != nil {
+				addSteps(step.Loop.Steps)
+			}
+		}
+	}
+	var addStages func(stages []Stage)
+	addStages = func(stages []Stage) {
+		for _, s := range stages {
+			addEnv(s.GetEnv())
+			for _, svc := range s.Services {
+				addEnv(svc.Env)
+			}
+			addSteps(s.Steps)
+			addStages(s.Stages)
+			addStages(s.Parallel)
+		}
+	}
+	addEnv(j.GetEnv())
+	addStages(j.Stages)
+
+	answer := make([]VaultKeySelector, 0, len(refs))
+	for ref := range refs {
+		answer = append(answer, ref)
+	}
+	sort.Slice(answer, func(i, k int) bool {
+		if answer[i].Path != answer[k].Path {
+			return answer[i].Path < answer[k].Path
+		}
+		return answer[i].Key < answer[k].Key
+	})
+	return answer
 }
 
 // AddContainerEnvVarsToPipeline allows for adding a slice of container environment variables directly to the
@@ -988,7 +1350,7 @@ func (j *ParsedPipeline) toStepEnvVars() []corev1.EnvVar {
 	envMap := make(map[string]corev1.EnvVar)
 
 	for _, e := range j.GetEnv() {
-		envMap[e.Name] = corev1.EnvVar{Name: e.Name, Value: e.Value}
+		envMap[e.Name] = toContainerEnvVar(e)
 	}
 
 	return EnvMapToSlice(envMap)
@@ -1151,7 +1513,7 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 		return nil, err
 	}
 
-	if len(s.Steps) > 0 {
+	if len(s.Steps) > 0 || s.Approval != nil {
 		t := &tektonv1alpha1.Task{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: TektonAPIVersion,
@@ -1192,6 +1554,29 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 
 		// We don't want to dupe volumes for the Task if there are multiple steps
 		volumes := make(map[string]corev1.Volume)
+
+		if len(s.Services) > 0 {
+			serviceSteps, err := servicesSteps(s.Services, sourceDir)
+			if err != nil {
+				return nil, err
+			}
+			t.Spec.Steps = append(t.Spec.Steps, serviceSteps...)
+			volumes[ServicesVolumeName] = corev1.Volume{
+				Name: ServicesVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				},
+			}
+		}
+
+		if s.Approval != nil {
+			approvalStep, err := approvalStep(s, enclosingStage, sourceDir)
+			if err != nil {
+				return nil, err
+			}
+			t.Spec.Steps = append(t.Spec.Steps, approvalStep)
+		}
+
 		for _, step := range s.Steps {
 			actualSteps, stepVolumes, newCounter, err := generateSteps(step, agent.Image, sourceDir, baseWorkingDir, env, stageContainer, podTemplates, stepCounter)
 			if err != nil {
@@ -1206,6 +1591,10 @@ func stageToTask(s Stage, pipelineIdentifier string, buildIdentifier string, nam
 			}
 		}
 
+		if len(s.Services) > 0 {
+			t.Spec.Steps = append(t.Spec.Steps, servicesStep(StopServicesStepName, "stop", sourceDir))
+		}
+
 		// Avoid nondeterministic results by sorting the keys and appending volumes in that order.
 		var volNames []string
 		for k := range volumes {
@@ -1317,6 +1706,93 @@ func MergeContainers(parentContainer, childContainer *corev1.Container) (*corev1
 	return merged, nil
 }
 
+// servicesSteps returns the steps which run the services of a stage as containers of its Task pod. Tekton runs the
+// steps of a Task one after another, so each service is run by 'jx step services run', which lets the next step start
+// once the service is ready and keeps the service running until the stop step after the steps of the stage. The first
+// step places the jx binary in the volume shared with the services, as the images of the services do not contain it.
+func servicesSteps(services []Service, sourceDir string) ([]corev1.Container, error) {
+	steps := []corev1.Container{servicesStep(StartServicesStepName, "start", sourceDir)}
+	for i := range services {
+		svc := &services[i]
+		data, err := json.Marshal(svc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal service %s", svc.Name)
+		}
+		c := corev1.Container{
+			Name:         ServiceStepNamePrefix + svc.Name,
+			Image:        svc.Image,
+			Command:      []string{filepath.Join(ServicesDir, "jx")},
+			Args:         []string{"step", "services", "run", "--dir", ServicesDir},
+			Env:          append(toContainerEnvVars(svc.Env), corev1.EnvVar{Name: ServiceEnvVar, Value: string(data)}),
+			VolumeMounts: []corev1.VolumeMount{{Name: ServicesVolumeName, MountPath: ServicesDir}},
+		}
+		for _, port := range svc.Ports {
+			c.Ports = append(c.Ports, corev1.ContainerPort{
+				ContainerPort: port,
+				Protocol:      corev1.ProtocolTCP,
+			})
+		}
+		steps = append(steps, c)
+	}
+	return steps, nil
+}
+
+// servicesStep creates a step which starts or stops the services of a stage
+func servicesStep(name string, action string, sourceDir string) corev1.Container {
+	return corev1.Container{
+		Name:         name,
+		Image:        GitMergeImage,
+		Command:      []string{"jx"},
+		Args:         []string{"step", "services", action, "--dir", ServicesDir},
+		WorkingDir:   filepath.Join(WorkingDirRoot, sourceDir),
+		VolumeMounts: []corev1.VolumeMount{{Name: ServicesVolumeName, MountPath: ServicesDir}},
+	}
+}
+
+// approvalStep returns the step which waits for the approval of an approval stage
+func approvalStep(s Stage, enclosingStage *transformedStage, sourceDir string) (corev1.Container, error) {
+	a := s.Approval
+	timeout := a.Timeout
+	if equality.Semantic.DeepEqual(timeout, Timeout{}) {
+		timeout = DefaultApprovalTimeout
+	}
+	d, err := timeout.toDuration()
+	if err != nil {
+		return corev1.Container{}, errors.Wrapf(err, "invalid timeout for the approval of stage %s", s.Name)
+	}
+	defaultOutcome := a.DefaultOutcome
+	if defaultOutcome == "" {
+		defaultOutcome = ApprovalOutcomeReject
+	}
+
+	// the stage name used by the PipelineActivity includes the names of the enclosing stages
+	names := []string{s.Name}
+	for e := enclosingStage; e != nil; e = e.EnclosingStage {
+		names = append([]string{e.Stage.Name}, names...)
+	}
+	args := []string{"step", "wait-for-approval",
+		"--stage", strings.NewReplacer("-", " ").Replace(strings.Join(names, " / ")),
+		"--timeout", d.Duration.String(),
+		"--default-outcome", string(defaultOutcome),
+	}
+	if a.Message != "" {
+		args = append(args, "--message", a.Message)
+	}
+	if a.Environment != "" {
+		args = append(args, "--environment", a.Environment)
+	}
+	for _, role := range a.Roles {
+		args = append(args, "--role", role)
+	}
+	return corev1.Container{
+		Name:       ApprovalStepName,
+		Image:      GitMergeImage,
+		Command:    []string{"jx"},
+		Args:       args,
+		WorkingDir: filepath.Join(WorkingDirRoot, sourceDir),
+	}, nil
+}
+
 func isNestedFirstStepsStage(enclosingStage *transformedStage) bool {
 	if enclosingStage != nil {
 		if enclosingStage.PreviousSiblingStage != nil {
@@ -145,11 +145,21 @@ func CopyFileOrDir(src string, dst string, force bool) (err error) {
 	return CopyFile(src, dst)
 }
 
-// credit https://gist.github.com/r0l1/92462b38df26839a3ca324697c8cba04
+// CopyDir copies the directory recursively. If the destination is inside the source it is not copied into itself.
 func CopyDir(src string, dst string, force bool) (err error) {
-	src = filepath.Clean(src)
-	dst = filepath.Clean(dst)
+	src, err = filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	dst, err = filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	return copyDir(src, dst, force, dst)
+}
 
+// credit https://gist.github.com/r0l1/92462b38df26839a3ca324697c8cba04
+func copyDir(src string, dst string, force bool, exclude string) (err error) {
 	si, err := os.Stat(src)
 	if err != nil {
 		return err
@@ -183,9 +193,12 @@ func CopyDir(src string, dst string, force bool) (err error) {
 	for _, entry := range entries {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
+		if srcPath == exclude {
+			continue
+		}
 
 		if entry.IsDir() {
-			err = CopyDir(srcPath, dstPath, force)
+			err = copyDir(srcPath, dstPath, force, exclude)
 			if err != nil {
 				return
 			}
@@ -126,3 +126,27 @@ func TestDeleteDirContentsExcept(t *testing.T) {
 		fmt.Sprintf("Expected tmp dir %s to be empty, but contains %v.", tmpDir, remainingFiles))
 
 }
+
+func TestCopyDirIntoItself(t *testing.T) {
+	t.Parallel()
+
+	tmpDir, err := ioutil.TempDir("", "TestCopyDirIntoItself")
+	require.NoError(t, err, "Failed to create temporary directory.")
+	defer os.RemoveAll(tmpDir)
+
+	err = os.MkdirAll(filepath.Join(tmpDir, "src"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	dst := filepath.Join(tmpDir, "workspace", "source")
+	err = util.CopyDir(tmpDir, dst, true)
+	require.NoError(t, err)
+
+	exists, err := util.FileExists(filepath.Join(dst, "src", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, true, exists)
+	exists, err = util.FileExists(filepath.Join(dst, "workspace", "source"))
+	require.NoError(t, err)
+	assert.Equal(t, false, exists, "the destination is not copied into itself")
+}
@@ -0,0 +1,256 @@
+package webhooks
+
+import (
+	"strings"
+)
+
+type bitbucketServerRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+// cloneURL returns the http clone URL of the repository
+func (r *bitbucketServerRepository) cloneURL() string {
+	for _, link := range r.Links.Clone {
+		if strings.HasPrefix(link.Name, "http") {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+type bitbucketServerRef struct {
+	ID           string                    `json:"id"`
+	DisplayID    string                    `json:"displayId"`
+	LatestCommit string                    `json:"latestCommit"`
+	Repository   bitbucketServerRepository `json:"repository"`
+}
+
+type bitbucketServerEvent struct {
+	Actor struct {
+		Name string `json:"name"`
+	} `json:"actor"`
+	Repository *bitbucketServerRepository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"ref"`
+		ToHash string `json:"toHash"`
+		Type   string `json:"type"`
+	} `json:"changes"`
+	PullRequest *struct {
+		ID     int `json:"id"`
+		Author struct {
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"author"`
+		FromRef bitbucketServerRef `json:"fromRef"`
+		ToRef   bitbucketServerRef `json:"toRef"`
+		Links   struct {
+			Self []struct {
+				Href string `json:"href"`
+			} `json:"self"`
+		} `json:"links"`
+	} `json:"pullRequest"`
+	Comment *struct {
+		Text string `json:"text"`
+	} `json:"comment"`
+}
+
+// parseBitbucketServerEvent parses the push, pull request and comment events of Bitbucket Server
+func parseBitbucketServerEvent(eventKey string, body []byte) (*Event, error) {
+	payload := &bitbucketServerEvent{}
+	err := unmarshalEvent(body, payload, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		Sender: payload.Actor.Name,
+	}
+	switch eventKey {
+	case "repo:refs_changed":
+		if payload.Repository == nil {
+			return nil, ignored("push without a repository")
+		}
+		event.Kind = EventKindPush
+		event.Owner = payload.Repository.Project.Key
+		event.Repo = payload.Repository.Slug
+		event.CloneURL = payload.Repository.cloneURL()
+		for _, change := range payload.Changes {
+			if change.Type == "DELETE" || change.Ref.Type != "BRANCH" {
+				continue
+			}
+			event.Branch = branchOfRef(change.Ref.ID)
+			event.Sha = change.ToHash
+			break
+		}
+		if event.Branch == "" {
+			return nil, ignored("push did not update a branch")
+		}
+	case "pr:opened", "pr:from_ref_updated", "pr:comment:added":
+		pr := payload.PullRequest
+		if pr == nil {
+			return nil, ignored("%s event without a pull request", eventKey)
+		}
+		repository := pr.ToRef.Repository
+		event.Owner = repository.Project.Key
+		event.Repo = repository.Slug
+		event.CloneURL = repository.cloneURL()
+		event.PullRequest = &EventPullRequest{
+			Number:   pr.ID,
+			Author:   pr.Author.User.Name,
+			HeadRef:  pr.FromRef.DisplayID,
+			HeadSha:  pr.FromRef.LatestCommit,
+			BaseRef:  pr.ToRef.DisplayID,
+			BaseSha:  pr.ToRef.LatestCommit,
+			Complete: pr.FromRef.LatestCommit != "" && pr.ToRef.DisplayID != "",
+		}
+		if len(pr.Links.Self) > 0 {
+			event.PullRequest.Link = pr.Links.Self[0].Href
+		}
+		event.Kind = EventKindPullRequest
+		if eventKey == "pr:comment:added" {
+			if payload.Comment == nil {
+				return nil, ignored("comment event without a comment")
+			}
+			event.Kind = EventKindComment
+			event.Comment = payload.Comment.Text
+		}
+	default:
+		return nil, ignored("event type %s", eventKey)
+	}
+	return event, nil
+}
+
+type bitbucketCloudUser struct {
+	UserName string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+func (u *bitbucketCloudUser) name() string {
+	if u.UserName != "" {
+		return u.UserName
+	}
+	return u.Nickname
+}
+
+type bitbucketCloudRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+type bitbucketCloudEvent struct {
+	Actor      bitbucketCloudUser `json:"actor"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		Links    struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+	Push *struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	PullRequest *struct {
+		ID          int                `json:"id"`
+		Author      bitbucketCloudUser `json:"author"`
+		Source      bitbucketCloudRef  `json:"source"`
+		Destination bitbucketCloudRef  `json:"destination"`
+		Links       struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"pullrequest"`
+	Comment *struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	} `json:"comment"`
+}
+
+// parseBitbucketCloudEvent parses the push, pull request and comment events of Bitbucket Cloud
+func parseBitbucketCloudEvent(eventKey string, body []byte) (*Event, error) {
+	payload := &bitbucketCloudEvent{}
+	err := unmarshalEvent(body, payload, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		Sender: payload.Actor.name(),
+	}
+	parts := strings.SplitN(payload.Repository.FullName, "/", 2)
+	if len(parts) == 2 {
+		event.Owner = parts[0]
+		event.Repo = parts[1]
+	}
+	if payload.Repository.Links.HTML.Href != "" {
+		event.CloneURL = payload.Repository.Links.HTML.Href + ".git"
+	}
+	switch eventKey {
+	case "repo:push":
+		event.Kind = EventKindPush
+		if payload.Push != nil {
+			for _, change := range payload.Push.Changes {
+				if change.New == nil || change.New.Type != "branch" {
+					continue
+				}
+				event.Branch = change.New.Name
+				event.Sha = change.New.Target.Hash
+				break
+			}
+		}
+		if event.Branch == "" {
+			return nil, ignored("push did not update a branch")
+		}
+	case "pullrequest:created", "pullrequest:updated", "pullrequest:comment_created":
+		pr := payload.PullRequest
+		if pr == nil {
+			return nil, ignored("%s event without a pull request", eventKey)
+		}
+		event.PullRequest = &EventPullRequest{
+			Number:   pr.ID,
+			Author:   pr.Author.name(),
+			HeadRef:  pr.Source.Branch.Name,
+			HeadSha:  pr.Source.Commit.Hash,
+			BaseRef:  pr.Destination.Branch.Name,
+			BaseSha:  pr.Destination.Commit.Hash,
+			Link:     pr.Links.HTML.Href,
+			Complete: pr.Source.Commit.Hash != "" && pr.Destination.Branch.Name != "",
+		}
+		event.Kind = EventKindPullRequest
+		if eventKey == "pullrequest:comment_created" {
+			if payload.Comment == nil {
+				return nil, ignored("comment event without a comment")
+			}
+			event.Kind = EventKindComment
+			event.Comment = payload.Comment.Content.Raw
+		}
+	default:
+		return nil, ignored("event type %s", eventKey)
+	}
+	return event, nil
+}
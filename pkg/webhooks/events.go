@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/pkg/errors"
+)
+
+// EventKind is the kind of a git provider event which can trigger pipelines
+type EventKind string
+
+const (
+	// EventKindPush a push of commits to a branch
+	EventKindPush EventKind = "push"
+	// EventKindPullRequest a pull request was opened, reopened or has new commits
+	EventKindPullRequest EventKind = "pullRequest"
+	// EventKindComment a comment on a pull request
+	EventKindComment EventKind = "comment"
+)
+
+// nullSha is the revision of a branch which does not exist before a push creates it or after a push deletes it
+const nullSha = "0000000000000000000000000000000000000000"
+
+// Event is a push, pull request or pull request comment event of any git provider
+type Event struct {
+	Kind     EventKind
+	GitKind  string
+	Owner    string
+	Repo     string
+	CloneURL string
+	// Sender is the user who caused the event
+	Sender string
+
+	// Branch and Sha are the branch and revision pushed to
+	Branch string
+	Sha    string
+	// ChangedFiles are the files changed by the push if the git provider includes them
+	ChangedFiles []string
+
+	PullRequest *EventPullRequest
+	// Comment is the text of the comment on a pull request
+	Comment string
+}
+
+// EventPullRequest is the pull request of an event
+type EventPullRequest struct {
+	Number   int
+	Author   string
+	HeadRef  string
+	HeadSha  string
+	BaseRef  string
+	BaseSha  string
+	Link     string
+	Complete bool
+}
+
+// ErrIgnoredEvent is returned when an event does not trigger pipelines, such as a pushed tag or closed pull request
+type ErrIgnoredEvent struct {
+	Reason string
+}
+
+func (e *ErrIgnoredEvent) Error() string {
+	return "ignored event: " + e.Reason
+}
+
+// IsIgnoredEvent returns true if the error is an ErrIgnoredEvent
+func IsIgnoredEvent(err error) bool {
+	_, ok := errors.Cause(err).(*ErrIgnoredEvent)
+	return ok
+}
+
+func ignored(format string, args ...interface{}) error {
+	return &ErrIgnoredEvent{Reason: fmt.Sprintf(format, args...)}
+}
+
+// GitKindOfRequest returns the kind of git provider which sent the webhook request based on its headers
+func GitKindOfRequest(r *http.Request) string {
+	switch {
+	case r.Header.Get("X-Gitea-Event") != "":
+		return gits.KindGitea
+	case r.Header.Get("X-GitHub-Event") != "":
+		return gits.KindGitHub
+	case r.Header.Get("X-Gitlab-Event") != "":
+		return gits.KindGitlab
+	case r.Header.Get("X-Event-Key") != "" && r.Header.Get("X-Hook-UUID") != "":
+		return gits.KindBitBucketCloud
+	case r.Header.Get("X-Event-Key") != "":
+		return gits.KindBitBucketServer
+	}
+	return gits.KindUnknown
+}
+
+// ParseEvent parses the push, pull request or comment event of a webhook request of any git provider. Events which
+// cannot trigger pipelines return an ErrIgnoredEvent.
+func ParseEvent(r *http.Request, body []byte) (*Event, error) {
+	gitKind := GitKindOfRequest(r)
+	var event *Event
+	var err error
+	switch gitKind {
+	case gits.KindGitHub:
+		event, err = parseGitHubEvent(r.Header.Get("X-GitHub-Event"), body)
+	case gits.KindGitea:
+		event, err = parseGitHubEvent(r.Header.Get("X-Gitea-Event"), body)
+	case gits.KindGitlab:
+		event, err = parseGitLabEvent(r.Header.Get("X-Gitlab-Event"), body)
+	case gits.KindBitBucketServer:
+		event, err = parseBitbucketServerEvent(r.Header.Get("X-Event-Key"), body)
+	case gits.KindBitBucketCloud:
+		event, err = parseBitbucketCloudEvent(r.Header.Get("X-Event-Key"), body)
+	default:
+		return nil, fmt.Errorf("could not determine the git provider of the webhook request from its headers")
+	}
+	if err != nil {
+		return nil, err
+	}
+	event.GitKind = gitKind
+	return event, nil
+}
+
+// branchOfRef returns the branch of a git reference such as refs/heads/master or an empty string if it is not a branch
+func branchOfRef(ref string) string {
+	if strings.HasPrefix(ref, "refs/heads/") {
+		return strings.TrimPrefix(ref, "refs/heads/")
+	}
+	return ""
+}
+
+func unmarshalEvent(body []byte, event interface{}, eventType string) error {
+	err := json.Unmarshal(body, event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the %s event", eventType)
+	}
+	return nil
+}
+
+type gitHubUser struct {
+	Login    string `json:"login"`
+	UserName string `json:"username"`
+}
+
+func (u *gitHubUser) name() string {
+	if u.Login != "" {
+		return u.Login
+	}
+	return u.UserName
+}
+
+type gitHubRepository struct {
+	Name     string     `json:"name"`
+	CloneURL string     `json:"clone_url"`
+	Owner    gitHubUser `json:"owner"`
+}
+
+type gitHubCommit struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+type gitHubBranch struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+type gitHubPullRequest struct {
+	Number  int          `json:"number"`
+	HTMLURL string       `json:"html_url"`
+	User    gitHubUser   `json:"user"`
+	Head    gitHubBranch `json:"head"`
+	Base    gitHubBranch `json:"base"`
+}
+
+type gitHubEvent struct {
+	Action      string             `json:"action"`
+	Ref         string             `json:"ref"`
+	Before      string             `json:"before"`
+	After       string             `json:"after"`
+	Deleted     bool               `json:"deleted"`
+	Commits     []gitHubCommit     `json:"commits"`
+	Repository  gitHubRepository   `json:"repository"`
+	Sender      gitHubUser         `json:"sender"`
+	PullRequest *gitHubPullRequest `json:"pull_request"`
+	Issue       *struct {
+		Number      int         `json:"number"`
+		PullRequest interface{} `json:"pull_request"`
+	} `json:"issue"`
+	Comment *struct {
+		Body string `json:"body"`
+	} `json:"comment"`
+	// IsPull is set by Gitea on comments on pull requests
+	IsPull bool `json:"is_pull"`
+}
+
+// parseGitHubEvent parses the events of GitHub and Gitea which has compatible payloads
+func parseGitHubEvent(eventType string, body []byte) (*Event, error) {
+	payload := &gitHubEvent{}
+	err := unmarshalEvent(body, payload, eventType)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		Owner:    payload.Repository.Owner.name(),
+		Repo:     payload.Repository.Name,
+		CloneURL: payload.Repository.CloneURL,
+		Sender:   payload.Sender.name(),
+	}
+	switch eventType {
+	case "push":
+		event.Kind = EventKindPush
+		event.Branch = branchOfRef(payload.Ref)
+		event.Sha = payload.After
+		if event.Branch == "" {
+			return nil, ignored("push to %s is not a branch", payload.Ref)
+		}
+		if payload.Deleted || payload.After == nullSha {
+			return nil, ignored("branch %s was deleted", event.Branch)
+		}
+		for _, c := range payload.Commits {
+			event.ChangedFiles = append(event.ChangedFiles, c.Added...)
+			event.ChangedFiles = append(event.ChangedFiles, c.Removed...)
+			event.ChangedFiles = append(event.ChangedFiles, c.Modified...)
+		}
+	case "pull_request":
+		switch payload.Action {
+		case "opened", "reopened", "synchronize", "synchronized":
+		default:
+			return nil, ignored("pull request action %s", payload.Action)
+		}
+		if payload.PullRequest == nil {
+			return nil, fmt.Errorf("the pull_request event has no pull_request")
+		}
+		event.Kind = EventKindPullRequest
+		event.PullRequest = toEventPullRequest(payload.PullRequest)
+	case "issue_comment":
+		if payload.Action != "" && payload.Action != "created" {
+			return nil, ignored("comment action %s", payload.Action)
+		}
+		if payload.Issue == nil || payload.Comment == nil {
+			return nil, fmt.Errorf("the issue_comment event has no issue or comment")
+		}
+		if payload.Issue.PullRequest == nil && !payload.IsPull {
+			return nil, ignored("comment on issue %d which is not a pull request", payload.Issue.Number)
+		}
+		event.Kind = EventKindComment
+		event.Comment = payload.Comment.Body
+		event.PullRequest = &EventPullRequest{Number: payload.Issue.Number}
+		if payload.PullRequest != nil {
+			event.PullRequest = toEventPullRequest(payload.PullRequest)
+		}
+	default:
+		return nil, ignored("event type %s", eventType)
+	}
+	return event, nil
+}
+
+func toEventPullRequest(pr *gitHubPullRequest) *EventPullRequest {
+	return &EventPullRequest{
+		Number:   pr.Number,
+		Author:   pr.User.name(),
+		HeadRef:  pr.Head.Ref,
+		HeadSha:  pr.Head.Sha,
+		BaseRef:  pr.Base.Ref,
+		BaseSha:  pr.Base.Sha,
+		Link:     pr.HTMLURL,
+		Complete: pr.Head.Sha != "" && pr.Base.Ref != "",
+	}
+}
@@ -0,0 +1,222 @@
+package webhooks_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestEvent(t *testing.T, headers map[string]string, body string) (*webhooks.Event, error) {
+	req, err := http.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return webhooks.ParseEvent(req, []byte(body))
+}
+
+func TestParseGitHubEvents(t *testing.T) {
+	t.Parallel()
+	headers := map[string]string{"X-GitHub-Event": "push"}
+	event, err := parseTestEvent(t, headers, `{
+		"ref": "refs/heads/master",
+		"after": "abc123",
+		"commits": [{"added": ["docs/new.md"], "modified": ["main.go"], "removed": []}],
+		"repository": {"name": "myapp", "clone_url": "https://github.com/myorg/myapp.git", "owner": {"login": "myorg"}},
+		"sender": {"login": "jstrachan"}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindPush, event.Kind)
+	assert.Equal(t, gits.KindGitHub, event.GitKind)
+	assert.Equal(t, "myorg", event.Owner)
+	assert.Equal(t, "myapp", event.Repo)
+	assert.Equal(t, "https://github.com/myorg/myapp.git", event.CloneURL)
+	assert.Equal(t, "master", event.Branch)
+	assert.Equal(t, "abc123", event.Sha)
+	assert.Equal(t, []string{"docs/new.md", "main.go"}, event.ChangedFiles)
+
+	_, err = parseTestEvent(t, headers, `{"ref": "refs/tags/v1.0.0", "after": "abc123"}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err), "tag pushes are ignored")
+	_, err = parseTestEvent(t, headers, `{"ref": "refs/heads/feature", "deleted": true, "after": "0000000000000000000000000000000000000000"}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err), "deleted branches are ignored")
+
+	headers = map[string]string{"X-GitHub-Event": "pull_request"}
+	event, err = parseTestEvent(t, headers, `{
+		"action": "synchronize",
+		"pull_request": {
+			"number": 7,
+			"html_url": "https://github.com/myorg/myapp/pull/7",
+			"user": {"login": "rawlingsj"},
+			"head": {"ref": "feature", "sha": "def456"},
+			"base": {"ref": "master", "sha": "abc123"}
+		},
+		"repository": {"name": "myapp", "owner": {"login": "myorg"}}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindPullRequest, event.Kind)
+	require.NotNil(t, event.PullRequest)
+	assert.Equal(t, 7, event.PullRequest.Number)
+	assert.Equal(t, "rawlingsj", event.PullRequest.Author)
+	assert.Equal(t, "def456", event.PullRequest.HeadSha)
+	assert.Equal(t, "master", event.PullRequest.BaseRef)
+	assert.True(t, event.PullRequest.Complete)
+
+	_, err = parseTestEvent(t, headers, `{"action": "closed", "pull_request": {"number": 7}}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err))
+
+	headers = map[string]string{"X-GitHub-Event": "issue_comment"}
+	event, err = parseTestEvent(t, headers, `{
+		"action": "created",
+		"issue": {"number": 7, "pull_request": {"url": "https://api.github.com/repos/myorg/myapp/pulls/7"}},
+		"comment": {"body": "/retest"},
+		"repository": {"name": "myapp", "owner": {"login": "myorg"}},
+		"sender": {"login": "jstrachan"}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindComment, event.Kind)
+	assert.Equal(t, "/retest", event.Comment)
+	assert.Equal(t, "jstrachan", event.Sender)
+	assert.Equal(t, 7, event.PullRequest.Number)
+	assert.False(t, event.PullRequest.Complete, "comments on GitHub do not include the pull request refs")
+
+	_, err = parseTestEvent(t, headers, `{"action": "created", "issue": {"number": 8}, "comment": {"body": "/test all"}}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err), "comments on issues are ignored")
+
+	_, err = parseTestEvent(t, map[string]string{"X-GitHub-Event": "release"}, `{}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err))
+}
+
+func TestParseGiteaEvents(t *testing.T) {
+	t.Parallel()
+	headers := map[string]string{"X-Gitea-Event": "pull_request", "X-GitHub-Event": "pull_request"}
+	event, err := parseTestEvent(t, headers, `{
+		"action": "opened",
+		"pull_request": {
+			"number": 3,
+			"user": {"username": "someone"},
+			"head": {"ref": "feature", "sha": "def456"},
+			"base": {"ref": "master", "sha": "abc123"}
+		},
+		"repository": {"name": "myapp", "owner": {"username": "myorg"}}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, gits.KindGitea, event.GitKind)
+	assert.Equal(t, "myorg", event.Owner)
+	assert.Equal(t, "someone", event.PullRequest.Author)
+
+	headers = map[string]string{"X-Gitea-Event": "issue_comment"}
+	event, err = parseTestEvent(t, headers, `{
+		"action": "created",
+		"issue": {"number": 3},
+		"is_pull": true,
+		"comment": {"body": "/test unit"},
+		"repository": {"name": "myapp", "owner": {"username": "myorg"}}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindComment, event.Kind)
+	assert.Equal(t, 3, event.PullRequest.Number)
+}
+
+func TestParseGitLabEvents(t *testing.T) {
+	t.Parallel()
+	event, err := parseTestEvent(t, map[string]string{"X-Gitlab-Event": "Push Hook"}, `{
+		"object_kind": "push",
+		"ref": "refs/heads/master",
+		"after": "abc123",
+		"user_username": "someone",
+		"project": {"name": "myapp", "path_with_namespace": "mygroup/sub/myapp", "git_http_url": "https://gitlab.com/mygroup/sub/myapp.git"},
+		"commits": [{"modified": ["README.md"]}]
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, gits.KindGitlab, event.GitKind)
+	assert.Equal(t, webhooks.EventKindPush, event.Kind)
+	assert.Equal(t, "mygroup/sub", event.Owner)
+	assert.Equal(t, "myapp", event.Repo)
+	assert.Equal(t, "someone", event.Sender)
+	assert.Equal(t, []string{"README.md"}, event.ChangedFiles)
+
+	headers := map[string]string{"X-Gitlab-Event": "Merge Request Hook"}
+	event, err = parseTestEvent(t, headers, `{
+		"object_kind": "merge_request",
+		"user": {"username": "someone"},
+		"project": {"name": "myapp", "path_with_namespace": "mygroup/myapp"},
+		"object_attributes": {"iid": 5, "action": "update", "oldrev": "abc", "source_branch": "feature", "target_branch": "master", "last_commit": {"id": "def456"}}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindPullRequest, event.Kind)
+	assert.Equal(t, 5, event.PullRequest.Number)
+	assert.Equal(t, "def456", event.PullRequest.HeadSha)
+	assert.Equal(t, "master", event.PullRequest.BaseRef)
+
+	_, err = parseTestEvent(t, headers, `{"object_kind": "merge_request", "object_attributes": {"iid": 5, "action": "update"}}`)
+	assert.True(t, webhooks.IsIgnoredEvent(err), "updates without new commits are ignored")
+
+	event, err = parseTestEvent(t, map[string]string{"X-Gitlab-Event": "Note Hook"}, `{
+		"object_kind": "note",
+		"user": {"username": "someone"},
+		"project": {"name": "myapp", "path_with_namespace": "mygroup/myapp"},
+		"object_attributes": {"note": "/test all", "noteable_type": "MergeRequest"},
+		"merge_request": {"iid": 5, "source_branch": "feature", "target_branch": "master", "last_commit": {"id": "def456"}}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindComment, event.Kind)
+	assert.Equal(t, "/test all", event.Comment)
+	assert.True(t, event.PullRequest.Complete)
+}
+
+func TestParseBitbucketEvents(t *testing.T) {
+	t.Parallel()
+	event, err := parseTestEvent(t, map[string]string{"X-Event-Key": "repo:refs_changed", "X-Request-Id": "1"}, `{
+		"actor": {"name": "someone"},
+		"repository": {"slug": "myapp", "project": {"key": "PROJ"}, "links": {"clone": [{"href": "ssh://git@bitbucket:7999/proj/myapp.git", "name": "ssh"}, {"href": "https://bitbucket/scm/proj/myapp.git", "name": "http"}]}},
+		"changes": [{"ref": {"id": "refs/heads/master", "type": "BRANCH"}, "toHash": "abc123", "type": "UPDATE"}]
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, gits.KindBitBucketServer, event.GitKind)
+	assert.Equal(t, "PROJ", event.Owner)
+	assert.Equal(t, "https://bitbucket/scm/proj/myapp.git", event.CloneURL)
+	assert.Equal(t, "master", event.Branch)
+	assert.Equal(t, "abc123", event.Sha)
+
+	event, err = parseTestEvent(t, map[string]string{"X-Event-Key": "pr:comment:added"}, `{
+		"actor": {"name": "someone"},
+		"pullRequest": {
+			"id": 2,
+			"author": {"user": {"name": "author"}},
+			"fromRef": {"displayId": "feature", "latestCommit": "def456"},
+			"toRef": {"displayId": "master", "latestCommit": "abc123", "repository": {"slug": "myapp", "project": {"key": "PROJ"}}}
+		},
+		"comment": {"text": "/retest"}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.EventKindComment, event.Kind)
+	assert.Equal(t, "author", event.PullRequest.Author)
+	assert.Equal(t, "abc123", event.PullRequest.BaseSha)
+
+	event, err = parseTestEvent(t, map[string]string{"X-Event-Key": "pullrequest:created", "X-Hook-UUID": "uuid"}, `{
+		"actor": {"nickname": "someone"},
+		"repository": {"full_name": "myorg/myapp", "links": {"html": {"href": "https://bitbucket.org/myorg/myapp"}}},
+		"pullrequest": {
+			"id": 4,
+			"author": {"nickname": "someone"},
+			"source": {"branch": {"name": "feature"}, "commit": {"hash": "def456"}},
+			"destination": {"branch": {"name": "master"}, "commit": {"hash": "abc123"}}
+		}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, gits.KindBitBucketCloud, event.GitKind)
+	assert.Equal(t, webhooks.EventKindPullRequest, event.Kind)
+	assert.Equal(t, "myorg", event.Owner)
+	assert.Equal(t, "myapp", event.Repo)
+	assert.Equal(t, "https://bitbucket.org/myorg/myapp.git", event.CloneURL)
+	assert.Equal(t, 4, event.PullRequest.Number)
+
+	_, err = parseTestEvent(t, nil, `{}`)
+	assert.Error(t, err)
+	assert.False(t, webhooks.IsIgnoredEvent(err), "requests from unknown providers are errors")
+}
@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"strings"
+)
+
+type gitLabProject struct {
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	GitHTTPURL        string `json:"git_http_url"`
+}
+
+// owner returns the group of the project including any subgroups
+func (p *gitLabProject) owner() string {
+	idx := strings.LastIndex(p.PathWithNamespace, "/")
+	if idx < 0 {
+		return ""
+	}
+	return p.PathWithNamespace[:idx]
+}
+
+type gitLabMergeRequest struct {
+	IID          int    `json:"iid"`
+	Action       string `json:"action"`
+	OldRev       string `json:"oldrev"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	URL          string `json:"url"`
+	LastCommit   struct {
+		ID string `json:"id"`
+	} `json:"last_commit"`
+}
+
+type gitLabEvent struct {
+	ObjectKind   string         `json:"object_kind"`
+	Ref          string         `json:"ref"`
+	After        string         `json:"after"`
+	UserUsername string         `json:"user_username"`
+	Commits      []gitHubCommit `json:"commits"`
+	Project      gitLabProject  `json:"project"`
+	User         struct {
+		UserName string `json:"username"`
+	} `json:"user"`
+	ObjectAttributes struct {
+		gitLabMergeRequest
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+	} `json:"object_attributes"`
+	MergeRequest *gitLabMergeRequest `json:"merge_request"`
+}
+
+// parseGitLabEvent parses the push, merge request and note events of GitLab
+func parseGitLabEvent(eventType string, body []byte) (*Event, error) {
+	payload := &gitLabEvent{}
+	err := unmarshalEvent(body, payload, eventType)
+	if err != nil {
+		return nil, err
+	}
+	event := &Event{
+		Owner:    payload.Project.owner(),
+		Repo:     payload.Project.Name,
+		CloneURL: payload.Project.GitHTTPURL,
+		Sender:   payload.User.UserName,
+	}
+	switch payload.ObjectKind {
+	case "push":
+		event.Kind = EventKindPush
+		event.Sender = payload.UserUsername
+		event.Branch = branchOfRef(payload.Ref)
+		event.Sha = payload.After
+		if event.Branch == "" {
+			return nil, ignored("push to %s is not a branch", payload.Ref)
+		}
+		if payload.After == nullSha {
+			return nil, ignored("branch %s was deleted", event.Branch)
+		}
+		for _, c := range payload.Commits {
+			event.ChangedFiles = append(event.ChangedFiles, c.Added...)
+			event.ChangedFiles = append(event.ChangedFiles, c.Removed...)
+			event.ChangedFiles = append(event.ChangedFiles, c.Modified...)
+		}
+	case "merge_request":
+		mr := &payload.ObjectAttributes.gitLabMergeRequest
+		switch mr.Action {
+		case "open", "reopen":
+		case "update":
+			if mr.OldRev == "" {
+				return nil, ignored("merge request %d was updated without new commits", mr.IID)
+			}
+		default:
+			return nil, ignored("merge request action %s", mr.Action)
+		}
+		event.Kind = EventKindPullRequest
+		event.PullRequest = gitLabEventPullRequest(mr, event.Sender)
+	case "note":
+		if payload.ObjectAttributes.NoteableType != "MergeRequest" || payload.MergeRequest == nil {
+			return nil, ignored("note on a %s which is not a merge request", payload.ObjectAttributes.NoteableType)
+		}
+		event.Kind = EventKindComment
+		event.Comment = payload.ObjectAttributes.Note
+		event.PullRequest = gitLabEventPullRequest(payload.MergeRequest, "")
+	default:
+		return nil, ignored("event type %s", eventType)
+	}
+	return event, nil
+}
+
+func gitLabEventPullRequest(mr *gitLabMergeRequest, author string) *EventPullRequest {
+	return &EventPullRequest{
+		Number:   mr.IID,
+		Author:   author,
+		HeadRef:  mr.SourceBranch,
+		HeadSha:  mr.LastCommit.ID,
+		BaseRef:  mr.TargetBranch,
+		Link:     mr.URL,
+		Complete: mr.LastCommit.ID != "" && mr.TargetBranch != "",
+	}
+}
@@ -0,0 +1,326 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of a webhook request body in bytes
+	DefaultMaxBodySize = 1024 * 1024
+
+	// DefaultDeliveryTTL is how long the response to a delivery is remembered by default
+	DefaultDeliveryTTL = time.Hour
+
+	// DefaultMaxDeliveries is how many deliveries are remembered by default
+	DefaultMaxDeliveries = 10000
+
+	// DuplicateDeliveryHeader is set on the response replayed for a duplicate delivery
+	DuplicateDeliveryHeader = "X-Duplicate-Delivery"
+)
+
+// deliveryHeaders are the headers which git providers and other callers use to identify a delivery
+var deliveryHeaders = []string{
+	"X-Delivery-ID",
+	"X-GitHub-Delivery",
+	"X-Gitea-Delivery",
+	"X-Gogs-Delivery",
+	"X-Gitlab-Event-UUID",
+	"X-Request-UUID",
+}
+
+// GuardOptions configures how a Guard authenticates, limits and deduplicates webhook requests
+type GuardOptions struct {
+	// HMACSecret if specified requests can authenticate with a X-Hub-Signature-256, X-Hub-Signature,
+	// X-Gitea-Signature or X-Gogs-Signature of the body
+	HMACSecret []byte
+	// Token if specified requests can authenticate with an 'Authorization: Bearer' or X-Gitlab-Token header
+	Token string
+	// MaxBodySize is the maximum size of a request body in bytes
+	MaxBodySize int64
+	// DeliveryTTL is how long the response to a delivery is replayed to duplicates of the delivery
+	DeliveryTTL time.Duration
+	// MaxDeliveries is the maximum number of deliveries which are remembered
+	MaxDeliveries int
+}
+
+// delivery is a delivery which is being handled or has been handled
+type delivery struct {
+	received time.Time
+	done     bool
+	status   int
+	header   http.Header
+	body     []byte
+}
+
+// Guard is a http.Handler which authenticates webhook requests and limits their size before passing them to the next
+// handler. The successful response to a delivery is remembered so that a retried delivery gets the same response
+// without being handled again. The deliveries are remembered in memory, so they are only deduplicated by the replica
+// which received them. If the handler runs with several replicas behind a load balancer a retried delivery can reach
+// another replica, so the next handler must tolerate a delivery being handled more than once.
+type Guard struct {
+	options GuardOptions
+	next    http.Handler
+
+	lock       sync.Mutex
+	deliveries map[string]*delivery
+	now        func() time.Time
+}
+
+// NewGuard creates a Guard for the next handler
+func NewGuard(next http.Handler, options GuardOptions) *Guard {
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	if options.DeliveryTTL <= 0 {
+		options.DeliveryTTL = DefaultDeliveryTTL
+	}
+	if options.MaxDeliveries <= 0 {
+		options.MaxDeliveries = DefaultMaxDeliveries
+	}
+	return &Guard{
+		options:    options,
+		next:       next,
+		deliveries: map[string]*delivery{},
+		now:        time.Now,
+	}
+}
+
+// IsAuthenticated returns true if the guard requires requests to authenticate
+func (g *Guard) IsAuthenticated() bool {
+	return len(g.options.HMACSecret) > 0 || g.options.Token != ""
+}
+
+// ServeHTTP implements http.Handler
+func (g *Guard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+	id := DeliveryID(r)
+	if r.ContentLength > g.options.MaxBodySize {
+		g.reject(w, r, id, http.StatusRequestEntityTooLarge, "request body is larger than "+strconv.FormatInt(g.options.MaxBodySize, 10)+" bytes")
+		return
+	}
+	// read one byte more than the limit to find out if the body is too large without reading all of it
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, g.options.MaxBodySize+1))
+	if err != nil {
+		g.reject(w, r, id, http.StatusBadRequest, "failed to read the request body: "+err.Error())
+		return
+	}
+	if int64(len(body)) > g.options.MaxBodySize {
+		g.reject(w, r, id, http.StatusRequestEntityTooLarge, "request body is larger than "+strconv.FormatInt(g.options.MaxBodySize, 10)+" bytes")
+		return
+	}
+	status, reason := g.authenticate(r, body)
+	if status != http.StatusOK {
+		g.reject(w, r, id, status, reason)
+		return
+	}
+
+	if id != "" {
+		previous, inProgress := g.startDelivery(id)
+		if inProgress {
+			g.reject(w, r, id, http.StatusConflict, "delivery is already being handled")
+			return
+		}
+		if previous != nil {
+			log.Infof("Replaying the response with status %d to the duplicate webhook delivery %s from %s\n", previous.status, id, r.RemoteAddr)
+			for k, v := range previous.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set(DuplicateDeliveryHeader, "true")
+			w.WriteHeader(previous.status)
+			w.Write(previous.body)
+			return
+		}
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	g.next.ServeHTTP(recorder, r)
+	if id != "" {
+		g.completeDelivery(id, recorder)
+	}
+}
+
+// authenticate checks the credentials of the request returning http.StatusOK if it is allowed
+func (g *Guard) authenticate(r *http.Request, body []byte) (int, string) {
+	if !g.IsAuthenticated() {
+		return http.StatusOK, ""
+	}
+	token := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	} else if gitLabToken := r.Header.Get("X-Gitlab-Token"); gitLabToken != "" {
+		token = gitLabToken
+	}
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if signature == "" {
+		signature = r.Header.Get("X-Hub-Signature")
+	}
+	if signature == "" {
+		// Gitea and Gogs sign the body with a hex SHA256 HMAC without a prefix
+		for _, header := range []string{"X-Gitea-Signature", "X-Gogs-Signature"} {
+			if hexSignature := r.Header.Get(header); hexSignature != "" {
+				signature = "sha256=" + hexSignature
+				break
+			}
+		}
+	}
+	if token == "" && signature == "" {
+		if GitKindOfRequest(r) == gits.KindBitBucketCloud {
+			return http.StatusUnauthorized, "Bitbucket Cloud cannot sign webhooks or send a token so they cannot be authenticated. Send them through a proxy which adds a bearer token or run without an auth Secret"
+		}
+		return http.StatusUnauthorized, "missing token or signature"
+	}
+	if token != "" && g.options.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(g.options.Token)) == 1 {
+		return http.StatusOK, ""
+	}
+	if signature != "" && len(g.options.HMACSecret) > 0 && ValidSignature(body, signature, g.options.HMACSecret) {
+		return http.StatusOK, ""
+	}
+	return http.StatusForbidden, "invalid token or signature"
+}
+
+// startDelivery returns the previous delivery with the id if it has completed or records the delivery as being
+// handled. It returns true if the delivery is still being handled.
+func (g *Guard) startDelivery(id string) (*delivery, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.now()
+	d := g.deliveries[id]
+	if d != nil && now.Sub(d.received) < g.options.DeliveryTTL {
+		if !d.done {
+			return nil, true
+		}
+		return d, false
+	}
+	g.evict(now)
+	g.deliveries[id] = &delivery{received: now}
+	return nil, false
+}
+
+// completeDelivery remembers the response to a successful delivery. Failed deliveries are forgotten so that they
+// can be retried.
+func (g *Guard) completeDelivery(id string, recorder *responseRecorder) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if recorder.status >= http.StatusMultipleChoices {
+		delete(g.deliveries, id)
+		return
+	}
+	d := g.deliveries[id]
+	if d == nil {
+		return
+	}
+	d.done = true
+	d.status = recorder.status
+	d.header = http.Header{}
+	for k, v := range recorder.Header() {
+		d.header[k] = v
+	}
+	d.body = recorder.body.Bytes()
+}
+
+// evict removes the expired deliveries and the oldest deliveries when there are too many
+func (g *Guard) evict(now time.Time) {
+	for id, d := range g.deliveries {
+		if now.Sub(d.received) >= g.options.DeliveryTTL {
+			delete(g.deliveries, id)
+		}
+	}
+	for len(g.deliveries) >= g.options.MaxDeliveries {
+		oldest := ""
+		for id, d := range g.deliveries {
+			if oldest == "" || d.received.Before(g.deliveries[oldest].received) {
+				oldest = id
+			}
+		}
+		delete(g.deliveries, oldest)
+	}
+}
+
+// reject responds with the status and logs the rejected request
+func (g *Guard) reject(w http.ResponseWriter, r *http.Request, id string, status int, reason string) {
+	log.Warnf("Rejected the webhook request to %s from %s with delivery ID %q with status %d: %s\n", r.URL.Path, r.RemoteAddr, id, status, reason)
+	http.Error(w, strconv.Itoa(status)+" "+http.StatusText(status)+": "+reason, status)
+}
+
+// DeliveryID returns the ID of the webhook delivery from the headers of the request or an empty string if it has none
+func DeliveryID(r *http.Request) string {
+	for _, header := range deliveryHeaders {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// ValidSignature returns true if the signature is a 'sha256=' or 'sha1=' HMAC of the payload with the key
+func ValidSignature(payload []byte, signature string, key []byte) bool {
+	var hashFn func() hash.Hash
+	switch {
+	case strings.HasPrefix(signature, "sha256="):
+		hashFn = sha256.New
+	case strings.HasPrefix(signature, "sha1="):
+		hashFn = sha1.New
+	default:
+		return false
+	}
+	actual, err := hex.DecodeString(signature[strings.Index(signature, "=")+1:])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(hashFn, key)
+	mac.Write(payload)
+	return hmac.Equal(actual, mac.Sum(nil))
+}
+
+// Signature returns the 'sha256=' HMAC of the payload with the key
+func Signature(payload []byte, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// responseRecorder writes the response through to the client while recording it
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,184 @@
+package webhooks_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"prowJobSpec":{"refs":{"org":"myorg","repo":"myapp"}}}`
+
+// countingHandler counts the requests it handles and responds with the request body
+type countingHandler struct {
+	count  int32
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&h.count, 1)
+	data, _ := ioutil.ReadAll(r.Body)
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	w.Write(data)
+}
+
+func post(t *testing.T, server *httptest.Server, body string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestGuardAuthentication(t *testing.T) {
+	t.Parallel()
+	secret := []byte("s3cr3t")
+	handler := &countingHandler{}
+	server := httptest.NewServer(webhooks.NewGuard(handler, webhooks.GuardOptions{
+		HMACSecret: secret,
+		Token:      "mytoken",
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"no credentials", nil, http.StatusUnauthorized},
+		{"sha256 signature", map[string]string{"X-Hub-Signature-256": webhooks.Signature([]byte(testPayload), secret)}, http.StatusOK},
+		{"sha1 signature", map[string]string{"X-Hub-Signature": "sha1=fdda374f91ca6ef9c363943f26c4e1df66c62aa0"}, http.StatusOK},
+		{"wrong key", map[string]string{"X-Hub-Signature-256": webhooks.Signature([]byte(testPayload), []byte("other"))}, http.StatusForbidden},
+		{"gitea signature", map[string]string{"X-Gitea-Signature": strings.TrimPrefix(webhooks.Signature([]byte(testPayload), secret), "sha256=")}, http.StatusOK},
+		{"wrong gitea signature", map[string]string{"X-Gitea-Signature": strings.TrimPrefix(webhooks.Signature([]byte(testPayload), []byte("other")), "sha256=")}, http.StatusForbidden},
+		{"bearer token", map[string]string{"Authorization": "Bearer mytoken"}, http.StatusOK},
+		{"gitlab token", map[string]string{"X-Gitlab-Token": "mytoken"}, http.StatusOK},
+		{"wrong token", map[string]string{"Authorization": "Bearer nope"}, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		resp, _ := post(t, server, testPayload, tc.headers)
+		assert.Equal(t, tc.expected, resp.StatusCode, tc.name)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&handler.count))
+
+	resp, body := post(t, server, testPayload, map[string]string{"X-Event-Key": "repo:push", "X-Hook-UUID": "1234"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, body, "Bitbucket Cloud cannot sign webhooks")
+
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "GET requests are passed through")
+}
+
+func TestGuardBodySize(t *testing.T) {
+	t.Parallel()
+	handler := &countingHandler{}
+	server := httptest.NewServer(webhooks.NewGuard(handler, webhooks.GuardOptions{MaxBodySize: 16}))
+	defer server.Close()
+
+	resp, _ := post(t, server, testPayload, nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	resp, body := post(t, server, `{"small":true}`, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"small":true}`, body)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handler.count))
+}
+
+// failingReader returns an error instead of the rest of the body
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("connection reset by peer")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestGuardBodyReadErrors(t *testing.T) {
+	t.Parallel()
+	handler := &countingHandler{}
+	guard := webhooks.NewGuard(handler, webhooks.GuardOptions{MaxBodySize: 16})
+
+	// a body without a content length is only found to be too large when it is read
+	req := httptest.NewRequest(http.MethodPost, "/hook", ioutil.NopCloser(strings.NewReader(testPayload)))
+	req.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	guard.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/hook", &failingReader{data: "{"})
+	req.ContentLength = -1
+	recorder = httptest.NewRecorder()
+	guard.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code, "a failure to read the body is not reported as a body which is too large")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&handler.count))
+}
+
+func TestGuardDeduplicatesDeliveries(t *testing.T) {
+	t.Parallel()
+	handler := &countingHandler{}
+	server := httptest.NewServer(webhooks.NewGuard(handler, webhooks.GuardOptions{}))
+	defer server.Close()
+
+	headers := map[string]string{"X-GitHub-Delivery": "72d3162e-cc78-11e3-81ab-4c9367dc0958"}
+	resp, body := post(t, server, testPayload, headers)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(webhooks.DuplicateDeliveryHeader))
+
+	resp, replayed := post(t, server, testPayload, headers)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(webhooks.DuplicateDeliveryHeader))
+	assert.Equal(t, body, replayed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handler.count), "a duplicate delivery should not be handled again")
+
+	post(t, server, testPayload, map[string]string{"X-Delivery-ID": "another"})
+	post(t, server, testPayload, nil)
+	post(t, server, testPayload, nil)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&handler.count), "requests without a delivery ID are always handled")
+}
+
+func TestGuardRetriesFailedDeliveries(t *testing.T) {
+	t.Parallel()
+	handler := &countingHandler{status: http.StatusBadRequest}
+	server := httptest.NewServer(webhooks.NewGuard(handler, webhooks.GuardOptions{}))
+	defer server.Close()
+
+	headers := map[string]string{"X-Delivery-ID": "1234"}
+	resp, _ := post(t, server, testPayload, headers)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = post(t, server, testPayload, headers)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(webhooks.DuplicateDeliveryHeader))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&handler.count))
+}
+
+func TestValidSignature(t *testing.T) {
+	t.Parallel()
+	key := []byte("key")
+	payload := []byte("payload")
+	assert.True(t, webhooks.ValidSignature(payload, webhooks.Signature(payload, key), key))
+	assert.True(t, webhooks.ValidSignature(payload, "sha1=2f3902cd1626fa7fdfb67e93109f50412ad71531", key))
+	assert.False(t, webhooks.ValidSignature(payload, "sha1=2f3902cd1626fa7fdfb67e93109f50412ad71532", key))
+	assert.False(t, webhooks.ValidSignature(payload, "md5=abcd", key))
+	assert.False(t, webhooks.ValidSignature(payload, "sha256=zz", key))
+}